@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/util/errors"
@@ -33,15 +34,31 @@ type Assets []Asset
 // New walks through a directory recursively and renders each file as asset. Only those files
 // are rendered that make all predicates true.
 func New(dir string, data interface{}, manifestPredicates []FileContentsPredicate, predicates ...FileInfoPredicate) (Assets, error) {
+	return NewWithRenderOptions(dir, data, nil, manifestPredicates, predicates...)
+}
+
+// NewWithRenderOptions is like New, rendering the files with the render options, e.g. StrictRendering. The assets
+// are sorted by name.
+func NewWithRenderOptions(dir string, data interface{}, renderOptions []RenderOption, manifestPredicates []FileContentsPredicate, predicates ...FileInfoPredicate) (Assets, error) {
 	files, err := LoadFilesRecursively(dir, predicates...)
 	if err != nil {
 		return nil, err
 	}
+	return renderAssets(files, data, renderOptions, manifestPredicates)
+}
+
+// renderAssets renders the files as assets, sorted by name, skipping those not matching all manifestPredicates.
+func renderAssets(files map[string][]byte, data interface{}, renderOptions []RenderOption, manifestPredicates []FileContentsPredicate) (Assets, error) {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
 
 	var as Assets
 	var errs []error
-	for path, bs := range files {
-		a, err := assetFromTemplate(path, bs, data)
+	for _, path := range paths {
+		a, err := assetFromTemplate(path, files[path], data, renderOptions...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to render %q: %v", path, err)
 		}
@@ -110,8 +127,17 @@ func MustCreateAssetFromTemplate(name string, template []byte, config interface{
 	return *asset
 }
 
-func assetFromTemplate(name string, tb []byte, data interface{}) (*Asset, error) {
-	bs, err := renderFile(name, tb, data)
+// CreateAssetFromTemplate processes the given template with the render options and returns an asset.
+func CreateAssetFromTemplate(name string, template []byte, config interface{}, options ...RenderOption) (Asset, error) {
+	asset, err := assetFromTemplate(name, template, config, options...)
+	if err != nil {
+		return Asset{}, err
+	}
+	return *asset, nil
+}
+
+func assetFromTemplate(name string, tb []byte, data interface{}, options ...RenderOption) (*Asset, error) {
+	bs, err := renderFile(name, tb, data, options...)
 	if err != nil {
 		return nil, err
 	}
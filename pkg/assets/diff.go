@@ -0,0 +1,159 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"sigs.k8s.io/yaml"
+)
+
+// AssetChangeType is the type of change of an asset between two releases.
+type AssetChangeType string
+
+const (
+	AssetAdded    AssetChangeType = "Added"
+	AssetRemoved  AssetChangeType = "Removed"
+	AssetModified AssetChangeType = "Modified"
+)
+
+// AssetChange is the change of a single asset between two releases.
+type AssetChange struct {
+	Name string
+	Type AssetChangeType
+	// Resource identifies the Kubernetes object of manifests, as "Kind namespace/name". It is empty for other assets.
+	Resource string
+	// PermissionChanged is true when the file permission of a modified asset changed.
+	PermissionChanged bool
+	// Diff is the unified diff of the data of a modified asset. It is empty when only the permission changed.
+	Diff string
+}
+
+// DiffAssets compares the rendered assets of two releases, and returns the changes sorted by asset name. YAML and JSON
+// assets whose content is semantically equal, e.g. only differing by formatting or field order, are not modified.
+func DiffAssets(from, to Assets) []AssetChange {
+	fromAssets := make(map[string]Asset, len(from))
+	for _, asset := range from {
+		fromAssets[asset.Name] = asset
+	}
+	toAssets := make(map[string]Asset, len(to))
+	for _, asset := range to {
+		toAssets[asset.Name] = asset
+	}
+
+	var changes []AssetChange
+	for name, fromAsset := range fromAssets {
+		toAsset, ok := toAssets[name]
+		if !ok {
+			changes = append(changes, AssetChange{Name: name, Type: AssetRemoved, Resource: resourceOf(fromAsset.Data)})
+			continue
+		}
+		permissionChanged := effectivePermission(fromAsset) != effectivePermission(toAsset)
+		dataChanged := !semanticallyEqual(fromAsset.Data, toAsset.Data)
+		if !permissionChanged && !dataChanged {
+			continue
+		}
+		change := AssetChange{Name: name, Type: AssetModified, Resource: resourceOf(toAsset.Data), PermissionChanged: permissionChanged}
+		if dataChanged {
+			change.Diff, _ = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+				A:        difflib.SplitLines(string(fromAsset.Data)),
+				B:        difflib.SplitLines(string(toAsset.Data)),
+				FromFile: "a/" + name,
+				ToFile:   "b/" + name,
+				Context:  3,
+			})
+		}
+		changes = append(changes, change)
+	}
+	for name, toAsset := range toAssets {
+		if _, ok := fromAssets[name]; !ok {
+			changes = append(changes, AssetChange{Name: name, Type: AssetAdded, Resource: resourceOf(toAsset.Data)})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+// AssetChangeReport returns a human readable report of the changes of the assets between two releases, listing the
+// added and removed assets, and the diff of the modified ones.
+func AssetChangeReport(fromRelease, toRelease string, changes []AssetChange) string {
+	counts := map[AssetChangeType]int{}
+	for _, change := range changes {
+		counts[change.Type]++
+	}
+
+	var report strings.Builder
+	fmt.Fprintf(&report, "Asset changes from %s to %s: %d added, %d removed, %d modified\n",
+		fromRelease, toRelease, counts[AssetAdded], counts[AssetRemoved], counts[AssetModified])
+	for _, change := range changes {
+		fmt.Fprintf(&report, "\n%s %s", change.Type, change.Name)
+		if len(change.Resource) > 0 {
+			fmt.Fprintf(&report, " (%s)", change.Resource)
+		}
+		if change.PermissionChanged {
+			report.WriteString(", file permission changed")
+		}
+		report.WriteString("\n")
+		report.WriteString(change.Diff)
+	}
+	return report.String()
+}
+
+func effectivePermission(asset Asset) Permission {
+	if asset.FilePermission == 0 {
+		return PermissionFileDefault
+	}
+	return asset.FilePermission
+}
+
+func semanticallyEqual(a, b []byte) bool {
+	if string(a) == string(b) {
+		return true
+	}
+	aObj, aErr := parseYAML(a)
+	bObj, bErr := parseYAML(b)
+	if aErr != nil || bErr != nil {
+		return false
+	}
+	return reflect.DeepEqual(aObj, bObj)
+}
+
+func parseYAML(data []byte) (interface{}, error) {
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var obj interface{}
+	if err := json.Unmarshal(jsonData, &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// resourceOf returns the "Kind namespace/name" of the manifest, or an empty string when data is not a manifest.
+func resourceOf(data []byte) string {
+	obj, err := parseYAML(data)
+	if err != nil {
+		return ""
+	}
+	manifest, ok := obj.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	kind, _ := manifest["kind"].(string)
+	metadata, _ := manifest["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	if len(kind) == 0 || len(name) == 0 {
+		return ""
+	}
+	if namespace, _ := metadata["namespace"].(string); len(namespace) > 0 {
+		name = namespace + "/" + name
+	}
+	return kind + " " + name
+}
@@ -0,0 +1,62 @@
+package assets
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffAssets(t *testing.T) {
+	from := Assets{
+		{Name: "deployment.yaml", Data: []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: operator\n  namespace: openshift-operator\nspec:\n  replicas: 1\n")},
+		{Name: "formatting.yaml", Data: []byte("kind: ConfigMap\nmetadata: {name: config}\ndata:\n  a: b\n")},
+		{Name: "removed.yaml", Data: []byte("kind: Secret\nmetadata:\n  name: removed\n")},
+		{Name: "script.sh", Data: []byte("#!/bin/sh\necho hello\n")},
+		{Name: "key", Data: []byte("key"), FilePermission: PermissionFileRestricted},
+		{Name: "default-permission", Data: []byte("unchanged")},
+	}
+	to := Assets{
+		{Name: "added.json", Data: []byte(`{"kind": "ServiceAccount", "metadata": {"name": "added", "namespace": "openshift-operator"}}`)},
+		{Name: "default-permission", Data: []byte("unchanged"), FilePermission: PermissionFileDefault},
+		{Name: "deployment.yaml", Data: []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: operator\n  namespace: openshift-operator\nspec:\n  replicas: 2\n")},
+		{Name: "formatting.yaml", Data: []byte("data: {a: b}\nkind: ConfigMap\nmetadata:\n  name: config\n")},
+		{Name: "key", Data: []byte("key"), FilePermission: PermissionFileDefault},
+		{Name: "script.sh", Data: []byte("#!/bin/sh\necho hello world\n")},
+	}
+
+	changes := DiffAssets(from, to)
+	var summary []AssetChange
+	for _, change := range changes {
+		summary = append(summary, AssetChange{Name: change.Name, Type: change.Type, Resource: change.Resource, PermissionChanged: change.PermissionChanged})
+	}
+	expected := []AssetChange{
+		{Name: "added.json", Type: AssetAdded, Resource: "ServiceAccount openshift-operator/added"},
+		{Name: "deployment.yaml", Type: AssetModified, Resource: "Deployment openshift-operator/operator"},
+		{Name: "key", Type: AssetModified, PermissionChanged: true},
+		{Name: "removed.yaml", Type: AssetRemoved, Resource: "Secret removed"},
+		{Name: "script.sh", Type: AssetModified},
+	}
+	if !reflect.DeepEqual(expected, summary) {
+		t.Fatalf("expected changes %#v, got %#v", expected, summary)
+	}
+
+	if diff := changes[1].Diff; !strings.Contains(diff, "--- a/deployment.yaml\n+++ b/deployment.yaml\n") || !strings.Contains(diff, "-  replicas: 1\n+  replicas: 2\n") {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+	if len(changes[2].Diff) > 0 {
+		t.Errorf("unexpected diff of a permission change:\n%s", changes[2].Diff)
+	}
+
+	report := AssetChangeReport("4.13.0", "4.14.0", changes)
+	for _, expected := range []string{
+		"Asset changes from 4.13.0 to 4.14.0: 1 added, 1 removed, 3 modified\n",
+		"\nAdded added.json (ServiceAccount openshift-operator/added)\n",
+		"\nModified key, file permission changed\n",
+		"\nRemoved removed.yaml (Secret removed)\n",
+		"-echo hello\n+echo hello world\n",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("report does not contain %q:\n%s", expected, report)
+		}
+	}
+}
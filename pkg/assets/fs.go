@@ -0,0 +1,87 @@
+package assets
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// NewFromFS walks through a directory of the file system recursively, e.g. of an embed.FS, and renders each file as
+// asset. Use "." as dir for the root of the file system. Only those files are rendered that make all predicates true.
+// The assets are sorted by name, which is relative to dir.
+func NewFromFS(fsys fs.FS, dir string, data interface{}, renderOptions []RenderOption, manifestPredicates []FileContentsPredicate, predicates ...FileInfoPredicate) (Assets, error) {
+	files, err := LoadFilesRecursivelyFS(fsys, dir, predicates...)
+	if err != nil {
+		return nil, err
+	}
+	return renderAssets(files, data, renderOptions, manifestPredicates)
+}
+
+// LoadFilesRecursivelyFS returns a map from path names relative to dir to file content, for the files of the file
+// system making all predicates true. The predicates are called with the path of the file in the file system.
+func LoadFilesRecursivelyFS(fsys fs.FS, dir string, predicates ...FileInfoPredicate) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	err := fs.WalkDir(fsys, dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		for _, p := range predicates {
+			include, err := p(filePath, info)
+			if err != nil {
+				return err
+			}
+			if !include {
+				return nil
+			}
+		}
+
+		bs, err := fs.ReadFile(fsys, filePath)
+		if err != nil {
+			return err
+		}
+		files[relativePath(dir, filePath)] = bs
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// relativePath returns the path of the file relative to dir, both being valid fs.FS paths.
+func relativePath(dir, filePath string) string {
+	dir = path.Clean(dir)
+	if dir == "." {
+		return filePath
+	}
+	if filePath == dir {
+		return path.Base(filePath)
+	}
+	return filePath[len(dir)+1:]
+}
+
+// AssetFuncFromFS returns a function reading the named files of the file system, a replacement of the Asset function
+// of generated bindata that can be used as resourceapply.AssetFunc.
+func AssetFuncFromFS(fsys fs.FS) func(name string) ([]byte, error) {
+	return func(name string) ([]byte, error) {
+		return fs.ReadFile(fsys, name)
+	}
+}
+
+// MustAssetFromFS returns the content of the named file of the file system, a replacement of the MustAsset function
+// of generated bindata. It panics when the file cannot be read.
+func MustAssetFromFS(fsys fs.FS, name string) []byte {
+	bs, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		panic(fmt.Sprintf("asset %s: %v", name, err))
+	}
+	return bs
+}
@@ -0,0 +1,79 @@
+package assets
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNewFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"manifests/b.yaml":       {Data: []byte("name: {{ .name }}")},
+		"manifests/a.yml":        {Data: []byte("namespace: {{ .name }}")},
+		"manifests/sub/c.yaml":   {Data: []byte("kind: {{ .name }}")},
+		"manifests/README.md":    {Data: []byte("{{ .invalid")},
+		"other/d.yaml":           {Data: []byte("other")},
+		"manifests-other/e.yaml": {Data: []byte("other")},
+	}
+
+	tests := []struct {
+		name     string
+		dir      string
+		expected []Asset
+	}{
+		{
+			name: "directory",
+			dir:  "manifests",
+			expected: []Asset{
+				{Name: "a.yml", Data: []byte("namespace: test")},
+				{Name: "b.yaml", Data: []byte("name: test")},
+				{Name: "sub/c.yaml", Data: []byte("kind: test")},
+			},
+		},
+		{
+			name: "root",
+			dir:  ".",
+			expected: []Asset{
+				{Name: "manifests-other/e.yaml", Data: []byte("other")},
+				{Name: "manifests/a.yml", Data: []byte("namespace: test")},
+				{Name: "manifests/b.yaml", Data: []byte("name: test")},
+				{Name: "manifests/sub/c.yaml", Data: []byte("kind: test")},
+				{Name: "other/d.yaml", Data: []byte("other")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assets, err := NewFromFS(fsys, tt.dir, map[string]string{"name": "test"}, []RenderOption{StrictRendering()}, nil, OnlyYaml)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(Assets(tt.expected), assets) {
+				t.Errorf("expected %v, got %v", tt.expected, assets)
+			}
+		})
+	}
+
+	if _, err := NewFromFS(fsys, "missing", nil, nil, nil); err == nil {
+		t.Error("expected error for a missing directory")
+	}
+}
+
+func TestAssetFuncFromFS(t *testing.T) {
+	fsys := fstest.MapFS{"manifests/a.yaml": {Data: []byte("a")}}
+
+	data, err := AssetFuncFromFS(fsys)("manifests/a.yaml")
+	if err != nil || string(data) != "a" {
+		t.Errorf("unexpected asset %q: %v", data, err)
+	}
+	if _, err := AssetFuncFromFS(fsys)("manifests/b.yaml"); err == nil {
+		t.Error("expected error for a missing asset")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for a missing asset")
+		}
+	}()
+	MustAssetFromFS(fsys, "manifests/b.yaml")
+}
@@ -2,7 +2,10 @@ package assets
 
 import (
 	"bytes"
+	"crypto/x509"
 	"encoding/base64"
+	"fmt"
+	"reflect"
 	"strings"
 	"text/template"
 	"time"
@@ -65,8 +68,115 @@ func load(n string, assets map[string][]byte) []byte {
 	return assets[n]
 }
 
-func renderFile(name string, tb []byte, data interface{}) ([]byte, error) {
-	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(string(tb))
+// RenderOption configures the rendering of asset templates.
+type RenderOption func(*renderOptions)
+
+type renderOptions struct {
+	strict bool
+	funcs  template.FuncMap
+}
+
+// StrictRendering makes the rendering of a template fail when it references a missing map key, instead of rendering
+// "<no value>". The functions of the templates return errors instead of panicking, and base64 and indent accept
+// strings as well as byte slices. Use the default function to render optional values, e.g.
+// {{ index . "replicas" | default 3 }}.
+func StrictRendering() RenderOption {
+	return func(o *renderOptions) {
+		o.strict = true
+	}
+}
+
+// WithTemplateFuncs adds functions to the templates, overriding the built-in functions of the same name.
+func WithTemplateFuncs(funcs template.FuncMap) RenderOption {
+	return func(o *renderOptions) {
+		for name, fn := range funcs {
+			o.funcs[name] = fn
+		}
+	}
+}
+
+var strictTemplateFuncs = map[string]interface{}{
+	"notAfter":  strictCertFunc(func(c *x509.Certificate) string { return c.NotAfter.Format(time.RFC3339) }),
+	"notBefore": strictCertFunc(func(c *x509.Certificate) string { return c.NotBefore.Format(time.RFC3339) }),
+	"issuer":    strictCertFunc(func(c *x509.Certificate) string { return c.Issuer.CommonName }),
+	"base64":    strictBase64encode,
+	"indent":    strictIndent,
+	"default":   defaultValue,
+	"load":      load,
+}
+
+func toBytes(v interface{}) ([]byte, error) {
+	switch t := v.(type) {
+	case []byte:
+		return t, nil
+	case string:
+		return []byte(t), nil
+	default:
+		return nil, fmt.Errorf("expected a string or []byte, got %T", v)
+	}
+}
+
+func strictIndent(indention int, v interface{}) (string, error) {
+	bs, err := toBytes(v)
+	if err != nil {
+		return "", err
+	}
+	return indent(indention, bs), nil
+}
+
+func strictBase64encode(v interface{}) (string, error) {
+	bs, err := toBytes(v)
+	if err != nil {
+		return "", err
+	}
+	return base64encode(bs), nil
+}
+
+func strictCertFunc(fn func(*x509.Certificate) string) func(interface{}) (string, error) {
+	return func(v interface{}) (string, error) {
+		certBytes, err := toBytes(v)
+		if err != nil || len(certBytes) == 0 {
+			return "", err
+		}
+		certs, err := cert.ParseCertsPEM(certBytes)
+		if err != nil {
+			return "", err
+		}
+		return fn(certs[0]), nil
+	}
+}
+
+// defaultValue returns v, or defaultV when v is nil, or empty, or the zero value of its type.
+func defaultValue(defaultV, v interface{}) interface{} {
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Invalid:
+		return defaultV
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		if value.Len() == 0 {
+			return defaultV
+		}
+	default:
+		if value.IsZero() {
+			return defaultV
+		}
+	}
+	return v
+}
+
+func renderFile(name string, tb []byte, data interface{}, options ...RenderOption) ([]byte, error) {
+	o := &renderOptions{funcs: template.FuncMap{}}
+	for _, option := range options {
+		option(o)
+	}
+
+	tmpl := template.New(name)
+	if o.strict {
+		tmpl = tmpl.Option("missingkey=error").Funcs(strictTemplateFuncs)
+	} else {
+		tmpl = tmpl.Funcs(templateFuncs)
+	}
+	tmpl, err := tmpl.Funcs(o.funcs).Parse(string(tb))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,141 @@
+package assets
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestStrictRendering(t *testing.T) {
+	type config struct {
+		Name  string
+		Cert  []byte
+		Extra map[string]interface{}
+	}
+	data := config{
+		Name:  "operator",
+		Cert:  []byte("line1\nline2"),
+		Extra: map[string]interface{}{"replicas": 0, "image": "quay.io/operator", "b": 2, "a": 1},
+	}
+
+	tests := []struct {
+		name          string
+		template      string
+		options       []RenderOption
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "missing key is rendered without strict mode",
+			template: "image: {{ .Extra.imag }}",
+			expected: "image: <no value>",
+		},
+		{
+			name:          "missing key",
+			template:      "image: {{ .Extra.imag }}",
+			options:       []RenderOption{StrictRendering()},
+			expectedError: `map has no entry for key "imag"`,
+		},
+		{
+			name:          "missing field",
+			template:      "name: {{ .Nmae }}",
+			options:       []RenderOption{StrictRendering()},
+			expectedError: "can't evaluate field Nmae",
+		},
+		{
+			name:     "base64 of a string",
+			template: `{{ .Name | base64 }} {{ .Cert | base64 }}`,
+			options:  []RenderOption{StrictRendering()},
+			expected: "b3BlcmF0b3I= bGluZTEKbGluZTI=",
+		},
+		{
+			name:          "base64 of a number",
+			template:      `{{ .Extra.replicas | base64 }}`,
+			options:       []RenderOption{StrictRendering()},
+			expectedError: "expected a string or []byte, got int",
+		},
+		{
+			name:     "indent",
+			template: "data: |\n  {{ .Cert | indent 2 }}",
+			options:  []RenderOption{StrictRendering()},
+			expected: "data: |\n  line1\n  line2",
+		},
+		{
+			name:     "default",
+			template: `{{ index .Extra "replicas" | default 3 }} {{ index .Extra "missing" | default "none" }} {{ .Extra.image | default "none" }}`,
+			options:  []RenderOption{StrictRendering()},
+			expected: "3 none quay.io/operator",
+		},
+		{
+			name:          "invalid certificate",
+			template:      `{{ .Name | notAfter }}`,
+			options:       []RenderOption{StrictRendering()},
+			expectedError: "data does not contain any valid RSA or ECDSA certificates",
+		},
+		{
+			name:     "deterministic map iteration",
+			template: `{{ range $k, $v := .Extra }}{{ $k }} {{ end }}`,
+			options:  []RenderOption{StrictRendering()},
+			expected: "a b image replicas ",
+		},
+		{
+			name:     "additional functions",
+			template: `{{ .Name | upper }}`,
+			options:  []RenderOption{StrictRendering(), WithTemplateFuncs(template.FuncMap{"upper": strings.ToUpper})},
+			expected: "OPERATOR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asset, err := CreateAssetFromTemplate("test.yaml", []byte(tt.template), data, tt.options...)
+			if len(tt.expectedError) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(asset.Data) != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, string(asset.Data))
+			}
+		})
+	}
+}
+
+func TestNewWithRenderOptions(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"b.yaml":     "name: {{ .name }}",
+		"a.yaml":     "name: {{ .name }}",
+		"sub/c.yaml": "name: {{ .name }}",
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assets, err := NewWithRenderOptions(dir, map[string]string{"name": "test"}, []RenderOption{StrictRendering()}, nil, OnlyYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, asset := range assets {
+		names = append(names, asset.Name)
+	}
+	if expected := []string{"a.yaml", "b.yaml", "sub/c.yaml"}; !reflect.DeepEqual(expected, names) {
+		t.Errorf("expected assets %v, got %v", expected, names)
+	}
+
+	if _, err := NewWithRenderOptions(dir, map[string]string{"nmae": "test"}, []RenderOption{StrictRendering()}, nil, OnlyYaml); err == nil || !strings.Contains(err.Error(), `failed to render "a.yaml"`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
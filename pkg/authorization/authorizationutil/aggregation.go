@@ -0,0 +1,148 @@
+package authorizationutil
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	rbacv1listers "k8s.io/client-go/listers/rbac/v1"
+)
+
+// ResolveClusterRoleRules returns the effective rules of the cluster role. The rules of an aggregated cluster role
+// are the rules of the cluster roles its aggregation rule selects, resolved recursively, as the cluster role
+// aggregation controller eventually sets them; this resolves them without waiting for the controller. The rules are
+// deduplicated and kept in the order the controller aggregates them: by cluster role name, then in the order of
+// the rules of each cluster role.
+func ResolveClusterRoleRules(lister rbacv1listers.ClusterRoleLister, clusterRole *rbacv1.ClusterRole) ([]rbacv1.PolicyRule, error) {
+	return resolveClusterRoleRules(lister, clusterRole, sets.NewString())
+}
+
+func resolveClusterRoleRules(lister rbacv1listers.ClusterRoleLister, clusterRole *rbacv1.ClusterRole, visiting sets.String) ([]rbacv1.PolicyRule, error) {
+	if clusterRole.AggregationRule == nil {
+		return clusterRole.Rules, nil
+	}
+	if visiting.Has(clusterRole.Name) {
+		return nil, fmt.Errorf("the aggregation of the cluster role %s is cyclic", clusterRole.Name)
+	}
+	visiting.Insert(clusterRole.Name)
+	defer visiting.Delete(clusterRole.Name)
+
+	selected := map[string]*rbacv1.ClusterRole{}
+	for _, labelSelector := range clusterRole.AggregationRule.ClusterRoleSelectors {
+		selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("the aggregation rule of the cluster role %s is invalid: %v", clusterRole.Name, err)
+		}
+		clusterRoles, err := lister.List(selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, selectedRole := range clusterRoles {
+			// the controller ignores the cluster role selecting itself
+			if selectedRole.Name != clusterRole.Name {
+				selected[selectedRole.Name] = selectedRole
+			}
+		}
+	}
+	names := make([]string, 0, len(selected))
+	for name := range selected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var rules []rbacv1.PolicyRule
+	seen := sets.NewString()
+	for _, name := range names {
+		selectedRules, err := resolveClusterRoleRules(lister, selected[name], visiting)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range selectedRules {
+			if key := ruleKey(rule); !seen.Has(key) {
+				seen.Insert(key)
+				rules = append(rules, rule)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// ResolveClusterRoleRulesByName returns the effective rules of the cluster role with the name.
+func ResolveClusterRoleRulesByName(lister rbacv1listers.ClusterRoleLister, name string) ([]rbacv1.PolicyRule, error) {
+	clusterRole, err := lister.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return ResolveClusterRoleRules(lister, clusterRole)
+}
+
+// RulesDiff is the difference between two sets of rules, as the permissions granted by a single verb on a single
+// resource, resource name or non resource URL, regardless of how the rules group them.
+type RulesDiff struct {
+	// Added are the permissions granted by the new rules only.
+	Added []rbacv1.PolicyRule
+	// Removed are the permissions granted by the old rules only.
+	Removed []rbacv1.PolicyRule
+}
+
+// Empty returns whether the rules grant the same permissions.
+func (d RulesDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// DiffRules returns the difference between the permissions of the old and new rules, e.g. of two resolutions of an
+// aggregated cluster role. Wildcards are compared literally: a rule for all the verbs does not grant the permissions
+// of a rule for the get verb.
+func DiffRules(oldRules, newRules []rbacv1.PolicyRule) RulesDiff {
+	oldPermissions, newPermissions := breakdownRules(oldRules), breakdownRules(newRules)
+	var diff RulesDiff
+	for _, key := range sets.StringKeySet(newPermissions).List() {
+		if _, ok := oldPermissions[key]; !ok {
+			diff.Added = append(diff.Added, newPermissions[key])
+		}
+	}
+	for _, key := range sets.StringKeySet(oldPermissions).List() {
+		if _, ok := newPermissions[key]; !ok {
+			diff.Removed = append(diff.Removed, oldPermissions[key])
+		}
+	}
+	return diff
+}
+
+// breakdownRules breaks the rules down into rules granting a single permission, by key.
+func breakdownRules(rules []rbacv1.PolicyRule) map[string]rbacv1.PolicyRule {
+	permissions := map[string]rbacv1.PolicyRule{}
+	add := func(rule rbacv1.PolicyRule) {
+		permissions[ruleKey(rule)] = rule
+	}
+	for _, rule := range rules {
+		for _, verb := range rule.Verbs {
+			for _, url := range rule.NonResourceURLs {
+				add(rbacv1.PolicyRule{Verbs: []string{verb}, NonResourceURLs: []string{url}})
+			}
+			for _, group := range rule.APIGroups {
+				for _, resource := range rule.Resources {
+					if len(rule.ResourceNames) == 0 {
+						add(rbacv1.PolicyRule{Verbs: []string{verb}, APIGroups: []string{group}, Resources: []string{resource}})
+						continue
+					}
+					for _, name := range rule.ResourceNames {
+						add(rbacv1.PolicyRule{Verbs: []string{verb}, APIGroups: []string{group}, Resources: []string{resource}, ResourceNames: []string{name}})
+					}
+				}
+			}
+		}
+	}
+	return permissions
+}
+
+// ruleKey returns a key identifying the rule, its fields being compared as sets.
+func ruleKey(rule rbacv1.PolicyRule) string {
+	join := func(values []string) string {
+		return strings.Join(sets.NewString(values...).List(), ",")
+	}
+	return strings.Join([]string{join(rule.Verbs), join(rule.APIGroups), join(rule.Resources), join(rule.ResourceNames), join(rule.NonResourceURLs)}, "|")
+}
@@ -0,0 +1,140 @@
+package authorizationutil
+
+import (
+	"reflect"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	rbacv1listers "k8s.io/client-go/listers/rbac/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func aggregatedClusterRole(name string, labels map[string]string, selectors ...map[string]string) *rbacv1.ClusterRole {
+	clusterRole := &rbacv1.ClusterRole{
+		ObjectMeta:      metav1.ObjectMeta{Name: name, Labels: labels},
+		AggregationRule: &rbacv1.AggregationRule{},
+		// the rules of aggregated cluster roles are ignored, the controller overwrites them
+		Rules: []rbacv1.PolicyRule{{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}},
+	}
+	for _, selector := range selectors {
+		clusterRole.AggregationRule.ClusterRoleSelectors = append(clusterRole.AggregationRule.ClusterRoleSelectors, metav1.LabelSelector{MatchLabels: selector})
+	}
+	return clusterRole
+}
+
+func clusterRole(name string, labels map[string]string, rules ...rbacv1.PolicyRule) *rbacv1.ClusterRole {
+	return &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}, Rules: rules}
+}
+
+func clusterRoleLister(t *testing.T, clusterRoles ...*rbacv1.ClusterRole) rbacv1listers.ClusterRoleLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, clusterRole := range clusterRoles {
+		if err := indexer.Add(clusterRole); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rbacv1listers.NewClusterRoleLister(indexer)
+}
+
+func TestResolveClusterRoleRules(t *testing.T) {
+	getPods := rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}
+	listPods := rbacv1.PolicyRule{Verbs: []string{"list"}, APIGroups: []string{""}, Resources: []string{"pods"}}
+	getRoutes := rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{"route.openshift.io"}, Resources: []string{"routes"}}
+	healthz := rbacv1.PolicyRule{Verbs: []string{"get"}, NonResourceURLs: []string{"/healthz"}}
+
+	tests := []struct {
+		name         string
+		clusterRoles []*rbacv1.ClusterRole
+		expected     []rbacv1.PolicyRule
+		expectErr    bool
+	}{
+		{
+			name:         "not aggregated",
+			clusterRoles: []*rbacv1.ClusterRole{clusterRole("target", nil, getPods, healthz)},
+			expected:     []rbacv1.PolicyRule{getPods, healthz},
+		},
+		{
+			name: "aggregated in name order and deduplicated",
+			clusterRoles: []*rbacv1.ClusterRole{
+				aggregatedClusterRole("target", map[string]string{"aggregate-to-view": "true"}, map[string]string{"aggregate-to-view": "true"}),
+				clusterRole("b", map[string]string{"aggregate-to-view": "true"}, getRoutes, getPods),
+				clusterRole("a", map[string]string{"aggregate-to-view": "true"}, getPods),
+				clusterRole("c", map[string]string{"aggregate-to-edit": "true"}, listPods),
+			},
+			expected: []rbacv1.PolicyRule{getPods, getRoutes},
+		},
+		{
+			name: "multiple selectors",
+			clusterRoles: []*rbacv1.ClusterRole{
+				aggregatedClusterRole("target", nil, map[string]string{"aggregate-to-view": "true"}, map[string]string{"aggregate-to-edit": "true"}),
+				clusterRole("a", map[string]string{"aggregate-to-view": "true", "aggregate-to-edit": "true"}, getPods),
+				clusterRole("c", map[string]string{"aggregate-to-edit": "true"}, listPods),
+			},
+			expected: []rbacv1.PolicyRule{getPods, listPods},
+		},
+		{
+			name: "nested",
+			clusterRoles: []*rbacv1.ClusterRole{
+				aggregatedClusterRole("target", nil, map[string]string{"aggregate-to-edit": "true"}),
+				aggregatedClusterRole("view", map[string]string{"aggregate-to-edit": "true"}, map[string]string{"aggregate-to-view": "true"}),
+				clusterRole("a", map[string]string{"aggregate-to-view": "true"}, getPods),
+				clusterRole("b", map[string]string{"aggregate-to-edit": "true"}, listPods),
+			},
+			expected: []rbacv1.PolicyRule{listPods, getPods},
+		},
+		{
+			name: "cyclic",
+			clusterRoles: []*rbacv1.ClusterRole{
+				aggregatedClusterRole("target", map[string]string{"aggregate-to-view": "true"}, map[string]string{"aggregate-to-edit": "true"}),
+				aggregatedClusterRole("edit", map[string]string{"aggregate-to-edit": "true"}, map[string]string{"aggregate-to-view": "true"}),
+			},
+			expectErr: true,
+		},
+		{
+			name: "no selected cluster role",
+			clusterRoles: []*rbacv1.ClusterRole{
+				aggregatedClusterRole("target", nil, map[string]string{"aggregate-to-view": "true"}),
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rules, err := ResolveClusterRoleRulesByName(clusterRoleLister(t, tc.clusterRoles...), "target")
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %t, got %v", tc.expectErr, err)
+			}
+			if !reflect.DeepEqual(rules, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, rules)
+			}
+		})
+	}
+}
+
+func TestDiffRules(t *testing.T) {
+	oldRules := []rbacv1.PolicyRule{
+		{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"pods", "services"}},
+		{Verbs: []string{"get"}, NonResourceURLs: []string{"/healthz"}},
+	}
+	newRules := []rbacv1.PolicyRule{
+		{Verbs: []string{"list", "get"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+		{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"services"}},
+		{Verbs: []string{"update"}, APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{"config"}},
+		{Verbs: []string{"get"}, NonResourceURLs: []string{"/healthz"}},
+	}
+
+	diff := DiffRules(oldRules, newRules)
+	expected := RulesDiff{
+		Added:   []rbacv1.PolicyRule{{Verbs: []string{"update"}, APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{"config"}}},
+		Removed: []rbacv1.PolicyRule{{Verbs: []string{"list"}, APIGroups: []string{""}, Resources: []string{"services"}}},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("expected %#v, got %#v", expected, diff)
+	}
+	if diff.Empty() {
+		t.Errorf("expected a difference")
+	}
+	if diff := DiffRules(oldRules, oldRules); !diff.Empty() {
+		t.Errorf("expected no difference, got %#v", diff)
+	}
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// DefaultControllerQPS and DefaultControllerBurst are the default rate limits of the clients of each controller.
+	// The kube-apiserver protects itself with API Priority and Fairness, so the client side rate limits only need to
+	// stop runaway controllers, and are higher than the client-go defaults of 5 and 10 which throttle busy controllers
+	// long before the server does.
+	DefaultControllerQPS   float32 = 50
+	DefaultControllerBurst int     = 100
+)
+
+// ControllerClientBuilder creates the rest.Configs of the controllers of a process from a base config. Unlike sharing
+// the base config, each controller gets its own user agent, identifying it in the audit logs and the flow control
+// metrics of the kube-apiserver, and its own rate limiter, so that a busy controller does not throttle the others.
+type ControllerClientBuilder struct {
+	base           *rest.Config
+	qps            float32
+	burst          int
+	requestMetrics bool
+}
+
+// NewControllerClientBuilder creates a builder of the configs of controllers from the base config, which is not modified.
+func NewControllerClientBuilder(base *rest.Config) *ControllerClientBuilder {
+	return &ControllerClientBuilder{
+		base:  base,
+		qps:   DefaultControllerQPS,
+		burst: DefaultControllerBurst,
+	}
+}
+
+// WithRateLimits sets the default rate limits of the controllers. A negative QPS disables the client side rate
+// limiting, leaving it to the flow control of the kube-apiserver.
+func (b *ControllerClientBuilder) WithRateLimits(qps float32, burst int) *ControllerClientBuilder {
+	b.qps = qps
+	b.burst = burst
+	return b
+}
+
+// WithRequestMetrics records the latency of the requests of the controllers in the
+// openshift_controller_client_request_duration_seconds metric, labeled by controller.
+func (b *ControllerClientBuilder) WithRequestMetrics() *ControllerClientBuilder {
+	b.requestMetrics = true
+	return b
+}
+
+// Config returns the config of the controller, with the default rate limits.
+func (b *ControllerClientBuilder) Config(controllerName string) *rest.Config {
+	return b.ConfigWithRateLimits(controllerName, b.qps, b.burst)
+}
+
+// ConfigWithRateLimits returns the config of the controller, with its own rate limits.
+func (b *ControllerClientBuilder) ConfigWithRateLimits(controllerName string, qps float32, burst int) *rest.Config {
+	config := rest.CopyConfig(b.base)
+
+	userAgent := config.UserAgent
+	if len(userAgent) == 0 {
+		userAgent = rest.DefaultKubernetesUserAgent()
+	}
+	config.UserAgent = userAgent + "/" + controllerName
+
+	// a rate limiter of the base config would be shared by all the controllers
+	config.RateLimiter = nil
+	config.QPS = qps
+	config.Burst = burst
+
+	if b.requestMetrics {
+		config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return &requestMetricsRoundTripper{controller: controllerName, delegate: rt}
+		})
+	}
+	return config
+}
+
+// requestMetricsRoundTripper records the latency of the requests of a controller.
+type requestMetricsRoundTripper struct {
+	controller string
+	delegate   http.RoundTripper
+}
+
+func (rt *requestMetricsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := rt.delegate.RoundTrip(req)
+	code := "<error>"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	metrics.ObserveRequest(rt.controller, req.Method, code, time.Since(start))
+	return resp, err
+}
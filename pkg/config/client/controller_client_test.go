@@ -0,0 +1,96 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestControllerClientBuilder(t *testing.T) {
+	userAgents := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents <- r.UserAgent()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"major":"1","minor":"27"}`))
+	}))
+	defer server.Close()
+
+	base := &rest.Config{
+		Host:        server.URL,
+		UserAgent:   "operator",
+		QPS:         1,
+		Burst:       1,
+		RateLimiter: flowcontrol.NewTokenBucketRateLimiter(1, 1),
+	}
+	builder := NewControllerClientBuilder(base).WithRequestMetrics()
+
+	testCases := []struct {
+		desc              string
+		config            *rest.Config
+		expectedUserAgent string
+		expectedQPS       float32
+		expectedBurst     int
+	}{
+		{
+			desc:              "default rate limits",
+			config:            builder.Config("status-controller"),
+			expectedUserAgent: "operator/status-controller",
+			expectedQPS:       DefaultControllerQPS,
+			expectedBurst:     DefaultControllerBurst,
+		},
+		{
+			desc:              "controller rate limits",
+			config:            builder.ConfigWithRateLimits("sync-controller", 10, 20),
+			expectedUserAgent: "operator/sync-controller",
+			expectedQPS:       10,
+			expectedBurst:     20,
+		},
+		{
+			desc:              "client side rate limiting disabled",
+			config:            NewControllerClientBuilder(&rest.Config{Host: server.URL}).WithRateLimits(-1, 0).Config("sync-controller"),
+			expectedUserAgent: rest.DefaultKubernetesUserAgent() + "/sync-controller",
+			expectedQPS:       -1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			if tc.config.QPS != tc.expectedQPS || tc.config.Burst != tc.expectedBurst {
+				t.Errorf("expected QPS %v and burst %v, got %v and %v", tc.expectedQPS, tc.expectedBurst, tc.config.QPS, tc.config.Burst)
+			}
+			if tc.config.RateLimiter != nil {
+				t.Errorf("expected the rate limiter of the base config not to be shared")
+			}
+			client, err := kubernetes.NewForConfig(tc.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := client.Discovery().ServerVersion(); err != nil {
+				t.Fatal(err)
+			}
+			if userAgent := <-userAgents; userAgent != tc.expectedUserAgent {
+				t.Errorf("expected user agent %q, got %q", tc.expectedUserAgent, userAgent)
+			}
+		})
+	}
+
+	if base.UserAgent != "operator" || base.QPS != 1 || base.RateLimiter == nil || base.WrapTransport != nil {
+		t.Errorf("expected the base config not to be modified, got %#v", base)
+	}
+
+	requests, err := testutil.GetHistogramMetricCount(metrics.requestDuration.WithLabelValues("status-controller", http.MethodGet, "200"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request of the status controller, got %d", requests)
+	}
+	// the request of the builder without request metrics is not recorded
+	if requests, err := testutil.GetHistogramMetricCount(metrics.requestDuration.WithLabelValues("sync-controller", http.MethodGet, "200")); err != nil || requests != 1 {
+		t.Errorf("expected 1 request of the sync controller, got %d: %v", requests, err)
+	}
+}
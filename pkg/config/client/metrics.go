@@ -0,0 +1,42 @@
+package client
+
+import (
+	"time"
+
+	k8smetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+// metrics provides access to all controller client metrics.
+var metrics *clientMetrics
+
+func init() {
+	metrics = newClientMetrics(legacyregistry.Register)
+}
+
+// clientMetrics instruments the clients of the controllers with prometheus metrics.
+type clientMetrics struct {
+	requestDuration *k8smetrics.HistogramVec
+}
+
+// newClientMetrics creates a new clientMetrics, configured with default metric names.
+func newClientMetrics(registerFunc func(k8smetrics.Registerable) error) *clientMetrics {
+	requestDuration := k8smetrics.NewHistogramVec(
+		&k8smetrics.HistogramOpts{
+			Namespace: "openshift",
+			Subsystem: "controller_client",
+			Name:      "request_duration_seconds",
+			Help:      "The latency of the requests of the controllers to the kube-apiserver, labeled by controller, verb (HTTP method) and code",
+			Buckets:   k8smetrics.ExponentialBuckets(0.005, 2, 12),
+		}, []string{"controller", "verb", "code"})
+	registerFunc(requestDuration)
+
+	return &clientMetrics{
+		requestDuration: requestDuration,
+	}
+}
+
+// ObserveRequest records the latency of a request of a controller.
+func (m *clientMetrics) ObserveRequest(controller, verb, code string, duration time.Duration) {
+	m.requestDuration.WithLabelValues(controller, verb, code).Observe(duration.Seconds())
+}
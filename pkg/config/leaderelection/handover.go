@@ -0,0 +1,87 @@
+package leaderelection
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/klog/v2"
+)
+
+// RunWithHandover runs the leader election like leaderelection.RunOrDie, running the work while leading, but hands
+// the leadership over when ctx is cancelled, e.g. when the pod is deleted during a rolling update: the work is given
+// the handover timeout to stop, and only then is the lease released, so that another candidate acquires it within its
+// retry period instead of waiting for the lease to expire, without ever running the work concurrently with this
+// process. The callbacks of the config other than OnStartedLeading are kept, e.g. OnStoppedLeading is called once the
+// lease is released.
+//
+// The coordinated leader election of Kubernetes (the LeaseCandidate API) is not supported by this version of
+// client-go, so the handover always relies on the early release of the lease.
+func RunWithHandover(ctx context.Context, config leaderelection.LeaderElectionConfig, work func(ctx context.Context), handoverTimeout time.Duration) error {
+	// the lease is released when the leader election context is cancelled, which is delayed until the work stopped
+	config.ReleaseOnCancel = true
+	leaderElectionCtx, cancelLeaderElection := context.WithCancel(context.Background())
+	defer cancelLeaderElection()
+
+	var lock sync.Mutex
+	var shuttingDown bool
+	var workStopped chan struct{}
+
+	config.Callbacks.OnStartedLeading = func(leadingCtx context.Context) {
+		lock.Lock()
+		if shuttingDown {
+			lock.Unlock()
+			return
+		}
+		stopped := make(chan struct{})
+		workStopped = stopped
+		lock.Unlock()
+		defer close(stopped)
+
+		// the work stops when the process shuts down or when the leadership is lost
+		workCtx, cancel := context.WithCancel(leadingCtx)
+		defer cancel()
+		go func() {
+			select {
+			case <-ctx.Done():
+			case <-workCtx.Done():
+			}
+			cancel()
+		}()
+		work(workCtx)
+	}
+
+	elector, err := leaderelection.NewLeaderElector(config)
+	if err != nil {
+		return err
+	}
+	if config.WatchDog != nil {
+		config.WatchDog.SetLeaderElection(elector)
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-leaderElectionCtx.Done():
+			return
+		}
+		lock.Lock()
+		shuttingDown = true
+		stopped := workStopped
+		lock.Unlock()
+
+		if stopped != nil {
+			select {
+			case <-stopped:
+				klog.Infof("Leader work stopped, releasing the lease %s", config.Lock.Describe())
+			case <-time.After(handoverTimeout):
+				klog.Warningf("Leader work did not stop in %v, releasing the lease %s", handoverTimeout, config.Lock.Describe())
+			}
+		}
+		cancelLeaderElection()
+	}()
+
+	elector.Run(leaderElectionCtx)
+	return nil
+}
@@ -0,0 +1,189 @@
+package leaderelection
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// fakeLock is an in memory lock recording the holders of the lease.
+type fakeLock struct {
+	lock    sync.Mutex
+	record  *resourcelock.LeaderElectionRecord
+	holders []string
+}
+
+func (l *fakeLock) Get(ctx context.Context) (*resourcelock.LeaderElectionRecord, []byte, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.record == nil {
+		return nil, nil, apierrors.NewNotFound(schema.GroupResource{Resource: "leases"}, "test")
+	}
+	record := *l.record
+	raw, err := json.Marshal(record)
+	return &record, raw, err
+}
+
+func (l *fakeLock) Create(ctx context.Context, record resourcelock.LeaderElectionRecord) error {
+	return l.Update(ctx, record)
+}
+
+func (l *fakeLock) Update(ctx context.Context, record resourcelock.LeaderElectionRecord) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.record == nil || l.record.HolderIdentity != record.HolderIdentity {
+		l.holders = append(l.holders, record.HolderIdentity)
+	}
+	l.record = &record
+	return nil
+}
+
+func (l *fakeLock) RecordEvent(string) {}
+
+func (l *fakeLock) Identity() string { return "test" }
+
+func (l *fakeLock) Describe() string { return "test/test" }
+
+func (l *fakeLock) holder() string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.record == nil {
+		return ""
+	}
+	return l.record.HolderIdentity
+}
+
+func TestRunWithHandover(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		workDuration    time.Duration
+		handoverTimeout time.Duration
+		expectWorkDone  bool
+	}{
+		{
+			desc:            "the lease is released after the work stopped",
+			workDuration:    200 * time.Millisecond,
+			handoverTimeout: 5 * time.Second,
+			expectWorkDone:  true,
+		},
+		{
+			desc:            "the lease is released after the handover timeout",
+			workDuration:    5 * time.Second,
+			handoverTimeout: 100 * time.Millisecond,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			lock := &fakeLock{}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			started := make(chan struct{})
+			var workLock sync.Mutex
+			workDone := false
+			holderWhenDone := ""
+			work := func(ctx context.Context) {
+				close(started)
+				<-ctx.Done()
+				// the lease must still be held while stopping
+				time.Sleep(tc.workDuration)
+				workLock.Lock()
+				defer workLock.Unlock()
+				workDone = true
+				holderWhenDone = lock.holder()
+			}
+
+			stoppedLeading := make(chan struct{})
+			config := leaderelection.LeaderElectionConfig{
+				Lock:          lock,
+				LeaseDuration: 10 * time.Second,
+				RenewDeadline: 5 * time.Second,
+				RetryPeriod:   time.Second,
+				Callbacks: leaderelection.LeaderCallbacks{
+					OnStoppedLeading: func() { close(stoppedLeading) },
+				},
+			}
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- RunWithHandover(ctx, config, work, tc.handoverTimeout)
+			}()
+
+			select {
+			case <-started:
+			case <-time.After(5 * time.Second):
+				t.Fatal("the work was not started")
+			}
+			cancel()
+
+			select {
+			case err := <-errCh:
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatal("the leader election did not stop")
+			}
+			<-stoppedLeading
+
+			if holder := lock.holder(); len(holder) != 0 {
+				t.Errorf("expected the lease to be released, held by %q", holder)
+			}
+			workLock.Lock()
+			defer workLock.Unlock()
+			if workDone != tc.expectWorkDone {
+				t.Errorf("expected the work to be done %v before the release, got %v", tc.expectWorkDone, workDone)
+			}
+			if tc.expectWorkDone && holderWhenDone != "test" {
+				t.Errorf("expected the lease to be held until the work stopped, held by %q", holderWhenDone)
+			}
+		})
+	}
+}
+
+func TestRunWithHandoverNotLeading(t *testing.T) {
+	// the lease is held by another candidate
+	lock := &fakeLock{record: &resourcelock.LeaderElectionRecord{
+		HolderIdentity:       "other",
+		LeaseDurationSeconds: 3600,
+	}}
+	lock.record.RenewTime.Time = time.Now()
+	lock.record.AcquireTime.Time = time.Now()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	config := leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: 10 * time.Second,
+		RenewDeadline: 5 * time.Second,
+		RetryPeriod:   100 * time.Millisecond,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStoppedLeading: func() {},
+		},
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- RunWithHandover(ctx, config, func(ctx context.Context) {
+			t.Errorf("the work must not run without the lease")
+		}, time.Hour)
+	}()
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the leader election did not stop without waiting for the handover timeout")
+	}
+	if holder := lock.holder(); holder != "other" {
+		t.Errorf("expected the lease to be held by the other candidate, got %q", holder)
+	}
+}
@@ -0,0 +1,132 @@
+package leaderelection
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+// LeaderElectionProfile is a named set of leader election durations tuned for a cluster topology.
+type LeaderElectionProfile struct {
+	Name          string
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+}
+
+var (
+	// HighlyAvailableLeaderElectionProfile has the durations of LeaderElectionDefaulting, tolerating 78s of
+	// kube-apiserver downtime.
+	HighlyAvailableLeaderElectionProfile = LeaderElectionProfile{
+		Name:          "HighlyAvailable",
+		LeaseDuration: 137 * time.Second,
+		RenewDeadline: 107 * time.Second,
+		RetryPeriod:   26 * time.Second,
+	}
+	// SingleReplicaLeaderElectionProfile has the durations of LeaderElectionSNOConfig, limiting the calls to the
+	// kube-apiserver of single node clusters and tolerating its 180s of downtime during their upgrades.
+	SingleReplicaLeaderElectionProfile = LeaderElectionProfile{
+		Name:          "SingleReplica",
+		LeaseDuration: 270 * time.Second,
+		RenewDeadline: 240 * time.Second,
+		RetryPeriod:   60 * time.Second,
+	}
+	// ExternalLeaderElectionProfile is used when the control plane is hosted outside of the cluster. It has the
+	// durations of HighlyAvailableLeaderElectionProfile, the hosted kube-apiserver being highly available.
+	ExternalLeaderElectionProfile = LeaderElectionProfile{
+		Name:          "External",
+		LeaseDuration: 137 * time.Second,
+		RenewDeadline: 107 * time.Second,
+		RetryPeriod:   26 * time.Second,
+	}
+	// FastFailoverLeaderElectionProfile trades kube-apiserver downtime tolerance for faster lease acquisition, for the
+	// components whose unavailability is more disruptive than a restart: it keeps 15s of clock skew tolerance and 4
+	// retries, tolerates 30s of kube-apiserver downtime, and acquires a lost lease in at most 70s.
+	FastFailoverLeaderElectionProfile = LeaderElectionProfile{
+		Name:          "FastFailover",
+		LeaseDuration: 60 * time.Second,
+		RenewDeadline: 45 * time.Second,
+		RetryPeriod:   10 * time.Second,
+	}
+)
+
+// Apply returns the config with the durations of the profile. Like LeaderElectionSNOConfig, it does not respect the
+// durations of the config, and does not mutate it.
+func (p LeaderElectionProfile) Apply(config configv1.LeaderElection) configv1.LeaderElection {
+	ret := *(&config).DeepCopy()
+	ret.LeaseDuration.Duration = p.LeaseDuration
+	ret.RenewDeadline.Duration = p.RenewDeadline
+	ret.RetryPeriod.Duration = p.RetryPeriod
+	return ret
+}
+
+// String describes the profile with the guarantees of its durations, following the formula of LeaderElectionDefaulting.
+func (p LeaderElectionProfile) String() string {
+	retryTimes := int(math.Floor(float64(p.RenewDeadline / p.RetryPeriod)))
+	return fmt.Sprintf("%s (lease duration %v, renew deadline %v, retry period %v: %v retries, %v of clock skew, %v of kube-apiserver downtime tolerance, worst non-graceful lease acquisition %v)",
+		p.Name,
+		p.LeaseDuration,
+		p.RenewDeadline,
+		p.RetryPeriod,
+		retryTimes,
+		p.LeaseDuration-p.RenewDeadline,
+		time.Duration(retryTimes-1)*p.RetryPeriod,
+		p.LeaseDuration+p.RetryPeriod,
+	)
+}
+
+// LeaderElectionProfileSelection is the profile selected for a cluster and the reason it was selected.
+type LeaderElectionProfileSelection struct {
+	Profile LeaderElectionProfile
+	Reason  string
+}
+
+// SelectLeaderElectionProfile selects the leader election profile for the topology of the cluster, so that the
+// operators can log why their leader election is tuned the way it is. Single replica control planes get the
+// SingleReplica profile, even when fastFailover is requested as there is no other replica to fail over to; other
+// clusters get the FastFailover profile when requested, and otherwise the profile of their control plane topology.
+// When the infrastructure status is unknown, the HighlyAvailable profile is selected.
+func SelectLeaderElectionProfile(infraStatus *configv1.InfrastructureStatus, fastFailover bool) LeaderElectionProfileSelection {
+	if infraStatus == nil {
+		return LeaderElectionProfileSelection{
+			Profile: HighlyAvailableLeaderElectionProfile,
+			Reason:  "the cluster topology is unknown",
+		}
+	}
+	switch topology := infraStatus.ControlPlaneTopology; {
+	case topology == configv1.SingleReplicaTopologyMode && fastFailover:
+		return LeaderElectionProfileSelection{
+			Profile: SingleReplicaLeaderElectionProfile,
+			Reason:  fmt.Sprintf("the control plane topology is %s, fast failover was requested but there is no replica to fail over to", topology),
+		}
+	case topology == configv1.SingleReplicaTopologyMode:
+		return LeaderElectionProfileSelection{
+			Profile: SingleReplicaLeaderElectionProfile,
+			Reason:  fmt.Sprintf("the control plane topology is %s", topology),
+		}
+	case fastFailover:
+		return LeaderElectionProfileSelection{
+			Profile: FastFailoverLeaderElectionProfile,
+			Reason:  fmt.Sprintf("fast failover was requested and the control plane topology is %s", topologyOrUnset(topology)),
+		}
+	case topology == configv1.ExternalTopologyMode:
+		return LeaderElectionProfileSelection{
+			Profile: ExternalLeaderElectionProfile,
+			Reason:  fmt.Sprintf("the control plane topology is %s", topology),
+		}
+	default:
+		return LeaderElectionProfileSelection{
+			Profile: HighlyAvailableLeaderElectionProfile,
+			Reason:  fmt.Sprintf("the control plane topology is %s", topologyOrUnset(topology)),
+		}
+	}
+}
+
+func topologyOrUnset(topology configv1.TopologyMode) string {
+	if len(topology) == 0 {
+		return "not set"
+	}
+	return string(topology)
+}
@@ -0,0 +1,104 @@
+package leaderelection
+
+import (
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+func TestSelectLeaderElectionProfile(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		infraStatus     *configv1.InfrastructureStatus
+		fastFailover    bool
+		expectedProfile LeaderElectionProfile
+		expectedReason  string
+	}{
+		{
+			desc:            "unknown topology",
+			expectedProfile: HighlyAvailableLeaderElectionProfile,
+			expectedReason:  "the cluster topology is unknown",
+		},
+		{
+			desc:            "unknown topology with fast failover",
+			fastFailover:    true,
+			expectedProfile: HighlyAvailableLeaderElectionProfile,
+			expectedReason:  "the cluster topology is unknown",
+		},
+		{
+			desc:            "topology not set",
+			infraStatus:     &configv1.InfrastructureStatus{},
+			expectedProfile: HighlyAvailableLeaderElectionProfile,
+			expectedReason:  "the control plane topology is not set",
+		},
+		{
+			desc:            "highly available",
+			infraStatus:     &configv1.InfrastructureStatus{ControlPlaneTopology: configv1.HighlyAvailableTopologyMode},
+			expectedProfile: HighlyAvailableLeaderElectionProfile,
+			expectedReason:  "the control plane topology is HighlyAvailable",
+		},
+		{
+			desc:            "highly available with fast failover",
+			infraStatus:     &configv1.InfrastructureStatus{ControlPlaneTopology: configv1.HighlyAvailableTopologyMode},
+			fastFailover:    true,
+			expectedProfile: FastFailoverLeaderElectionProfile,
+			expectedReason:  "fast failover was requested and the control plane topology is HighlyAvailable",
+		},
+		{
+			desc:            "single replica",
+			infraStatus:     &configv1.InfrastructureStatus{ControlPlaneTopology: configv1.SingleReplicaTopologyMode},
+			expectedProfile: SingleReplicaLeaderElectionProfile,
+			expectedReason:  "the control plane topology is SingleReplica",
+		},
+		{
+			desc:            "single replica with fast failover",
+			infraStatus:     &configv1.InfrastructureStatus{ControlPlaneTopology: configv1.SingleReplicaTopologyMode},
+			fastFailover:    true,
+			expectedProfile: SingleReplicaLeaderElectionProfile,
+			expectedReason:  "the control plane topology is SingleReplica, fast failover was requested but there is no replica to fail over to",
+		},
+		{
+			desc:            "external",
+			infraStatus:     &configv1.InfrastructureStatus{ControlPlaneTopology: configv1.ExternalTopologyMode},
+			expectedProfile: ExternalLeaderElectionProfile,
+			expectedReason:  "the control plane topology is External",
+		},
+		{
+			desc:            "external with fast failover",
+			infraStatus:     &configv1.InfrastructureStatus{ControlPlaneTopology: configv1.ExternalTopologyMode},
+			fastFailover:    true,
+			expectedProfile: FastFailoverLeaderElectionProfile,
+			expectedReason:  "fast failover was requested and the control plane topology is External",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			selection := SelectLeaderElectionProfile(tc.infraStatus, tc.fastFailover)
+			if selection.Profile != tc.expectedProfile {
+				t.Errorf("expected profile %v, got %v", tc.expectedProfile, selection.Profile)
+			}
+			if selection.Reason != tc.expectedReason {
+				t.Errorf("expected reason %q, got %q", tc.expectedReason, selection.Reason)
+			}
+		})
+	}
+}
+
+func TestLeaderElectionProfileString(t *testing.T) {
+	expected := "FastFailover (lease duration 1m0s, renew deadline 45s, retry period 10s: 4 retries, 15s of clock skew, 30s of kube-apiserver downtime tolerance, worst non-graceful lease acquisition 1m10s)"
+	if description := FastFailoverLeaderElectionProfile.String(); description != expected {
+		t.Errorf("expected %q, got %q", expected, description)
+	}
+	// the profiles must match the durations of the defaulting
+	defaulted := LeaderElectionDefaulting(configv1.LeaderElection{}, "ns", "name")
+	if applied := HighlyAvailableLeaderElectionProfile.Apply(configv1.LeaderElection{Namespace: "ns", Name: "name"}); applied != defaulted {
+		t.Errorf("expected %#v, got %#v", defaulted, applied)
+	}
+	if sno := LeaderElectionSNOConfig(configv1.LeaderElection{}); sno != SingleReplicaLeaderElectionProfile.Apply(configv1.LeaderElection{}) {
+		t.Errorf("unexpected SNO config %#v", sno)
+	}
+	if !strings.HasPrefix(SingleReplicaLeaderElectionProfile.String(), "SingleReplica (") {
+		t.Errorf("unexpected description %q", SingleReplicaLeaderElectionProfile.String())
+	}
+}
@@ -17,8 +17,13 @@ import (
 	"github.com/openshift/library-go/pkg/config/configdefaults"
 	leaderelectionconverter "github.com/openshift/library-go/pkg/config/leaderelection"
 	"github.com/openshift/library-go/pkg/config/serving"
+	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/controller/fileobserver"
 	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/serviceability"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -28,10 +33,11 @@ import (
 	"k8s.io/apiserver/pkg/server/healthz"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/component-base/metrics"
 	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/component-base/tracing"
+	tracingapiv1 "k8s.io/component-base/tracing/api/v1"
 	"k8s.io/klog/v2"
 )
 
@@ -49,6 +55,15 @@ type ControllerContext struct {
 	// Note that this config might not be safe for CR resources, instead it should be used for other resources.
 	ProtoKubeConfig *rest.Config
 
+	// ClientBuilder builds the REST configs of the controllers from KubeConfig, with their own user agent and rate
+	// limits. Use its configs rather than sharing KubeConfig between the controllers.
+	ClientBuilder *client.ControllerClientBuilder
+
+	// TracerProvider provides the tracers of the controllers, see factory.WithTracerProvider. It does not record any span
+	// unless the tracing is enabled with WithTracing, in which case KubeConfig and ProtoKubeConfig are instrumented
+	// to trace the API calls.
+	TracerProvider oteltrace.TracerProvider
+
 	// EventRecorder is used to record events in controllers.
 	EventRecorder events.Recorder
 
@@ -57,12 +72,24 @@ type ControllerContext struct {
 
 	// Namespace where the operator runs. Either specified on the command line or autodetected.
 	OperatorNamespace string
+
+	// Runner starts the controllers added to it in the order of their dependencies, once the StartFunc returned, and
+	// runs them until the context is done. The StartFunc can still run its controllers itself and block.
+	Runner *factory.Runner
 }
 
 // defaultObserverInterval specifies the default interval that file observer will do rehash the files it watches and react to any changes
 // in those files.
 var defaultObserverInterval = 5 * time.Second
 
+// leaderElectionHandoverTimeout is how long the lease is kept on shutdown while the controllers stop, a bit longer than
+// their graceful termination time, after which the process exits anyway.
+var leaderElectionHandoverTimeout = 15 * time.Second
+
+// controllerStartupPollInterval is how often the runner of the controller context checks whether the dependencies of
+// the controllers waiting to start are met.
+var controllerStartupPollInterval = 5 * time.Second
+
 // ControllerBuilder allows the construction of an controller in optional pieces.
 type ControllerBuilder struct {
 	kubeAPIServerConfigFile *string
@@ -83,6 +110,10 @@ type ControllerBuilder struct {
 	authenticationConfig *operatorv1alpha1.DelegatedAuthentication
 	authorizationConfig  *operatorv1alpha1.DelegatedAuthorization
 	healthChecks         []healthz.HealthChecker
+	// tracingConfig enables the OTLP tracing when set
+	tracingConfig *tracingapiv1.TracingConfiguration
+	// runtimeProfilingMaxDuration enables the runtime profiling of the server when not zero
+	runtimeProfilingMaxDuration time.Duration
 
 	versionInfo *version.Info
 
@@ -94,6 +125,8 @@ type ControllerBuilder struct {
 	// Keep track if we defaulted leader election, used to make sure we don't stomp on the users intent for leader election
 	// We use this flag to determine at runtime if we can alter leader election for SNO configurations
 	userExplicitlySetLeaderElectionValues bool
+	// leaderElectionFastFailover selects the fast failover leader election profile on multi replica control planes
+	leaderElectionFastFailover bool
 }
 
 // NewController returns a builder struct for constructing the command you want to run
@@ -157,6 +190,14 @@ func (b *ControllerBuilder) WithLeaderElection(leaderElection configv1.LeaderEle
 	return b
 }
 
+// WithFastFailoverLeaderElection selects the fast failover leader election profile, trading kube-apiserver downtime
+// tolerance for faster lease acquisition, when the cluster does not have a single replica control plane and the
+// leader election durations are not explicitly set.
+func (b *ControllerBuilder) WithFastFailoverLeaderElection() *ControllerBuilder {
+	b.leaderElectionFastFailover = true
+	return b
+}
+
 // WithVersion accepts a getting that provide binary version information that is used to report build_info information to prometheus
 func (b *ControllerBuilder) WithVersion(info version.Info) *ControllerBuilder {
 	b.versionInfo = &info
@@ -178,6 +219,21 @@ func (b *ControllerBuilder) WithHealthChecks(healthChecks ...healthz.HealthCheck
 	return b
 }
 
+// WithRuntimeProfiling replaces the always enabled pprof endpoints of the server with endpoints that are disabled until
+// the profiling is enabled at runtime, for at most maxDuration, through the /debug/profiling endpoint of the server.
+// See serviceability.RuntimeProfiler.
+func (b *ControllerBuilder) WithRuntimeProfiling(maxDuration time.Duration) *ControllerBuilder {
+	b.runtimeProfilingMaxDuration = maxDuration
+	return b
+}
+
+// WithTracing enables the export of traces to the OTLP collector of the tracing configuration. The clients of the
+// controller context are instrumented, so that the API calls made in the context of a traced sync are its child spans.
+func (b *ControllerBuilder) WithTracing(tracingConfig tracingapiv1.TracingConfiguration) *ControllerBuilder {
+	b.tracingConfig = &tracingConfig
+	return b
+}
+
 // WithKubeConfigFile sets an optional kubeconfig file. inclusterconfig will be used if filename is empty
 func (b *ControllerBuilder) WithKubeConfigFile(kubeConfigFilename string, defaults *client.ClientConnectionOverrides) *ControllerBuilder {
 	b.kubeAPIServerConfigFile = &kubeConfigFilename
@@ -280,11 +336,20 @@ func (b *ControllerBuilder) Run(ctx context.Context, config *unstructured.Unstru
 			serverConfig.Authorization.Authorizer,
 		)
 		serverConfig.HealthzChecks = append(serverConfig.HealthzChecks, b.healthChecks...)
+		if b.runtimeProfilingMaxDuration > 0 {
+			serverConfig.EnableProfiling = false
+			serverConfig.EnableContentionProfiling = false
+		}
 
 		server, err = serverConfig.Complete(nil).New(b.componentName, genericapiserver.NewEmptyDelegate())
 		if err != nil {
 			return err
 		}
+		if b.runtimeProfilingMaxDuration > 0 {
+			profiler := serviceability.NewRuntimeProfiler(b.runtimeProfilingMaxDuration)
+			server.Handler.NonGoRestfulMux.Handle(serviceability.RuntimeProfilingPath, profiler)
+			server.Handler.NonGoRestfulMux.HandlePrefix(serviceability.RuntimeProfilerPprofPath, profiler)
+		}
 
 		go func() {
 			if err := server.PrepareRun().Run(ctx.Done()); err != nil {
@@ -294,6 +359,22 @@ func (b *ControllerBuilder) Run(ctx context.Context, config *unstructured.Unstru
 		}()
 	}
 
+	tracerProvider, err := tracing.NewProvider(ctx, b.tracingConfig, nil, []resource.Option{
+		resource.WithAttributes(semconv.ServiceNameKey.String(b.componentName)),
+	})
+	if err != nil {
+		return err
+	}
+	if b.tracingConfig != nil {
+		clientConfig.Wrap(tracing.WrapperFor(tracerProvider))
+		go func() {
+			<-ctx.Done()
+			if err := tracerProvider.Shutdown(context.Background()); err != nil {
+				klog.Warningf("failed to flush the traces: %v", err)
+			}
+		}()
+	}
+
 	protoConfig := rest.CopyConfig(clientConfig)
 	protoConfig.AcceptContentTypes = "application/vnd.kubernetes.protobuf,application/json"
 	protoConfig.ContentType = "application/vnd.kubernetes.protobuf"
@@ -302,13 +383,16 @@ func (b *ControllerBuilder) Run(ctx context.Context, config *unstructured.Unstru
 		ComponentConfig:   config,
 		KubeConfig:        clientConfig,
 		ProtoKubeConfig:   protoConfig,
+		ClientBuilder:     client.NewControllerClientBuilder(clientConfig).WithRequestMetrics(),
+		TracerProvider:    tracerProvider,
 		EventRecorder:     eventRecorder,
 		Server:            server,
 		OperatorNamespace: namespace,
+		Runner:            factory.NewRunner(controllerStartupPollInterval),
 	}
 
 	if b.leaderElection == nil {
-		if err := b.startFunc(ctx, controllerContext); err != nil {
+		if err := b.start(ctx, controllerContext); err != nil {
 			return err
 		}
 		return nil
@@ -320,8 +404,8 @@ func (b *ControllerBuilder) Run(ctx context.Context, config *unstructured.Unstru
 			eventRecorder.Warningf("ClusterInfrastructureStatus", "unable to get cluster infrastructure status, using HA cluster values for leader election: %v", err)
 			klog.Warningf("unable to get cluster infrastructure status, using HA cluster values for leader election: %v", err)
 		} else {
-			snoLeaderElection := infraStatusTopologyLeaderElection(infraStatus, *b.leaderElection)
-			b.leaderElection = &snoLeaderElection
+			topologyLeaderElection := infraStatusTopologyLeaderElection(infraStatus, *b.leaderElection, b.leaderElectionFastFailover)
+			b.leaderElection = &topologyLeaderElection
 		}
 	}
 
@@ -337,10 +421,23 @@ func (b *ControllerBuilder) Run(ctx context.Context, config *unstructured.Unstru
 	// 10s is the graceful termination time we give the controllers to finish their workers.
 	// when this time pass, we exit with non-zero code, killing all controller workers.
 	// NOTE: The pod must set the termination graceful time.
-	leaderElection.Callbacks.OnStartedLeading = b.getOnStartedLeadingFunc(controllerContext, 10*time.Second)
+	onStartedLeading := b.getOnStartedLeadingFunc(controllerContext, 10*time.Second)
+
+	// the lease is released once the controllers stopped, so that the next leader takes over in seconds during
+	// rolling updates instead of waiting for the lease to expire.
+	return leaderelectionconverter.RunWithHandover(ctx, leaderElection, onStartedLeading, leaderElectionHandoverTimeout)
+}
 
-	leaderelection.RunOrDie(ctx, leaderElection)
-	return nil
+// start calls the StartFunc, then runs the controllers it added to the runner of the controller context until they
+// are finished.
+func (b ControllerBuilder) start(ctx context.Context, controllerContext *ControllerContext) error {
+	if err := b.startFunc(ctx, controllerContext); err != nil {
+		return err
+	}
+	if controllerContext.Runner == nil {
+		return nil
+	}
+	return controllerContext.Runner.Run(ctx)
 }
 
 func (b ControllerBuilder) getOnStartedLeadingFunc(controllerContext *ControllerContext, gracefulTerminationDuration time.Duration) func(ctx context.Context) {
@@ -348,7 +445,7 @@ func (b ControllerBuilder) getOnStartedLeadingFunc(controllerContext *Controller
 		stoppedCh := make(chan struct{})
 		go func() {
 			defer close(stoppedCh)
-			if err := b.startFunc(ctx, controllerContext); err != nil {
+			if err := b.start(ctx, controllerContext); err != nil {
 				b.nonZeroExitFn(fmt.Sprintf("graceful termination failed, controllers failed with error: %v", err))
 			}
 		}()
@@ -392,16 +489,18 @@ func (b *ControllerBuilder) getClientConfig() (*rest.Config, error) {
 	return client.GetKubeConfigOrInClusterConfig(kubeconfig, b.clientOverrides)
 }
 
-func infraStatusTopologyLeaderElection(infraStatus *configv1.InfrastructureStatus, original configv1.LeaderElection) configv1.LeaderElection {
+func infraStatusTopologyLeaderElection(infraStatus *configv1.InfrastructureStatus, original configv1.LeaderElection, fastFailover bool) configv1.LeaderElection {
 	// if we can't determine the infra toplogy, return original
-	if infraStatus == nil {
+	if infraStatus == nil || original.Disable {
 		return original
 	}
 
-	// If we are running in a SingleReplicaTopologyMode and leader election is not disabled, configure leader election for SNO Toplogy
-	if infraStatus.ControlPlaneTopology == configv1.SingleReplicaTopologyMode && !original.Disable {
-		klog.Info("detected SingleReplicaTopologyMode, the original leader election has been altered for the default SingleReplicaToplogy")
-		return leaderelectionconverter.LeaderElectionSNOConfig(original)
+	selection := leaderelectionconverter.SelectLeaderElectionProfile(infraStatus, fastFailover)
+	switch selection.Profile.Name {
+	case leaderelectionconverter.SingleReplicaLeaderElectionProfile.Name, leaderelectionconverter.FastFailoverLeaderElectionProfile.Name:
+		klog.Infof("the original leader election has been altered for the %s profile because %s", selection.Profile, selection.Reason)
+		return selection.Profile.Apply(original)
 	}
+	klog.Infof("using the original leader election for the %s profile because %s", selection.Profile.Name, selection.Reason)
 	return original
 }
@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 func TestControllerBuilder_getOnStartedLeadingFunc(t *testing.T) {
@@ -103,6 +106,93 @@ func TestControllerBuilder_GracefulShutdown(t *testing.T) {
 	}
 }
 
+func TestControllerBuilder_OnLeadingFunc_Runner(t *testing.T) {
+	nonZeroExitCh := make(chan struct{})
+	var lock sync.Mutex
+	var started []string
+	ready := false
+
+	newController := func(name string) factory.Controller {
+		return factory.New().WithPostStartHooks(func(ctx context.Context, syncCtx factory.SyncContext) error {
+			lock.Lock()
+			defer lock.Unlock()
+			started = append(started, name)
+			syncCtx.Queue().Add(factory.DefaultQueueKey)
+			return nil
+		}).WithSync(func(ctx context.Context, syncCtx factory.SyncContext) error {
+			return nil
+		}).ToController(name, eventstesting.NewTestingEventRecorder(t))
+	}
+	b := &ControllerBuilder{
+		nonZeroExitFn: func(args ...interface{}) {
+			t.Logf("non-zero exit detected: %+v", args)
+			close(nonZeroExitCh)
+		},
+		startFunc: func(ctx context.Context, controllerContext *ControllerContext) error {
+			// the start function returns once the controllers are added, the runner runs them
+			controllerContext.Runner.
+				Add(newController("operand"), 1, factory.ControllerDependencies{Controllers: []string{"config"}}).
+				Add(newController("config"), 1, factory.ControllerDependencies{Preconditions: []factory.Precondition{{
+					Name: "config ready",
+					Check: func(context.Context) (bool, error) {
+						lock.Lock()
+						defer lock.Unlock()
+						return ready, nil
+					},
+				}}})
+			return nil
+		},
+	}
+	controllerContext := &ControllerContext{
+		EventRecorder: eventstesting.NewTestingEventRecorder(t),
+		Runner:        factory.NewRunner(10 * time.Millisecond),
+	}
+
+	ctx, shutdown := context.WithCancel(context.Background())
+	defer shutdown()
+	stoppedCh := make(chan struct{})
+	go func() {
+		defer close(stoppedCh)
+		b.getOnStartedLeadingFunc(controllerContext, 10*time.Second)(ctx)
+	}()
+
+	// the operand controller waits for the config controller, which waits for its precondition
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		blocked := controllerContext.Runner.Blocked()
+		return blocked["config"] == "precondition config ready" && blocked["operand"] == "controller config to start", nil
+	}); err != nil {
+		t.Fatalf("unexpected blocked controllers %v", controllerContext.Runner.Blocked())
+	}
+	lock.Lock()
+	if len(started) > 0 {
+		t.Errorf("expected no controller to be started, got %v", started)
+	}
+	ready = true
+	lock.Unlock()
+
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(started) == 2, nil
+	}); err != nil {
+		t.Fatalf("expected both controllers to be started, got %v", started)
+	}
+	lock.Lock()
+	if !reflect.DeepEqual(started, []string{"config", "operand"}) {
+		t.Errorf("expected the controllers to be started in dependency order, got %v", started)
+	}
+	lock.Unlock()
+
+	shutdown()
+	select {
+	case <-nonZeroExitCh:
+		t.Fatal("unexpected non-zero shutdown")
+	case <-stoppedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("unexpected timeout while terminating")
+	}
+}
+
 func TestControllerBuilder_OnLeadingFunc_ControllerError(t *testing.T) {
 	startedCh := make(chan struct{})
 	stoppedCh := make(chan struct{})
@@ -190,10 +280,11 @@ func TestControllerBuilder_OnLeadingFunc_NonZeroExit(t *testing.T) {
 
 func TestInfraStatusTopologyLeaderElection(t *testing.T) {
 	testCases := []struct {
-		desc     string
-		infra    *configv1.InfrastructureStatus
-		original configv1.LeaderElection
-		expected configv1.LeaderElection
+		desc         string
+		infra        *configv1.InfrastructureStatus
+		original     configv1.LeaderElection
+		fastFailover bool
+		expected     configv1.LeaderElection
 	}{
 		{
 			desc:  "should not set SNO config when infra is nil",
@@ -241,10 +332,52 @@ func TestInfraStatusTopologyLeaderElection(t *testing.T) {
 				RetryPeriod:   metav1.Duration{Duration: 60 * time.Second},
 			},
 		},
+		{
+			desc: "should set fast failover leader election config when requested on HighlyAvailableTopologyMode",
+			infra: &configv1.InfrastructureStatus{
+				ControlPlaneTopology: configv1.HighlyAvailableTopologyMode,
+			},
+			original: configv1.LeaderElection{
+				LeaseDuration: metav1.Duration{Duration: 137 * time.Second},
+				RenewDeadline: metav1.Duration{Duration: 107 * time.Second},
+				RetryPeriod:   metav1.Duration{Duration: 26 * time.Second},
+			},
+			fastFailover: true,
+			expected: configv1.LeaderElection{
+				LeaseDuration: metav1.Duration{Duration: 60 * time.Second},
+				RenewDeadline: metav1.Duration{Duration: 45 * time.Second},
+				RetryPeriod:   metav1.Duration{Duration: 10 * time.Second},
+			},
+		},
+		{
+			desc: "should set SNO leader election config when fast failover is requested on SingleReplicaToplogy Controlplane",
+			infra: &configv1.InfrastructureStatus{
+				ControlPlaneTopology: configv1.SingleReplicaTopologyMode,
+			},
+			original: configv1.LeaderElection{
+				LeaseDuration: metav1.Duration{Duration: 137 * time.Second},
+				RenewDeadline: metav1.Duration{Duration: 107 * time.Second},
+				RetryPeriod:   metav1.Duration{Duration: 26 * time.Second},
+			},
+			fastFailover: true,
+			expected: configv1.LeaderElection{
+				LeaseDuration: metav1.Duration{Duration: 270 * time.Second},
+				RenewDeadline: metav1.Duration{Duration: 240 * time.Second},
+				RetryPeriod:   metav1.Duration{Duration: 60 * time.Second},
+			},
+		},
+		{
+			desc: "should not set SNO config when leader election is disabled",
+			infra: &configv1.InfrastructureStatus{
+				ControlPlaneTopology: configv1.SingleReplicaTopologyMode,
+			},
+			original: configv1.LeaderElection{Disable: true},
+			expected: configv1.LeaderElection{Disable: true},
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.desc, func(t *testing.T) {
-			leConfig := infraStatusTopologyLeaderElection(tc.infra, tc.original)
+			leConfig := infraStatusTopologyLeaderElection(tc.infra, tc.original, tc.fastFailover)
 			if !reflect.DeepEqual(tc.expected, leConfig) {
 				t.Errorf("expected %#v, got %#v", tc.expected, leConfig)
 			}
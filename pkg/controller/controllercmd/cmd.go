@@ -45,6 +45,11 @@ type ControllerCommandConfig struct {
 	// DisableServing disables serving metrics, debug and health checks and so on.
 	DisableServing bool
 
+	// RuntimeProfilingMaxDuration enables the profiling of the running controller through the /debug/profiling endpoint
+	// of the server for at most this duration at a time, instead of always serving the pprof endpoints. Zero keeps the
+	// pprof endpoints always enabled.
+	RuntimeProfilingMaxDuration time.Duration
+
 	// DisableLeaderElection allows leader election to be suspended
 	DisableLeaderElection bool
 
@@ -313,7 +318,8 @@ func (c *ControllerCommandConfig) StartController(ctx context.Context) error {
 		WithComponentOwnerReference(c.ComponentOwnerReference)
 
 	if !c.DisableServing {
-		builder = builder.WithServer(config.ServingInfo, config.Authentication, config.Authorization)
+		builder = builder.WithServer(config.ServingInfo, config.Authentication, config.Authorization).
+			WithRuntimeProfiling(c.RuntimeProfilingMaxDuration)
 	}
 
 	return builder.Run(controllerCtx, unstructuredConfig)
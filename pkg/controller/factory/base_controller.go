@@ -5,9 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/robfig/cron"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -16,6 +20,7 @@ import (
 	"k8s.io/klog/v2"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/management"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 	operatorv1helpers "github.com/openshift/library-go/pkg/operator/v1helpers"
@@ -27,6 +32,9 @@ var SyntheticRequeueError = errors.New("synthetic requeue request")
 
 var defaultCacheSyncTimeout = 10 * time.Minute
 
+// tracerName is the name of the tracer of the controller syncs.
+const tracerName = "github.com/openshift/library-go/pkg/controller/factory"
+
 // baseController represents generic Kubernetes controller boiler-plate
 type baseController struct {
 	name               string
@@ -38,14 +46,25 @@ type baseController struct {
 	resyncSchedules    []cron.Schedule
 	postStartHooks     []PostStartHook
 	cacheSyncTimeout   time.Duration
+	// tracer traces the syncs when set
+	tracer trace.Tracer
+	// synced is set once a sync succeeded
+	synced atomic.Bool
+	// panicHandlers are called with the panics of the controller after degradedPanicHandler
+	panicHandlers []func(interface{})
 }
 
 var _ Controller = &baseController{}
 
-func (c baseController) Name() string {
+func (c *baseController) Name() string {
 	return c.name
 }
 
+// HasSynced returns whether a sync of the controller succeeded, so that the controllers depending on it can start.
+func (c *baseController) HasSynced() bool {
+	return c.synced.Load()
+}
+
 type scheduledJob struct {
 	queue workqueue.RateLimitingInterface
 	name  string
@@ -77,7 +96,7 @@ func waitForNamedCacheSync(controllerName string, stopCh <-chan struct{}, cacheS
 
 func (c *baseController) Run(ctx context.Context, workers int) {
 	// HandleCrash recovers panics
-	defer utilruntime.HandleCrash(c.degradedPanicHandler)
+	defer utilruntime.HandleCrash(c.handlePanic)
 
 	// give caches 10 minutes to sync
 	cacheSyncCtx, cacheSyncCancel := context.WithTimeout(ctx, c.cacheSyncTimeout)
@@ -183,7 +202,7 @@ func (c *baseController) runWorker(queueCtx context.Context) {
 	wait.UntilWithContext(
 		queueCtx,
 		func(queueCtx context.Context) {
-			defer utilruntime.HandleCrash(c.degradedPanicHandler)
+			defer utilruntime.HandleCrash(c.handlePanic)
 			for {
 				select {
 				case <-queueCtx.Done():
@@ -207,6 +226,14 @@ func (c *baseController) reconcile(ctx context.Context, syncCtx SyncContext) err
 	return degradedErr
 }
 
+// handlePanic calls the panic handlers of the controller.
+func (c *baseController) handlePanic(panicVal interface{}) {
+	c.degradedPanicHandler(panicVal)
+	for _, handler := range c.panicHandlers {
+		handler(panicVal)
+	}
+}
+
 // degradedPanicHandler will go degraded on failures, then we should catch potential panics and covert them into bad status.
 func (c *baseController) degradedPanicHandler(panicVal interface{}) {
 	if c.syncDegradedClient == nil {
@@ -242,10 +269,11 @@ func (c *baseController) reportDegraded(ctx context.Context, reportedError error
 	return updateErr
 }
 
-func (c *baseController) processNextWorkItem(queueCtx context.Context) {
+// processNextWorkItem syncs the next key of the queue and returns the error of the sync, if any.
+func (c *baseController) processNextWorkItem(queueCtx context.Context) error {
 	key, quit := c.syncContext.Queue().Get()
 	if quit {
-		return
+		return nil
 	}
 	defer c.syncContext.Queue().Done(key)
 
@@ -253,11 +281,12 @@ func (c *baseController) processNextWorkItem(queueCtx context.Context) {
 	var ok bool
 	syncCtx.queueKey, ok = key.(string)
 	if !ok {
-		utilruntime.HandleError(fmt.Errorf("%q controller failed to process key %q (not a string)", c.name, key))
-		return
+		err := fmt.Errorf("%q controller failed to process key %q (not a string)", c.name, key)
+		utilruntime.HandleError(err)
+		return err
 	}
 
-	if err := c.reconcile(queueCtx, syncCtx); err != nil {
+	if err := c.tracedReconcile(queueCtx, syncCtx); err != nil {
 		if err == SyntheticRequeueError {
 			// logging this helps detecting wedged controllers with missing pre-requirements
 			klog.V(5).Infof("%q controller requested synthetic requeue with key %q", c.name, key)
@@ -269,8 +298,38 @@ func (c *baseController) processNextWorkItem(queueCtx context.Context) {
 			}
 		}
 		c.syncContext.Queue().AddRateLimited(key)
-		return
+		return err
 	}
 
+	c.synced.Store(true)
 	c.syncContext.Queue().Forget(key)
+	return nil
+}
+
+// tracedReconcile wraps the reconcile() call in a span when the tracing is enabled, adding the events recorded during
+// the sync to the span.
+func (c *baseController) tracedReconcile(ctx context.Context, syncCtx syncContext) error {
+	if c.tracer == nil {
+		return c.reconcile(ctx, syncCtx)
+	}
+
+	ctx, span := c.tracer.Start(ctx, c.name+".sync", trace.WithAttributes(
+		attribute.String("controller", c.name),
+		attribute.String("key", syncCtx.queueKey),
+	))
+	defer span.End()
+	syncCtx.eventRecorder = events.NewSpanRecorder(syncCtx.eventRecorder, span)
+
+	err := c.reconcile(ctx, syncCtx)
+	switch {
+	case err == nil:
+		span.SetAttributes(attribute.String("outcome", "success"))
+	case err == SyntheticRequeueError:
+		span.SetAttributes(attribute.String("outcome", "requeue"))
+	default:
+		span.SetAttributes(attribute.String("outcome", "error"))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
 }
@@ -3,13 +3,21 @@ package factory
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/tracing"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
@@ -184,3 +192,137 @@ func TestBaseController_Run(t *testing.T) {
 		t.Errorf("expected the post start hook to be terminated when context is cancelled")
 	}
 }
+
+func TestBaseController_PanicHandlers(t *testing.T) {
+	reallyCrash := utilruntime.ReallyCrash
+	defer func() { utilruntime.ReallyCrash = reallyCrash }()
+	utilruntime.ReallyCrash = false
+
+	operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{}, &operatorv1.OperatorStatus{}, nil)
+	panics := make(chan interface{}, 1)
+	c := New().WithSync(func(ctx context.Context, syncCtx SyncContext) error {
+		panic("sync panic")
+	}).ResyncEvery(100*time.Millisecond).WithSyncDegradedOnError(operatorClient).WithPanicHandlers(func(panicVal interface{}) {
+		select {
+		case panics <- panicVal:
+		default:
+		}
+	}).ToController("test", eventstesting.NewTestingEventRecorder(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Run(ctx, 1)
+	}()
+	// the workers must be stopped before ReallyCrash is restored
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	select {
+	case panicVal := <-panics:
+		if panicVal != "sync panic" {
+			t.Errorf("unexpected panic %v", panicVal)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the panic handler to be called")
+	}
+	// the controller went degraded before the handlers were called
+	_, status, _, _ := operatorClient.GetOperatorState()
+	if cond := v1helpers.FindOperatorCondition(status.Conditions, "testDegraded"); cond == nil || cond.Status != operatorv1.ConditionTrue {
+		t.Errorf("expected the controller to be degraded, got %v", cond)
+	}
+}
+
+// recordingExporter keeps the exported spans in memory.
+type recordingExporter struct {
+	lock  sync.Mutex
+	spans []sdktrace.ReadOnlySpan
+}
+
+func (e *recordingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *recordingExporter) Shutdown(ctx context.Context) error { return nil }
+
+func TestBaseController_Tracing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	exporter := &recordingExporter{}
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	apiClient := &http.Client{Transport: tracing.WrapperFor(tracerProvider)(http.DefaultTransport)}
+
+	testCases := []struct {
+		name            string
+		syncErr         error
+		expectedOutcome string
+		expectedStatus  codes.Code
+	}{
+		{name: "success", expectedOutcome: "success", expectedStatus: codes.Unset},
+		{name: "requeue", syncErr: SyntheticRequeueError, expectedOutcome: "requeue", expectedStatus: codes.Unset},
+		{name: "error", syncErr: fmt.Errorf("sync failed"), expectedOutcome: "error", expectedStatus: codes.Error},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			exporter.spans = nil
+			controller := New().
+				WithSync(func(ctx context.Context, syncCtx SyncContext) error {
+					syncCtx.Recorder().Warningf("TestWarning", "warning about %s", syncCtx.QueueKey())
+					req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+					if err != nil {
+						return err
+					}
+					resp, err := apiClient.Do(req)
+					if err != nil {
+						return err
+					}
+					resp.Body.Close()
+					return tc.syncErr
+				}).
+				WithTracerProvider(tracerProvider).
+				ToController("TestController", eventstesting.NewTestingEventRecorder(t)).(*baseController)
+			controller.syncContext.Queue().Add("test-key")
+			controller.processNextWorkItem(context.TODO())
+
+			if len(exporter.spans) != 2 {
+				t.Fatalf("expected the spans of the API call and the sync, got %d", len(exporter.spans))
+			}
+			apiCall, sync := exporter.spans[0], exporter.spans[1]
+			if sync.Name() != "TestController.sync" {
+				t.Errorf("unexpected sync span %q", sync.Name())
+			}
+			if apiCall.Parent().SpanID() != sync.SpanContext().SpanID() {
+				t.Errorf("expected the API call to be a child span of the sync")
+			}
+			attributes := map[attribute.Key]string{}
+			for _, kv := range sync.Attributes() {
+				attributes[kv.Key] = kv.Value.AsString()
+			}
+			expectedAttributes := map[attribute.Key]string{"controller": "TestController", "key": "test-key", "outcome": tc.expectedOutcome}
+			if !reflect.DeepEqual(attributes, expectedAttributes) {
+				t.Errorf("expected attributes %v, got %v", expectedAttributes, attributes)
+			}
+			if sync.Status().Code != tc.expectedStatus {
+				t.Errorf("expected status %v, got %v", tc.expectedStatus, sync.Status())
+			}
+			var reasons []string
+			for _, event := range sync.Events() {
+				for _, kv := range event.Attributes {
+					if kv.Key == "event.reason" {
+						reasons = append(reasons, kv.Value.AsString())
+					}
+				}
+			}
+			if !reflect.DeepEqual(reasons, []string{"TestWarning"}) {
+				t.Errorf("expected the recorded event as span event, got %v", sync.Events())
+			}
+		})
+	}
+}
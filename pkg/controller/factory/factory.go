@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/robfig/cron"
+	"go.opentelemetry.io/otel/trace"
 	"k8s.io/apimachinery/pkg/runtime"
 	errorutil "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -38,6 +39,8 @@ type Factory struct {
 	namespaceInformers    []*namespaceInformer
 	cachesToSync          []cache.InformerSynced
 	interestingNamespaces sets.String
+	tracerProvider        trace.TracerProvider
+	panicHandlers         []func(interface{})
 }
 
 // Informer represents any structure that allow to register event handlers and informs if caches are synced.
@@ -239,6 +242,23 @@ func (f *Factory) WithSyncDegradedOnError(operatorClient operatorv1helpers.Opera
 	return f
 }
 
+// WithTracerProvider enables the tracing of the syncs of the controller: each sync is a span with the controller name,
+// the queue key and the outcome of the sync, the events recorded during the sync are added to it as span events, and
+// the API calls made with the context of the sync through instrumented clients (see tracing.WrapperFor) are its child
+// spans.
+func (f *Factory) WithTracerProvider(tracerProvider trace.TracerProvider) *Factory {
+	f.tracerProvider = tracerProvider
+	return f
+}
+
+// WithPanicHandlers adds handlers called with the panics caught in the controller, after it went degraded (see
+// WithSyncDegradedOnError), e.g. to report them with serviceability.CrashReportingPanicHandler. Unlike
+// utilruntime.PanicHandlers, they only see the panics of this controller.
+func (f *Factory) WithPanicHandlers(handlers ...func(interface{})) *Factory {
+	f.panicHandlers = append(f.panicHandlers, handlers...)
+	return f
+}
+
 // Controller produce a runnable controller.
 func (f *Factory) ToController(name string, eventRecorder events.Recorder) Controller {
 	if f.sync == nil {
@@ -251,7 +271,10 @@ func (f *Factory) ToController(name string, eventRecorder events.Recorder) Contr
 	} else {
 		ctx = NewSyncContext(name, eventRecorder)
 	}
+	return f.toController(name, ctx)
+}
 
+func (f *Factory) toController(name string, ctx SyncContext) *baseController {
 	var cronSchedules []cron.Schedule
 	if len(f.resyncSchedules) > 0 {
 		var errors []error
@@ -277,6 +300,10 @@ func (f *Factory) ToController(name string, eventRecorder events.Recorder) Contr
 		syncContext:        ctx,
 		postStartHooks:     f.postStartHooks,
 		cacheSyncTimeout:   defaultCacheSyncTimeout,
+		panicHandlers:      f.panicHandlers,
+	}
+	if f.tracerProvider != nil {
+		c.tracer = f.tracerProvider.Tracer(tracerName)
 	}
 
 	for i := range f.informerQueueKeys {
@@ -0,0 +1,365 @@
+package factorytesting
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// maxHarnessSyncs bounds the syncs of a single ProcessQueue call, so that a controller requeueing its keys forever
+// fails the test instead of hanging it.
+const maxHarnessSyncs = 100
+
+// Harness runs the syncs of a controller deterministically for unit tests: there are no workers, no resync loops and
+// no cache sync. The events of the informers, e.g. FakeInformers, enqueue the keys of the controller like when it
+// runs, and ProcessQueue syncs them in the calling goroutine. The requeues after a failed sync, the keys added with a
+// delay and the periodical resyncs are driven by the fake clock of the harness, see Step.
+type Harness struct {
+	controller *factory.QueueDrivenController
+	name       string
+	queue      *harnessQueue
+	clock      *clocktesting.FakeClock
+
+	nextResync   time.Time
+	lastSchedule time.Time
+}
+
+// NewHarness produces a Harness running the syncs of the controller built by the factory instead of a runnable
+// controller. The sync context given by WithSyncContext is ignored, the harness has its own queue and fake clock. The
+// post start hooks are not run.
+func NewHarness(f *factory.Factory, name string, eventRecorder events.Recorder) *Harness {
+	clock := clocktesting.NewFakeClock(time.Now())
+	queue := newHarnessQueue(clock)
+	controller := f.ToQueueDrivenController(name, eventRecorder, queue)
+	return &Harness{
+		controller:   controller,
+		name:         name,
+		queue:        queue,
+		clock:        clock,
+		nextResync:   clock.Now().Add(controller.ResyncEvery()),
+		lastSchedule: clock.Now(),
+	}
+}
+
+// Clock returns the fake clock of the harness, e.g. to inject it in the controller under test.
+func (h *Harness) Clock() *clocktesting.FakeClock {
+	return h.clock
+}
+
+// Controller returns the controller under test.
+func (h *Harness) Controller() factory.Controller {
+	return h.controller.Controller()
+}
+
+// Enqueue adds the keys to the queue of the controller, e.g. factory.DefaultQueueKey to trigger a sync like a resync does.
+func (h *Harness) Enqueue(keys ...string) {
+	for _, key := range keys {
+		h.queue.Add(key)
+	}
+}
+
+// Step advances the fake clock, which enqueues the keys whose delay is over and the resyncs which are due.
+func (h *Harness) Step(d time.Duration) {
+	h.clock.Step(d)
+	now := h.clock.Now()
+
+	if resyncEvery := h.controller.ResyncEvery(); resyncEvery > 0 {
+		for !h.nextResync.After(now) {
+			h.queue.Add(factory.DefaultQueueKey)
+			h.nextResync = h.nextResync.Add(resyncEvery)
+		}
+	}
+	if h.controller.ScheduledResyncDue(h.lastSchedule, now) {
+		h.queue.Add(factory.DefaultQueueKey)
+	}
+	h.lastSchedule = now
+}
+
+// ProcessQueue syncs the keys of the queue until none is left, like the workers of the controller would, and returns
+// the errors of the syncs. The keys requeued after a failed sync are only synced again once the clock is stepped past
+// their delay.
+func (h *Harness) ProcessQueue(ctx context.Context) []error {
+	var errs []error
+	for syncs := 0; h.queue.Len() > 0; syncs++ {
+		if syncs == maxHarnessSyncs {
+			return append(errs, fmt.Errorf("%q controller did not settle after %d syncs", h.name, syncs))
+		}
+		if err := h.controller.ProcessNextWorkItem(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// harnessQueue is a workqueue without goroutines, whose delays are measured with the fake clock.
+type harnessQueue struct {
+	lock        sync.Mutex
+	clock       *clocktesting.FakeClock
+	rateLimiter workqueue.RateLimiter
+
+	queue      []interface{}
+	dirty      sets.String
+	processing sets.String
+	waiting    []delayedItem
+	shutDown   bool
+}
+
+type delayedItem struct {
+	item    interface{}
+	readyAt time.Time
+}
+
+var _ workqueue.RateLimitingInterface = &harnessQueue{}
+
+func newHarnessQueue(clock *clocktesting.FakeClock) *harnessQueue {
+	return &harnessQueue{
+		clock:       clock,
+		rateLimiter: workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 1000*time.Second),
+		dirty:       sets.NewString(),
+		processing:  sets.NewString(),
+	}
+}
+
+func (q *harnessQueue) Add(item interface{}) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.add(item)
+}
+
+func (q *harnessQueue) add(item interface{}) {
+	key := fmt.Sprint(item)
+	if q.shutDown || q.dirty.Has(key) {
+		return
+	}
+	q.dirty.Insert(key)
+	if q.processing.Has(key) {
+		return
+	}
+	q.queue = append(q.queue, item)
+}
+
+// promote adds the delayed items which are ready.
+func (q *harnessQueue) promote() {
+	now := q.clock.Now()
+	waiting := q.waiting[:0]
+	for _, delayed := range q.waiting {
+		if delayed.readyAt.After(now) {
+			waiting = append(waiting, delayed)
+			continue
+		}
+		q.add(delayed.item)
+	}
+	q.waiting = waiting
+}
+
+func (q *harnessQueue) Len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.promote()
+	return len(q.queue)
+}
+
+// Get returns the next item, or shutdown when there is none as it must not block.
+func (q *harnessQueue) Get() (interface{}, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.promote()
+	if len(q.queue) == 0 {
+		return nil, true
+	}
+	item := q.queue[0]
+	q.queue = q.queue[1:]
+	key := fmt.Sprint(item)
+	q.processing.Insert(key)
+	q.dirty.Delete(key)
+	return item, false
+}
+
+func (q *harnessQueue) Done(item interface{}) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	key := fmt.Sprint(item)
+	q.processing.Delete(key)
+	if q.dirty.Has(key) {
+		q.queue = append(q.queue, item)
+	}
+}
+
+func (q *harnessQueue) ShutDown() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.shutDown = true
+}
+
+func (q *harnessQueue) ShutDownWithDrain() {
+	q.ShutDown()
+}
+
+func (q *harnessQueue) ShuttingDown() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.shutDown
+}
+
+func (q *harnessQueue) AddAfter(item interface{}, duration time.Duration) {
+	if duration <= 0 {
+		q.Add(item)
+		return
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.waiting = append(q.waiting, delayedItem{item: item, readyAt: q.clock.Now().Add(duration)})
+}
+
+func (q *harnessQueue) AddRateLimited(item interface{}) {
+	q.AddAfter(item, q.rateLimiter.When(item))
+}
+
+func (q *harnessQueue) Forget(item interface{}) {
+	q.rateLimiter.Forget(item)
+}
+
+func (q *harnessQueue) NumRequeues(item interface{}) int {
+	return q.rateLimiter.NumRequeues(item)
+}
+
+// FakeInformer is an Informer backed by an indexer, which delivers its events to the handlers synchronously, for the
+// Harness. Its indexer can back the listers of the controller under test.
+type FakeInformer struct {
+	lock     sync.Mutex
+	indexer  cache.Indexer
+	handlers []cache.ResourceEventHandler
+}
+
+var _ factory.Informer = &FakeInformer{}
+
+// NewFakeInformer returns a FakeInformer holding the objects.
+func NewFakeInformer(objects ...runtime.Object) *FakeInformer {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, obj := range objects {
+		if err := indexer.Add(obj); err != nil {
+			panic(err)
+		}
+	}
+	return &FakeInformer{indexer: indexer}
+}
+
+// Indexer returns the indexer of the informer, e.g. for corev1listers.NewSecretLister.
+func (i *FakeInformer) Indexer() cache.Indexer {
+	return i.indexer
+}
+
+// AddEventHandler registers the handler, which is given an add event for each object held, like a shared informer does.
+func (i *FakeInformer) AddEventHandler(handler cache.ResourceEventHandler) (cache.ResourceEventHandlerRegistration, error) {
+	i.lock.Lock()
+	i.handlers = append(i.handlers, handler)
+	i.lock.Unlock()
+	for _, obj := range i.indexer.List() {
+		handler.OnAdd(obj, true)
+	}
+	return fakeRegistration{}, nil
+}
+
+// HasSynced is always true.
+func (i *FakeInformer) HasSynced() bool {
+	return true
+}
+
+// Add adds the object and delivers the add event.
+func (i *FakeInformer) Add(obj runtime.Object) error {
+	if err := i.indexer.Add(obj); err != nil {
+		return err
+	}
+	for _, handler := range i.eventHandlers() {
+		handler.OnAdd(obj, false)
+	}
+	return nil
+}
+
+// Update updates the object and delivers the update event.
+func (i *FakeInformer) Update(obj runtime.Object) error {
+	old, exists, err := i.indexer.Get(obj)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("cannot update missing %v", obj)
+	}
+	if err := i.indexer.Update(obj); err != nil {
+		return err
+	}
+	for _, handler := range i.eventHandlers() {
+		handler.OnUpdate(old, obj)
+	}
+	return nil
+}
+
+// Delete deletes the object and delivers the delete event.
+func (i *FakeInformer) Delete(obj runtime.Object) error {
+	if err := i.indexer.Delete(obj); err != nil {
+		return err
+	}
+	for _, handler := range i.eventHandlers() {
+		handler.OnDelete(obj)
+	}
+	return nil
+}
+
+func (i *FakeInformer) eventHandlers() []cache.ResourceEventHandler {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return append([]cache.ResourceEventHandler{}, i.handlers...)
+}
+
+type fakeRegistration struct{}
+
+func (fakeRegistration) HasSynced() bool {
+	return true
+}
+
+// CheckCondition returns an error unless the operator status has the condition with the status and, when not empty,
+// the reason, e.g. to assert the conditions set by the syncs run by a Harness.
+func CheckCondition(operatorClient v1helpers.OperatorClient, conditionType string, status operatorv1.ConditionStatus, reason string) error {
+	_, operatorStatus, _, err := operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	condition := v1helpers.FindOperatorCondition(operatorStatus.Conditions, conditionType)
+	switch {
+	case condition == nil:
+		return fmt.Errorf("condition %s is not set", conditionType)
+	case condition.Status != status:
+		return fmt.Errorf("condition %s is %s, expected %s: %s", conditionType, condition.Status, status, condition.Message)
+	case len(reason) > 0 && condition.Reason != reason:
+		return fmt.Errorf("condition %s has reason %s, expected %s: %s", conditionType, condition.Reason, reason, condition.Message)
+	}
+	return nil
+}
+
+// WriteActions returns the actions of a fake client which write to the API, i.e. all but the gets, lists and watches,
+// e.g. to assert the writes of the syncs run by a Harness.
+func WriteActions(actions []clienttesting.Action) []clienttesting.Action {
+	var ret []clienttesting.Action
+	for _, action := range actions {
+		switch action.GetVerb() {
+		case "get", "list", "watch":
+			continue
+		}
+		ret = append(ret, action)
+	}
+	return ret
+}
@@ -0,0 +1,105 @@
+package factorytesting
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func TestHarness(t *testing.T) {
+	configMap := func(name string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}}
+	}
+	informer := NewFakeInformer(configMap("existing"))
+	lister := corev1listers.NewConfigMapLister(informer.Indexer())
+	client := kubefake.NewSimpleClientset()
+	operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+
+	var synced []string
+	failing := true
+	h := NewHarness(factory.New().
+		WithInformersQueueKeysFunc(func(obj runtime.Object) []string {
+			return []string{obj.(*corev1.ConfigMap).Name}
+		}, informer).
+		ResyncEvery(time.Minute).
+		WithSyncDegradedOnError(operatorClient).
+		WithSync(func(ctx context.Context, syncCtx factory.SyncContext) error {
+			synced = append(synced, syncCtx.QueueKey())
+			if syncCtx.QueueKey() == "failing" && failing {
+				return fmt.Errorf("failed")
+			}
+			if _, err := lister.ConfigMaps("ns").Get(syncCtx.QueueKey()); err != nil {
+				return nil
+			}
+			_, err := client.CoreV1().Secrets("ns").Create(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: syncCtx.QueueKey()}}, metav1.CreateOptions{})
+			return err
+		}), "TestController", events.NewInMemoryRecorder("test"))
+
+	expectSynced := func(t *testing.T, expected ...string) {
+		t.Helper()
+		if !reflect.DeepEqual(synced, expected) {
+			t.Errorf("expected the syncs of %v, got %v", expected, synced)
+		}
+		synced = nil
+	}
+
+	if errs := h.ProcessQueue(context.TODO()); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	expectSynced(t, "existing")
+	if err := CheckCondition(operatorClient, "TestControllerDegraded", operatorv1.ConditionFalse, "AsExpected"); err != nil {
+		t.Error(err)
+	}
+	if writes := WriteActions(client.Actions()); len(writes) != 1 || !writes[0].Matches("create", "secrets") {
+		t.Errorf("expected the secret to be created, got %v", writes)
+	}
+
+	if err := informer.Add(configMap("failing")); err != nil {
+		t.Fatal(err)
+	}
+	if errs := h.ProcessQueue(context.TODO()); len(errs) != 1 {
+		t.Fatalf("expected the sync to fail, got %v", errs)
+	}
+	expectSynced(t, "failing")
+	if err := CheckCondition(operatorClient, "TestControllerDegraded", operatorv1.ConditionTrue, "SyncError"); err != nil {
+		t.Error(err)
+	}
+
+	// the failed key is only retried after its delay
+	if errs := h.ProcessQueue(context.TODO()); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	expectSynced(t)
+	failing = false
+	h.Step(time.Second)
+	if errs := h.ProcessQueue(context.TODO()); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	expectSynced(t, "failing")
+	if err := CheckCondition(operatorClient, "TestControllerDegraded", operatorv1.ConditionFalse, ""); err != nil {
+		t.Error(err)
+	}
+
+	if err := informer.Delete(configMap("existing")); err != nil {
+		t.Fatal(err)
+	}
+	h.Step(time.Minute)
+	if errs := h.ProcessQueue(context.TODO()); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	expectSynced(t, "existing", factory.DefaultQueueKey)
+}
@@ -0,0 +1,57 @@
+package factory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// QueueDrivenController is a controller whose queue is processed by its caller instead of workers, e.g. by the
+// factorytesting.Harness. There are no resync loops and no cache sync: the caller enqueues the resyncs which are due.
+type QueueDrivenController struct {
+	controller *baseController
+}
+
+// ToQueueDrivenController produces a QueueDrivenController with the queue of the syncs, instead of a runnable
+// controller. The sync context given by WithSyncContext is ignored. The post start hooks are not run.
+func (f *Factory) ToQueueDrivenController(name string, eventRecorder events.Recorder, queue workqueue.RateLimitingInterface) *QueueDrivenController {
+	if f.sync == nil {
+		panic(fmt.Errorf("WithSync() must be used before calling ToQueueDrivenController() in %q", name))
+	}
+	ctx := syncContext{
+		queue:         queue,
+		eventRecorder: eventRecorder.WithComponentSuffix(strings.ToLower(name)),
+	}
+	return &QueueDrivenController{controller: f.toController(name, ctx)}
+}
+
+// Controller returns the controller.
+func (c *QueueDrivenController) Controller() Controller {
+	return c.controller
+}
+
+// ProcessNextWorkItem syncs the next key of the queue like a worker of the controller would, and returns the error of
+// the sync, if any.
+func (c *QueueDrivenController) ProcessNextWorkItem(ctx context.Context) error {
+	return c.controller.processNextWorkItem(ctx)
+}
+
+// ResyncEvery returns the resync interval of the controller, zero when it does not resync periodically.
+func (c *QueueDrivenController) ResyncEvery() time.Duration {
+	return c.controller.resyncEvery
+}
+
+// ScheduledResyncDue returns whether a resync schedule of the controller fires after last and until now.
+func (c *QueueDrivenController) ScheduledResyncDue(last, now time.Time) bool {
+	for _, schedule := range c.controller.resyncSchedules {
+		if !schedule.Next(last).After(now) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,254 @@
+package factory
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	"k8s.io/klog/v2"
+)
+
+// PreconditionFunc returns whether a precondition of a controller is met. The error explains why it is not.
+type PreconditionFunc func(ctx context.Context) (bool, error)
+
+// Precondition is a condition a controller needs before it starts, e.g. a CRD being served or certificates being
+// present on disk.
+type Precondition struct {
+	Name  string
+	Check PreconditionFunc
+}
+
+// InformerSyncedPrecondition is met when the informer has synced.
+func InformerSyncedPrecondition(name string, informer Informer) Precondition {
+	return Precondition{
+		Name: fmt.Sprintf("informer %s synced", name),
+		Check: func(context.Context) (bool, error) {
+			return informer.HasSynced(), nil
+		},
+	}
+}
+
+// FilesExistPrecondition is met when all the files exist, e.g. the serving certificates of the controller.
+func FilesExistPrecondition(paths ...string) Precondition {
+	return Precondition{
+		Name: fmt.Sprintf("files %s exist", strings.Join(paths, ", ")),
+		Check: func(context.Context) (bool, error) {
+			for _, path := range paths {
+				if _, err := os.Stat(path); err != nil {
+					return false, err
+				}
+			}
+			return true, nil
+		},
+	}
+}
+
+// APIResourcePrecondition is met when the resource is served by the kube-apiserver, e.g. when the CRD of a custom
+// resource has been created and established.
+func APIResourcePrecondition(client discovery.DiscoveryInterface, resource schema.GroupVersionResource) Precondition {
+	return Precondition{
+		Name: fmt.Sprintf("resource %s served", resource),
+		Check: func(context.Context) (bool, error) {
+			resources, err := client.ServerResourcesForGroupVersion(resource.GroupVersion().String())
+			if err != nil {
+				return false, err
+			}
+			for _, apiResource := range resources.APIResources {
+				if apiResource.Name == resource.Resource {
+					return true, nil
+				}
+			}
+			return false, fmt.Errorf("%s is not served", resource)
+		},
+	}
+}
+
+// ControllerDependencies are what a controller needs before it starts.
+type ControllerDependencies struct {
+	// Controllers are the names of the controllers that must have synced successfully once, or be running for the
+	// controllers which do not report their syncs.
+	Controllers []string
+	// Preconditions must all be met.
+	Preconditions []Precondition
+}
+
+// syncedController is implemented by the controllers reporting whether they synced successfully once.
+type syncedController interface {
+	HasSynced() bool
+}
+
+type runnerEntry struct {
+	controller   Controller
+	workers      int
+	dependencies ControllerDependencies
+
+	// started and blockedBy are guarded by the lock of the runner
+	started   bool
+	blockedBy string
+}
+
+// Runner starts controllers in the order of their dependencies, instead of starting them all at once and having them
+// fail until what they need is available. Each controller is started once the controllers it depends on have synced
+// and its preconditions are met, and the dependency blocking its startup is logged and reported by Blocked. The
+// operators built with controllercmd get one in their controller context.
+type Runner struct {
+	pollInterval time.Duration
+
+	lock        sync.Mutex
+	controllers map[string]*runnerEntry
+	order       []string
+}
+
+// NewRunner creates a runner checking the dependencies of the controllers every pollInterval.
+func NewRunner(pollInterval time.Duration) *Runner {
+	return &Runner{
+		pollInterval: pollInterval,
+		controllers:  map[string]*runnerEntry{},
+	}
+}
+
+// Add adds a controller run with the number of workers once its dependencies are met.
+func (r *Runner) Add(controller Controller, workers int, dependencies ControllerDependencies) *Runner {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, exists := r.controllers[controller.Name()]; !exists {
+		r.order = append(r.order, controller.Name())
+	}
+	r.controllers[controller.Name()] = &runnerEntry{controller: controller, workers: workers, dependencies: dependencies}
+	return r
+}
+
+// Run starts the controllers in dependency order and blocks until they are all finished. It returns an error without
+// starting any controller when a dependency is unknown or the dependencies have a cycle.
+func (r *Runner) Run(ctx context.Context) error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, name := range r.order {
+		entry := r.controllers[name]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !r.waitForDependencies(ctx, entry) {
+				return
+			}
+			entry.controller.Run(ctx, entry.workers)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// Blocked returns the controllers which are not started yet, with the dependency blocking them.
+func (r *Runner) Blocked() map[string]string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	blocked := map[string]string{}
+	for name, entry := range r.controllers {
+		if !entry.started {
+			blocked[name] = entry.blockedBy
+		}
+	}
+	return blocked
+}
+
+// waitForDependencies waits until the dependencies of the controller are met and marks it started, or returns false
+// when the context is done.
+func (r *Runner) waitForDependencies(ctx context.Context, entry *runnerEntry) bool {
+	name := entry.controller.Name()
+	err := wait.PollImmediateUntilWithContext(ctx, r.pollInterval, func(ctx context.Context) (bool, error) {
+		blockedBy := r.blockingDependency(ctx, entry)
+
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		if blockedBy != entry.blockedBy && len(blockedBy) > 0 {
+			klog.Infof("Startup of the %s controller is waiting for %s", name, blockedBy)
+		}
+		entry.blockedBy = blockedBy
+		if len(blockedBy) > 0 {
+			return false, nil
+		}
+		entry.started = true
+		return true, nil
+	})
+	if err != nil {
+		return false
+	}
+	klog.Infof("Dependencies of the %s controller are met, starting it", name)
+	return true
+}
+
+// blockingDependency returns the first dependency of the controller which is not met, or an empty string.
+func (r *Runner) blockingDependency(ctx context.Context, entry *runnerEntry) string {
+	for _, dependency := range entry.dependencies.Controllers {
+		r.lock.Lock()
+		dependencyEntry := r.controllers[dependency]
+		started := dependencyEntry.started
+		r.lock.Unlock()
+
+		if !started {
+			return fmt.Sprintf("controller %s to start", dependency)
+		}
+		if synced, ok := dependencyEntry.controller.(syncedController); ok && !synced.HasSynced() {
+			return fmt.Sprintf("controller %s to sync", dependency)
+		}
+	}
+	for _, precondition := range entry.dependencies.Preconditions {
+		met, err := precondition.Check(ctx)
+		switch {
+		case err != nil:
+			return fmt.Sprintf("precondition %s: %v", precondition.Name, err)
+		case !met:
+			return fmt.Sprintf("precondition %s", precondition.Name)
+		}
+	}
+	return ""
+}
+
+// validate checks that the dependencies of the controllers exist and have no cycle.
+func (r *Runner) validate() error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := map[string]int{}
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch states[name] {
+		case visiting:
+			return fmt.Errorf("the controller dependencies have a cycle: %s", strings.Join(append(path, name), " -> "))
+		case visited:
+			return nil
+		}
+		states[name] = visiting
+		for _, dependency := range r.controllers[name].dependencies.Controllers {
+			if _, exists := r.controllers[dependency]; !exists {
+				return fmt.Errorf("the %s controller depends on the unknown %s controller", name, dependency)
+			}
+			if err := visit(dependency, append(path, name)); err != nil {
+				return err
+			}
+		}
+		states[name] = visited
+		return nil
+	}
+
+	names := append([]string{}, r.order...)
+	sort.Strings(names)
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
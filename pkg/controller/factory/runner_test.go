@@ -0,0 +1,156 @@
+package factory
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+)
+
+// startOrder records the order in which the controllers start.
+type startOrder struct {
+	lock    sync.Mutex
+	started []string
+}
+
+func (o *startOrder) add(name string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.started = append(o.started, name)
+}
+
+func (o *startOrder) get() []string {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return append([]string{}, o.started...)
+}
+
+func TestRunnerDependencyOrder(t *testing.T) {
+	order := &startOrder{}
+	newController := func(name string) Controller {
+		return New().
+			WithSync(func(ctx context.Context, syncCtx SyncContext) error {
+				order.add(name)
+				return nil
+			}).
+			WithPostStartHooks(func(ctx context.Context, syncCtx SyncContext) error {
+				syncCtx.Queue().Add(DefaultQueueKey)
+				return nil
+			}).
+			ToController(name, eventstesting.NewTestingEventRecorder(t))
+	}
+
+	certDir := t.TempDir()
+	certFile := filepath.Join(certDir, "tls.crt")
+
+	runner := NewRunner(10*time.Millisecond).
+		Add(newController("status"), 1, ControllerDependencies{Controllers: []string{"config", "certs"}}).
+		Add(newController("certs"), 1, ControllerDependencies{Preconditions: []Precondition{FilesExistPrecondition(certFile)}}).
+		Add(newController("config"), 1, ControllerDependencies{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() { done <- runner.Run(ctx) }()
+
+	// the certs controller waits for the certificate, blocking the status controller
+	err := waitFor(func() bool {
+		blocked := runner.Blocked()
+		return len(blocked) == 2 && strings.HasPrefix(blocked["certs"], "precondition files "+certFile+" exist") && blocked["status"] == "controller certs to start"
+	})
+	if err != nil {
+		t.Fatalf("unexpected blocked controllers %v", runner.Blocked())
+	}
+	if started := order.get(); len(started) != 1 || started[0] != "config" {
+		t.Fatalf("expected only the config controller to be started, got %v", started)
+	}
+
+	if err := os.WriteFile(certFile, []byte("cert"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitFor(func() bool { return len(order.get()) == 3 }); err != nil {
+		t.Fatalf("expected all the controllers to be started, got %v, blocked %v", order.get(), runner.Blocked())
+	}
+	if started := order.get(); started[1] != "certs" || started[2] != "status" {
+		t.Errorf("expected the status controller to start after its dependencies, got %v", started)
+	}
+	if blocked := runner.Blocked(); len(blocked) != 0 {
+		t.Errorf("expected no blocked controller, got %v", blocked)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunnerValidation(t *testing.T) {
+	newController := func(name string) Controller {
+		return New().WithSync(func(ctx context.Context, syncCtx SyncContext) error { return nil }).
+			ToController(name, eventstesting.NewTestingEventRecorder(t))
+	}
+	testCases := []struct {
+		name          string
+		runner        *Runner
+		expectedError string
+	}{
+		{
+			name: "unknown dependency",
+			runner: NewRunner(time.Second).
+				Add(newController("a"), 1, ControllerDependencies{Controllers: []string{"b"}}),
+			expectedError: "the a controller depends on the unknown b controller",
+		},
+		{
+			name: "cycle",
+			runner: NewRunner(time.Second).
+				Add(newController("a"), 1, ControllerDependencies{Controllers: []string{"b"}}).
+				Add(newController("b"), 1, ControllerDependencies{Controllers: []string{"c"}}).
+				Add(newController("c"), 1, ControllerDependencies{Controllers: []string{"a"}}),
+			expectedError: "the controller dependencies have a cycle: a -> b -> c -> a",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.runner.Run(context.Background())
+			if err == nil || err.Error() != tc.expectedError {
+				t.Errorf("expected error %q, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestAPIResourcePrecondition(t *testing.T) {
+	client := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	precondition := APIResourcePrecondition(client, schema.GroupVersionResource{Group: "operator.openshift.io", Version: "v1", Resource: "etcds"})
+
+	if met, err := precondition.Check(context.TODO()); met || err == nil {
+		t.Errorf("expected the precondition not to be met, got %v, %v", met, err)
+	}
+	client.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "operator.openshift.io/v1",
+		APIResources: []metav1.APIResource{{Name: "etcds"}},
+	}}
+	if met, err := precondition.Check(context.TODO()); !met || err != nil {
+		t.Errorf("expected the precondition to be met, got %v, %v", met, err)
+	}
+}
+
+func waitFor(condition func() bool) error {
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if condition() {
+			return nil
+		}
+	}
+	return fmt.Errorf("timed out")
+}
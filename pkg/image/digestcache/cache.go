@@ -0,0 +1,122 @@
+// Package digestcache caches the resolution of image tags to digests, to reduce the registry traffic of the
+// operators resolving the same images repeatedly.
+package digestcache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"golang.org/x/sync/singleflight"
+	"k8s.io/utils/clock"
+	"k8s.io/utils/lru"
+
+	"github.com/openshift/library-go/pkg/image/reference"
+)
+
+// lookupTimeout bounds the lookups shared by the callers, which are not cancelled with the context of any of them.
+const lookupTimeout = time.Minute
+
+// ResolveFunc resolves the image reference to the digest of its manifest, e.g. by fetching the manifest from the
+// registry.
+type ResolveFunc func(ctx context.Context, ref reference.DockerImageReference) (digest.Digest, error)
+
+// Cache resolves tags to digests, caching the digests for a TTL. The number of cached digests is bounded, the least
+// recently used being evicted first. Concurrent resolutions of the same reference share a single lookup. Failed
+// resolutions are not cached. Cache is safe for concurrent use.
+type Cache struct {
+	resolve       ResolveFunc
+	ttl           time.Duration
+	clock         clock.PassiveClock
+	lookupTimeout time.Duration
+
+	entries *lru.Cache
+	lookups singleflight.Group
+}
+
+type entry struct {
+	digest  digest.Digest
+	expires time.Time
+}
+
+// New returns a cache resolving references with resolve, caching up to maxSize digests for ttl.
+func New(resolve ResolveFunc, ttl time.Duration, maxSize int) *Cache {
+	return &Cache{
+		resolve:       resolve,
+		ttl:           ttl,
+		clock:         clock.RealClock{},
+		lookupTimeout: lookupTimeout,
+		entries:       lru.New(maxSize),
+	}
+}
+
+// Resolve returns the digest of the image reference. References by digest are returned as is, the tags are resolved
+// with the cache. The lookup shared by concurrent callers keeps the values of the context of the first of them, but
+// it is not cancelled with it, so that the other callers still get its result. Each caller stops waiting for it when
+// its own context is done.
+func (c *Cache) Resolve(ctx context.Context, ref reference.DockerImageReference) (digest.Digest, error) {
+	if len(ref.ID) > 0 {
+		return digest.Parse(ref.ID)
+	}
+
+	key := cacheKey(ref)
+	if value, ok := c.entries.Get(key); ok {
+		cached := value.(entry)
+		if c.clock.Now().Before(cached.expires) {
+			return cached.digest, nil
+		}
+		c.entries.Remove(key)
+	}
+
+	lookupCtx := detachedContext{parent: ctx}
+	result := c.lookups.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(lookupCtx, c.lookupTimeout)
+		defer cancel()
+		resolved, err := c.resolve(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		if err := resolved.Validate(); err != nil {
+			return nil, fmt.Errorf("%s resolved to an invalid digest %q: %w", key, resolved, err)
+		}
+		c.entries.Add(key, entry{digest: resolved, expires: c.clock.Now().Add(c.ttl)})
+		return resolved, nil
+	})
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case r := <-result:
+		if r.Err != nil {
+			return "", r.Err
+		}
+		return r.Val.(digest.Digest), nil
+	}
+}
+
+// Invalidate removes the cached digest of the image reference, e.g. after the tag was pushed.
+func (c *Cache) Invalidate(ref reference.DockerImageReference) {
+	c.entries.Remove(cacheKey(ref))
+}
+
+// Len returns the number of cached digests, including the expired ones not evicted yet.
+func (c *Cache) Len() int {
+	return c.entries.Len()
+}
+
+// detachedContext has the values of its parent but neither its deadline nor its cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// cacheKey returns the reference with the defaults of the docker client, so that e.g. busybox and
+// docker.io/library/busybox:latest share the same entry.
+func cacheKey(ref reference.DockerImageReference) string {
+	ref.ID = ""
+	return ref.DockerClientDefaults().Exact()
+}
@@ -0,0 +1,178 @@
+package digestcache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/openshift/library-go/pkg/image/reference"
+)
+
+func mustParse(t *testing.T, spec string) reference.DockerImageReference {
+	ref, err := reference.Parse(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ref
+}
+
+func TestCache(t *testing.T) {
+	var lookups []string
+	tags := map[string]digest.Digest{
+		"docker.io/library/busybox:latest":  digest.FromString("busybox"),
+		"quay.io/openshift/origin-cli:4.14": digest.FromString("cli"),
+		"quay.io/openshift/origin-cli:4.15": digest.FromString("cli-4.15"),
+		"quay.io/openshift/invalid:latest":  "sha256:invalid",
+	}
+	resolve := func(ctx context.Context, ref reference.DockerImageReference) (digest.Digest, error) {
+		key := ref.DockerClientDefaults().Exact()
+		lookups = append(lookups, key)
+		if dgst, ok := tags[key]; ok {
+			return dgst, nil
+		}
+		return "", fmt.Errorf("%s not found", key)
+	}
+	cache := New(resolve, time.Minute, 2)
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	cache.clock = fakeClock
+
+	expectResolve := func(spec string, expected digest.Digest, expectedLookups ...string) {
+		t.Helper()
+		lookups = nil
+		dgst, err := cache.Resolve(context.Background(), mustParse(t, spec))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dgst != expected {
+			t.Errorf("expected %s, got %s", expected, dgst)
+		}
+		if fmt.Sprint(lookups) != fmt.Sprint(expectedLookups) {
+			t.Errorf("expected lookups %v, got %v", expectedLookups, lookups)
+		}
+	}
+
+	expectResolve("busybox", digest.FromString("busybox"), "docker.io/library/busybox:latest")
+	// the defaulted reference shares the cached digest
+	expectResolve("docker.io/library/busybox:latest", digest.FromString("busybox"))
+	// references by digest are not looked up
+	expectResolve("quay.io/openshift/origin-cli@"+digest.FromString("other").String(), digest.FromString("other"))
+
+	// expired digests are looked up again
+	fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+	expectResolve("busybox", digest.FromString("busybox"), "docker.io/library/busybox:latest")
+
+	// the least recently used digest is evicted
+	expectResolve("quay.io/openshift/origin-cli:4.14", digest.FromString("cli"), "quay.io/openshift/origin-cli:4.14")
+	expectResolve("quay.io/openshift/origin-cli:4.15", digest.FromString("cli-4.15"), "quay.io/openshift/origin-cli:4.15")
+	if cache.Len() != 2 {
+		t.Errorf("expected 2 cached digests, got %d", cache.Len())
+	}
+	expectResolve("quay.io/openshift/origin-cli:4.14", digest.FromString("cli"))
+	expectResolve("busybox", digest.FromString("busybox"), "docker.io/library/busybox:latest")
+
+	// invalidated digests are looked up again
+	cache.Invalidate(mustParse(t, "quay.io/openshift/origin-cli:4.14"))
+	expectResolve("quay.io/openshift/origin-cli:4.14", digest.FromString("cli"), "quay.io/openshift/origin-cli:4.14")
+
+	// errors are not cached
+	for i := 0; i < 2; i++ {
+		lookups = nil
+		if _, err := cache.Resolve(context.Background(), mustParse(t, "quay.io/openshift/missing")); err == nil || err.Error() != "quay.io/openshift/missing:latest not found" {
+			t.Errorf("unexpected error %v", err)
+		}
+		if len(lookups) != 1 {
+			t.Errorf("expected a lookup, got %v", lookups)
+		}
+	}
+	if _, err := cache.Resolve(context.Background(), mustParse(t, "quay.io/openshift/invalid")); err == nil {
+		t.Error("expected error for an invalid digest")
+	}
+}
+
+func TestCacheSingleflight(t *testing.T) {
+	var lookups int32
+	release := make(chan struct{})
+	started := make(chan struct{})
+	cache := New(func(ctx context.Context, ref reference.DockerImageReference) (digest.Digest, error) {
+		if atomic.AddInt32(&lookups, 1) == 1 {
+			close(started)
+		}
+		select {
+		case <-release:
+			return digest.FromString("busybox"), nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}, time.Minute, 10)
+	ref := mustParse(t, "busybox")
+
+	// the caller starting the lookup gives up, the others still get its result
+	firstCtx, firstCancel := context.WithCancel(context.Background())
+	firstDone := make(chan error)
+	go func() {
+		_, err := cache.Resolve(firstCtx, ref)
+		firstDone <- err
+	}()
+	<-started
+	firstCancel()
+	if err := <-firstDone; err != context.Canceled {
+		t.Errorf("expected the context error, got %v", err)
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan digest.Digest, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dgst, err := cache.Resolve(context.Background(), ref)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- dgst
+		}()
+	}
+
+	// a caller whose context is done stops waiting for the shared lookup
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := cache.Resolve(ctx, ref); err != context.Canceled {
+		t.Errorf("expected the context error, got %v", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+	for dgst := range results {
+		if dgst != digest.FromString("busybox") {
+			t.Errorf("unexpected digest %s", dgst)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("expected a single lookup, got %d", lookups)
+	}
+}
+
+func TestCacheLookupTimeout(t *testing.T) {
+	type key struct{}
+	cache := New(func(ctx context.Context, ref reference.DockerImageReference) (digest.Digest, error) {
+		if ctx.Value(key{}) != "value" {
+			t.Errorf("expected the values of the context of the caller")
+		}
+		<-ctx.Done()
+		return "", ctx.Err()
+	}, time.Minute, 10)
+	cache.lookupTimeout = 10 * time.Millisecond
+
+	// the shared lookup is bounded even when the caller waits forever
+	if _, err := cache.Resolve(context.WithValue(context.Background(), key{}, "value"), mustParse(t, "busybox")); err != context.DeadlineExceeded {
+		t.Errorf("expected the lookup to time out, got %v", err)
+	}
+}
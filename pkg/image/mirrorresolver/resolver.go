@@ -0,0 +1,191 @@
+// Package mirrorresolver applies the ImageDigestMirrorSet and ImageContentSourcePolicy mirror rules of a cluster to
+// image references, so that registry clients pull images from the same mirrors as the container runtime of the nodes.
+package mirrorresolver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
+
+	"github.com/openshift/library-go/pkg/image/reference"
+)
+
+// rule is the merged mirror configuration of a source.
+type rule struct {
+	// source is a registry host, e.g. quay.io, a *. wildcard of its subdomains, or a repository prefix, e.g.
+	// quay.io/openshift-release-dev.
+	source             string
+	mirrors            []string
+	neverContactSource bool
+}
+
+// Resolver returns the locations of images by digest, in the order they are pulled from. Like the container runtime,
+// the mirrors of the most specific source matching the repository are used, the mirrors of all the rules with the
+// same source being merged in order. The digest mirror rules do not apply to images by tag. Resolver is safe for
+// concurrent use.
+type Resolver struct {
+	// rules are sorted by decreasing source length, so that the first matching rule is the most specific.
+	rules []rule
+}
+
+// New returns a resolver with the rules of the image digest mirror sets and image content source policies. The
+// sets and policies are merged in order, pass them sorted by name for a stable mirror order.
+func New(digestMirrorSets []configv1.ImageDigestMirrorSet, contentSourcePolicies []operatorv1alpha1.ImageContentSourcePolicy) (*Resolver, error) {
+	var rules []rule
+	bySource := map[string]int{}
+	add := func(owner, source string, mirrors []string, neverContactSource bool) error {
+		if err := validateSource(source); err != nil {
+			return fmt.Errorf("%s has an invalid source %q: %w", owner, source, err)
+		}
+		for _, mirror := range mirrors {
+			if err := validateMirror(mirror); err != nil {
+				return fmt.Errorf("%s has an invalid mirror %q of %s: %w", owner, mirror, source, err)
+			}
+		}
+		if neverContactSource && len(mirrors) == 0 {
+			return fmt.Errorf("%s never contacts the source %s but has no mirrors", owner, source)
+		}
+		i, ok := bySource[source]
+		if !ok {
+			i = len(rules)
+			bySource[source] = i
+			rules = append(rules, rule{source: source})
+		}
+		for _, mirror := range mirrors {
+			if !contains(rules[i].mirrors, mirror) && mirror != source {
+				rules[i].mirrors = append(rules[i].mirrors, mirror)
+			}
+		}
+		rules[i].neverContactSource = rules[i].neverContactSource || neverContactSource
+		return nil
+	}
+
+	for _, set := range digestMirrorSets {
+		for _, mirrors := range set.Spec.ImageDigestMirrors {
+			names := make([]string, 0, len(mirrors.Mirrors))
+			for _, mirror := range mirrors.Mirrors {
+				names = append(names, string(mirror))
+			}
+			owner := fmt.Sprintf("imagedigestmirrorset/%s", set.Name)
+			if err := add(owner, mirrors.Source, names, mirrors.MirrorSourcePolicy == configv1.NeverContactSource); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, policy := range contentSourcePolicies {
+		for _, mirrors := range policy.Spec.RepositoryDigestMirrors {
+			owner := fmt.Sprintf("imagecontentsourcepolicy/%s", policy.Name)
+			if err := add(owner, mirrors.Source, mirrors.Mirrors, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		return len(rules[i].source) > len(rules[j].source)
+	})
+	return &Resolver{rules: rules}, nil
+}
+
+// Resolve returns the locations of the image, mirrors first and then the image itself unless its source must never
+// be contacted. Images by tag are only pulled from their own location.
+func (r *Resolver) Resolve(ref reference.DockerImageReference) []reference.DockerImageReference {
+	if len(ref.ID) == 0 {
+		return []reference.DockerImageReference{ref}
+	}
+	return r.locations(ref)
+}
+
+// FirstRequest returns the locations of the repository, to be used as the AlternateBlobSourceStrategy of a
+// registryclient.Context: the registry client only consults the alternates for content by digest. It returns an
+// error when the repository has no location the mirror rules allow to contact.
+func (r *Resolver) FirstRequest(ctx context.Context, locator reference.DockerImageReference) ([]reference.DockerImageReference, error) {
+	locations := r.locations(locator)
+	if len(locations) == 0 {
+		return nil, fmt.Errorf("the mirror rules do not allow to contact %s", locator.AsRepository().Exact())
+	}
+	return locations, nil
+}
+
+// OnFailure returns no alternates, as all the mirrors were returned by FirstRequest.
+func (r *Resolver) OnFailure(ctx context.Context, locator reference.DockerImageReference) ([]reference.DockerImageReference, error) {
+	return nil, nil
+}
+
+// locations returns the mirrors of the repository of ref with its tag and ID, followed by ref unless the source must
+// never be contacted.
+func (r *Resolver) locations(ref reference.DockerImageReference) []reference.DockerImageReference {
+	repository := ref.DockerClientDefaults().AsRepository().Exact()
+	for _, rule := range r.rules {
+		prefix, ok := matchSource(rule.source, repository)
+		if !ok {
+			continue
+		}
+		var locations []reference.DockerImageReference
+		for _, mirror := range rule.mirrors {
+			location, err := reference.Parse(mirror + strings.TrimPrefix(repository, prefix))
+			if err != nil {
+				// the mirror was validated, only the resulting name can be invalid, e.g. too long
+				continue
+			}
+			location.Tag, location.ID = ref.Tag, ref.ID
+			locations = append(locations, location)
+		}
+		if !rule.neverContactSource {
+			locations = append(locations, ref)
+		}
+		return locations
+	}
+	return []reference.DockerImageReference{ref}
+}
+
+// matchSource returns the prefix of the repository matched by the source, which matches the repositories equal to
+// it or under it. A source with a *. wildcard matches the repositories of the subdomains of the registry.
+func matchSource(source, repository string) (string, bool) {
+	if strings.HasPrefix(source, "*.") {
+		host := repository
+		if i := strings.Index(repository, "/"); i != -1 {
+			host = repository[:i]
+		}
+		return host, strings.HasSuffix(host, source[1:])
+	}
+	if repository == source || strings.HasPrefix(repository, source+"/") {
+		return source, true
+	}
+	return "", false
+}
+
+func validateSource(source string) error {
+	if strings.HasPrefix(source, "*.") {
+		if strings.ContainsAny(source[2:], "/*") {
+			return fmt.Errorf("wildcard sources must be registry hosts")
+		}
+		return nil
+	}
+	return validateMirror(source)
+}
+
+// validateMirror checks that the mirror is a registry host or a repository, without tag or digest.
+func validateMirror(mirror string) error {
+	ref, err := reference.Parse(mirror)
+	if err != nil {
+		return err
+	}
+	if len(ref.Tag) > 0 || len(ref.ID) > 0 {
+		return fmt.Errorf("must not have a tag or digest")
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
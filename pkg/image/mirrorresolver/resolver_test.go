@@ -0,0 +1,191 @@
+package mirrorresolver
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/library-go/pkg/image/reference"
+	"github.com/openshift/library-go/pkg/image/registryclient"
+)
+
+var _ registryclient.AlternateBlobSourceStrategy = &Resolver{}
+
+func digestMirrorSet(name string, mirrors ...configv1.ImageDigestMirrors) configv1.ImageDigestMirrorSet {
+	return configv1.ImageDigestMirrorSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       configv1.ImageDigestMirrorSetSpec{ImageDigestMirrors: mirrors},
+	}
+}
+
+func contentSourcePolicy(name string, mirrors ...operatorv1alpha1.RepositoryDigestMirrors) operatorv1alpha1.ImageContentSourcePolicy {
+	return operatorv1alpha1.ImageContentSourcePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       operatorv1alpha1.ImageContentSourcePolicySpec{RepositoryDigestMirrors: mirrors},
+	}
+}
+
+const testDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+
+func TestResolve(t *testing.T) {
+	resolver, err := New(
+		[]configv1.ImageDigestMirrorSet{
+			digestMirrorSet("release",
+				configv1.ImageDigestMirrors{
+					Source:  "quay.io/openshift-release-dev/ocp-release",
+					Mirrors: []configv1.ImageMirror{"mirror.example.com/ocp/release", "backup.example.com/ocp/release"},
+				},
+				configv1.ImageDigestMirrors{
+					Source:             "quay.io/openshift-release-dev",
+					Mirrors:            []configv1.ImageMirror{"mirror.example.com/ocp"},
+					MirrorSourcePolicy: configv1.NeverContactSource,
+				},
+			),
+			digestMirrorSet("wildcard",
+				configv1.ImageDigestMirrors{
+					Source:  "*.redhat.io",
+					Mirrors: []configv1.ImageMirror{"mirror.example.com/redhat"},
+				},
+			),
+		},
+		[]operatorv1alpha1.ImageContentSourcePolicy{
+			contentSourcePolicy("legacy",
+				operatorv1alpha1.RepositoryDigestMirrors{
+					Source:  "quay.io/openshift-release-dev/ocp-release",
+					Mirrors: []string{"legacy.example.com/ocp/release", "mirror.example.com/ocp/release"},
+				},
+				operatorv1alpha1.RepositoryDigestMirrors{
+					Source:  "docker.io/library/busybox",
+					Mirrors: []string{"mirror.example.com:5000/busybox"},
+				},
+			),
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		ref      string
+		expected []string
+	}{
+		{
+			name: "mirrors of both resources are merged",
+			ref:  "quay.io/openshift-release-dev/ocp-release@" + testDigest,
+			expected: []string{
+				"mirror.example.com/ocp/release@" + testDigest,
+				"backup.example.com/ocp/release@" + testDigest,
+				"legacy.example.com/ocp/release@" + testDigest,
+				"quay.io/openshift-release-dev/ocp-release@" + testDigest,
+			},
+		},
+		{
+			name:     "the source is never contacted",
+			ref:      "quay.io/openshift-release-dev/ocp-v4.0-art-dev@" + testDigest,
+			expected: []string{"mirror.example.com/ocp/ocp-v4.0-art-dev@" + testDigest},
+		},
+		{
+			name:     "prefixes match whole path components",
+			ref:      "quay.io/openshift-release-dev-other/ocp-release@" + testDigest,
+			expected: []string{"quay.io/openshift-release-dev-other/ocp-release@" + testDigest},
+		},
+		{
+			name: "wildcard sources match subdomains",
+			ref:  "registry.redhat.io/ubi9/ubi@" + testDigest,
+			expected: []string{
+				"mirror.example.com/redhat/ubi9/ubi@" + testDigest,
+				"registry.redhat.io/ubi9/ubi@" + testDigest,
+			},
+		},
+		{
+			name: "docker hub defaults apply",
+			ref:  "busybox@" + testDigest,
+			expected: []string{
+				"mirror.example.com:5000/busybox@" + testDigest,
+				"busybox@" + testDigest,
+			},
+		},
+		{
+			name:     "images by tag are not mirrored",
+			ref:      "quay.io/openshift-release-dev/ocp-release:4.14.0-x86_64",
+			expected: []string{"quay.io/openshift-release-dev/ocp-release:4.14.0-x86_64"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ref, err := reference.Parse(test.ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var actual []string
+			for _, location := range resolver.Resolve(ref) {
+				actual = append(actual, location.Exact())
+			}
+			if !reflect.DeepEqual(test.expected, actual) {
+				t.Errorf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFirstRequest(t *testing.T) {
+	resolver, err := New([]configv1.ImageDigestMirrorSet{
+		digestMirrorSet("release", configv1.ImageDigestMirrors{
+			Source:             "quay.io/openshift-release-dev",
+			Mirrors:            []configv1.ImageMirror{"mirror.example.com/ocp"},
+			MirrorSourcePolicy: configv1.NeverContactSource,
+		}),
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the registry client passes the repositories without digest
+	ref, _ := reference.Parse("quay.io/openshift-release-dev/ocp-release")
+	locations, err := resolver.FirstRequest(context.Background(), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locations) != 1 || locations[0].Exact() != "mirror.example.com/ocp/ocp-release" {
+		t.Errorf("unexpected locations %v", locations)
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		set  configv1.ImageDigestMirrorSet
+	}{
+		{
+			name: "invalid source",
+			set:  digestMirrorSet("invalid", configv1.ImageDigestMirrors{Source: "quay.io/UPPER", Mirrors: []configv1.ImageMirror{"mirror.example.com"}}),
+		},
+		{
+			name: "source with tag",
+			set:  digestMirrorSet("invalid", configv1.ImageDigestMirrors{Source: "quay.io/ocp/release:latest", Mirrors: []configv1.ImageMirror{"mirror.example.com"}}),
+		},
+		{
+			name: "wildcard repository",
+			set:  digestMirrorSet("invalid", configv1.ImageDigestMirrors{Source: "*.redhat.io/ubi9", Mirrors: []configv1.ImageMirror{"mirror.example.com"}}),
+		},
+		{
+			name: "invalid mirror",
+			set:  digestMirrorSet("invalid", configv1.ImageDigestMirrors{Source: "quay.io", Mirrors: []configv1.ImageMirror{"mirror.example.com/ocp@" + testDigest}}),
+		},
+		{
+			name: "never contact source without mirrors",
+			set:  digestMirrorSet("invalid", configv1.ImageDigestMirrors{Source: "quay.io", MirrorSourcePolicy: configv1.NeverContactSource}),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := New([]configv1.ImageDigestMirrorSet{test.set}, nil); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
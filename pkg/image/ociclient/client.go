@@ -0,0 +1,225 @@
+// Package ociclient is a minimal client of the OCI distribution specification, to read and push manifests and blobs,
+// list tags and discover the referrers of manifests. It replaces the client that dockerv1client used to provide for
+// the consumers that do not need the mirroring and retry capabilities of registryclient.
+package ociclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/distribution/distribution/v3/registry/api/errcode"
+	"github.com/distribution/distribution/v3/registry/client/auth"
+	"github.com/distribution/distribution/v3/registry/client/auth/challenge"
+	"github.com/distribution/distribution/v3/registry/client/transport"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/openshift/library-go/pkg/image/registryclient"
+)
+
+// Descriptor describes a manifest or a blob.
+type Descriptor struct {
+	MediaType    string            `json:"mediaType,omitempty"`
+	Digest       digest.Digest     `json:"digest"`
+	Size         int64             `json:"size"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// Client accesses the repositories of a registry. Client is safe for concurrent use.
+type Client struct {
+	base        *url.URL
+	httpClient  *http.Client
+	credentials auth.CredentialStore
+	userAgent   string
+
+	// challenges are the authentication challenges of the registry, shared by the transports of the scopes
+	challenges challenge.Manager
+
+	lock sync.Mutex
+	// clients authenticate the requests of each scope, as registryclient does
+	clients map[string]*http.Client
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client of the requests, http.DefaultClient by default, e.g. to configure TLS. The
+// requests are authenticated on top of its transport.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithCredentials sets the username and password authenticating to the registry, or to its token server when the
+// registry uses bearer tokens. The registry is accessed anonymously by default.
+func WithCredentials(username, password string) Option {
+	return func(c *Client) {
+		credentials := registryclient.NewBasicCredentials()
+		credentials.Add(&url.URL{}, username, password)
+		c.credentials = credentials
+	}
+}
+
+// WithUserAgent sets the User-Agent header of the requests.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// New returns a client of the registry, a host with an optional port, e.g. quay.io or localhost:5000. The registry
+// is accessed with https, unless prefixed with http://.
+func New(registry string, options ...Option) (*Client, error) {
+	if !strings.HasPrefix(registry, "http://") && !strings.HasPrefix(registry, "https://") {
+		registry = "https://" + registry
+	}
+	base, err := url.Parse(registry)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry %q: %w", registry, err)
+	}
+	if len(base.Host) == 0 || (len(base.Path) > 0 && base.Path != "/") {
+		return nil, fmt.Errorf("invalid registry %q, expected a host", registry)
+	}
+	base.Path = ""
+	c := &Client{
+		base:        base,
+		httpClient:  http.DefaultClient,
+		credentials: registryclient.NoCredentials,
+		challenges:  challenge.NewSimpleManager(),
+		clients:     make(map[string]*http.Client),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c, nil
+}
+
+// Repository returns the repository of the registry with the name, e.g. openshift/origin-cli.
+func (c *Client) Repository(name string) *Repository {
+	return &Repository{client: c, name: name}
+}
+
+// request is a request to the registry, sent again after authenticating when the registry asks for it.
+type request struct {
+	method string
+	// url is relative to the registry
+	url    *url.URL
+	header http.Header
+	// body must be a *bytes.Reader for the request to be sent again after authenticating
+	body          io.Reader
+	contentLength int64
+	// scope is the scope of the bearer token of the request, e.g. repository:openshift/origin-cli:pull
+	scope auth.Scope
+}
+
+// do sends the request, authenticating when the registry challenges it, and returns the response of the request
+// or an *Error when its status is not successful.
+func (c *Client) do(ctx context.Context, r request) (*http.Response, error) {
+	client := c.clientFor(r.scope)
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, r.method, c.base.ResolveReference(r.url).String(), r.body)
+		if err != nil {
+			return nil, err
+		}
+		for key, values := range r.header {
+			req.Header[key] = values
+		}
+		if r.body != nil {
+			req.ContentLength = r.contentLength
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, authenticationError(err)
+		}
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return resp, nil
+		}
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 && canResend(r.body) {
+			// the transports authenticate the requests once they know the challenges of the registry
+			drainAndClose(resp)
+			if err := c.ping(ctx); err != nil {
+				return nil, err
+			}
+			if seeker, ok := r.body.(*bytes.Reader); ok {
+				if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		defer resp.Body.Close()
+		return nil, newError(resp)
+	}
+}
+
+func canResend(body io.Reader) bool {
+	if body == nil {
+		return true
+	}
+	_, ok := body.(*bytes.Reader)
+	return ok
+}
+
+// ping records the authentication challenges of the registry, returned for its base endpoint.
+func (c *Client) ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.base.ResolveReference(&url.URL{Path: "/v2/"}).String(), nil)
+	if err != nil {
+		return err
+	}
+	if len(c.userAgent) > 0 {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(resp)
+	return c.challenges.AddResponse(resp)
+}
+
+// clientFor returns the client authenticating the requests of the scope with registryclient.NewAuthorizingTransport.
+func (c *Client) clientFor(scope auth.Scope) *http.Client {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if client, ok := c.clients[scope.String()]; ok {
+		return client
+	}
+
+	rt := c.httpClient.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if len(c.userAgent) > 0 {
+		// set on the requests to the token servers too
+		rt = transport.NewTransport(rt, transport.NewHeaderRequestModifier(http.Header{"User-Agent": []string{c.userAgent}}))
+	}
+	client := *c.httpClient
+	// the redirects and the upload locations on other hosts, e.g. a storage, are not sent the credentials
+	client.Transport = registryclient.NewHostTransport(c.base.Host, registryclient.NewAuthorizingTransport(rt, c.challenges, c.credentials, []auth.Scope{scope}), rt)
+	c.clients[scope.String()] = &client
+	return &client
+}
+
+// authenticationError returns an *Error for the rejection of the credentials by a token server, which the transports
+// report as an error of the request.
+func authenticationError(err error) error {
+	var e errcode.Error
+	if errors.As(err, &e) && (e.Code == errcode.ErrorCodeUnauthorized || e.Code == errcode.ErrorCodeDenied) {
+		return &Error{StatusCode: http.StatusUnauthorized, Message: err.Error()}
+	}
+	return err
+}
+
+func drainAndClose(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+}
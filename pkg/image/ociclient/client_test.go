@@ -0,0 +1,386 @@
+package ociclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// fakeRegistry serves the content of a single repository, authenticating requests with bearer tokens.
+type fakeRegistry struct {
+	manifests map[string][]byte
+	blobs     map[digest.Digest][]byte
+	tags      []string
+	referrers map[digest.Digest][]Descriptor
+	// noReferrersAPI makes the registry answer 404 to the referrers API
+	noReferrersAPI bool
+
+	lock          sync.Mutex
+	tokenRequests []string
+	uploads       map[string][]byte
+}
+
+func (f *fakeRegistry) writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"errors":[{"code":%q,"message":%q}]}`, code, message)
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		username, password, _ := req.BasicAuth()
+		if username != "user" || password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		f.lock.Lock()
+		f.tokenRequests = append(f.tokenRequests, req.URL.Query().Get("scope"))
+		f.lock.Unlock()
+		fmt.Fprintf(w, `{"token":%q}`, "token-"+req.URL.Query().Get("scope"))
+		return
+	}
+
+	const prefix = "/v2/openshift/origin-cli/"
+	scope := "repository:openshift/origin-cli:pull"
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		scope = "repository:openshift/origin-cli:pull,push"
+	}
+	if req.Header.Get("Authorization") != "Bearer token-"+scope {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="http://%s/token",service="fake",scope=%q`, req.Host, scope))
+		f.writeError(w, http.StatusUnauthorized, ErrorCodeUnauthorized, "authentication required")
+		return
+	}
+	if !strings.HasPrefix(req.URL.Path, prefix) {
+		f.writeError(w, http.StatusNotFound, ErrorCodeNameUnknown, "repository name not known to registry")
+		return
+	}
+	path := strings.TrimPrefix(req.URL.Path, prefix)
+
+	switch {
+	case strings.HasPrefix(path, "manifests/"):
+		reference := strings.TrimPrefix(path, "manifests/")
+		switch req.Method {
+		case http.MethodGet, http.MethodHead:
+			content, ok := f.manifests[reference]
+			if !ok {
+				f.writeError(w, http.StatusNotFound, ErrorCodeManifestUnknown, "manifest unknown")
+				return
+			}
+			var document struct {
+				MediaType string `json:"mediaType"`
+			}
+			json.Unmarshal(content, &document)
+			w.Header().Set("Content-Type", document.MediaType)
+			w.Header().Set("Docker-Content-Digest", digest.FromBytes(content).String())
+			w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+			if req.Method == http.MethodGet {
+				w.Write(content)
+			}
+		case http.MethodPut:
+			content, _ := io.ReadAll(req.Body)
+			f.manifests[reference] = content
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete:
+			delete(f.manifests, reference)
+			w.WriteHeader(http.StatusAccepted)
+		}
+	case path == "blobs/uploads/" && req.Method == http.MethodPost:
+		w.Header().Set("Location", "/v2/openshift/origin-cli/blobs/uploads/1?state=abc")
+		w.WriteHeader(http.StatusAccepted)
+	case strings.HasPrefix(path, "blobs/"):
+		content, ok := f.blobs[digest.Digest(strings.TrimPrefix(path, "blobs/"))]
+		if !ok {
+			f.writeError(w, http.StatusNotFound, ErrorCodeBlobUnknown, "blob unknown to registry")
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", strings.TrimPrefix(path, "blobs/"))
+		w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+		if req.Method == http.MethodGet {
+			w.Write(content)
+		}
+	case path == "tags/list":
+		last := req.URL.Query().Get("last")
+		var page []string
+		for _, tag := range f.tags {
+			if tag > last {
+				page = append(page, tag)
+			}
+		}
+		if n := req.URL.Query().Get("n"); len(n) > 0 && len(page) > 2 {
+			page = page[:2]
+			w.Header().Set("Link", fmt.Sprintf(`<%stags/list?n=%s&last=%s>; rel="next"`, prefix, n, page[1]))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"name": "openshift/origin-cli", "tags": page})
+	case strings.HasPrefix(path, "referrers/") && !f.noReferrersAPI:
+		referrers := f.referrers[digest.Digest(strings.TrimPrefix(path, "referrers/"))]
+		w.Header().Set("Content-Type", MediaTypeImageIndex)
+		json.NewEncoder(w).Encode(map[string]interface{}{"schemaVersion": 2, "mediaType": MediaTypeImageIndex, "manifests": referrers})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeRegistry) serveUpload(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Query().Get("state") != "abc" || req.Header.Get("Authorization") != "Bearer token-repository:openshift/origin-cli:pull,push" {
+		f.writeError(w, http.StatusBadRequest, ErrorCodeBlobUploadInvalid, "invalid upload")
+		return
+	}
+	content, _ := io.ReadAll(req.Body)
+	dgst := digest.Digest(req.URL.Query().Get("digest"))
+	if digest.FromBytes(content) != dgst {
+		f.writeError(w, http.StatusBadRequest, ErrorCodeDigestInvalid, "provided digest did not match uploaded content")
+		return
+	}
+	f.blobs[dgst] = content
+	w.WriteHeader(http.StatusCreated)
+}
+
+func newFakeRegistry(t *testing.T, registry *fakeRegistry) *Repository {
+	mux := http.NewServeMux()
+	mux.Handle("/", registry)
+	mux.HandleFunc("/v2/openshift/origin-cli/blobs/uploads/1", registry.serveUpload)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	client, err := New(server.URL, WithCredentials("user", "pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client.Repository("openshift/origin-cli")
+}
+
+func TestManifests(t *testing.T) {
+	manifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","artifactType":"application/example","config":{},"layers":[]}`)
+	dgst := digest.FromBytes(manifest)
+	registry := &fakeRegistry{manifests: map[string][]byte{
+		"latest":                              manifest,
+		dgst.String():                         manifest,
+		digest.FromString("corrupt").String(): manifest,
+	}}
+	repo := newFakeRegistry(t, registry)
+	ctx := context.Background()
+
+	m, err := repo.GetManifest(ctx, "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Descriptor{MediaType: MediaTypeImageManifest, Digest: dgst, Size: int64(len(manifest)), ArtifactType: "application/example"}
+	if !reflect.DeepEqual(m.Descriptor, expected) || !bytes.Equal(m.Content, manifest) {
+		t.Errorf("unexpected manifest %#v", m.Descriptor)
+	}
+	if desc, err := repo.HeadManifest(ctx, dgst.String()); err != nil || desc.Digest != dgst || desc.Size != int64(len(manifest)) {
+		t.Errorf("unexpected descriptor %#v: %v", desc, err)
+	}
+	// the token is reused
+	if len(registry.tokenRequests) != 1 {
+		t.Errorf("expected a single token request, got %v", registry.tokenRequests)
+	}
+
+	if _, err := repo.GetManifest(ctx, digest.FromString("corrupt").String()); err == nil || !strings.Contains(err.Error(), "content integrity error") {
+		t.Errorf("unexpected error %v", err)
+	}
+	_, err = repo.GetManifest(ctx, "missing")
+	if !IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+	if e := err.(*Error); !e.HasCode(ErrorCodeManifestUnknown) || !strings.HasSuffix(e.Error(), "404: MANIFEST_UNKNOWN: manifest unknown") {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	if _, err := repo.PutManifest(ctx, "v2", MediaTypeImageManifest, manifest); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(registry.manifests["v2"], manifest) {
+		t.Errorf("the manifest was not pushed")
+	}
+	if _, err := repo.PutManifest(ctx, digest.FromString("other").String(), MediaTypeImageManifest, manifest); err == nil {
+		t.Errorf("expected an error pushing a manifest with another digest")
+	}
+	if err := repo.DeleteManifest(ctx, dgst); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := registry.manifests[dgst.String()]; ok {
+		t.Errorf("the manifest was not deleted")
+	}
+}
+
+func TestBlobs(t *testing.T) {
+	blob := []byte("layer")
+	registry := &fakeRegistry{blobs: map[digest.Digest][]byte{
+		digest.FromBytes(blob):       blob,
+		digest.FromString("corrupt"): blob,
+	}}
+	repo := newFakeRegistry(t, registry)
+	ctx := context.Background()
+
+	if desc, err := repo.StatBlob(ctx, digest.FromBytes(blob)); err != nil || desc.Size != int64(len(blob)) {
+		t.Errorf("unexpected descriptor %#v: %v", desc, err)
+	}
+	rc, err := repo.GetBlob(ctx, digest.FromBytes(blob))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || !bytes.Equal(content, blob) {
+		t.Errorf("unexpected content %q: %v", content, err)
+	}
+
+	rc, err = repo.GetBlob(ctx, digest.FromString("corrupt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(rc); err == nil || !strings.Contains(err.Error(), "content integrity error") {
+		t.Errorf("unexpected error %v", err)
+	}
+	rc.Close()
+
+	uploaded := []byte("uploaded")
+	if _, err := repo.PutBlob(ctx, bytes.NewBufferString(string(uploaded)), int64(len(uploaded)), digest.FromBytes(uploaded)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(registry.blobs[digest.FromBytes(uploaded)], uploaded) {
+		t.Errorf("the blob was not uploaded")
+	}
+	if _, err := repo.PutBlob(ctx, bytes.NewBufferString("other"), 5, digest.FromBytes(uploaded)); err == nil || !strings.Contains(err.Error(), ErrorCodeDigestInvalid) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestBlobRedirect(t *testing.T) {
+	blob := []byte("layer")
+	var storageAuths []string
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		storageAuths = append(storageAuths, req.Header.Get("Authorization"))
+		w.Write(blob)
+	}))
+	t.Cleanup(storage.Close)
+
+	registry := &fakeRegistry{}
+	mux := http.NewServeMux()
+	mux.Handle("/", registry)
+	mux.HandleFunc("/v2/openshift/origin-cli/blobs/"+digest.FromBytes(blob).String(), func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer token-repository:openshift/origin-cli:pull" {
+			registry.ServeHTTP(w, req)
+			return
+		}
+		http.Redirect(w, req, storage.URL+"/layer", http.StatusTemporaryRedirect)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	client, err := New(server.URL, WithCredentials("user", "pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := client.Repository("openshift/origin-cli").GetBlob(context.Background(), digest.FromBytes(blob))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || !bytes.Equal(content, blob) {
+		t.Errorf("unexpected content %q: %v", content, err)
+	}
+	if !reflect.DeepEqual(storageAuths, []string{""}) {
+		t.Errorf("expected a single request to the storage without credentials, got %q", storageAuths)
+	}
+}
+
+func TestTags(t *testing.T) {
+	registry := &fakeRegistry{tags: []string{"4.12", "4.13", "4.14", "4.15", "latest"}}
+	repo := newFakeRegistry(t, registry)
+
+	var pages [][]string
+	if err := repo.ListTags(context.Background(), 2, func(tags []string) error {
+		pages = append(pages, tags)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{{"4.12", "4.13"}, {"4.14", "4.15"}, {"latest"}}
+	if !reflect.DeepEqual(pages, expected) {
+		t.Errorf("expected pages %v, got %v", expected, pages)
+	}
+
+	tags, err := repo.Tags(context.Background())
+	if err != nil || !reflect.DeepEqual(tags, registry.tags) {
+		t.Errorf("unexpected tags %v: %v", tags, err)
+	}
+}
+
+func TestReferrers(t *testing.T) {
+	subject := digest.FromString("subject")
+	signature := Descriptor{MediaType: MediaTypeImageManifest, Digest: digest.FromString("signature"), Size: 10, ArtifactType: "application/vnd.dev.cosign.artifact.sig.v1+json"}
+	sbom := Descriptor{MediaType: MediaTypeImageManifest, Digest: digest.FromString("sbom"), Size: 10, ArtifactType: "application/spdx+json"}
+	index, _ := json.Marshal(map[string]interface{}{"schemaVersion": 2, "mediaType": MediaTypeImageIndex, "manifests": []Descriptor{signature, sbom}})
+
+	for _, noReferrersAPI := range []bool{false, true} {
+		t.Run(fmt.Sprintf("noReferrersAPI=%t", noReferrersAPI), func(t *testing.T) {
+			registry := &fakeRegistry{
+				referrers:      map[digest.Digest][]Descriptor{subject: {signature, sbom}},
+				manifests:      map[string][]byte{"sha256-" + subject.Encoded(): index},
+				noReferrersAPI: noReferrersAPI,
+			}
+			repo := newFakeRegistry(t, registry)
+
+			referrers, err := repo.Referrers(context.Background(), subject, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(referrers, []Descriptor{signature, sbom}) {
+				t.Errorf("unexpected referrers %#v", referrers)
+			}
+			referrers, err = repo.Referrers(context.Background(), subject, sbom.ArtifactType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(referrers, []Descriptor{sbom}) {
+				t.Errorf("unexpected referrers %#v", referrers)
+			}
+			referrers, err = repo.Referrers(context.Background(), digest.FromString("other"), "")
+			if err != nil || len(referrers) != 0 {
+				t.Errorf("unexpected referrers %#v: %v", referrers, err)
+			}
+		})
+	}
+}
+
+func TestAuthenticationErrors(t *testing.T) {
+	registry := &fakeRegistry{}
+	repo := newFakeRegistry(t, registry)
+	WithCredentials("user", "wrong")(repo.client)
+	_, err := repo.GetManifest(context.Background(), "latest")
+	if !IsUnauthorized(err) {
+		t.Errorf("expected an unauthorized error, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for registry, expected := range map[string]string{
+		"quay.io":               "https://quay.io",
+		"localhost:5000":        "https://localhost:5000",
+		"http://localhost:5000": "http://localhost:5000",
+		"quay.io/openshift":     "",
+	} {
+		client, err := New(registry)
+		if len(expected) == 0 {
+			if err == nil {
+				t.Errorf("%s: expected an error", registry)
+			}
+			continue
+		}
+		if err != nil || client.base.String() != expected {
+			t.Errorf("%s: unexpected registry %v: %v", registry, client, err)
+		}
+	}
+}
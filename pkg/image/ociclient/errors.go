@@ -0,0 +1,115 @@
+package ociclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxErrorResponseSize bounds the size of the error responses read.
+const maxErrorResponseSize = 64 * 1024
+
+// The error codes of the distribution specification.
+const (
+	ErrorCodeBlobUnknown         = "BLOB_UNKNOWN"
+	ErrorCodeBlobUploadInvalid   = "BLOB_UPLOAD_INVALID"
+	ErrorCodeBlobUploadUnknown   = "BLOB_UPLOAD_UNKNOWN"
+	ErrorCodeDigestInvalid       = "DIGEST_INVALID"
+	ErrorCodeManifestBlobUnknown = "MANIFEST_BLOB_UNKNOWN"
+	ErrorCodeManifestInvalid     = "MANIFEST_INVALID"
+	ErrorCodeManifestUnknown     = "MANIFEST_UNKNOWN"
+	ErrorCodeNameInvalid         = "NAME_INVALID"
+	ErrorCodeNameUnknown         = "NAME_UNKNOWN"
+	ErrorCodeSizeInvalid         = "SIZE_INVALID"
+	ErrorCodeUnauthorized        = "UNAUTHORIZED"
+	ErrorCodeDenied              = "DENIED"
+	ErrorCodeUnsupported         = "UNSUPPORTED"
+	ErrorCodeTooManyRequests     = "TOOMANYREQUESTS"
+)
+
+// ErrorDetail is an error of the error response of a registry.
+type ErrorDetail struct {
+	Code    string          `json:"code"`
+	Message string          `json:"message"`
+	Detail  json.RawMessage `json:"detail,omitempty"`
+}
+
+// Error is an unsuccessful response of a registry.
+type Error struct {
+	StatusCode int
+	Method     string
+	URL        string
+	// Errors are the errors of the response body, when the registry returned them.
+	Errors []ErrorDetail
+	// Message describes the error when the response has no errors.
+	Message string
+}
+
+func (e *Error) Error() string {
+	var message string
+	switch {
+	case len(e.Errors) > 0:
+		messages := make([]string, 0, len(e.Errors))
+		for _, detail := range e.Errors {
+			if len(detail.Message) > 0 {
+				messages = append(messages, fmt.Sprintf("%s: %s", detail.Code, detail.Message))
+			} else {
+				messages = append(messages, detail.Code)
+			}
+		}
+		message = strings.Join(messages, "; ")
+	case len(e.Message) > 0:
+		message = e.Message
+	default:
+		message = http.StatusText(e.StatusCode)
+	}
+	if len(e.URL) == 0 {
+		return fmt.Sprintf("%d: %s", e.StatusCode, message)
+	}
+	return fmt.Sprintf("%s %s: %d: %s", e.Method, e.URL, e.StatusCode, message)
+}
+
+// HasCode returns whether the registry returned the error code.
+func (e *Error) HasCode(code string) bool {
+	for _, detail := range e.Errors {
+		if detail.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// newError returns the error of the unsuccessful response.
+func newError(resp *http.Response) *Error {
+	e := &Error{StatusCode: resp.StatusCode}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.URL = resp.Request.URL.Redacted()
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorResponseSize))
+	var response struct {
+		Errors []ErrorDetail `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &response); err == nil && len(response.Errors) > 0 {
+		e.Errors = response.Errors
+	} else if text := strings.TrimSpace(string(body)); len(text) > 0 && resp.Request != nil && resp.Request.Method != http.MethodHead {
+		e.Message = text
+	}
+	return e
+}
+
+// IsNotFound returns whether the error is a response of a registry for missing content.
+func IsNotFound(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.StatusCode == http.StatusNotFound
+}
+
+// IsUnauthorized returns whether the error is a response of a registry rejecting the credentials or the access to
+// the content.
+func IsUnauthorized(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && (e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden)
+}
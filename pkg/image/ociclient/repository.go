@@ -0,0 +1,441 @@
+package ociclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/distribution/distribution/v3/registry/client/auth"
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	// MediaTypeImageManifest is the media type of OCI image manifests.
+	MediaTypeImageManifest = "application/vnd.oci.image.manifest.v1+json"
+	// MediaTypeImageIndex is the media type of OCI image indexes.
+	MediaTypeImageIndex = "application/vnd.oci.image.index.v1+json"
+	// MediaTypeDockerManifest is the media type of Docker schema 2 image manifests.
+	MediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	// MediaTypeDockerManifestList is the media type of Docker manifest lists.
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+
+	// maxManifestSize bounds the size of the manifests read, as recommended by the distribution specification.
+	maxManifestSize = 4 * 1024 * 1024
+)
+
+// DefaultManifestMediaTypes are the media types of the manifests accepted when none is requested.
+var DefaultManifestMediaTypes = []string{MediaTypeImageManifest, MediaTypeImageIndex, MediaTypeDockerManifest, MediaTypeDockerManifestList}
+
+// Manifest is a manifest and its content.
+type Manifest struct {
+	Descriptor
+	Content []byte
+}
+
+// Repository accesses the manifests, blobs, tags and referrers of a repository.
+type Repository struct {
+	client *Client
+	name   string
+}
+
+// Name returns the name of the repository in the registry.
+func (r *Repository) Name() string {
+	return r.name
+}
+
+func (r *Repository) pullScope() auth.Scope {
+	return auth.RepositoryScope{Repository: r.name, Actions: []string{"pull"}}
+}
+
+func (r *Repository) pushScope() auth.Scope {
+	return auth.RepositoryScope{Repository: r.name, Actions: []string{"pull", "push"}}
+}
+
+func (r *Repository) path(elements ...string) *url.URL {
+	return &url.URL{Path: "/v2/" + r.name + "/" + strings.Join(elements, "/")}
+}
+
+// GetManifest returns the manifest with the tag or digest. The manifest must have one of the media types, or of
+// DefaultManifestMediaTypes when none is passed. A manifest retrieved by digest is verified against it.
+func (r *Repository) GetManifest(ctx context.Context, reference string, mediaTypes ...string) (*Manifest, error) {
+	if len(mediaTypes) == 0 {
+		mediaTypes = DefaultManifestMediaTypes
+	}
+	resp, err := r.client.do(ctx, request{
+		method: http.MethodGet,
+		url:    r.path("manifests", reference),
+		header: http.Header{"Accept": {strings.Join(mediaTypes, ", ")}},
+		scope:  r.pullScope(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxManifestSize {
+		return nil, fmt.Errorf("the manifest %s of %s is larger than %d bytes", reference, r.name, maxManifestSize)
+	}
+
+	desc, err := manifestDescriptor(resp, reference, content)
+	if err != nil {
+		return nil, fmt.Errorf("the manifest %s of %s is not valid: %w", reference, r.name, err)
+	}
+	return &Manifest{Descriptor: desc, Content: content}, nil
+}
+
+// manifestDescriptor returns the descriptor of the manifest content, verified against the reference when it is a
+// digest.
+func manifestDescriptor(resp *http.Response, reference string, content []byte) (Descriptor, error) {
+	desc := Descriptor{
+		MediaType: mediaType(resp.Header.Get("Content-Type")),
+		Digest:    digest.FromBytes(content),
+		Size:      int64(len(content)),
+	}
+	var document struct {
+		MediaType    string `json:"mediaType"`
+		ArtifactType string `json:"artifactType"`
+	}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return Descriptor{}, err
+	}
+	if len(document.MediaType) > 0 {
+		desc.MediaType = document.MediaType
+	}
+	desc.ArtifactType = document.ArtifactType
+
+	if dgst, err := digest.Parse(reference); err == nil {
+		if actual := dgst.Algorithm().FromBytes(content); actual != dgst {
+			return Descriptor{}, fmt.Errorf("content integrity error: the content digest is %s", actual)
+		}
+		desc.Digest = dgst
+	}
+	return desc, nil
+}
+
+func mediaType(contentType string) string {
+	if i := strings.Index(contentType, ";"); i != -1 {
+		contentType = contentType[:i]
+	}
+	return strings.TrimSpace(contentType)
+}
+
+// HeadManifest returns the descriptor of the manifest with the tag or digest, without its content.
+func (r *Repository) HeadManifest(ctx context.Context, reference string, mediaTypes ...string) (Descriptor, error) {
+	if len(mediaTypes) == 0 {
+		mediaTypes = DefaultManifestMediaTypes
+	}
+	resp, err := r.client.do(ctx, request{
+		method: http.MethodHead,
+		url:    r.path("manifests", reference),
+		header: http.Header{"Accept": {strings.Join(mediaTypes, ", ")}},
+		scope:  r.pullScope(),
+	})
+	if err != nil {
+		return Descriptor{}, err
+	}
+	resp.Body.Close()
+	return headDescriptor(resp, reference)
+}
+
+// headDescriptor returns the descriptor of the response of a HEAD request for the reference.
+func headDescriptor(resp *http.Response, reference string) (Descriptor, error) {
+	desc := Descriptor{
+		MediaType: mediaType(resp.Header.Get("Content-Type")),
+		Size:      resp.ContentLength,
+	}
+	if value := resp.Header.Get("Docker-Content-Digest"); len(value) > 0 {
+		dgst, err := digest.Parse(value)
+		if err != nil {
+			return Descriptor{}, fmt.Errorf("the registry returned an invalid digest for %s: %w", reference, err)
+		}
+		desc.Digest = dgst
+	}
+	if dgst, err := digest.Parse(reference); err == nil {
+		if len(desc.Digest) > 0 && desc.Digest != dgst {
+			return Descriptor{}, fmt.Errorf("the registry returned the digest %s for %s", desc.Digest, reference)
+		}
+		desc.Digest = dgst
+	}
+	if len(desc.Digest) == 0 {
+		return Descriptor{}, fmt.Errorf("the registry returned no digest for %s", reference)
+	}
+	return desc, nil
+}
+
+// PutManifest pushes the manifest content of the media type with the tag or digest, and returns its descriptor.
+func (r *Repository) PutManifest(ctx context.Context, reference, mediaType string, content []byte) (Descriptor, error) {
+	desc := Descriptor{MediaType: mediaType, Digest: digest.FromBytes(content), Size: int64(len(content))}
+	if dgst, err := digest.Parse(reference); err == nil {
+		if actual := dgst.Algorithm().FromBytes(content); actual != dgst {
+			return Descriptor{}, fmt.Errorf("the manifest content has the digest %s, expected %s", actual, dgst)
+		}
+		desc.Digest = dgst
+	}
+	resp, err := r.client.do(ctx, request{
+		method:        http.MethodPut,
+		url:           r.path("manifests", reference),
+		header:        http.Header{"Content-Type": {mediaType}},
+		body:          bytes.NewReader(content),
+		contentLength: int64(len(content)),
+		scope:         r.pushScope(),
+	})
+	if err != nil {
+		return Descriptor{}, err
+	}
+	drainAndClose(resp)
+	return desc, nil
+}
+
+// DeleteManifest deletes the manifest with the digest.
+func (r *Repository) DeleteManifest(ctx context.Context, dgst digest.Digest) error {
+	resp, err := r.client.do(ctx, request{
+		method: http.MethodDelete,
+		url:    r.path("manifests", dgst.String()),
+		scope:  r.pushScope(),
+	})
+	if err != nil {
+		return err
+	}
+	drainAndClose(resp)
+	return nil
+}
+
+// StatBlob returns the descriptor of the blob with the digest.
+func (r *Repository) StatBlob(ctx context.Context, dgst digest.Digest) (Descriptor, error) {
+	resp, err := r.client.do(ctx, request{
+		method: http.MethodHead,
+		url:    r.path("blobs", dgst.String()),
+		scope:  r.pullScope(),
+	})
+	if err != nil {
+		return Descriptor{}, err
+	}
+	resp.Body.Close()
+	return headDescriptor(resp, dgst.String())
+}
+
+// GetBlob streams the blob with the digest. The content is verified against the digest while it is read, the
+// reader returning an error instead of io.EOF when it does not match.
+func (r *Repository) GetBlob(ctx context.Context, dgst digest.Digest) (io.ReadCloser, error) {
+	if err := dgst.Validate(); err != nil {
+		return nil, err
+	}
+	resp, err := r.client.do(ctx, request{
+		method: http.MethodGet,
+		url:    r.path("blobs", dgst.String()),
+		scope:  r.pullScope(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &verifyingReader{body: resp.Body, verifier: dgst.Verifier(), dgst: dgst}, nil
+}
+
+// verifyingReader verifies the digest of the content read at the end of the stream.
+type verifyingReader struct {
+	body     io.ReadCloser
+	verifier digest.Verifier
+	dgst     digest.Digest
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	n, err := v.body.Read(p)
+	v.verifier.Write(p[:n])
+	if err == io.EOF && !v.verifier.Verified() {
+		return n, fmt.Errorf("content integrity error: the blob streamed from digest %s does not match the digest calculated from the content", v.dgst)
+	}
+	return n, err
+}
+
+func (v *verifyingReader) Close() error {
+	return v.body.Close()
+}
+
+// PutBlob uploads the blob content with a monolithic upload and returns its descriptor. The content is uploaded
+// with a single request, the authentication happening when the upload is started.
+func (r *Repository) PutBlob(ctx context.Context, content io.Reader, size int64, dgst digest.Digest) (Descriptor, error) {
+	if err := dgst.Validate(); err != nil {
+		return Descriptor{}, err
+	}
+	resp, err := r.client.do(ctx, request{
+		method: http.MethodPost,
+		url:    r.path("blobs", "uploads", ""),
+		scope:  r.pushScope(),
+	})
+	if err != nil {
+		return Descriptor{}, err
+	}
+	drainAndClose(resp)
+	if resp.StatusCode != http.StatusAccepted {
+		return Descriptor{}, fmt.Errorf("unexpected status %s starting the upload of %s", resp.Status, dgst)
+	}
+	location, err := resp.Location()
+	if err != nil {
+		return Descriptor{}, fmt.Errorf("the registry returned no upload location for %s: %w", dgst, err)
+	}
+	query := location.Query()
+	query.Set("digest", dgst.String())
+	location.RawQuery = query.Encode()
+
+	// the location is absolute, as returned by resp.Location()
+	resp, err = r.client.do(ctx, request{
+		method:        http.MethodPut,
+		url:           location,
+		header:        http.Header{"Content-Type": {"application/octet-stream"}},
+		body:          content,
+		contentLength: size,
+		scope:         r.pushScope(),
+	})
+	if err != nil {
+		return Descriptor{}, err
+	}
+	drainAndClose(resp)
+	return Descriptor{MediaType: "application/octet-stream", Digest: dgst, Size: size}, nil
+}
+
+// ListTags lists the tags of the repository, calling fn with every page of at most pageSize tags, or of the size
+// the registry chooses when pageSize is zero. Listing stops when fn returns an error, which is returned.
+func (r *Repository) ListTags(ctx context.Context, pageSize int, fn func(tags []string) error) error {
+	next := r.path("tags", "list")
+	if pageSize > 0 {
+		next.RawQuery = url.Values{"n": {strconv.Itoa(pageSize)}}.Encode()
+	}
+	for next != nil {
+		resp, err := r.client.do(ctx, request{method: http.MethodGet, url: next, scope: r.pullScope()})
+		if err != nil {
+			return err
+		}
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("invalid tag list of %s: %w", r.name, err)
+		}
+		if next, err = nextLink(resp); err != nil {
+			return err
+		}
+		if err := fn(page.Tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Tags returns all the tags of the repository.
+func (r *Repository) Tags(ctx context.Context) ([]string, error) {
+	var tags []string
+	err := r.ListTags(ctx, 0, func(page []string) error {
+		tags = append(tags, page...)
+		return nil
+	})
+	return tags, err
+}
+
+// Referrers returns the manifests referring to the manifest with the digest as their subject, e.g. signatures or
+// SBOMs, only those of the artifact type when not empty. Registries without the referrers API are queried with the
+// referrers tag schema of the distribution specification.
+func (r *Repository) Referrers(ctx context.Context, dgst digest.Digest, artifactType string) ([]Descriptor, error) {
+	if err := dgst.Validate(); err != nil {
+		return nil, err
+	}
+	next := r.path("referrers", dgst.String())
+	if len(artifactType) > 0 {
+		next.RawQuery = url.Values{"artifactType": {artifactType}}.Encode()
+	}
+	var referrers []Descriptor
+	for next != nil {
+		resp, err := r.client.do(ctx, request{
+			method: http.MethodGet,
+			url:    next,
+			header: http.Header{"Accept": {MediaTypeImageIndex}},
+			scope:  r.pullScope(),
+		})
+		if IsNotFound(err) && len(referrers) == 0 {
+			return r.referrersFromTag(ctx, dgst, artifactType)
+		}
+		if err != nil {
+			return nil, err
+		}
+		var index struct {
+			Manifests []Descriptor `json:"manifests"`
+		}
+		err = json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(&index)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid referrers of %s@%s: %w", r.name, dgst, err)
+		}
+		// registries not supporting the filter return all the referrers
+		filtered := strings.Contains(resp.Header.Get("OCI-Filters-Applied"), "artifactType")
+		referrers = append(referrers, filterReferrers(index.Manifests, artifactType, filtered)...)
+		if next, err = nextLink(resp); err != nil {
+			return nil, err
+		}
+	}
+	return referrers, nil
+}
+
+// referrersFromTag returns the referrers of the index tagged with the referrers tag schema, <alg>-<hex>.
+func (r *Repository) referrersFromTag(ctx context.Context, dgst digest.Digest, artifactType string) ([]Descriptor, error) {
+	tag := dgst.Algorithm().String() + "-" + dgst.Encoded()
+	manifest, err := r.GetManifest(ctx, tag, MediaTypeImageIndex)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var index struct {
+		Manifests []Descriptor `json:"manifests"`
+	}
+	if err := json.Unmarshal(manifest.Content, &index); err != nil {
+		return nil, fmt.Errorf("invalid referrers index %s of %s: %w", tag, r.name, err)
+	}
+	return filterReferrers(index.Manifests, artifactType, false), nil
+}
+
+func filterReferrers(referrers []Descriptor, artifactType string, filtered bool) []Descriptor {
+	if len(artifactType) == 0 || filtered {
+		return referrers
+	}
+	var matching []Descriptor
+	for _, referrer := range referrers {
+		if referrer.ArtifactType == artifactType {
+			matching = append(matching, referrer)
+		}
+	}
+	return matching
+}
+
+// nextLink returns the URL of the next page of the Link header of the response, nil when there is none.
+func nextLink(resp *http.Response) (*url.URL, error) {
+	for _, value := range resp.Header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				if strings.ReplaceAll(strings.TrimSpace(param), " ", "") == `rel="next"` {
+					next, err := url.Parse(target[1 : len(target)-1])
+					if err != nil {
+						return nil, fmt.Errorf("invalid Link header %q: %w", value, err)
+					}
+					return resp.Request.URL.ResolveReference(next), nil
+				}
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,183 @@
+package registryclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/opencontainers/go-digest"
+	"golang.org/x/time/rate"
+	"k8s.io/klog/v2"
+)
+
+const (
+	defaultBlobFetchConcurrency = 4
+	blobFetchChunkSize          = 32 * 1024
+)
+
+// BlobFetchOptions configures a BlobFetcher.
+type BlobFetchOptions struct {
+	// Concurrency is the maximum number of blobs streamed at the same time by the fetcher, 4 by default.
+	Concurrency int
+	// BytesPerSecond limits the bandwidth shared by all the blobs streamed by the fetcher, unlimited when zero.
+	BytesPerSecond int64
+	// MaxResumes is the number of times an interrupted download is resumed from the last byte received, with a
+	// ranged request.
+	MaxResumes int
+}
+
+// BlobFetcher streams blobs from registries with bounded concurrency and bandwidth, resuming interrupted downloads
+// and verifying the digests of the blobs while they are streamed. BlobFetcher is safe for concurrent use.
+type BlobFetcher struct {
+	options BlobFetchOptions
+	limiter *rate.Limiter
+	slots   chan struct{}
+}
+
+// NewBlobFetcher returns a blob fetcher with the options.
+func NewBlobFetcher(options BlobFetchOptions) *BlobFetcher {
+	if options.Concurrency <= 0 {
+		options.Concurrency = defaultBlobFetchConcurrency
+	}
+	f := &BlobFetcher{
+		options: options,
+		slots:   make(chan struct{}, options.Concurrency),
+	}
+	if options.BytesPerSecond > 0 {
+		burst := int(options.BytesPerSecond)
+		if burst < blobFetchChunkSize {
+			burst = blobFetchChunkSize
+		}
+		f.limiter = rate.NewLimiter(rate.Limit(options.BytesPerSecond), burst)
+	}
+	return f
+}
+
+// Fetch streams the blob of the descriptor from the blob store to w, once a concurrency slot of the fetcher is
+// available, and returns the number of bytes written. An error is returned when the content does not match the size
+// or the digest of the descriptor, in which case the content already written to w must be discarded.
+func (f *BlobFetcher) Fetch(ctx context.Context, blobs distribution.BlobStore, desc distribution.Descriptor, w io.Writer) (int64, error) {
+	select {
+	case f.slots <- struct{}{}:
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+	defer func() { <-f.slots }()
+	return f.fetch(ctx, blobs, desc, w)
+}
+
+// FetchAll streams the blobs of the descriptors concurrently, to the writers returned by open. The writers are
+// closed once their blob is streamed. The first error cancels the remaining downloads and is returned.
+func (f *BlobFetcher) FetchAll(ctx context.Context, blobs distribution.BlobStore, descs []distribution.Descriptor, open func(desc distribution.Descriptor) (io.WriteCloser, error)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var once sync.Once
+	var firstErr error
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	var wg sync.WaitGroup
+	for _, desc := range descs {
+		desc := desc
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w, err := open(desc)
+			if err != nil {
+				fail(err)
+				return
+			}
+			_, err = f.Fetch(ctx, blobs, desc, w)
+			if closeErr := w.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				fail(fmt.Errorf("unable to fetch blob %s: %w", desc.Digest, err))
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// fetch streams the blob, resuming it from the last byte written when reading fails.
+func (f *BlobFetcher) fetch(ctx context.Context, blobs distribution.BlobStore, desc distribution.Descriptor, w io.Writer) (int64, error) {
+	if err := desc.Digest.Validate(); err != nil {
+		return 0, err
+	}
+	verifier := desc.Digest.Verifier()
+	var written int64
+	buf := make([]byte, blobFetchChunkSize)
+	for resumes := 0; ; resumes++ {
+		n, err := f.copyFrom(ctx, blobs, desc.Digest, written, io.MultiWriter(w, verifier), buf)
+		written += n
+		if err == nil {
+			break
+		}
+		var readErr *blobReadError
+		if !errors.As(err, &readErr) || ctx.Err() != nil || resumes >= f.options.MaxResumes {
+			return written, err
+		}
+		klog.V(4).Infof("Resuming the download of blob %s at byte %d: %v", desc.Digest, written, readErr.err)
+	}
+
+	if desc.Size > 0 && written != desc.Size {
+		return written, fmt.Errorf("content integrity error: the blob %s has %d bytes, expected %d", desc.Digest, written, desc.Size)
+	}
+	if !verifier.Verified() {
+		return written, fmt.Errorf("content integrity error: the blob streamed from digest %s does not match the digest calculated from the content", desc.Digest)
+	}
+	return written, nil
+}
+
+// blobReadError is a failure to read from the registry, after which the download can be resumed.
+type blobReadError struct {
+	err error
+}
+
+func (e *blobReadError) Error() string { return e.err.Error() }
+func (e *blobReadError) Unwrap() error { return e.err }
+
+// copyFrom copies the blob from the offset to w, and returns the number of bytes written.
+func (f *BlobFetcher) copyFrom(ctx context.Context, blobs distribution.BlobStore, dgst digest.Digest, offset int64, w io.Writer, buf []byte) (int64, error) {
+	rsc, err := blobs.Open(ctx, dgst)
+	if err != nil {
+		return 0, err
+	}
+	defer rsc.Close()
+	if offset > 0 {
+		if _, err := rsc.Seek(offset, io.SeekStart); err != nil {
+			return 0, &blobReadError{err: err}
+		}
+	}
+
+	var written int64
+	for {
+		n, readErr := rsc.Read(buf)
+		if n > 0 {
+			if f.limiter != nil {
+				if err := f.limiter.WaitN(ctx, n); err != nil {
+					return written, err
+				}
+			}
+			if _, err := w.Write(buf[:n]); err != nil {
+				return written, err
+			}
+			written += int64(n)
+		}
+		if readErr == io.EOF {
+			return written, nil
+		}
+		if readErr != nil {
+			return written, &blobReadError{err: readErr}
+		}
+	}
+}
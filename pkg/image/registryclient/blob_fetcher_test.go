@@ -0,0 +1,296 @@
+package registryclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/registry/client/transport"
+	"github.com/opencontainers/go-digest"
+)
+
+// streamingBlobStore serves blobs in small reads, which fail after failAfter bytes for the first failures opens.
+type streamingBlobStore struct {
+	distribution.BlobStore
+	blobs     map[digest.Digest][]byte
+	failAfter int64
+	failures  int
+
+	lock    sync.Mutex
+	opens   int
+	offsets []int64
+	active  int
+	peak    int
+	block   chan struct{}
+}
+
+func (s *streamingBlobStore) Open(ctx context.Context, dgst digest.Digest) (distribution.ReadSeekCloser, error) {
+	data, ok := s.blobs[dgst]
+	if !ok {
+		return nil, distribution.ErrBlobUnknown
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.opens++
+	s.active++
+	if s.active > s.peak {
+		s.peak = s.active
+	}
+	r := &streamingBlobReader{store: s, data: data}
+	if s.opens <= s.failures {
+		r.failAfter = s.failAfter
+	}
+	return r, nil
+}
+
+type streamingBlobReader struct {
+	store     *streamingBlobStore
+	data      []byte
+	offset    int64
+	read      int64
+	failAfter int64
+}
+
+func (r *streamingBlobReader) Read(p []byte) (int, error) {
+	if r.store.block != nil {
+		<-r.store.block
+	}
+	if r.failAfter > 0 && r.read >= r.failAfter {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if r.offset >= int64(len(r.data)) {
+		return 0, io.EOF
+	}
+	if len(p) > 10 {
+		p = p[:10]
+	}
+	if r.failAfter > 0 && int64(len(p)) > r.failAfter-r.read {
+		p = p[:r.failAfter-r.read]
+	}
+	n := copy(p, r.data[r.offset:])
+	r.offset += int64(n)
+	r.read += int64(n)
+	return n, nil
+}
+
+func (r *streamingBlobReader) Seek(offset int64, whence int) (int64, error) {
+	if whence != io.SeekStart {
+		return 0, fmt.Errorf("unsupported whence")
+	}
+	r.store.lock.Lock()
+	r.store.offsets = append(r.store.offsets, offset)
+	r.store.lock.Unlock()
+	r.offset = offset
+	return offset, nil
+}
+
+func (r *streamingBlobReader) Close() error {
+	r.store.lock.Lock()
+	defer r.store.lock.Unlock()
+	r.store.active--
+	return nil
+}
+
+func TestBlobFetcherFetch(t *testing.T) {
+	blob := []byte(strings.Repeat("0123456789", 10))
+	desc := distribution.Descriptor{Digest: digest.FromBytes(blob), Size: int64(len(blob))}
+
+	tests := []struct {
+		name        string
+		desc        distribution.Descriptor
+		failures    int
+		maxResumes  int
+		wantErr     string
+		wantOffsets []int64
+	}{
+		{
+			name: "complete download",
+			desc: desc,
+		},
+		{
+			name:        "resumed download",
+			desc:        desc,
+			failures:    2,
+			maxResumes:  2,
+			wantOffsets: []int64{25, 50},
+		},
+		{
+			name:       "too many interruptions",
+			desc:       desc,
+			failures:   2,
+			maxResumes: 1,
+			wantErr:    io.ErrUnexpectedEOF.Error(),
+		},
+		{
+			name:    "digest mismatch",
+			desc:    distribution.Descriptor{Digest: digest.FromString("other"), Size: int64(len(blob))},
+			wantErr: "content integrity error",
+		},
+		{
+			name:    "size mismatch",
+			desc:    distribution.Descriptor{Digest: desc.Digest, Size: 10},
+			wantErr: "has 100 bytes, expected 10",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := &streamingBlobStore{
+				blobs:     map[digest.Digest][]byte{desc.Digest: blob, digest.FromString("other"): blob},
+				failAfter: 25,
+				failures:  test.failures,
+			}
+			fetcher := NewBlobFetcher(BlobFetchOptions{MaxResumes: test.maxResumes})
+			out := &bytes.Buffer{}
+			_, err := fetcher.Fetch(context.Background(), store, test.desc, out)
+			if len(test.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected error %q, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out.Bytes(), blob) {
+				t.Errorf("unexpected content %q", out.String())
+			}
+			if fmt.Sprint(store.offsets) != fmt.Sprint(test.wantOffsets) {
+				t.Errorf("expected resumes at %v, got %v", test.wantOffsets, store.offsets)
+			}
+		})
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestBlobFetcherFetchAll(t *testing.T) {
+	store := &streamingBlobStore{blobs: map[digest.Digest][]byte{}, block: make(chan struct{})}
+	var descs []distribution.Descriptor
+	for i := 0; i < 6; i++ {
+		blob := []byte(fmt.Sprintf("blob-%d", i))
+		store.blobs[digest.FromBytes(blob)] = blob
+		descs = append(descs, distribution.Descriptor{Digest: digest.FromBytes(blob), Size: int64(len(blob))})
+	}
+
+	var lock sync.Mutex
+	outs := map[digest.Digest]*bytes.Buffer{}
+	fetcher := NewBlobFetcher(BlobFetchOptions{Concurrency: 2})
+	done := make(chan error)
+	go func() {
+		done <- fetcher.FetchAll(context.Background(), store, descs, func(desc distribution.Descriptor) (io.WriteCloser, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			outs[desc.Digest] = &bytes.Buffer{}
+			return nopWriteCloser{outs[desc.Digest]}, nil
+		})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(store.block)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if store.peak != 2 {
+		t.Errorf("expected 2 concurrent downloads, got %d", store.peak)
+	}
+	for dgst, blob := range store.blobs {
+		if !bytes.Equal(outs[dgst].Bytes(), blob) {
+			t.Errorf("unexpected content of %s: %q", dgst, outs[dgst].String())
+		}
+	}
+
+	// the first error is returned
+	descs = append(descs, distribution.Descriptor{Digest: digest.FromString("missing")})
+	err := fetcher.FetchAll(context.Background(), store, descs, func(desc distribution.Descriptor) (io.WriteCloser, error) {
+		return nopWriteCloser{io.Discard}, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), digest.FromString("missing").String()) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestBlobFetcherBandwidth(t *testing.T) {
+	blob := bytes.Repeat([]byte("x"), 3*blobFetchChunkSize)
+	store := &streamingBlobStore{blobs: map[digest.Digest][]byte{digest.FromBytes(blob): blob}}
+	fetcher := NewBlobFetcher(BlobFetchOptions{BytesPerSecond: 2 * blobFetchChunkSize})
+	start := time.Now()
+	if _, err := fetcher.Fetch(context.Background(), store, distribution.Descriptor{Digest: digest.FromBytes(blob)}, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	// the burst covers two chunks, the last one takes 500ms
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("expected the download to be throttled, took %s", elapsed)
+	}
+}
+
+func TestBlobFetcherRedirect(t *testing.T) {
+	blob := []byte(strings.Repeat("0123456789", 10))
+	desc := distribution.Descriptor{Digest: digest.FromBytes(blob), Size: int64(len(blob))}
+
+	var lock sync.Mutex
+	var storageAuths []string
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		storageAuths = append(storageAuths, r.Header.Get("Authorization"))
+		lock.Unlock()
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(blob))
+	}))
+	defer storage.Close()
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/":
+		case "/v2/test/image/blobs/" + desc.Digest.String():
+			http.Redirect(w, r, storage.URL+"/blob", http.StatusTemporaryRedirect)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer registry.Close()
+
+	ctx := context.Background()
+	registryURL, err := url.Parse(registry.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewContext(http.DefaultTransport, http.DefaultTransport).
+		WithCredentials(NoCredentials).
+		WithRequestModifiers(transport.NewHeaderRequestModifier(http.Header{"Authorization": {"Bearer secret"}}))
+	repo, err := c.Repository(ctx, registryURL, "test/image", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	if _, err := NewBlobFetcher(BlobFetchOptions{}).Fetch(ctx, repo.Blobs(ctx), desc, out); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), blob) {
+		t.Errorf("unexpected content %q", out.String())
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if len(storageAuths) == 0 {
+		t.Fatal("expected the download to be redirected to the storage")
+	}
+	for _, auth := range storageAuths {
+		if len(auth) > 0 {
+			t.Errorf("the credentials of the registry were forwarded to the storage: %q", auth)
+		}
+	}
+}
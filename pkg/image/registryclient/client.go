@@ -262,6 +262,22 @@ func (c *Context) Repository(ctx context.Context, registry *url.URL, repoName st
 	}, nil
 }
 
+// RepositoryTransport returns a transport authenticating the requests to the repository repoName of the registry
+// like the repositories of the context, and the URL of the registry to send them to. It serves the parts of the
+// registry API which distribution.Repository does not cover, e.g. the OCI referrers API. If insecure is true, HTTP
+// connections are allowed and HTTPS certificate verification errors will be ignored.
+func (c *Context) RepositoryTransport(ctx context.Context, registry *url.URL, repoName string, insecure bool) (http.RoundTripper, *url.URL, error) {
+	ref, err := imagereference.Parse(fmt.Sprintf("%s/%s", registry.Host, repoName))
+	if err != nil {
+		return nil, nil, err
+	}
+	rt, src, err := c.Ping(ctx, registry, insecure)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.repositoryTransport(rt, src, repoName, ref), src, nil
+}
+
 // connectToRegistry is private and returns a non-wrapped, non-mirrorable repository.
 func (c *Context) connectToRegistry(ctx context.Context, locator repositoryLocator, insecure bool) (RepositoryWithLocation, error) {
 	var named reference.Named = locator.named
@@ -398,28 +414,64 @@ func (c *Context) cachedTransport(rt http.RoundTripper, host string, scopes []au
 		creds = c.CredentialsFactory.CredentialStoreFor(ref.AsRepository().String())
 	}
 
-	modifiers := []transport.RequestModifier{
+	t := NewHostTransport(host, NewAuthorizingTransport(rt, c.Challenges, creds, scopes, c.RequestModifiers...), rt)
+	c.cachedTransports = append(c.cachedTransports, transportCache{
+		rt:        rt,
+		host:      host,
+		scopes:    scopeNames,
+		transport: t,
+	})
+	return t
+}
+
+// NewAuthorizingTransport returns a transport authenticating the requests sent with rt like the repositories of a
+// Context: with a bearer token of the scopes or with basic authentication, using the credentials, as challenged by
+// the registries in the responses recorded in challenges. The modifiers are applied to the requests after the
+// authentication.
+func NewAuthorizingTransport(rt http.RoundTripper, challenges challenge.Manager, credentials auth.CredentialStore, scopes []auth.Scope, modifiers ...transport.RequestModifier) http.RoundTripper {
+	var all []transport.RequestModifier
+	if resolver, ok := credentials.(credentialResolver); ok {
+		all = append(all, resolveCredentialsModifier{resolver: resolver})
+	}
+	all = append(all,
 		// TODO: slightly smarter authorizer that retries unauthenticated requests
 		// TODO: make multiple attempts if the first credential fails
 		auth.NewAuthorizer(
-			c.Challenges,
+			challenges,
 			auth.NewTokenHandlerWithOptions(auth.TokenHandlerOptions{
 				Transport:   rt,
-				Credentials: creds,
+				Credentials: credentials,
 				Scopes:      scopes,
 			}),
-			auth.NewBasicHandler(creds),
+			auth.NewBasicHandler(credentials),
 		),
+	)
+	all = append(all, modifiers...)
+	return transport.NewTransport(rt, all...)
+}
+
+// NewHostTransport returns a transport sending the requests to the host of the registry with the authorizing
+// transport, and the requests to other hosts, e.g. the storage the downloads of blobs are redirected to, with rt, so
+// that the credentials of the registry are not forwarded to them.
+func NewHostTransport(host string, authorizing, rt http.RoundTripper) http.RoundTripper {
+	return &hostTransport{host: host, authorizing: authorizing, rt: rt}
+}
+
+type hostTransport struct {
+	host        string
+	authorizing http.RoundTripper
+	rt          http.RoundTripper
+}
+
+func (t *hostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host == t.host {
+		return t.authorizing.RoundTrip(req)
 	}
-	modifiers = append(modifiers, c.RequestModifiers...)
-	t := transport.NewTransport(rt, modifiers...)
-	c.cachedTransports = append(c.cachedTransports, transportCache{
-		rt:        rt,
-		host:      host,
-		scopes:    scopeNames,
-		transport: t,
-	})
-	return t
+	if len(req.Header.Get("Authorization")) > 0 {
+		req = req.Clone(req.Context())
+		req.Header.Del("Authorization")
+	}
+	return t.rt.RoundTrip(req)
 }
 
 func (c *Context) scopes(repoName string) []auth.Scope {
@@ -555,7 +607,7 @@ func (c retryManifest) Get(ctx context.Context, dgst digest.Digest, options ...d
 		if err := c.repo.limiter.Wait(ctx); err != nil {
 			return nil, err
 		}
-		m, err := c.ManifestService.Get(ctx, dgst, options...)
+		m, err := c.ManifestService.Get(ctx, dgst, withManifestMediaTypes(options)...)
 		if c.repo.shouldRetry(i, err) {
 			continue
 		}
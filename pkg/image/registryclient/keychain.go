@@ -0,0 +1,338 @@
+package registryclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+
+	"github.com/distribution/distribution/v3/registry/client/auth"
+
+	imagereference "github.com/openshift/library-go/pkg/image/reference"
+)
+
+const (
+	tokenExchangeGrantType      = "urn:ietf:params:oauth:grant-type:token-exchange"
+	accessTokenType             = "urn:ietf:params:oauth:token-type:access_token"
+	jwtTokenType                = "urn:ietf:params:oauth:token-type:jwt"
+	defaultTokenExchangeTTL     = 10 * time.Minute
+	defaultTokenExchangeTimeout = 30 * time.Second
+	tokenExpiryMargin           = time.Minute
+	maxTokenExchangeResponse    = 1024 * 1024
+)
+
+// Credential is the authentication material of a registry.
+type Credential struct {
+	Username string
+	Password string
+	// IdentityToken is an OAuth2 refresh token, exchanged for access tokens at the token server of the registry
+	// instead of the username and password.
+	IdentityToken string
+	// Expiry is when the credential expires, zero when it does not.
+	Expiry time.Time
+}
+
+// Keychain provides the credentials of registries, e.g. by exchanging the token of a workload identity, so that no
+// long-lived pull secret is needed. Implementations must be safe for concurrent use.
+type Keychain interface {
+	// Resolve returns the credential for the image repository, e.g. quay.io/openshift/origin-cli, and false when the
+	// keychain has no credential for it.
+	Resolve(ctx context.Context, image string) (Credential, bool, error)
+}
+
+// KeychainFunc adapts a function to a Keychain.
+type KeychainFunc func(ctx context.Context, image string) (Credential, bool, error)
+
+func (f KeychainFunc) Resolve(ctx context.Context, image string) (Credential, bool, error) {
+	return f(ctx, image)
+}
+
+// NewMultiKeychain returns a keychain resolving credentials with the first of the keychains that has one. Errors of
+// a keychain are returned without trying the next ones, to not fall back to other identities silently.
+func NewMultiKeychain(keychains ...Keychain) Keychain {
+	return KeychainFunc(func(ctx context.Context, image string) (Credential, bool, error) {
+		for _, keychain := range keychains {
+			credential, ok, err := keychain.Resolve(ctx, image)
+			if err != nil || ok {
+				return credential, ok, err
+			}
+		}
+		return Credential{}, false, nil
+	})
+}
+
+// NewCredentialStoreKeychain returns a keychain with the basic credentials of the store, e.g. from a docker config,
+// to combine them with other keychains.
+func NewCredentialStoreKeychain(store auth.CredentialStore) Keychain {
+	return KeychainFunc(func(ctx context.Context, image string) (Credential, bool, error) {
+		username, password := store.Basic(&url.URL{Scheme: "https", Host: registryHost(image)})
+		if len(username) == 0 && len(password) == 0 {
+			return Credential{}, false, nil
+		}
+		return Credential{Username: username, Password: password}, true, nil
+	})
+}
+
+// NewKeychainCredentialStoreFactory returns a CredentialStoreFactory resolving the credentials of the images with the
+// keychain, to use with Context.WithCredentialsFactory. The Context resolves the credential before each request to
+// the registry, with the context of the request, and fails the request when the credential cannot be resolved.
+func NewKeychainCredentialStoreFactory(keychain Keychain) CredentialStoreFactory {
+	return &keychainCredentialStoreFactory{keychain: keychain}
+}
+
+type keychainCredentialStoreFactory struct {
+	keychain Keychain
+}
+
+func (f *keychainCredentialStoreFactory) CredentialStoreFor(image string) auth.CredentialStore {
+	return &keychainCredentialStore{keychain: f.keychain, image: image, refreshTokenStore: &refreshTokenStore{}}
+}
+
+// credentialResolver is a CredentialStore whose credential is resolved before each request.
+type credentialResolver interface {
+	resolve(ctx context.Context) error
+}
+
+// keychainCredentialStore holds the last credential resolved for an image. The URLs passed by the authentication
+// handlers are those of the registry or its token server, the credential is the one of the image in both cases.
+type keychainCredentialStore struct {
+	keychain Keychain
+	image    string
+	*refreshTokenStore
+
+	lock       sync.Mutex
+	credential Credential
+}
+
+var _ credentialResolver = &keychainCredentialStore{}
+
+func (s *keychainCredentialStore) resolve(ctx context.Context) error {
+	credential, ok, err := s.keychain.Resolve(ctx, s.image)
+	if err != nil {
+		return fmt.Errorf("unable to resolve the credential of %s: %w", s.image, err)
+	}
+	if !ok {
+		credential = Credential{}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.credential = credential
+	return nil
+}
+
+func (s *keychainCredentialStore) resolved() Credential {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.credential
+}
+
+func (s *keychainCredentialStore) Basic(url *url.URL) (string, string) {
+	credential := s.resolved()
+	return credential.Username, credential.Password
+}
+
+func (s *keychainCredentialStore) RefreshToken(url *url.URL, service string) string {
+	if token := s.refreshTokenStore.RefreshToken(url, service); len(token) > 0 {
+		return token
+	}
+	return s.resolved().IdentityToken
+}
+
+// resolveCredentialsModifier resolves the credential of the store before each request.
+type resolveCredentialsModifier struct {
+	resolver credentialResolver
+}
+
+func (m resolveCredentialsModifier) ModifyRequest(req *http.Request) error {
+	return m.resolver.resolve(req.Context())
+}
+
+// TokenSource provides the token of a workload identity.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenSourceFunc adapts a function to a TokenSource.
+type TokenSourceFunc func(ctx context.Context) (string, error)
+
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// NewFileTokenSource returns a token source reading the token of the file, e.g. a projected service account token.
+// The file is read for every token, as it is rotated.
+func NewFileTokenSource(path string) TokenSource {
+	return TokenSourceFunc(func(ctx context.Context) (string, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		token := strings.TrimSpace(string(data))
+		if len(token) == 0 {
+			return "", fmt.Errorf("the token file %s is empty", path)
+		}
+		return token, nil
+	})
+}
+
+// TokenExchangeOptions configures the exchange of the token of a workload identity for a registry access token,
+// following the OAuth 2.0 Token Exchange (RFC 8693).
+type TokenExchangeOptions struct {
+	// Registries are the hosts of the registries the access token is used for, e.g. us-docker.pkg.dev. A leading
+	// "*." matches all subdomains.
+	Registries []string
+	// TokenURL is the token exchange endpoint, e.g. https://sts.googleapis.com/v1/token.
+	TokenURL string
+	// Audience identifies the workload identity provider at the token exchange endpoint.
+	Audience string
+	// Scope is the optional space separated scope of the access token.
+	Scope string
+	// SubjectTokenType is the type of the subject token, urn:ietf:params:oauth:token-type:jwt by default.
+	SubjectTokenType string
+	// SubjectToken provides the token of the workload identity.
+	SubjectToken TokenSource
+	// Username is the registry username of the access token, e.g. oauth2accesstoken.
+	Username string
+	// Client sends the token exchange requests. By default, a client with the proxy of the environment and a timeout
+	// of 30 seconds.
+	Client *http.Client
+}
+
+// NewTokenExchangeKeychain returns a keychain exchanging the token of a workload identity for a registry access
+// token, used as the password of the registries. The access token is cached until shortly before it expires.
+func NewTokenExchangeKeychain(options TokenExchangeOptions) Keychain {
+	if len(options.SubjectTokenType) == 0 {
+		options.SubjectTokenType = jwtTokenType
+	}
+	if options.Client == nil {
+		options.Client = &http.Client{
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSHandshakeTimeout: 10 * time.Second},
+			Timeout:   defaultTokenExchangeTimeout,
+		}
+	}
+	return &tokenExchangeKeychain{options: options, clock: clock.RealClock{}}
+}
+
+type tokenExchangeKeychain struct {
+	options TokenExchangeOptions
+	clock   clock.PassiveClock
+
+	lock   sync.Mutex
+	cached *Credential
+}
+
+type tokenExchangeResponse struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int64  `json:"expires_in"`
+}
+
+func (k *tokenExchangeKeychain) Resolve(ctx context.Context, image string) (Credential, bool, error) {
+	if !matchesRegistry(k.options.Registries, registryHost(image)) {
+		return Credential{}, false, nil
+	}
+
+	k.lock.Lock()
+	cached := k.cached
+	k.lock.Unlock()
+	if cached != nil && k.clock.Now().Add(tokenExpiryMargin).Before(cached.Expiry) {
+		return *cached, true, nil
+	}
+
+	// the lock is not held during the exchange, concurrent resolutions may exchange the token concurrently
+	credential, err := k.exchange(ctx)
+	if err != nil {
+		return Credential{}, false, fmt.Errorf("unable to exchange the workload identity token at %s: %w", k.options.TokenURL, err)
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if k.cached == nil || k.cached.Expiry.Before(credential.Expiry) {
+		k.cached = credential
+	}
+	return *credential, true, nil
+}
+
+func (k *tokenExchangeKeychain) exchange(ctx context.Context) (*Credential, error) {
+	subjectToken, err := k.options.SubjectToken.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":           {tokenExchangeGrantType},
+		"requested_token_type": {accessTokenType},
+		"subject_token":        {subjectToken},
+		"subject_token_type":   {k.options.SubjectTokenType},
+	}
+	if len(k.options.Audience) > 0 {
+		form.Set("audience", k.options.Audience)
+	}
+	if len(k.options.Scope) > 0 {
+		form.Set("scope", k.options.Scope)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.options.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := k.options.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTokenExchangeResponse))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	response := &tokenExchangeResponse{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	if len(response.AccessToken) == 0 {
+		return nil, fmt.Errorf("the response has no access token")
+	}
+	ttl := defaultTokenExchangeTTL
+	if response.ExpiresIn > 0 {
+		ttl = time.Duration(response.ExpiresIn) * time.Second
+	}
+	return &Credential{
+		Username: k.options.Username,
+		Password: response.AccessToken,
+		Expiry:   k.clock.Now().Add(ttl),
+	}, nil
+}
+
+// registryHost returns the registry host of the image, docker.io for images without registry.
+func registryHost(image string) string {
+	ref, err := imagereference.Parse(image)
+	if err != nil || len(ref.Registry) == 0 {
+		return imagereference.DockerDefaultRegistry
+	}
+	return ref.Registry
+}
+
+func matchesRegistry(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if pattern == host {
+			return true
+		}
+		if strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package registryclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestTokenExchangeKeychain(t *testing.T) {
+	var exchanges int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		expected := url.Values{
+			"grant_type":           {"urn:ietf:params:oauth:grant-type:token-exchange"},
+			"requested_token_type": {"urn:ietf:params:oauth:token-type:access_token"},
+			"subject_token":        {"workload-token"},
+			"subject_token_type":   {"urn:ietf:params:oauth:token-type:jwt"},
+			"audience":             {"//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/pool/providers/cluster"},
+		}
+		if fmt.Sprint(r.PostForm) != fmt.Sprint(expected) {
+			http.Error(w, fmt.Sprintf("unexpected form %v", r.PostForm), http.StatusBadRequest)
+			return
+		}
+		exchanges++
+		fmt.Fprintf(w, `{"access_token": "access-token-%d", "issued_token_type": "urn:ietf:params:oauth:token-type:access_token", "token_type": "Bearer", "expires_in": 3600}`, exchanges)
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("workload-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	keychain := NewTokenExchangeKeychain(TokenExchangeOptions{
+		Registries:   []string{"*.pkg.dev", "gcr.io"},
+		TokenURL:     server.URL,
+		Audience:     "//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/pool/providers/cluster",
+		SubjectToken: NewFileTokenSource(tokenFile),
+		Username:     "oauth2accesstoken",
+	}).(*tokenExchangeKeychain)
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	fakeClock := clocktesting.NewFakePassiveClock(now)
+	keychain.clock = fakeClock
+
+	credential, ok, err := keychain.Resolve(context.Background(), "us-docker.pkg.dev/project/repository/image")
+	if err != nil || !ok {
+		t.Fatalf("unexpected result %v %v", ok, err)
+	}
+	if expected := (Credential{Username: "oauth2accesstoken", Password: "access-token-1", Expiry: now.Add(time.Hour)}); credential != expected {
+		t.Errorf("expected %#v, got %#v", expected, credential)
+	}
+
+	// the access token is cached until shortly before it expires
+	now = now.Add(58 * time.Minute)
+	fakeClock.SetTime(now)
+	if credential, _, _ := keychain.Resolve(context.Background(), "gcr.io/project/image"); credential.Password != "access-token-1" {
+		t.Errorf("expected the cached access token, got %q", credential.Password)
+	}
+	now = now.Add(time.Minute)
+	fakeClock.SetTime(now)
+	if credential, _, _ := keychain.Resolve(context.Background(), "gcr.io/project/image"); credential.Password != "access-token-2" {
+		t.Errorf("expected a new access token, got %q", credential.Password)
+	}
+
+	if _, ok, err := keychain.Resolve(context.Background(), "quay.io/openshift/origin-cli"); ok || err != nil {
+		t.Errorf("unexpected credential for another registry: %v %v", ok, err)
+	}
+
+	// a failed exchange is an error
+	if err := os.WriteFile(tokenFile, []byte("expired-token"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Hour)
+	fakeClock.SetTime(now)
+	if _, _, err := keychain.Resolve(context.Background(), "gcr.io/project/image"); err == nil {
+		t.Error("expected error for a rejected exchange")
+	}
+}
+
+func TestMultiKeychain(t *testing.T) {
+	basic := NewBasicCredentials()
+	basic.Add(&url.URL{Host: "quay.io"}, "user", "password")
+	identity := KeychainFunc(func(ctx context.Context, image string) (Credential, bool, error) {
+		if registryHost(image) != "registry.example.com" {
+			return Credential{}, false, nil
+		}
+		return Credential{IdentityToken: "refresh-token"}, true, nil
+	})
+	failing := KeychainFunc(func(ctx context.Context, image string) (Credential, bool, error) {
+		if registryHost(image) != "docker.io" {
+			return Credential{}, false, nil
+		}
+		return Credential{}, false, fmt.Errorf("identity unavailable")
+	})
+	factory := NewKeychainCredentialStoreFactory(NewMultiKeychain(identity, NewCredentialStoreKeychain(basic), failing))
+
+	tests := []struct {
+		image            string
+		expectedUser     string
+		expectedPassword string
+		expectedRefresh  string
+		expectedErr      bool
+	}{
+		{image: "quay.io/openshift/origin-cli", expectedUser: "user", expectedPassword: "password"},
+		{image: "registry.example.com/openshift/origin-cli", expectedRefresh: "refresh-token"},
+		// errors fail the request instead of accessing the registry anonymously
+		{image: "library/busybox", expectedErr: true},
+		{image: "registry.other.com/openshift/origin-cli"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			store := factory.CredentialStoreFor(tt.image)
+			req, err := http.NewRequest(http.MethodGet, "https://registry.example.com/v2/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = resolveCredentialsModifier{resolver: store.(credentialResolver)}.ModifyRequest(req)
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("unexpected error %v", err)
+			}
+			// the URL of the token server does not change the credential of the image
+			realm := &url.URL{Scheme: "https", Host: "auth.example.com", Path: "/token"}
+			if user, password := store.Basic(realm); user != tt.expectedUser || password != tt.expectedPassword {
+				t.Errorf("unexpected basic credentials %q %q", user, password)
+			}
+			if refresh := store.RefreshToken(realm, "registry"); refresh != tt.expectedRefresh {
+				t.Errorf("unexpected refresh token %q", refresh)
+			}
+			store.SetRefreshToken(realm, "registry", "rotated")
+			if refresh := store.RefreshToken(realm, "registry"); refresh != "rotated" {
+				t.Errorf("expected the rotated refresh token, got %q", refresh)
+			}
+		})
+	}
+}
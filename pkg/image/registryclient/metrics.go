@@ -0,0 +1,72 @@
+package registryclient
+
+import (
+	k8smetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	namespace = "registry_client"
+)
+
+// metrics provides access to all registry client metrics.
+var metrics *registryClientMetrics
+
+func init() {
+	metrics = newRegistryClientMetrics(legacyregistry.Register)
+}
+
+// registryClientMetrics instruments the retries and circuit breakers of the registry client with prometheus metrics.
+type registryClientMetrics struct {
+	retries         *k8smetrics.CounterVec
+	circuitRejected *k8smetrics.CounterVec
+	circuitState    *k8smetrics.GaugeVec
+}
+
+// newRegistryClientMetrics creates a new registryClientMetrics, configured with default metric names.
+func newRegistryClientMetrics(registerFunc func(k8smetrics.Registerable) error) *registryClientMetrics {
+	retries := k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: namespace,
+			Name:      "retries_total",
+			Help:      "Total number of retried registry requests, labeled with the registry host and the reason of the retry",
+		}, []string{"host", "reason"})
+	registerFunc(retries)
+
+	circuitRejected := k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: namespace,
+			Name:      "circuit_rejected_requests_total",
+			Help:      "Total number of registry requests rejected because the circuit of the registry host is open",
+		}, []string{"host"})
+	registerFunc(circuitRejected)
+
+	circuitState := k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: namespace,
+			Name:      "circuit_state",
+			Help:      "The state of the circuit of the registry hosts: 0 when closed, 1 when open, 2 when half-open",
+		}, []string{"host"})
+	registerFunc(circuitState)
+
+	return &registryClientMetrics{
+		retries:         retries,
+		circuitRejected: circuitRejected,
+		circuitState:    circuitState,
+	}
+}
+
+// Retry records a retry of a request to the host.
+func (m *registryClientMetrics) Retry(host, reason string) {
+	m.retries.WithLabelValues(host, reason).Inc()
+}
+
+// CircuitRejected records a request to the host rejected by its open circuit.
+func (m *registryClientMetrics) CircuitRejected(host string) {
+	m.circuitRejected.WithLabelValues(host).Inc()
+}
+
+// SetCircuitState records the state of the circuit of the host.
+func (m *registryClientMetrics) SetCircuitState(host string, state circuitState) {
+	m.circuitState.WithLabelValues(host).Set(float64(state))
+}
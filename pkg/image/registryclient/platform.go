@@ -0,0 +1,322 @@
+package registryclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/manifestlist"
+	"github.com/distribution/distribution/v3/manifest/schema2"
+	"github.com/opencontainers/go-digest"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	// register the OCI image manifest schema, so that the manifests of OCI indexes can be retrieved. The registered
+	// schemas are the default Accept headers of the distribution client, the requests of the repositories of this
+	// package list theirs instead, see withManifestMediaTypes.
+	_ "github.com/distribution/distribution/v3/manifest/ocischema"
+)
+
+var (
+	// indexMediaTypes are accepted when getting a manifest that can be an image index or manifest list.
+	indexMediaTypes = []string{
+		manifestlist.MediaTypeManifestList,
+		imagespecv1.MediaTypeImageIndex,
+		schema2.MediaTypeManifest,
+		imagespecv1.MediaTypeImageManifest,
+	}
+	// platformManifestMediaTypes are accepted when getting the manifest of a platform of an image index.
+	platformManifestMediaTypes = []string{
+		schema2.MediaTypeManifest,
+		imagespecv1.MediaTypeImageManifest,
+	}
+)
+
+// withManifestMediaTypes returns the options with the media types accepted by default, unless they list some. The
+// default are the registered ones but the OCI image manifest, which only the callers handling it ask for, e.g.
+// GetPlatformManifest, so that registering its schema does not change the manifests served to the other callers.
+func withManifestMediaTypes(options []distribution.ManifestServiceOption) []distribution.ManifestServiceOption {
+	for _, option := range options {
+		if _, ok := option.(distribution.WithManifestMediaTypesOption); ok {
+			return options
+		}
+	}
+	var mediaTypes []string
+	for _, mediaType := range distribution.ManifestMediaTypes() {
+		if mediaType != imagespecv1.MediaTypeImageManifest {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+	}
+	return append(options, distribution.WithManifestMediaTypes(mediaTypes))
+}
+
+// Platform is the operating system, CPU architecture and optional CPU variant an image runs on.
+type Platform struct {
+	OS           string
+	Architecture string
+	Variant      string
+}
+
+// ParsePlatform parses a platform of the form os/arch[/variant], e.g. linux/arm64 or linux/arm/v7. The common
+// aliases of the architectures, e.g. x86_64 or aarch64, are normalized.
+func ParsePlatform(s string) (Platform, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Platform{}, fmt.Errorf("invalid platform %q, expected os/arch[/variant]", s)
+	}
+	for _, part := range parts {
+		if len(part) == 0 {
+			return Platform{}, fmt.Errorf("invalid platform %q, expected os/arch[/variant]", s)
+		}
+	}
+	platform := Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		platform.Variant = parts[2]
+	}
+	return platform.normalize(), nil
+}
+
+// DefaultPlatform returns the platform of the running process.
+func DefaultPlatform() Platform {
+	return Platform{OS: runtime.GOOS, Architecture: runtime.GOARCH}.normalize()
+}
+
+func (p Platform) String() string {
+	if len(p.Variant) > 0 {
+		return p.OS + "/" + p.Architecture + "/" + p.Variant
+	}
+	return p.OS + "/" + p.Architecture
+}
+
+// normalize applies the aliases of the architectures and their default variants.
+func (p Platform) normalize() Platform {
+	p.OS = strings.ToLower(p.OS)
+	p.Architecture = strings.ToLower(p.Architecture)
+	switch p.Architecture {
+	case "x86_64", "x86-64":
+		p.Architecture = "amd64"
+	case "aarch64":
+		p.Architecture = "arm64"
+	case "armhf":
+		p.Architecture, p.Variant = "arm", "v7"
+	case "armel":
+		p.Architecture, p.Variant = "arm", "v6"
+	case "i386":
+		p.Architecture = "386"
+	}
+	switch {
+	case p.Architecture == "arm64" && p.Variant == "v8":
+		p.Variant = ""
+	case p.Architecture == "amd64" && p.Variant == "v1":
+		p.Variant = ""
+	}
+	return p
+}
+
+// compatibleVariants returns the variants of the architecture able to run on the platform, from the most to the
+// least specific, excluding its own variant.
+func (p Platform) compatibleVariants() []string {
+	var variants []string
+	switch p.Architecture {
+	case "amd64":
+		variants = []string{"v4", "v3", "v2", ""}
+	case "arm":
+		variants = []string{"v8", "v7", "v6", "v5"}
+	default:
+		return nil
+	}
+	for i, variant := range variants {
+		if variant == p.Variant {
+			return variants[i+1:]
+		}
+	}
+	return nil
+}
+
+// PlatformFallback is the policy applied when an image index has no manifest for any of the requested platforms.
+type PlatformFallback int
+
+const (
+	// NoPlatformFallback only selects manifests of the requested platforms.
+	NoPlatformFallback PlatformFallback = iota
+	// CompatibleVariantFallback selects the manifest of an older variant of a requested architecture able to run on
+	// it, e.g. linux/arm/v6 for linux/arm/v7, or linux/amd64 for linux/amd64/v3.
+	CompatibleVariantFallback
+	// AnyPlatformFallback selects a compatible variant, or else the first image manifest of the index, e.g. for tools
+	// only reading the content of multi-arch payloads identical for all the platforms.
+	AnyPlatformFallback
+)
+
+// PlatformSelection configures the selection of a manifest in an image index or manifest list.
+type PlatformSelection struct {
+	// Platforms are the requested platforms in the order of preference. A platform without variant matches the
+	// manifests of any variant of the architecture, preferring the one without variant. DefaultPlatform() is used
+	// when empty.
+	Platforms []Platform
+	// Fallback is the policy applied when no manifest matches the platforms.
+	Fallback PlatformFallback
+}
+
+// ErrNoMatchingManifest is returned when an image index has no manifest for the requested platforms.
+type ErrNoMatchingManifest struct {
+	Digest    digest.Digest
+	Requested []Platform
+	Available []Platform
+}
+
+func (e *ErrNoMatchingManifest) Error() string {
+	return fmt.Sprintf("the image index %s has no manifest for %s, available platforms: %s", e.Digest, joinPlatforms(e.Requested), joinPlatforms(e.Available))
+}
+
+func joinPlatforms(platforms []Platform) string {
+	if len(platforms) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(platforms))
+	for _, platform := range platforms {
+		names = append(names, platform.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// indexManifest is a descriptor of an image index, with the fields of OCI artifacts that the manifest list of the
+// distribution library does not expose.
+type indexManifest struct {
+	MediaType    string                `json:"mediaType"`
+	Digest       digest.Digest         `json:"digest"`
+	ArtifactType string                `json:"artifactType,omitempty"`
+	Annotations  map[string]string     `json:"annotations,omitempty"`
+	Platform     *imagespecv1.Platform `json:"platform,omitempty"`
+}
+
+// dockerReferenceTypeAnnotation marks the attestation manifests that buildkit adds to image indexes.
+const dockerReferenceTypeAnnotation = "vnd.docker.reference.type"
+
+// isImage returns whether the descriptor references the image manifest of a platform, rather than an artifact such
+// as an attestation or a signature, or a nested index.
+func (m indexManifest) isImage() bool {
+	switch m.MediaType {
+	case schema2.MediaTypeManifest, imagespecv1.MediaTypeImageManifest:
+	default:
+		return false
+	}
+	if len(m.ArtifactType) > 0 || len(m.Annotations[dockerReferenceTypeAnnotation]) > 0 {
+		return false
+	}
+	return m.Platform != nil && m.Platform.OS != "unknown" && m.Platform.Architecture != "unknown"
+}
+
+func (m indexManifest) platform() Platform {
+	return Platform{OS: m.Platform.OS, Architecture: m.Platform.Architecture, Variant: m.Platform.Variant}.normalize()
+}
+
+// SelectPlatformManifest returns the descriptor of the manifest of the image index or manifest list for the
+// platforms of the selection. The artifacts of the index, e.g. attestations and signatures, are never selected.
+// An *ErrNoMatchingManifest is returned when the index has no manifest for the selection.
+func SelectPlatformManifest(list *manifestlist.DeserializedManifestList, selection PlatformSelection) (distribution.Descriptor, error) {
+	_, payload, err := list.Payload()
+	if err != nil {
+		return distribution.Descriptor{}, err
+	}
+	var index struct {
+		Manifests []indexManifest `json:"manifests"`
+	}
+	if err := json.Unmarshal(payload, &index); err != nil {
+		return distribution.Descriptor{}, err
+	}
+	var images []indexManifest
+	for _, m := range index.Manifests {
+		if m.isImage() {
+			images = append(images, m)
+		}
+	}
+
+	requested := selection.Platforms
+	if len(requested) == 0 {
+		requested = []Platform{DefaultPlatform()}
+	}
+	find := func(want Platform, anyVariant bool) (indexManifest, bool) {
+		var candidate *indexManifest
+		for i := range images {
+			have := images[i].platform()
+			if have.OS != want.OS || have.Architecture != want.Architecture {
+				continue
+			}
+			if have.Variant == want.Variant {
+				return images[i], true
+			}
+			if anyVariant && candidate == nil {
+				candidate = &images[i]
+			}
+		}
+		if candidate != nil {
+			return *candidate, true
+		}
+		return indexManifest{}, false
+	}
+
+	for _, platform := range requested {
+		platform = platform.normalize()
+		if m, ok := find(platform, len(platform.Variant) == 0); ok {
+			return descriptorFor(list, m.Digest), nil
+		}
+	}
+	if selection.Fallback >= CompatibleVariantFallback {
+		for _, platform := range requested {
+			platform = platform.normalize()
+			for _, variant := range platform.compatibleVariants() {
+				platform.Variant = variant
+				if m, ok := find(platform, false); ok {
+					return descriptorFor(list, m.Digest), nil
+				}
+			}
+		}
+	}
+	if selection.Fallback >= AnyPlatformFallback && len(images) > 0 {
+		return descriptorFor(list, images[0].Digest), nil
+	}
+
+	noMatch := &ErrNoMatchingManifest{Digest: digest.FromBytes(payload), Requested: requested}
+	for _, m := range images {
+		noMatch.Available = append(noMatch.Available, m.platform())
+	}
+	return distribution.Descriptor{}, noMatch
+}
+
+// descriptorFor returns the descriptor of the list with the digest.
+func descriptorFor(list *manifestlist.DeserializedManifestList, dgst digest.Digest) distribution.Descriptor {
+	for _, m := range list.Manifests {
+		if m.Digest == dgst {
+			return m.Descriptor
+		}
+	}
+	return distribution.Descriptor{Digest: dgst}
+}
+
+// GetPlatformManifest retrieves the manifest with the digest and, when it is an image index or manifest list, the
+// manifest of the selected platform. It returns the image manifest and its digest. Single platform manifests are
+// returned as is, as their platform is only known from their image configuration.
+func GetPlatformManifest(ctx context.Context, manifests distribution.ManifestService, dgst digest.Digest, selection PlatformSelection) (distribution.Manifest, digest.Digest, error) {
+	m, err := manifests.Get(ctx, dgst, distribution.WithManifestMediaTypes(indexMediaTypes))
+	if err != nil {
+		return nil, "", err
+	}
+	list, ok := m.(*manifestlist.DeserializedManifestList)
+	if !ok {
+		return m, dgst, nil
+	}
+	desc, err := SelectPlatformManifest(list, selection)
+	if err != nil {
+		return nil, "", err
+	}
+	m, err = manifests.Get(ctx, desc.Digest, distribution.WithManifestMediaTypes(platformManifestMediaTypes))
+	if err != nil {
+		return nil, "", err
+	}
+	if _, ok := m.(*manifestlist.DeserializedManifestList); ok {
+		return nil, "", fmt.Errorf("the manifest %s selected in the image index %s is an image index", desc.Digest, dgst)
+	}
+	return m, desc.Digest, nil
+}
@@ -0,0 +1,229 @@
+package registryclient
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/manifestlist"
+	"github.com/distribution/distribution/v3/manifest/ocischema"
+	"github.com/distribution/distribution/v3/manifest/schema2"
+	"github.com/opencontainers/go-digest"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestParsePlatform(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected string
+		wantErr  bool
+	}{
+		{in: "linux/amd64", expected: "linux/amd64"},
+		{in: "linux/x86_64", expected: "linux/amd64"},
+		{in: "linux/aarch64/v8", expected: "linux/arm64"},
+		{in: "linux/arm/v7", expected: "linux/arm/v7"},
+		{in: "Linux/ARMHF", expected: "linux/arm/v7"},
+		{in: "linux", wantErr: true},
+		{in: "linux//v7", wantErr: true},
+		{in: "linux/arm/v7/extra", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			platform, err := ParsePlatform(test.in)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil && platform.String() != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, platform)
+			}
+		})
+	}
+}
+
+// testIndexManifest returns an index entry of an OCI image manifest for the platform.
+func testIndexManifest(platform string) string {
+	parts := strings.Split(platform, "/")
+	variant := ""
+	if len(parts) == 3 {
+		variant = fmt.Sprintf(`,"variant":%q`, parts[2])
+	}
+	return fmt.Sprintf(`{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":%q,"size":100,"platform":{"os":%q,"architecture":%q%s}}`,
+		digest.FromString(platform), parts[0], parts[1], variant)
+}
+
+func testIndex(t *testing.T, manifests ...string) *manifestlist.DeserializedManifestList {
+	payload := `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[` + strings.Join(manifests, ",") + `]}`
+	m, _, err := distribution.UnmarshalManifest("application/vnd.oci.image.index.v1+json", []byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m.(*manifestlist.DeserializedManifestList)
+}
+
+func TestSelectPlatformManifest(t *testing.T) {
+	attestation := `{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"` + digest.FromString("attestation").String() + `","size":100,` +
+		`"annotations":{"vnd.docker.reference.type":"attestation-manifest"},"platform":{"os":"unknown","architecture":"unknown"}}`
+	signature := `{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"` + digest.FromString("signature").String() + `","size":100,` +
+		`"artifactType":"application/vnd.dev.cosign.artifact.sig.v1+json","platform":{"os":"linux","architecture":"arm64"}}`
+
+	tests := []struct {
+		name      string
+		index     []string
+		selection PlatformSelection
+		expected  string
+		wantErr   string
+	}{
+		{
+			name:      "exact match in order of preference",
+			index:     []string{testIndexManifest("linux/amd64"), testIndexManifest("linux/arm64"), testIndexManifest("linux/s390x")},
+			selection: PlatformSelection{Platforms: []Platform{{OS: "linux", Architecture: "s390x"}, {OS: "linux", Architecture: "amd64"}}},
+			expected:  "linux/s390x",
+		},
+		{
+			name:      "default variant",
+			index:     []string{testIndexManifest("linux/arm/v6"), testIndexManifest("linux/arm64/v8")},
+			selection: PlatformSelection{Platforms: []Platform{{OS: "linux", Architecture: "arm64"}}},
+			expected:  "linux/arm64/v8",
+		},
+		{
+			name:      "platform without variant matches any variant",
+			index:     []string{testIndexManifest("linux/arm/v6"), testIndexManifest("linux/arm/v7")},
+			selection: PlatformSelection{Platforms: []Platform{{OS: "linux", Architecture: "arm"}}},
+			expected:  "linux/arm/v6",
+		},
+		{
+			name:      "compatible variant",
+			index:     []string{testIndexManifest("linux/arm/v5"), testIndexManifest("linux/arm/v6")},
+			selection: PlatformSelection{Platforms: []Platform{{OS: "linux", Architecture: "arm", Variant: "v7"}}, Fallback: CompatibleVariantFallback},
+			expected:  "linux/arm/v6",
+		},
+		{
+			name:      "compatible variant without fallback",
+			index:     []string{testIndexManifest("linux/amd64")},
+			selection: PlatformSelection{Platforms: []Platform{{OS: "linux", Architecture: "amd64", Variant: "v3"}}},
+			wantErr:   "has no manifest for linux/amd64/v3, available platforms: linux/amd64",
+		},
+		{
+			name:      "compatible amd64 variant",
+			index:     []string{testIndexManifest("linux/amd64")},
+			selection: PlatformSelection{Platforms: []Platform{{OS: "linux", Architecture: "amd64", Variant: "v3"}}, Fallback: CompatibleVariantFallback},
+			expected:  "linux/amd64",
+		},
+		{
+			name:      "artifacts are not selected",
+			index:     []string{attestation, signature, testIndexManifest("linux/amd64")},
+			selection: PlatformSelection{Platforms: []Platform{{OS: "linux", Architecture: "arm64"}}, Fallback: CompatibleVariantFallback},
+			wantErr:   "has no manifest for linux/arm64, available platforms: linux/amd64",
+		},
+		{
+			name:      "any platform",
+			index:     []string{attestation, testIndexManifest("linux/ppc64le"), testIndexManifest("linux/amd64")},
+			selection: PlatformSelection{Platforms: []Platform{{OS: "linux", Architecture: "arm64"}}, Fallback: AnyPlatformFallback},
+			expected:  "linux/ppc64le",
+		},
+		{
+			name:      "no image manifests",
+			index:     []string{attestation},
+			selection: PlatformSelection{Platforms: []Platform{{OS: "linux", Architecture: "arm64"}}, Fallback: AnyPlatformFallback},
+			wantErr:   "has no manifest for linux/arm64, available platforms: none",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			desc, err := SelectPlatformManifest(testIndex(t, test.index...), test.selection)
+			if len(test.wantErr) > 0 {
+				if _, ok := err.(*ErrNoMatchingManifest); !ok || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected error %q, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if desc.Digest != digest.FromString(test.expected) {
+				t.Errorf("expected the manifest of %s, got %s", test.expected, desc.Digest)
+			}
+		})
+	}
+}
+
+type mapManifestService struct {
+	distribution.ManifestService
+	manifests map[digest.Digest]distribution.Manifest
+}
+
+func (s *mapManifestService) Get(ctx context.Context, dgst digest.Digest, options ...distribution.ManifestServiceOption) (distribution.Manifest, error) {
+	m, ok := s.manifests[dgst]
+	if !ok {
+		return nil, distribution.ErrManifestUnknownRevision{Revision: dgst}
+	}
+	return m, nil
+}
+
+func TestGetPlatformManifest(t *testing.T) {
+	image, _, err := distribution.UnmarshalManifest("application/vnd.oci.image.manifest.v1+json",
+		[]byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"`+digest.FromString("config").String()+`","size":10},"layers":[]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := image.(*ocischema.DeserializedManifest); !ok {
+		t.Fatalf("unexpected manifest %T", image)
+	}
+	index := testIndex(t, testIndexManifest("linux/amd64"), testIndexManifest("linux/arm64"))
+	imageDigest := digest.FromString("linux/arm64")
+	ms := &mapManifestService{manifests: map[digest.Digest]distribution.Manifest{
+		imageDigest:                image,
+		digest.FromString("index"): index,
+	}}
+
+	m, dgst, err := GetPlatformManifest(context.Background(), ms, digest.FromString("index"), PlatformSelection{Platforms: []Platform{{OS: "linux", Architecture: "arm64"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != image || dgst != imageDigest {
+		t.Errorf("unexpected manifest %s", dgst)
+	}
+
+	// single platform manifests are returned as is
+	m, dgst, err = GetPlatformManifest(context.Background(), ms, imageDigest, PlatformSelection{Platforms: []Platform{{OS: "linux", Architecture: "s390x"}}})
+	if err != nil || m != image || dgst != imageDigest {
+		t.Errorf("unexpected manifest %s: %v", dgst, err)
+	}
+
+	_, _, err = GetPlatformManifest(context.Background(), ms, digest.FromString("index"), PlatformSelection{Platforms: []Platform{{OS: "linux", Architecture: "s390x"}}})
+	if _, ok := err.(*ErrNoMatchingManifest); !ok {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestWithManifestMediaTypes(t *testing.T) {
+	accepted := func(options []distribution.ManifestServiceOption) []string {
+		for _, option := range options {
+			if o, ok := option.(distribution.WithManifestMediaTypesOption); ok {
+				return o.MediaTypes
+			}
+		}
+		return nil
+	}
+	contains := func(mediaTypes []string, mediaType string) bool {
+		for _, t := range mediaTypes {
+			if t == mediaType {
+				return true
+			}
+		}
+		return false
+	}
+
+	// the registered OCI image manifest is only accepted on request
+	defaults := accepted(withManifestMediaTypes([]distribution.ManifestServiceOption{distribution.WithTag("latest")}))
+	if !contains(defaults, schema2.MediaTypeManifest) || contains(defaults, imagespecv1.MediaTypeImageManifest) {
+		t.Errorf("unexpected default media types %v", defaults)
+	}
+	requested := accepted(withManifestMediaTypes([]distribution.ManifestServiceOption{distribution.WithManifestMediaTypes(platformManifestMediaTypes)}))
+	if !reflect.DeepEqual(requested, platformManifestMediaTypes) {
+		t.Errorf("expected the requested media types, got %v", requested)
+	}
+}
@@ -0,0 +1,321 @@
+package registryclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// RetryOptions configures the retries of the requests to registries, and the circuit breakers of the hosts.
+type RetryOptions struct {
+	// MaxRetries is the number of times a failed request is retried.
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry, doubled for every further retry up to MaxBackoff. A
+	// Retry-After header of the response takes precedence, and the request is not retried when it asks for a delay
+	// larger than MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// FailureThreshold is the number of consecutive failures of a host opening its circuit: the requests to the host
+	// then fail immediately for OpenDuration. The circuit is closed again when the first request after that succeeds.
+	FailureThreshold int
+	OpenDuration     time.Duration
+}
+
+// DefaultRetryOptions returns the default options of the retry transport.
+func DefaultRetryOptions() RetryOptions {
+	return RetryOptions{
+		MaxRetries:       3,
+		InitialBackoff:   500 * time.Millisecond,
+		MaxBackoff:       30 * time.Second,
+		FailureThreshold: 10,
+		OpenDuration:     30 * time.Second,
+	}
+}
+
+// ErrCircuitOpen is returned for requests to a host whose circuit is open after consecutive failures.
+type ErrCircuitOpen struct {
+	Host  string
+	Until time.Time
+}
+
+func (e *ErrCircuitOpen) Error() string {
+	return fmt.Sprintf("requests to %s are suspended until %s after repeated failures", e.Host, e.Until.Format(time.RFC3339))
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker tracks the consecutive failures of a host.
+type circuitBreaker struct {
+	state     circuitState
+	failures  int
+	openUntil time.Time
+	// probeSince is when the probe of the half open circuit was let through.
+	probeSince time.Time
+}
+
+// retryTransport retries the requests failing with server errors or rate limiting, with exponential backoff, and
+// suspends the requests to hosts failing repeatedly.
+type retryTransport struct {
+	rt      http.RoundTripper
+	options RetryOptions
+
+	nowFn func() time.Time
+	// sleepFn replaces the wait between retries in tests.
+	sleepFn func(time.Duration)
+
+	lock     sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+// NewRetryTransport wraps the round tripper with retries and per host circuit breakers. Only the requests that can
+// be replayed, i.e. without body or with a GetBody function, are retried.
+func NewRetryTransport(rt http.RoundTripper, options RetryOptions) http.RoundTripper {
+	return &retryTransport{
+		rt:       rt,
+		options:  options,
+		nowFn:    time.Now,
+		breakers: make(map[string]*circuitBreaker),
+	}
+}
+
+// WithRetryTransport wraps the transports of the context with NewRetryTransport. The retries of the repositories are
+// disabled, so that failed requests are not retried twice.
+func (c *Context) WithRetryTransport(options RetryOptions) *Context {
+	if c.Transport != nil {
+		c.Transport = NewRetryTransport(c.Transport, options)
+	}
+	if c.InsecureTransport != nil {
+		c.InsecureTransport = NewRetryTransport(c.InsecureTransport, options)
+	}
+	c.Retries = 0
+	return c
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	// probing is set while this request probes a half open circuit. A probe that ends without a result, e.g. because
+	// it was cancelled, gives the slot back so that the next request probes the host.
+	probing := false
+	defer func() {
+		if probing {
+			t.releaseProbe(host)
+		}
+	}()
+	for attempt := 0; ; attempt++ {
+		probe, err := t.allow(host)
+		if err != nil {
+			metrics.CircuitRejected(host)
+			return nil, err
+		}
+		probing = probe
+
+		attemptReq := req
+		if attempt > 0 {
+			var err error
+			if attemptReq, err = rewindRequest(req); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := t.rt.RoundTrip(attemptReq)
+		if err != nil && req.Context().Err() != nil {
+			// cancelled requests say nothing about the health of the host
+			return resp, err
+		}
+		failed, reason := isRetriableResult(resp, err)
+		t.record(host, failed && reason != "rate_limited")
+		probing = false
+		if !failed || attempt >= t.options.MaxRetries || !canRewind(req) {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), t.nowFn()); ok {
+				if retryAfter > t.options.MaxBackoff {
+					return resp, err
+				}
+				delay = retryAfter
+			}
+			// release the connection of the failed attempt
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		metrics.Retry(host, reason)
+		klog.V(4).Infof("Retrying %s %s in %s after %s (%d retries remaining)", req.Method, req.URL, delay, reason, t.options.MaxRetries-attempt)
+
+		if err := t.wait(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wait waits for the delay, or until the context is done.
+func (t *retryTransport) wait(ctx context.Context, delay time.Duration) error {
+	if t.sleepFn != nil {
+		t.sleepFn(delay)
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// allow returns an error when the circuit of the host is open. The first request after the circuit was open for
+// OpenDuration is let through to probe the host, and allow returns true for it. A probe without result after another
+// OpenDuration is given up, and the next request probes the host instead.
+func (t *retryTransport) allow(host string) (bool, error) {
+	if t.options.FailureThreshold <= 0 {
+		return false, nil
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	breaker := t.breakers[host]
+	if breaker == nil {
+		return false, nil
+	}
+	now := t.nowFn()
+	switch breaker.state {
+	case circuitOpen:
+		if now.Before(breaker.openUntil) {
+			return false, &ErrCircuitOpen{Host: host, Until: breaker.openUntil}
+		}
+		breaker.state = circuitHalfOpen
+		breaker.probeSince = now
+		metrics.SetCircuitState(host, circuitHalfOpen)
+		return true, nil
+	case circuitHalfOpen:
+		if now.Before(breaker.probeSince.Add(t.options.OpenDuration)) {
+			// a probe is in flight
+			return false, &ErrCircuitOpen{Host: host, Until: breaker.probeSince.Add(t.options.OpenDuration)}
+		}
+		klog.V(2).Infof("The probe of %s did not complete within %s, probing again", host, t.options.OpenDuration)
+		breaker.probeSince = now
+		return true, nil
+	}
+	return false, nil
+}
+
+// releaseProbe gives back the probe slot of a half open circuit when the probe ended without result.
+func (t *retryTransport) releaseProbe(host string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	breaker := t.breakers[host]
+	if breaker == nil || breaker.state != circuitHalfOpen {
+		return
+	}
+	breaker.state = circuitOpen
+	metrics.SetCircuitState(host, circuitOpen)
+}
+
+// record updates the circuit of the host with the result of a request.
+func (t *retryTransport) record(host string, failed bool) {
+	if t.options.FailureThreshold <= 0 {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	breaker := t.breakers[host]
+	if breaker == nil {
+		breaker = &circuitBreaker{}
+		t.breakers[host] = breaker
+	}
+
+	if !failed {
+		if breaker.state != circuitClosed {
+			klog.V(2).Infof("Requests to %s succeed again, closing its circuit", host)
+			metrics.SetCircuitState(host, circuitClosed)
+		}
+		breaker.state = circuitClosed
+		breaker.failures = 0
+		return
+	}
+	breaker.failures++
+	if breaker.state == circuitHalfOpen || breaker.failures >= t.options.FailureThreshold {
+		if breaker.state != circuitOpen {
+			klog.V(2).Infof("Suspending requests to %s for %s after %d consecutive failures", host, t.options.OpenDuration, breaker.failures)
+		}
+		breaker.state = circuitOpen
+		breaker.openUntil = t.nowFn().Add(t.options.OpenDuration)
+		metrics.SetCircuitState(host, circuitOpen)
+	}
+}
+
+// backoff returns the exponential backoff of the attempt, with up to 10% jitter.
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	delay := t.options.InitialBackoff
+	for i := 0; i < attempt && delay < t.options.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > t.options.MaxBackoff {
+		delay = t.options.MaxBackoff
+	}
+	if delay > 0 {
+		delay += time.Duration(rand.Int63n(int64(delay)/10 + 1))
+	}
+	return delay
+}
+
+// isRetriableResult returns whether the request failed in a way worth retrying, and the reason.
+func isRetriableResult(resp *http.Response, err error) (bool, string) {
+	if err != nil {
+		return true, "connection_error"
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true, "rate_limited"
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true, "server_error"
+	}
+	return false, ""
+}
+
+func canRewind(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+// parseRetryAfter parses the delay in seconds or the date of a Retry-After header.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if len(value) == 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
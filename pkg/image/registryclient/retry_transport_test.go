@@ -0,0 +1,274 @@
+package registryclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/component-base/metrics/testutil"
+)
+
+// fakeRoundTripper returns the responses in order, a status of 0 being a connection error.
+type fakeRoundTripper struct {
+	responses []fakeResponse
+	bodies    []string
+}
+
+type fakeResponse struct {
+	status     int
+	retryAfter string
+}
+
+func (rt *fakeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		body, _ := io.ReadAll(req.Body)
+		rt.bodies = append(rt.bodies, string(body))
+	} else {
+		rt.bodies = append(rt.bodies, "")
+	}
+	if len(rt.responses) == 0 {
+		return nil, fmt.Errorf("unexpected request")
+	}
+	response := rt.responses[0]
+	rt.responses = rt.responses[1:]
+	if response.status == 0 {
+		return nil, errors.New("connection refused")
+	}
+	resp := &http.Response{StatusCode: response.status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("body"))}
+	if len(response.retryAfter) > 0 {
+		resp.Header.Set("Retry-After", response.retryAfter)
+	}
+	return resp, nil
+}
+
+func TestRetryTransport(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	options := RetryOptions{MaxRetries: 3, InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		responses      []fakeResponse
+		expectedStatus int
+		expectedErr    string
+		expectedSleeps []time.Duration
+	}{
+		{
+			name:           "success",
+			responses:      []fakeResponse{{status: http.StatusOK}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "not found is not retried",
+			responses:      []fakeResponse{{status: http.StatusNotFound}},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "exponential backoff",
+			responses:      []fakeResponse{{status: http.StatusServiceUnavailable}, {status: 0}, {status: http.StatusBadGateway}, {status: http.StatusOK}},
+			expectedStatus: http.StatusOK,
+			expectedSleeps: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			name:           "retries exhausted",
+			responses:      []fakeResponse{{status: 0}, {status: 0}, {status: 0}, {status: 0}},
+			expectedErr:    "connection refused",
+			expectedSleeps: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			name:           "retry after seconds",
+			responses:      []fakeResponse{{status: http.StatusTooManyRequests, retryAfter: "7"}, {status: http.StatusOK}},
+			expectedStatus: http.StatusOK,
+			expectedSleeps: []time.Duration{7 * time.Second},
+		},
+		{
+			name:           "retry after date",
+			responses:      []fakeResponse{{status: http.StatusServiceUnavailable, retryAfter: now.Add(3 * time.Second).Format(http.TimeFormat)}, {status: http.StatusOK}},
+			expectedStatus: http.StatusOK,
+			expectedSleeps: []time.Duration{3 * time.Second},
+		},
+		{
+			name:           "retry after beyond the maximum backoff",
+			responses:      []fakeResponse{{status: http.StatusTooManyRequests, retryAfter: "3600"}},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:           "body is replayed",
+			method:         http.MethodPut,
+			body:           "manifest",
+			responses:      []fakeResponse{{status: http.StatusInternalServerError}, {status: http.StatusCreated}},
+			expectedStatus: http.StatusCreated,
+			expectedSleeps: []time.Duration{time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeRoundTripper{responses: tt.responses}
+			transport := NewRetryTransport(fake, options).(*retryTransport)
+			transport.nowFn = func() time.Time { return now }
+			var sleeps []time.Duration
+			transport.sleepFn = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+			method := tt.method
+			if len(method) == 0 {
+				method = http.MethodGet
+			}
+			var body io.Reader
+			if len(tt.body) > 0 {
+				body = strings.NewReader(tt.body)
+			}
+			req, err := http.NewRequest(method, "https://"+strings.ReplaceAll(tt.name, " ", "-")+".example.com/v2/", body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := transport.RoundTrip(req)
+			if len(tt.expectedErr) > 0 {
+				if err == nil || err.Error() != tt.expectedErr {
+					t.Fatalf("expected error %q, got %v", tt.expectedErr, err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if len(fake.responses) > 0 {
+				t.Errorf("%d responses were not requested", len(fake.responses))
+			}
+
+			if len(sleeps) != len(tt.expectedSleeps) {
+				t.Fatalf("expected sleeps %v, got %v", tt.expectedSleeps, sleeps)
+			}
+			for i, sleep := range sleeps {
+				// up to 10% jitter is added to the exponential backoff
+				if expected := tt.expectedSleeps[i]; sleep < expected || sleep > expected+expected/10 {
+					t.Errorf("expected sleeps %v, got %v", tt.expectedSleeps, sleeps)
+				}
+			}
+			for _, requestBody := range fake.bodies {
+				if requestBody != tt.body {
+					t.Errorf("expected request body %q, got %q", tt.body, requestBody)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryTransportCircuitBreaker(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	fake := &fakeRoundTripper{}
+	transport := NewRetryTransport(fake, RetryOptions{MaxRetries: 1, FailureThreshold: 3, OpenDuration: time.Minute}).(*retryTransport)
+	transport.nowFn = func() time.Time { return now }
+	transport.sleepFn = func(time.Duration) {}
+
+	get := func(host string, responses ...fakeResponse) (*http.Response, error) {
+		fake.responses = responses
+		req, err := http.NewRequest(http.MethodGet, "https://"+host+"/v2/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return transport.RoundTrip(req)
+	}
+
+	// rate limiting is not a failure of the host
+	if _, err := get("mirror.example.com", fakeResponse{status: http.StatusTooManyRequests}, fakeResponse{status: http.StatusTooManyRequests}); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := get("mirror.example.com", fakeResponse{status: http.StatusBadGateway}, fakeResponse{status: http.StatusBadGateway}); err != nil || resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("unexpected result %v %v", resp, err)
+	}
+	// the third consecutive failure opens the circuit, the retry is rejected
+	_, err := get("mirror.example.com", fakeResponse{status: 0})
+	var circuitErr *ErrCircuitOpen
+	if !errors.As(err, &circuitErr) || circuitErr.Host != "mirror.example.com" || !circuitErr.Until.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected the circuit to be open, got %v", err)
+	}
+	if _, err := get("mirror.example.com"); !errors.As(err, &circuitErr) {
+		t.Fatalf("expected the circuit to be open, got %v", err)
+	}
+	// other hosts are not affected
+	if _, err := get("registry.example.com", fakeResponse{status: http.StatusOK}); err != nil {
+		t.Fatal(err)
+	}
+
+	if value, err := testutil.GetCounterMetricValue(metrics.circuitRejected.WithLabelValues("mirror.example.com")); err != nil || value != 2 {
+		t.Errorf("expected 2 rejected requests, got %v %v", value, err)
+	}
+	if value, err := testutil.GetGaugeMetricValue(metrics.circuitState.WithLabelValues("mirror.example.com")); err != nil || value != float64(circuitOpen) {
+		t.Errorf("expected the open circuit state, got %v %v", value, err)
+	}
+
+	// a failed probe opens the circuit again
+	now = now.Add(time.Minute)
+	if _, err := get("mirror.example.com", fakeResponse{status: http.StatusServiceUnavailable}); !errors.As(err, &circuitErr) || !circuitErr.Until.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected the circuit to be open again, got %v", err)
+	}
+	// a successful probe closes it
+	now = now.Add(time.Minute)
+	if resp, err := get("mirror.example.com", fakeResponse{status: http.StatusOK}); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected result %v %v", resp, err)
+	}
+	if resp, err := get("mirror.example.com", fakeResponse{status: http.StatusOK}); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected result %v %v", resp, err)
+	}
+	if value, err := testutil.GetGaugeMetricValue(metrics.circuitState.WithLabelValues("mirror.example.com")); err != nil || value != float64(circuitClosed) {
+		t.Errorf("expected the closed circuit state, got %v %v", value, err)
+	}
+}
+
+func TestRetryTransportCircuitBreakerProbe(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	fake := &fakeRoundTripper{}
+	transport := NewRetryTransport(fake, RetryOptions{FailureThreshold: 1, OpenDuration: time.Minute}).(*retryTransport)
+	transport.nowFn = func() time.Time { return now }
+	transport.sleepFn = func(time.Duration) {}
+
+	get := func(ctx context.Context, responses ...fakeResponse) (*http.Response, error) {
+		fake.responses = responses
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://probe.example.com/v2/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return transport.RoundTrip(req)
+	}
+
+	if _, err := get(context.Background(), fakeResponse{status: 0}); err == nil {
+		t.Fatal("expected the request to fail")
+	}
+	var circuitErr *ErrCircuitOpen
+	if _, err := get(context.Background()); !errors.As(err, &circuitErr) {
+		t.Fatalf("expected the circuit to be open, got %v", err)
+	}
+
+	// a cancelled probe gives the slot back
+	now = now.Add(time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := get(ctx, fakeResponse{status: 0}); errors.As(err, &circuitErr) {
+		t.Fatalf("expected the probe to be let through, got %v", err)
+	}
+	if resp, err := get(context.Background(), fakeResponse{status: http.StatusOK}); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the next request to probe the host, got %v %v", resp, err)
+	}
+
+	// a probe that never completes is given up after the open duration
+	if _, err := get(context.Background(), fakeResponse{status: 0}); err == nil {
+		t.Fatal("expected the request to fail")
+	}
+	now = now.Add(time.Minute)
+	if probe, err := transport.allow("probe.example.com"); !probe || err != nil {
+		t.Fatalf("expected a probe, got %v %v", probe, err)
+	}
+	if _, err := get(context.Background()); !errors.As(err, &circuitErr) {
+		t.Fatalf("expected the circuit to be half open, got %v", err)
+	}
+	now = now.Add(time.Minute)
+	if resp, err := get(context.Background(), fakeResponse{status: http.StatusOK}); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the stale probe to be given up, got %v %v", resp, err)
+	}
+}
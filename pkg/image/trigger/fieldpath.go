@@ -0,0 +1,165 @@
+package trigger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fieldPathSegment is a field of a field path, with the optional list item selected in the field.
+type fieldPathSegment struct {
+	field string
+	// index is the index of the selected list item, -1 when the item is selected by key or not at all
+	index int
+	// key and value select the list item whose key field has the value, e.g. name=="test"
+	key   string
+	value string
+}
+
+func (s fieldPathSegment) selectsItem() bool {
+	return s.index >= 0 || len(s.key) > 0
+}
+
+// parseFieldPath parses the subset of JSONPath used by the field paths of the triggers: dot separated fields, with
+// list items selected by index, e.g. spec.containers[0].image, or by the value of one of their fields, e.g.
+// spec.containers[?(@.name=="test")].image.
+func parseFieldPath(path string) ([]fieldPathSegment, error) {
+	var segments []fieldPathSegment
+	rest := strings.TrimPrefix(path, ".")
+	for len(rest) > 0 {
+		end := strings.IndexAny(rest, ".[")
+		if end == -1 {
+			end = len(rest)
+		}
+		segment := fieldPathSegment{field: rest[:end], index: -1}
+		if len(segment.field) == 0 {
+			return nil, fmt.Errorf("field path is not valid: %s", path)
+		}
+		rest = rest[end:]
+
+		if strings.HasPrefix(rest, "[") {
+			closing := selectorEnd(rest)
+			if closing == -1 {
+				return nil, fmt.Errorf("field path is not valid: %s", path)
+			}
+			if err := segment.parseSelector(rest[1:closing]); err != nil {
+				return nil, fmt.Errorf("field path is not valid: %s: %v", path, err)
+			}
+			rest = rest[closing+1:]
+		}
+		segments = append(segments, segment)
+
+		switch {
+		case len(rest) == 0:
+		case rest[0] == '.' && len(rest) > 1:
+			rest = rest[1:]
+		default:
+			return nil, fmt.Errorf("field path is not valid: %s", path)
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("field path is not valid: %s", path)
+	}
+	return segments, nil
+}
+
+// selectorEnd returns the index of the bracket closing the selector at the start of s, ignoring the brackets of
+// quoted values, or -1.
+func selectorEnd(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *fieldPathSegment) parseSelector(selector string) error {
+	if index, err := strconv.Atoi(selector); err == nil {
+		if index < 0 {
+			return fmt.Errorf("negative index %d", index)
+		}
+		s.index = index
+		return nil
+	}
+	expression := strings.TrimSuffix(strings.TrimPrefix(selector, "?(@."), ")")
+	if expression == selector {
+		return fmt.Errorf("unsupported selector %q", selector)
+	}
+	parts := strings.SplitN(expression, "==", 2)
+	if len(parts) != 2 || len(parts[0]) == 0 {
+		return fmt.Errorf("unsupported selector %q", selector)
+	}
+	value := parts[1]
+	if len(value) < 2 || (value[0] != '"' && value[0] != '\'') || value[len(value)-1] != value[0] {
+		return fmt.Errorf("the value of the selector %q must be quoted", selector)
+	}
+	s.key, s.value = parts[0], value[1:len(value)-1]
+	return nil
+}
+
+// resolvedField is a field of an object resolved from a field path.
+type resolvedField struct {
+	// parent is the object holding the field
+	parent map[string]interface{}
+	name   string
+}
+
+// resolveFieldPath resolves the field of the object at the end of the field path. The field itself may be missing,
+// but all the fields and list items leading to it must exist.
+func resolveFieldPath(obj map[string]interface{}, segments []fieldPathSegment) (*resolvedField, error) {
+	current := obj
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		if last && !segment.selectsItem() {
+			return &resolvedField{parent: current, name: segment.field}, nil
+		}
+
+		value, ok := current[segment.field]
+		if !ok {
+			return nil, fmt.Errorf("the field %s does not exist", segment.field)
+		}
+		if !segment.selectsItem() {
+			if current, ok = value.(map[string]interface{}); !ok {
+				return nil, fmt.Errorf("the field %s is not an object", segment.field)
+			}
+			continue
+		}
+
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("the field %s is not a list", segment.field)
+		}
+		index := segment.index
+		if len(segment.key) > 0 {
+			index = -1
+			for j, item := range items {
+				if item, ok := item.(map[string]interface{}); ok && item[segment.key] == segment.value {
+					index = j
+					break
+				}
+			}
+			if index == -1 {
+				return nil, fmt.Errorf("no item of %s has %s %q", segment.field, segment.key, segment.value)
+			}
+		}
+		if index >= len(items) {
+			return nil, fmt.Errorf("the field %s has no item %d", segment.field, index)
+		}
+		if last {
+			return nil, fmt.Errorf("the field path selects an item of %s instead of a field", segment.field)
+		}
+		if current, ok = items[index].(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("the item %d of %s is not an object", index, segment.field)
+		}
+	}
+	return nil, fmt.Errorf("the field path is empty")
+}
@@ -0,0 +1,104 @@
+package trigger
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/klog/v2"
+)
+
+// ImageFieldUpdate is the update of an image field of an object by a trigger.
+type ImageFieldUpdate struct {
+	// FieldPath is the field path of the trigger.
+	FieldPath string
+	// OldImage is the image of the field before the update, empty when the field was not set.
+	OldImage string
+	// NewImage is the image the trigger resolved.
+	NewImage string
+}
+
+// UpdateUnstructuredFromImages resolves the triggers of the object of any kind, e.g. a custom resource, to the
+// fields their field paths point to. Unlike UpdateObjectFromImages, which only supports the containers of the kinds
+// with pod templates, the field path of a trigger can point to any string field, e.g. spec.image or
+// spec.components[?(@.name=="api")].image, whose parent fields must exist. If changes are necessary, it lazily copies
+// obj and returns it with the updates, or if no changes are necessary returns nil.
+func UpdateUnstructuredFromImages(obj *unstructured.Unstructured, tagRetriever TagRetriever) (*unstructured.Unstructured, []ImageFieldUpdate, error) {
+	_, _, triggers, err := CalculateAnnotationTriggers(obj, "/")
+	if err != nil {
+		return nil, nil, err
+	}
+	klog.V(5).Infof("%s/%s has triggers: %#v", obj.GetKind(), obj.GetName(), triggers)
+
+	var updated *unstructured.Unstructured
+	var updates []ImageFieldUpdate
+	for _, trigger := range triggers {
+		if trigger.Paused {
+			continue
+		}
+		segments, err := parseFieldPath(trigger.FieldPath)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		namespace := trigger.From.Namespace
+		if len(namespace) == 0 {
+			namespace = obj.GetNamespace()
+		}
+		ref, _, ok := tagRetriever.ImageStreamTag(namespace, trigger.From.Name)
+		if !ok {
+			klog.V(5).Infof("%s/%s detected no pending image on %s from %#v", obj.GetKind(), obj.GetName(), trigger.FieldPath, trigger.From)
+			continue
+		}
+
+		target := obj
+		if updated != nil {
+			target = updated
+		}
+		field, err := resolveFieldPath(target.Object, segments)
+		if err != nil {
+			return nil, nil, fmt.Errorf("field path %s cannot be resolved: %v", trigger.FieldPath, err)
+		}
+		var current string
+		if value, ok := field.parent[field.name]; ok {
+			if current, ok = value.(string); !ok {
+				return nil, nil, fmt.Errorf("field path %s does not point to a string", trigger.FieldPath)
+			}
+		}
+		if current == ref {
+			continue
+		}
+
+		if updated == nil {
+			updated = obj.DeepCopy()
+			if field, err = resolveFieldPath(updated.Object, segments); err != nil {
+				return nil, nil, err
+			}
+		}
+		klog.V(5).Infof("%s/%s detected change on %s = %s", obj.GetKind(), obj.GetName(), trigger.FieldPath, ref)
+		field.parent[field.name] = ref
+		updates = append(updates, ImageFieldUpdate{
+			FieldPath: trigger.FieldPath,
+			OldImage:  current,
+			NewImage:  ref,
+		})
+	}
+	return updated, updates, nil
+}
+
+// ImageUpdatesPatch returns the strategic merge patch from the original object to the object updated by
+// UpdateUnstructuredFromImages, to send with the StrategicMergePatchType patch type. dataStruct is the Go type of the
+// kind, e.g. &appsv1.Deployment{}, whose patch strategies merge the list items by key, so that the patch only
+// changes the image fields of the items the triggers selected.
+func ImageUpdatesPatch(original, updated *unstructured.Unstructured, dataStruct interface{}) ([]byte, error) {
+	originalData, err := json.Marshal(original.Object)
+	if err != nil {
+		return nil, err
+	}
+	updatedData, err := json.Marshal(updated.Object)
+	if err != nil {
+		return nil, err
+	}
+	return strategicpatch.CreateTwoWayMergePatch(originalData, updatedData, dataStruct)
+}
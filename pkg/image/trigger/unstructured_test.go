@@ -0,0 +1,213 @@
+package trigger
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// testWidget is the Go type of the Widget custom resource of the tests, whose components are merged by name.
+type testWidget struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              testWidgetSpec `json:"spec"`
+}
+
+type testWidgetSpec struct {
+	Image      string                `json:"image,omitempty"`
+	Components []testWidgetComponent `json:"components,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+	Sidecars   []testWidgetComponent `json:"sidecars,omitempty"`
+}
+
+type testWidgetComponent struct {
+	Name  string `json:"name,omitempty"`
+	Image string `json:"image,omitempty"`
+}
+
+func testUnstructured(triggers []ObjectFieldTrigger, spec string) *unstructured.Unstructured {
+	data, _ := json.Marshal(triggers)
+	obj := &unstructured.Unstructured{}
+	if err := json.Unmarshal([]byte(`{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"test","namespace":"default"},"spec":`+spec+`}`), &obj.Object); err != nil {
+		panic(err)
+	}
+	obj.SetAnnotations(map[string]string{TriggerAnnotationKey: string(data)})
+	return obj
+}
+
+func TestParseFieldPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []fieldPathSegment
+		wantErr  bool
+	}{
+		{
+			path:     "spec.image",
+			expected: []fieldPathSegment{{field: "spec", index: -1}, {field: "image", index: -1}},
+		},
+		{
+			path:     ".spec.components[2].image",
+			expected: []fieldPathSegment{{field: "spec", index: -1}, {field: "components", index: 2}, {field: "image", index: -1}},
+		},
+		{
+			path:     `spec.components[?(@.name=="a.b[0]")].image`,
+			expected: []fieldPathSegment{{field: "spec", index: -1}, {field: "components", index: -1, key: "name", value: "a.b[0]"}, {field: "image", index: -1}},
+		},
+		{
+			path:     `spec.components[?(@.id=='api')].image`,
+			expected: []fieldPathSegment{{field: "spec", index: -1}, {field: "components", index: -1, key: "id", value: "api"}, {field: "image", index: -1}},
+		},
+		{path: "", wantErr: true},
+		{path: "spec..image", wantErr: true},
+		{path: "spec.image.", wantErr: true},
+		{path: "spec.components[-1].image", wantErr: true},
+		{path: "spec.components[?(@.name==api)].image", wantErr: true},
+		{path: `spec.components[?(@.name=="api").image`, wantErr: true},
+		{path: "spec.components[*].image", wantErr: true},
+		{path: "spec.components[0]image", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			segments, err := parseFieldPath(test.path)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(segments, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, segments)
+			}
+		})
+	}
+}
+
+func TestUpdateUnstructuredFromImages(t *testing.T) {
+	tags := fakeTagRetriever{
+		{Namespace: "default", Name: "api:latest", Ref: "registry/api@sha256:1"},
+		{Namespace: "other", Name: "worker:latest", Ref: "registry/worker@sha256:2"},
+		{Namespace: "default", Name: "web:latest", Ref: "registry/web@sha256:3"},
+	}
+	spec := `{"image":"old","components":[{"name":"web","image":"registry/web@sha256:3"},{"name":"worker","image":"old-worker"}],"sidecars":[{}]}`
+
+	tests := []struct {
+		name          string
+		triggers      []ObjectFieldTrigger
+		expectedSpec  string
+		expectedPatch string
+		wantErr       string
+	}{
+		{
+			name: "fields are updated",
+			triggers: []ObjectFieldTrigger{
+				{From: ObjectReference{Kind: "ImageStreamTag", Name: "api:latest"}, FieldPath: "spec.image"},
+				{From: ObjectReference{Kind: "ImageStreamTag", Name: "worker:latest", Namespace: "other"}, FieldPath: `spec.components[?(@.name=="worker")].image`},
+				{From: ObjectReference{Kind: "ImageStreamTag", Name: "web:latest"}, FieldPath: `spec.components[?(@.name=="web")].image`},
+				{From: ObjectReference{Kind: "ImageStreamTag", Name: "api:latest"}, FieldPath: "spec.sidecars[0].image"},
+				{From: ObjectReference{Kind: "ImageStreamTag", Name: "missing:latest"}, FieldPath: "spec.missing.image"},
+				{From: ObjectReference{Kind: "ImageStreamTag", Name: "api:latest"}, FieldPath: "spec.paused", Paused: true},
+			},
+			expectedSpec: `{"image":"registry/api@sha256:1","components":[{"name":"web","image":"registry/web@sha256:3"},{"name":"worker","image":"registry/worker@sha256:2"}],"sidecars":[{"image":"registry/api@sha256:1"}]}`,
+			expectedPatch: `{"spec":{"$setElementOrder/components":[{"name":"web"},{"name":"worker"}],` +
+				`"components":[{"image":"registry/worker@sha256:2","name":"worker"}],"image":"registry/api@sha256:1","sidecars":[{"image":"registry/api@sha256:1"}]}}`,
+		},
+		{
+			name: "up to date",
+			triggers: []ObjectFieldTrigger{
+				{From: ObjectReference{Kind: "ImageStreamTag", Name: "web:latest"}, FieldPath: `spec.components[?(@.name=="web")].image`},
+			},
+		},
+		{
+			name: "missing item",
+			triggers: []ObjectFieldTrigger{
+				{From: ObjectReference{Kind: "ImageStreamTag", Name: "api:latest"}, FieldPath: `spec.components[?(@.name=="api")].image`},
+			},
+			wantErr: `no item of components has name "api"`,
+		},
+		{
+			name: "not a string",
+			triggers: []ObjectFieldTrigger{
+				{From: ObjectReference{Kind: "ImageStreamTag", Name: "api:latest"}, FieldPath: "spec.components"},
+			},
+			wantErr: "does not point to a string",
+		},
+		{
+			name: "item instead of field",
+			triggers: []ObjectFieldTrigger{
+				{From: ObjectReference{Kind: "ImageStreamTag", Name: "api:latest"}, FieldPath: "spec.components[0]"},
+			},
+			wantErr: "selects an item",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			obj := testUnstructured(test.triggers, spec)
+			original := obj.DeepCopy()
+			updated, updates, err := UpdateUnstructuredFromImages(obj, tags)
+			if len(test.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected error %q, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(obj, original) {
+				t.Errorf("the object was modified")
+			}
+			if len(test.expectedSpec) == 0 {
+				if updated != nil || len(updates) != 0 {
+					t.Errorf("expected no update, got %v", updates)
+				}
+				return
+			}
+
+			expected := testUnstructured(test.triggers, test.expectedSpec)
+			if !reflect.DeepEqual(updated, expected) {
+				t.Errorf("unexpected object %v", updated.Object["spec"])
+			}
+			patch, err := ImageUpdatesPatch(original, updated, &testWidget{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(patch) != test.expectedPatch {
+				t.Errorf("unexpected patch %s", patch)
+			}
+
+			// the patch applies to the original object, and merges the list items by key even when they were reordered
+			data, _ := json.Marshal(original.Object)
+			patched, err := strategicpatch.StrategicMergePatch(data, patch, &testWidget{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedData, _ := json.Marshal(expected.Object)
+			if !jsonpatch.Equal(patched, expectedData) {
+				t.Errorf("unexpected patched object %s", patched)
+			}
+			if patched, err = strategicpatch.StrategicMergePatch(swapComponents(data), patch, &testWidget{}); err != nil {
+				t.Fatal(err)
+			}
+			if !jsonpatch.Equal(patched, expectedData) {
+				t.Errorf("unexpected patched object with reordered items %s", patched)
+			}
+		})
+	}
+}
+
+// swapComponents swaps the first two items of spec.components of the object.
+func swapComponents(data []byte) []byte {
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		panic(err)
+	}
+	components, _, _ := unstructured.NestedSlice(obj, "spec", "components")
+	components[0], components[1] = components[1], components[0]
+	if err := unstructured.SetNestedSlice(obj, components, "spec", "components"); err != nil {
+		panic(err)
+	}
+	data, _ = json.Marshal(obj)
+	return data
+}
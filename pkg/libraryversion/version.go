@@ -0,0 +1,29 @@
+// Package libraryversion reports the version of library-go a binary was built with. It depends only on the standard
+// library, so that any package can import it.
+package libraryversion
+
+import "runtime/debug"
+
+const libraryGoModule = "github.com/openshift/library-go"
+
+// Get returns the version of the library-go module the binary was built with, "unknown" when the build information
+// is not available.
+func Get() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if info.Main.Path == libraryGoModule {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != libraryGoModule {
+			continue
+		}
+		if dep.Replace != nil && len(dep.Replace.Version) > 0 {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return "unknown"
+}
@@ -0,0 +1,86 @@
+package manifest
+
+import (
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+// InclusionFilter describes the cluster for which manifests are filtered, like the arguments of
+// Manifest.IncludeAllowUnknownCapabilities. Nil fields do not exclude any manifest.
+type InclusionFilter struct {
+	// ExcludeIdentifier excludes the manifests annotated with exclude.release.openshift.io/<ExcludeIdentifier>=true.
+	ExcludeIdentifier *string
+	// RequiredFeatureSet excludes the manifests whose release.openshift.io/feature-set annotation does not list it.
+	RequiredFeatureSet *string
+	// Profile excludes the manifests not annotated with include.release.openshift.io/<Profile>=true.
+	Profile *string
+	// Capabilities excludes the manifests of capabilities that are unknown or not enabled.
+	Capabilities *configv1.ClusterVersionCapabilitiesStatus
+	// Overrides excludes the manifests of unmanaged components.
+	Overrides []configv1.ComponentOverride
+	// AllowUnknownCapabilities does not exclude the manifests of unknown capabilities, only of disabled ones.
+	AllowUnknownCapabilities bool
+}
+
+// ExclusionReason is the reason why a manifest is excluded by an InclusionFilter.
+type ExclusionReason string
+
+const (
+	ExclusionReasonNoAnnotations      ExclusionReason = "NoAnnotations"
+	ExclusionReasonExcludeAnnotation  ExclusionReason = "ExcludeAnnotation"
+	ExclusionReasonFeatureSet         ExclusionReason = "FeatureSet"
+	ExclusionReasonClusterProfile     ExclusionReason = "ClusterProfile"
+	ExclusionReasonUnknownCapability  ExclusionReason = "UnknownCapability"
+	ExclusionReasonDisabledCapability ExclusionReason = "DisabledCapability"
+	ExclusionReasonOverride           ExclusionReason = "Override"
+)
+
+// Exclusion explains why a manifest is excluded.
+type Exclusion struct {
+	Reason  ExclusionReason
+	Message string
+}
+
+// Evaluate returns why the manifest is excluded by the filter, or nil when it is included.
+func (m *Manifest) Evaluate(filter InclusionFilter) *Exclusion {
+	reason, err := m.evaluate(filter)
+	if err == nil {
+		return nil
+	}
+	return &Exclusion{Reason: reason, Message: err.Error()}
+}
+
+// ExcludedManifest is a manifest excluded by an InclusionFilter.
+type ExcludedManifest struct {
+	Manifest  Manifest
+	Exclusion Exclusion
+}
+
+// FilterResult is the set of manifests a cluster would apply, and the excluded ones with the reasons.
+type FilterResult struct {
+	Included []Manifest
+	Excluded []ExcludedManifest
+}
+
+// Filter splits the manifests into the ones included by the filter and the excluded ones, preserving their order.
+func Filter(manifests []Manifest, filter InclusionFilter) FilterResult {
+	result := FilterResult{}
+	for i := range manifests {
+		if exclusion := manifests[i].Evaluate(filter); exclusion != nil {
+			result.Excluded = append(result.Excluded, ExcludedManifest{Manifest: manifests[i], Exclusion: *exclusion})
+			continue
+		}
+		result.Included = append(result.Included, manifests[i])
+	}
+	return result
+}
+
+// ExcludedBy returns the manifests excluded for the reason.
+func (r FilterResult) ExcludedBy(reason ExclusionReason) []Manifest {
+	var manifests []Manifest
+	for _, excluded := range r.Excluded {
+		if excluded.Exclusion.Reason == reason {
+			manifests = append(manifests, excluded.Manifest)
+		}
+	}
+	return manifests
+}
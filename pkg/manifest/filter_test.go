@@ -0,0 +1,120 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter(t *testing.T) {
+	manifests, err := ParseManifests(strings.NewReader(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: always
+  annotations:
+    include.release.openshift.io/self-managed-high-availability: "true"
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: tech-preview
+  annotations:
+    include.release.openshift.io/self-managed-high-availability: "true"
+    release.openshift.io/feature-set: TechPreviewNoUpgrade
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: single-node
+  annotations:
+    include.release.openshift.io/single-node-developer: "true"
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: console
+  annotations:
+    include.release.openshift.io/self-managed-high-availability: "true"
+    capability.openshift.io/name: Console
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: insights
+  annotations:
+    include.release.openshift.io/self-managed-high-availability: "true"
+    capability.openshift.io/name: Insights
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: future
+  annotations:
+    include.release.openshift.io/self-managed-high-availability: "true"
+    capability.openshift.io/name: Future
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: overridden
+  namespace: openshift-test
+  annotations:
+    include.release.openshift.io/self-managed-high-availability: "true"
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: no-annotations
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile := DefaultClusterProfile
+	featureSet := ""
+	filter := InclusionFilter{
+		RequiredFeatureSet: &featureSet,
+		Profile:            &profile,
+		Capabilities: &configv1.ClusterVersionCapabilitiesStatus{
+			KnownCapabilities:   []configv1.ClusterVersionCapability{"Console", "Insights"},
+			EnabledCapabilities: []configv1.ClusterVersionCapability{"Console"},
+		},
+		Overrides: []configv1.ComponentOverride{{Kind: "ConfigMap", Namespace: "openshift-test", Name: "overridden", Unmanaged: true}},
+	}
+
+	names := func(manifests []Manifest) []string {
+		var ret []string
+		for _, m := range manifests {
+			ret = append(ret, m.Obj.GetName())
+		}
+		return ret
+	}
+
+	result := Filter(manifests, filter)
+	assert.Equal(t, []string{"always", "console"}, names(result.Included))
+	exclusions := map[string]Exclusion{}
+	for _, excluded := range result.Excluded {
+		exclusions[excluded.Manifest.Obj.GetName()] = excluded.Exclusion
+	}
+	assert.Equal(t, map[string]Exclusion{
+		"tech-preview":   {Reason: ExclusionReasonFeatureSet, Message: `"Default" is required, and release.openshift.io/feature-set=TechPreviewNoUpgrade`},
+		"single-node":    {Reason: ExclusionReasonClusterProfile, Message: "include.release.openshift.io/self-managed-high-availability unset"},
+		"insights":       {Reason: ExclusionReasonDisabledCapability, Message: "disabled capabilities: Insights"},
+		"future":         {Reason: ExclusionReasonUnknownCapability, Message: "unrecognized capability names: Future"},
+		"overridden":     {Reason: ExclusionReasonOverride, Message: "overridden"},
+		"no-annotations": {Reason: ExclusionReasonNoAnnotations, Message: "no annotations"},
+	}, exclusions)
+	assert.Equal(t, []string{"insights"}, names(result.ExcludedBy(ExclusionReasonDisabledCapability)))
+
+	// unknown capabilities are only disabled when they are allowed
+	filter.AllowUnknownCapabilities = true
+	assert.Equal(t, &Exclusion{Reason: ExclusionReasonDisabledCapability, Message: "disabled capabilities: Future"}, manifests[5].Evaluate(filter))
+
+	// nil fields do not filter
+	result = Filter(manifests, InclusionFilter{})
+	assert.Equal(t, []string{"no-annotations"}, names(result.ExcludedBy(ExclusionReasonNoAnnotations)))
+	assert.Len(t, result.Included, len(manifests)-1)
+}
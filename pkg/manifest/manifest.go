@@ -199,47 +199,60 @@ func (m *Manifest) Include(excludeIdentifier *string, requiredFeatureSet *string
 func (m *Manifest) IncludeAllowUnknownCapabilities(excludeIdentifier *string, requiredFeatureSet *string, profile *string,
 	capabilities *configv1.ClusterVersionCapabilitiesStatus, overrides []configv1.ComponentOverride, allowUnknownCapabilities bool) error {
 
+	_, err := m.evaluate(InclusionFilter{
+		ExcludeIdentifier:        excludeIdentifier,
+		RequiredFeatureSet:       requiredFeatureSet,
+		Profile:                  profile,
+		Capabilities:             capabilities,
+		Overrides:                overrides,
+		AllowUnknownCapabilities: allowUnknownCapabilities,
+	})
+	return err
+}
+
+// evaluate returns why the manifest is excluded by the filter, and the error describing it.
+func (m *Manifest) evaluate(filter InclusionFilter) (ExclusionReason, error) {
 	annotations := m.Obj.GetAnnotations()
 	if annotations == nil {
-		return fmt.Errorf("no annotations")
+		return ExclusionReasonNoAnnotations, fmt.Errorf("no annotations")
 	}
 
-	if excludeIdentifier != nil {
-		excludeAnnotation := fmt.Sprintf("exclude.release.openshift.io/%s", *excludeIdentifier)
+	if filter.ExcludeIdentifier != nil {
+		excludeAnnotation := fmt.Sprintf("exclude.release.openshift.io/%s", *filter.ExcludeIdentifier)
 		if v := annotations[excludeAnnotation]; v == "true" {
-			return fmt.Errorf("%s=%s", excludeAnnotation, v)
+			return ExclusionReasonExcludeAnnotation, fmt.Errorf("%s=%s", excludeAnnotation, v)
 		}
 	}
 
-	if requiredFeatureSet != nil {
-		err := checkFeatureSets(*requiredFeatureSet, annotations)
+	if filter.RequiredFeatureSet != nil {
+		err := checkFeatureSets(*filter.RequiredFeatureSet, annotations)
 		if err != nil {
-			return err
+			return ExclusionReasonFeatureSet, err
 		}
 	}
 
-	if profile != nil {
-		profileAnnotation := fmt.Sprintf("include.release.openshift.io/%s", *profile)
+	if filter.Profile != nil {
+		profileAnnotation := fmt.Sprintf("include.release.openshift.io/%s", *filter.Profile)
 		if val, ok := annotations[profileAnnotation]; ok && val != "true" {
-			return fmt.Errorf("unrecognized value %s=%s", profileAnnotation, val)
+			return ExclusionReasonClusterProfile, fmt.Errorf("unrecognized value %s=%s", profileAnnotation, val)
 		} else if !ok {
-			return fmt.Errorf("%s unset", profileAnnotation)
+			return ExclusionReasonClusterProfile, fmt.Errorf("%s unset", profileAnnotation)
 		}
 	}
 
 	// If there is no capabilities defined in a release then we do not need to check presence of capabilities in the manifest
-	if capabilities != nil {
-		err := checkResourceEnablement(annotations, capabilities, allowUnknownCapabilities)
+	if filter.Capabilities != nil {
+		reason, err := checkResourceEnablement(annotations, filter.Capabilities, filter.AllowUnknownCapabilities)
 		if err != nil {
-			return err
+			return reason, err
 		}
 	}
 
-	if override := m.getOverrideForManifest(overrides); override != nil && override.Unmanaged {
-		return fmt.Errorf("overridden")
+	if override := m.getOverrideForManifest(filter.Overrides); override != nil && override.Unmanaged {
+		return ExclusionReasonOverride, fmt.Errorf("overridden")
 	}
 
-	return nil
+	return "", nil
 }
 
 // getOverrideForManifest returns the override when override exists and nil otherwise.
@@ -265,9 +278,10 @@ func (m *Manifest) getOverrideForManifest(overrides []configv1.ComponentOverride
 // annotation exists. If so, each capability name is validated against the known set of capabilities unless
 // allowUnknownCapabilities is true. Each valid capability is then checked if it is disabled. If any invalid
 // capabilities are found an error is returned listing all invalid capabilities. Otherwise, if any disabled
-// capabilities are found an error is returned listing all disabled capabilities.
+// capabilities are found an error is returned listing all disabled capabilities. The reason of the error is returned
+// with it.
 func checkResourceEnablement(annotations map[string]string, capabilities *configv1.ClusterVersionCapabilitiesStatus,
-	allowUnknownCapabilities bool) error {
+	allowUnknownCapabilities bool) (ExclusionReason, error) {
 
 	caps := getManifestCapabilities(annotations)
 	numCaps := len(caps)
@@ -301,12 +315,12 @@ func checkResourceEnablement(annotations map[string]string, capabilities *config
 		}
 	}
 	if len(unknownCaps) > 0 {
-		return fmt.Errorf("unrecognized capability names: %s", strings.Join(unknownCaps, ", "))
+		return ExclusionReasonUnknownCapability, fmt.Errorf("unrecognized capability names: %s", strings.Join(unknownCaps, ", "))
 	}
 	if len(disabledCaps) > 0 {
-		return fmt.Errorf("disabled capabilities: %s", strings.Join(disabledCaps, ", "))
+		return ExclusionReasonDisabledCapability, fmt.Errorf("disabled capabilities: %s", strings.Join(disabledCaps, ", "))
 	}
-	return nil
+	return "", nil
 }
 
 // GetManifestCapabilities returns the manifest's capabilities.
@@ -0,0 +1,168 @@
+package networkutils
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+)
+
+// ParseDualStackCIDRs parses a comma separated list of one CIDR, or of two CIDRs of different IP families, as the
+// dual-stack CIDR flags and fields of kube, e.g. "10.128.0.0/14,fd01::/48". The CIDRs must be in canonical form, see
+// ParseCIDRMask, and are returned in their order.
+func ParseDualStackCIDRs(cidrs string) ([]*net.IPNet, error) {
+	var values []string
+	for _, value := range strings.Split(cidrs, ",") {
+		if value = strings.TrimSpace(value); len(value) > 0 {
+			values = append(values, value)
+		}
+	}
+	return ParseDualStackCIDRList(values)
+}
+
+// ParseDualStackCIDRList parses a list of one CIDR, or of two CIDRs of different IP families.
+func ParseDualStackCIDRList(cidrs []string) ([]*net.IPNet, error) {
+	switch len(cidrs) {
+	case 0:
+		return nil, fmt.Errorf("at least one CIDR is required")
+	case 1, 2:
+	default:
+		return nil, fmt.Errorf("at most two CIDRs, one per IP family, are allowed, got %d", len(cidrs))
+	}
+	parsed := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		ipNet, err := ParseCIDRMask(cidr)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, ipNet)
+	}
+	if len(parsed) == 2 && IsIPv6CIDR(parsed[0]) == IsIPv6CIDR(parsed[1]) {
+		return nil, fmt.Errorf("the CIDRs %s and %s must be of different IP families", parsed[0], parsed[1])
+	}
+	return parsed, nil
+}
+
+// SplitCIDRsByFamily returns the first IPv4 and the first IPv6 CIDR of the list, nil when there is none.
+func SplitCIDRsByFamily(cidrs []*net.IPNet) (ipv4, ipv6 *net.IPNet) {
+	for _, cidr := range cidrs {
+		switch {
+		case IsIPv6CIDR(cidr) && ipv6 == nil:
+			ipv6 = cidr
+		case !IsIPv6CIDR(cidr) && ipv4 == nil:
+			ipv4 = cidr
+		}
+	}
+	return ipv4, ipv6
+}
+
+// IsIPv6CIDR returns whether the CIDR is an IPv6 CIDR. The IPv4 CIDRs in IPv4-mapped IPv6 form, e.g.
+// ::ffff:10.0.0.0/104, are IPv4 CIDRs.
+func IsIPv6CIDR(cidr *net.IPNet) bool {
+	return cidr.IP.To4() == nil
+}
+
+// CIDRsOverlap returns whether the CIDRs have addresses in common, CIDRs of different IP families never overlap.
+func CIDRsOverlap(a, b *net.IPNet) bool {
+	a, b = normalizeIPNet(a), normalizeIPNet(b)
+	if len(a.IP) != len(b.IP) {
+		return false
+	}
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// NormalizeIP returns the canonical representation of the IP address (RFC 5952 for IPv6), e.g. 2001:db8::1 for
+// 2001:0DB8:0:0::1, and the dotted decimal representation of IPv4-mapped IPv6 addresses.
+func NormalizeIP(ip string) (string, error) {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return "", fmt.Errorf("invalid IP address %q", ip)
+	}
+	return parsed.String(), nil
+}
+
+// NormalizeCIDR returns the canonical representation of the CIDR, which must be in canonical form, see
+// ParseCIDRMask, e.g. 2001:db8::/32 for 2001:0DB8:0000::/32.
+func NormalizeCIDR(cidr string) (string, error) {
+	parsed, err := ParseCIDRMask(strings.TrimSpace(cidr))
+	if err != nil {
+		return "", err
+	}
+	return parsed.String(), nil
+}
+
+// AllocateSubnets returns the count first subnets of the prefix length in the CIDR that do not overlap the
+// allocated subnets, e.g. to allocate the subnets of nodes or of additional networks. It fails if the CIDR does not
+// have enough free subnets.
+func AllocateSubnets(cidr *net.IPNet, prefixLength int, allocated []*net.IPNet, count int) ([]*net.IPNet, error) {
+	cidr = normalizeIPNet(cidr)
+	ones, bits := cidr.Mask.Size()
+	if prefixLength < ones || prefixLength > bits {
+		return nil, fmt.Errorf("the prefix length %d is not within the prefix length of %s and %d", prefixLength, cidr, bits)
+	}
+
+	subnetSize := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefixLength))
+	end := new(big.Int).Add(ipToInt(cidr.IP), new(big.Int).Lsh(big.NewInt(1), uint(bits-ones)))
+	var subnets []*net.IPNet
+	used := make([]*net.IPNet, 0, len(allocated)+count)
+	for _, subnet := range allocated {
+		used = append(used, normalizeIPNet(subnet))
+	}
+	for candidate := ipToInt(cidr.IP); len(subnets) < count && candidate.Cmp(end) < 0; {
+		subnet := &net.IPNet{IP: intToIP(candidate, len(cidr.IP)), Mask: net.CIDRMask(prefixLength, bits)}
+		next := new(big.Int).Add(candidate, subnetSize)
+		overlaps := false
+		for _, usedSubnet := range used {
+			if !CIDRsOverlap(subnet, usedSubnet) {
+				continue
+			}
+			overlaps = true
+			// skip to the first subnet after the allocated one
+			usedOnes, usedBits := usedSubnet.Mask.Size()
+			usedEnd := new(big.Int).Add(ipToInt(usedSubnet.IP), new(big.Int).Lsh(big.NewInt(1), uint(usedBits-usedOnes)))
+			if usedEnd.Cmp(next) > 0 {
+				next = alignUp(usedEnd, subnetSize)
+			}
+		}
+		if !overlaps {
+			subnets = append(subnets, subnet)
+			used = append(used, subnet)
+		}
+		candidate = next
+	}
+	if len(subnets) < count {
+		return nil, fmt.Errorf("%s has only %d free /%d subnets, %d are required", cidr, len(subnets), prefixLength, count)
+	}
+	return subnets, nil
+}
+
+// normalizeIPNet returns the CIDR with a 4 bytes IP for IPv4, so that the IPs and masks of CIDRs of the same family
+// have the same length.
+func normalizeIPNet(cidr *net.IPNet) *net.IPNet {
+	if ip := cidr.IP.To4(); ip != nil && len(cidr.Mask) == net.IPv6len {
+		ones, _ := cidr.Mask.Size()
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(ones-96, 32)}
+	}
+	if ip := cidr.IP.To4(); ip != nil {
+		return &net.IPNet{IP: ip, Mask: cidr.Mask}
+	}
+	return cidr
+}
+
+func ipToInt(ip net.IP) *big.Int {
+	return new(big.Int).SetBytes(ip)
+}
+
+func intToIP(value *big.Int, length int) net.IP {
+	ip := make(net.IP, length)
+	return value.FillBytes(ip)
+}
+
+// alignUp returns the first multiple of size not lower than value.
+func alignUp(value, size *big.Int) *big.Int {
+	remainder := new(big.Int).Mod(value, size)
+	if remainder.Sign() == 0 {
+		return value
+	}
+	return new(big.Int).Add(value, new(big.Int).Sub(size, remainder))
+}
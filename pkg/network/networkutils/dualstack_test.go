@@ -0,0 +1,200 @@
+package networkutils
+
+import (
+	"net"
+	"testing"
+)
+
+func mustParseCIDRs(t *testing.T, cidrs ...string) []*net.IPNet {
+	t.Helper()
+	var parsed []*net.IPNet
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed = append(parsed, ipNet)
+	}
+	return parsed
+}
+
+func TestParseDualStackCIDRs(t *testing.T) {
+	tests := []struct {
+		cidrs     string
+		expected  []string
+		expectErr bool
+	}{
+		{cidrs: "10.128.0.0/14", expected: []string{"10.128.0.0/14"}},
+		{cidrs: "fd01::/48", expected: []string{"fd01::/48"}},
+		{cidrs: "10.128.0.0/14, fd01::/48", expected: []string{"10.128.0.0/14", "fd01::/48"}},
+		{cidrs: "fd01::/48,10.128.0.0/14", expected: []string{"fd01::/48", "10.128.0.0/14"}},
+		{cidrs: "", expectErr: true},
+		{cidrs: "10.128.0.0/14,10.0.0.0/16", expectErr: true},
+		{cidrs: "fd01::/48,fd02::/48", expectErr: true},
+		{cidrs: "10.128.0.0/14,fd01::/48,10.0.0.0/16", expectErr: true},
+		{cidrs: "10.128.0.1/14", expectErr: true},
+		{cidrs: "10.128.0.0/14,bad", expectErr: true},
+	}
+	for _, tc := range tests {
+		cidrs, err := ParseDualStackCIDRs(tc.cidrs)
+		if (err != nil) != tc.expectErr {
+			t.Errorf("%q: expected error %t, got %v", tc.cidrs, tc.expectErr, err)
+			continue
+		}
+		if len(cidrs) != len(tc.expected) {
+			t.Errorf("%q: expected %v, got %v", tc.cidrs, tc.expected, cidrs)
+			continue
+		}
+		for i := range cidrs {
+			if cidrs[i].String() != tc.expected[i] {
+				t.Errorf("%q: expected %v, got %v", tc.cidrs, tc.expected, cidrs)
+			}
+		}
+	}
+}
+
+func TestSplitCIDRsByFamily(t *testing.T) {
+	ipv4, ipv6 := SplitCIDRsByFamily(mustParseCIDRs(t, "fd01::/48", "10.128.0.0/14", "10.0.0.0/16"))
+	if ipv4.String() != "10.128.0.0/14" || ipv6.String() != "fd01::/48" {
+		t.Errorf("unexpected split %v, %v", ipv4, ipv6)
+	}
+	ipv4, ipv6 = SplitCIDRsByFamily(mustParseCIDRs(t, "::ffff:10.0.0.0/104"))
+	if ipv4 == nil || ipv6 != nil {
+		t.Errorf("expected an IPv4-mapped CIDR to be IPv4, got %v, %v", ipv4, ipv6)
+	}
+}
+
+func TestCIDRsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"10.0.0.0/8", "10.1.0.0/16", true},
+		{"10.1.0.0/16", "10.0.0.0/8", true},
+		{"10.0.0.0/16", "10.1.0.0/16", false},
+		{"10.0.0.0/8", "::ffff:10.1.0.0/112", true},
+		{"fd01::/48", "fd01:0:0:1::/64", true},
+		{"fd01::/48", "fd02::/48", false},
+		{"0.0.0.0/0", "::/0", false},
+	}
+	for _, tc := range tests {
+		cidrs := mustParseCIDRs(t, tc.a, tc.b)
+		if overlap := CIDRsOverlap(cidrs[0], cidrs[1]); overlap != tc.expected {
+			t.Errorf("expected overlap of %s and %s to be %t", tc.a, tc.b, tc.expected)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for input, expected := range map[string]string{
+		"2001:0DB8:0:0::1":     "2001:db8::1",
+		"2001:db8:0:0:1:0:0:1": "2001:db8::1:0:0:1",
+		" 10.0.0.1 ":           "10.0.0.1",
+		"::ffff:10.0.0.1":      "10.0.0.1",
+	} {
+		if normalized, err := NormalizeIP(input); err != nil || normalized != expected {
+			t.Errorf("expected %q to be normalized to %s, got %s, %v", input, expected, normalized, err)
+		}
+	}
+	if _, err := NormalizeIP("2001:db8::g"); err == nil {
+		t.Errorf("expected an error for an invalid IP")
+	}
+
+	for input, expected := range map[string]string{
+		"2001:0DB8:0000::/32": "2001:db8::/32",
+		"FD01:0:0:0::/48":     "fd01::/48",
+		"10.128.0.0/14":       "10.128.0.0/14",
+	} {
+		if normalized, err := NormalizeCIDR(input); err != nil || normalized != expected {
+			t.Errorf("expected %q to be normalized to %s, got %s, %v", input, expected, normalized, err)
+		}
+	}
+	if _, err := NormalizeCIDR("2001:db8::1/32"); err == nil {
+		t.Errorf("expected an error for a CIDR with host bits")
+	}
+}
+
+func TestAllocateSubnets(t *testing.T) {
+	tests := []struct {
+		name         string
+		cidr         string
+		prefixLength int
+		allocated    []string
+		count        int
+		expected     []string
+		expectErr    bool
+	}{
+		{
+			name:         "first subnets",
+			cidr:         "10.128.0.0/14",
+			prefixLength: 23,
+			count:        2,
+			expected:     []string{"10.128.0.0/23", "10.128.2.0/23"},
+		},
+		{
+			name:         "skip allocated subnets",
+			cidr:         "10.128.0.0/14",
+			prefixLength: 23,
+			allocated:    []string{"10.128.0.0/23", "10.128.4.0/22", "10.128.9.0/24"},
+			count:        3,
+			expected:     []string{"10.128.2.0/23", "10.128.10.0/23", "10.128.12.0/23"},
+		},
+		{
+			name:         "allocated supernet",
+			cidr:         "10.128.0.0/16",
+			prefixLength: 24,
+			allocated:    []string{"10.0.0.0/8"},
+			count:        1,
+			expectErr:    true,
+		},
+		{
+			name:         "ipv6",
+			cidr:         "fd01::/48",
+			prefixLength: 64,
+			allocated:    []string{"fd01::/64", "fd01:0:0:2::/63"},
+			count:        2,
+			expected:     []string{"fd01:0:0:1::/64", "fd01:0:0:4::/64"},
+		},
+		{
+			name:         "other family allocations are ignored",
+			cidr:         "fd01::/48",
+			prefixLength: 64,
+			allocated:    []string{"10.0.0.0/8"},
+			count:        1,
+			expected:     []string{"fd01::/64"},
+		},
+		{
+			name:         "full",
+			cidr:         "10.0.0.0/24",
+			prefixLength: 25,
+			allocated:    []string{"10.0.0.128/25"},
+			count:        2,
+			expectErr:    true,
+		},
+		{
+			name:         "prefix length too short",
+			cidr:         "10.0.0.0/24",
+			prefixLength: 16,
+			count:        1,
+			expectErr:    true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cidr := mustParseCIDRs(t, tc.cidr)[0]
+			allocated := mustParseCIDRs(t, tc.allocated...)
+			subnets, err := AllocateSubnets(cidr, tc.prefixLength, allocated, tc.count)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %t, got %v", tc.expectErr, err)
+			}
+			if len(subnets) != len(tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, subnets)
+			}
+			for i := range subnets {
+				if subnets[i].String() != tc.expected[i] {
+					t.Errorf("expected %v, got %v", tc.expected, subnets)
+				}
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"path"
 	"strings"
+	"sync"
 
 	configv1 "github.com/openshift/api/config/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -22,6 +23,8 @@ var assets embed.FS
 var (
 	basePolicy   auditv1.Policy
 	profileRules = map[configv1.AuditProfileType][]auditv1.PolicyRule{}
+	// profileRulesLock guards profileRules against the registration of custom profiles
+	profileRulesLock sync.RWMutex
 
 	auditScheme         = runtime.NewScheme()
 	auditCodecs         = serializer.NewCodecFactory(auditScheme)
@@ -94,6 +97,9 @@ func GetAuditPolicy(audit configv1.Audit) (*auditv1.Policy, error) {
 	p := basePolicy.DeepCopy()
 	p.Name = "policy"
 
+	profileRulesLock.RLock()
+	defer profileRulesLock.RUnlock()
+
 	for _, cr := range audit.CustomRules {
 		rules, ok := profileRules[cr.Profile]
 		if !ok {
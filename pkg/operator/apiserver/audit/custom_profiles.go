@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"k8s.io/apiserver/pkg/apis/audit"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+	"k8s.io/apiserver/pkg/apis/audit/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// RegisterProfile registers an additional named audit profile, whose rules are the rules of the fragments in order, e.g.
+// so that a distribution ships compliance specific profiles. The profile can then be used like the built-in ones, as
+// the profile of the audit config or of its custom rules, as long as the config API accepts it.
+//
+// It is meant to be called at startup, before the audit policies are computed. It fails when the profile is already
+// registered, including the built-in ones, or when its rules are not valid. The rules must not set userGroups, which
+// are set to the group of the custom rules using the profile.
+func RegisterProfile(profile configv1.AuditProfileType, fragments ...[]auditv1.PolicyRule) error {
+	if len(profile) == 0 {
+		return fmt.Errorf("the audit profile name is required")
+	}
+	var rules []auditv1.PolicyRule
+	for _, fragment := range fragments {
+		for _, rule := range fragment {
+			// the user groups of the rules are set to the group of the custom rules using the profile
+			if len(rule.UserGroups) > 0 {
+				return fmt.Errorf("invalid audit profile %q: the rules must not set userGroups", profile)
+			}
+			rules = append(rules, *rule.DeepCopy())
+		}
+	}
+	if len(rules) == 0 {
+		return fmt.Errorf("the audit profile %q has no rule", profile)
+	}
+
+	// the profile is validated as part of a policy built from the base policy, like GetAuditPolicy does
+	policy := basePolicy.DeepCopy()
+	policy.Rules = append(policy.Rules, rules...)
+	var internalPolicy audit.Policy
+	if err := auditv1.Convert_v1_Policy_To_audit_Policy(policy, &internalPolicy, nil); err != nil {
+		return fmt.Errorf("invalid audit profile %q: %v", profile, err)
+	}
+	if errs := validation.ValidatePolicy(&internalPolicy); len(errs) > 0 {
+		return fmt.Errorf("invalid audit profile %q: %v", profile, errs.ToAggregate())
+	}
+
+	profileRulesLock.Lock()
+	defer profileRulesLock.Unlock()
+	if _, exists := profileRules[profile]; exists {
+		return fmt.Errorf("the audit profile %q is already registered", profile)
+	}
+	profileRules[profile] = rules
+	return nil
+}
+
+// MustRegisterProfile is like RegisterProfile but panics on error, e.g. to register profiles in init().
+func MustRegisterProfile(profile configv1.AuditProfileType, fragments ...[]auditv1.PolicyRule) {
+	if err := RegisterProfile(profile, fragments...); err != nil {
+		panic(err)
+	}
+}
+
+// ParseRules parses a rule fragment, i.e. a YAML list of audit policy rules like the manifests of the built-in profiles.
+func ParseRules(data []byte) ([]auditv1.PolicyRule, error) {
+	var rules []auditv1.PolicyRule
+	if err := yaml.UnmarshalStrict(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid audit policy rules: %v", err)
+	}
+	return rules, nil
+}
+
+// Profiles returns the names of the built-in and registered audit profiles, sorted.
+func Profiles() []configv1.AuditProfileType {
+	profileRulesLock.RLock()
+	defer profileRulesLock.RUnlock()
+
+	profiles := make([]configv1.AuditProfileType, 0, len(profileRules))
+	for profile := range profileRules {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i] < profiles[j] })
+	return profiles
+}
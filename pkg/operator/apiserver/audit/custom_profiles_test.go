@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+func TestRegisterProfile(t *testing.T) {
+	secretRules, err := ParseRules([]byte(`
+- level: RequestResponse
+  resources:
+  - group: ""
+    resources: ["secrets"]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadataRules := []auditv1.PolicyRule{{Level: auditv1.LevelMetadata}}
+
+	if err := RegisterProfile("Compliance", secretRules, metadataRules); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		profileRulesLock.Lock()
+		defer profileRulesLock.Unlock()
+		delete(profileRules, "Compliance")
+	})
+	expectedRules := append(append([]auditv1.PolicyRule{}, secretRules...), metadataRules...)
+
+	policy, err := GetAuditPolicy(configv1.Audit{Profile: "Compliance"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := policy.Rules[len(basePolicy.Rules):]; !reflect.DeepEqual(actual, expectedRules) {
+		t.Errorf("expected the rules of the profile %#v, got %#v", expectedRules, actual)
+	}
+
+	policy, err = GetAuditPolicy(configv1.Audit{
+		Profile:     configv1.DefaultAuditProfileType,
+		CustomRules: []configv1.AuditCustomRule{{Group: "system:auditors", Profile: "Compliance"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := policy.Rules[len(basePolicy.Rules)]; actual.Level != auditv1.LevelRequestResponse || !reflect.DeepEqual(actual.UserGroups, []string{"system:auditors"}) {
+		t.Errorf("expected the rules of the profile for the custom rule group, got %#v", actual)
+	}
+
+	found := false
+	for _, profile := range Profiles() {
+		found = found || profile == "Compliance"
+	}
+	if !found {
+		t.Errorf("expected the registered profile in %v", Profiles())
+	}
+}
+
+func TestRegisterProfileErrors(t *testing.T) {
+	scenarios := []struct {
+		name        string
+		profile     configv1.AuditProfileType
+		fragments   [][]auditv1.PolicyRule
+		errContains string
+	}{
+		{
+			name:        "missing name",
+			fragments:   [][]auditv1.PolicyRule{{{Level: auditv1.LevelMetadata}}},
+			errContains: "name is required",
+		},
+		{
+			name:        "built-in profile",
+			profile:     configv1.DefaultAuditProfileType,
+			fragments:   [][]auditv1.PolicyRule{{{Level: auditv1.LevelMetadata}}},
+			errContains: `"Default" is already registered`,
+		},
+		{
+			name:        "no rule",
+			profile:     "Empty",
+			fragments:   [][]auditv1.PolicyRule{{}},
+			errContains: "has no rule",
+		},
+		{
+			name:        "user groups",
+			profile:     "Groups",
+			fragments:   [][]auditv1.PolicyRule{{{Level: auditv1.LevelMetadata, UserGroups: []string{"system:authenticated"}}}},
+			errContains: "must not set userGroups",
+		},
+		{
+			name:        "invalid level",
+			profile:     "Invalid",
+			fragments:   [][]auditv1.PolicyRule{{{Level: "Everything"}}},
+			errContains: `invalid audit profile "Invalid"`,
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			err := RegisterProfile(scenario.profile, scenario.fragments...)
+			if err == nil || !strings.Contains(err.Error(), scenario.errContains) {
+				t.Errorf("expected error containing %q, got %v", scenario.errContains, err)
+			}
+		})
+	}
+
+	if _, err := ParseRules([]byte("- level: Metadata\n  unknown: true\n")); err == nil {
+		t.Errorf("expected unknown fields of the rules to be rejected")
+	}
+}
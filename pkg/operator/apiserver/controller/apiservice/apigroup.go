@@ -7,6 +7,9 @@ import (
 	"sync"
 	"time"
 
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	kubeinformers "k8s.io/client-go/informers"
@@ -16,33 +19,36 @@ import (
 	"github.com/openshift/library-go/pkg/operator/events"
 )
 
+type serviceCoordinate struct {
+	namespace string
+	name      string
+}
+
+// serviceCoordinates returns the services of the apiservices, without duplicates.
+func serviceCoordinates(apiServices []*apiregistrationv1.APIService) []serviceCoordinate {
+	coordinates := []serviceCoordinate{}
+	for _, apiService := range apiServices {
+		curr := serviceCoordinate{namespace: apiService.Spec.Service.Namespace, name: apiService.Spec.Service.Name}
+		exists := false
+		for _, j := range coordinates {
+			if j == curr {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			coordinates = append(coordinates, curr)
+		}
+	}
+	return coordinates
+}
+
 func newEndpointPrecondition(kubeInformers kubeinformers.SharedInformerFactory) func(apiServices []*apiregistrationv1.APIService) (bool, error) {
 	// this is outside the func so it always registers before the informers start
 	endpointsLister := kubeInformers.Core().V1().Endpoints().Lister()
 
-	type coordinate struct {
-		namespace string
-		name      string
-	}
-
 	return func(apiServices []*apiregistrationv1.APIService) (bool, error) {
-
-		coordinates := []coordinate{}
-		for _, apiService := range apiServices {
-			curr := coordinate{namespace: apiService.Spec.Service.Namespace, name: apiService.Spec.Service.Name}
-			exists := false
-			for _, j := range coordinates {
-				if j == curr {
-					exists = true
-					break
-				}
-			}
-			if !exists {
-				coordinates = append(coordinates, curr)
-			}
-		}
-
-		for _, curr := range coordinates {
+		for _, curr := range serviceCoordinates(apiServices) {
 			endpoints, err := endpointsLister.Endpoints(curr.namespace).Get(curr.name)
 			if err != nil {
 				return false, err
@@ -67,6 +73,45 @@ func newEndpointPrecondition(kubeInformers kubeinformers.SharedInformerFactory)
 	}
 }
 
+// newEndpointSlicePrecondition is newEndpointPrecondition reading the EndpointSlices of the services, which are not
+// capped at 1000 addresses like the Endpoints, and are the only source of endpoints when Endpoints mirroring is
+// disabled.
+func newEndpointSlicePrecondition(kubeInformers kubeinformers.SharedInformerFactory) func(apiServices []*apiregistrationv1.APIService) (bool, error) {
+	// this is outside the func so it always registers before the informers start
+	endpointSliceLister := kubeInformers.Discovery().V1().EndpointSlices().Lister()
+
+	return func(apiServices []*apiregistrationv1.APIService) (bool, error) {
+		for _, curr := range serviceCoordinates(apiServices) {
+			slices, err := endpointSliceLister.EndpointSlices(curr.namespace).List(labels.SelectorFromSet(labels.Set{discoveryv1.LabelServiceName: curr.name}))
+			if err != nil {
+				return false, err
+			}
+			// the service is missing like its Endpoints would be
+			if len(slices) == 0 {
+				return false, apierrors.NewNotFound(discoveryv1.Resource("endpointslices"), curr.name)
+			}
+			if !hasReadyEndpoint(slices) {
+				return false, nil
+			}
+		}
+
+		return true, nil
+	}
+}
+
+// hasReadyEndpoint returns whether an endpoint of the slices is ready, i.e. would be in the addresses of the Endpoints.
+func hasReadyEndpoint(slices []*discoveryv1.EndpointSlice) bool {
+	for _, slice := range slices {
+		for _, endpoint := range slice.Endpoints {
+			// a nil ready condition means the endpoint is ready
+			if len(endpoint.Addresses) > 0 && (endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func checkDiscoveryForByAPIServices(ctx context.Context, recorder events.Recorder, restclient rest.Interface, apiServices []*apiregistrationv1.APIService) []string {
 	missingMessages := []string{}
 	for _, apiService := range apiServices {
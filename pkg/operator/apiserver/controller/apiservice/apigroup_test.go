@@ -0,0 +1,132 @@
+package apiservice
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	kubeaggregatorfake "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset/fake"
+	apiregistrationinformers "k8s.io/kube-aggregator/pkg/client/informers/externalversions"
+	"k8s.io/utils/pointer"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func TestEndpointSlicePrecondition(t *testing.T) {
+	newSlice := func(name, service string, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+		return &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "target-namespace",
+				Name:      name,
+				Labels:    map[string]string{discoveryv1.LabelServiceName: service},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints:   endpoints,
+		}
+	}
+	apiServices := []*apiregistrationv1.APIService{
+		newAPIService("build.openshift.io", "v1"),
+		newAPIService("apps.openshift.io", "v1"),
+	}
+
+	testCases := []struct {
+		name          string
+		slices        []*discoveryv1.EndpointSlice
+		expectedReady bool
+		expectedErr   func(error) bool
+	}{
+		{
+			name:        "missing slices",
+			expectedErr: apierrors.IsNotFound,
+		},
+		{
+			name:   "no endpoints",
+			slices: []*discoveryv1.EndpointSlice{newSlice("api-1", "api")},
+		},
+		{
+			name: "no ready endpoint",
+			slices: []*discoveryv1.EndpointSlice{
+				newSlice("api-1", "api", discoveryv1.Endpoint{Addresses: []string{"10.0.0.1"}, Conditions: discoveryv1.EndpointConditions{Ready: pointer.Bool(false)}}),
+			},
+		},
+		{
+			name: "endpoints of another service",
+			slices: []*discoveryv1.EndpointSlice{
+				newSlice("other-1", "other", discoveryv1.Endpoint{Addresses: []string{"10.0.0.1"}}),
+			},
+			expectedErr: apierrors.IsNotFound,
+		},
+		{
+			name: "ready endpoint in one of the slices",
+			slices: []*discoveryv1.EndpointSlice{
+				newSlice("api-1", "api", discoveryv1.Endpoint{Addresses: []string{"10.0.0.1"}, Conditions: discoveryv1.EndpointConditions{Ready: pointer.Bool(false)}}),
+				newSlice("api-2", "api", discoveryv1.Endpoint{Addresses: []string{"10.0.0.2"}, Conditions: discoveryv1.EndpointConditions{Ready: pointer.Bool(true)}}),
+			},
+			expectedReady: true,
+		},
+		{
+			name: "endpoint with unknown readiness",
+			slices: []*discoveryv1.EndpointSlice{
+				newSlice("api-1", "api", discoveryv1.Endpoint{Addresses: []string{"10.0.0.1"}}),
+			},
+			expectedReady: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeInformers := kubeinformers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+			precondition := newEndpointSlicePrecondition(kubeInformers)
+			for _, slice := range tc.slices {
+				if err := kubeInformers.Discovery().V1().EndpointSlices().Informer().GetIndexer().Add(slice); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ready, err := precondition(apiServices)
+			switch {
+			case tc.expectedErr == nil && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.expectedErr != nil && !tc.expectedErr(err):
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ready != tc.expectedReady {
+				t.Errorf("expected ready %v, got %v", tc.expectedReady, ready)
+			}
+		})
+	}
+}
+
+func TestEndpointSlicesDoNotWatchEndpoints(t *testing.T) {
+	kubeInformers := kubeinformers.NewSharedInformerFactoryWithOptions(fake.NewSimpleClientset(), 0, kubeinformers.WithNamespace("target-namespace"))
+	NewAPIServiceController(
+		"test",
+		func() ([]*apiregistrationv1.APIService, []*apiregistrationv1.APIService, error) { return nil, nil, nil },
+		v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil),
+		apiregistrationinformers.NewSharedInformerFactory(kubeaggregatorfake.NewSimpleClientset(), 0),
+		kubeaggregatorfake.NewSimpleClientset().ApiregistrationV1(),
+		kubeInformers,
+		fake.NewSimpleClientset(),
+		events.NewInMemoryRecorder(""),
+		WithEndpointSlices(),
+	)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	kubeInformers.Start(stopCh)
+	synced := kubeInformers.WaitForCacheSync(stopCh)
+	if _, ok := synced[reflect.TypeOf(&corev1.Endpoints{})]; ok {
+		t.Error("unexpected Endpoints informer")
+	}
+	if _, ok := synced[reflect.TypeOf(&discoveryv1.EndpointSlice{})]; !ok {
+		t.Error("missing EndpointSlice informer")
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/errors"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
 	apiregistrationv1client "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset/typed/apiregistration/v1"
@@ -41,6 +42,21 @@ type APIServiceController struct {
 	kubeClient              kubernetes.Interface
 	apiregistrationv1Client apiregistrationv1client.ApiregistrationV1Interface
 	apiservicelister        apiregistrationv1lister.APIServiceLister
+
+	// useEndpointSlices makes the precondition read the EndpointSlices of the services instead of their Endpoints
+	useEndpointSlices bool
+}
+
+// Option configures the APIServiceController.
+type Option func(*APIServiceController)
+
+// WithEndpointSlices makes the controller wait for a ready endpoint of the services of the enabled APIServices in their
+// EndpointSlices instead of their Endpoints, which are capped at 1000 addresses and not mirrored when Endpoints
+// mirroring is disabled. The operator must be allowed to list and watch the EndpointSlices of the operand namespace.
+func WithEndpointSlices() Option {
+	return func(c *APIServiceController) {
+		c.useEndpointSlices = true
+	}
 }
 
 func NewAPIServiceController(
@@ -52,20 +68,34 @@ func NewAPIServiceController(
 	kubeInformersForOperandNamespace kubeinformers.SharedInformerFactory,
 	kubeClient kubernetes.Interface,
 	eventRecorder events.Recorder,
+	opts ...Option,
 ) factory.Controller {
 	c := &APIServiceController{
-		preconditionForEnabledAPIServices: newEndpointPrecondition(kubeInformersForOperandNamespace),
-		getAPIServicesToManageFn:          getAPIServicesToManageFunc,
+		getAPIServicesToManageFn: getAPIServicesToManageFunc,
 
 		operatorClient:          operatorClient,
 		apiregistrationv1Client: apiregistrationv1Client,
 		apiservicelister:        apiregistrationInformers.Apiregistration().V1().APIServices().Lister(),
 		kubeClient:              kubeClient,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	// the informers are only created for the resources the precondition reads, so that the operator is not required to
+	// watch the Endpoints with WithEndpointSlices
+	var endpointsInformer cache.SharedIndexInformer
+	if c.useEndpointSlices {
+		endpointsInformer = kubeInformersForOperandNamespace.Discovery().V1().EndpointSlices().Informer()
+		c.preconditionForEnabledAPIServices = newEndpointSlicePrecondition(kubeInformersForOperandNamespace)
+	} else {
+		endpointsInformer = kubeInformersForOperandNamespace.Core().V1().Endpoints().Informer()
+		c.preconditionForEnabledAPIServices = newEndpointPrecondition(kubeInformersForOperandNamespace)
+	}
 
 	return factory.New().WithSync(c.sync).ResyncEvery(10*time.Second).WithInformers(
 		kubeInformersForOperandNamespace.Core().V1().Services().Informer(),
-		kubeInformersForOperandNamespace.Core().V1().Endpoints().Informer(),
+		endpointsInformer,
 		apiregistrationInformers.Apiregistration().V1().APIServices().Informer(),
 	).ToController("APIServiceController_"+name, eventRecorder.WithComponentSuffix("apiservice-"+name+"-controller"))
 }
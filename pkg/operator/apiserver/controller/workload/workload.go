@@ -45,6 +45,19 @@ type Delegate interface {
 	PreconditionFulfilled(ctx context.Context) (bool, error)
 }
 
+// MultiDeploymentDelegate is the Delegate of a workload made of several Deployments, e.g. an "apiserver" and a
+// "controller" Deployment, which are reported as one: the workload is available when all the Deployments are, the
+// degraded and progressing messages list the Deployments they are about, and the versions of the operands are only
+// reported once all the Deployments are rolled out.
+type MultiDeploymentDelegate interface {
+	// SyncDeployments brings the Deployments of the workload into operation. A nil Deployment stands for one which
+	// could not be retrieved.
+	SyncDeployments(ctx context.Context, controllerContext factory.SyncContext) ([]*appsv1.Deployment, bool, []error)
+
+	// PreconditionFulfilled is Delegate.PreconditionFulfilled.
+	PreconditionFulfilled(ctx context.Context) (bool, error)
+}
+
 // Controller is a generic workload controller that deals with Deployment resource.
 // Callers must provide a sync function for delegation. It should bring the desired workload into operation.
 // The returned state along with errors will be converted into conditions and persisted in the status field.
@@ -64,6 +77,7 @@ type Controller struct {
 	openshiftClusterConfigClient openshiftconfigclientv1.ClusterOperatorInterface
 
 	delegate           Delegate
+	multiDelegate      MultiDeploymentDelegate
 	queue              workqueue.RateLimitingInterface
 	versionRecorder    status.VersionGetter
 	preRunCachesSynced []cache.InformerSynced
@@ -88,7 +102,37 @@ func NewController(name, operatorNamespace, targetNamespace, targetOperandVersio
 	eventRecorder events.Recorder,
 	versionRecorder status.VersionGetter,
 ) factory.Controller {
-	controllerRef := &Controller{
+	controllerRef := newController(operatorNamespace, targetNamespace, targetOperandVersion, operandNamePrefix, conditionsPrefix, operatorClient, kubeClient, podLister, openshiftClusterConfigClient, versionRecorder, name)
+	controllerRef.delegate = delegate
+	return controllerRef.toController(name, targetNamespace, informers, tagetNamespaceInformers, eventRecorder)
+}
+
+// NewMultiDeploymentController is NewController for a workload made of several Deployments.
+func NewMultiDeploymentController(name, operatorNamespace, targetNamespace, targetOperandVersion, operandNamePrefix, conditionsPrefix string,
+	operatorClient v1helpers.OperatorClient,
+	kubeClient kubernetes.Interface,
+	podLister corev1listers.PodLister,
+	informers []factory.Informer,
+	tagetNamespaceInformers []factory.Informer,
+	delegate MultiDeploymentDelegate,
+	openshiftClusterConfigClient openshiftconfigclientv1.ClusterOperatorInterface,
+	eventRecorder events.Recorder,
+	versionRecorder status.VersionGetter,
+) factory.Controller {
+	controllerRef := newController(operatorNamespace, targetNamespace, targetOperandVersion, operandNamePrefix, conditionsPrefix, operatorClient, kubeClient, podLister, openshiftClusterConfigClient, versionRecorder, name)
+	controllerRef.multiDelegate = delegate
+	return controllerRef.toController(name, targetNamespace, informers, tagetNamespaceInformers, eventRecorder)
+}
+
+func newController(operatorNamespace, targetNamespace, targetOperandVersion, operandNamePrefix, conditionsPrefix string,
+	operatorClient v1helpers.OperatorClient,
+	kubeClient kubernetes.Interface,
+	podLister corev1listers.PodLister,
+	openshiftClusterConfigClient openshiftconfigclientv1.ClusterOperatorInterface,
+	versionRecorder status.VersionGetter,
+	queueName string,
+) *Controller {
+	return &Controller{
 		operatorNamespace:            operatorNamespace,
 		targetNamespace:              targetNamespace,
 		targetOperandVersion:         targetOperandVersion,
@@ -97,18 +141,19 @@ func NewController(name, operatorNamespace, targetNamespace, targetOperandVersio
 		operatorClient:               operatorClient,
 		kubeClient:                   kubeClient,
 		podsLister:                   podLister,
-		delegate:                     delegate,
 		openshiftClusterConfigClient: openshiftClusterConfigClient,
 		versionRecorder:              versionRecorder,
-		queue:                        workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), name),
+		queue:                        workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), queueName),
 	}
+}
 
-	c := factory.New()
+func (c *Controller) toController(name, targetNamespace string, informers, tagetNamespaceInformers []factory.Informer, eventRecorder events.Recorder) factory.Controller {
+	f := factory.New()
 	for _, nsi := range tagetNamespaceInformers {
-		c.WithNamespaceInformer(nsi, targetNamespace)
+		f.WithNamespaceInformer(nsi, targetNamespace)
 	}
 
-	return c.WithSync(controllerRef.sync).
+	return f.WithSync(c.sync).
 		WithInformers(informers...).
 		ToController(fmt.Sprintf("%sWorkloadController", name), eventRecorder)
 }
@@ -123,15 +168,30 @@ func (c *Controller) sync(ctx context.Context, controllerContext factory.SyncCon
 		return err
 	}
 
-	if fulfilled, err := c.delegate.PreconditionFulfilled(ctx); err != nil {
+	delegate := c.multiDelegate
+	if delegate == nil {
+		delegate = singleDeploymentDelegate{c.delegate}
+	}
+
+	if fulfilled, err := delegate.PreconditionFulfilled(ctx); err != nil {
 		return c.updateOperatorStatus(ctx, operatorStatus, nil, false, false, []error{err})
 	} else if !fulfilled {
 		return c.updateOperatorStatus(ctx, operatorStatus, nil, false, false, nil)
 	}
 
-	workload, operatorConfigAtHighestGeneration, errs := c.delegate.Sync(ctx, controllerContext)
+	workloads, operatorConfigAtHighestGeneration, errs := delegate.SyncDeployments(ctx, controllerContext)
+
+	return c.updateOperatorStatus(ctx, operatorStatus, workloads, operatorConfigAtHighestGeneration, true, errs)
+}
 
-	return c.updateOperatorStatus(ctx, operatorStatus, workload, operatorConfigAtHighestGeneration, true, errs)
+// singleDeploymentDelegate is the MultiDeploymentDelegate of the workload of a Delegate.
+type singleDeploymentDelegate struct {
+	Delegate
+}
+
+func (d singleDeploymentDelegate) SyncDeployments(ctx context.Context, controllerContext factory.SyncContext) ([]*appsv1.Deployment, bool, []error) {
+	workload, operatorConfigAtHighestGeneration, errs := d.Sync(ctx, controllerContext)
+	return []*appsv1.Deployment{workload}, operatorConfigAtHighestGeneration, errs
 }
 
 // shouldSync checks ManagementState to determine if we can run this operator, probably set by a cluster administrator.
@@ -152,8 +212,8 @@ func (c *Controller) shouldSync(ctx context.Context, operatorSpec *operatorv1.Op
 	}
 }
 
-// updateOperatorStatus updates the status based on the actual workload and errors that might have occurred during synchronization.
-func (c *Controller) updateOperatorStatus(ctx context.Context, previousStatus *operatorv1.OperatorStatus, workload *appsv1.Deployment, operatorConfigAtHighestGeneration bool, preconditionsReady bool, errs []error) (err error) {
+// updateOperatorStatus updates the status based on the actual Deployments of the workload and errors that might have occurred during synchronization.
+func (c *Controller) updateOperatorStatus(ctx context.Context, previousStatus *operatorv1.OperatorStatus, workloads []*appsv1.Deployment, operatorConfigAtHighestGeneration bool, preconditionsReady bool, errs []error) (err error) {
 	if errs == nil {
 		errs = []error{}
 	}
@@ -231,7 +291,7 @@ func (c *Controller) updateOperatorStatus(ctx context.Context, previousStatus *o
 		workloadDegradedCondition.Status = operatorv1.ConditionFalse
 	}
 
-	if workload == nil {
+	if len(workloads) == 0 || hasNilDeployment(workloads) {
 		message := fmt.Sprintf("deployment/%s: could not be retrieved", c.targetNamespace)
 		deploymentAvailableCondition.Status = operatorv1.ConditionFalse
 		deploymentAvailableCondition.Reason = "NoDeployment"
@@ -248,71 +308,99 @@ func (c *Controller) updateOperatorStatus(ctx context.Context, previousStatus *o
 		return kerrors.NewAggregate(errs)
 	}
 
-	if workload.Status.AvailableReplicas == 0 {
+	workloadIsBeingUpdatedTooLong, err := isUpdatingTooLong(previousStatus, deploymentProgressingCondition.Type)
+	var unavailableMessages, progressingMessages, degradedMessages []string
+	allWorkloadsRolledOut := true
+	for _, workload := range workloads {
+		if workload.Status.AvailableReplicas == 0 {
+			unavailableMessages = append(unavailableMessages, fmt.Sprintf("no %s.%s pods available on any node.", workload.Name, c.targetNamespace))
+		}
+
+		desiredReplicas := int32(1)
+		if workload.Spec.Replicas != nil {
+			desiredReplicas = *(workload.Spec.Replicas)
+		}
+
+		// If the workload is up to date, then we are no longer progressing
+		workloadAtHighestGeneration := workload.ObjectMeta.Generation == workload.Status.ObservedGeneration
+		workloadIsBeingUpdated := workload.Status.UpdatedReplicas < desiredReplicas
+		if !workloadAtHighestGeneration {
+			if len(progressingMessages) == 0 {
+				deploymentProgressingCondition.Reason = "NewGeneration"
+			}
+			progressingMessages = append(progressingMessages, fmt.Sprintf("deployment/%s.%s: observed generation is %d, desired generation is %d.", workload.Name, c.targetNamespace, workload.Status.ObservedGeneration, workload.ObjectMeta.Generation))
+		} else if workloadIsBeingUpdated {
+			if len(progressingMessages) == 0 {
+				deploymentProgressingCondition.Reason = "PodsUpdating"
+			}
+			progressingMessages = append(progressingMessages, fmt.Sprintf("deployment/%s.%s: %d/%d pods have been updated to the latest generation", workload.Name, c.targetNamespace, workload.Status.UpdatedReplicas, desiredReplicas))
+		}
+
+		// During a rollout the default maxSurge (25%) will allow the available
+		// replicas to temporarily exceed the desired replica count. If this were
+		// to occur, the operator should not report degraded.
+		workloadHasAllPodsAvailable := workload.Status.AvailableReplicas >= desiredReplicas
+		if !workloadHasAllPodsAvailable && (!workloadIsBeingUpdated || workloadIsBeingUpdatedTooLong) {
+			numNonAvailablePods := desiredReplicas - workload.Status.AvailableReplicas
+			podContainersStatus, err := deployment.PodContainersStatus(workload, c.podsLister)
+			if err != nil {
+				podContainersStatus = []string{fmt.Sprintf("failed to get pod containers details: %v", err)}
+			}
+			degradedMessages = append(degradedMessages, fmt.Sprintf("%v of %v requested instances are unavailable for %s.%s (%s)", numNonAvailablePods, desiredReplicas, workload.Name, c.targetNamespace,
+				strings.Join(podContainersStatus, ", ")))
+		}
+
+		workloadHasAllPodsUpdated := workload.Status.UpdatedReplicas == desiredReplicas
+		if !workloadAtHighestGeneration || !workloadHasAllPodsAvailable || !workloadHasAllPodsUpdated {
+			allWorkloadsRolledOut = false
+		}
+	}
+
+	if len(unavailableMessages) > 0 {
 		deploymentAvailableCondition.Status = operatorv1.ConditionFalse
 		deploymentAvailableCondition.Reason = "NoPod"
-		deploymentAvailableCondition.Message = fmt.Sprintf("no %s.%s pods available on any node.", workload.Name, c.targetNamespace)
+		deploymentAvailableCondition.Message = strings.Join(unavailableMessages, "\n")
 	} else {
 		deploymentAvailableCondition.Status = operatorv1.ConditionTrue
 		deploymentAvailableCondition.Reason = "AsExpected"
 	}
 
-	desiredReplicas := int32(1)
-	if workload.Spec.Replicas != nil {
-		desiredReplicas = *(workload.Spec.Replicas)
-	}
-
-	// If the workload is up to date, then we are no longer progressing
-	workloadAtHighestGeneration := workload.ObjectMeta.Generation == workload.Status.ObservedGeneration
-	workloadIsBeingUpdated := workload.Status.UpdatedReplicas < desiredReplicas
-	workloadIsBeingUpdatedTooLong, err := isUpdatingTooLong(previousStatus, deploymentProgressingCondition.Type)
-	if !workloadAtHighestGeneration {
-		deploymentProgressingCondition.Status = operatorv1.ConditionTrue
-		deploymentProgressingCondition.Reason = "NewGeneration"
-		deploymentProgressingCondition.Message = fmt.Sprintf("deployment/%s.%s: observed generation is %d, desired generation is %d.", workload.Name, c.targetNamespace, workload.Status.ObservedGeneration, workload.ObjectMeta.Generation)
-	} else if workloadIsBeingUpdated {
+	if len(progressingMessages) > 0 {
 		deploymentProgressingCondition.Status = operatorv1.ConditionTrue
-		deploymentProgressingCondition.Reason = "PodsUpdating"
-		deploymentProgressingCondition.Message = fmt.Sprintf("deployment/%s.%s: %d/%d pods have been updated to the latest generation", workload.Name, c.targetNamespace, workload.Status.UpdatedReplicas, desiredReplicas)
+		deploymentProgressingCondition.Message = strings.Join(progressingMessages, "\n")
 	} else {
 		deploymentProgressingCondition.Status = operatorv1.ConditionFalse
 		deploymentProgressingCondition.Reason = "AsExpected"
 	}
 
-	// During a rollout the default maxSurge (25%) will allow the available
-	// replicas to temporarily exceed the desired replica count. If this were
-	// to occur, the operator should not report degraded.
-	workloadHasAllPodsAvailable := workload.Status.AvailableReplicas >= desiredReplicas
-	if !workloadHasAllPodsAvailable && (!workloadIsBeingUpdated || workloadIsBeingUpdatedTooLong) {
-		numNonAvailablePods := desiredReplicas - workload.Status.AvailableReplicas
+	if len(degradedMessages) > 0 {
 		deploymentDegradedCondition.Status = operatorv1.ConditionTrue
 		deploymentDegradedCondition.Reason = "UnavailablePod"
-		podContainersStatus, err := deployment.PodContainersStatus(workload, c.podsLister)
-		if err != nil {
-			podContainersStatus = []string{fmt.Sprintf("failed to get pod containers details: %v", err)}
-		}
-		deploymentDegradedCondition.Message = fmt.Sprintf("%v of %v requested instances are unavailable for %s.%s (%s)", numNonAvailablePods, desiredReplicas, workload.Name, c.targetNamespace,
-			strings.Join(podContainersStatus, ", "))
+		deploymentDegradedCondition.Message = strings.Join(degradedMessages, "\n")
 	} else {
 		deploymentDegradedCondition.Status = operatorv1.ConditionFalse
 		deploymentDegradedCondition.Reason = "AsExpected"
 	}
 
-	// if the deployment is all available and at the expected generation, then update the version to the latest
+	// if all the deployments are available and at the expected generation, then update the versions to the latest
 	// when we update, the image pull spec should immediately be different, which should immediately cause a deployment rollout
 	// which should immediately result in a deployment generation diff, which should cause this block to be skipped until it is ready.
-	workloadHasAllPodsUpdated := workload.Status.UpdatedReplicas == desiredReplicas
-	if workloadAtHighestGeneration && workloadHasAllPodsAvailable && workloadHasAllPodsUpdated && operatorConfigAtHighestGeneration {
-		operandName := workload.Name
-		if len(c.operandNamePrefix) > 0 {
-			operandName = fmt.Sprintf("%s-%s", c.operandNamePrefix, workload.Name)
+	// The versions of the deployments of a workload are updated together, once they are all rolled out.
+	if allWorkloadsRolledOut && operatorConfigAtHighestGeneration {
+		for _, workload := range workloads {
+			operandName := workload.Name
+			if len(c.operandNamePrefix) > 0 {
+				operandName = fmt.Sprintf("%s-%s", c.operandNamePrefix, workload.Name)
+			}
+			c.versionRecorder.SetVersion(operandName, c.targetOperandVersion)
 		}
-		c.versionRecorder.SetVersion(operandName, c.targetOperandVersion)
 	}
 
 	// set updateGenerationFn so that it is invoked in defer
 	updateGenerationFn = func(newStatus *operatorv1.OperatorStatus) error {
-		resourcemerge.SetDeploymentGeneration(&newStatus.Generations, workload)
+		for _, workload := range workloads {
+			resourcemerge.SetDeploymentGeneration(&newStatus.Generations, workload)
+		}
 		return nil
 	}
 
@@ -322,6 +410,16 @@ func (c *Controller) updateOperatorStatus(ctx context.Context, previousStatus *o
 	return nil
 }
 
+// hasNilDeployment returns whether a Deployment of the workload could not be retrieved.
+func hasNilDeployment(workloads []*appsv1.Deployment) bool {
+	for _, workload := range workloads {
+		if workload == nil {
+			return true
+		}
+	}
+	return false
+}
+
 // isUpdatingTooLong determines if updating operands takes too long.
 // it returns true if the progressing condition has been set to True for at least 15 minutes
 func isUpdatingTooLong(operatorStatus *operatorv1.OperatorStatus, progressingConditionType string) (bool, error) {
@@ -14,6 +14,7 @@ import (
 	operatorv1 "github.com/openshift/api/operator/v1"
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/status"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -597,6 +598,92 @@ func TestUpdateOperatorStatus(t *testing.T) {
 	}
 }
 
+var _ MultiDeploymentDelegate = &testMultiDeploymentDelegate{}
+
+type testMultiDeploymentDelegate struct {
+	workloads []*appsv1.Deployment
+}
+
+func (d *testMultiDeploymentDelegate) PreconditionFulfilled(_ context.Context) (bool, error) {
+	return true, nil
+}
+
+func (d *testMultiDeploymentDelegate) SyncDeployments(_ context.Context, _ factory.SyncContext) ([]*appsv1.Deployment, bool, []error) {
+	return d.workloads, true, nil
+}
+
+func TestMultiDeploymentWorkload(t *testing.T) {
+	newDeployment := func(name string, available, updated int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "openshift-apiserver", Generation: 2},
+			Spec:       appsv1.DeploymentSpec{Replicas: pointer.Int32(3)},
+			Status:     appsv1.DeploymentStatus{AvailableReplicas: available, UpdatedReplicas: updated, ObservedGeneration: 2},
+		}
+	}
+
+	fakeOperatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+	versionRecorder := status.NewVersionGetter()
+	delegate := &testMultiDeploymentDelegate{}
+	target := &Controller{
+		operatorClient:       fakeOperatorClient,
+		targetNamespace:      "openshift-apiserver",
+		targetOperandVersion: "4.14.0",
+		podsLister:           &fakePodLister{},
+		multiDelegate:        delegate,
+		versionRecorder:      versionRecorder,
+	}
+	sync := func(workloads ...*appsv1.Deployment) *operatorv1.OperatorStatus {
+		t.Helper()
+		delegate.workloads = workloads
+		if err := target.sync(context.TODO(), factory.NewSyncContext("workloadcontroller_test", events.NewInMemoryRecorder("workloadcontroller_test"))); err != nil {
+			t.Fatal(err)
+		}
+		_, actualStatus, _, err := fakeOperatorClient.GetOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return actualStatus
+	}
+
+	// the controller deployment is not available and is rolling out
+	actualStatus := sync(newDeployment("apiserver", 3, 3), newDeployment("controller", 0, 1))
+	if err := areCondidtionsEqual([]operatorv1.OperatorCondition{
+		{Type: "DeploymentAvailable", Status: operatorv1.ConditionFalse, Reason: "NoPod", Message: "no controller.openshift-apiserver pods available on any node."},
+		{Type: "WorkloadDegraded", Status: operatorv1.ConditionFalse},
+		{Type: "DeploymentDegraded", Status: operatorv1.ConditionFalse, Reason: "AsExpected"},
+		{Type: "DeploymentProgressing", Status: operatorv1.ConditionTrue, Reason: "PodsUpdating", Message: "deployment/controller.openshift-apiserver: 1/3 pods have been updated to the latest generation"},
+	}, actualStatus.Conditions); err != nil {
+		t.Fatal(err)
+	}
+	if versions := versionRecorder.GetVersions(); len(versions) != 0 {
+		t.Errorf("expected no version before all the deployments are rolled out, got %v", versions)
+	}
+	if len(actualStatus.Generations) != 2 {
+		t.Errorf("expected the generations of both deployments, got %v", actualStatus.Generations)
+	}
+
+	// both deployments are rolled out
+	actualStatus = sync(newDeployment("apiserver", 3, 3), newDeployment("controller", 3, 3))
+	if err := areCondidtionsEqual([]operatorv1.OperatorCondition{
+		{Type: "DeploymentAvailable", Status: operatorv1.ConditionTrue, Reason: "AsExpected"},
+		{Type: "WorkloadDegraded", Status: operatorv1.ConditionFalse},
+		{Type: "DeploymentDegraded", Status: operatorv1.ConditionFalse, Reason: "AsExpected"},
+		{Type: "DeploymentProgressing", Status: operatorv1.ConditionFalse, Reason: "AsExpected"},
+	}, actualStatus.Conditions); err != nil {
+		t.Fatal(err)
+	}
+	expectedVersions := map[string]string{"apiserver": "4.14.0", "controller": "4.14.0"}
+	if versions := versionRecorder.GetVersions(); !equality.Semantic.DeepEqual(expectedVersions, versions) {
+		t.Errorf("expected versions %v, got %v", expectedVersions, versions)
+	}
+
+	// a missing deployment makes the whole workload unavailable
+	actualStatus = sync(newDeployment("apiserver", 3, 3), nil)
+	if condition := v1helpers.FindOperatorCondition(actualStatus.Conditions, "DeploymentAvailable"); condition == nil || condition.Reason != "NoDeployment" {
+		t.Errorf("expected the workload to be unavailable without a deployment, got %v", condition)
+	}
+}
+
 type fakePodLister struct {
 	pods []*corev1.Pod
 }
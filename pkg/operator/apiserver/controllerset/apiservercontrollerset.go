@@ -180,6 +180,7 @@ func (cs *APIServerControllerSet) WithAPIServiceController(
 	apiregistrationv1Client apiregistrationv1client.ApiregistrationV1Interface,
 	kubeInformersForTargetNamesace kubeinformers.SharedInformerFactory,
 	kubeClient kubernetes.Interface,
+	opts ...apiservice.Option,
 ) *APIServerControllerSet {
 	cs.apiServiceController.controller = apiservice.NewAPIServiceController(
 		controllerName,
@@ -190,6 +191,7 @@ func (cs *APIServerControllerSet) WithAPIServiceController(
 		kubeInformersForTargetNamesace,
 		kubeClient,
 		cs.eventRecorder,
+		opts...,
 	)
 	return cs
 }
@@ -275,13 +277,7 @@ func (cs *APIServerControllerSet) WithWorkloadController(
 		cs.operatorClient,
 		kubeClient,
 		kubeInformersForNamespaces.PodLister(),
-		append(informers,
-			kubeInformersForNamespaces.InformersFor(targetNamespace).Core().V1().ConfigMaps().Informer(),
-			kubeInformersForNamespaces.InformersFor(targetNamespace).Core().V1().Secrets().Informer(),
-			kubeInformersForNamespaces.InformersFor(targetNamespace).Core().V1().Pods().Informer(),
-			kubeInformersForNamespaces.InformersFor(targetNamespace).Apps().V1().Deployments().Informer(),
-			kubeInformersForNamespaces.InformersFor(metav1.NamespaceSystem).Core().V1().Nodes().Informer(),
-		),
+		workloadInformers(targetNamespace, kubeInformersForNamespaces, informers),
 		[]factory.Informer{kubeInformersForNamespaces.InformersFor(targetNamespace).Core().V1().Namespaces().Informer()},
 
 		delegate,
@@ -293,6 +289,48 @@ func (cs *APIServerControllerSet) WithWorkloadController(
 	return cs
 }
 
+// WithMultiDeploymentWorkloadController is WithWorkloadController for an operand made of several Deployments, which
+// are reported as one workload.
+func (cs *APIServerControllerSet) WithMultiDeploymentWorkloadController(
+	name, operatorNamespace, targetNamespace, targetOperandVersion, operandNamePrefix, conditionsPrefix string,
+	kubeClient kubernetes.Interface,
+	delegate workload.MultiDeploymentDelegate,
+	openshiftClusterConfigClient openshiftconfigclientv1.ClusterOperatorInterface,
+	versionRecorder status.VersionGetter,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	informers ...factory.Informer) *APIServerControllerSet {
+
+	cs.workloadController.controller = workload.NewMultiDeploymentController(
+		name,
+		operatorNamespace,
+		targetNamespace,
+		targetOperandVersion,
+		operandNamePrefix,
+		conditionsPrefix,
+		cs.operatorClient,
+		kubeClient,
+		kubeInformersForNamespaces.PodLister(),
+		workloadInformers(targetNamespace, kubeInformersForNamespaces, informers),
+		[]factory.Informer{kubeInformersForNamespaces.InformersFor(targetNamespace).Core().V1().Namespaces().Informer()},
+
+		delegate,
+		openshiftClusterConfigClient,
+		cs.eventRecorder,
+		versionRecorder)
+	return cs
+}
+
+// workloadInformers returns the informers of the workload controller, in addition to the given ones.
+func workloadInformers(targetNamespace string, kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces, informers []factory.Informer) []factory.Informer {
+	return append(informers,
+		kubeInformersForNamespaces.InformersFor(targetNamespace).Core().V1().ConfigMaps().Informer(),
+		kubeInformersForNamespaces.InformersFor(targetNamespace).Core().V1().Secrets().Informer(),
+		kubeInformersForNamespaces.InformersFor(targetNamespace).Core().V1().Pods().Informer(),
+		kubeInformersForNamespaces.InformersFor(targetNamespace).Apps().V1().Deployments().Informer(),
+		kubeInformersForNamespaces.InformersFor(metav1.NamespaceSystem).Core().V1().Nodes().Informer(),
+	)
+}
+
 func (cs *APIServerControllerSet) WithoutWorkloadController() *APIServerControllerSet {
 	cs.workloadController.controller = nil
 	cs.workloadController.emptyAllowed = true
@@ -7,6 +7,7 @@ import (
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/clock"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/condition"
@@ -45,6 +46,31 @@ type CertRotationController struct {
 
 	// Plumbing:
 	OperatorClient v1helpers.StaticPodOperatorClient
+
+	clock clock.PassiveClock
+}
+
+// Option configures the rotation controllers.
+type Option func(*options)
+
+type options struct {
+	clock clock.PassiveClock
+}
+
+// WithClock sets the clock the rotation decisions are based on, e.g. a fake clock in unit tests. Only the decisions
+// use it: the certificates are issued by pkg/crypto, whose NotBefore and NotAfter are based on the real time.
+func WithClock(clock clock.PassiveClock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{clock: clock.RealClock{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
 }
 
 func NewCertRotationController(
@@ -54,6 +80,7 @@ func NewCertRotationController(
 	rotatedSelfSignedCertKeySecret RotatedSelfSignedCertKeySecret,
 	operatorClient v1helpers.StaticPodOperatorClient,
 	recorder events.Recorder,
+	opts ...Option,
 ) factory.Controller {
 	c := &CertRotationController{
 		name:                           name,
@@ -61,6 +88,7 @@ func NewCertRotationController(
 		CABundleConfigMap:              caBundleConfigMap,
 		RotatedSelfSignedCertKeySecret: rotatedSelfSignedCertKeySecret,
 		OperatorClient:                 operatorClient,
+		clock:                          newOptions(opts...).clock,
 	}
 	return factory.New().
 		ResyncEvery(time.Minute).
@@ -106,7 +134,8 @@ func (c CertRotationController) Sync(ctx context.Context, syncCtx factory.SyncCo
 }
 
 func (c CertRotationController) syncWorker(ctx context.Context) error {
-	signingCertKeyPair, err := c.rotatedSigningCASecret.ensureSigningCertKeyPair(ctx)
+	now := c.clock.Now()
+	signingCertKeyPair, err := c.rotatedSigningCASecret.ensureSigningCertKeyPair(ctx, now)
 	if err != nil {
 		return err
 	}
@@ -116,7 +145,7 @@ func (c CertRotationController) syncWorker(ctx context.Context) error {
 		return err
 	}
 
-	if err := c.RotatedSelfSignedCertKeySecret.ensureTargetCertKeyPair(ctx, signingCertKeyPair, cabundleCerts); err != nil {
+	if err := c.RotatedSelfSignedCertKeySecret.ensureTargetCertKeyPair(ctx, signingCertKeyPair, cabundleCerts, now); err != nil {
 		return err
 	}
 
@@ -0,0 +1,147 @@
+package certrotation
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/openshift/library-go/pkg/crypto"
+)
+
+// defaultMaxIssuanceRecords keeps the config map of the log well below the size limit of objects.
+const defaultMaxIssuanceRecords = 1000
+
+// CertificateIssuanceLog is a log of the certificates signed by the rotation controllers, so that security teams can
+// audit what the in-cluster CAs have issued. Each certificate is recorded as the JSON CertificateIssuanceRecord under its
+// SHA-256 fingerprint in the config map of the log. Records are never updated; once the log holds MaxRecords records,
+// the oldest ones are removed, so the log must be collected regularly to keep the full history.
+//
+// Recording is best effort: a failure is reported as an event and does not stop the rotation, so that certificates
+// never expire because of the log.
+type CertificateIssuanceLog struct {
+	// Namespace is the namespace of the ConfigMap.
+	Namespace string
+	// Name is the name of the ConfigMap.
+	Name string
+	// MaxRecords is the number of records kept in the log. It defaults to 1000.
+	MaxRecords int
+
+	// Plumbing:
+	Client corev1client.ConfigMapsGetter
+}
+
+// CertificateIssuanceRecord describes an issued certificate.
+type CertificateIssuanceRecord struct {
+	Subject      string    `json:"subject"`
+	SerialNumber string    `json:"serialNumber"`
+	NotBefore    time.Time `json:"notBefore"`
+	NotAfter     time.Time `json:"notAfter"`
+	Issuer       string    `json:"issuer"`
+	// SignerSerialNumber is the serial number of the signing certificate, i.e. the generation of the signer that issued
+	// the certificate. It is the serial number of the certificate for the self-signed signers.
+	SignerSerialNumber string `json:"signerSerialNumber"`
+	// Secret is the namespace/name of the secret the certificate was issued for.
+	Secret   string    `json:"secret"`
+	IssuedAt time.Time `json:"issuedAt"`
+}
+
+// recordSecret records the certificate of the tls.crt of the secret, issued at now by the signer or self-signed when
+// signer is nil.
+func (l *CertificateIssuanceLog) recordSecret(ctx context.Context, secret *corev1.Secret, signer *x509.Certificate, now time.Time) error {
+	certs, err := crypto.CertsFromPEM(secret.Data["tls.crt"])
+	if err != nil {
+		return fmt.Errorf("failed to read the certificate of %q in %q: %w", secret.Name, secret.Namespace, err)
+	}
+	if signer == nil {
+		signer = certs[0]
+	}
+	return l.record(ctx, certs[0], signer, secret.Namespace+"/"+secret.Name, now)
+}
+
+func (l *CertificateIssuanceLog) record(ctx context.Context, cert, signer *x509.Certificate, secret string, now time.Time) error {
+	fingerprint := sha256.Sum256(cert.Raw)
+	key := hex.EncodeToString(fingerprint[:])
+	record, err := json.Marshal(CertificateIssuanceRecord{
+		Subject:            cert.Subject.String(),
+		SerialNumber:       cert.SerialNumber.Text(16),
+		NotBefore:          cert.NotBefore.UTC(),
+		NotAfter:           cert.NotAfter.UTC(),
+		Issuer:             cert.Issuer.String(),
+		SignerSerialNumber: signer.SerialNumber.Text(16),
+		Secret:             secret,
+		IssuedAt:           now.UTC().Truncate(time.Second),
+	})
+	if err != nil {
+		return err
+	}
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		configMap, err := l.Client.ConfigMaps(l.Namespace).Get(ctx, l.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			_, err = l.Client.ConfigMaps(l.Namespace).Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: l.Namespace, Name: l.Name},
+				Data:       map[string]string{key: string(record)},
+			}, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				// created concurrently, retry the append
+				return apierrors.NewConflict(corev1.Resource("configmaps"), l.Name, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if _, ok := configMap.Data[key]; ok {
+			return nil
+		}
+		configMap = configMap.DeepCopy()
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data[key] = string(record)
+		l.prune(configMap.Data)
+		_, err = l.Client.ConfigMaps(l.Namespace).Update(ctx, configMap, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// prune removes the oldest records beyond MaxRecords. Records that cannot be parsed are removed first.
+func (l *CertificateIssuanceLog) prune(records map[string]string) {
+	maxRecords := l.MaxRecords
+	if maxRecords <= 0 {
+		maxRecords = defaultMaxIssuanceRecords
+	}
+	if len(records) <= maxRecords {
+		return
+	}
+
+	keys := make([]string, 0, len(records))
+	issuedAt := make(map[string]time.Time, len(records))
+	for key, data := range records {
+		keys = append(keys, key)
+		var record CertificateIssuanceRecord
+		if err := json.Unmarshal([]byte(data), &record); err == nil {
+			issuedAt[key] = record.IssuedAt
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !issuedAt[keys[i]].Equal(issuedAt[keys[j]]) {
+			return issuedAt[keys[i]].Before(issuedAt[keys[j]])
+		}
+		return keys[i] < keys[j]
+	})
+	for _, key := range keys[:len(keys)-maxRecords] {
+		delete(records, key)
+	}
+}
@@ -0,0 +1,141 @@
+package certrotation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestCertificateIssuanceLog(t *testing.T) {
+	client := kubefake.NewSimpleClientset()
+	issuanceLog := &CertificateIssuanceLog{Namespace: "ns", Name: "issuance-log", Client: client.CoreV1()}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+
+	signer := &RotatedSigningCASecret{
+		Namespace:     "ns",
+		Name:          "signer",
+		Validity:      24 * time.Hour,
+		Refresh:       12 * time.Hour,
+		IssuanceLog:   issuanceLog,
+		Client:        client.CoreV1(),
+		Lister:        corev1listers.NewSecretLister(indexer),
+		EventRecorder: events.NewInMemoryRecorder("test"),
+	}
+	ca, err := signer.ensureSigningCertKeyPair(context.TODO(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := &RotatedSelfSignedCertKeySecret{
+		Namespace:     "ns",
+		Name:          "target",
+		Validity:      time.Hour,
+		Refresh:       30 * time.Minute,
+		CertCreator:   &ClientRotation{UserInfo: &user.DefaultInfo{Name: "client"}},
+		IssuanceLog:   issuanceLog,
+		Client:        client.CoreV1(),
+		Lister:        corev1listers.NewSecretLister(indexer),
+		EventRecorder: events.NewInMemoryRecorder("test"),
+	}
+	if err := target.ensureTargetCertKeyPair(context.TODO(), ca, ca.Config.Certs, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	configMap, err := client.CoreV1().ConfigMaps("ns").Get(context.TODO(), "issuance-log", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configMap.Data) != 2 {
+		t.Fatalf("expected 2 records, got %v", configMap.Data)
+	}
+	records := map[string]CertificateIssuanceRecord{}
+	for _, data := range configMap.Data {
+		var record CertificateIssuanceRecord
+		if err := json.Unmarshal([]byte(data), &record); err != nil {
+			t.Fatal(err)
+		}
+		records[record.Secret] = record
+	}
+
+	caSerial := ca.Config.Certs[0].SerialNumber.Text(16)
+	if record := records["ns/signer"]; record.SerialNumber != caSerial || record.SignerSerialNumber != caSerial || record.Subject != ca.Config.Certs[0].Subject.String() {
+		t.Errorf("unexpected record of the signer: %#v", record)
+	}
+	if record := records["ns/target"]; record.Subject != "CN=client" || record.SignerSerialNumber != caSerial || record.Issuer != ca.Config.Certs[0].Subject.String() || record.NotAfter.Sub(record.NotBefore) > time.Hour+time.Second {
+		t.Errorf("unexpected record of the target: %#v", record)
+	}
+
+	// records are appended once
+	secret, err := client.CoreV1().Secrets("ns").Get(context.TODO(), "target", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := issuanceLog.recordSecret(context.TODO(), secret, ca.Config.Certs[0], time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	configMap, err = client.CoreV1().ConfigMaps("ns").Get(context.TODO(), "issuance-log", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configMap.Data) != 2 {
+		t.Errorf("expected the records to be kept, got %v", configMap.Data)
+	}
+}
+
+func TestCertificateIssuanceLogPrune(t *testing.T) {
+	issuanceLog := &CertificateIssuanceLog{MaxRecords: 2}
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	records := map[string]string{}
+	for i, key := range []string{"c", "a", "b"} {
+		data, err := json.Marshal(CertificateIssuanceRecord{IssuedAt: now.Add(time.Duration(i) * time.Hour)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		records[key] = string(data)
+	}
+	records["invalid"] = "{"
+
+	issuanceLog.prune(records)
+	if _, ok := records["a"]; !ok || len(records) != 2 {
+		t.Errorf("expected the two most recent records to be kept, got %v", records)
+	}
+	if _, ok := records["b"]; !ok {
+		t.Errorf("expected the two most recent records to be kept, got %v", records)
+	}
+}
+
+func TestCertificateIssuanceLogFailureDoesNotBlockRotation(t *testing.T) {
+	client := kubefake.NewSimpleClientset()
+	client.PrependReactor("*", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("request entity too large")
+	})
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	signer := &RotatedSigningCASecret{
+		Namespace:     "ns",
+		Name:          "signer",
+		Validity:      24 * time.Hour,
+		Refresh:       12 * time.Hour,
+		IssuanceLog:   &CertificateIssuanceLog{Namespace: "ns", Name: "issuance-log", Client: client.CoreV1()},
+		Client:        client.CoreV1(),
+		Lister:        corev1listers.NewSecretLister(indexer),
+		EventRecorder: events.NewInMemoryRecorder("test"),
+	}
+	if _, err := signer.ensureSigningCertKeyPair(context.TODO(), time.Now()); err != nil {
+		t.Fatalf("expected the rotation to succeed despite the issuance log, got %v", err)
+	}
+	if _, err := client.CoreV1().Secrets("ns").Get(context.TODO(), "signer", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the signer to be created: %v", err)
+	}
+}
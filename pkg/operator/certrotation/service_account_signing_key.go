@@ -0,0 +1,387 @@
+package certrotation
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/utils/clock"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/condition"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+const (
+	// ServiceAccountSigningKeyNotBeforeAnnotation contains the time the current signing key started signing tokens in RFC3339 format.
+	ServiceAccountSigningKeyNotBeforeAnnotation = "auth.openshift.io/service-account-signing-key-not-before"
+	// ServiceAccountSigningKeyStagedAtAnnotation contains the time the public key of the next signing key was published in RFC3339 format.
+	ServiceAccountSigningKeyStagedAtAnnotation = "auth.openshift.io/service-account-signing-key-staged-at"
+	// ServiceAccountPreviousPublicKeyAnnotation contains the ID of the public key of the previous signing key, still trusted
+	// for the tokens it signed.
+	ServiceAccountPreviousPublicKeyAnnotation = "auth.openshift.io/service-account-previous-public-key"
+	// ServiceAccountPreviousPublicKeyRetireAfterAnnotation contains the time after which the public key of the previous signing
+	// key is not trusted anymore in RFC3339 format.
+	ServiceAccountPreviousPublicKeyRetireAfterAnnotation = "auth.openshift.io/service-account-previous-public-key-retire-after"
+
+	// ServiceAccountSigningKeyKey is the key of the private key signing the tokens in the secret.
+	ServiceAccountSigningKeyKey = "service-account.key"
+	// ServiceAccountPublicKeyKey is the key of the public key of the signing key in the secret.
+	ServiceAccountPublicKeyKey = "service-account.pub"
+	// ServiceAccountNextSigningKeyKey is the key of the staged next signing key in the secret.
+	ServiceAccountNextSigningKeyKey = "next-service-account.key"
+	// ServiceAccountNextPublicKeyKey is the key of the public key of the staged next signing key in the secret.
+	ServiceAccountNextPublicKeyKey = "next-service-account.pub"
+
+	serviceAccountSigningKeyBits = 2048
+)
+
+// PublicKeysDistributedFunc returns whether all the API servers trust the public keys of the config map, e.g. whether they
+// all run a revision including it, or why not.
+type PublicKeysDistributedFunc func(ctx context.Context, publicKeys *corev1.ConfigMap) (bool, string, error)
+
+// RotatedServiceAccountSigningKey rotates the key signing the bound service account tokens, stored in a secret, and
+// maintains the config map of the public keys the API servers trust to validate the tokens. A rotation takes three steps,
+// none of which invalidates a token in use:
+//
+// 1) once the signing key is older than Refresh, a new key is generated and its public key is published.
+// 2) once the new public key has been published for PublicKeyPropagation and DistributedFn reports that it is trusted,
+// the new key starts signing the tokens.
+// 3) once TokenRefreshHorizon has passed since then, the tokens signed by the previous key have been refreshed and its
+// public key is removed.
+type RotatedServiceAccountSigningKey struct {
+	// Namespace is the namespace of the Secret and of the ConfigMap.
+	Namespace string
+	// Name is the name of the Secret holding the signing key.
+	Name string
+	// PublicKeysName is the name of the ConfigMap holding the trusted public keys.
+	PublicKeysName string
+	// Refresh is the duration after the signing key started signing when it is rotated.
+	Refresh time.Duration
+	// PublicKeyPropagation is the minimal duration between the publication of the new public key and its use, which is
+	// expected to cover the rollout of the public keys to all the API servers.
+	PublicKeyPropagation time.Duration
+	// TokenRefreshHorizon is the duration after which all the tokens in use have been refreshed, i.e. the maximum
+	// lifetime of the tokens before their refresh, after which the public key of the previous signing key is retired.
+	TokenRefreshHorizon time.Duration
+	// DistributedFn optionally checks that the public keys are trusted before signing with the new key.
+	DistributedFn PublicKeysDistributedFunc
+
+	// Plumbing:
+	Informer          corev1informers.SecretInformer
+	Lister            corev1listers.SecretLister
+	Client            corev1client.SecretsGetter
+	ConfigMapInformer corev1informers.ConfigMapInformer
+	ConfigMapLister   corev1listers.ConfigMapLister
+	ConfigMapClient   corev1client.ConfigMapsGetter
+	EventRecorder     events.Recorder
+}
+
+// signingKeyRotationStatus describes the step of the rotation the signing key is in.
+type signingKeyRotationStatus struct {
+	progressing bool
+	reason      string
+	message     string
+}
+
+func (c RotatedServiceAccountSigningKey) ensureServiceAccountSigningKey(ctx context.Context, now time.Time) (*signingKeyRotationStatus, error) {
+	originalSecret, err := c.Lister.Secrets(c.Namespace).Get(c.Name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	secret := originalSecret.DeepCopy()
+	if apierrors.IsNotFound(err) {
+		secret = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: c.Namespace, Name: c.Name}}
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	LabelAsManagedSecret(secret, CertificateTypeSigner)
+
+	existingPublicKeys, err := c.ConfigMapLister.ConfigMaps(c.Namespace).Get(c.PublicKeysName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	var status *signingKeyRotationStatus
+	if len(secret.Data[ServiceAccountSigningKeyKey]) == 0 {
+		// no token has been signed yet, so the first key signs right away
+		c.EventRecorder.Eventf("ServiceAccountSigningKeyCreated", "%q in %q has no service account signing key, creating one", c.Name, c.Namespace)
+		if err := setServiceAccountSigningKey(secret, ServiceAccountSigningKeyKey, ServiceAccountPublicKeyKey); err != nil {
+			return nil, err
+		}
+		secret.Annotations[ServiceAccountSigningKeyNotBeforeAnnotation] = now.Format(time.RFC3339)
+	}
+
+	if previousID, ok := secret.Annotations[ServiceAccountPreviousPublicKeyAnnotation]; ok {
+		retireAfter, err := annotationTime(secret, ServiceAccountPreviousPublicKeyRetireAfterAnnotation)
+		if err != nil {
+			return nil, err
+		}
+		if now.After(retireAfter) {
+			c.EventRecorder.Eventf("ServiceAccountPublicKeyRetired", "%q in %q does not trust the previous public key %s anymore, the tokens it signed have been refreshed", c.Name, c.Namespace, previousID)
+			removeAnnotation(secret, ServiceAccountPreviousPublicKeyAnnotation)
+			removeAnnotation(secret, ServiceAccountPreviousPublicKeyRetireAfterAnnotation)
+		}
+	}
+
+	notBefore, err := annotationTime(secret, ServiceAccountSigningKeyNotBeforeAnnotation)
+	if err != nil {
+		return nil, err
+	}
+	_, hasPrevious := secret.Annotations[ServiceAccountPreviousPublicKeyAnnotation]
+	_, staged := secret.Annotations[ServiceAccountSigningKeyStagedAtAnnotation]
+	// a single rotation is in progress at a time, so that the previous public key is not retired before its horizon
+	if !staged && !hasPrevious && now.After(notBefore.Add(c.Refresh)) {
+		c.EventRecorder.Eventf("ServiceAccountSigningKeyStaged", "%q in %q has a signing key older than %v, publishing the public key of the next one", c.Name, c.Namespace, c.Refresh)
+		if err := setServiceAccountSigningKey(secret, ServiceAccountNextSigningKeyKey, ServiceAccountNextPublicKeyKey); err != nil {
+			return nil, err
+		}
+		secret.Annotations[ServiceAccountSigningKeyStagedAtAnnotation] = now.Format(time.RFC3339)
+		status = &signingKeyRotationStatus{
+			progressing: true,
+			reason:      "PublicKeyStaged",
+			message: fmt.Sprintf("The public key %s of the next signing key was published at %s. Tokens are signed with it once all the API servers trust it, after %s at the earliest.",
+				publicKeyID(secret.Data[ServiceAccountNextPublicKeyKey]), secret.Annotations[ServiceAccountSigningKeyStagedAtAnnotation], now.Add(c.PublicKeyPropagation).Format(time.RFC3339)),
+		}
+	} else if staged {
+		stagedAt, err := annotationTime(secret, ServiceAccountSigningKeyStagedAtAnnotation)
+		if err != nil {
+			return nil, err
+		}
+		nextID := publicKeyID(secret.Data[ServiceAccountNextPublicKeyKey])
+		trusted, waitingFor, err := c.nextKeyTrusted(ctx, existingPublicKeys, nextID, stagedAt, now)
+		if err != nil {
+			return nil, err
+		}
+		if trusted {
+			c.EventRecorder.Eventf("ServiceAccountSigningKeyPromoted", "%q in %q signs the tokens with the key %s, trusted by all the API servers", c.Name, c.Namespace, nextID)
+			secret.Annotations[ServiceAccountPreviousPublicKeyAnnotation] = publicKeyID(secret.Data[ServiceAccountPublicKeyKey])
+			secret.Annotations[ServiceAccountPreviousPublicKeyRetireAfterAnnotation] = now.Add(c.TokenRefreshHorizon).Format(time.RFC3339)
+			secret.Annotations[ServiceAccountSigningKeyNotBeforeAnnotation] = now.Format(time.RFC3339)
+			secret.Data[ServiceAccountSigningKeyKey] = secret.Data[ServiceAccountNextSigningKeyKey]
+			secret.Data[ServiceAccountPublicKeyKey] = secret.Data[ServiceAccountNextPublicKeyKey]
+			delete(secret.Data, ServiceAccountNextSigningKeyKey)
+			delete(secret.Data, ServiceAccountNextPublicKeyKey)
+			removeAnnotation(secret, ServiceAccountSigningKeyStagedAtAnnotation)
+		} else {
+			status = &signingKeyRotationStatus{
+				progressing: true,
+				reason:      "PublicKeyStaged",
+				message: fmt.Sprintf("The public key %s of the next signing key was published at %s. Tokens are signed with it once %s.",
+					nextID, secret.Annotations[ServiceAccountSigningKeyStagedAtAnnotation], waitingFor),
+			}
+		}
+	}
+
+	if status == nil {
+		notBefore, err := annotationTime(secret, ServiceAccountSigningKeyNotBeforeAnnotation)
+		if err != nil {
+			return nil, err
+		}
+		currentID := publicKeyID(secret.Data[ServiceAccountPublicKeyKey])
+		if previousID, ok := secret.Annotations[ServiceAccountPreviousPublicKeyAnnotation]; ok {
+			status = &signingKeyRotationStatus{
+				progressing: true,
+				reason:      "PreviousPublicKeyTrusted",
+				message: fmt.Sprintf("Tokens are signed with the key %s since %s. The previous public key %s stays trusted until %s, once the tokens it signed have been refreshed.",
+					currentID, secret.Annotations[ServiceAccountSigningKeyNotBeforeAnnotation], previousID, secret.Annotations[ServiceAccountPreviousPublicKeyRetireAfterAnnotation]),
+			}
+		} else {
+			status = &signingKeyRotationStatus{
+				reason: "AsExpected",
+				message: fmt.Sprintf("Tokens are signed with the key %s since %s, which is rotated after %s.",
+					currentID, secret.Annotations[ServiceAccountSigningKeyNotBeforeAnnotation], notBefore.Add(c.Refresh).Format(time.RFC3339)),
+			}
+		}
+	}
+
+	// the public keys are published before the secret is updated, so that a key never signs before its public key is
+	// trusted, nor is the public key of a key still signing retired
+	if err := c.applyPublicKeys(ctx, existingPublicKeys, secret); err != nil {
+		return nil, err
+	}
+	if !equality.Semantic.DeepEqual(originalSecret, secret) {
+		if _, _, err := resourceapply.ApplySecret(ctx, c.Client, c.EventRecorder, secret); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// nextKeyTrusted returns whether the API servers trust the public key of the next signing key, or what it waits for.
+func (c RotatedServiceAccountSigningKey) nextKeyTrusted(ctx context.Context, publicKeys *corev1.ConfigMap, nextID string, stagedAt, now time.Time) (bool, string, error) {
+	if publicKeys == nil || len(publicKeys.Data[nextID+".pub"]) == 0 {
+		return false, fmt.Sprintf("it is in the config map %q", c.PublicKeysName), nil
+	}
+	if trustedAt := stagedAt.Add(c.PublicKeyPropagation); now.Before(trustedAt) {
+		return false, fmt.Sprintf("it has been propagated to all the API servers, after %s at the earliest", trustedAt.Format(time.RFC3339)), nil
+	}
+	if c.DistributedFn == nil {
+		return true, "", nil
+	}
+	distributed, reason, err := c.DistributedFn(ctx, publicKeys)
+	if err != nil || distributed {
+		return distributed, "", err
+	}
+	return false, fmt.Sprintf("all the API servers trust it: %s", reason), nil
+}
+
+// applyPublicKeys updates the config map to hold the public keys of the current, next and previous signing keys of the
+// secret, by their ID.
+func (c RotatedServiceAccountSigningKey) applyPublicKeys(ctx context.Context, existing *corev1.ConfigMap, secret *corev1.Secret) error {
+	data := map[string]string{}
+	for _, key := range []string{ServiceAccountPublicKeyKey, ServiceAccountNextPublicKeyKey} {
+		if publicKey := secret.Data[key]; len(publicKey) > 0 {
+			data[publicKeyID(publicKey)+".pub"] = string(publicKey)
+		}
+	}
+	if previousID, ok := secret.Annotations[ServiceAccountPreviousPublicKeyAnnotation]; ok {
+		if existing == nil || len(existing.Data[previousID+".pub"]) == 0 {
+			return fmt.Errorf("the previous public key %s is missing from the config map %q in %q", previousID, c.PublicKeysName, c.Namespace)
+		}
+		data[previousID+".pub"] = existing.Data[previousID+".pub"]
+	}
+
+	publicKeys := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: c.Namespace, Name: c.PublicKeysName}}
+	if existing != nil {
+		publicKeys = existing.DeepCopy()
+	}
+	LabelAsManagedConfigMap(publicKeys, CertificateTypeCABundle)
+	if existing != nil && equality.Semantic.DeepEqual(existing.Data, data) && equality.Semantic.DeepEqual(existing.Labels, publicKeys.Labels) {
+		return nil
+	}
+	publicKeys.Data = data
+	_, _, err := resourceapply.ApplyConfigMap(ctx, c.ConfigMapClient, c.EventRecorder, publicKeys)
+	return err
+}
+
+// setServiceAccountSigningKey generates a signing key and stores it and its public key in PEM format under the keys.
+func setServiceAccountSigningKey(secret *corev1.Secret, privateKeyKey, publicKeyKey string) error {
+	privateKey, err := rsa.GenerateKey(rand.Reader, serviceAccountSigningKeyBits)
+	if err != nil {
+		return err
+	}
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return err
+	}
+	secret.Data[privateKeyKey] = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+	secret.Data[publicKeyKey] = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes})
+	return nil
+}
+
+// publicKeyID identifies a public key by the beginning of the hash of its PEM encoding.
+func publicKeyID(publicKey []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(publicKey))[:16]
+}
+
+// removeAnnotation removes the annotation from the secret, and from the existing secret when it is applied.
+func removeAnnotation(secret *corev1.Secret, annotation string) {
+	delete(secret.Annotations, annotation)
+	secret.Annotations[annotation+"-"] = ""
+}
+
+func annotationTime(secret *corev1.Secret, annotation string) (time.Time, error) {
+	value, ok := secret.Annotations[annotation]
+	if !ok {
+		return time.Time{}, fmt.Errorf("%q in %q is missing the %s annotation", secret.Name, secret.Namespace, annotation)
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q in %q has an invalid %s annotation: %v", secret.Name, secret.Namespace, annotation, err)
+	}
+	return t, nil
+}
+
+// ServiceAccountSigningKeyRotationController rotates the service account signing key, see RotatedServiceAccountSigningKey,
+// and narrates the rotation in its Progressing condition.
+type ServiceAccountSigningKeyRotationController struct {
+	// name is used in operator conditions to identify this controller, compare ServiceAccountSigningKeyRotationProgressingConditionTypeFmt.
+	name string
+
+	signingKey RotatedServiceAccountSigningKey
+
+	// Plumbing:
+	operatorClient v1helpers.OperatorClient
+
+	clock clock.PassiveClock
+}
+
+func NewServiceAccountSigningKeyRotationController(
+	name string,
+	signingKey RotatedServiceAccountSigningKey,
+	operatorClient v1helpers.OperatorClient,
+	recorder events.Recorder,
+	opts ...Option,
+) factory.Controller {
+	c := &ServiceAccountSigningKeyRotationController{
+		name:           name,
+		signingKey:     signingKey,
+		operatorClient: operatorClient,
+		clock:          newOptions(opts...).clock,
+	}
+	return factory.New().
+		ResyncEvery(time.Minute).
+		WithSync(c.Sync).
+		WithInformers(
+			signingKey.Informer.Informer(),
+			signingKey.ConfigMapInformer.Informer(),
+		).
+		ToController("ServiceAccountSigningKeyRotationController", recorder.WithComponentSuffix("service-account-signing-key-rotation-controller"))
+}
+
+func (c ServiceAccountSigningKeyRotationController) Sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	status, syncErr := c.signingKey.ensureServiceAccountSigningKey(ctx, c.clock.Now())
+
+	degraded := operatorv1.OperatorCondition{
+		Type:   fmt.Sprintf(condition.ServiceAccountSigningKeyRotationDegradedConditionTypeFmt, c.name),
+		Status: operatorv1.ConditionFalse,
+	}
+	updateFuncs := []v1helpers.UpdateStatusFunc{}
+	if syncErr != nil {
+		degraded.Status = operatorv1.ConditionTrue
+		degraded.Reason = "RotationError"
+		degraded.Message = syncErr.Error()
+	} else {
+		// the rotation step is only known after a successful sync
+		progressing := operatorv1.OperatorCondition{
+			Type:    fmt.Sprintf(condition.ServiceAccountSigningKeyRotationProgressingConditionTypeFmt, c.name),
+			Status:  operatorv1.ConditionFalse,
+			Reason:  status.reason,
+			Message: status.message,
+		}
+		if status.progressing {
+			progressing.Status = operatorv1.ConditionTrue
+		}
+		updateFuncs = append(updateFuncs, v1helpers.UpdateConditionFn(progressing))
+	}
+	updateFuncs = append(updateFuncs, v1helpers.UpdateConditionFn(degraded))
+
+	_, updated, updateErr := v1helpers.UpdateStatus(ctx, c.operatorClient, updateFuncs...)
+	if updateErr != nil {
+		return updateErr
+	}
+	if updated && syncErr != nil {
+		syncCtx.Recorder().Warningf("RotationError", degraded.Message)
+	}
+	return syncErr
+}
@@ -0,0 +1,198 @@
+package certrotation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestServiceAccountSigningKeyRotation(t *testing.T) {
+	start := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	client := kubefake.NewSimpleClientset()
+	distributed := false
+
+	// sync runs the rotation at the given time against the current state of the objects
+	sync := func(at time.Time) (*signingKeyRotationStatus, *corev1.Secret, *corev1.ConfigMap) {
+		secretIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		configMapIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		if secret, err := client.CoreV1().Secrets("ns").Get(context.TODO(), "signing-key", metav1.GetOptions{}); err == nil {
+			secretIndexer.Add(secret)
+		}
+		if configMap, err := client.CoreV1().ConfigMaps("ns").Get(context.TODO(), "public-keys", metav1.GetOptions{}); err == nil {
+			configMapIndexer.Add(configMap)
+		}
+
+		c := RotatedServiceAccountSigningKey{
+			Namespace:            "ns",
+			Name:                 "signing-key",
+			PublicKeysName:       "public-keys",
+			Refresh:              30 * 24 * time.Hour,
+			PublicKeyPropagation: time.Hour,
+			TokenRefreshHorizon:  24 * time.Hour,
+			DistributedFn: func(context.Context, *corev1.ConfigMap) (bool, string, error) {
+				if !distributed {
+					return false, "1 API server runs an older revision", nil
+				}
+				return true, "", nil
+			},
+			Lister:          corev1listers.NewSecretLister(secretIndexer),
+			Client:          client.CoreV1(),
+			ConfigMapLister: corev1listers.NewConfigMapLister(configMapIndexer),
+			ConfigMapClient: client.CoreV1(),
+			EventRecorder:   events.NewInMemoryRecorder("test"),
+		}
+		status, err := c.ensureServiceAccountSigningKey(context.TODO(), at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		secret, err := client.CoreV1().Secrets("ns").Get(context.TODO(), "signing-key", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		configMap, err := client.CoreV1().ConfigMaps("ns").Get(context.TODO(), "public-keys", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status, secret, configMap
+	}
+	expectPublicKeys := func(t *testing.T, configMap *corev1.ConfigMap, publicKeys ...[]byte) {
+		t.Helper()
+		if len(configMap.Data) != len(publicKeys) {
+			t.Errorf("expected %d public keys, got %d", len(publicKeys), len(configMap.Data))
+		}
+		for _, publicKey := range publicKeys {
+			if configMap.Data[publicKeyID(publicKey)+".pub"] != string(publicKey) {
+				t.Errorf("expected the public key %s to be trusted", publicKeyID(publicKey))
+			}
+		}
+	}
+	expectStatus := func(t *testing.T, status *signingKeyRotationStatus, progressing bool, reason string) {
+		t.Helper()
+		if status.progressing != progressing || status.reason != reason {
+			t.Errorf("expected progressing %v with reason %s, got %v with reason %s: %s", progressing, reason, status.progressing, status.reason, status.message)
+		}
+	}
+
+	status, secret, publicKeys := sync(start)
+	expectStatus(t, status, false, "AsExpected")
+	firstKey, firstPublicKey := secret.Data[ServiceAccountSigningKeyKey], secret.Data[ServiceAccountPublicKeyKey]
+	if len(firstKey) == 0 || len(firstPublicKey) == 0 {
+		t.Fatalf("expected the signing key to be created, got %v", secret.Data)
+	}
+	expectPublicKeys(t, publicKeys, firstPublicKey)
+
+	status, secret, publicKeys = sync(start.Add(24 * time.Hour))
+	expectStatus(t, status, false, "AsExpected")
+	if string(secret.Data[ServiceAccountSigningKeyKey]) != string(firstKey) {
+		t.Errorf("expected the signing key to be kept before its refresh")
+	}
+
+	stagedAt := start.Add(31 * 24 * time.Hour)
+	status, secret, publicKeys = sync(stagedAt)
+	expectStatus(t, status, true, "PublicKeyStaged")
+	nextPublicKey := secret.Data[ServiceAccountNextPublicKeyKey]
+	if string(secret.Data[ServiceAccountSigningKeyKey]) != string(firstKey) || len(nextPublicKey) == 0 {
+		t.Fatalf("expected the next signing key to be staged while the first one signs")
+	}
+	expectPublicKeys(t, publicKeys, firstPublicKey, nextPublicKey)
+
+	status, secret, _ = sync(stagedAt.Add(30 * time.Minute))
+	expectStatus(t, status, true, "PublicKeyStaged")
+	if string(secret.Data[ServiceAccountSigningKeyKey]) != string(firstKey) {
+		t.Errorf("expected the first key to sign during the propagation of the next public key")
+	}
+
+	status, secret, _ = sync(stagedAt.Add(2 * time.Hour))
+	expectStatus(t, status, true, "PublicKeyStaged")
+	if expected := "1 API server runs an older revision"; !strings.Contains(status.message, expected) {
+		t.Errorf("expected the message to contain %q, got %q", expected, status.message)
+	}
+	if string(secret.Data[ServiceAccountSigningKeyKey]) != string(firstKey) {
+		t.Errorf("expected the first key to sign until the next public key is distributed")
+	}
+
+	distributed = true
+	promotedAt := stagedAt.Add(3 * time.Hour)
+	status, secret, publicKeys = sync(promotedAt)
+	expectStatus(t, status, true, "PreviousPublicKeyTrusted")
+	if string(secret.Data[ServiceAccountPublicKeyKey]) != string(nextPublicKey) || len(secret.Data[ServiceAccountNextSigningKeyKey]) != 0 {
+		t.Fatalf("expected the next key to sign once distributed")
+	}
+	expectPublicKeys(t, publicKeys, firstPublicKey, nextPublicKey)
+
+	status, _, publicKeys = sync(promotedAt.Add(23 * time.Hour))
+	expectStatus(t, status, true, "PreviousPublicKeyTrusted")
+	expectPublicKeys(t, publicKeys, firstPublicKey, nextPublicKey)
+
+	status, secret, publicKeys = sync(promotedAt.Add(25 * time.Hour))
+	expectStatus(t, status, false, "AsExpected")
+	if _, ok := secret.Annotations[ServiceAccountPreviousPublicKeyAnnotation]; ok {
+		t.Errorf("expected the previous public key to be retired, got %v", secret.Annotations)
+	}
+	expectPublicKeys(t, publicKeys, nextPublicKey)
+}
+
+func TestServiceAccountSigningKeyRotationErrors(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		annotations   map[string]string
+		expectedError string
+	}{
+		{
+			name:          "missing not before",
+			annotations:   map[string]string{},
+			expectedError: fmt.Sprintf(`"signing-key" in "ns" is missing the %s annotation`, ServiceAccountSigningKeyNotBeforeAnnotation),
+		},
+		{
+			name: "previous public key missing",
+			annotations: map[string]string{
+				ServiceAccountSigningKeyNotBeforeAnnotation:          now.Format(time.RFC3339),
+				ServiceAccountPreviousPublicKeyAnnotation:            "0123456789abcdef",
+				ServiceAccountPreviousPublicKeyRetireAfterAnnotation: now.Add(time.Hour).Format(time.RFC3339),
+			},
+			expectedError: `the previous public key 0123456789abcdef is missing from the config map "public-keys" in "ns"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "signing-key", Annotations: test.annotations},
+				Data:       map[string][]byte{},
+			}
+			if err := setServiceAccountSigningKey(secret, ServiceAccountSigningKeyKey, ServiceAccountPublicKeyKey); err != nil {
+				t.Fatal(err)
+			}
+			secretIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			secretIndexer.Add(secret)
+			configMapIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			client := kubefake.NewSimpleClientset(secret)
+
+			c := RotatedServiceAccountSigningKey{
+				Namespace:       "ns",
+				Name:            "signing-key",
+				PublicKeysName:  "public-keys",
+				Refresh:         time.Hour,
+				Lister:          corev1listers.NewSecretLister(secretIndexer),
+				Client:          client.CoreV1(),
+				ConfigMapLister: corev1listers.NewConfigMapLister(configMapIndexer),
+				ConfigMapClient: client.CoreV1(),
+				EventRecorder:   events.NewInMemoryRecorder("test"),
+			}
+			_, err := c.ensureServiceAccountSigningKey(context.TODO(), now)
+			if err == nil || err.Error() != test.expectedError {
+				t.Errorf("expected error %q, got %v", test.expectedError, err)
+			}
+		})
+	}
+}
@@ -14,6 +14,8 @@ import (
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
 
 	"github.com/openshift/library-go/pkg/operator/events"
 )
@@ -23,6 +25,7 @@ func TestEnsureSigningCertKeyPair(t *testing.T) {
 		name string
 
 		initialSecret *corev1.Secret
+		clock         clock.PassiveClock
 
 		verifyActions func(t *testing.T, client *kubefake.Clientset)
 		expectedError string
@@ -96,6 +99,29 @@ func TestEnsureSigningCertKeyPair(t *testing.T) {
 			},
 			expectedError: "certFile missing", // this means we tried to read the cert from the existing secret.  If we created one, we fail in the client check
 		},
+		{
+			name: "update past refresh time of the clock",
+			initialSecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "signer",
+					Annotations: map[string]string{
+						"auth.openshift.io/certificate-not-after":  "2108-09-10T20:47:31-07:00",
+						"auth.openshift.io/certificate-not-before": "2108-09-08T20:47:31-07:00",
+					}},
+				Type: corev1.SecretTypeTLS,
+			},
+			clock: clocktesting.NewFakePassiveClock(time.Date(2108, 9, 10, 0, 0, 0, 0, time.UTC)),
+			verifyActions: func(t *testing.T, client *kubefake.Clientset) {
+				t.Helper()
+				actions := client.Actions()
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+
+				if !actions[1].Matches("update", "secrets") {
+					t.Error(actions[1])
+				}
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -118,7 +144,11 @@ func TestEnsureSigningCertKeyPair(t *testing.T) {
 				EventRecorder: events.NewInMemoryRecorder("test"),
 			}
 
-			_, err := c.ensureSigningCertKeyPair(context.TODO())
+			now := time.Now()
+			if test.clock != nil {
+				now = test.clock.Now()
+			}
+			_, err := c.ensureSigningCertKeyPair(context.TODO(), now)
 			switch {
 			case err != nil && len(test.expectedError) == 0:
 				t.Error(err)
@@ -12,6 +12,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/utils/clock"
 
 	"github.com/openshift/library-go/pkg/certs"
 	"github.com/openshift/library-go/pkg/crypto"
@@ -53,6 +54,9 @@ type RotatedSelfSignedCertKeySecret struct {
 	// CertCreator does the actual cert generation.
 	CertCreator TargetCertCreator
 
+	// IssuanceLog, when set, records every certificate signed.
+	IssuanceLog *CertificateIssuanceLog
+
 	// Plumbing:
 	Informer      corev1informers.SecretInformer
 	Lister        corev1listers.SecretLister
@@ -69,13 +73,21 @@ type TargetCertCreator interface {
 	SetAnnotations(cert *crypto.TLSCertificateConfig, annotations map[string]string) map[string]string
 }
 
+// nowFrom returns the time of the clock, the real time when it is nil.
+func nowFrom(c clock.PassiveClock) time.Time {
+	if c == nil {
+		return time.Now()
+	}
+	return c.Now()
+}
+
 // TargetCertRechecker is an optional interface to be implemented by the TargetCertCreator to enforce
 // a controller run.
 type TargetCertRechecker interface {
 	RecheckChannel() <-chan struct{}
 }
 
-func (c RotatedSelfSignedCertKeySecret) ensureTargetCertKeyPair(ctx context.Context, signingCertKeyPair *crypto.CA, caBundleCerts []*x509.Certificate) error {
+func (c RotatedSelfSignedCertKeySecret) ensureTargetCertKeyPair(ctx context.Context, signingCertKeyPair *crypto.CA, caBundleCerts []*x509.Certificate, now time.Time) error {
 	// at this point our trust bundle has been updated.  We don't know for sure that consumers have updated, but that's why we have a second
 	// validity percentage.  We always check to see if we need to sign.  Often we are signing with an old key or we have no target
 	// and need to mint one
@@ -91,14 +103,22 @@ func (c RotatedSelfSignedCertKeySecret) ensureTargetCertKeyPair(ctx context.Cont
 	}
 	targetCertKeyPairSecret.Type = corev1.SecretTypeTLS
 
-	if reason := needNewTargetCertKeyPair(targetCertKeyPairSecret.Annotations, signingCertKeyPair, caBundleCerts, c.Refresh, c.RefreshOnlyWhenExpired); len(reason) > 0 {
+	if reason := needNewTargetCertKeyPair(targetCertKeyPairSecret.Annotations, signingCertKeyPair, caBundleCerts, c.Refresh, c.RefreshOnlyWhenExpired, now); len(reason) > 0 {
 		c.EventRecorder.Eventf("TargetUpdateRequired", "%q in %q requires a new target cert/key pair: %v", c.Name, c.Namespace, reason)
-		if err := setTargetCertKeyPairSecret(targetCertKeyPairSecret, c.Validity, signingCertKeyPair, c.CertCreator); err != nil {
+		if err := setTargetCertKeyPairSecret(targetCertKeyPairSecret, c.Validity, signingCertKeyPair, c.CertCreator, now); err != nil {
 			return err
 		}
 
 		LabelAsManagedSecret(targetCertKeyPairSecret, CertificateTypeTarget)
 
+		// the certificate is recorded before it is used, so that no issued certificate is missing from the log.
+		// A failure must not block the rotation, the certificates would expire.
+		if c.IssuanceLog != nil {
+			if err := c.IssuanceLog.recordSecret(ctx, targetCertKeyPairSecret, signingCertKeyPair.Config.Certs[0], now); err != nil {
+				c.EventRecorder.Warningf("CertificateIssuanceLogFailed", "Failed to record %q in %q in the issuance log: %v", c.Name, c.Namespace, err)
+			}
+		}
+
 		actualTargetCertKeyPairSecret, _, err := resourceapply.ApplySecret(ctx, c.Client, c.EventRecorder, targetCertKeyPairSecret)
 		if err != nil {
 			return err
@@ -109,8 +129,8 @@ func (c RotatedSelfSignedCertKeySecret) ensureTargetCertKeyPair(ctx context.Cont
 	return nil
 }
 
-func needNewTargetCertKeyPair(annotations map[string]string, signer *crypto.CA, caBundleCerts []*x509.Certificate, refresh time.Duration, refreshOnlyWhenExpired bool) string {
-	if reason := needNewTargetCertKeyPairForTime(annotations, signer, refresh, refreshOnlyWhenExpired); len(reason) > 0 {
+func needNewTargetCertKeyPair(annotations map[string]string, signer *crypto.CA, caBundleCerts []*x509.Certificate, refresh time.Duration, refreshOnlyWhenExpired bool, now time.Time) string {
+	if reason := needNewTargetCertKeyPairForTime(annotations, signer, refresh, refreshOnlyWhenExpired, now); len(reason) > 0 {
 		return reason
 	}
 
@@ -147,14 +167,14 @@ func needNewTargetCertKeyPair(annotations map[string]string, signer *crypto.CA,
 // Hence, if the CAs are rotated too fast (like CA percentage around 10% or smaller), we will not hit the time to make use of the CA. Or if the cert renewal percentage is at 90%, there is not much time either.
 //
 // So with a cert percentage of 75% and equally long CA and cert validities at the worst case we start at 85% of the cert to renew, trying again every minute.
-func needNewTargetCertKeyPairForTime(annotations map[string]string, signer *crypto.CA, refresh time.Duration, refreshOnlyWhenExpired bool) string {
+func needNewTargetCertKeyPairForTime(annotations map[string]string, signer *crypto.CA, refresh time.Duration, refreshOnlyWhenExpired bool, now time.Time) string {
 	notBefore, notAfter, reason := getValidityFromAnnotations(annotations)
 	if len(reason) > 0 {
 		return reason
 	}
 
 	// Is cert expired?
-	if time.Now().After(notAfter) {
+	if now.After(notAfter) {
 		return "already expired"
 	}
 
@@ -165,16 +185,16 @@ func needNewTargetCertKeyPairForTime(annotations map[string]string, signer *cryp
 	// Are we at 80% of validity?
 	validity := notAfter.Sub(notBefore)
 	at80Percent := notAfter.Add(-validity / 5)
-	if time.Now().After(at80Percent) {
+	if now.After(at80Percent) {
 		return fmt.Sprintf("past its latest possible time %v", at80Percent)
 	}
 
 	// If Certificate is past its refresh time, we may have action to take. We only do this if the signer is old enough.
 	refreshTime := notBefore.Add(refresh)
-	if time.Now().After(refreshTime) {
+	if now.After(refreshTime) {
 		// make sure the signer has been valid for more than 10% of the target's refresh time.
 		timeToWaitForTrustRotation := refresh / 10
-		if time.Now().After(signer.Config.Certs[0].NotBefore.Add(time.Duration(timeToWaitForTrustRotation))) {
+		if now.After(signer.Config.Certs[0].NotBefore.Add(time.Duration(timeToWaitForTrustRotation))) {
 			return fmt.Sprintf("past its refresh time %v", refreshTime)
 		}
 	}
@@ -184,7 +204,7 @@ func needNewTargetCertKeyPairForTime(annotations map[string]string, signer *cryp
 
 // setTargetCertKeyPairSecret creates a new cert/key pair and sets them in the secret.  Only one of client, serving, or signer rotation may be specified.
 // TODO refactor with an interface for actually signing and move the one-of check higher in the stack.
-func setTargetCertKeyPairSecret(targetCertKeyPairSecret *corev1.Secret, validity time.Duration, signer *crypto.CA, certCreator TargetCertCreator) error {
+func setTargetCertKeyPairSecret(targetCertKeyPairSecret *corev1.Secret, validity time.Duration, signer *crypto.CA, certCreator TargetCertCreator, now time.Time) error {
 	if targetCertKeyPairSecret.Annotations == nil {
 		targetCertKeyPairSecret.Annotations = map[string]string{}
 	}
@@ -194,7 +214,7 @@ func setTargetCertKeyPairSecret(targetCertKeyPairSecret *corev1.Secret, validity
 
 	// our annotation is based on our cert validity, so we want to make sure that we don't specify something past our signer
 	targetValidity := validity
-	remainingSignerValidity := signer.Config.Certs[0].NotAfter.Sub(time.Now())
+	remainingSignerValidity := signer.Config.Certs[0].NotAfter.Sub(now)
 	if remainingSignerValidity < validity {
 		targetValidity = remainingSignerValidity
 	}
@@ -218,6 +238,10 @@ func setTargetCertKeyPairSecret(targetCertKeyPairSecret *corev1.Secret, validity
 
 type ClientRotation struct {
 	UserInfo user.Info
+
+	// Clock is the time source of NeedNewTargetCertKeyPair, the real clock when nil. The CertRotationController
+	// decides with its own clock, set with WithClock.
+	Clock clock.PassiveClock
 }
 
 func (r *ClientRotation) NewCertificate(signer *crypto.CA, validity time.Duration) (*crypto.TLSCertificateConfig, error) {
@@ -225,7 +249,7 @@ func (r *ClientRotation) NewCertificate(signer *crypto.CA, validity time.Duratio
 }
 
 func (r *ClientRotation) NeedNewTargetCertKeyPair(annotations map[string]string, signer *crypto.CA, caBundleCerts []*x509.Certificate, refresh time.Duration, refreshOnlyWhenExpired bool) string {
-	return needNewTargetCertKeyPair(annotations, signer, caBundleCerts, refresh, refreshOnlyWhenExpired)
+	return needNewTargetCertKeyPair(annotations, signer, caBundleCerts, refresh, refreshOnlyWhenExpired, nowFrom(r.Clock))
 }
 
 func (r *ClientRotation) SetAnnotations(cert *crypto.TLSCertificateConfig, annotations map[string]string) map[string]string {
@@ -236,6 +260,10 @@ type ServingRotation struct {
 	Hostnames              ServingHostnameFunc
 	CertificateExtensionFn []crypto.CertificateExtensionFunc
 	HostnamesChanged       <-chan struct{}
+
+	// Clock is the time source of NeedNewTargetCertKeyPair, the real clock when nil. The CertRotationController
+	// decides with its own clock, set with WithClock.
+	Clock clock.PassiveClock
 }
 
 func (r *ServingRotation) NewCertificate(signer *crypto.CA, validity time.Duration) (*crypto.TLSCertificateConfig, error) {
@@ -250,7 +278,7 @@ func (r *ServingRotation) RecheckChannel() <-chan struct{} {
 }
 
 func (r *ServingRotation) NeedNewTargetCertKeyPair(annotations map[string]string, signer *crypto.CA, caBundleCerts []*x509.Certificate, refresh time.Duration, refreshOnlyWhenExpired bool) string {
-	reason := needNewTargetCertKeyPair(annotations, signer, caBundleCerts, refresh, refreshOnlyWhenExpired)
+	reason := needNewTargetCertKeyPair(annotations, signer, caBundleCerts, refresh, refreshOnlyWhenExpired, nowFrom(r.Clock))
 	if len(reason) > 0 {
 		return reason
 	}
@@ -288,15 +316,19 @@ type ServingHostnameFunc func() []string
 
 type SignerRotation struct {
 	SignerName string
+
+	// Clock is the time source of NeedNewTargetCertKeyPair and of the suffix of the signer names, the real clock when nil. The CertRotationController
+	// decides with its own clock, set with WithClock.
+	Clock clock.PassiveClock
 }
 
 func (r *SignerRotation) NewCertificate(signer *crypto.CA, validity time.Duration) (*crypto.TLSCertificateConfig, error) {
-	signerName := fmt.Sprintf("%s_@%d", r.SignerName, time.Now().Unix())
+	signerName := fmt.Sprintf("%s_@%d", r.SignerName, nowFrom(r.Clock).Unix())
 	return crypto.MakeCAConfigForDuration(signerName, validity, signer)
 }
 
 func (r *SignerRotation) NeedNewTargetCertKeyPair(annotations map[string]string, signer *crypto.CA, caBundleCerts []*x509.Certificate, refresh time.Duration, refreshOnlyWhenExpired bool) string {
-	return needNewTargetCertKeyPair(annotations, signer, caBundleCerts, refresh, refreshOnlyWhenExpired)
+	return needNewTargetCertKeyPair(annotations, signer, caBundleCerts, refresh, refreshOnlyWhenExpired, nowFrom(r.Clock))
 }
 
 func (r *SignerRotation) SetAnnotations(cert *crypto.TLSCertificateConfig, annotations map[string]string) map[string]string {
@@ -18,6 +18,7 @@ import (
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
 )
 
 func TestNeedNewTargetCertKeyPairForTime(t *testing.T) {
@@ -123,7 +124,7 @@ func TestNeedNewTargetCertKeyPairForTime(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			actual := needNewTargetCertKeyPairForTime(test.annotations, signer, test.refresh, test.refreshOnlyWhenExpired)
+			actual := needNewTargetCertKeyPairForTime(test.annotations, signer, test.refresh, test.refreshOnlyWhenExpired, now)
 			if !strings.HasPrefix(actual, test.expected) {
 				t.Errorf("expected %v, got %v", test.expected, actual)
 			}
@@ -131,6 +132,28 @@ func TestNeedNewTargetCertKeyPairForTime(t *testing.T) {
 	}
 }
 
+func TestRotationClock(t *testing.T) {
+	now := time.Now()
+	signer, err := newTestCACertificate(pkix.Name{CommonName: "signer-tests"}, int64(1), metav1.Duration{Duration: 200 * time.Minute}, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations := map[string]string{
+		CertificateNotAfterAnnotation:  now.Add(time.Hour).Format(time.RFC3339),
+		CertificateNotBeforeAnnotation: now.Add(-time.Hour).Format(time.RFC3339),
+		CertificateIssuer:              signer.Config.Certs[0].Subject.CommonName,
+	}
+
+	rotation := &ClientRotation{}
+	if reason := rotation.NeedNewTargetCertKeyPair(annotations, signer, signer.Config.Certs, 0, true); len(reason) > 0 {
+		t.Errorf("expected no new certificate at the real time, got %q", reason)
+	}
+	rotation.Clock = clocktesting.NewFakePassiveClock(now.Add(2 * time.Hour))
+	if reason := rotation.NeedNewTargetCertKeyPair(annotations, signer, signer.Config.Certs, 0, true); !strings.HasPrefix(reason, "already expired") {
+		t.Errorf("expected a new certificate at the time of the clock, got %q", reason)
+	}
+}
+
 func TestEnsureTargetCertKeyPair(t *testing.T) {
 	tests := []struct {
 		name string
@@ -229,7 +252,7 @@ func TestEnsureTargetCertKeyPair(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			err = c.ensureTargetCertKeyPair(context.TODO(), newCA, newCA.Config.Certs)
+			err = c.ensureTargetCertKeyPair(context.TODO(), newCA, newCA.Config.Certs, time.Now())
 			switch {
 			case err != nil && len(test.expectedError) == 0:
 				t.Error(err)
@@ -423,7 +446,7 @@ func TestEnsureTargetSignerCertKeyPair(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			err = c.ensureTargetCertKeyPair(context.TODO(), newCA, newCA.Config.Certs)
+			err = c.ensureTargetCertKeyPair(context.TODO(), newCA, newCA.Config.Certs, time.Now())
 			switch {
 			case err != nil && len(test.expectedError) == 0:
 				t.Error(err)
@@ -47,6 +47,15 @@ const (
 	// validity can expire and without rotating/renewing them manual recovery might be required to fix the cluster.
 	CertRotationDegradedConditionTypeFmt = "CertRotation_%s_Degraded"
 
+	// ServiceAccountSigningKeyRotationDegradedConditionTypeFmt is true when the operator failed to rotate the service account signing key.
+	// The RotationError reason is given with message describing details of this failure.
+	ServiceAccountSigningKeyRotationDegradedConditionTypeFmt = "ServiceAccountSigningKeyRotation_%s_Degraded"
+
+	// ServiceAccountSigningKeyRotationProgressingConditionTypeFmt is true while a rotation of the service account signing key is in progress.
+	// The reason names the current step (PublicKeyStaged, PreviousPublicKeyTrusted) and the message explains what the rotation waits for
+	// before taking the next step safely.
+	ServiceAccountSigningKeyRotationProgressingConditionTypeFmt = "ServiceAccountSigningKeyRotation_%s_Progressing"
+
 	// InstallerControllerDegradedConditionType is true when the operator is not able to create new installer pods so the new revisions
 	// cannot be rolled out. This might happen when one or more required secrets or config maps does not exists.
 	// In case the missing secret or config map is available, this condition is automatically set to false.
@@ -66,7 +75,16 @@ const (
 	// This condition mean no new revision will be created.
 	RevisionControllerDegradedConditionType = "RevisionControllerDegraded"
 
+	// OperandNamespaceTerminationDegradedConditionType is true when an operand namespace has been terminating for too long.
+	// The message names the finalizers and the resources blocking the deletion of the namespace.
+	OperandNamespaceTerminationDegradedConditionType = "OperandNamespaceTerminationDegraded"
+
 	// NodeControllerDegradedConditionType is true when the operator observed a master node that is not ready.
 	// Note that a node is not ready when its Condition.NodeReady wasn't set to true
 	NodeControllerDegradedConditionType = "NodeControllerDegraded"
+
+	// NodeControllerProgressingConditionType is true while master nodes added to replace or scale up the control
+	// plane have not reached a revision yet. The Replacing reason is set when master nodes were removed since the
+	// control plane was last complete.
+	NodeControllerProgressingConditionType = "NodeControllerProgressing"
 )
@@ -0,0 +1,200 @@
+package authentication
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+const (
+	// AuthenticationTypeOIDC is the authentication type of the clusters authenticating the users with external OIDC
+	// providers. The vendored API does not have it yet, which is why the Authentication resource is read unstructured.
+	AuthenticationTypeOIDC = "OIDC"
+
+	// issuerCANamespace is the namespace of the config maps holding the CA of the OIDC issuers.
+	issuerCANamespace = "openshift-config"
+	// issuerCAKey is the key of the CA bundle in the config maps holding the CA of the OIDC issuers.
+	issuerCAKey = "ca-bundle.crt"
+)
+
+var authenticationsResource = configv1.GroupVersion.WithResource("authentications")
+
+// OIDCListers lists the Authentication resource, e.g. with configobserver.DynamicListers, and the config maps of the
+// openshift-config namespace.
+type OIDCListers interface {
+	configobserver.GenericListers
+	ConfigMapLister() corev1listers.ConfigMapLister
+}
+
+// NewObserveExternalOIDCFunc returns an observer writing at configPath the JWT authenticators of the structured
+// authentication configuration of the operand (the jwt list of an apiserver.config.k8s.io AuthenticationConfiguration)
+// for the OIDC providers of the cluster Authentication resource, i.e. their issuer URL, audiences, CA and claim mappings.
+// The path is removed from the observed config unless the authentication type is OIDC. When the configuration cannot be
+// read, the existing value is kept and the error is returned.
+func NewObserveExternalOIDCFunc(configPath ...string) configobserver.ObserveConfigFunc {
+	return func(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+		defer func() {
+			ret = configobserver.Pruned(ret, configPath)
+		}()
+
+		listers, ok := genericListers.(OIDCListers)
+		if !ok {
+			return existingConfig, append(errs, fmt.Errorf("failed to assert: given lister does not implement OIDC listers"))
+		}
+
+		observed, err := observeJWTAuthenticators(listers)
+		if err != nil {
+			return existingConfig, append(errs, err)
+		}
+
+		observedConfig := map[string]interface{}{}
+		if observed != nil {
+			if err := unstructured.SetNestedField(observedConfig, observed, configPath...); err != nil {
+				return existingConfig, append(errs, err)
+			}
+		}
+
+		existing, _, _ := unstructured.NestedFieldNoCopy(existingConfig, configPath...)
+		if !equality.Semantic.DeepEqual(existing, observed) {
+			recorder.Eventf("ObserveExternalOIDC", "%s changed to the JWT authenticators of %d OIDC providers", strings.Join(configPath, "."), len(observed))
+		}
+		return observedConfig, errs
+	}
+}
+
+// observeJWTAuthenticators returns the JWT authenticators of the OIDC providers, or nil when the authentication type is
+// not OIDC.
+func observeJWTAuthenticators(listers OIDCListers) ([]interface{}, error) {
+	lister := listers.GenericLister(authenticationsResource)
+	if lister == nil {
+		return nil, fmt.Errorf("no lister of %s", authenticationsResource)
+	}
+	obj, err := lister.Get("cluster")
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var authentication map[string]interface{}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		authentication = u.UnstructuredContent()
+	} else if authentication, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+		return nil, err
+	}
+
+	if authenticationType, _, _ := unstructured.NestedString(authentication, "spec", "type"); authenticationType != AuthenticationTypeOIDC {
+		return nil, nil
+	}
+	providers, _, err := unstructured.NestedSlice(authentication, "spec", "oidcProviders")
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec.oidcProviders of authentications.config.openshift.io/cluster: %w", err)
+	}
+
+	authenticators := []interface{}{}
+	for i, p := range providers {
+		provider, ok := p.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid spec.oidcProviders[%d] of authentications.config.openshift.io/cluster", i)
+		}
+		authenticator, err := jwtAuthenticator(listers, provider)
+		if err != nil {
+			name, _, _ := unstructured.NestedString(provider, "name")
+			return nil, fmt.Errorf("invalid OIDC provider %q of authentications.config.openshift.io/cluster: %w", name, err)
+		}
+		authenticators = append(authenticators, authenticator)
+	}
+	return authenticators, nil
+}
+
+// jwtAuthenticator converts the OIDC provider into the JWT authenticator of the operand.
+func jwtAuthenticator(listers OIDCListers, provider map[string]interface{}) (map[string]interface{}, error) {
+	issuerURL, _, _ := unstructured.NestedString(provider, "issuer", "issuerURL")
+	if len(issuerURL) == 0 {
+		return nil, fmt.Errorf("issuer.issuerURL is required")
+	}
+	audiences, _, err := unstructured.NestedStringSlice(provider, "issuer", "audiences")
+	if err != nil {
+		return nil, fmt.Errorf("invalid issuer.audiences: %w", err)
+	}
+	if len(audiences) == 0 {
+		return nil, fmt.Errorf("issuer.audiences is required")
+	}
+	issuer := map[string]interface{}{
+		"url":       issuerURL,
+		"audiences": stringsToInterfaces(audiences),
+	}
+
+	if caName, _, _ := unstructured.NestedString(provider, "issuer", "issuerCertificateAuthority", "name"); len(caName) > 0 {
+		caConfigMap, err := listers.ConfigMapLister().ConfigMaps(issuerCANamespace).Get(caName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the issuer CA: %w", err)
+		}
+		ca, ok := caConfigMap.Data[issuerCAKey]
+		if !ok || len(ca) == 0 {
+			return nil, fmt.Errorf("config map %s/%s has no %s", issuerCANamespace, caName, issuerCAKey)
+		}
+		issuer["certificateAuthority"] = ca
+	}
+
+	usernameClaim, _, _ := unstructured.NestedString(provider, "claimMappings", "username", "claim")
+	if len(usernameClaim) == 0 {
+		usernameClaim = "sub"
+	}
+	usernamePrefix, err := usernamePrefix(provider, issuerURL, usernameClaim)
+	if err != nil {
+		return nil, err
+	}
+	claimMappings := map[string]interface{}{
+		"username": map[string]interface{}{"claim": usernameClaim, "prefix": usernamePrefix},
+	}
+	if groupsClaim, _, _ := unstructured.NestedString(provider, "claimMappings", "groups", "claim"); len(groupsClaim) > 0 {
+		groupsPrefix, _, _ := unstructured.NestedString(provider, "claimMappings", "groups", "prefix")
+		claimMappings["groups"] = map[string]interface{}{"claim": groupsClaim, "prefix": groupsPrefix}
+	}
+
+	return map[string]interface{}{
+		"issuer":        issuer,
+		"claimMappings": claimMappings,
+	}, nil
+}
+
+// usernamePrefix returns the prefix of the usernames. By default, the usernames are prefixed with the issuer URL unless
+// the claim is the email, like the kube-apiserver does for its --oidc-username-prefix flag.
+func usernamePrefix(provider map[string]interface{}, issuerURL, claim string) (string, error) {
+	policy, _, _ := unstructured.NestedString(provider, "claimMappings", "username", "prefixPolicy")
+	switch policy {
+	case "":
+		if claim == "email" {
+			return "", nil
+		}
+		return issuerURL + "#", nil
+	case "NoPrefix":
+		return "", nil
+	case "Prefix":
+		prefix, _, _ := unstructured.NestedString(provider, "claimMappings", "username", "prefix", "prefixString")
+		if len(prefix) == 0 {
+			return "", fmt.Errorf("claimMappings.username.prefix.prefixString is required with the Prefix policy")
+		}
+		return prefix, nil
+	default:
+		return "", fmt.Errorf("unknown claimMappings.username.prefixPolicy %q", policy)
+	}
+}
+
+func stringsToInterfaces(values []string) []interface{} {
+	ret := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		ret = append(ret, value)
+	}
+	return ret
+}
@@ -0,0 +1,174 @@
+package authentication
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
+)
+
+type testLister struct {
+	authenticationLister cache.GenericLister
+	configMapLister      corev1listers.ConfigMapLister
+}
+
+func (l testLister) GenericLister(resource schema.GroupVersionResource) cache.GenericLister {
+	if resource != authenticationsResource {
+		return nil
+	}
+	return l.authenticationLister
+}
+
+func (l testLister) ConfigMapLister() corev1listers.ConfigMapLister {
+	return l.configMapLister
+}
+
+func (l testLister) ResourceSyncer() resourcesynccontroller.ResourceSyncer {
+	return nil
+}
+
+func (l testLister) PreRunHasSynced() []cache.InformerSynced {
+	return nil
+}
+
+func TestObserveExternalOIDC(t *testing.T) {
+	configPath := []string{"authConfig", "jwt"}
+	authentication := func(authenticationType string, providers ...interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "config.openshift.io/v1",
+			"kind":       "Authentication",
+			"metadata":   map[string]interface{}{"name": "cluster"},
+			"spec": map[string]interface{}{
+				"type":          authenticationType,
+				"oidcProviders": providers,
+			},
+		}}
+	}
+	provider := map[string]interface{}{
+		"name": "keycloak",
+		"issuer": map[string]interface{}{
+			"issuerURL":                  "https://keycloak.example.com/realms/openshift",
+			"audiences":                  []interface{}{"openshift", "console"},
+			"issuerCertificateAuthority": map[string]interface{}{"name": "keycloak-ca"},
+		},
+		"claimMappings": map[string]interface{}{
+			"username": map[string]interface{}{"claim": "preferred_username", "prefixPolicy": "Prefix", "prefix": map[string]interface{}{"prefixString": "keycloak:"}},
+			"groups":   map[string]interface{}{"claim": "groups", "prefix": "keycloak:"},
+		},
+	}
+	existingConfig := map[string]interface{}{
+		"authConfig": map[string]interface{}{"jwt": []interface{}{map[string]interface{}{"issuer": map[string]interface{}{"url": "https://old.example.com"}}}},
+	}
+
+	tests := []struct {
+		name           string
+		authentication *unstructured.Unstructured
+		configMaps     []*corev1.ConfigMap
+		expectedConfig map[string]interface{}
+		expectErrors   bool
+	}{
+		{
+			name:           "no authentication resource",
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name:           "integrated OAuth",
+			authentication: authentication("IntegratedOAuth"),
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name:           "OIDC provider",
+			authentication: authentication(AuthenticationTypeOIDC, provider),
+			configMaps: []*corev1.ConfigMap{
+				{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-config", Name: "keycloak-ca"}, Data: map[string]string{"ca-bundle.crt": "CA"}},
+			},
+			expectedConfig: map[string]interface{}{
+				"authConfig": map[string]interface{}{"jwt": []interface{}{
+					map[string]interface{}{
+						"issuer": map[string]interface{}{
+							"url":                  "https://keycloak.example.com/realms/openshift",
+							"audiences":            []interface{}{"openshift", "console"},
+							"certificateAuthority": "CA",
+						},
+						"claimMappings": map[string]interface{}{
+							"username": map[string]interface{}{"claim": "preferred_username", "prefix": "keycloak:"},
+							"groups":   map[string]interface{}{"claim": "groups", "prefix": "keycloak:"},
+						},
+					},
+				}},
+			},
+		},
+		{
+			name: "default username mapping",
+			authentication: authentication(AuthenticationTypeOIDC, map[string]interface{}{
+				"name":   "dex",
+				"issuer": map[string]interface{}{"issuerURL": "https://dex.example.com", "audiences": []interface{}{"openshift"}},
+			}),
+			expectedConfig: map[string]interface{}{
+				"authConfig": map[string]interface{}{"jwt": []interface{}{
+					map[string]interface{}{
+						"issuer": map[string]interface{}{
+							"url":       "https://dex.example.com",
+							"audiences": []interface{}{"openshift"},
+						},
+						"claimMappings": map[string]interface{}{
+							"username": map[string]interface{}{"claim": "sub", "prefix": "https://dex.example.com#"},
+						},
+					},
+				}},
+			},
+		},
+		{
+			name:           "missing issuer CA keeps the existing config",
+			authentication: authentication(AuthenticationTypeOIDC, provider),
+			expectedConfig: existingConfig,
+			expectErrors:   true,
+		},
+		{
+			name: "missing audiences keeps the existing config",
+			authentication: authentication(AuthenticationTypeOIDC, map[string]interface{}{
+				"name":   "dex",
+				"issuer": map[string]interface{}{"issuerURL": "https://dex.example.com"},
+			}),
+			expectedConfig: existingConfig,
+			expectErrors:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticationIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if tt.authentication != nil {
+				if err := authenticationIndexer.Add(tt.authentication); err != nil {
+					t.Fatal(err)
+				}
+			}
+			configMapIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, configMap := range tt.configMaps {
+				if err := configMapIndexer.Add(configMap); err != nil {
+					t.Fatal(err)
+				}
+			}
+			listers := testLister{
+				authenticationLister: cache.NewGenericLister(authenticationIndexer, authenticationsResource.GroupResource()),
+				configMapLister:      corev1listers.NewConfigMapLister(configMapIndexer),
+			}
+
+			observedConfig, errs := NewObserveExternalOIDCFunc(configPath...)(listers, events.NewInMemoryRecorder("test"), existingConfig)
+			if tt.expectErrors != (len(errs) > 0) {
+				t.Errorf("unexpected errors: %v", errs)
+			}
+			if !reflect.DeepEqual(observedConfig, tt.expectedConfig) {
+				t.Errorf("expected config %#v, got %#v", tt.expectedConfig, observedConfig)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package configobserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// AppliedConfigFunc returns the config currently applied to the operand, e.g. the config of its latest revision.
+// It is compared with the observed config decoded from JSON, so it should be decoded from JSON as well.
+type AppliedConfigFunc func(ctx context.Context) (map[string]interface{}, error)
+
+// configDriftController reports whether the observed config has been applied to the operand.
+type configDriftController struct {
+	name             string
+	operatorClient   v1helpers.OperatorClient
+	nestedConfigPath []string
+	appliedConfig    AppliedConfigFunc
+}
+
+// NewConfigDriftController creates a controller that compares the observed config (its nestedConfigPath section, if set)
+// with the config applied to the operand. The observed config has drifted when any of its values is missing or different
+// in the applied config, the applied config may contain more than the observed one.
+// The drift is exposed by the config_observer_observed_config_drifted metric, labeled with name, to make delays of
+// the config propagation visible.
+func NewConfigDriftController(
+	name string,
+	operatorClient v1helpers.OperatorClient,
+	nestedConfigPath []string,
+	appliedConfig AppliedConfigFunc,
+	informers []factory.Informer,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &configDriftController{
+		name:             name,
+		operatorClient:   operatorClient,
+		nestedConfigPath: nestedConfigPath,
+		appliedConfig:    appliedConfig,
+	}
+
+	return factory.New().ResyncEvery(time.Minute).WithSync(c.sync).WithInformers(append(informers, operatorClient.Informer())...).ToController(name, eventRecorder)
+}
+
+func (c *configDriftController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	spec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	observedConfig := map[string]interface{}{}
+	if len(spec.ObservedConfig.Raw) > 0 {
+		if err := json.Unmarshal(spec.ObservedConfig.Raw, &observedConfig); err != nil {
+			return fmt.Errorf("failed to decode the observed config: %w", err)
+		}
+	}
+	if len(c.nestedConfigPath) > 0 {
+		observedConfig, _, err = unstructured.NestedMap(observedConfig, c.nestedConfigPath...)
+		if err != nil {
+			return err
+		}
+	}
+
+	appliedConfig, err := c.appliedConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	drifted := false
+	for _, leaf := range leaves("", observedConfig, nil) {
+		applied, found, err := unstructured.NestedFieldNoCopy(appliedConfig, leaf.path...)
+		if err != nil || !found || !equality.Semantic.DeepEqual(applied, leaf.value) {
+			klog.V(4).Infof("%s: observed config %s not applied yet", c.name, strings.Join(leaf.path, "."))
+			drifted = true
+			break
+		}
+	}
+	metrics.ObserveDrift(c.name, drifted)
+	return nil
+}
@@ -0,0 +1,97 @@
+package configobserver
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/component-base/metrics/testutil"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/condition"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func TestConfigDriftController(t *testing.T) {
+	observedConfig := `{"servingInfo":{"minTLSVersion":"VersionTLS12"},"operand":{"replicas":3}}`
+
+	tests := []struct {
+		name             string
+		nestedConfigPath []string
+		appliedConfig    map[string]interface{}
+		expectedDrift    float64
+	}{
+		{
+			name: "applied",
+			appliedConfig: map[string]interface{}{
+				"servingInfo": map[string]interface{}{"minTLSVersion": "VersionTLS12", "bindAddress": "0.0.0.0:8443"},
+				"operand":     map[string]interface{}{"replicas": float64(3)},
+			},
+		},
+		{
+			name: "not applied yet",
+			appliedConfig: map[string]interface{}{
+				"servingInfo": map[string]interface{}{"minTLSVersion": "VersionTLS11"},
+				"operand":     map[string]interface{}{"replicas": float64(3)},
+			},
+			expectedDrift: 1,
+		},
+		{
+			name:             "nested config applied",
+			nestedConfigPath: []string{"operand"},
+			appliedConfig:    map[string]interface{}{"replicas": float64(3)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ObservedConfig: runtime.RawExtension{Raw: []byte(observedConfig)}}, &operatorv1.OperatorStatus{}, nil)
+			c := &configDriftController{
+				name:             "TestConfigDrift",
+				operatorClient:   operatorClient,
+				nestedConfigPath: tt.nestedConfigPath,
+				appliedConfig: func(context.Context) (map[string]interface{}, error) {
+					return tt.appliedConfig, nil
+				},
+			}
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if value, _ := testutil.GetGaugeMetricValue(metrics.observedConfigDrifted.WithLabelValues("TestConfigDrift")); value != tt.expectedDrift {
+				t.Errorf("expected drift %v, got %v", tt.expectedDrift, value)
+			}
+		})
+	}
+}
+
+func TestSyncMetrics(t *testing.T) {
+	failingObserver := func(listers Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		return existingConfig, []error{fmt.Errorf("failed")}
+	}
+	conditionType := "TestSyncMetrics" + condition.ConfigObservationDegradedConditionType
+	configObserver := ConfigObserver{
+		listers:               &fakeLister{},
+		operatorClient:        v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{}, &operatorv1.OperatorStatus{}, nil),
+		observers:             []ObserveConfigFunc{failingObserver},
+		degradedConditionType: conditionType,
+	}
+
+	configObserver.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test")))
+	if value, _ := testutil.GetCounterMetricValue(metrics.observationErrors.WithLabelValues(conditionType, observerName(0, failingObserver))); value != 1 {
+		t.Errorf("expected 1 error, got %v", value)
+	}
+	if value, _ := testutil.GetGaugeMetricValue(metrics.lastSuccessTimestamp.WithLabelValues(conditionType)); value != 0 {
+		t.Errorf("expected no successful observation, got %v", value)
+	}
+
+	configObserver.observers = nil
+	if err := configObserver.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value, _ := testutil.GetGaugeMetricValue(metrics.lastSuccessTimestamp.WithLabelValues(conditionType)); value == 0 {
+		t.Error("expected a successful observation")
+	}
+}
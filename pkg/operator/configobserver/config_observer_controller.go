@@ -6,12 +6,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
+	goruntime "runtime"
 	"strings"
 	"time"
 
 	"github.com/imdario/mergo"
 	"k8s.io/klog/v2"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -55,6 +58,11 @@ type ConfigObserver struct {
 
 	nestedConfigPath      []string
 	degradedConditionType string
+
+	// schema is the optional structural schema of the observed config
+	schema *apiextensionsv1.JSONSchemaProps
+	// provenance records which observer set which path, served by NewProvenanceDebugHandler
+	provenance *configProvenance
 }
 
 func NewConfigObserver(
@@ -102,6 +110,34 @@ func NewNestedConfigObserver(
 	nestedConfigPath []string,
 	degradedConditionPrefix string,
 	observers ...ObserveConfigFunc,
+) factory.Controller {
+	return NewNestedConfigObserverWithSchema(
+		operatorClient,
+		eventRecorder,
+		listers,
+		informers,
+		nestedConfigPath,
+		degradedConditionPrefix,
+		nil,
+		observers...,
+	)
+}
+
+// NewNestedConfigObserverWithSchema creates a config observer like NewNestedConfigObserver whose observations are
+// validated against the structural schema of the whole observed config (not only the nestedConfigPath section).
+// Fields unknown to the schema are pruned from each observation. When an observation is invalid, the observed config
+// is not updated at all and the ConfigObservationDegraded condition names the observer and the offending path,
+// so that invalid config never reaches the operand.
+// A nil schema disables the validation.
+func NewNestedConfigObserverWithSchema(
+	operatorClient v1helpers.OperatorClient,
+	eventRecorder events.Recorder,
+	listers Listers,
+	informers []factory.Informer,
+	nestedConfigPath []string,
+	degradedConditionPrefix string,
+	schema *apiextensionsv1.JSONSchemaProps,
+	observers ...ObserveConfigFunc,
 ) factory.Controller {
 	c := &ConfigObserver{
 		operatorClient:        operatorClient,
@@ -109,6 +145,8 @@ func NewNestedConfigObserver(
 		listers:               listers,
 		nestedConfigPath:      nestedConfigPath,
 		degradedConditionType: degradedConditionPrefix + condition.ConfigObservationDegradedConditionType,
+		schema:                schema,
+		provenance:            registerProvenance(degradedConditionPrefix + condition.ConfigObservationDegradedConditionType),
 	}
 
 	return factory.New().ResyncEvery(time.Minute).WithSync(c.sync).WithInformers(append(informers, listersToInformer(listers)...)...).ToController("ConfigObserver", eventRecorder.WithComponentSuffix("config-observer"))
@@ -133,14 +171,35 @@ func (c ConfigObserver) sync(ctx context.Context, syncCtx factory.SyncContext) e
 	}
 
 	var errs []error
+	var invalid bool
 	var observedConfigs []map[string]interface{}
+	var observedLeaves []observedLeaf
 	for _, i := range rand.Perm(len(c.observers)) {
 		var currErrs []error
+		name := observerName(i, c.observers[i])
 		observedConfig, currErrs := c.observers[i](c.listers, syncCtx.Recorder(), existingConfig)
+		if c.schema != nil {
+			var schemaErr error
+			if observedConfig, schemaErr = c.pruneAndValidateObservation(observedConfig); schemaErr != nil {
+				invalid = true
+				currErrs = append(currErrs, fmt.Errorf("observer %s produced invalid config: %v", name, schemaErr))
+			}
+		}
 		observedConfigs = append(observedConfigs, observedConfig)
+		observedLeaves = append(observedLeaves, leaves(name, observedConfig, nil)...)
+		metrics.ObserveErrors(c.degradedConditionType, name, len(currErrs))
 		errs = append(errs, currErrs...)
 	}
 
+	// conflicts make the merge non-deterministic, which is reported below, the events tell which observers are to blame
+	observedLeavesByPath, conflictErrs := detectConflicts(observedLeaves)
+	for _, err := range conflictErrs {
+		syncCtx.Recorder().Warningf("ObservedConfigConflict", "%v", err)
+	}
+	if c.provenance != nil {
+		c.provenance.update(observedLeavesByPath, time.Now())
+	}
+
 	mergedObservedConfig := map[string]interface{}{}
 	for _, observedConfig := range observedConfigs {
 		if err := mergo.Merge(&mergedObservedConfig, observedConfig); err != nil {
@@ -159,10 +218,15 @@ func (c ConfigObserver) sync(ctx context.Context, syncCtx factory.SyncContext) e
 		errs = append(errs, errors.New("non-deterministic config observation detected"))
 	}
 
-	if err := c.updateObservedConfig(ctx, syncCtx, existingConfig, mergedObservedConfig); err != nil {
+	if invalid {
+		syncCtx.Recorder().Warningf("ObservedConfigInvalid", "Not writing invalid observed config: %v", v1helpers.NewMultiLineAggregate(errs))
+	} else if err := c.updateObservedConfig(ctx, syncCtx, existingConfig, mergedObservedConfig); err != nil {
 		errs = []error{err}
 	}
 	configError := v1helpers.NewMultiLineAggregate(errs)
+	if configError == nil {
+		metrics.ObserveSuccess(c.degradedConditionType, time.Now())
+	}
 
 	// update failing condition
 	cond := operatorv1.OperatorCondition{
@@ -232,6 +296,15 @@ func (c ConfigObserver) updateNestedConfigHelper(updatedNestedConfig map[string]
 	}
 }
 
+// observerName identifies an observer in error messages by its index and function name.
+func observerName(index int, observer ObserveConfigFunc) string {
+	name := "<unknown>"
+	if fn := goruntime.FuncForPC(reflect.ValueOf(observer).Pointer()); fn != nil {
+		name = fn.Name()
+	}
+	return fmt.Sprintf("#%d (%s)", index, name)
+}
+
 // listersToInformer converts the Listers interface to informer with empty AddEventHandler as we only care about synced caches in the Run.
 func listersToInformer(l Listers) []factory.Informer {
 	result := make([]factory.Informer, len(l.PreRunHasSynced()))
@@ -194,6 +194,7 @@ func TestSyncStatus(t *testing.T) {
 				}
 			},
 			expectEvents: [][]string{
+				{"ObservedConfigConflict", "observers #"},
 				{"ObservedConfigChanged", "Writing updated observed config"},
 			},
 			observers: []ObserveConfigFunc{
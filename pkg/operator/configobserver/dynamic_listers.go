@@ -0,0 +1,183 @@
+package configobserver
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// discoveryTTL is how long the availability of a resource is cached.
+const discoveryTTL = 30 * time.Second
+
+// DynamicListers provides GenericListers backed by a dynamic informer factory. The informer of a resource is only
+// started the first time an observer asks for it, and only once the resource is served, so that observers can
+// watch CRDs that don't exist when the operator starts:
+//
+//   - while the resource is not served, the lister behaves as if there were no objects (Get returns NotFound),
+//   - while the informer is syncing, the lister returns an error, so that observers keep the existing config,
+//   - afterwards the informer's lister is returned.
+//
+// Embed it into the Listers of a config observer and pass it as one of its informers too: the handlers added to it
+// are added to all informers it starts, so that the config observer is triggered by changes of the lazily started
+// informers.
+type DynamicListers struct {
+	informerFactory dynamicinformer.DynamicSharedInformerFactory
+	discoveryClient discovery.ServerResourcesInterface
+	clock           clock.PassiveClock
+
+	lock      sync.Mutex
+	stopCh    <-chan struct{}
+	informers map[schema.GroupVersionResource]informers.GenericInformer
+	served    map[schema.GroupVersionResource]servedCheck
+	handlers  []cache.ResourceEventHandler
+}
+
+type servedCheck struct {
+	served    bool
+	checkedAt time.Time
+}
+
+// NewDynamicListers returns DynamicListers starting informers of informerFactory once the resources are
+// reported by discoveryClient. Informers are started only after Start is called.
+func NewDynamicListers(informerFactory dynamicinformer.DynamicSharedInformerFactory, discoveryClient discovery.ServerResourcesInterface) *DynamicListers {
+	return &DynamicListers{
+		informerFactory: informerFactory,
+		discoveryClient: discoveryClient,
+		clock:           clock.RealClock{},
+		informers:       map[schema.GroupVersionResource]informers.GenericInformer{},
+		served:          map[schema.GroupVersionResource]servedCheck{},
+	}
+}
+
+// Start allows starting the informers, now and when they are requested later. They are stopped with stopCh.
+func (l *DynamicListers) Start(stopCh <-chan struct{}) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.stopCh = stopCh
+	l.informerFactory.Start(stopCh)
+}
+
+// GenericLister implements GenericListers.
+func (l *DynamicListers) GenericLister(resource schema.GroupVersionResource) cache.GenericLister {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	informer, ok := l.informers[resource]
+	if !ok {
+		served, err := l.isServed(resource)
+		if err != nil {
+			return &errorLister{err: err}
+		}
+		if !served {
+			return &emptyLister{resource: resource}
+		}
+
+		klog.Infof("Starting informer of %s", resource)
+		informer = l.informerFactory.ForResource(resource)
+		for _, handler := range l.handlers {
+			// the informer is not kept, so that the next call tries again
+			if _, err := informer.Informer().AddEventHandler(handler); err != nil {
+				return &errorLister{err: fmt.Errorf("failed to watch %s: %w", resource, err)}
+			}
+		}
+		l.informers[resource] = informer
+		if l.stopCh != nil {
+			l.informerFactory.Start(l.stopCh)
+		}
+	}
+
+	if !informer.Informer().HasSynced() {
+		return &errorLister{err: fmt.Errorf("informer of %s is not synced yet", resource)}
+	}
+	return informer.Lister()
+}
+
+// isServed returns whether the resource is served, cached for discoveryTTL.
+func (l *DynamicListers) isServed(resource schema.GroupVersionResource) (bool, error) {
+	if check, ok := l.served[resource]; ok && l.clock.Now().Sub(check.checkedAt) < discoveryTTL {
+		return check.served, nil
+	}
+
+	served := false
+	resourceList, err := l.discoveryClient.ServerResourcesForGroupVersion(resource.GroupVersion().String())
+	switch {
+	case errors.IsNotFound(err):
+	case err != nil:
+		return false, fmt.Errorf("failed to discover %s: %w", resource, err)
+	default:
+		for _, r := range resourceList.APIResources {
+			if r.Name == resource.Resource {
+				served = true
+				break
+			}
+		}
+	}
+	l.served[resource] = servedCheck{served: served, checkedAt: l.clock.Now()}
+	return served, nil
+}
+
+// AddEventHandler adds the handler to all informers started now and later, as a factory.Informer.
+func (l *DynamicListers) AddEventHandler(handler cache.ResourceEventHandler) (cache.ResourceEventHandlerRegistration, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.handlers = append(l.handlers, handler)
+	for _, informer := range l.informers {
+		if _, err := informer.Informer().AddEventHandler(handler); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// HasSynced always returns true, as a factory.Informer. Informers that are not synced yet are reported by their listers
+// instead of blocking the start of the controllers.
+func (l *DynamicListers) HasSynced() bool {
+	return true
+}
+
+// emptyLister is the lister of a resource that is not served.
+type emptyLister struct {
+	resource schema.GroupVersionResource
+}
+
+func (l *emptyLister) List(labels.Selector) ([]runtime.Object, error) {
+	return nil, nil
+}
+
+func (l *emptyLister) Get(name string) (runtime.Object, error) {
+	return nil, errors.NewNotFound(l.resource.GroupResource(), name)
+}
+
+func (l *emptyLister) ByNamespace(string) cache.GenericNamespaceLister {
+	return l
+}
+
+// errorLister is the lister of a resource that cannot be listed yet.
+type errorLister struct {
+	err error
+}
+
+func (l *errorLister) List(labels.Selector) ([]runtime.Object, error) {
+	return nil, l.err
+}
+
+func (l *errorLister) Get(string) (runtime.Object, error) {
+	return nil, l.err
+}
+
+func (l *errorLister) ByNamespace(string) cache.GenericNamespaceLister {
+	return l
+}
@@ -0,0 +1,104 @@
+package configobserver
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestDynamicListers(t *testing.T) {
+	widgets := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	widget := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]interface{}{"name": "cluster"},
+		"spec":       map[string]interface{}{"size": "large"},
+	}}
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{widgets: "WidgetList"}, widget)
+	discoveryClient := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	listers := NewDynamicListers(dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, 0), discoveryClient)
+	fakeClock := clocktesting.NewFakePassiveClock(now)
+	listers.clock = fakeClock
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	listers.Start(ctx.Done())
+	triggered := make(chan struct{}, 10)
+	if _, err := listers.AddEventHandler(cache.ResourceEventHandlerFuncs{AddFunc: func(interface{}) { triggered <- struct{}{} }}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the CRD doesn't exist yet
+	if _, err := listers.GenericLister(widgets).Get("cluster"); !errors.IsNotFound(err) {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+
+	// the CRD was created, but the absence is still cached
+	discoveryClient.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "example.com/v1",
+		APIResources: []metav1.APIResource{{Name: "widgets", Kind: "Widget"}},
+	}}
+	if _, err := listers.GenericLister(widgets).Get("cluster"); !errors.IsNotFound(err) {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+
+	// the informer is started once the cache expires
+	now = now.Add(discoveryTTL)
+	fakeClock.SetTime(now)
+	if err := waitFor(func() bool {
+		_, err := listers.GenericLister(widgets).Get("cluster")
+		return err == nil
+	}); err != nil {
+		t.Fatalf("the widget was never listed: %v", err)
+	}
+	select {
+	case <-triggered:
+	case <-time.After(10 * time.Second):
+		t.Error("the event handler was not added to the started informer")
+	}
+
+	// field observers work with the listers
+	observe := NewFieldObserver(widgets, "", "cluster", "spec", "size").WithDestination("widgetSize").ToObserveConfigFunc()
+	observedConfig, errs := observe(&dynamicTestListers{DynamicListers: listers}, events.NewInMemoryRecorder("test"), map[string]interface{}{})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if expected := map[string]interface{}{"widgetSize": "large"}; !reflect.DeepEqual(observedConfig, expected) {
+		t.Errorf("expected %v, got %v", expected, observedConfig)
+	}
+}
+
+type dynamicTestListers struct {
+	fakeLister
+	*DynamicListers
+}
+
+func waitFor(condition func() bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for !condition() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return nil
+}
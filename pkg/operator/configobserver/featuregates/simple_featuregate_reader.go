@@ -85,7 +85,8 @@ type defaultFeatureGateAccess struct {
 // of featuregates to use.
 // featureGateInformer is used to track changes to the featureGates once they are initially set.
 // By default, when the enabled/disabled list  of featuregates changes, os.Exit is called.  This behavior can be
-// overridden by calling SetChangeHandler to whatever you wish the behavior to be.
+// overridden by calling SetChangeHandler to whatever you wish the behavior to be, e.g. the ChangeHandler of
+// FeatureGateSubscriptions to react to individual features or of GracefulRestart to stop the controllers before exiting.
 // A common construct is:
 /* go
 featureGateAccessor := NewFeatureGateAccess(args)
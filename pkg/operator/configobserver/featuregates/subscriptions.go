@@ -0,0 +1,139 @@
+package featuregates
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+)
+
+// FeatureToggleHandlerFunc is called when a feature is enabled or disabled after the initial featuregates were observed.
+type FeatureToggleHandlerFunc func(feature configv1.FeatureGateName, enabled bool)
+
+// FeatureGateSubscriptions dispatches the changes of the featuregates to callbacks of the individual features.
+// Pass ChangeHandler to FeatureGateAccess.SetChangeHandler before running the FeatureGateAccess:
+/* go
+subscriptions := featuregates.NewFeatureGateSubscriptions()
+subscriptions.Subscribe(configv1.FeatureGateName("MyFeature"), func(feature configv1.FeatureGateName, enabled bool) {
+	restart.Restart(fmt.Sprintf("feature %s toggled", feature))
+})
+featureGateAccessor.SetChangeHandler(subscriptions.ChangeHandler())
+go featureGateAccessor.Run(ctx)
+*/
+// Changes of features without a subscription are ignored.
+type FeatureGateSubscriptions struct {
+	lock     sync.Mutex
+	handlers map[configv1.FeatureGateName][]FeatureToggleHandlerFunc
+}
+
+// NewFeatureGateSubscriptions returns subscriptions without any handler.
+func NewFeatureGateSubscriptions() *FeatureGateSubscriptions {
+	return &FeatureGateSubscriptions{
+		handlers: map[configv1.FeatureGateName][]FeatureToggleHandlerFunc{},
+	}
+}
+
+// Subscribe adds a handler called whenever feature is enabled or disabled.
+func (s *FeatureGateSubscriptions) Subscribe(feature configv1.FeatureGateName, handler FeatureToggleHandlerFunc) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.handlers[feature] = append(s.handlers[feature], handler)
+}
+
+// ChangeHandler returns the change handler calling the subscribed handlers of all features toggled by a change.
+// The initial observation of the featuregates is not a change.
+// The handlers are called synchronously, in the order they were subscribed. They must not block and they
+// must not call the FeatureGateAccess.
+func (s *FeatureGateSubscriptions) ChangeHandler() FeatureGateChangeHandlerFunc {
+	return func(featureChange FeatureChange) {
+		if featureChange.Previous == nil {
+			return
+		}
+		previouslyEnabled := sets.New[configv1.FeatureGateName](featureChange.Previous.Enabled...)
+		enabled := sets.New[configv1.FeatureGateName](featureChange.New.Enabled...)
+
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		for _, feature := range sets.List(previouslyEnabled.SymmetricDifference(enabled)) {
+			for _, handler := range s.handlers[feature] {
+				handler(feature, enabled.Has(feature))
+			}
+		}
+	}
+}
+
+// GracefulRestart coordinates the restart of an operator, e.g. after a featuregate change: instead of exiting abruptly,
+// it stops the controllers, waits for them to finish their current sync, flushes the last state and exits 0.
+// The container is then restarted by the kubelet and reads the new featuregates.
+type GracefulRestart struct {
+	// Stop stops the controllers, usually the cancel function of their context.
+	Stop context.CancelFunc
+	// Stopped is an optional channel closed when all controllers returned.
+	Stopped <-chan struct{}
+	// Flush are optional functions called after the controllers stopped, e.g. to report a final status.
+	// Errors are logged, they don't prevent the exit.
+	Flush []func(ctx context.Context) error
+	// Timeout bounds the wait for Stopped and the Flush functions together. The operator exits when it expires.
+	// Defaults to 30 seconds.
+	Timeout time.Duration
+
+	once sync.Once
+	exit func(code int)
+}
+
+// ChangeHandler returns a change handler restarting the operator on any featuregate change.
+// It can be used instead of ForceExit.
+func (r *GracefulRestart) ChangeHandler() FeatureGateChangeHandlerFunc {
+	return func(featureChange FeatureChange) {
+		if featureChange.Previous != nil {
+			r.Restart("FeatureGates changed")
+		}
+	}
+}
+
+// Restart starts the restart in the background. Only the first call has any effect.
+func (r *GracefulRestart) Restart(reason string) {
+	r.once.Do(func() {
+		klog.Infof("Restarting gracefully: %s", reason)
+		go r.restart()
+	})
+}
+
+func (r *GracefulRestart) restart() {
+	timeout := r.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if r.Stop != nil {
+		r.Stop()
+	}
+	if r.Stopped != nil {
+		select {
+		case <-r.Stopped:
+		case <-ctx.Done():
+			klog.Warningf("Timed out waiting for the controllers to stop")
+		}
+	}
+	for _, flush := range r.Flush {
+		if err := flush(ctx); err != nil {
+			klog.Warningf("Failed to flush before restart: %v", err)
+		}
+	}
+
+	klog.Infof("Exiting for restart")
+	klog.Flush()
+	exit := r.exit
+	if exit == nil {
+		exit = os.Exit
+	}
+	exit(0)
+}
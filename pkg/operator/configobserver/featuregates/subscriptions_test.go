@@ -0,0 +1,78 @@
+package featuregates
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+func TestFeatureGateSubscriptions(t *testing.T) {
+	type toggle struct {
+		feature configv1.FeatureGateName
+		enabled bool
+	}
+	var toggles []toggle
+	record := func(feature configv1.FeatureGateName, enabled bool) {
+		toggles = append(toggles, toggle{feature: feature, enabled: enabled})
+	}
+
+	subscriptions := NewFeatureGateSubscriptions()
+	subscriptions.Subscribe("Alpha", record)
+	subscriptions.Subscribe("Beta", record)
+	handler := subscriptions.ChangeHandler()
+
+	initial := Features{Enabled: []configv1.FeatureGateName{"Alpha", "Other"}, Disabled: []configv1.FeatureGateName{"Beta"}}
+	handler(FeatureChange{New: initial})
+	if len(toggles) != 0 {
+		t.Fatalf("the initial featuregates must not be reported, got %v", toggles)
+	}
+
+	changed := Features{Enabled: []configv1.FeatureGateName{"Beta"}, Disabled: []configv1.FeatureGateName{"Alpha", "Other"}}
+	handler(FeatureChange{Previous: &initial, New: changed})
+	expected := []toggle{{feature: "Alpha", enabled: false}, {feature: "Beta", enabled: true}}
+	if !reflect.DeepEqual(toggles, expected) {
+		t.Errorf("expected toggles %v, got %v", expected, toggles)
+	}
+}
+
+func TestGracefulRestart(t *testing.T) {
+	var steps []string
+	stopped := make(chan struct{})
+	exited := make(chan int)
+	restart := &GracefulRestart{
+		Stop: func() {
+			steps = append(steps, "stop")
+			close(stopped)
+		},
+		Stopped: stopped,
+		Flush: []func(ctx context.Context) error{
+			func(ctx context.Context) error {
+				steps = append(steps, "flush")
+				return nil
+			},
+		},
+		Timeout: time.Second,
+		exit:    func(code int) { exited <- code },
+	}
+
+	handler := restart.ChangeHandler()
+	handler(FeatureChange{New: Features{}})
+	handler(FeatureChange{Previous: &Features{}, New: Features{Enabled: []configv1.FeatureGateName{"Alpha"}}})
+	// a second change doesn't restart again
+	handler(FeatureChange{Previous: &Features{}, New: Features{}})
+
+	select {
+	case code := <-exited:
+		if code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the exit")
+	}
+	if expected := []string{"stop", "flush"}; !reflect.DeepEqual(steps, expected) {
+		t.Errorf("expected steps %v, got %v", expected, steps)
+	}
+}
@@ -0,0 +1,150 @@
+package configobserver
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// GenericListers is implemented by Listers that provide listers of arbitrary resources, e.g. backed by
+// the ForResource() informers of an informer factory. It is required by observers built with NewFieldObserver.
+type GenericListers interface {
+	// GenericLister returns the lister of the given resource, or nil if the resource is not supported.
+	GenericLister(resource schema.GroupVersionResource) cache.GenericLister
+}
+
+// FieldTransformFunc converts the value of the observed field into the value written to the observed config.
+// found is false when either the field or the whole resource does not exist.
+// Returning a nil value removes the destination path from the observed config. Other values must be JSON
+// compatible (e.g. []interface{} instead of []string), see runtime.DeepCopyJSONValue.
+type FieldTransformFunc func(value interface{}, found bool) (interface{}, error)
+
+// FieldObserverBuilder builds an ObserveConfigFunc that copies a single field of a resource into the observed config.
+type FieldObserverBuilder struct {
+	resource        schema.GroupVersionResource
+	namespace       string
+	name            string
+	fieldPath       []string
+	destinationPath []string
+	transform       FieldTransformFunc
+}
+
+// NewFieldObserver starts building an observer of the field at fieldPath of the resource name (in namespace,
+// empty for cluster scoped resources). By default the field is copied as it is to the same path in the observed
+// config, and the path is removed from the observed config when the field does not exist.
+//
+// Example:
+//
+//	configobserver.NewFieldObserver(configv1.GroupVersion.WithResource("apiservers"), "", "cluster", "spec", "audit", "profile").
+//		WithDestination("apiServerArguments", "audit-profile").
+//		WithTransform(toStringSlice).
+//		ToObserveConfigFunc()
+func NewFieldObserver(resource schema.GroupVersionResource, namespace, name string, fieldPath ...string) *FieldObserverBuilder {
+	return &FieldObserverBuilder{
+		resource:        resource,
+		namespace:       namespace,
+		name:            name,
+		fieldPath:       fieldPath,
+		destinationPath: fieldPath,
+	}
+}
+
+// WithDestination sets the path of the observed config the field is written to.
+func (b *FieldObserverBuilder) WithDestination(path ...string) *FieldObserverBuilder {
+	b.destinationPath = path
+	return b
+}
+
+// WithTransform sets the function converting the field value before it is written to the observed config.
+func (b *FieldObserverBuilder) WithTransform(transform FieldTransformFunc) *FieldObserverBuilder {
+	b.transform = transform
+	return b
+}
+
+// ToObserveConfigFunc returns the observer. The returned observed config only ever contains the destination path.
+// When the resource cannot be read or the transformation fails, the existing value of the destination is kept
+// and the error is returned.
+func (b *FieldObserverBuilder) ToObserveConfigFunc() ObserveConfigFunc {
+	if len(b.fieldPath) == 0 || len(b.destinationPath) == 0 {
+		panic(fmt.Sprintf("field observer of %s %s: both the field and the destination path must be set", b.resource, b.name))
+	}
+	observer := *b
+
+	return func(genericListers Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, errs []error) {
+		defer func() {
+			ret = Pruned(ret, observer.destinationPath)
+		}()
+
+		listers, ok := genericListers.(GenericListers)
+		if !ok {
+			return existingConfig, append(errs, fmt.Errorf("failed to assert: given lister does not implement generic listers"))
+		}
+		lister := listers.GenericLister(observer.resource)
+		if lister == nil {
+			return existingConfig, append(errs, fmt.Errorf("no lister of %s", observer.resource))
+		}
+
+		value, found, err := observer.observe(lister)
+		if err != nil {
+			return existingConfig, append(errs, err)
+		}
+		if observer.transform != nil {
+			value, err = observer.transform(value, found)
+			if err != nil {
+				return existingConfig, append(errs, fmt.Errorf("failed to transform %s of %s %s: %w", strings.Join(observer.fieldPath, "."), observer.resource, observer.name, err))
+			}
+		} else if !found {
+			value = nil
+		}
+
+		observedConfig := map[string]interface{}{}
+		if value != nil {
+			if err := unstructured.SetNestedField(observedConfig, value, observer.destinationPath...); err != nil {
+				return existingConfig, append(errs, err)
+			}
+		}
+
+		existingValue, _, _ := unstructured.NestedFieldNoCopy(existingConfig, observer.destinationPath...)
+		if !equality.Semantic.DeepEqual(existingValue, value) {
+			recorder.Eventf("ObservedConfigChanged", "%s changed from %v to %v", strings.Join(observer.destinationPath, "."), existingValue, value)
+		}
+		return observedConfig, errs
+	}
+}
+
+// observe returns a copy of the field value. A missing resource is reported like a missing field.
+func (b *FieldObserverBuilder) observe(lister cache.GenericLister) (interface{}, bool, error) {
+	var obj runtime.Object
+	var err error
+	if len(b.namespace) > 0 {
+		obj, err = lister.ByNamespace(b.namespace).Get(b.name)
+	} else {
+		obj, err = lister.Get(b.name)
+	}
+	if errors.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var content map[string]interface{}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		content = u.UnstructuredContent()
+	} else if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+		return nil, false, err
+	}
+	value, found, err := unstructured.NestedFieldCopy(content, b.fieldPath...)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read %s of %s %s: %w", strings.Join(b.fieldPath, "."), b.resource, b.name, err)
+	}
+	return value, found, nil
+}
@@ -0,0 +1,131 @@
+package configobserver
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+type fakeGenericListers struct {
+	fakeLister
+	listers map[schema.GroupVersionResource]cache.GenericLister
+}
+
+func (l *fakeGenericListers) GenericLister(resource schema.GroupVersionResource) cache.GenericLister {
+	return l.listers[resource]
+}
+
+func TestFieldObserver(t *testing.T) {
+	apiservers := configv1.GroupVersion.WithResource("apiservers")
+	apiserver := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "config.openshift.io/v1",
+		"kind":       "APIServer",
+		"metadata":   map[string]interface{}{"name": "cluster"},
+		"spec":       map[string]interface{}{"audit": map[string]interface{}{"profile": "WriteRequestBodies"}},
+	}}
+	existingConfig := map[string]interface{}{
+		"apiServerArguments": map[string]interface{}{"audit-profile": []interface{}{"Default"}},
+	}
+	toSlice := func(value interface{}, found bool) (interface{}, error) {
+		if !found {
+			return []interface{}{"Default"}, nil
+		}
+		return []interface{}{value}, nil
+	}
+
+	tests := []struct {
+		name           string
+		objects        []*unstructured.Unstructured
+		builder        *FieldObserverBuilder
+		noLister       bool
+		expectedConfig map[string]interface{}
+		expectErrors   bool
+		expectEvents   int
+	}{
+		{
+			name:    "field copied and transformed",
+			objects: []*unstructured.Unstructured{apiserver},
+			builder: NewFieldObserver(apiservers, "", "cluster", "spec", "audit", "profile").
+				WithDestination("apiServerArguments", "audit-profile").
+				WithTransform(toSlice),
+			expectedConfig: map[string]interface{}{
+				"apiServerArguments": map[string]interface{}{"audit-profile": []interface{}{"WriteRequestBodies"}},
+			},
+			expectEvents: 1,
+		},
+		{
+			name:    "field copied to the same path",
+			objects: []*unstructured.Unstructured{apiserver},
+			builder: NewFieldObserver(apiservers, "", "cluster", "spec", "audit", "profile"),
+			expectedConfig: map[string]interface{}{
+				"spec": map[string]interface{}{"audit": map[string]interface{}{"profile": "WriteRequestBodies"}},
+			},
+			expectEvents: 1,
+		},
+		{
+			name: "missing resource transformed to a default",
+			builder: NewFieldObserver(apiservers, "", "cluster", "spec", "audit", "profile").
+				WithDestination("apiServerArguments", "audit-profile").
+				WithTransform(toSlice),
+			expectedConfig: existingConfig,
+		},
+		{
+			name: "missing resource removes the destination",
+			builder: NewFieldObserver(apiservers, "", "cluster", "spec", "audit", "profile").
+				WithDestination("apiServerArguments", "audit-profile"),
+			expectedConfig: map[string]interface{}{},
+			expectEvents:   1,
+		},
+		{
+			name:    "transformation failure keeps the existing value",
+			objects: []*unstructured.Unstructured{apiserver},
+			builder: NewFieldObserver(apiservers, "", "cluster", "spec", "audit", "profile").
+				WithDestination("apiServerArguments", "audit-profile").
+				WithTransform(func(interface{}, bool) (interface{}, error) { return nil, fmt.Errorf("invalid") }),
+			expectedConfig: existingConfig,
+			expectErrors:   true,
+		},
+		{
+			name: "missing lister keeps the existing value",
+			builder: NewFieldObserver(apiservers, "", "cluster", "spec", "audit", "profile").
+				WithDestination("apiServerArguments", "audit-profile"),
+			noLister:       true,
+			expectedConfig: existingConfig,
+			expectErrors:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, obj := range tt.objects {
+				if err := indexer.Add(obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+			listers := &fakeGenericListers{listers: map[schema.GroupVersionResource]cache.GenericLister{}}
+			if !tt.noLister {
+				listers.listers[apiservers] = cache.NewGenericLister(indexer, apiservers.GroupResource())
+			}
+			recorder := events.NewInMemoryRecorder("test")
+
+			observedConfig, errs := tt.builder.ToObserveConfigFunc()(listers, recorder, existingConfig)
+			if tt.expectErrors != (len(errs) > 0) {
+				t.Errorf("unexpected errors: %v", errs)
+			}
+			if !reflect.DeepEqual(observedConfig, tt.expectedConfig) {
+				t.Errorf("expected config %v, got %v", tt.expectedConfig, observedConfig)
+			}
+			if len(recorder.Events()) != tt.expectEvents {
+				t.Errorf("expected %d events, got %v", tt.expectEvents, recorder.Events())
+			}
+		})
+	}
+}
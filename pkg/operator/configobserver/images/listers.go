@@ -0,0 +1,10 @@
+package images
+
+import (
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+)
+
+// ImageConfigLister lists the image.config.openshift.io resource.
+type ImageConfigLister interface {
+	ImageConfigLister() configlistersv1.ImageLister
+}
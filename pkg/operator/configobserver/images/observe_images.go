@@ -0,0 +1,135 @@
+package images
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/operator/configobserver"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
+)
+
+// globalConfigNamespace is the namespace of the additional trusted CA ConfigMap referenced by the image config.
+const globalConfigNamespace = "openshift-config"
+
+// ImageConfigPaths are the paths of the observed config the image config is written to.
+// Fields with an empty path are not observed.
+type ImageConfigPaths struct {
+	// InternalRegistryHostname receives the hostname of the internal image registry, as a string.
+	InternalRegistryHostname []string
+	// ExternalRegistryHostnames receives the external hostnames of the internal image registry, as a list of strings.
+	// The hostnames set in the spec come first, followed by the ones set by the image registry operator in the status.
+	ExternalRegistryHostnames []string
+	// AdditionalTrustedCA receives the name of the ConfigMap with the additional trusted CA bundles of image registries,
+	// as synced to the operand, see NewObserveImageConfigFunc.
+	AdditionalTrustedCA []string
+}
+
+// DefaultImageConfigPaths returns the paths used by the openshift-apiserver config, which most operands read as well.
+func DefaultImageConfigPaths() ImageConfigPaths {
+	return ImageConfigPaths{
+		InternalRegistryHostname:  []string{"imagePolicyConfig", "internalRegistryHostname"},
+		ExternalRegistryHostnames: []string{"imagePolicyConfig", "externalRegistryHostnames"},
+	}
+}
+
+// NewObserveImageConfigFunc returns an observer of the image.config.openshift.io/cluster resource writing the registry
+// hostnames to paths. The Listers must implement ImageConfigLister.
+// When paths.AdditionalTrustedCA is set, the ConfigMap referenced by spec.additionalTrustedCA (keyed by registry
+// hostname, with ".." instead of ":" before a port) is synced to trustedCADestination with the ResourceSyncer, and
+// trustedCADestination.Name is observed, so that operands can mount it. The destination is removed when the image
+// config references no ConfigMap.
+// When the image config doesn't exist, nothing is observed and the trusted CA ConfigMap is not touched.
+func NewObserveImageConfigFunc(paths ImageConfigPaths, trustedCADestination resourcesynccontroller.ResourceLocation) configobserver.ObserveConfigFunc {
+	return (&imageConfigObserver{
+		paths:                paths,
+		trustedCADestination: trustedCADestination,
+	}).observe
+}
+
+type imageConfigObserver struct {
+	paths                ImageConfigPaths
+	trustedCADestination resourcesynccontroller.ResourceLocation
+}
+
+func (o *imageConfigObserver) observe(genericListers configobserver.Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, _ []error) {
+	var paths [][]string
+	for _, path := range [][]string{o.paths.InternalRegistryHostname, o.paths.ExternalRegistryHostnames, o.paths.AdditionalTrustedCA} {
+		if len(path) > 0 {
+			paths = append(paths, path)
+		}
+	}
+	defer func() {
+		ret = configobserver.Pruned(ret, paths...)
+	}()
+
+	errs := []error{}
+	listers, ok := genericListers.(ImageConfigLister)
+	if !ok {
+		return existingConfig, append(errs, fmt.Errorf("failed to assert: given lister does not implement an image config lister"))
+	}
+
+	observedConfig := map[string]interface{}{}
+	imageConfig, err := listers.ImageConfigLister().Get("cluster")
+	if errors.IsNotFound(err) {
+		klog.Warningf("image.config.openshift.io/cluster: not found")
+		return observedConfig, errs
+	}
+	if err != nil {
+		// keep the existing config on transient errors
+		return existingConfig, append(errs, err)
+	}
+
+	if len(o.paths.InternalRegistryHostname) > 0 && len(imageConfig.Status.InternalRegistryHostname) > 0 {
+		if err := unstructured.SetNestedField(observedConfig, imageConfig.Status.InternalRegistryHostname, o.paths.InternalRegistryHostname...); err != nil {
+			return existingConfig, append(errs, err)
+		}
+	}
+
+	if len(o.paths.ExternalRegistryHostnames) > 0 {
+		// keep the order, the first hostname is usually the one presented to users
+		var hostnames []string
+		seen := sets.NewString()
+		for _, hostname := range append(append([]string{}, imageConfig.Spec.ExternalRegistryHostnames...), imageConfig.Status.ExternalRegistryHostnames...) {
+			if !seen.Has(hostname) {
+				seen.Insert(hostname)
+				hostnames = append(hostnames, hostname)
+			}
+		}
+		if len(hostnames) > 0 {
+			if err := unstructured.SetNestedStringSlice(observedConfig, hostnames, o.paths.ExternalRegistryHostnames...); err != nil {
+				return existingConfig, append(errs, err)
+			}
+		}
+	}
+
+	if len(o.paths.AdditionalTrustedCA) > 0 {
+		source := resourcesynccontroller.ResourceLocation{}
+		if name := imageConfig.Spec.AdditionalTrustedCA.Name; len(name) > 0 {
+			source = resourcesynccontroller.ResourceLocation{Namespace: globalConfigNamespace, Name: name}
+		}
+		if err := genericListers.ResourceSyncer().SyncConfigMap(o.trustedCADestination, source); err != nil {
+			return existingConfig, append(errs, err)
+		}
+		if len(source.Name) > 0 {
+			if err := unstructured.SetNestedField(observedConfig, o.trustedCADestination.Name, o.paths.AdditionalTrustedCA...); err != nil {
+				return existingConfig, append(errs, err)
+			}
+		}
+	}
+
+	for _, path := range paths {
+		existing, _, _ := unstructured.NestedFieldNoCopy(existingConfig, path...)
+		observed, _, _ := unstructured.NestedFieldNoCopy(observedConfig, path...)
+		if !equality.Semantic.DeepEqual(existing, observed) {
+			recorder.Eventf("ObserveImageConfig", "%v changed to %v", path, observed)
+		}
+	}
+
+	return observedConfig, errs
+}
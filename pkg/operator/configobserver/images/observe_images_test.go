@@ -0,0 +1,132 @@
+package images
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resourcesynccontroller"
+)
+
+type fakeResourceSyncer struct {
+	configMaps map[resourcesynccontroller.ResourceLocation]resourcesynccontroller.ResourceLocation
+}
+
+func (s *fakeResourceSyncer) SyncConfigMap(destination, source resourcesynccontroller.ResourceLocation) error {
+	s.configMaps[destination] = source
+	return nil
+}
+
+func (s *fakeResourceSyncer) SyncSecret(destination, source resourcesynccontroller.ResourceLocation) error {
+	return nil
+}
+
+type testLister struct {
+	lister configlistersv1.ImageLister
+	syncer resourcesynccontroller.ResourceSyncer
+}
+
+func (l testLister) ImageConfigLister() configlistersv1.ImageLister {
+	return l.lister
+}
+
+func (l testLister) ResourceSyncer() resourcesynccontroller.ResourceSyncer {
+	return l.syncer
+}
+
+func (l testLister) PreRunHasSynced() []cache.InformerSynced {
+	return nil
+}
+
+func TestObserveImageConfig(t *testing.T) {
+	destination := resourcesynccontroller.ResourceLocation{Namespace: "openshift-operand", Name: "image-import-ca"}
+	paths := DefaultImageConfigPaths()
+	paths.AdditionalTrustedCA = []string{"imagePolicyConfig", "additionalTrustedCA"}
+
+	tests := []struct {
+		name           string
+		imageConfig    *configv1.Image
+		existingConfig map[string]interface{}
+		expectedConfig map[string]interface{}
+		expectedSource *resourcesynccontroller.ResourceLocation
+		expectedEvents int
+	}{
+		{
+			name:           "no image config",
+			existingConfig: map[string]interface{}{},
+			expectedConfig: map[string]interface{}{},
+		},
+		{
+			name: "all set",
+			imageConfig: &configv1.Image{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Spec: configv1.ImageSpec{
+					ExternalRegistryHostnames: []string{"registry.example.com", "default-route.example.com"},
+					AdditionalTrustedCA:       configv1.ConfigMapNameReference{Name: "registry-cas"},
+				},
+				Status: configv1.ImageStatus{
+					InternalRegistryHostname:  "image-registry.openshift-image-registry.svc:5000",
+					ExternalRegistryHostnames: []string{"default-route.example.com"},
+				},
+			},
+			existingConfig: map[string]interface{}{},
+			expectedConfig: map[string]interface{}{
+				"imagePolicyConfig": map[string]interface{}{
+					"internalRegistryHostname":  "image-registry.openshift-image-registry.svc:5000",
+					"externalRegistryHostnames": []interface{}{"registry.example.com", "default-route.example.com"},
+					"additionalTrustedCA":       "image-import-ca",
+				},
+			},
+			expectedSource: &resourcesynccontroller.ResourceLocation{Namespace: "openshift-config", Name: "registry-cas"},
+			expectedEvents: 3,
+		},
+		{
+			name:        "unset fields removed",
+			imageConfig: &configv1.Image{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}},
+			existingConfig: map[string]interface{}{
+				"imagePolicyConfig": map[string]interface{}{
+					"internalRegistryHostname": "image-registry.openshift-image-registry.svc:5000",
+					"additionalTrustedCA":      "image-import-ca",
+				},
+			},
+			expectedConfig: map[string]interface{}{},
+			expectedSource: &resourcesynccontroller.ResourceLocation{},
+			expectedEvents: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if tt.imageConfig != nil {
+				indexer.Add(tt.imageConfig)
+			}
+			syncer := &fakeResourceSyncer{configMaps: map[resourcesynccontroller.ResourceLocation]resourcesynccontroller.ResourceLocation{}}
+			listers := testLister{lister: configlistersv1.NewImageLister(indexer), syncer: syncer}
+			recorder := events.NewInMemoryRecorder("")
+
+			observedConfig, errs := NewObserveImageConfigFunc(paths, destination)(listers, recorder, tt.existingConfig)
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if !reflect.DeepEqual(observedConfig, tt.expectedConfig) {
+				t.Errorf("expected config %v, got %v", tt.expectedConfig, observedConfig)
+			}
+			source, synced := syncer.configMaps[destination]
+			switch {
+			case tt.expectedSource == nil && synced:
+				t.Errorf("unexpected sync of %v", source)
+			case tt.expectedSource != nil && (!synced || source != *tt.expectedSource):
+				t.Errorf("expected the trusted CA to be synced from %v, got %v", *tt.expectedSource, source)
+			}
+			if len(recorder.Events()) != tt.expectedEvents {
+				t.Errorf("expected %d events, got %v", tt.expectedEvents, recorder.Events())
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package configobserver
+
+import (
+	"time"
+
+	k8smetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	namespace = "config_observer"
+)
+
+// metrics provides access to all config observer metrics.
+var metrics *configObserverMetrics
+
+func init() {
+	metrics = newConfigObserverMetrics(legacyregistry.Register)
+}
+
+// configObserverMetrics instruments the config observers with prometheus metrics.
+type configObserverMetrics struct {
+	observationErrors     *k8smetrics.CounterVec
+	lastSuccessTimestamp  *k8smetrics.GaugeVec
+	observedConfigDrifted *k8smetrics.GaugeVec
+}
+
+// newConfigObserverMetrics creates a new configObserverMetrics, configured with default metric names.
+func newConfigObserverMetrics(registerFunc func(k8smetrics.Registerable) error) *configObserverMetrics {
+	observationErrors := k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: namespace,
+			Name:      "observation_errors_total",
+			Help:      "The number of errors returned by an observer, labeled with the config observer (its degraded condition type) and the observer",
+		}, []string{"controller", "observer"})
+	registerFunc(observationErrors)
+
+	lastSuccessTimestamp := k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_successful_observation_timestamp_seconds",
+			Help:      "The time of the last observation of a config observer without any error, labeled with the config observer (its degraded condition type)",
+		}, []string{"controller"})
+	registerFunc(lastSuccessTimestamp)
+
+	observedConfigDrifted := k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: namespace,
+			Name:      "observed_config_drifted",
+			Help:      "1 when the observed config differs from the config applied to the operand, 0 otherwise, labeled with the drift controller",
+		}, []string{"controller"})
+	registerFunc(observedConfigDrifted)
+
+	return &configObserverMetrics{
+		observationErrors:     observationErrors,
+		lastSuccessTimestamp:  lastSuccessTimestamp,
+		observedConfigDrifted: observedConfigDrifted,
+	}
+}
+
+// ObserveErrors counts the errors of an observer.
+func (m *configObserverMetrics) ObserveErrors(controller, observer string, errors int) {
+	if errors > 0 {
+		m.observationErrors.WithLabelValues(controller, observer).Add(float64(errors))
+	}
+}
+
+// ObserveSuccess records the time of a successful observation.
+func (m *configObserverMetrics) ObserveSuccess(controller string, now time.Time) {
+	m.lastSuccessTimestamp.WithLabelValues(controller).Set(float64(now.Unix()))
+}
+
+// ObserveDrift records whether the observed config differs from the applied one.
+func (m *configObserverMetrics) ObserveDrift(controller string, drifted bool) {
+	value := 0.0
+	if drifted {
+		value = 1.0
+	}
+	m.observedConfigDrifted.WithLabelValues(controller).Set(value)
+}
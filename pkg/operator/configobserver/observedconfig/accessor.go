@@ -0,0 +1,182 @@
+package observedconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/configobserver/etcd"
+)
+
+var (
+	// ServingInfoMinTLSVersionPath and ServingInfoCipherSuitesPath are set by apiserver.ObserveTLSSecurityProfile.
+	ServingInfoMinTLSVersionPath = []string{"servingInfo", "minTLSVersion"}
+	ServingInfoCipherSuitesPath  = []string{"servingInfo", "cipherSuites"}
+
+	// ArgumentsMinTLSVersionPath and ArgumentsCipherSuitesPath are set by apiserver.ObserveTLSSecurityProfileToArguments.
+	ArgumentsMinTLSVersionPath = []string{"apiServerArguments", "tls-min-version"}
+	ArgumentsCipherSuitesPath  = []string{"apiServerArguments", "tls-cipher-suites"}
+
+	// AuditPolicyFilePath is set by apiserver.NewAuditObserver.
+	AuditPolicyFilePath = []string{"apiServerArguments", "audit-policy-file"}
+)
+
+// TLSProfile is the observed TLS configuration of a server.
+type TLSProfile struct {
+	// MinTLSVersion is the name of the minimal TLS version, e.g. VersionTLS12.
+	MinTLSVersion string
+	// CipherSuites are the IANA names of the cipher suites.
+	CipherSuites []string
+}
+
+// DefaultTLSProfile returns the TLS configuration of the Intermediate profile, the one observed when the
+// APIServer config doesn't set a profile.
+func DefaultTLSProfile() TLSProfile {
+	spec := configv1.TLSProfiles[configv1.TLSProfileIntermediateType]
+	return TLSProfile{
+		MinTLSVersion: string(spec.MinTLSVersion),
+		CipherSuites:  crypto.OpenSSLToIANACipherSuites(spec.Ciphers),
+	}
+}
+
+// Accessor provides typed getters of well-known paths of an observed config, as written by the observers of this
+// repository, so that operands and controllers don't read them with unstructured.Nested* calls. A missing path
+// is not an error, the getters default it; a path of an unexpected type is.
+// The accessor never modifies the observed config.
+type Accessor struct {
+	config map[string]interface{}
+}
+
+// NewAccessor returns an accessor of an observed config. A nil config is an empty one.
+func NewAccessor(config map[string]interface{}) *Accessor {
+	if config == nil {
+		config = map[string]interface{}{}
+	}
+	return &Accessor{config: config}
+}
+
+// NewAccessorFromRaw returns an accessor of an observed config in JSON, like operatorSpec.ObservedConfig.Raw.
+// Empty raw data is an empty config.
+func NewAccessorFromRaw(raw []byte) (*Accessor, error) {
+	config := map[string]interface{}{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &config); err != nil {
+			return nil, fmt.Errorf("failed to decode the observed config: %w", err)
+		}
+	}
+	return NewAccessor(config), nil
+}
+
+// String returns the string at path, and whether it is set. A list of a single string is accepted too,
+// because apiServerArguments are lists even when they take a single value.
+func (a *Accessor) String(path ...string) (string, bool, error) {
+	value, found, err := unstructured.NestedFieldNoCopy(a.config, path...)
+	if err != nil || !found {
+		return "", false, pathError(path, err)
+	}
+	switch v := value.(type) {
+	case string:
+		return v, true, nil
+	case []interface{}:
+		if len(v) == 1 {
+			if s, ok := v[0].(string); ok {
+				return s, true, nil
+			}
+		}
+	case []string:
+		if len(v) == 1 {
+			return v[0], true, nil
+		}
+	}
+	return "", false, pathError(path, fmt.Errorf("expected a string, got %v of type %T", value, value))
+}
+
+// StringSlice returns a copy of the list of strings at path, and whether it is set.
+func (a *Accessor) StringSlice(path ...string) ([]string, bool, error) {
+	value, found, err := unstructured.NestedFieldNoCopy(a.config, path...)
+	if err != nil || !found {
+		return nil, false, pathError(path, err)
+	}
+	switch v := value.(type) {
+	case []string:
+		return append([]string{}, v...), true, nil
+	case []interface{}:
+		ret := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false, pathError(path, fmt.Errorf("expected a list of strings, got an item %v of type %T", item, item))
+			}
+			ret = append(ret, s)
+		}
+		return ret, true, nil
+	}
+	return nil, false, pathError(path, fmt.Errorf("expected a list of strings, got %v of type %T", value, value))
+}
+
+// ServingTLSProfile returns the TLS configuration observed in servingInfo, defaulted by DefaultTLSProfile.
+func (a *Accessor) ServingTLSProfile() (TLSProfile, error) {
+	return a.tlsProfile(ServingInfoMinTLSVersionPath, ServingInfoCipherSuitesPath)
+}
+
+// ArgumentsTLSProfile returns the TLS configuration observed in apiServerArguments, defaulted by DefaultTLSProfile.
+func (a *Accessor) ArgumentsTLSProfile() (TLSProfile, error) {
+	return a.tlsProfile(ArgumentsMinTLSVersionPath, ArgumentsCipherSuitesPath)
+}
+
+func (a *Accessor) tlsProfile(minTLSVersionPath, cipherSuitesPath []string) (TLSProfile, error) {
+	profile := DefaultTLSProfile()
+	minTLSVersion, found, err := a.String(minTLSVersionPath...)
+	if err != nil {
+		return TLSProfile{}, err
+	}
+	if found && len(minTLSVersion) > 0 {
+		profile.MinTLSVersion = minTLSVersion
+	}
+	cipherSuites, found, err := a.StringSlice(cipherSuitesPath...)
+	if err != nil {
+		return TLSProfile{}, err
+	}
+	if found && len(cipherSuites) > 0 {
+		profile.CipherSuites = cipherSuites
+	}
+	return profile, nil
+}
+
+// AuditPolicyFile returns the observed path of the audit policy file, or defaultPath when it is not observed.
+func (a *Accessor) AuditPolicyFile(defaultPath string) (string, error) {
+	auditPolicyFile, found, err := a.String(AuditPolicyFilePath...)
+	if err != nil {
+		return "", err
+	}
+	if !found || len(auditPolicyFile) == 0 {
+		return defaultPath, nil
+	}
+	return auditPolicyFile, nil
+}
+
+// EtcdServers returns the observed etcd endpoints, from apiServerArguments or from the storageConfig of older
+// operators, in that order. It returns an error when neither is set: an apiserver cannot start without them.
+func (a *Accessor) EtcdServers() ([]string, error) {
+	for _, path := range [][]string{etcd.StorageConfigURLsPath, etcd.OldStorageConfigURLsPath} {
+		urls, found, err := a.StringSlice(path...)
+		if err != nil {
+			return nil, err
+		}
+		if found && len(urls) > 0 {
+			return urls, nil
+		}
+	}
+	return nil, fmt.Errorf("no etcd endpoints observed in %s or %s", strings.Join(etcd.StorageConfigURLsPath, "."), strings.Join(etcd.OldStorageConfigURLsPath, "."))
+}
+
+func pathError(path []string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("failed to read observedConfig.%s: %w", strings.Join(path, "."), err)
+}
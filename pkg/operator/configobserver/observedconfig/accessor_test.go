@@ -0,0 +1,112 @@
+package observedconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAccessor(t *testing.T) {
+	defaultProfile := DefaultTLSProfile()
+
+	tests := []struct {
+		name   string
+		config string
+
+		expectServingTLS   TLSProfile
+		expectArgumentsTLS TLSProfile
+		expectTLSErr       bool
+		expectAuditPolicy  string
+		expectAuditErr     bool
+		expectEtcdServers  []string
+		expectEtcdErr      bool
+	}{
+		{
+			name:               "empty",
+			config:             "",
+			expectServingTLS:   defaultProfile,
+			expectArgumentsTLS: defaultProfile,
+			expectAuditPolicy:  "/default/policy.yaml",
+			expectEtcdErr:      true,
+		},
+		{
+			name: "all set",
+			config: `{
+				"servingInfo": {"minTLSVersion": "VersionTLS13", "cipherSuites": ["TLS_AES_128_GCM_SHA256"]},
+				"apiServerArguments": {
+					"tls-min-version": "VersionTLS11",
+					"tls-cipher-suites": ["TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"],
+					"audit-policy-file": ["/etc/audit/policy.yaml"],
+					"etcd-servers": ["https://10.0.0.1:2379", "https://10.0.0.2:2379"]
+				}
+			}`,
+			expectServingTLS:   TLSProfile{MinTLSVersion: "VersionTLS13", CipherSuites: []string{"TLS_AES_128_GCM_SHA256"}},
+			expectArgumentsTLS: TLSProfile{MinTLSVersion: "VersionTLS11", CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}},
+			expectAuditPolicy:  "/etc/audit/policy.yaml",
+			expectEtcdServers:  []string{"https://10.0.0.1:2379", "https://10.0.0.2:2379"},
+		},
+		{
+			name:               "partial TLS and old storage config",
+			config:             `{"servingInfo": {"minTLSVersion": "VersionTLS13"}, "storageConfig": {"urls": ["https://10.0.0.3:2379"]}}`,
+			expectServingTLS:   TLSProfile{MinTLSVersion: "VersionTLS13", CipherSuites: defaultProfile.CipherSuites},
+			expectArgumentsTLS: defaultProfile,
+			expectAuditPolicy:  "/default/policy.yaml",
+			expectEtcdServers:  []string{"https://10.0.0.3:2379"},
+		},
+		{
+			name:              "invalid types",
+			config:            `{"servingInfo": {"minTLSVersion": 12}, "apiServerArguments": {"audit-policy-file": ["a", "b"], "etcd-servers": "https://10.0.0.1:2379"}}`,
+			expectTLSErr:      true,
+			expectAuditErr:    true,
+			expectEtcdErr:     true,
+			expectAuditPolicy: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			accessor, err := NewAccessorFromRaw([]byte(tc.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			servingTLS, err := accessor.ServingTLSProfile()
+			if (err != nil) != tc.expectTLSErr {
+				t.Errorf("expected TLS error %v, got %v", tc.expectTLSErr, err)
+			}
+			if !tc.expectTLSErr {
+				if !reflect.DeepEqual(servingTLS, tc.expectServingTLS) {
+					t.Errorf("expected serving TLS profile %v, got %v", tc.expectServingTLS, servingTLS)
+				}
+				argumentsTLS, err := accessor.ArgumentsTLSProfile()
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(argumentsTLS, tc.expectArgumentsTLS) {
+					t.Errorf("expected arguments TLS profile %v, got %v", tc.expectArgumentsTLS, argumentsTLS)
+				}
+			}
+
+			auditPolicy, err := accessor.AuditPolicyFile("/default/policy.yaml")
+			if (err != nil) != tc.expectAuditErr {
+				t.Errorf("expected audit error %v, got %v", tc.expectAuditErr, err)
+			}
+			if auditPolicy != tc.expectAuditPolicy {
+				t.Errorf("expected audit policy file %q, got %q", tc.expectAuditPolicy, auditPolicy)
+			}
+
+			etcdServers, err := accessor.EtcdServers()
+			if (err != nil) != tc.expectEtcdErr {
+				t.Errorf("expected etcd error %v, got %v", tc.expectEtcdErr, err)
+			}
+			if !reflect.DeepEqual(etcdServers, tc.expectEtcdServers) {
+				t.Errorf("expected etcd servers %v, got %v", tc.expectEtcdServers, etcdServers)
+			}
+		})
+	}
+}
+
+func TestAccessorInvalidRaw(t *testing.T) {
+	if _, err := NewAccessorFromRaw([]byte("{")); err == nil {
+		t.Error("expected an error")
+	}
+}
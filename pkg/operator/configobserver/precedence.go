@@ -0,0 +1,110 @@
+package configobserver
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// ConfigSource is one source of a setting observed by a PrecedenceObserver.
+type ConfigSource struct {
+	// Name identifies the source in events and reasons, e.g. "operator override" or "cluster config".
+	Name string
+	// Observe observes the setting from this source. An observation without any of the paths of the
+	// PrecedenceObserver means that the source does not set it.
+	Observe ObserveConfigFunc
+}
+
+// PrecedenceObserver observes a setting that can come from multiple sources, e.g. a per-operator override in the
+// operator CR of a cluster-wide default in the config.openshift.io API. The first source in the precedence chain
+// that sets the setting wins, the sources after it are not observed.
+type PrecedenceObserver struct {
+	paths   [][]string
+	sources []ConfigSource
+
+	lock   sync.Mutex
+	source string
+	reason string
+}
+
+// NewPrecedenceObserver returns an observer of the given paths from sources, highest precedence first.
+// Pass its ObserveConfig method to the config observer:
+//
+//	precedence := configobserver.NewPrecedenceObserver([][]string{{"servingInfo", "minTLSVersion"}},
+//		configobserver.ConfigSource{Name: "operator override", Observe: observeOverride},
+//		configobserver.ConfigSource{Name: "cluster config", Observe: apiserver.ObserveTLSSecurityProfile},
+//	)
+func NewPrecedenceObserver(paths [][]string, sources ...ConfigSource) *PrecedenceObserver {
+	if len(paths) == 0 || len(sources) == 0 {
+		panic("precedence observer: both paths and sources must be set")
+	}
+	return &PrecedenceObserver{
+		paths:   paths,
+		sources: sources,
+	}
+}
+
+// ObserveConfig observes the sources in order and returns the observation of the first one setting any of the paths.
+// When a source fails before a winner is found, the existing config is kept: falling back to a source of lower
+// precedence could revert an override on a transient error.
+func (o *PrecedenceObserver) ObserveConfig(listers Listers, recorder events.Recorder, existingConfig map[string]interface{}) (ret map[string]interface{}, _ []error) {
+	defer func() {
+		ret = Pruned(ret, o.paths...)
+	}()
+
+	var unset []string
+	for _, source := range o.sources {
+		observed, errs := source.Observe(listers, recorder, existingConfig)
+		if len(errs) > 0 {
+			wrapped := make([]error, 0, len(errs))
+			for _, err := range errs {
+				wrapped = append(wrapped, fmt.Errorf("%s: %w", source.Name, err))
+			}
+			return existingConfig, wrapped
+		}
+
+		observed = Pruned(observed, o.paths...)
+		if len(observed) == 0 {
+			unset = append(unset, source.Name)
+			continue
+		}
+
+		reason := fmt.Sprintf("set by %s", source.Name)
+		if len(unset) > 0 {
+			reason = fmt.Sprintf("%s, not set by %s", reason, strings.Join(unset, ", "))
+		}
+		o.setSource(recorder, source.Name, reason)
+		return observed, nil
+	}
+
+	o.setSource(recorder, "", fmt.Sprintf("not set by %s", strings.Join(unset, ", ")))
+	return map[string]interface{}{}, nil
+}
+
+// ObservedSource returns the name of the source that won the last observation, empty if none set the paths, and
+// the reason.
+func (o *PrecedenceObserver) ObservedSource() (source, reason string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	return o.source, o.reason
+}
+
+func (o *PrecedenceObserver) setSource(recorder events.Recorder, source, reason string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if o.source == source && o.reason == reason {
+		return
+	}
+	o.source = source
+	o.reason = reason
+
+	paths := make([]string, 0, len(o.paths))
+	for _, path := range o.paths {
+		paths = append(paths, strings.Join(path, "."))
+	}
+	recorder.Eventf("ObservedConfigSourceChanged", "%s: %s", strings.Join(paths, ", "), reason)
+}
@@ -0,0 +1,115 @@
+package configobserver
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestPrecedenceObserver(t *testing.T) {
+	path := []string{"servingInfo", "minTLSVersion"}
+	setTo := func(value string) ObserveConfigFunc {
+		return func(_ Listers, _ events.Recorder, _ map[string]interface{}) (map[string]interface{}, []error) {
+			return map[string]interface{}{
+				"servingInfo": map[string]interface{}{"minTLSVersion": value},
+				"unrelated":   "dropped",
+			}, nil
+		}
+	}
+	unset := func(_ Listers, _ events.Recorder, _ map[string]interface{}) (map[string]interface{}, []error) {
+		return map[string]interface{}{}, nil
+	}
+	failing := func(_ Listers, _ events.Recorder, _ map[string]interface{}) (map[string]interface{}, []error) {
+		return nil, []error{fmt.Errorf("lister failed")}
+	}
+	existingConfig := map[string]interface{}{"servingInfo": map[string]interface{}{"minTLSVersion": "VersionTLS11"}}
+
+	tests := []struct {
+		name         string
+		sources      []ConfigSource
+		expected     map[string]interface{}
+		expectErrors bool
+		expectSource string
+		expectReason string
+	}{
+		{
+			name: "override wins",
+			sources: []ConfigSource{
+				{Name: "override", Observe: setTo("VersionTLS13")},
+				{Name: "cluster", Observe: setTo("VersionTLS12")},
+			},
+			expected:     map[string]interface{}{"servingInfo": map[string]interface{}{"minTLSVersion": "VersionTLS13"}},
+			expectSource: "override",
+			expectReason: "set by override",
+		},
+		{
+			name: "falls back to cluster",
+			sources: []ConfigSource{
+				{Name: "override", Observe: unset},
+				{Name: "cluster", Observe: setTo("VersionTLS12")},
+			},
+			expected:     map[string]interface{}{"servingInfo": map[string]interface{}{"minTLSVersion": "VersionTLS12"}},
+			expectSource: "cluster",
+			expectReason: "set by cluster, not set by override",
+		},
+		{
+			name: "none set",
+			sources: []ConfigSource{
+				{Name: "override", Observe: unset},
+				{Name: "cluster", Observe: unset},
+			},
+			expected:     map[string]interface{}{},
+			expectReason: "not set by override, cluster",
+		},
+		{
+			name: "error keeps existing config",
+			sources: []ConfigSource{
+				{Name: "override", Observe: failing},
+				{Name: "cluster", Observe: setTo("VersionTLS12")},
+			},
+			expected:     existingConfig,
+			expectErrors: true,
+		},
+		{
+			name: "error of a lower precedence source is not observed",
+			sources: []ConfigSource{
+				{Name: "override", Observe: setTo("VersionTLS13")},
+				{Name: "cluster", Observe: failing},
+			},
+			expected:     map[string]interface{}{"servingInfo": map[string]interface{}{"minTLSVersion": "VersionTLS13"}},
+			expectSource: "override",
+			expectReason: "set by override",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := events.NewInMemoryRecorder("test")
+			observer := NewPrecedenceObserver([][]string{path}, tc.sources...)
+
+			observed, errs := observer.ObserveConfig(nil, recorder, existingConfig)
+			if (len(errs) > 0) != tc.expectErrors {
+				t.Errorf("expected errors %v, got %v", tc.expectErrors, errs)
+			}
+			if !reflect.DeepEqual(observed, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, observed)
+			}
+			source, reason := observer.ObservedSource()
+			if source != tc.expectSource || reason != tc.expectReason {
+				t.Errorf("expected source %q with reason %q, got %q with %q", tc.expectSource, tc.expectReason, source, reason)
+			}
+
+			// the source is only reported when it changes
+			observer.ObserveConfig(nil, recorder, existingConfig)
+			expectedEvents := 1
+			if tc.expectErrors {
+				expectedEvents = 0
+			}
+			if got := len(recorder.Events()); got != expectedEvents {
+				t.Errorf("expected %d events, got %d: %v", expectedEvents, got, recorder.Events())
+			}
+		})
+	}
+}
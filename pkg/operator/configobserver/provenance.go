@@ -0,0 +1,146 @@
+package configobserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PathProvenance describes which observer set a path of the observed config.
+type PathProvenance struct {
+	Path     string `json:"path"`
+	Observer string `json:"observer"`
+	// LastChanged is the time the observer or the value of the path last changed, as seen by this process.
+	LastChanged metav1.Time `json:"lastChanged"`
+
+	value interface{}
+}
+
+// configProvenance holds the provenance of the observed config of a single config observer.
+type configProvenance struct {
+	lock  sync.RWMutex
+	paths map[string]PathProvenance
+}
+
+// provenances holds the provenance of all config observers of the process, by their degraded condition type.
+var provenances = struct {
+	lock      sync.RWMutex
+	observers map[string]*configProvenance
+}{observers: map[string]*configProvenance{}}
+
+func registerProvenance(name string) *configProvenance {
+	provenances.lock.Lock()
+	defer provenances.lock.Unlock()
+
+	p := &configProvenance{paths: map[string]PathProvenance{}}
+	provenances.observers[name] = p
+	return p
+}
+
+// observedLeaf is a leaf value of the observed config set by an observer.
+type observedLeaf struct {
+	path     []string
+	observer string
+	value    interface{}
+}
+
+// leaves returns the leaf values of an observation. Lists and empty objects are leaves.
+func leaves(observer string, config map[string]interface{}, prefix []string) []observedLeaf {
+	var ret []observedLeaf
+	for key, value := range config {
+		path := append(append([]string{}, prefix...), key)
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			ret = append(ret, leaves(observer, nested, path)...)
+			continue
+		}
+		ret = append(ret, observedLeaf{path: path, observer: observer, value: value})
+	}
+	return ret
+}
+
+// detectConflicts returns the leaves of the observations by path and errors for every path that is set by two observers
+// to different values, or that is set by one observer and nested below by another.
+func detectConflicts(observations []observedLeaf) (map[string]observedLeaf, []error) {
+	byPath := map[string]observedLeaf{}
+	var errs []error
+	for _, leaf := range observations {
+		path := strings.Join(leaf.path, ".")
+		if other, ok := byPath[path]; ok && other.observer != leaf.observer && !equality.Semantic.DeepEqual(other.value, leaf.value) {
+			errs = append(errs, fmt.Errorf("observers %s and %s set %s to different values: %v and %v", other.observer, leaf.observer, path, other.value, leaf.value))
+			continue
+		}
+		byPath[path] = leaf
+	}
+	for path, leaf := range byPath {
+		for i := 1; i < len(leaf.path); i++ {
+			prefix := strings.Join(leaf.path[:i], ".")
+			if other, ok := byPath[prefix]; ok && other.observer != leaf.observer {
+				errs = append(errs, fmt.Errorf("observer %s sets %s that is nested in %s set by observer %s", leaf.observer, path, prefix, other.observer))
+			}
+		}
+	}
+	// map iteration above is random, make the errors stable
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return byPath, errs
+}
+
+// update records the provenance of the given leaves, keeping the time of the paths whose observer and value didn't change.
+func (p *configProvenance) update(byPath map[string]observedLeaf, now time.Time) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	paths := make(map[string]PathProvenance, len(byPath))
+	for path, leaf := range byPath {
+		if existing, ok := p.paths[path]; ok && existing.Observer == leaf.observer && equality.Semantic.DeepEqual(existing.value, leaf.value) {
+			paths[path] = existing
+			continue
+		}
+		paths[path] = PathProvenance{Path: path, Observer: leaf.observer, LastChanged: metav1.NewTime(now), value: leaf.value}
+	}
+	p.paths = paths
+}
+
+func (p *configProvenance) list() []PathProvenance {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	ret := make([]PathProvenance, 0, len(p.paths))
+	for _, path := range p.paths {
+		ret = append(ret, path)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Path < ret[j].Path })
+	return ret
+}
+
+// NewProvenanceDebugHandler returns a handler serving which observer set which path of the observed config,
+// for all config observers of the process, keyed by their degraded condition type.
+func NewProvenanceDebugHandler() http.Handler {
+	return &provenanceDebugHTTPHandler{}
+}
+
+type provenanceDebugHTTPHandler struct{}
+
+func (h *provenanceDebugHTTPHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	provenances.lock.RLock()
+	all := make(map[string][]PathProvenance, len(provenances.observers))
+	for name, p := range provenances.observers {
+		all[name] = p.list()
+	}
+	provenances.lock.RUnlock()
+
+	data, err := json.Marshal(all)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
@@ -0,0 +1,115 @@
+package configobserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestDetectConflicts(t *testing.T) {
+	tests := []struct {
+		name           string
+		observations   map[string]map[string]interface{}
+		expectedPaths  []string
+		expectedErrors []string
+	}{
+		{
+			name: "disjoint paths",
+			observations: map[string]map[string]interface{}{
+				"a": {"servingInfo": map[string]interface{}{"minTLSVersion": "VersionTLS12"}},
+				"b": {"servingInfo": map[string]interface{}{"cipherSuites": []interface{}{"x"}}},
+			},
+			expectedPaths: []string{"servingInfo.cipherSuites", "servingInfo.minTLSVersion"},
+		},
+		{
+			name: "same value",
+			observations: map[string]map[string]interface{}{
+				"a": {"foo": "bar"},
+				"b": {"foo": "bar"},
+			},
+			expectedPaths: []string{"foo"},
+		},
+		{
+			name: "different values",
+			observations: map[string]map[string]interface{}{
+				"a": {"foo": "bar"},
+				"b": {"foo": "baz"},
+			},
+			expectedPaths:  []string{"foo"},
+			expectedErrors: []string{"observers a and b set foo to different values: bar and baz"},
+		},
+		{
+			name: "nested path",
+			observations: map[string]map[string]interface{}{
+				"a": {"foo": "bar"},
+				"b": {"foo": map[string]interface{}{"nested": "baz"}},
+			},
+			expectedPaths:  []string{"foo", "foo.nested"},
+			expectedErrors: []string{"observer b sets foo.nested that is nested in foo set by observer a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var observed []observedLeaf
+			for _, observer := range []string{"a", "b"} {
+				observed = append(observed, leaves(observer, tt.observations[observer], nil)...)
+			}
+			byPath, errs := detectConflicts(observed)
+
+			var paths []string
+			for path := range byPath {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			if !reflect.DeepEqual(paths, tt.expectedPaths) {
+				t.Errorf("expected paths %v, got %v", tt.expectedPaths, paths)
+			}
+			var errStrings []string
+			for _, err := range errs {
+				errStrings = append(errStrings, err.Error())
+			}
+			if !reflect.DeepEqual(errStrings, tt.expectedErrors) {
+				t.Errorf("expected errors %v, got %v", tt.expectedErrors, errStrings)
+			}
+		})
+	}
+}
+
+func TestProvenanceDebugHandler(t *testing.T) {
+	p := registerProvenance("TestProvenanceConfigObservationDegraded")
+	first := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+
+	observe := func(foo, bar string, now time.Time) {
+		byPath, _ := detectConflicts(append(
+			leaves("a", map[string]interface{}{"foo": foo}, nil),
+			leaves("b", map[string]interface{}{"bar": bar}, nil)...,
+		))
+		p.update(byPath, now)
+	}
+	observe("1", "1", first)
+	// only the changed value gets a new time
+	observe("1", "2", second)
+
+	writer := httptest.NewRecorder()
+	NewProvenanceDebugHandler().ServeHTTP(writer, &http.Request{})
+	all := map[string][]PathProvenance{}
+	if err := json.Unmarshal(writer.Body.Bytes(), &all); err != nil {
+		t.Fatal(err)
+	}
+	got := all["TestProvenanceConfigObservationDegraded"]
+	if len(got) != 2 {
+		t.Fatalf("expected 2 paths, got %v", got)
+	}
+	if got[0].Path != "bar" || got[0].Observer != "b" || !got[0].LastChanged.Time.Equal(second) {
+		t.Errorf("unexpected provenance of bar: %#v", got[0])
+	}
+	if got[1].Path != "foo" || got[1].Observer != "a" || !got[1].LastChanged.Time.Equal(first) {
+		t.Errorf("unexpected provenance of foo: %#v", got[1])
+	}
+}
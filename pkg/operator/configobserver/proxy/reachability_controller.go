@@ -0,0 +1,128 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1informers "github.com/openshift/client-go/config/informers/externalversions/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// ProxyUnreachableConditionType is the default condition reporting an HTTPS proxy that cannot be reached.
+const ProxyUnreachableConditionType = "ProxyUnreachableDegraded"
+
+const defaultProxyDialTimeout = 5 * time.Second
+
+type reachabilityController struct {
+	operatorClient v1helpers.OperatorClient
+	proxyLister    configlistersv1.ProxyLister
+	conditionType  string
+	dial           func(ctx context.Context, address string) error
+}
+
+// NewReachabilityController returns a controller which probes the HTTPS proxy of proxy.config.openshift.io/cluster
+// with a TCP connection from the operator, every minute and on proxy changes. The result is reported in the
+// conditionType condition of operatorClient (ProxyUnreachableConditionType when empty): True when the proxy cannot be
+// reached, False when it can or when no HTTPS proxy is configured. A bad proxy setting otherwise only shows up as image
+// pull or webhook failures of the operands.
+//
+// The probe runs apart from the config observation, so that an unreachable proxy does not delay the observed config.
+func NewReachabilityController(operatorClient v1helpers.OperatorClient, proxyInformer configv1informers.ProxyInformer, conditionType string, recorder events.Recorder) factory.Controller {
+	if len(conditionType) == 0 {
+		conditionType = ProxyUnreachableConditionType
+	}
+	c := &reachabilityController{
+		operatorClient: operatorClient,
+		proxyLister:    proxyInformer.Lister(),
+		conditionType:  conditionType,
+		dial: func(ctx context.Context, address string) error {
+			dialer := &net.Dialer{Timeout: defaultProxyDialTimeout}
+			conn, err := dialer.DialContext(ctx, "tcp", address)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		},
+	}
+	return factory.New().
+		WithInformers(operatorClient.Informer(), proxyInformer.Informer()).
+		WithSync(c.sync).
+		ResyncEvery(time.Minute).
+		ToController("ProxyReachabilityController", recorder.WithComponentSuffix("proxy-reachability-controller"))
+}
+
+func (c *reachabilityController) sync(ctx context.Context, _ factory.SyncContext) error {
+	var httpsProxy string
+	proxyConfig, err := c.proxyLister.Get("cluster")
+	switch {
+	case errors.IsNotFound(err):
+	case err != nil:
+		return err
+	default:
+		httpsProxy = proxyConfig.Status.HTTPSProxy
+	}
+
+	cond := operatorv1.OperatorCondition{
+		Type:   c.conditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	if len(httpsProxy) == 0 {
+		cond.Reason = "NoHTTPSProxy"
+	} else if address, err := proxyAddress(httpsProxy); err != nil {
+		cond.Status = operatorv1.ConditionTrue
+		cond.Reason = "InvalidProxyURL"
+		cond.Message = err.Error()
+	} else if err := c.dial(ctx, address); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		cond.Status = operatorv1.ConditionTrue
+		cond.Reason = "ProxyUnreachable"
+		cond.Message = fmt.Sprintf("HTTPS proxy %s is not reachable from the operator: %v", address, err)
+	}
+
+	_, _, err = v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(cond))
+	return err
+}
+
+// proxyAddress returns the host:port of a proxy URL. The port defaults to the one of the scheme,
+// a URL without a scheme is an HTTP proxy. The errors do not contain the credentials of the URL.
+func proxyAddress(proxyURL string) (string, error) {
+	if !strings.Contains(proxyURL, "://") {
+		proxyURL = "http://" + proxyURL
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		// the error of url.Parse quotes the URL
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return "", fmt.Errorf("failed to parse HTTPS proxy: %w", err)
+	}
+	if len(u.Hostname()) == 0 {
+		return "", fmt.Errorf("HTTPS proxy %q has no host", u.Redacted())
+	}
+	port := u.Port()
+	if len(port) == 0 {
+		switch u.Scheme {
+		case "https":
+			port = "443"
+		case "http":
+			port = "80"
+		default:
+			return "", fmt.Errorf("HTTPS proxy %q has an unsupported scheme %q", u.Redacted(), u.Scheme)
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
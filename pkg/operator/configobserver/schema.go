@@ -0,0 +1,188 @@
+package configobserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// pruneAndValidateObservation returns a copy of observedConfig without the fields unknown to the schema of the
+// config observer, and the validation errors of the copy. The copy goes through JSON, so that its values are
+// normalized the way the observed config is read back, and observations sharing data with the existing config
+// are not modified.
+func (c ConfigObserver) pruneAndValidateObservation(observedConfig map[string]interface{}) (map[string]interface{}, error) {
+	if observedConfig == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(observedConfig)
+	if err != nil {
+		return observedConfig, err
+	}
+	copied := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &copied); err != nil {
+		return observedConfig, err
+	}
+	return copied, pruneAndValidate(c.schema, copied, field.NewPath("observedConfig")).ToAggregate()
+}
+
+// pruneAndValidate removes the fields unknown to the structural schema from value, in place, and validates
+// the remaining fields. A nil schema accepts anything.
+// Supported are type, properties, additionalProperties, items, required, enum, pattern, nullable and
+// x-kubernetes-preserve-unknown-fields.
+func pruneAndValidate(schema *apiextensionsv1.JSONSchemaProps, value interface{}, fldPath *field.Path) field.ErrorList {
+	if schema == nil {
+		return nil
+	}
+	if value == nil {
+		if schema.Nullable || len(schema.Type) == 0 {
+			return nil
+		}
+		return field.ErrorList{field.Invalid(fldPath, value, "must not be null")}
+	}
+
+	allErrs := field.ErrorList{}
+	switch schema.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return append(allErrs, field.Invalid(fldPath, value, "must be an object"))
+		}
+		allErrs = append(allErrs, pruneAndValidateObject(schema, obj, fldPath)...)
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return append(allErrs, field.Invalid(fldPath, value, "must be an array"))
+		}
+		if schema.Items != nil {
+			for i := range items {
+				allErrs = append(allErrs, pruneAndValidate(schema.Items.Schema, items[i], fldPath.Index(i))...)
+			}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return append(allErrs, field.Invalid(fldPath, value, "must be a string"))
+		}
+		if len(schema.Pattern) > 0 {
+			pattern, err := regexp.Compile(schema.Pattern)
+			if err != nil {
+				return append(allErrs, field.InternalError(fldPath, fmt.Errorf("invalid pattern %q: %w", schema.Pattern, err)))
+			}
+			if !pattern.MatchString(s) {
+				allErrs = append(allErrs, field.Invalid(fldPath, s, fmt.Sprintf("must match %q", schema.Pattern)))
+			}
+		}
+	case "integer":
+		if !isInteger(value) {
+			return append(allErrs, field.Invalid(fldPath, value, "must be an integer"))
+		}
+	case "number":
+		if !isInteger(value) && !isNumber(value) {
+			return append(allErrs, field.Invalid(fldPath, value, "must be a number"))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return append(allErrs, field.Invalid(fldPath, value, "must be a boolean"))
+		}
+	case "":
+		// untyped, e.g. x-kubernetes-int-or-string or preserved unknown fields
+		if obj, ok := value.(map[string]interface{}); ok && len(schema.Properties) > 0 {
+			allErrs = append(allErrs, pruneAndValidateObject(schema, obj, fldPath)...)
+		}
+	default:
+		return append(allErrs, field.InternalError(fldPath, fmt.Errorf("unsupported type %q", schema.Type)))
+	}
+
+	if len(schema.Enum) > 0 {
+		allErrs = append(allErrs, validateEnum(schema.Enum, value, fldPath)...)
+	}
+	return allErrs
+}
+
+func pruneAndValidateObject(schema *apiextensionsv1.JSONSchemaProps, obj map[string]interface{}, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	preserveUnknownFields := schema.XPreserveUnknownFields != nil && *schema.XPreserveUnknownFields
+
+	// sorted for stable error messages
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if propSchema, ok := schema.Properties[key]; ok {
+			allErrs = append(allErrs, pruneAndValidate(&propSchema, obj[key], fldPath.Child(key))...)
+			continue
+		}
+		if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+			allErrs = append(allErrs, pruneAndValidate(schema.AdditionalProperties.Schema, obj[key], fldPath.Key(key))...)
+			continue
+		}
+		if preserveUnknownFields || (schema.AdditionalProperties != nil && schema.AdditionalProperties.Allows) {
+			continue
+		}
+		delete(obj, key)
+	}
+
+	for _, required := range schema.Required {
+		if _, ok := obj[required]; !ok {
+			allErrs = append(allErrs, field.Required(fldPath.Child(required), ""))
+		}
+	}
+	return allErrs
+}
+
+func validateEnum(enum []apiextensionsv1.JSON, value interface{}, fldPath *field.Path) field.ErrorList {
+	allowed := make([]string, 0, len(enum))
+	for _, e := range enum {
+		var enumValue interface{}
+		if err := json.Unmarshal(e.Raw, &enumValue); err != nil {
+			return field.ErrorList{field.InternalError(fldPath, fmt.Errorf("invalid enum value %q: %w", string(e.Raw), err))}
+		}
+		if reflect.DeepEqual(normalizeNumber(enumValue), normalizeNumber(value)) {
+			return nil
+		}
+		allowed = append(allowed, string(e.Raw))
+	}
+	return field.ErrorList{field.NotSupported(fldPath, value, allowed)}
+}
+
+func isInteger(value interface{}) bool {
+	switch v := value.(type) {
+	case int, int32, int64:
+		return true
+	case float64:
+		// JSON decoding produces float64 for all numbers
+		return v == math.Trunc(v)
+	}
+	return false
+}
+
+func isNumber(value interface{}) bool {
+	switch value.(type) {
+	case float32, float64:
+		return true
+	}
+	return false
+}
+
+// normalizeNumber converts numbers to float64 to compare them regardless of their Go type.
+func normalizeNumber(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	}
+	return value
+}
@@ -0,0 +1,179 @@
+package configobserver
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/condition"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+var testSchema = &apiextensionsv1.JSONSchemaProps{
+	Type: "object",
+	Properties: map[string]apiextensionsv1.JSONSchemaProps{
+		"servingInfo": {
+			Type: "object",
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"minTLSVersion": {Type: "string", Pattern: "^VersionTLS1[0-3]$"},
+				"cipherSuites":  {Type: "array", Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}}},
+			},
+		},
+		"apiServerArguments": {
+			Type:                 "object",
+			AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{Schema: &apiextensionsv1.JSONSchemaProps{Type: "array"}},
+		},
+		"logLevel": {
+			Type: "string",
+			Enum: []apiextensionsv1.JSON{{Raw: []byte(`"Normal"`)}, {Raw: []byte(`"Debug"`)}},
+		},
+		"replicas": {Type: "integer"},
+		"extra": {
+			Type:                   "object",
+			XPreserveUnknownFields: boolPtr(true),
+		},
+	},
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestPruneAndValidate(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         map[string]interface{}
+		expectedConfig map[string]interface{}
+		expectedErrors []string
+	}{
+		{
+			name: "valid config",
+			config: map[string]interface{}{
+				"servingInfo":        map[string]interface{}{"minTLSVersion": "VersionTLS12", "cipherSuites": []interface{}{"a", "b"}},
+				"apiServerArguments": map[string]interface{}{"feature-gates": []interface{}{"A=true"}},
+				"logLevel":           "Debug",
+				"replicas":           float64(3),
+				"extra":              map[string]interface{}{"anything": "goes"},
+			},
+			expectedConfig: map[string]interface{}{
+				"servingInfo":        map[string]interface{}{"minTLSVersion": "VersionTLS12", "cipherSuites": []interface{}{"a", "b"}},
+				"apiServerArguments": map[string]interface{}{"feature-gates": []interface{}{"A=true"}},
+				"logLevel":           "Debug",
+				"replicas":           float64(3),
+				"extra":              map[string]interface{}{"anything": "goes"},
+			},
+		},
+		{
+			name: "unknown fields pruned",
+			config: map[string]interface{}{
+				"servingInfo": map[string]interface{}{"minTLSVersion": "VersionTLS12", "unknown": "x"},
+				"unknown":     map[string]interface{}{"a": "b"},
+			},
+			expectedConfig: map[string]interface{}{
+				"servingInfo": map[string]interface{}{"minTLSVersion": "VersionTLS12"},
+			},
+		},
+		{
+			name: "invalid fields",
+			config: map[string]interface{}{
+				"servingInfo":        map[string]interface{}{"minTLSVersion": "TLS1.2", "cipherSuites": []interface{}{"a", float64(1)}},
+				"apiServerArguments": map[string]interface{}{"feature-gates": "A=true"},
+				"logLevel":           "Trace",
+				"replicas":           1.5,
+			},
+			expectedErrors: []string{
+				"observedConfig.apiServerArguments[feature-gates]",
+				"observedConfig.logLevel",
+				"observedConfig.replicas",
+				"observedConfig.servingInfo.cipherSuites[1]",
+				"observedConfig.servingInfo.minTLSVersion",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := pruneAndValidate(testSchema, tt.config, field.NewPath("observedConfig"))
+			var errPaths []string
+			for _, err := range errs {
+				errPaths = append(errPaths, err.Field)
+			}
+			if !reflect.DeepEqual(errPaths, tt.expectedErrors) {
+				t.Errorf("expected errors at %v, got %v", tt.expectedErrors, errs)
+			}
+			if tt.expectedConfig != nil && !reflect.DeepEqual(tt.config, tt.expectedConfig) {
+				t.Errorf("expected config %v, got %v", tt.expectedConfig, tt.config)
+			}
+		})
+	}
+}
+
+func TestSyncWithSchema(t *testing.T) {
+	validObserver := func(listers Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		return map[string]interface{}{"logLevel": "Debug", "unknown": "pruned"}, nil
+	}
+	invalidObserver := func(listers Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		return map[string]interface{}{"replicas": "three"}, nil
+	}
+
+	tests := []struct {
+		name              string
+		observers         []ObserveConfigFunc
+		expectedConfig    map[string]interface{}
+		expectedCondition operatorv1.ConditionStatus
+		expectedMessage   string
+	}{
+		{
+			name:              "unknown fields pruned",
+			observers:         []ObserveConfigFunc{validObserver},
+			expectedConfig:    map[string]interface{}{"logLevel": "Debug"},
+			expectedCondition: operatorv1.ConditionFalse,
+		},
+		{
+			name:              "invalid observation rejected",
+			observers:         []ObserveConfigFunc{validObserver, invalidObserver},
+			expectedCondition: operatorv1.ConditionTrue,
+			expectedMessage:   `observer #1 (github.com/openshift/library-go/pkg/operator/configobserver.TestSyncWithSchema.func2) produced invalid config: observedConfig.replicas: Invalid value: "three": must be an integer`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{}, &operatorv1.OperatorStatus{}, nil)
+			configObserver := ConfigObserver{
+				listers:               &fakeLister{},
+				operatorClient:        operatorClient,
+				observers:             tt.observers,
+				degradedConditionType: condition.ConfigObservationDegradedConditionType,
+				schema:                testSchema,
+			}
+			recorder := events.NewInMemoryRecorder("test")
+			err := configObserver.sync(context.TODO(), factory.NewSyncContext("test", recorder))
+			if (err != nil) != (tt.expectedCondition == operatorv1.ConditionTrue) {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			spec, status, _, _ := operatorClient.GetOperatorState()
+			if tt.expectedConfig == nil {
+				if spec.ObservedConfig.Object != nil || spec.ObservedConfig.Raw != nil {
+					t.Errorf("expected the observed config not to be written, got %s", string(spec.ObservedConfig.Raw))
+				}
+			} else if !reflect.DeepEqual(spec.ObservedConfig.Object.(*unstructured.Unstructured).Object, tt.expectedConfig) {
+				t.Errorf("expected observed config %v, got %v", tt.expectedConfig, spec.ObservedConfig.Object)
+			}
+
+			cond := v1helpers.FindOperatorCondition(status.Conditions, condition.ConfigObservationDegradedConditionType)
+			if cond == nil || cond.Status != tt.expectedCondition || !strings.Contains(cond.Message, tt.expectedMessage) {
+				t.Errorf("unexpected condition %#v", cond)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package connectivitycheckcontroller
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/openshift/api/operatorcontrolplane/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// maxLogEntries is the number of successes and of failures kept in the status of a check.
+	maxLogEntries = 10
+	// maxOutageLogEntries is the number of start and of end logs kept in an outage.
+	maxOutageLogEntries = 5
+	// maxOutages is the number of outages kept in the status of a check.
+	maxOutages = 20
+)
+
+// RecordLogEntry records the result of a check in its status, the latest entries first:
+//   - the entry is added to the successes or to the failures, both keeping the last maxLogEntries entries,
+//   - a failure opens an outage unless one is in progress, a success ends the outage in progress,
+//     only the last maxOutages outages are kept,
+//   - the Reachable condition reflects the entry.
+//
+// The latency of the entry is also observed in the pod_network_connectivity_check_latency_seconds histogram, by check
+// type.
+func RecordLogEntry(check *v1alpha1.PodNetworkConnectivityCheck, entry v1alpha1.LogEntry) {
+	metrics.ObserveLatency(GetCheckType(check), entry.Success, entry.Latency.Seconds())
+
+	status := &check.Status
+	if entry.Success {
+		status.Successes = prependLogEntry(status.Successes, entry, maxLogEntries)
+	} else {
+		status.Failures = prependLogEntry(status.Failures, entry, maxLogEntries)
+	}
+	recordOutage(status, entry)
+	setReachableCondition(status, entry)
+}
+
+func recordOutage(status *v1alpha1.PodNetworkConnectivityCheckStatus, entry v1alpha1.LogEntry) {
+	inProgress := len(status.Outages) > 0 && status.Outages[0].End.IsZero()
+	switch {
+	case !entry.Success && !inProgress:
+		status.Outages = append([]v1alpha1.OutageEntry{{
+			Start:     entry.Start,
+			StartLogs: []v1alpha1.LogEntry{entry},
+			Message:   fmt.Sprintf("Connectivity outage detected at %s", entry.Start.UTC().Format(time.RFC3339)),
+		}}, status.Outages...)
+		if len(status.Outages) > maxOutages {
+			status.Outages = status.Outages[:maxOutages]
+		}
+	case !entry.Success && inProgress:
+		// only record the changes of the failure mode
+		outage := &status.Outages[0]
+		if outage.StartLogs[0].Reason != entry.Reason {
+			outage.StartLogs = prependLogEntry(outage.StartLogs, entry, maxOutageLogEntries)
+		}
+	case entry.Success && inProgress:
+		outage := &status.Outages[0]
+		outage.End = entry.Start
+		outage.EndLogs = []v1alpha1.LogEntry{entry}
+		// keep the failures that preceded the end of the outage
+		for _, failure := range status.Failures {
+			if len(outage.EndLogs) == maxOutageLogEntries || failure.Start.Before(&outage.Start) {
+				break
+			}
+			outage.EndLogs = append(outage.EndLogs, failure)
+		}
+		outage.Message = fmt.Sprintf("Connectivity restored after %s", outage.End.Sub(outage.Start.Time).Round(time.Second))
+	}
+}
+
+func setReachableCondition(status *v1alpha1.PodNetworkConnectivityCheckStatus, entry v1alpha1.LogEntry) {
+	condition := v1alpha1.PodNetworkConnectivityCheckCondition{
+		Type:               v1alpha1.Reachable,
+		Status:             metav1.ConditionTrue,
+		Reason:             entry.Reason,
+		Message:            entry.Message,
+		LastTransitionTime: entry.Start,
+	}
+	if !entry.Success {
+		condition.Status = metav1.ConditionFalse
+	}
+	for i := range status.Conditions {
+		if status.Conditions[i].Type != v1alpha1.Reachable {
+			continue
+		}
+		if status.Conditions[i].Status == condition.Status {
+			condition.LastTransitionTime = status.Conditions[i].LastTransitionTime
+		}
+		status.Conditions[i] = condition
+		return
+	}
+	status.Conditions = append(status.Conditions, condition)
+}
+
+func prependLogEntry(entries []v1alpha1.LogEntry, entry v1alpha1.LogEntry, max int) []v1alpha1.LogEntry {
+	entries = append([]v1alpha1.LogEntry{entry}, entries...)
+	if len(entries) > max {
+		entries = entries[:max]
+	}
+	return entries
+}
+
+// OutageDuration returns how long the outage lasted, or has been lasting until now when it is in progress.
+func OutageDuration(outage v1alpha1.OutageEntry, now time.Time) time.Duration {
+	if outage.End.IsZero() {
+		return now.Sub(outage.Start.Time)
+	}
+	return outage.End.Sub(outage.Start.Time)
+}
+
+// LatencyPercentile returns the p-th percentile (0 < p <= 100) of the latencies of the successful entries in the
+// status of a check, using the nearest-rank method, or zero when there are none.
+func LatencyPercentile(status *v1alpha1.PodNetworkConnectivityCheckStatus, p float64) time.Duration {
+	if len(status.Successes) == 0 || p <= 0 {
+		return 0
+	}
+	latencies := make([]time.Duration, 0, len(status.Successes))
+	for _, entry := range status.Successes {
+		latencies = append(latencies, entry.Latency.Duration)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	rank := int(math.Ceil(p/100*float64(len(latencies)))) - 1
+	if rank >= len(latencies) {
+		rank = len(latencies) - 1
+	}
+	return latencies[rank]
+}
@@ -0,0 +1,114 @@
+package connectivitycheckcontroller
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openshift/api/operatorcontrolplane/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestRecordLogEntry(t *testing.T) {
+	check := NewPodNetworkConnectivityCheckTemplate("10.0.0.1:6443", "openshift-test", WithSource("a"), WithTarget("b"))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	entry := func(second int, success bool, reason string) v1alpha1.LogEntry {
+		return v1alpha1.LogEntry{
+			Start:   metav1.NewTime(start.Add(time.Duration(second) * time.Second)),
+			Success: success,
+			Reason:  reason,
+			Latency: metav1.Duration{Duration: time.Duration(second+1) * time.Millisecond},
+		}
+	}
+	reachable := func() metav1.ConditionStatus {
+		for _, condition := range check.Status.Conditions {
+			if condition.Type == v1alpha1.Reachable {
+				return condition.Status
+			}
+		}
+		return metav1.ConditionUnknown
+	}
+
+	RecordLogEntry(check, entry(0, true, v1alpha1.LogEntryReasonTCPConnect))
+	if len(check.Status.Outages) != 0 || reachable() != metav1.ConditionTrue {
+		t.Fatalf("unexpected status after a success: %#v", check.Status)
+	}
+
+	RecordLogEntry(check, entry(1, false, v1alpha1.LogEntryReasonTCPConnectError))
+	RecordLogEntry(check, entry(2, false, v1alpha1.LogEntryReasonTCPConnectError))
+	RecordLogEntry(check, entry(3, false, v1alpha1.LogEntryReasonDNSError))
+	if len(check.Status.Outages) != 1 || !check.Status.Outages[0].End.IsZero() {
+		t.Fatalf("expected an outage in progress, got %#v", check.Status.Outages)
+	}
+	if got := len(check.Status.Outages[0].StartLogs); got != 2 {
+		t.Errorf("expected the changes of the failure mode in the start logs, got %d entries", got)
+	}
+	if reachable() != metav1.ConditionFalse {
+		t.Errorf("expected Reachable=False during the outage")
+	}
+	if got := OutageDuration(check.Status.Outages[0], start.Add(time.Minute)); got != 59*time.Second {
+		t.Errorf("expected the outage to be in progress for 59s, got %v", got)
+	}
+
+	RecordLogEntry(check, entry(5, true, v1alpha1.LogEntryReasonTCPConnect))
+	outage := check.Status.Outages[0]
+	if got := OutageDuration(outage, start.Add(time.Hour)); got != 4*time.Second {
+		t.Errorf("expected an outage of 4s, got %v", got)
+	}
+	if outage.Message != "Connectivity restored after 4s" {
+		t.Errorf("unexpected outage message %q", outage.Message)
+	}
+	if got := len(outage.EndLogs); got != 4 {
+		t.Errorf("expected the success and the 3 failures in the end logs, got %d entries", got)
+	}
+	if reachable() != metav1.ConditionTrue {
+		t.Errorf("expected Reachable=True after the outage")
+	}
+
+	// the history is bounded
+	for i := 10; i < 100; i += 2 {
+		RecordLogEntry(check, entry(i, false, v1alpha1.LogEntryReasonTCPConnectError))
+		RecordLogEntry(check, entry(i+1, true, v1alpha1.LogEntryReasonTCPConnect))
+	}
+	if len(check.Status.Successes) != maxLogEntries || len(check.Status.Failures) != maxLogEntries || len(check.Status.Outages) != maxOutages {
+		t.Errorf("expected a bounded history, got %d successes, %d failures and %d outages", len(check.Status.Successes), len(check.Status.Failures), len(check.Status.Outages))
+	}
+	if got := check.Status.Outages[0].Start.Time; !got.Equal(start.Add(98 * time.Second)) {
+		t.Errorf("expected the latest outage first, got one started at %v", got)
+	}
+
+	count, err := testutil.GetHistogramMetricCount(metrics.latency.WithLabelValues(string(CheckTypeTCPConnect), "true"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 47 {
+		t.Errorf("expected 47 latencies of successes, got %d", count)
+	}
+}
+
+func TestLatencyPercentile(t *testing.T) {
+	status := &v1alpha1.PodNetworkConnectivityCheckStatus{}
+	if got := LatencyPercentile(status, 50); got != 0 {
+		t.Errorf("expected no latency without successes, got %v", got)
+	}
+	for i := 10; i > 0; i-- {
+		status.Successes = append(status.Successes, v1alpha1.LogEntry{Success: true, Latency: metav1.Duration{Duration: time.Duration(i) * time.Millisecond}})
+	}
+	for _, tt := range []struct {
+		p        float64
+		expected time.Duration
+	}{
+		{p: 50, expected: 5 * time.Millisecond},
+		{p: 90, expected: 9 * time.Millisecond},
+		{p: 99, expected: 10 * time.Millisecond},
+		{p: 100, expected: 10 * time.Millisecond},
+		{p: 1, expected: time.Millisecond},
+	} {
+		t.Run(fmt.Sprintf("p%v", tt.p), func(t *testing.T) {
+			if got := LatencyPercentile(status, tt.p); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
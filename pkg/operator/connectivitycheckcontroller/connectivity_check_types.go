@@ -0,0 +1,205 @@
+package connectivitycheckcontroller
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/openshift/api/operatorcontrolplane/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CheckType is the kind of check performed against the target endpoint of a PodNetworkConnectivityCheck.
+// The PodNetworkConnectivityCheck API has no field for it, it is recorded in the checkTypeAnnotation.
+type CheckType string
+
+const (
+	// CheckTypeTCPConnect checks that a TCP connection to the endpoint can be established. It is the default.
+	CheckTypeTCPConnect CheckType = "TCPConnect"
+	// CheckTypeUDP sends a datagram to the endpoint and expects a response.
+	CheckTypeUDP CheckType = "UDP"
+	// CheckTypeTLSHandshake checks that a full TLS handshake with the endpoint succeeds.
+	CheckTypeTLSHandshake CheckType = "TLSHandshake"
+)
+
+const (
+	checkTypeAnnotation             = "networking.openshift.io/check-type"
+	udpRequestAnnotation            = "networking.openshift.io/udp-request"
+	udpExpectedResponseAnnotation   = "networking.openshift.io/udp-expected-response"
+	tlsServerNameAnnotation         = "networking.openshift.io/tls-server-name"
+	defaultConnectivityCheckTimeout = 10 * time.Second
+)
+
+// Log entry reasons of the UDP and TLS handshake checks, complementing the v1alpha1.LogEntryReason* of the TCP checks.
+const (
+	LogEntryReasonUDPExchange       = "UDPExchange"
+	LogEntryReasonUDPExchangeError  = "UDPExchangeError"
+	LogEntryReasonTLSHandshake      = "TLSHandshake"
+	LogEntryReasonTLSHandshakeError = "TLSHandshakeError"
+)
+
+// WithUDPCheck option makes the check send the request datagram to the target endpoint, and expect a response
+// starting with expectedResponse. An empty expectedResponse accepts any response.
+func WithUDPCheck(request, expectedResponse []byte) func(*v1alpha1.PodNetworkConnectivityCheck) {
+	return func(check *v1alpha1.PodNetworkConnectivityCheck) {
+		setAnnotation(check, checkTypeAnnotation, string(CheckTypeUDP))
+		setAnnotation(check, udpRequestAnnotation, base64.StdEncoding.EncodeToString(request))
+		if len(expectedResponse) > 0 {
+			setAnnotation(check, udpExpectedResponseAnnotation, base64.StdEncoding.EncodeToString(expectedResponse))
+		}
+	}
+}
+
+// WithTLSHandshakeCheck option makes the check perform a full TLS handshake with the target endpoint, presenting
+// serverName in the SNI extension when it is not empty.
+func WithTLSHandshakeCheck(serverName string) func(*v1alpha1.PodNetworkConnectivityCheck) {
+	return func(check *v1alpha1.PodNetworkConnectivityCheck) {
+		setAnnotation(check, checkTypeAnnotation, string(CheckTypeTLSHandshake))
+		if len(serverName) > 0 {
+			setAnnotation(check, tlsServerNameAnnotation, serverName)
+		}
+	}
+}
+
+func setAnnotation(check *v1alpha1.PodNetworkConnectivityCheck, key, value string) {
+	if check.Annotations == nil {
+		check.Annotations = map[string]string{}
+	}
+	check.Annotations[key] = value
+}
+
+// GetCheckType returns the type of the check, CheckTypeTCPConnect when it is not set.
+func GetCheckType(check *v1alpha1.PodNetworkConnectivityCheck) CheckType {
+	if checkType := check.Annotations[checkTypeAnnotation]; len(checkType) > 0 {
+		return CheckType(checkType)
+	}
+	return CheckTypeTCPConnect
+}
+
+// RunCheck performs the check against its target endpoint, and returns the log entry describing the result.
+// tlsConfig is used for the TLS handshake checks, e.g. to present the client certificate of the check. When it is
+// nil, the server certificates are not verified: the check is about reachability, it records the negotiated version
+// and the expiry of the certificate chain, and fails only when a certificate of the chain is expired.
+func RunCheck(ctx context.Context, check *v1alpha1.PodNetworkConnectivityCheck, tlsConfig *tls.Config) v1alpha1.LogEntry {
+	ctx, cancel := context.WithTimeout(ctx, defaultConnectivityCheckTimeout)
+	defer cancel()
+
+	address := check.Spec.TargetEndpoint
+	switch checkType := GetCheckType(check); checkType {
+	case CheckTypeTCPConnect:
+		return checkTCPConnect(ctx, address)
+	case CheckTypeUDP:
+		request, err := base64.StdEncoding.DecodeString(check.Annotations[udpRequestAnnotation])
+		if err != nil {
+			return newLogEntry(time.Now(), LogEntryReasonUDPExchangeError, fmt.Sprintf("%s: invalid request: %v", address, err), false)
+		}
+		expectedResponse, err := base64.StdEncoding.DecodeString(check.Annotations[udpExpectedResponseAnnotation])
+		if err != nil {
+			return newLogEntry(time.Now(), LogEntryReasonUDPExchangeError, fmt.Sprintf("%s: invalid expected response: %v", address, err), false)
+		}
+		return checkUDP(ctx, address, request, expectedResponse)
+	case CheckTypeTLSHandshake:
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{InsecureSkipVerify: true}
+		}
+		tlsConfig = tlsConfig.Clone()
+		if serverName := check.Annotations[tlsServerNameAnnotation]; len(serverName) > 0 {
+			tlsConfig.ServerName = serverName
+		}
+		return checkTLSHandshake(ctx, address, tlsConfig)
+	default:
+		return newLogEntry(time.Now(), v1alpha1.LogEntryReasonTCPConnectError, fmt.Sprintf("%s: unknown check type %q", address, checkType), false)
+	}
+}
+
+func checkTCPConnect(ctx context.Context, address string) v1alpha1.LogEntry {
+	start := time.Now()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+	if err != nil {
+		return newLogEntry(start, v1alpha1.LogEntryReasonTCPConnectError, fmt.Sprintf("Failed to establish a TCP connection to %s: %v", address, err), false)
+	}
+	defer conn.Close()
+	return newLogEntry(start, v1alpha1.LogEntryReasonTCPConnect, fmt.Sprintf("TCP connection to %s succeeded", address), true)
+}
+
+func checkUDP(ctx context.Context, address string, request, expectedResponse []byte) v1alpha1.LogEntry {
+	start := time.Now()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "udp", address)
+	if err != nil {
+		return newLogEntry(start, LogEntryReasonUDPExchangeError, fmt.Sprintf("Failed to reach %s over UDP: %v", address, err), false)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(request); err != nil {
+		return newLogEntry(start, LogEntryReasonUDPExchangeError, fmt.Sprintf("Failed to send the UDP request to %s: %v", address, err), false)
+	}
+	response := make([]byte, 65535)
+	n, err := conn.Read(response)
+	if err != nil {
+		return newLogEntry(start, LogEntryReasonUDPExchangeError, fmt.Sprintf("No UDP response from %s: %v", address, err), false)
+	}
+	if !bytes.HasPrefix(response[:n], expectedResponse) {
+		return newLogEntry(start, LogEntryReasonUDPExchangeError, fmt.Sprintf("Unexpected UDP response from %s: %q", address, response[:n]), false)
+	}
+	return newLogEntry(start, LogEntryReasonUDPExchange, fmt.Sprintf("UDP exchange with %s succeeded", address), true)
+}
+
+func checkTLSHandshake(ctx context.Context, address string, tlsConfig *tls.Config) v1alpha1.LogEntry {
+	start := time.Now()
+	dialer := &tls.Dialer{Config: tlsConfig}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return newLogEntry(start, LogEntryReasonTLSHandshakeError, fmt.Sprintf("TLS handshake with %s failed: %v", address, err), false)
+	}
+	defer conn.Close()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	var expiring *x509.Certificate
+	for _, cert := range state.PeerCertificates {
+		if expiring == nil || cert.NotAfter.Before(expiring.NotAfter) {
+			expiring = cert
+		}
+	}
+	if expiring == nil {
+		return newLogEntry(start, LogEntryReasonTLSHandshakeError, fmt.Sprintf("TLS handshake with %s (%s) returned no certificate", address, tlsVersionName(state.Version)), false)
+	}
+	if start.After(expiring.NotAfter) {
+		return newLogEntry(start, LogEntryReasonTLSHandshakeError,
+			fmt.Sprintf("TLS handshake with %s (%s) returned the expired certificate %q: it expired at %s", address, tlsVersionName(state.Version), expiring.Subject.CommonName, expiring.NotAfter.UTC().Format(time.RFC3339)), false)
+	}
+	return newLogEntry(start, LogEntryReasonTLSHandshake,
+		fmt.Sprintf("TLS handshake with %s (%s) succeeded, the certificate chain expires at %s", address, tlsVersionName(state.Version), expiring.NotAfter.UTC().Format(time.RFC3339)), true)
+}
+
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	default:
+		return fmt.Sprintf("TLS 0x%04x", version)
+	}
+}
+
+func newLogEntry(start time.Time, reason, message string, success bool) v1alpha1.LogEntry {
+	return v1alpha1.LogEntry{
+		Start:   metav1.NewTime(start),
+		Success: success,
+		Reason:  reason,
+		Message: message,
+		Latency: metav1.Duration{Duration: time.Since(start)},
+	}
+}
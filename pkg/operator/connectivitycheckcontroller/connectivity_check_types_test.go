@@ -0,0 +1,100 @@
+package connectivitycheckcontroller
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openshift/api/operatorcontrolplane/v1alpha1"
+)
+
+func TestRunCheck(t *testing.T) {
+	udpServer, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udpServer.Close()
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := udpServer.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			udpServer.WriteTo(append([]byte("pong:"), buf[:n]...), addr)
+		}
+	}()
+
+	tlsServer := httptest.NewTLSServer(nil)
+	defer tlsServer.Close()
+	tlsAddress := strings.TrimPrefix(tlsServer.URL, "https://")
+
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcpListener.Close()
+
+	tests := []struct {
+		name            string
+		address         string
+		options         []func(*v1alpha1.PodNetworkConnectivityCheck)
+		expectSuccess   bool
+		expectReason    string
+		expectInMessage string
+	}{
+		{
+			name:          "tcp",
+			address:       tcpListener.Addr().String(),
+			expectSuccess: true,
+			expectReason:  "TCPConnect",
+		},
+		{
+			name:            "udp with expected response",
+			address:         udpServer.LocalAddr().String(),
+			options:         []func(*v1alpha1.PodNetworkConnectivityCheck){WithUDPCheck([]byte("ping"), []byte("pong:ping"))},
+			expectSuccess:   true,
+			expectReason:    LogEntryReasonUDPExchange,
+			expectInMessage: "UDP exchange with",
+		},
+		{
+			name:            "udp with unexpected response",
+			address:         udpServer.LocalAddr().String(),
+			options:         []func(*v1alpha1.PodNetworkConnectivityCheck){WithUDPCheck([]byte("ping"), []byte("ack"))},
+			expectReason:    LogEntryReasonUDPExchangeError,
+			expectInMessage: `Unexpected UDP response from ` + udpServer.LocalAddr().String() + `: "pong:ping"`,
+		},
+		{
+			name:            "tls handshake",
+			address:         tlsAddress,
+			options:         []func(*v1alpha1.PodNetworkConnectivityCheck){WithTLSHandshakeCheck("example.com")},
+			expectSuccess:   true,
+			expectReason:    LogEntryReasonTLSHandshake,
+			expectInMessage: "(TLS 1.3) succeeded, the certificate chain expires at",
+		},
+		{
+			name:            "tls handshake without a listening endpoint",
+			address:         udpServer.LocalAddr().String(),
+			options:         []func(*v1alpha1.PodNetworkConnectivityCheck){WithTLSHandshakeCheck("")},
+			expectReason:    LogEntryReasonTLSHandshakeError,
+			expectInMessage: "TLS handshake with",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := NewPodNetworkConnectivityCheckTemplate(tt.address, "openshift-test", tt.options...)
+			entry := RunCheck(context.Background(), check, nil)
+			if entry.Success != tt.expectSuccess {
+				t.Errorf("expected success %v, got %v: %s", tt.expectSuccess, entry.Success, entry.Message)
+			}
+			if entry.Reason != tt.expectReason {
+				t.Errorf("expected reason %s, got %s", tt.expectReason, entry.Reason)
+			}
+			if !strings.Contains(entry.Message, tt.expectInMessage) {
+				t.Errorf("expected %q in the message %q", tt.expectInMessage, entry.Message)
+			}
+		})
+	}
+}
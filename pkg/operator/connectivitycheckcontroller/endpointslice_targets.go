@@ -0,0 +1,139 @@
+package connectivitycheckcontroller
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openshift/api/operatorcontrolplane/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	discoveryv1listers "k8s.io/client-go/listers/discovery/v1"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+)
+
+// ServiceTarget names a service whose endpoints are targets of connectivity checks.
+type ServiceTarget struct {
+	Namespace string
+	Name      string
+	// PortName selects the port of the endpoints to check. The first port of the protocol is checked when it is empty.
+	PortName string
+	// Protocol is the protocol of the port to check, TCP when it is empty. It must be UDP for the checks set
+	// WithUDPCheck.
+	Protocol corev1.Protocol
+}
+
+// NewEndpointSliceCheckFn returns a PodNetworkConnectivityCheckFunc that generates a check from sourcePod to every
+// ready endpoint of the services, as found in their EndpointSlices. The checks follow the endpoints as pods are
+// rescheduled: checks of removed endpoints are reaped when the controller is configured WithReapOldConnectivityCheck.
+// The informer of endpointSliceLister must be passed in the triggers of the controller.
+//
+// The checks are named '<sourcePod>-to-<service>-<pod>', or '<sourcePod>-to-<service>-<address>-<port>' for
+// endpoints that are not pods. The options are applied to every check, e.g. to set WithTLSHandshakeCheck.
+func NewEndpointSliceCheckFn(checkNamespace, sourcePod string, endpointSliceLister discoveryv1listers.EndpointSliceLister, services []ServiceTarget, options ...func(*v1alpha1.PodNetworkConnectivityCheck)) PodNetworkConnectivityCheckFunc {
+	return func(ctx context.Context, syncContext factory.SyncContext) ([]*v1alpha1.PodNetworkConnectivityCheck, error) {
+		var checks []*v1alpha1.PodNetworkConnectivityCheck
+		names := sets.NewString()
+		for _, service := range services {
+			slices, err := endpointSliceLister.EndpointSlices(service.Namespace).List(labels.SelectorFromSet(labels.Set{discoveryv1.LabelServiceName: service.Name}))
+			if err != nil {
+				return nil, err
+			}
+			for _, target := range endpointSliceTargets(slices, service) {
+				checkOptions := append([]func(*v1alpha1.PodNetworkConnectivityCheck){
+					WithSource(sourcePod),
+					WithTarget(service.Name + "-" + target.name),
+				}, options...)
+				check := NewPodNetworkConnectivityCheckTemplate(target.address, checkNamespace, checkOptions...)
+				check.Spec.SourcePod = sourcePod
+				if names.Has(check.Name) {
+					continue
+				}
+				names.Insert(check.Name)
+				checks = append(checks, check)
+			}
+		}
+		sort.Slice(checks, func(i, j int) bool { return checks[i].Name < checks[j].Name })
+		return checks, nil
+	}
+}
+
+type endpointTarget struct {
+	name    string
+	address string
+}
+
+// endpointSliceTargets returns the address of every ready endpoint of the slices, with the port of the service target.
+// The endpoints of a dual-stack service are in a slice per address family: an endpoint referencing the same object in
+// several slices is only checked once, on its IPv4 address when it has one.
+func endpointSliceTargets(slices []*discoveryv1.EndpointSlice, service ServiceTarget) []endpointTarget {
+	slices = append([]*discoveryv1.EndpointSlice(nil), slices...)
+	sort.Slice(slices, func(i, j int) bool {
+		if slices[i].AddressType != slices[j].AddressType {
+			return slices[i].AddressType == discoveryv1.AddressTypeIPv4
+		}
+		return slices[i].Name < slices[j].Name
+	})
+
+	var targets []endpointTarget
+	seen := sets.NewString()
+	for _, slice := range slices {
+		if slice.AddressType == discoveryv1.AddressTypeFQDN {
+			continue
+		}
+		port, ok := endpointSlicePort(slice, service.PortName, service.Protocol)
+		if !ok {
+			continue
+		}
+		for _, endpoint := range slice.Endpoints {
+			// a nil ready condition means the endpoint is ready, and addresses of an endpoint are fungible
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready || len(endpoint.Addresses) == 0 {
+				continue
+			}
+			if ref := endpoint.TargetRef; ref != nil {
+				key := ref.Kind + "/" + ref.Namespace + "/" + ref.Name
+				if seen.Has(key) {
+					continue
+				}
+				seen.Insert(key)
+			}
+			address := endpoint.Addresses[0]
+			name := strings.NewReplacer(".", "-", ":", "-").Replace(address) + "-" + strconv.Itoa(int(port))
+			if endpoint.TargetRef != nil && endpoint.TargetRef.Kind == "Pod" {
+				name = endpoint.TargetRef.Name
+			}
+			targets = append(targets, endpointTarget{
+				name:    name,
+				address: net.JoinHostPort(address, strconv.Itoa(int(port))),
+			})
+		}
+	}
+	return targets
+}
+
+// endpointSlicePort returns the port of the slice with the name and the protocol, the first port of the protocol
+// when the name is empty.
+func endpointSlicePort(slice *discoveryv1.EndpointSlice, portName string, protocol corev1.Protocol) (int32, bool) {
+	if len(protocol) == 0 {
+		protocol = corev1.ProtocolTCP
+	}
+	for _, port := range slice.Ports {
+		// a nil protocol means TCP
+		portProtocol := corev1.ProtocolTCP
+		if port.Protocol != nil {
+			portProtocol = *port.Protocol
+		}
+		if port.Port == nil || portProtocol != protocol {
+			continue
+		}
+		if len(portName) == 0 || port.Name != nil && *port.Name == portName {
+			return *port.Port, true
+		}
+	}
+	return 0, false
+}
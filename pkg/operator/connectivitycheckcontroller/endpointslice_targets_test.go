@@ -0,0 +1,106 @@
+package connectivitycheckcontroller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	discoveryv1listers "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/pointer"
+)
+
+func TestEndpointSliceCheckFn(t *testing.T) {
+	newSlice := func(name, service string, portName string, port int32, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+		return &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "openshift-etcd",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: service},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Ports:       []discoveryv1.EndpointPort{{Name: pointer.String(portName), Port: pointer.Int32(port)}},
+			Endpoints:   endpoints,
+		}
+	}
+	podEndpoint := func(pod, address string, ready *bool) discoveryv1.Endpoint {
+		return discoveryv1.Endpoint{
+			Addresses:  []string{address},
+			Conditions: discoveryv1.EndpointConditions{Ready: ready},
+			TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: pod},
+		}
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, slice := range []*discoveryv1.EndpointSlice{
+		newSlice("etcd-abc", "etcd", "etcd", 2379,
+			podEndpoint("etcd-master-0", "10.0.0.1", pointer.Bool(true)),
+			podEndpoint("etcd-master-1", "10.0.0.2", nil),
+			podEndpoint("etcd-master-2", "10.0.0.3", pointer.Bool(false)),
+		),
+		newSlice("etcd-def", "etcd", "etcd", 2379,
+			discoveryv1.Endpoint{Addresses: []string{"192.168.0.1"}},
+		),
+		newSlice("etcd-metrics", "etcd", "metrics", 9979,
+			podEndpoint("etcd-master-0", "10.0.0.1", nil),
+		),
+		newSlice("other", "other", "etcd", 2379,
+			podEndpoint("other-0", "10.0.0.9", nil),
+		),
+		// the IPv6 slice of the dual-stack service, the pods are checked on their IPv4 address
+		func() *discoveryv1.EndpointSlice {
+			slice := newSlice("etcd-aaa", "etcd", "etcd", 2379,
+				podEndpoint("etcd-master-0", "fd00::1", nil),
+				podEndpoint("etcd-master-3", "fd00::4", nil),
+			)
+			slice.AddressType = discoveryv1.AddressTypeIPv6
+			return slice
+		}(),
+		// the first port is UDP, the TCP port is checked
+		func() *discoveryv1.EndpointSlice {
+			slice := newSlice("dns", "dns", "dns", 53, podEndpoint("dns-0", "10.0.1.1", nil))
+			slice.Ports = []discoveryv1.EndpointPort{
+				{Name: pointer.String("dns"), Port: pointer.Int32(53), Protocol: &[]corev1.Protocol{corev1.ProtocolUDP}[0]},
+				{Name: pointer.String("dns-tcp"), Port: pointer.Int32(5353)},
+			}
+			return slice
+		}(),
+	} {
+		if err := indexer.Add(slice); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkFn := NewEndpointSliceCheckFn(
+		"openshift-test",
+		"apiserver-master-0",
+		discoveryv1listers.NewEndpointSliceLister(indexer),
+		[]ServiceTarget{{Namespace: "openshift-etcd", Name: "etcd", PortName: "etcd"}, {Namespace: "openshift-etcd", Name: "dns"}},
+		WithTlsClientCert("etcd-client"),
+	)
+	checks, err := checkFn(context.TODO(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got [][]string
+	for _, check := range checks {
+		if check.Spec.SourcePod != "apiserver-master-0" || check.Spec.TLSClientCert.Name != "etcd-client" || check.Namespace != "openshift-test" {
+			t.Errorf("unexpected check %#v", check)
+		}
+		got = append(got, []string{check.Name, check.Spec.TargetEndpoint})
+	}
+	expected := [][]string{
+		{"apiserver-master-0-to-dns-dns-0", "10.0.1.1:5353"},
+		{"apiserver-master-0-to-etcd-192-168-0-1-2379", "192.168.0.1:2379"},
+		{"apiserver-master-0-to-etcd-etcd-master-0", "10.0.0.1:2379"},
+		{"apiserver-master-0-to-etcd-etcd-master-1", "10.0.0.2:2379"},
+		{"apiserver-master-0-to-etcd-etcd-master-3", "[fd00::4]:2379"},
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected checks %v, got %v", expected, got)
+	}
+}
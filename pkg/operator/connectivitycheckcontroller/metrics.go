@@ -0,0 +1,51 @@
+package connectivitycheckcontroller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	k8smetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	namespace = "pod_network_connectivity_check"
+)
+
+// metrics provides access to all connectivity check metrics.
+var metrics *connectivityCheckMetrics
+
+func init() {
+	metrics = newConnectivityCheckMetrics(legacyregistry.Register)
+}
+
+// connectivityCheckMetrics instruments the connectivity checks with prometheus metrics.
+type connectivityCheckMetrics struct {
+	latency *k8smetrics.HistogramVec
+}
+
+// newConnectivityCheckMetrics creates a new connectivityCheckMetrics, configured with default metric names.
+func newConnectivityCheckMetrics(registerFunc func(k8smetrics.Registerable) error) *connectivityCheckMetrics {
+	latency := k8smetrics.NewHistogramVec(
+		&k8smetrics.HistogramOpts{
+			Namespace: namespace,
+			Name:      "latency_seconds",
+			Help:      "The latency of the connectivity checks, labeled with the type of the check and whether it succeeded",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+		}, []string{"type", "success"})
+	registerFunc(latency)
+
+	return &connectivityCheckMetrics{
+		latency: latency,
+	}
+}
+
+// ObserveLatency records the latency of a check in seconds. The checks and their target endpoints come and go with the
+// pods and the nodes, they are not labels so that the cardinality of the histogram is bounded: the latencies of a check
+// are in its status, see LatencyPercentile.
+func (m *connectivityCheckMetrics) ObserveLatency(checkType CheckType, success bool, seconds float64) {
+	successLabel := "false"
+	if success {
+		successLabel = "true"
+	}
+	m.latency.WithLabelValues(string(checkType), successLabel).Observe(seconds)
+}
@@ -7,9 +7,12 @@ import (
 
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 
+	configv1 "github.com/openshift/api/config/v1"
+	configv1client "github.com/openshift/client-go/config/clientset/versioned/typed/config/v1"
 	configinformers "github.com/openshift/client-go/config/informers/externalversions"
 	operatorinformer "github.com/openshift/client-go/operator/informers/externalversions"
 	"github.com/openshift/library-go/pkg/controller/factory"
@@ -17,7 +20,9 @@ import (
 	"github.com/openshift/library-go/pkg/operator/csi/csiconfigobservercontroller"
 	"github.com/openshift/library-go/pkg/operator/csi/csidrivercontrollerservicecontroller"
 	"github.com/openshift/library-go/pkg/operator/csi/csidrivernodeservicecontroller"
+	"github.com/openshift/library-go/pkg/operator/csi/csisnapshotclasscontroller"
 	"github.com/openshift/library-go/pkg/operator/csi/csistorageclasscontroller"
+	"github.com/openshift/library-go/pkg/operator/csi/csistorageclassmigrationcontroller"
 	"github.com/openshift/library-go/pkg/operator/deploymentcontroller"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/loglevel"
@@ -25,6 +30,7 @@ import (
 	"github.com/openshift/library-go/pkg/operator/managementstatecontroller"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
 	"github.com/openshift/library-go/pkg/operator/staticresourcecontroller"
+	"github.com/openshift/library-go/pkg/operator/status"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 )
 
@@ -32,16 +38,20 @@ var defaultCacheSyncTimeout = 10 * time.Minute
 
 // CSIControllerSet contains a set of controllers that are usually used to deploy CSI Drivers.
 type CSIControllerSet struct {
-	logLevelController                   factory.Controller
-	managementStateController            factory.Controller
-	staticResourcesController            factory.Controller
-	conditionalStaticResourcesController factory.Controller
-	credentialsRequestController         factory.Controller
-	csiConfigObserverController          factory.Controller
-	csiDriverControllerServiceController factory.Controller
-	csiDriverNodeServiceController       factory.Controller
-	serviceMonitorController             factory.Controller
-	csiStorageclassController            factory.Controller
+	logLevelController                    factory.Controller
+	managementStateController             factory.Controller
+	staticResourcesController             factory.Controller
+	controlPlaneStaticResourcesController factory.Controller
+	conditionalStaticResourcesController  factory.Controller
+	credentialsRequestController          factory.Controller
+	csiConfigObserverController           factory.Controller
+	csiDriverControllerServiceController  factory.Controller
+	csiDriverNodeServiceController        factory.Controller
+	serviceMonitorController              factory.Controller
+	csiStorageclassController             factory.Controller
+	csiSnapshotClassController            factory.Controller
+	csiStorageClassMigrationController    factory.Controller
+	clusterOperatorStatusController       factory.Controller
 
 	operatorClient v1helpers.OperatorClientWithFinalizers
 	eventRecorder  events.Recorder
@@ -53,6 +63,7 @@ func (c *CSIControllerSet) Run(ctx context.Context, workers int) {
 		c.logLevelController,
 		c.managementStateController,
 		c.staticResourcesController,
+		c.controlPlaneStaticResourcesController,
 		c.conditionalStaticResourcesController,
 		c.credentialsRequestController,
 		c.csiConfigObserverController,
@@ -60,6 +71,9 @@ func (c *CSIControllerSet) Run(ctx context.Context, workers int) {
 		c.csiDriverNodeServiceController,
 		c.serviceMonitorController,
 		c.csiStorageclassController,
+		c.csiSnapshotClassController,
+		c.csiStorageClassMigrationController,
+		c.clusterOperatorStatusController,
 	} {
 		if ctrl == nil {
 			continue
@@ -132,6 +146,27 @@ func (c *CSIControllerSet) WithStaticResourcesController(
 	return c
 }
 
+// WithControlPlaneStaticResourcesController returns a *ControllerSet with a static resources controller initialized
+// that applies files in a separate management cluster, where the CSI controller Deployment lives with hosted control planes.
+func (c *CSIControllerSet) WithControlPlaneStaticResourcesController(
+	name string,
+	controlPlaneKubeClient kubernetes.Interface,
+	controlPlaneDynamicClient dynamic.Interface,
+	controlPlaneKubeInformersForNamespace v1helpers.KubeInformersForNamespaces,
+	manifests resourceapply.AssetFunc,
+	files []string,
+) *CSIControllerSet {
+	c.controlPlaneStaticResourcesController = staticresourcecontroller.NewStaticResourceController(
+		name,
+		manifests,
+		files,
+		(&resourceapply.ClientHolder{}).WithKubernetes(controlPlaneKubeClient).WithDynamicClient(controlPlaneDynamicClient),
+		c.operatorClient,
+		c.eventRecorder,
+	).AddKubeInformers(controlPlaneKubeInformersForNamespace)
+	return c
+}
+
 // WithCredentialsRequestController returns a *ControllerSet with a CredentialsRequestController initialized.
 func (c *CSIControllerSet) WithCredentialsRequestController(
 	name string,
@@ -224,6 +259,42 @@ func (c *CSIControllerSet) WithCSIDriverNodeService(
 	return c
 }
 
+// WithCSIDriverNodeServiceWithWindows returns a *ControllerSet with a CSI node service controller that,
+// besides the DaemonSet in file, manages a DaemonSet for Windows nodes read from windowsFile.
+func (c *CSIControllerSet) WithCSIDriverNodeServiceWithWindows(
+	name string,
+	assetFunc resourceapply.AssetFunc,
+	file string,
+	windowsFile string,
+	kubeClient kubernetes.Interface,
+	namespacedInformerFactory informers.SharedInformerFactory,
+	optionalInformers []factory.Informer,
+	optionalDaemonSetHooks []csidrivernodeservicecontroller.DaemonSetHookFunc,
+	optionalWindowsDaemonSetHooks ...csidrivernodeservicecontroller.DaemonSetHookFunc,
+) *CSIControllerSet {
+	manifestFile, err := assetFunc(file)
+	if err != nil {
+		panic(fmt.Sprintf("asset: Asset(%v): %v", file, err))
+	}
+	windowsManifestFile, err := assetFunc(windowsFile)
+	if err != nil {
+		panic(fmt.Sprintf("asset: Asset(%v): %v", windowsFile, err))
+	}
+	c.csiDriverNodeServiceController = csidrivernodeservicecontroller.NewCSIDriverNodeServiceControllerWithWindows(
+		name,
+		manifestFile,
+		windowsManifestFile,
+		c.eventRecorder,
+		c.operatorClient,
+		kubeClient,
+		namespacedInformerFactory.Apps().V1().DaemonSets(),
+		optionalInformers,
+		optionalDaemonSetHooks,
+		optionalWindowsDaemonSetHooks...,
+	)
+	return c
+}
+
 // WithServiceMonitorController returns a *ControllerSet that creates ServiceMonitor.
 func (c *CSIControllerSet) WithServiceMonitorController(
 	name string,
@@ -267,6 +338,67 @@ func (c *CSIControllerSet) WithStorageClassController(
 	return c
 }
 
+// WithSnapshotClassController returns a *ControllerSet with a controller that applies the VolumeSnapshotClasses in files.
+func (c *CSIControllerSet) WithSnapshotClassController(
+	name string,
+	assetFunc resourceapply.AssetFunc,
+	files []string,
+	dynamicClient dynamic.Interface,
+	dynamicInformerFactory dynamicinformer.DynamicSharedInformerFactory,
+	hooks ...csisnapshotclasscontroller.VolumeSnapshotClassHookFunc,
+) *CSIControllerSet {
+	c.csiSnapshotClassController = csisnapshotclasscontroller.NewCSISnapshotClassController(
+		name,
+		assetFunc,
+		files,
+		dynamicClient,
+		dynamicInformerFactory,
+		c.operatorClient,
+		c.eventRecorder,
+		hooks...,
+	)
+	return c
+}
+
+// WithStorageClassMigrationController returns a *ControllerSet with a controller that replaces deprecated StorageClasses.
+func (c *CSIControllerSet) WithStorageClassMigrationController(
+	name string,
+	migrations []csistorageclassmigrationcontroller.StorageClassMigration,
+	kubeClient kubernetes.Interface,
+	informerFactory informers.SharedInformerFactory,
+) *CSIControllerSet {
+	c.csiStorageClassMigrationController = csistorageclassmigrationcontroller.NewCSIStorageClassMigrationController(
+		name,
+		migrations,
+		kubeClient,
+		informerFactory,
+		c.operatorClient,
+		c.eventRecorder,
+	)
+	return c
+}
+
+// WithClusterOperatorStatusController returns a *ControllerSet with a controller that reports the conditions
+// of the operator CR in the ClusterOperator clusterOperatorName.
+func (c *CSIControllerSet) WithClusterOperatorStatusController(
+	clusterOperatorName string,
+	relatedObjects []configv1.ObjectReference,
+	clusterOperatorClient configv1client.ClusterOperatorsGetter,
+	configInformer configinformers.SharedInformerFactory,
+	versionGetter status.VersionGetter,
+) *CSIControllerSet {
+	c.clusterOperatorStatusController = status.NewClusterOperatorStatusController(
+		clusterOperatorName,
+		relatedObjects,
+		clusterOperatorClient,
+		configInformer.Config().V1().ClusterOperators(),
+		c.operatorClient,
+		versionGetter,
+		c.eventRecorder,
+	)
+	return c
+}
+
 // New returns a basic *ControllerSet without any controller.
 func NewCSIControllerSet(operatorClient v1helpers.OperatorClientWithFinalizers, eventRecorder events.Recorder) *CSIControllerSet {
 	return &CSIControllerSet{
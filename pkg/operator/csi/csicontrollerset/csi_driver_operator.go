@@ -0,0 +1,454 @@
+package csicontrollerset
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configclient "github.com/openshift/client-go/config/clientset/versioned"
+	configinformers "github.com/openshift/client-go/config/informers/externalversions"
+	operatorinformer "github.com/openshift/client-go/operator/informers/externalversions"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/csi/csidrivercontrollerservicecontroller"
+	"github.com/openshift/library-go/pkg/operator/csi/csidrivernodeservicecontroller"
+	"github.com/openshift/library-go/pkg/operator/csi/csirollout"
+	"github.com/openshift/library-go/pkg/operator/csi/csisnapshotclasscontroller"
+	"github.com/openshift/library-go/pkg/operator/csi/csistorageclasscontroller"
+	"github.com/openshift/library-go/pkg/operator/csi/csistorageclassmigrationcontroller"
+	"github.com/openshift/library-go/pkg/operator/deploymentcontroller"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/status"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// CSIDriverOperatorConfig describes a CSI driver operator built by NewCSIDriverOperator.
+// All asset paths are relative to Assets. Optional assets are skipped when empty.
+type CSIDriverOperatorConfig struct {
+	// Name prefixes the names of all controllers (and thus their conditions), e.g. "AWSEBSDriver".
+	Name string
+	// OperandNamespace is the namespace where the CSI driver is deployed.
+	OperandNamespace string
+	// Assets holds the manifests of the operand, usually an embed.FS.
+	Assets fs.FS
+
+	// StaticAssets are applied as they are, e.g. the namespace, RBAC, CSIDriver and ServiceAccounts.
+	StaticAssets []string
+	// ControllerAsset is the Deployment of the CSI controller service.
+	ControllerAsset string
+	// NodeAsset is the DaemonSet of the CSI node service.
+	NodeAsset string
+	// WindowsNodeAsset is the optional DaemonSet of the CSI node service for Windows nodes.
+	WindowsNodeAsset string
+	// StorageClassAssets are the optional StorageClasses provided by the operator.
+	StorageClassAssets []string
+	// StorageClassMigrations replace deprecated StorageClasses of previous versions of the driver.
+	StorageClassMigrations []csistorageclassmigrationcontroller.StorageClassMigration
+	// VolumeSnapshotClassAssets are the optional VolumeSnapshotClasses provided by the operator.
+	VolumeSnapshotClassAssets []string
+	// CredentialsRequestAsset is the optional CredentialsRequest with the cloud credentials of the driver.
+	CredentialsRequestAsset string
+	// ServiceMonitorAsset is the optional ServiceMonitor of the driver metrics.
+	ServiceMonitorAsset string
+
+	// ControlPlaneNamespace is the namespace of the controller Deployment, in the management cluster when the control
+	// plane runs in a separate one (see CSIDriverOperatorClients), otherwise in the cluster of the operands. It
+	// defaults to OperandNamespace. The kube informers of its cluster must contain informers for it.
+	ControlPlaneNamespace string
+	// ControlPlaneStaticAssets are applied in the management cluster, e.g. the RBAC and Service of the controller
+	// Deployment. They are ignored when there is no separate management cluster.
+	ControlPlaneStaticAssets []string
+
+	// TrustedCAConfigMap is the optional name of a ConfigMap in OperandNamespace with the trusted CA bundle
+	// injected by the cluster network operator. When set, the bundle is injected into the containers listed
+	// in the config.openshift.io/inject-proxy-cabundle annotation of the controller Deployment and node DaemonSet.
+	TrustedCAConfigMap string
+	// SupportsOperandRemoval allows the operand to be removed by setting the operator to Removed.
+	SupportsOperandRemoval bool
+
+	// ClusterOperatorName is the optional ClusterOperator where the conditions of the operator are reported.
+	ClusterOperatorName string
+	// RelatedObjects are reported in the ClusterOperator.
+	RelatedObjects []configv1.ObjectReference
+	// OperatorVersion is reported as the "operator" version in the ClusterOperator, if set.
+	OperatorVersion string
+
+	// ControllerLogLevelFlags override the verbosity flags of the controller Deployment containers
+	// (container name -> flag name), on top of csidrivercontrollerservicecontroller.DefaultLogLevelFlags.
+	// An empty flag name disables the log level propagation for the container.
+	ControllerLogLevelFlags map[string]string
+	// NodeLogLevelFlags override the verbosity flags of the node DaemonSet containers,
+	// on top of csidrivernodeservicecontroller.DefaultLogLevelFlags.
+	NodeLogLevelFlags map[string]string
+	// SidecarLeaderElection opts in to setting the leader election parameters of the controller Deployment sidecars.
+	// The sidecars keep their own flags when it is nil. Zero durations are derived from the control plane topology,
+	// see csidrivercontrollerservicecontroller.WithSidecarLeaderElectionHook.
+	SidecarLeaderElection *configv1.LeaderElection
+	// SidecarLeaderElectionContainers are the containers whose leader election is tuned when SidecarLeaderElection
+	// is set, csidrivercontrollerservicecontroller.DefaultLeaderElectionContainers when empty.
+	SidecarLeaderElectionContainers []string
+
+	// ControllerHooks are additional hooks of the controller Deployment.
+	ControllerHooks []deploymentcontroller.DeploymentHookFunc
+	// NodeHooks are additional hooks of the node DaemonSet.
+	NodeHooks []csidrivernodeservicecontroller.DaemonSetHookFunc
+	// WindowsNodeHooks are additional hooks of the Windows node DaemonSet. Only the observed proxy and the log level
+	// are propagated to it by default, the other hooks of the node DaemonSet assume Linux nodes.
+	WindowsNodeHooks []csidrivernodeservicecontroller.DaemonSetHookFunc
+	// StorageClassHooks are additional hooks of the StorageClasses.
+	StorageClassHooks []csistorageclasscontroller.StorageClassHookFunc
+	// VolumeSnapshotClassHooks are additional hooks of the VolumeSnapshotClasses.
+	VolumeSnapshotClassHooks []csisnapshotclasscontroller.VolumeSnapshotClassHookFunc
+	// ExtraInformers trigger the controller and node service controllers, e.g. informers used by the hooks.
+	ExtraInformers []factory.Informer
+}
+
+// CSIDriverOperatorClients are the clients and informers shared by the controllers of a CSI driver operator.
+// KubeInformers must contain informers for both OperandNamespace and the cluster scope ("").
+//
+// By default all operands live in the same cluster. With a hosted control plane (e.g. HyperShift), the controller
+// Deployment, the ServiceMonitor and ControlPlaneStaticAssets live in a separate management cluster,
+// accessed by the ControlPlane* clients. All other objects (CSIDriver, StorageClasses, node DaemonSet, ...)
+// live in the guest cluster, accessed by the remaining clients.
+type CSIDriverOperatorClients struct {
+	OperatorClient    v1helpers.OperatorClientWithFinalizers
+	KubeClient        kubernetes.Interface
+	DynamicClient     dynamic.Interface
+	ConfigClient      configclient.Interface
+	KubeInformers     v1helpers.KubeInformersForNamespaces
+	ConfigInformers   configinformers.SharedInformerFactory
+	OperatorInformers operatorinformer.SharedInformerFactory
+	DynamicInformers  dynamicinformer.DynamicSharedInformerFactory
+	EventRecorder     events.Recorder
+
+	// ControlPlaneKubeClient, ControlPlaneDynamicClient and ControlPlaneKubeInformers access the management
+	// cluster. They are optional, ControlPlaneKubeInformers must contain informers for ControlPlaneNamespace.
+	ControlPlaneKubeClient    kubernetes.Interface
+	ControlPlaneDynamicClient dynamic.Interface
+	ControlPlaneKubeInformers v1helpers.KubeInformersForNamespaces
+}
+
+// hasControlPlaneCluster returns true if the control plane runs in a separate management cluster.
+func (c CSIDriverOperatorClients) hasControlPlaneCluster() bool {
+	return c.ControlPlaneKubeClient != nil
+}
+
+// controlPlane returns the clients of the cluster where the controller Deployment lives.
+func (c CSIDriverOperatorClients) controlPlane() (kubernetes.Interface, dynamic.Interface, v1helpers.KubeInformersForNamespaces) {
+	if c.hasControlPlaneCluster() {
+		return c.ControlPlaneKubeClient, c.ControlPlaneDynamicClient, c.ControlPlaneKubeInformers
+	}
+	return c.KubeClient, c.DynamicClient, c.KubeInformers
+}
+
+// CSIDriverOperator is a ready to run CSI driver operator.
+type CSIDriverOperator struct {
+	*CSIControllerSet
+	clients CSIDriverOperatorClients
+}
+
+// NewCSIDriverOperator wires the controllers usually needed by a CSI driver operator: management state, log level,
+// static resources, config observer, controller and node services (with proxy and trusted CA injection),
+// StorageClasses (including the migration of deprecated ones), VolumeSnapshotClasses, credentials request, ServiceMonitor and ClusterOperator status.
+// The rollout of the controller and node services, named by their assets, is reported by the csirollout metrics.
+func NewCSIDriverOperator(config CSIDriverOperatorConfig, clients CSIDriverOperatorClients) (*CSIDriverOperator, error) {
+	if err := validateCSIDriverOperator(config, clients); err != nil {
+		return nil, err
+	}
+
+	assetFunc := assetFuncFromFS(config.Assets)
+	namespacedInformers := clients.KubeInformers.InformersFor(config.OperandNamespace)
+	clusterInformers := clients.KubeInformers.InformersFor("")
+	controlPlaneNamespace := controlPlaneNamespace(config)
+	controlPlaneKubeClient, controlPlaneDynamicClient, controlPlaneKubeInformers := clients.controlPlane()
+	controlPlaneInformers := controlPlaneKubeInformers.InformersFor(controlPlaneNamespace)
+
+	// rollout metrics of the controller Deployment and the node DaemonSets
+	controllerName, err := assetObjectName(config.Assets, config.ControllerAsset)
+	if err != nil {
+		return nil, err
+	}
+	nodeNames := []string{}
+	for _, file := range []string{config.NodeAsset, config.WindowsNodeAsset} {
+		if len(file) == 0 {
+			continue
+		}
+		name, err := assetObjectName(config.Assets, file)
+		if err != nil {
+			return nil, err
+		}
+		nodeNames = append(nodeNames, name)
+	}
+	if err := csirollout.NewRolloutTracker(controlPlaneNamespace, controllerName).AddInformers(controlPlaneInformers.Apps().V1().Deployments().Informer(), nil); err != nil {
+		return nil, err
+	}
+	if err := csirollout.NewRolloutTracker(config.OperandNamespace, nodeNames...).AddInformers(nil, namespacedInformers.Apps().V1().DaemonSets().Informer()); err != nil {
+		return nil, err
+	}
+
+	controllerLogLevelFlags := csidrivercontrollerservicecontroller.DefaultLogLevelFlags()
+	for container, flag := range config.ControllerLogLevelFlags {
+		controllerLogLevelFlags[container] = flag
+	}
+	nodeLogLevelFlags := csidrivernodeservicecontroller.DefaultLogLevelFlags()
+	for container, flag := range config.NodeLogLevelFlags {
+		nodeLogLevelFlags[container] = flag
+	}
+
+	controllerHooks := []deploymentcontroller.DeploymentHookFunc{
+		csidrivercontrollerservicecontroller.WithObservedProxyDeploymentHook(),
+		csidrivercontrollerservicecontroller.WithLogLevelDeploymentHook(controllerLogLevelFlags),
+	}
+	if config.SidecarLeaderElection != nil {
+		controllerHooks = append(controllerHooks, csidrivercontrollerservicecontroller.WithSidecarLeaderElectionHook(clients.ConfigInformers, *config.SidecarLeaderElection, config.SidecarLeaderElectionContainers...))
+	}
+	nodeHooks := []csidrivernodeservicecontroller.DaemonSetHookFunc{
+		csidrivernodeservicecontroller.WithObservedProxyDaemonSetHook(),
+		csidrivernodeservicecontroller.WithLogLevelDaemonSetHook(nodeLogLevelFlags),
+	}
+	windowsNodeHooks := []csidrivernodeservicecontroller.DaemonSetHookFunc{
+		csidrivernodeservicecontroller.WithObservedProxyDaemonSetHook(),
+		csidrivernodeservicecontroller.WithLogLevelDaemonSetHook(nodeLogLevelFlags),
+	}
+	controllerInformers := append([]factory.Informer{clients.ConfigInformers.Config().V1().Infrastructures().Informer()}, config.ExtraInformers...)
+	nodeInformers := append([]factory.Informer{}, config.ExtraInformers...)
+	if len(config.TrustedCAConfigMap) > 0 {
+		controlPlaneConfigMapInformer := controlPlaneInformers.Core().V1().ConfigMaps()
+		controllerHooks = append(controllerHooks, csidrivercontrollerservicecontroller.WithCABundleDeploymentHook(controlPlaneNamespace, config.TrustedCAConfigMap, controlPlaneConfigMapInformer))
+		controllerInformers = append(controllerInformers, controlPlaneConfigMapInformer.Informer())
+
+		configMapInformer := namespacedInformers.Core().V1().ConfigMaps()
+		nodeHooks = append(nodeHooks, csidrivernodeservicecontroller.WithCABundleDaemonSetHook(config.OperandNamespace, config.TrustedCAConfigMap, configMapInformer))
+		nodeInformers = append(nodeInformers, configMapInformer.Informer())
+	}
+	controllerHooks = append(controllerHooks, config.ControllerHooks...)
+	nodeHooks = append(nodeHooks, config.NodeHooks...)
+	windowsNodeHooks = append(windowsNodeHooks, config.WindowsNodeHooks...)
+
+	cs := NewCSIControllerSet(clients.OperatorClient, clients.EventRecorder).
+		WithLogLevelController().
+		WithManagementStateController(config.Name, config.SupportsOperandRemoval).
+		WithStaticResourcesController(
+			config.Name+"StaticResourcesController",
+			clients.KubeClient,
+			clients.DynamicClient,
+			clients.KubeInformers,
+			assetFunc,
+			config.StaticAssets,
+		).
+		WithCSIConfigObserverController(config.Name+"CSIConfigObserverController", clients.ConfigInformers).
+		WithCSIDriverControllerService(
+			config.Name+"ControllerServiceController",
+			assetFunc,
+			config.ControllerAsset,
+			controlPlaneKubeClient,
+			controlPlaneInformers,
+			clients.ConfigInformers,
+			controllerInformers,
+			controllerHooks...,
+		)
+
+	if clients.hasControlPlaneCluster() && len(config.ControlPlaneStaticAssets) > 0 {
+		cs = cs.WithControlPlaneStaticResourcesController(
+			config.Name+"ControlPlaneStaticResourcesController",
+			controlPlaneKubeClient,
+			controlPlaneDynamicClient,
+			controlPlaneKubeInformers,
+			assetFunc,
+			config.ControlPlaneStaticAssets,
+		)
+	}
+
+	if len(config.WindowsNodeAsset) > 0 {
+		cs = cs.WithCSIDriverNodeServiceWithWindows(
+			config.Name+"NodeServiceController",
+			assetFunc,
+			config.NodeAsset,
+			config.WindowsNodeAsset,
+			clients.KubeClient,
+			namespacedInformers,
+			nodeInformers,
+			nodeHooks,
+			windowsNodeHooks...,
+		)
+	} else {
+		cs = cs.WithCSIDriverNodeService(
+			config.Name+"NodeServiceController",
+			assetFunc,
+			config.NodeAsset,
+			clients.KubeClient,
+			namespacedInformers,
+			nodeInformers,
+			nodeHooks...,
+		)
+	}
+
+	if len(config.StorageClassAssets) > 0 {
+		cs = cs.WithStorageClassController(
+			config.Name+"StorageClassController",
+			assetFunc,
+			config.StorageClassAssets,
+			clients.KubeClient,
+			clusterInformers,
+			clients.OperatorInformers,
+			config.StorageClassHooks...,
+		)
+	}
+	if len(config.StorageClassMigrations) > 0 {
+		cs = cs.WithStorageClassMigrationController(
+			config.Name+"StorageClassMigrationController",
+			config.StorageClassMigrations,
+			clients.KubeClient,
+			clusterInformers,
+		)
+	}
+	if len(config.VolumeSnapshotClassAssets) > 0 {
+		cs = cs.WithSnapshotClassController(
+			config.Name+"SnapshotClassController",
+			assetFunc,
+			config.VolumeSnapshotClassAssets,
+			clients.DynamicClient,
+			clients.DynamicInformers,
+			config.VolumeSnapshotClassHooks...,
+		)
+	}
+	if len(config.CredentialsRequestAsset) > 0 {
+		cs = cs.WithCredentialsRequestController(
+			config.Name+"CredentialsRequestController",
+			config.OperandNamespace,
+			assetFunc,
+			config.CredentialsRequestAsset,
+			clients.DynamicClient,
+			clients.OperatorInformers,
+		)
+	}
+	if len(config.ServiceMonitorAsset) > 0 {
+		cs = cs.WithServiceMonitorController(
+			config.Name+"ServiceMonitorController",
+			controlPlaneDynamicClient,
+			assetFunc,
+			config.ServiceMonitorAsset,
+		)
+	}
+	if len(config.ClusterOperatorName) > 0 {
+		versionGetter := status.NewVersionGetter()
+		if len(config.OperatorVersion) > 0 {
+			versionGetter.SetVersion("operator", config.OperatorVersion)
+		}
+		cs = cs.WithClusterOperatorStatusController(
+			config.ClusterOperatorName,
+			config.RelatedObjects,
+			clients.ConfigClient.ConfigV1(),
+			clients.ConfigInformers,
+			versionGetter,
+		)
+	}
+
+	return &CSIDriverOperator{CSIControllerSet: cs, clients: clients}, nil
+}
+
+// Run starts the informers passed in CSIDriverOperatorClients and all controllers of the operator.
+// The informers of the operator client itself must be started by the caller.
+func (o *CSIDriverOperator) Run(ctx context.Context, workers int) {
+	o.clients.KubeInformers.Start(ctx.Done())
+	if o.clients.ControlPlaneKubeInformers != nil {
+		o.clients.ControlPlaneKubeInformers.Start(ctx.Done())
+	}
+	o.clients.ConfigInformers.Start(ctx.Done())
+	if o.clients.OperatorInformers != nil {
+		o.clients.OperatorInformers.Start(ctx.Done())
+	}
+	if o.clients.DynamicInformers != nil {
+		o.clients.DynamicInformers.Start(ctx.Done())
+	}
+	o.CSIControllerSet.Run(ctx, workers)
+}
+
+func validateCSIDriverOperator(config CSIDriverOperatorConfig, clients CSIDriverOperatorClients) error {
+	switch {
+	case len(config.Name) == 0:
+		return fmt.Errorf("missing operator name")
+	case len(config.OperandNamespace) == 0:
+		return fmt.Errorf("missing operand namespace")
+	case config.Assets == nil:
+		return fmt.Errorf("missing assets")
+	case len(config.ControllerAsset) == 0 || len(config.NodeAsset) == 0:
+		return fmt.Errorf("both the controller and the node service assets are required")
+	case clients.OperatorClient == nil || clients.KubeClient == nil || clients.DynamicClient == nil || clients.ConfigInformers == nil || clients.EventRecorder == nil:
+		return fmt.Errorf("the operator, kube and dynamic clients, config informers and event recorder are required")
+	case clients.KubeInformers == nil || clients.KubeInformers.InformersFor(config.OperandNamespace) == nil:
+		return fmt.Errorf("missing kube informers for namespace %q", config.OperandNamespace)
+	case len(config.StorageClassAssets) > 0 && (clients.KubeInformers.InformersFor("") == nil || clients.OperatorInformers == nil):
+		return fmt.Errorf("StorageClasses require cluster scoped kube informers and operator informers")
+	case len(config.StorageClassMigrations) > 0 && clients.KubeInformers.InformersFor("") == nil:
+		return fmt.Errorf("StorageClass migrations require cluster scoped kube informers")
+	case len(config.VolumeSnapshotClassAssets) > 0 && clients.DynamicInformers == nil:
+		return fmt.Errorf("VolumeSnapshotClasses require dynamic informers")
+	case len(config.CredentialsRequestAsset) > 0 && clients.OperatorInformers == nil:
+		return fmt.Errorf("CredentialsRequest requires operator informers")
+	case len(config.ClusterOperatorName) > 0 && clients.ConfigClient == nil:
+		return fmt.Errorf("ClusterOperator status requires a config client")
+	case clients.hasControlPlaneCluster() && (clients.ControlPlaneDynamicClient == nil || clients.ControlPlaneKubeInformers == nil):
+		return fmt.Errorf("a separate control plane requires a dynamic client and kube informers")
+	case !clients.hasControlPlaneCluster() && (clients.ControlPlaneDynamicClient != nil || clients.ControlPlaneKubeInformers != nil):
+		return fmt.Errorf("a separate control plane requires a kube client")
+	}
+	if _, _, controlPlaneKubeInformers := clients.controlPlane(); controlPlaneKubeInformers.InformersFor(controlPlaneNamespace(config)) == nil {
+		return fmt.Errorf("missing kube informers for the control plane namespace %q", controlPlaneNamespace(config))
+	}
+
+	for _, file := range allAssets(config) {
+		if _, err := fs.Stat(config.Assets, file); err != nil {
+			return fmt.Errorf("asset %q: %w", file, err)
+		}
+	}
+	return nil
+}
+
+func allAssets(config CSIDriverOperatorConfig) []string {
+	assets := append([]string{}, config.StaticAssets...)
+	assets = append(assets, config.StorageClassAssets...)
+	assets = append(assets, config.VolumeSnapshotClassAssets...)
+	assets = append(assets, config.ControlPlaneStaticAssets...)
+	for _, file := range []string{config.ControllerAsset, config.NodeAsset, config.WindowsNodeAsset, config.CredentialsRequestAsset, config.ServiceMonitorAsset} {
+		if len(file) > 0 {
+			assets = append(assets, file)
+		}
+	}
+	return assets
+}
+
+// assetObjectName returns the name of the object of the asset file.
+func assetObjectName(assets fs.FS, file string) (string, error) {
+	data, err := fs.ReadFile(assets, file)
+	if err != nil {
+		return "", fmt.Errorf("asset %q: %w", file, err)
+	}
+	object := struct {
+		metav1.ObjectMeta `json:"metadata"`
+	}{}
+	if err := yaml.Unmarshal(data, &object); err != nil {
+		return "", fmt.Errorf("asset %q: %w", file, err)
+	}
+	return object.Name, nil
+}
+
+func controlPlaneNamespace(config CSIDriverOperatorConfig) string {
+	if len(config.ControlPlaneNamespace) > 0 {
+		return config.ControlPlaneNamespace
+	}
+	return config.OperandNamespace
+}
+
+func assetFuncFromFS(fsys fs.FS) resourceapply.AssetFunc {
+	return func(name string) ([]byte, error) {
+		return fs.ReadFile(fsys, name)
+	}
+}
@@ -0,0 +1,171 @@
+package csicontrollerset
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	opv1 "github.com/openshift/api/operator/v1"
+	fakeconfig "github.com/openshift/client-go/config/clientset/versioned/fake"
+	configinformers "github.com/openshift/client-go/config/informers/externalversions"
+	fakeoperator "github.com/openshift/client-go/operator/clientset/versioned/fake"
+	operatorinformer "github.com/openshift/client-go/operator/informers/externalversions"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	fakecore "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func TestNewCSIDriverOperator(t *testing.T) {
+	const namespace = "openshift-cluster-csi-drivers"
+
+	assets := fstest.MapFS{
+		"namespace.yaml":           {Data: []byte("kind: Namespace")},
+		"controller.yaml":          {Data: []byte("kind: Deployment\nmetadata:\n  name: test-csi-driver-controller")},
+		"node.yaml":                {Data: []byte("kind: DaemonSet")},
+		"node_windows.yaml":        {Data: []byte("kind: DaemonSet")},
+		"storageclass.yaml":        {Data: []byte("kind: StorageClass")},
+		"volumesnapshotclass.yaml": {Data: []byte("kind: VolumeSnapshotClass")},
+		"controller_sa.yaml":       {Data: []byte("kind: ServiceAccount")},
+	}
+	newClients := func() CSIDriverOperatorClients {
+		kubeClient := fakecore.NewSimpleClientset()
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+		configClient := fakeconfig.NewSimpleClientset()
+		return CSIDriverOperatorClients{
+			OperatorClient:    v1helpers.NewFakeOperatorClient(&opv1.OperatorSpec{ManagementState: opv1.Managed}, &opv1.OperatorStatus{}, nil),
+			KubeClient:        kubeClient,
+			DynamicClient:     dynamicClient,
+			ConfigClient:      configClient,
+			KubeInformers:     v1helpers.NewKubeInformersForNamespaces(kubeClient, namespace, ""),
+			ConfigInformers:   configinformers.NewSharedInformerFactory(configClient, 0),
+			OperatorInformers: operatorinformer.NewSharedInformerFactory(fakeoperator.NewSimpleClientset(), 0),
+			DynamicInformers:  dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, 0),
+			EventRecorder:     events.NewInMemoryRecorder("test"),
+		}
+	}
+	newConfig := func() CSIDriverOperatorConfig {
+		return CSIDriverOperatorConfig{
+			Name:                      "TestDriver",
+			OperandNamespace:          namespace,
+			Assets:                    assets,
+			StaticAssets:              []string{"namespace.yaml"},
+			ControllerAsset:           "controller.yaml",
+			NodeAsset:                 "node.yaml",
+			WindowsNodeAsset:          "node_windows.yaml",
+			StorageClassAssets:        []string{"storageclass.yaml"},
+			VolumeSnapshotClassAssets: []string{"volumesnapshotclass.yaml"},
+			TrustedCAConfigMap:        "trusted-ca-bundle",
+			ClusterOperatorName:       "storage",
+			OperatorVersion:           "4.14.0",
+		}
+	}
+
+	testCases := []struct {
+		name                              string
+		modify                            func(*CSIDriverOperatorConfig, *CSIDriverOperatorClients)
+		expectedError                     string
+		expectControlPlaneStaticResources bool
+	}{
+		{
+			name:   "all controllers",
+			modify: func(*CSIDriverOperatorConfig, *CSIDriverOperatorClients) {},
+		},
+		{
+			name: "separate control plane",
+			modify: func(config *CSIDriverOperatorConfig, clients *CSIDriverOperatorClients) {
+				config.ControlPlaneNamespace = "clusters-test"
+				config.ControlPlaneStaticAssets = []string{"controller_sa.yaml"}
+				clients.ControlPlaneKubeClient = fakecore.NewSimpleClientset()
+				clients.ControlPlaneDynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+				clients.ControlPlaneKubeInformers = v1helpers.NewKubeInformersForNamespaces(clients.ControlPlaneKubeClient, "clusters-test")
+			},
+			expectControlPlaneStaticResources: true,
+		},
+		{
+			name: "separate control plane without informers for its namespace",
+			modify: func(config *CSIDriverOperatorConfig, clients *CSIDriverOperatorClients) {
+				config.ControlPlaneNamespace = "clusters-test"
+				clients.ControlPlaneKubeClient = fakecore.NewSimpleClientset()
+				clients.ControlPlaneDynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+				clients.ControlPlaneKubeInformers = v1helpers.NewKubeInformersForNamespaces(clients.ControlPlaneKubeClient, namespace)
+			},
+			expectedError: `kube informers for the control plane namespace "clusters-test"`,
+		},
+		{
+			name: "control plane namespace without a separate control plane",
+			modify: func(config *CSIDriverOperatorConfig, clients *CSIDriverOperatorClients) {
+				config.ControlPlaneNamespace = "clusters-test"
+			},
+			expectedError: `kube informers for the control plane namespace "clusters-test"`,
+		},
+		{
+			name: "control plane informers without a control plane kube client",
+			modify: func(config *CSIDriverOperatorConfig, clients *CSIDriverOperatorClients) {
+				config.ControlPlaneNamespace = "clusters-test"
+				clients.ControlPlaneKubeInformers = v1helpers.NewKubeInformersForNamespaces(fakecore.NewSimpleClientset(), "clusters-test")
+			},
+			expectedError: "a separate control plane requires a kube client",
+		},
+		{
+			name: "missing node asset",
+			modify: func(config *CSIDriverOperatorConfig, _ *CSIDriverOperatorClients) {
+				config.NodeAsset = ""
+			},
+			expectedError: "both the controller and the node service assets are required",
+		},
+		{
+			name: "asset not found",
+			modify: func(config *CSIDriverOperatorConfig, _ *CSIDriverOperatorClients) {
+				config.StaticAssets = append(config.StaticAssets, "rbac.yaml")
+			},
+			expectedError: `asset "rbac.yaml"`,
+		},
+		{
+			name: "missing informers for the operand namespace",
+			modify: func(_ *CSIDriverOperatorConfig, clients *CSIDriverOperatorClients) {
+				clients.KubeInformers = v1helpers.NewKubeInformersForNamespaces(clients.KubeClient, "")
+			},
+			expectedError: "missing kube informers",
+		},
+		{
+			name: "VolumeSnapshotClasses without dynamic informers",
+			modify: func(_ *CSIDriverOperatorConfig, clients *CSIDriverOperatorClients) {
+				clients.DynamicInformers = nil
+			},
+			expectedError: "VolumeSnapshotClasses require dynamic informers",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, clients := newConfig(), newClients()
+			tc.modify(&config, &clients)
+
+			operator, err := NewCSIDriverOperator(config, clients)
+			if len(tc.expectedError) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			cs := operator.CSIControllerSet
+			if cs.staticResourcesController == nil || cs.csiDriverControllerServiceController == nil || cs.csiDriverNodeServiceController == nil ||
+				cs.csiStorageclassController == nil || cs.csiSnapshotClassController == nil || cs.clusterOperatorStatusController == nil {
+				t.Errorf("expected all configured controllers to be initialized, got %#v", cs)
+			}
+			if (cs.controlPlaneStaticResourcesController != nil) != tc.expectControlPlaneStaticResources {
+				t.Errorf("expected control plane static resources controller: %v", tc.expectControlPlaneStaticResources)
+			}
+			if cs.credentialsRequestController != nil || cs.serviceMonitorController != nil {
+				t.Errorf("expected controllers without assets to be skipped, got %#v", cs)
+			}
+		})
+	}
+}
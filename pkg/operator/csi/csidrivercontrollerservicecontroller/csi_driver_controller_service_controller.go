@@ -40,6 +40,8 @@ import (
 // The controller can also override the log level passed in to the CSI driver container.
 // In order to do that, the placeholder ${LOG_LEVEL} from the manifest file is replaced with the value specified
 // in the OperatorClient resource (Spec.LogLevel).
+// To set the verbosity of each container individually (including sidecars that don't use the placeholder),
+// pass WithLogLevelDeploymentHook(DefaultLogLevelFlags()) as an optional hook. The flag name can be customized per container.
 //
 // 3. Cluster ID
 //
@@ -49,6 +51,8 @@ import (
 // 4. Leader election parameters
 // The placeholders ${LEADER_ELECTION_LEASE_DURATION}, ${LEADER_ELECTION_RENEW_DEADLINE} and ${LEADER_ELECTION_RETRY_PERIOD}
 // are replaced with OpenShift's recommended parameters for leader election.
+// To tune leader election of all sidecars consistently without the placeholders, pass WithSidecarLeaderElectionHook
+// as an optional hook. It derives the parameters from the control plane topology (SNO vs. HA) and accepts overrides.
 //
 // 5. TLS Cipher Suites
 //
@@ -56,6 +60,11 @@ import (
 // These are primarily meant for Kube RBAC sidecars, which may allow some insecure
 // ciphers unless the --tls-cipher-suites argument is explictly provided.
 //
+// 6. Scheduling
+//
+// Highly available scheduling is not enabled by default. Operators can pass WithTopologySpreadConstraintsHook,
+// WithZonalPodAntiAffinityHook and WithInfrastructureTopologyNodeSelectorHook as optional deployment hooks.
+//
 // This controller supports removable operands, as configured in pkg/operator/management.
 //
 // This controller produces the following conditions:
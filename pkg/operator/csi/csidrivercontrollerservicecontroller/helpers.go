@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	corev1 "k8s.io/client-go/informers/core/v1"
@@ -16,7 +17,9 @@ import (
 	configv1 "github.com/openshift/api/config/v1"
 	opv1 "github.com/openshift/api/operator/v1"
 	configinformers "github.com/openshift/client-go/config/informers/externalversions"
+	"github.com/openshift/library-go/pkg/config/leaderelection"
 	"github.com/openshift/library-go/pkg/operator/csi/csiconfigobservercontroller"
+	"github.com/openshift/library-go/pkg/operator/csi/csiinjection"
 	dc "github.com/openshift/library-go/pkg/operator/deploymentcontroller"
 	"github.com/openshift/library-go/pkg/operator/loglevel"
 	"github.com/openshift/library-go/pkg/operator/resource/resourcehash"
@@ -35,6 +38,12 @@ const (
 	defaultTLSCipherSuites = "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305"
 
 	infraConfigName = "cluster"
+
+	zoneTopologyKey     = "topology.kubernetes.io/zone"
+	hostnameTopologyKey = "kubernetes.io/hostname"
+
+	masterNodeRoleLabel       = "node-role.kubernetes.io/master"
+	controlPlaneNodeRoleLabel = "node-role.kubernetes.io/control-plane"
 )
 
 // WithObservedProxyDeploymentHook creates a deployment hook that injects into the deployment's containers the observed proxy config.
@@ -51,6 +60,29 @@ func WithObservedProxyDeploymentHook() dc.DeploymentHookFunc {
 	}
 }
 
+// DefaultLogLevelFlags returns the verbosity flags of the driver and sidecar containers of a CSI controller
+// Deployment, keyed by the usual container names. The result can be modified and passed to WithLogLevelDeploymentHook
+// when a container uses a different name or flag.
+func DefaultLogLevelFlags() map[string]string {
+	return map[string]string{
+		"csi-driver":         "--v",
+		"csi-provisioner":    "--v",
+		"csi-attacher":       "--v",
+		"csi-resizer":        "--v",
+		"csi-snapshotter":    "--v",
+		"csi-liveness-probe": "--v",
+	}
+}
+
+// WithLogLevelDeploymentHook creates a deployment hook that sets the verbosity flag of each container in flagNames
+// (container name -> flag name) to the verbosity derived from the operator's Spec.LogLevel.
+func WithLogLevelDeploymentHook(flagNames map[string]string) dc.DeploymentHookFunc {
+	return func(opSpec *opv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		v1helpers.SetContainerVerbosityFlags(&deployment.Spec.Template.Spec, loglevel.LogLevelToVerbosity(opSpec.LogLevel), flagNames)
+		return nil
+	}
+}
+
 func WithCABundleDeploymentHook(
 	configMapNamespace string,
 	configMapName string,
@@ -96,6 +128,19 @@ func WithCABundleDeploymentHook(
 	}
 }
 
+// WithInjectionsDeploymentHook creates a deployment hook that applies the injections to the Deployment's pod template
+// and annotates the Deployment with the hashes of their sources, so that it's rolled out when they change.
+// The informers of the sources must be passed to the controller as optional informers.
+func WithInjectionsDeploymentHook(injector csiinjection.Injector, injections ...csiinjection.Injection) dc.DeploymentHookFunc {
+	return func(_ *opv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		inputHashes, err := injector.Inject(&deployment.Spec.Template.Spec, injections...)
+		if err != nil {
+			return err
+		}
+		return addObjectHash(deployment, inputHashes)
+	}
+}
+
 // WithSecretHashAnnotationHook creates a deployment hook that annotates a Deployment with a secret's hash.
 func WithSecretHashAnnotationHook(
 	namespace string,
@@ -215,6 +260,89 @@ func WithControlPlaneTopologyHook(configInformer configinformers.SharedInformerF
 	}
 }
 
+// WithTopologySpreadConstraintsHook spreads the pods of the deployment evenly across zones and nodes.
+// The constraints are soft (ScheduleAnyway) so that the pods can still be scheduled in clusters with
+// a single zone or node. Constraints for a topology key already present in the manifest are left untouched.
+func WithTopologySpreadConstraintsHook() dc.DeploymentHookFunc {
+	return func(_ *opv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		podSpec := &deployment.Spec.Template.Spec
+		for _, topologyKey := range []string{zoneTopologyKey, hostnameTopologyKey} {
+			if hasTopologySpreadConstraint(podSpec.TopologySpreadConstraints, topologyKey) {
+				continue
+			}
+			podSpec.TopologySpreadConstraints = append(podSpec.TopologySpreadConstraints, v1.TopologySpreadConstraint{
+				MaxSkew:           1,
+				TopologyKey:       topologyKey,
+				WhenUnsatisfiable: v1.ScheduleAnyway,
+				LabelSelector:     deployment.Spec.Selector.DeepCopy(),
+			})
+		}
+		return nil
+	}
+}
+
+// WithZonalPodAntiAffinityHook makes the scheduler prefer placing the pods of the deployment in different zones.
+// The anti-affinity is preferred rather than required so that the deployment can still roll out in clusters
+// with fewer zones than replicas.
+func WithZonalPodAntiAffinityHook() dc.DeploymentHookFunc {
+	return func(_ *opv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		podSpec := &deployment.Spec.Template.Spec
+		if podSpec.Affinity == nil {
+			podSpec.Affinity = &v1.Affinity{}
+		}
+		if podSpec.Affinity.PodAntiAffinity == nil {
+			podSpec.Affinity.PodAntiAffinity = &v1.PodAntiAffinity{}
+		}
+		antiAffinity := podSpec.Affinity.PodAntiAffinity
+		for _, term := range antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			if term.PodAffinityTerm.TopologyKey == zoneTopologyKey {
+				return nil // respect what the manifest says
+			}
+		}
+		antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, v1.WeightedPodAffinityTerm{
+			Weight: 100,
+			PodAffinityTerm: v1.PodAffinityTerm{
+				LabelSelector: deployment.Spec.Selector.DeepCopy(),
+				TopologyKey:   zoneTopologyKey,
+			},
+		})
+		return nil
+	}
+}
+
+// WithInfrastructureTopologyNodeSelectorHook derives the node selector of the deployment from
+// Infrastructure.Status.ControlPlaneTopology. With an External control plane there are no control plane
+// nodes in the cluster, so the control plane node role labels are removed from the node selector while any
+// other labels are kept. Otherwise the pods are placed on control plane nodes, unless the manifest
+// already selects nodes on its own.
+func WithInfrastructureTopologyNodeSelectorHook(configInformer configinformers.SharedInformerFactory) dc.DeploymentHookFunc {
+	return func(_ *opv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		infra, err := configInformer.Config().V1().Infrastructures().Lister().Get(infraConfigName)
+		if err != nil {
+			return err
+		}
+		podSpec := &deployment.Spec.Template.Spec
+		if infra.Status.ControlPlaneTopology == configv1.ExternalTopologyMode {
+			delete(podSpec.NodeSelector, masterNodeRoleLabel)
+			delete(podSpec.NodeSelector, controlPlaneNodeRoleLabel)
+			return nil
+		}
+		if len(podSpec.NodeSelector) == 0 {
+			podSpec.NodeSelector = map[string]string{masterNodeRoleLabel: ""}
+		}
+		return nil
+	}
+}
+
+func hasTopologySpreadConstraint(constraints []v1.TopologySpreadConstraint, topologyKey string) bool {
+	for _, constraint := range constraints {
+		if constraint.TopologyKey == topologyKey {
+			return true
+		}
+	}
+	return false
+}
+
 // WithLeaderElectionReplacerHook modifies ${LEADER_ELECTION_*} parameters in a yaml file with
 // OpenShift's recommended values.
 func WithLeaderElectionReplacerHook(defaults configv1.LeaderElection) dc.ManifestHookFunc {
@@ -230,6 +358,51 @@ func WithLeaderElectionReplacerHook(defaults configv1.LeaderElection) dc.Manifes
 	}
 }
 
+// DefaultLeaderElectionContainers are the sidecars of a CSI controller Deployment that run leader election.
+func DefaultLeaderElectionContainers() []string {
+	return []string{"csi-provisioner", "csi-attacher", "csi-resizer", "csi-snapshotter"}
+}
+
+// WithSidecarLeaderElectionHook creates a deployment hook that sets the leader election flags
+// (--leader-election-lease-duration, --leader-election-renew-deadline and --leader-election-retry-period)
+// of the given containers, DefaultLeaderElectionContainers when none are given.
+// The values are OpenShift's recommended ones, relaxed for single replica control planes (SNO) as reported
+// by the Infrastructure. Non-zero durations in overrides take precedence over them.
+func WithSidecarLeaderElectionHook(configInformer configinformers.SharedInformerFactory, overrides configv1.LeaderElection, containerNames ...string) dc.DeploymentHookFunc {
+	if len(containerNames) == 0 {
+		containerNames = DefaultLeaderElectionContainers()
+	}
+	ha := leaderelection.LeaderElectionDefaulting(overrides, "default", "default")
+	sno := leaderelection.LeaderElectionSNOConfig(overrides)
+	if overrides.LeaseDuration.Duration != 0 {
+		sno.LeaseDuration = overrides.LeaseDuration
+	}
+	if overrides.RenewDeadline.Duration != 0 {
+		sno.RenewDeadline = overrides.RenewDeadline
+	}
+	if overrides.RetryPeriod.Duration != 0 {
+		sno.RetryPeriod = overrides.RetryPeriod
+	}
+
+	return func(_ *opv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		infra, err := configInformer.Config().V1().Infrastructures().Lister().Get(infraConfigName)
+		if err != nil {
+			return err
+		}
+		le := ha
+		if infra.Status.ControlPlaneTopology == configv1.SingleReplicaTopologyMode {
+			le = sno
+		}
+
+		podSpec := &deployment.Spec.Template.Spec
+		// truncate to int() to avoid long floats ("137.000000s")
+		v1helpers.SetContainerFlag(podSpec, containerNames, "--leader-election-lease-duration", fmt.Sprintf("%ds", int(le.LeaseDuration.Seconds())))
+		v1helpers.SetContainerFlag(podSpec, containerNames, "--leader-election-renew-deadline", fmt.Sprintf("%ds", int(le.RenewDeadline.Seconds())))
+		v1helpers.SetContainerFlag(podSpec, containerNames, "--leader-election-retry-period", fmt.Sprintf("%ds", int(le.RetryPeriod.Seconds())))
+		return nil
+	}
+}
+
 func addObjectHash(deployment *appsv1.Deployment, inputHashes map[string]string) error {
 	if deployment == nil {
 		return fmt.Errorf("invalid deployment: %v", deployment)
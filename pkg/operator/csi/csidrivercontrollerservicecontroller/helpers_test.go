@@ -19,9 +19,11 @@ import (
 	"github.com/google/go-cmp/cmp"
 
 	configv1 "github.com/openshift/api/config/v1"
+	opv1 "github.com/openshift/api/operator/v1"
 	fakeconfig "github.com/openshift/client-go/config/clientset/versioned/fake"
 	configinformers "github.com/openshift/client-go/config/informers/externalversions"
 	"github.com/openshift/library-go/pkg/operator/csi/csiconfigobservercontroller"
+	dc "github.com/openshift/library-go/pkg/operator/deploymentcontroller"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
 )
 
@@ -421,6 +423,100 @@ func TestWithControlPlaneTopologyHook(t *testing.T) {
 	}
 }
 
+func TestSchedulingHooks(t *testing.T) {
+	argsLevel2 := 2
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test-csi-driver-controller"}}
+
+	tests := []struct {
+		name               string
+		hook               func(configinformers.SharedInformerFactory) dc.DeploymentHookFunc
+		infra              *configv1.Infrastructure
+		initialDeployment  *appsv1.Deployment
+		expectedDeployment *appsv1.Deployment
+	}{
+		{
+			name: "topology spread constraints added",
+			hook: func(configinformers.SharedInformerFactory) dc.DeploymentHookFunc {
+				return WithTopologySpreadConstraintsHook()
+			},
+			initialDeployment: makeDeployment(defaultClusterID, argsLevel2, defaultImages()),
+			expectedDeployment: makeDeployment(defaultClusterID, argsLevel2, defaultImages(), withTopologySpreadConstraints(
+				v1.TopologySpreadConstraint{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: v1.ScheduleAnyway, LabelSelector: selector},
+				v1.TopologySpreadConstraint{MaxSkew: 1, TopologyKey: "kubernetes.io/hostname", WhenUnsatisfiable: v1.ScheduleAnyway, LabelSelector: selector},
+			)),
+		},
+		{
+			name: "topology spread constraints from the manifest are kept",
+			hook: func(configinformers.SharedInformerFactory) dc.DeploymentHookFunc {
+				return WithTopologySpreadConstraintsHook()
+			},
+			initialDeployment: makeDeployment(defaultClusterID, argsLevel2, defaultImages(), withTopologySpreadConstraints(
+				v1.TopologySpreadConstraint{MaxSkew: 2, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: v1.DoNotSchedule, LabelSelector: selector},
+			)),
+			expectedDeployment: makeDeployment(defaultClusterID, argsLevel2, defaultImages(), withTopologySpreadConstraints(
+				v1.TopologySpreadConstraint{MaxSkew: 2, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: v1.DoNotSchedule, LabelSelector: selector},
+				v1.TopologySpreadConstraint{MaxSkew: 1, TopologyKey: "kubernetes.io/hostname", WhenUnsatisfiable: v1.ScheduleAnyway, LabelSelector: selector},
+			)),
+		},
+		{
+			name: "zonal anti-affinity added",
+			hook: func(configinformers.SharedInformerFactory) dc.DeploymentHookFunc {
+				return WithZonalPodAntiAffinityHook()
+			},
+			initialDeployment: makeDeployment(defaultClusterID, argsLevel2, defaultImages()),
+			expectedDeployment: makeDeployment(defaultClusterID, argsLevel2, defaultImages(), func(deployment *appsv1.Deployment) *appsv1.Deployment {
+				deployment.Spec.Template.Spec.Affinity = &v1.Affinity{PodAntiAffinity: &v1.PodAntiAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{{
+						Weight:          100,
+						PodAffinityTerm: v1.PodAffinityTerm{LabelSelector: selector, TopologyKey: "topology.kubernetes.io/zone"},
+					}},
+				}}
+				return deployment
+			}),
+		},
+		{
+			name:               "external topology drops control plane role from the node selector",
+			hook:               WithInfrastructureTopologyNodeSelectorHook,
+			infra:              makeInfraWithCPTopology(configv1.ExternalTopologyMode),
+			initialDeployment:  makeDeployment(defaultClusterID, argsLevel2, defaultImages(), withNodeSelector(map[string]string{"node-role.kubernetes.io/master": "", "kubernetes.io/os": "linux"})),
+			expectedDeployment: makeDeployment(defaultClusterID, argsLevel2, defaultImages(), withNodeSelector(map[string]string{"kubernetes.io/os": "linux"})),
+		},
+		{
+			name:               "highly available topology selects control plane nodes",
+			hook:               WithInfrastructureTopologyNodeSelectorHook,
+			infra:              makeInfraWithCPTopology(configv1.HighlyAvailableTopologyMode),
+			initialDeployment:  makeDeployment(defaultClusterID, argsLevel2, defaultImages(), withNodeSelector(nil)),
+			expectedDeployment: makeDeployment(defaultClusterID, argsLevel2, defaultImages(), withNodeSelector(map[string]string{"node-role.kubernetes.io/master": ""})),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configClient := fakeconfig.NewSimpleClientset()
+			configInformerFactory := configinformers.NewSharedInformerFactory(configClient, 0)
+			if test.infra != nil {
+				configInformerFactory.Config().V1().Infrastructures().Informer().GetIndexer().Add(test.infra)
+			}
+
+			fn := test.hook(configInformerFactory)
+			err := fn(nil, test.initialDeployment)
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if !equality.Semantic.DeepEqual(test.initialDeployment, test.expectedDeployment) {
+				t.Errorf("Unexpected Deployment content:\n%s", cmp.Diff(test.initialDeployment, test.expectedDeployment))
+			}
+		})
+	}
+}
+
+func withTopologySpreadConstraints(constraints ...v1.TopologySpreadConstraint) deploymentModifier {
+	return func(deployment *appsv1.Deployment) *appsv1.Deployment {
+		deployment.Spec.Template.Spec.TopologySpreadConstraints = constraints
+		return deployment
+	}
+}
+
 func withNodeSelector(nodeSelector map[string]string) deploymentModifier {
 	return func(deployment *appsv1.Deployment) *appsv1.Deployment {
 		deployment.Spec.Template.Spec.NodeSelector = nodeSelector
@@ -513,3 +609,127 @@ func TestWithLeaderElectionReplacerHook(t *testing.T) {
 		t.Errorf("expected %q, got %q", expected, string(out))
 	}
 }
+
+func TestWithLogLevelDeploymentHook(t *testing.T) {
+	flagNames := DefaultLogLevelFlags()
+	flagNames["csi-custom-sidecar"] = "--log-level"
+
+	deployment := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{Name: "csi-driver", Args: []string{"--endpoint=$(CSI_ENDPOINT)", "--v=2"}},
+						{Name: "csi-provisioner", Args: []string{"--v", "2", "--csi-address=$(ADDRESS)"}},
+						{Name: "csi-attacher", Args: []string{"--csi-address=$(ADDRESS)"}},
+						{Name: "csi-resizer", Args: []string{"-v=2", "--csi-address=$(ADDRESS)"}},
+						{Name: "csi-snapshotter", Args: []string{"--log-level", "debug"}},
+						{Name: "csi-custom-sidecar", Args: []string{"--log-level=2"}},
+						{Name: "kube-rbac-proxy", Args: []string{"--logtostderr"}},
+					},
+				},
+			},
+		},
+	}
+
+	err := WithLogLevelDeploymentHook(flagNames)(&opv1.OperatorSpec{LogLevel: opv1.Trace}, deployment)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedArgs := map[string][]string{
+		"csi-driver":         {"--endpoint=$(CSI_ENDPOINT)", "--v=6"},
+		"csi-provisioner":    {"--v", "6", "--csi-address=$(ADDRESS)"},
+		"csi-attacher":       {"--csi-address=$(ADDRESS)", "--v=6"},
+		"csi-resizer":        {"-v=2", "--csi-address=$(ADDRESS)"},
+		"csi-snapshotter":    {"--log-level", "debug"},
+		"csi-custom-sidecar": {"--log-level=6"},
+		"kube-rbac-proxy":    {"--logtostderr"},
+	}
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if !equality.Semantic.DeepEqual(container.Args, expectedArgs[container.Name]) {
+			t.Errorf("unexpected args of container %s: %v", container.Name, container.Args)
+		}
+	}
+}
+
+func TestWithSidecarLeaderElectionHook(t *testing.T) {
+	tests := []struct {
+		name             string
+		topology         configv1.TopologyMode
+		overrides        configv1.LeaderElection
+		expectedSidecars []string
+		expectedOther    []string
+	}{
+		{
+			name:     "highly available topology",
+			topology: configv1.HighlyAvailableTopologyMode,
+			expectedSidecars: []string{
+				"--csi-address=$(ADDRESS)",
+				"--leader-election-lease-duration=137s",
+				"--leader-election-renew-deadline=107s",
+				"--leader-election-retry-period=26s",
+			},
+		},
+		{
+			name:     "single replica topology",
+			topology: configv1.SingleReplicaTopologyMode,
+			expectedSidecars: []string{
+				"--csi-address=$(ADDRESS)",
+				"--leader-election-lease-duration=270s",
+				"--leader-election-renew-deadline=240s",
+				"--leader-election-retry-period=60s",
+			},
+		},
+		{
+			name:      "overrides",
+			topology:  configv1.SingleReplicaTopologyMode,
+			overrides: configv1.LeaderElection{RetryPeriod: metav1.Duration{Duration: 10 * time.Second}},
+			expectedSidecars: []string{
+				"--csi-address=$(ADDRESS)",
+				"--leader-election-lease-duration=270s",
+				"--leader-election-renew-deadline=240s",
+				"--leader-election-retry-period=10s",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			infra := makeInfraWithCPTopology(test.topology)
+			configClient := fakeconfig.NewSimpleClientset(infra)
+			configInformerFactory := configinformers.NewSharedInformerFactory(configClient, 0)
+			configInformerFactory.Config().V1().Infrastructures().Informer().GetIndexer().Add(infra)
+
+			deployment := &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: v1.PodTemplateSpec{
+						Spec: v1.PodSpec{
+							Containers: []v1.Container{
+								{Name: "csi-driver", Args: []string{"--endpoint=$(CSI_ENDPOINT)"}},
+								{Name: "csi-provisioner", Args: []string{"--csi-address=$(ADDRESS)", "--leader-election-lease-duration=1s"}},
+								{Name: "csi-attacher", Args: []string{"--csi-address=$(ADDRESS)"}},
+							},
+						},
+					},
+				},
+			}
+
+			err := WithSidecarLeaderElectionHook(configInformerFactory, test.overrides)(nil, deployment)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expectedArgs := map[string][]string{
+				"csi-driver":      {"--endpoint=$(CSI_ENDPOINT)"},
+				"csi-provisioner": test.expectedSidecars,
+				"csi-attacher":    test.expectedSidecars,
+			}
+			for _, container := range deployment.Spec.Template.Spec.Containers {
+				if !equality.Semantic.DeepEqual(container.Args, expectedArgs[container.Name]) {
+					t.Errorf("unexpected args of container %s: %v", container.Name, container.Args)
+				}
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	appsinformersv1 "k8s.io/client-go/informers/apps/v1"
@@ -30,6 +31,12 @@ const (
 	nodeDriverRegistrarImageEnvName = "NODE_DRIVER_REGISTRAR_IMAGE"
 	livenessProbeImageEnvName       = "LIVENESS_PROBE_IMAGE"
 	kubeRBACProxyImageEnvName       = "KUBE_RBAC_PROXY_IMAGE"
+
+	windowsDriverImageEnvName              = "WINDOWS_DRIVER_IMAGE"
+	windowsNodeDriverRegistrarImageEnvName = "WINDOWS_NODE_DRIVER_REGISTRAR_IMAGE"
+	windowsLivenessProbeImageEnvName       = "WINDOWS_LIVENESS_PROBE_IMAGE"
+
+	osNodeLabel = "kubernetes.io/os"
 )
 
 // DaemonSetHookFunc is a hook function to modify the DaemonSet.
@@ -58,6 +65,16 @@ type DaemonSetHookFunc func(*opv1.OperatorSpec, *appsv1.DaemonSet) error
 // The controller can also override the log level passed in to the CSI driver container.
 // In order to do that, the placeholder ${LOG_LEVEL} from the manifest file is replaced with the value specified
 // in the OperatorClient resource (Spec.LogLevel).
+// To set the verbosity of each container individually (including sidecars that don't use the placeholder),
+// pass WithLogLevelDaemonSetHook(DefaultLogLevelFlags()) as an optional hook. The flag name can be customized per container.
+//
+// 3. Windows nodes
+//
+// When created with NewCSIDriverNodeServiceControllerWithWindows, the controller manages a second DaemonSet
+// from a separate manifest that runs the node plugin on Windows nodes. Its images are taken from the
+// WINDOWS_DRIVER_IMAGE, WINDOWS_NODE_DRIVER_REGISTRAR_IMAGE and WINDOWS_LIVENESS_PROBE_IMAGE environment variables
+// and it's always scheduled to Windows nodes only. The conditions below cover both DaemonSets; the Windows
+// DaemonSet does not make the controller unavailable when there are no Windows nodes in the cluster.
 //
 // This controller supports removable operands, as configured in pkg/operator/management.
 //
@@ -77,6 +94,10 @@ type CSIDriverNodeServiceController struct {
 	// fails indicating the ordinal position of the failed function.
 	// Also, in that scenario the Degraded status is set to True.
 	optionalDaemonSetHooks []DaemonSetHookFunc
+	// Optional manifest of the DaemonSet for Windows nodes.
+	// Empty when the CSI driver doesn't ship a Windows node plugin.
+	windowsManifest               []byte
+	optionalWindowsDaemonSetHooks []DaemonSetHookFunc
 }
 
 func NewCSIDriverNodeServiceController(
@@ -97,7 +118,39 @@ func NewCSIDriverNodeServiceController(
 		dsInformer:             dsInformer,
 		optionalDaemonSetHooks: optionalDaemonSetHooks,
 	}
-	informers := append(optionalInformers, operatorClient.Informer(), dsInformer.Informer())
+	return c.toController(recorder, optionalInformers)
+}
+
+// NewCSIDriverNodeServiceControllerWithWindows returns a controller that, in addition to the DaemonSet from manifest,
+// manages a DaemonSet for Windows nodes from windowsManifest. The hooks in optionalDaemonSetHooks are applied to
+// the first DaemonSet only, the ones in optionalWindowsDaemonSetHooks to the Windows DaemonSet only.
+func NewCSIDriverNodeServiceControllerWithWindows(
+	name string,
+	manifest []byte,
+	windowsManifest []byte,
+	recorder events.Recorder,
+	operatorClient v1helpers.OperatorClientWithFinalizers,
+	kubeClient kubernetes.Interface,
+	dsInformer appsinformersv1.DaemonSetInformer,
+	optionalInformers []factory.Informer,
+	optionalDaemonSetHooks []DaemonSetHookFunc,
+	optionalWindowsDaemonSetHooks ...DaemonSetHookFunc,
+) factory.Controller {
+	c := &CSIDriverNodeServiceController{
+		name:                          name,
+		manifest:                      manifest,
+		operatorClient:                operatorClient,
+		kubeClient:                    kubeClient,
+		dsInformer:                    dsInformer,
+		optionalDaemonSetHooks:        optionalDaemonSetHooks,
+		windowsManifest:               windowsManifest,
+		optionalWindowsDaemonSetHooks: optionalWindowsDaemonSetHooks,
+	}
+	return c.toController(recorder, optionalInformers)
+}
+
+func (c *CSIDriverNodeServiceController) toController(recorder events.Recorder, optionalInformers []factory.Informer) factory.Controller {
+	informers := append(optionalInformers, c.operatorClient.Informer(), c.dsInformer.Informer())
 	return factory.New().WithInformers(
 		informers...,
 	).WithSync(
@@ -105,10 +158,10 @@ func NewCSIDriverNodeServiceController(
 	).ResyncEvery(
 		time.Minute,
 	).WithSyncDegradedOnError(
-		operatorClient,
+		c.operatorClient,
 	).ToController(
 		c.name,
-		recorder.WithComponentSuffix("csi-driver-node-service_"+strings.ToLower(name)),
+		recorder.WithComponentSuffix("csi-driver-node-service_"+strings.ToLower(c.name)),
 	)
 }
 
@@ -163,6 +216,24 @@ func (c *CSIDriverNodeServiceController) syncManaged(ctx context.Context, opSpec
 		return err
 	}
 
+	var windowsDaemonSet *appsv1.DaemonSet
+	if c.hasWindowsDaemonSet() {
+		requiredWindows, err := c.getWindowsDaemonSet(opSpec)
+		if err != nil {
+			return err
+		}
+		windowsDaemonSet, _, err = resourceapply.ApplyDaemonSet(
+			ctx,
+			c.kubeClient.AppsV1(),
+			syncContext.Recorder(),
+			requiredWindows,
+			resourcemerge.ExpectedDaemonSetGeneration(requiredWindows, opStatus.Generations),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
 	availableCondition := opv1.OperatorCondition{
 		Type:   c.name + opv1.OperatorStatusTypeAvailable,
 		Status: opv1.ConditionTrue,
@@ -175,6 +246,12 @@ func (c *CSIDriverNodeServiceController) syncManaged(ctx context.Context, opSpec
 		availableCondition.Message = "Waiting for the DaemonSet to deploy the CSI Node Service"
 		availableCondition.Reason = "Deploying"
 	}
+	// a cluster without Windows nodes has nothing to deploy
+	if windowsDaemonSet != nil && windowsDaemonSet.Status.DesiredNumberScheduled > 0 && windowsDaemonSet.Status.NumberAvailable == 0 {
+		availableCondition.Status = opv1.ConditionFalse
+		availableCondition.Message = "Waiting for the Windows DaemonSet to deploy the CSI Node Service"
+		availableCondition.Reason = "Deploying"
+	}
 
 	progressingCondition := opv1.OperatorCondition{
 		Type:   c.name + opv1.OperatorStatusTypeProgressing,
@@ -185,11 +262,20 @@ func (c *CSIDriverNodeServiceController) syncManaged(ctx context.Context, opSpec
 		progressingCondition.Status = opv1.ConditionTrue
 		progressingCondition.Message = msg
 		progressingCondition.Reason = "Deploying"
+	} else if windowsDaemonSet != nil {
+		if ok, msg := isProgressing(opStatus, windowsDaemonSet); ok {
+			progressingCondition.Status = opv1.ConditionTrue
+			progressingCondition.Message = "Windows: " + msg
+			progressingCondition.Reason = "Deploying"
+		}
 	}
 
 	updateStatusFn := func(newStatus *opv1.OperatorStatus) error {
 		// TODO: set ObservedGeneration (the last stable generation change we dealt with)
 		resourcemerge.SetDaemonSetGeneration(&newStatus.Generations, daemonSet)
+		if windowsDaemonSet != nil {
+			resourcemerge.SetDaemonSetGeneration(&newStatus.Generations, windowsDaemonSet)
+		}
 		return nil
 	}
 
@@ -217,6 +303,50 @@ func (c *CSIDriverNodeServiceController) getDaemonSet(opSpec *opv1.OperatorSpec)
 	return required, nil
 }
 
+func (c *CSIDriverNodeServiceController) hasWindowsDaemonSet() bool {
+	return len(c.windowsManifest) > 0
+}
+
+func (c *CSIDriverNodeServiceController) getWindowsDaemonSet(opSpec *opv1.OperatorSpec) (*appsv1.DaemonSet, error) {
+	manifest := replacePlaceholders(c.windowsManifest, opSpec)
+	required := resourceread.ReadDaemonSetV1OrDie(manifest)
+
+	for i := range c.optionalWindowsDaemonSetHooks {
+		err := c.optionalWindowsDaemonSetHooks[i](opSpec, required)
+		if err != nil {
+			return nil, fmt.Errorf("error running Windows hook function (index=%d): %w", i, err)
+		}
+	}
+
+	// whatever the manifest and the hooks say, Windows pods can only run on Windows nodes
+	podSpec := &required.Spec.Template.Spec
+	if podSpec.NodeSelector == nil {
+		podSpec.NodeSelector = map[string]string{}
+	}
+	podSpec.NodeSelector[osNodeLabel] = "windows"
+	if !hasToleration(podSpec.Tolerations, windowsNodeToleration) {
+		podSpec.Tolerations = append(podSpec.Tolerations, windowsNodeToleration)
+	}
+	return required, nil
+}
+
+// windowsNodeToleration tolerates the taint Windows nodes in OpenShift are created with.
+var windowsNodeToleration = corev1.Toleration{
+	Key:      "os",
+	Operator: corev1.TolerationOpEqual,
+	Value:    "Windows",
+	Effect:   corev1.TaintEffectNoSchedule,
+}
+
+func hasToleration(tolerations []corev1.Toleration, toleration corev1.Toleration) bool {
+	for _, t := range tolerations {
+		if t.MatchToleration(&toleration) {
+			return true
+		}
+	}
+	return false
+}
+
 func isProgressing(status *opv1.OperatorStatus, daemonSet *appsv1.DaemonSet) (bool, string) {
 	switch {
 	case daemonSet.Generation != daemonSet.Status.ObservedGeneration:
@@ -247,6 +377,21 @@ func replacePlaceholders(manifest []byte, spec *opv1.OperatorSpec) []byte {
 		pairs = append(pairs, []string{"${LIVENESS_PROBE_IMAGE}", livenessProbe}...)
 	}
 
+	windowsDriver := os.Getenv(windowsDriverImageEnvName)
+	if windowsDriver != "" {
+		pairs = append(pairs, []string{"${WINDOWS_DRIVER_IMAGE}", windowsDriver}...)
+	}
+
+	windowsNodeDriverRegistrar := os.Getenv(windowsNodeDriverRegistrarImageEnvName)
+	if windowsNodeDriverRegistrar != "" {
+		pairs = append(pairs, []string{"${WINDOWS_NODE_DRIVER_REGISTRAR_IMAGE}", windowsNodeDriverRegistrar}...)
+	}
+
+	windowsLivenessProbe := os.Getenv(windowsLivenessProbeImageEnvName)
+	if windowsLivenessProbe != "" {
+		pairs = append(pairs, []string{"${WINDOWS_LIVENESS_PROBE_IMAGE}", windowsLivenessProbe}...)
+	}
+
 	kubeRBACProxy := os.Getenv(kubeRBACProxyImageEnvName)
 	if kubeRBACProxy != "" {
 		pairs = append(pairs, []string{"${KUBE_RBAC_PROXY_IMAGE}", kubeRBACProxy}...)
@@ -274,6 +419,19 @@ func (c *CSIDriverNodeServiceController) syncDeleting(ctx context.Context, opSpe
 		klog.V(2).Infof("Deleted DaemonSet %s/%s", required.Namespace, required.Name)
 	}
 
+	if c.hasWindowsDaemonSet() {
+		requiredWindows, err := c.getWindowsDaemonSet(opSpec)
+		if err != nil {
+			return err
+		}
+		err = c.kubeClient.AppsV1().DaemonSets(requiredWindows.Namespace).Delete(ctx, requiredWindows.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		} else {
+			klog.V(2).Infof("Deleted DaemonSet %s/%s", requiredWindows.Namespace, requiredWindows.Name)
+		}
+	}
+
 	// All removed, remove the finalizer as the last step
 	return v1helpers.RemoveFinalizer(ctx, c.operatorClient, c.name)
 }
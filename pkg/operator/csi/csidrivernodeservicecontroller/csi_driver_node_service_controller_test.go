@@ -800,3 +800,128 @@ spec:
             type: Directory
 `)
 }
+
+func makeFakeWindowsManifest() []byte {
+	return []byte(`
+kind: DaemonSet
+apiVersion: apps/v1
+metadata:
+  name: test-csi-driver-node-windows
+  namespace: openshift-test-csi-driver
+spec:
+  selector:
+    matchLabels:
+      app: test-csi-driver-node-windows
+  template:
+    metadata:
+      labels:
+        app: test-csi-driver-node-windows
+    spec:
+      containers:
+        - name: csi-driver
+          image: ${WINDOWS_DRIVER_IMAGE}
+          args:
+            - --v=${LOG_LEVEL}
+        - name: csi-node-driver-registrar
+          image: ${WINDOWS_NODE_DRIVER_REGISTRAR_IMAGE}
+`)
+}
+
+func TestSyncWithWindows(t *testing.T) {
+	const windowsDaemonSetName = "test-csi-driver-node-windows"
+
+	testCases := []struct {
+		name                  string
+		windowsDaemonSet      *appsv1.DaemonSet
+		expectedAvailable     opv1.ConditionStatus
+		expectedProgressing   opv1.ConditionStatus
+		expectedProgressedMsg string
+	}{
+		{
+			name:                "no Windows nodes",
+			expectedAvailable:   opv1.ConditionTrue,
+			expectedProgressing: opv1.ConditionFalse,
+		},
+		{
+			name: "Windows pods not available",
+			windowsDaemonSet: &appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Name: windowsDaemonSetName, Namespace: operandNamespace, Generation: 1},
+				Status:     appsv1.DaemonSetStatus{ObservedGeneration: 1, DesiredNumberScheduled: 1, NumberUnavailable: 1},
+			},
+			expectedAvailable:     opv1.ConditionFalse,
+			expectedProgressing:   opv1.ConditionTrue,
+			expectedProgressedMsg: "Windows: Waiting for DaemonSet to deploy node pods",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			management.SetOperatorNotRemovable()
+			os.Setenv(windowsDriverImageEnvName, "quay.io/openshift/origin-test-csi-driver-windows:latest")
+			os.Setenv(windowsNodeDriverRegistrarImageEnvName, "quay.io/openshift/origin-csi-node-driver-registrar-windows:latest")
+			defer os.Unsetenv(windowsDriverImageEnvName)
+			defer os.Unsetenv(windowsNodeDriverRegistrarImageEnvName)
+
+			linuxDaemonSet := getDaemonSet(2, defaultImages(), withDaemonSetGeneration(1, 1), withDaemonSetStatus(1, 1, 1, 0))
+			initialObjects := []runtime.Object{linuxDaemonSet}
+			if test.windowsDaemonSet != nil {
+				initialObjects = append(initialObjects, test.windowsDaemonSet)
+			}
+			coreClient := fakecore.NewSimpleClientset(initialObjects...)
+			coreInformerFactory := coreinformers.NewSharedInformerFactory(coreClient, 0 /*no resync */)
+			driver := makeFakeDriverInstance()
+			fakeOperatorClient := v1helpers.NewFakeOperatorClientWithObjectMeta(&driver.ObjectMeta, &driver.Spec, &driver.Status, nil)
+
+			controller := NewCSIDriverNodeServiceControllerWithWindows(
+				controllerName,
+				makeFakeManifest(),
+				makeFakeWindowsManifest(),
+				events.NewInMemoryRecorder(operandName),
+				fakeOperatorClient,
+				coreClient,
+				coreInformerFactory.Apps().V1().DaemonSets(),
+				nil, /* optional informers */
+				nil, /* optional DaemonSet hooks */
+				daemonSetAnnotationHook,
+			)
+
+			if err := controller.Sync(context.TODO(), factory.NewSyncContext(controllerName, events.NewInMemoryRecorder("test-csi-driver"))); err != nil {
+				t.Fatalf("sync() returned unexpected error: %v", err)
+			}
+
+			windowsDaemonSet, err := coreClient.AppsV1().DaemonSets(operandNamespace).Get(context.TODO(), windowsDaemonSetName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Failed to get Windows DaemonSet: %v", err)
+			}
+			podSpec := windowsDaemonSet.Spec.Template.Spec
+			if podSpec.NodeSelector["kubernetes.io/os"] != "windows" {
+				t.Errorf("expected Windows node selector, got %v", podSpec.NodeSelector)
+			}
+			if !hasToleration(podSpec.Tolerations, windowsNodeToleration) {
+				t.Errorf("expected Windows toleration, got %v", podSpec.Tolerations)
+			}
+			if image := podSpec.Containers[0].Image; image != "quay.io/openshift/origin-test-csi-driver-windows:latest" {
+				t.Errorf("unexpected Windows driver image %q", image)
+			}
+			if windowsDaemonSet.Annotations[hookDaemonSetAnnKey] != hookDaemonSetAnnVal {
+				t.Errorf("expected the Windows hook to be applied, got annotations %v", windowsDaemonSet.Annotations)
+			}
+
+			_, status, _, err := fakeOperatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			available := v1helpers.FindOperatorCondition(status.Conditions, conditionAvailable)
+			if available == nil || available.Status != test.expectedAvailable {
+				t.Errorf("expected %s=%s, got %v", conditionAvailable, test.expectedAvailable, available)
+			}
+			progressing := v1helpers.FindOperatorCondition(status.Conditions, conditionProgressing)
+			if progressing == nil || progressing.Status != test.expectedProgressing || progressing.Message != test.expectedProgressedMsg {
+				t.Errorf("expected %s=%s (%q), got %v", conditionProgressing, test.expectedProgressing, test.expectedProgressedMsg, progressing)
+			}
+			if len(status.Generations) != 2 {
+				t.Errorf("expected generations of both DaemonSets to be recorded, got %v", status.Generations)
+			}
+		})
+	}
+}
@@ -11,6 +11,8 @@ import (
 	corev1 "k8s.io/client-go/informers/core/v1"
 
 	"github.com/openshift/library-go/pkg/operator/csi/csiconfigobservercontroller"
+	"github.com/openshift/library-go/pkg/operator/csi/csiinjection"
+	"github.com/openshift/library-go/pkg/operator/loglevel"
 	"github.com/openshift/library-go/pkg/operator/resource/resourcehash"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 )
@@ -29,6 +31,26 @@ func WithObservedProxyDaemonSetHook() DaemonSetHookFunc {
 	}
 }
 
+// DefaultLogLevelFlags returns the verbosity flags of the driver and sidecar containers of a CSI node
+// DaemonSet, keyed by the usual container names. The result can be modified and passed to WithLogLevelDaemonSetHook
+// when a container uses a different name or flag.
+func DefaultLogLevelFlags() map[string]string {
+	return map[string]string{
+		"csi-driver":                "--v",
+		"csi-node-driver-registrar": "--v",
+		"csi-liveness-probe":        "--v",
+	}
+}
+
+// WithLogLevelDaemonSetHook creates a hook that sets the verbosity flag of each container in flagNames
+// (container name -> flag name) to the verbosity derived from the operator's Spec.LogLevel.
+func WithLogLevelDaemonSetHook(flagNames map[string]string) DaemonSetHookFunc {
+	return func(opSpec *opv1.OperatorSpec, daemonSet *appsv1.DaemonSet) error {
+		v1helpers.SetContainerVerbosityFlags(&daemonSet.Spec.Template.Spec, loglevel.LogLevelToVerbosity(opSpec.LogLevel), flagNames)
+		return nil
+	}
+}
+
 func WithCABundleDaemonSetHook(
 	configMapNamespace string,
 	configMapName string,
@@ -74,6 +96,19 @@ func WithCABundleDaemonSetHook(
 	}
 }
 
+// WithInjectionsDaemonSetHook creates a hook that applies the injections to the DaemonSet's pod template
+// and annotates the DaemonSet with the hashes of their sources, so that it's rolled out when they change.
+// The informers of the sources must be passed to the controller as optional informers.
+func WithInjectionsDaemonSetHook(injector csiinjection.Injector, injections ...csiinjection.Injection) DaemonSetHookFunc {
+	return func(_ *opv1.OperatorSpec, daemonSet *appsv1.DaemonSet) error {
+		inputHashes, err := injector.Inject(&daemonSet.Spec.Template.Spec, injections...)
+		if err != nil {
+			return err
+		}
+		return addObjectHash(daemonSet, inputHashes)
+	}
+}
+
 // WithSecretHashAnnotationHook creates a DaemonSet hook that annotates a DaemonSet with a secret's hash.
 func WithSecretHashAnnotationHook(
 	namespace string,
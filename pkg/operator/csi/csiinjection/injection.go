@@ -0,0 +1,196 @@
+package csiinjection
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	"github.com/openshift/library-go/pkg/operator/resource/resourcehash"
+)
+
+// SourceKind is the kind of object an Injection depends on.
+type SourceKind string
+
+const (
+	ConfigMapSource SourceKind = "ConfigMap"
+	SecretSource    SourceKind = "Secret"
+)
+
+// Source is a ConfigMap or Secret an Injection depends on.
+type Source struct {
+	Kind      SourceKind
+	Namespace string
+	Name      string
+	// RequiredKeys must all be present in the source, otherwise the Injection is skipped.
+	RequiredKeys []string
+}
+
+// Injection declares environment variables, volumes and volume mounts to inject into containers of a CSI operand.
+//
+// When Source is set, the injection is skipped as long as the source does not exist (or misses one
+// of its RequiredKeys). Once injected, the hash of the source is returned so that the caller can annotate
+// the pod template with it and changes of the source are rolled out.
+type Injection struct {
+	// ContainerNames are the containers (including init containers) to inject the env vars and volume mounts into.
+	// All containers are injected when empty.
+	ContainerNames []string
+	Env            []corev1.EnvVar
+	Volumes        []corev1.Volume
+	VolumeMounts   []corev1.VolumeMount
+	Source         *Source
+}
+
+// Injector applies Injections to pod templates, resolving their sources from listers.
+// The listers are only needed for injections with a source of their kind.
+type Injector struct {
+	ConfigMapLister corev1listers.ConfigMapLister
+	SecretLister    corev1listers.SecretLister
+}
+
+// Inject applies the injections to the pod spec. It returns the hashes of the sources
+// of the applied injections, keyed as in resourcehash.MultipleObjectHashStringMap.
+// Env vars, volumes and volume mounts already present with the same name are replaced,
+// so injecting into a pod spec twice yields the same result.
+func (i Injector) Inject(podSpec *corev1.PodSpec, injections ...Injection) (map[string]string, error) {
+	var sources []*resourcehash.ObjectReference
+	for _, injection := range injections {
+		found, err := i.sourceFound(injection.Source)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+
+		for _, volume := range injection.Volumes {
+			podSpec.Volumes = setVolume(podSpec.Volumes, volume)
+		}
+		for c := range podSpec.InitContainers {
+			injectIntoContainer(&podSpec.InitContainers[c], injection)
+		}
+		for c := range podSpec.Containers {
+			injectIntoContainer(&podSpec.Containers[c], injection)
+		}
+
+		if injection.Source != nil {
+			sources = append(sources, objectRef(injection.Source))
+		}
+	}
+
+	if len(sources) == 0 {
+		return map[string]string{}, nil
+	}
+	return resourcehash.MultipleObjectHashStringMapForObjectReferenceFromLister(i.ConfigMapLister, i.SecretLister, sources...)
+}
+
+func (i Injector) sourceFound(source *Source) (bool, error) {
+	if source == nil {
+		return true, nil
+	}
+
+	var keys map[string]bool
+	var err error
+	switch source.Kind {
+	case ConfigMapSource:
+		if i.ConfigMapLister == nil {
+			return false, fmt.Errorf("no ConfigMap lister to get %s/%s", source.Namespace, source.Name)
+		}
+		var cm *corev1.ConfigMap
+		if cm, err = i.ConfigMapLister.ConfigMaps(source.Namespace).Get(source.Name); err == nil {
+			keys = map[string]bool{}
+			for k := range cm.Data {
+				keys[k] = true
+			}
+			for k := range cm.BinaryData {
+				keys[k] = true
+			}
+		}
+	case SecretSource:
+		if i.SecretLister == nil {
+			return false, fmt.Errorf("no Secret lister to get %s/%s", source.Namespace, source.Name)
+		}
+		var secret *corev1.Secret
+		if secret, err = i.SecretLister.Secrets(source.Namespace).Get(source.Name); err == nil {
+			keys = map[string]bool{}
+			for k := range secret.Data {
+				keys[k] = true
+			}
+		}
+	default:
+		return false, fmt.Errorf("unsupported injection source kind %q", source.Kind)
+	}
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get %s %s/%s: %w", source.Kind, source.Namespace, source.Name, err)
+	}
+
+	for _, key := range source.RequiredKeys {
+		if !keys[key] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func injectIntoContainer(container *corev1.Container, injection Injection) {
+	if len(injection.ContainerNames) > 0 && !contains(injection.ContainerNames, container.Name) {
+		return
+	}
+	for _, env := range injection.Env {
+		container.Env = setEnvVar(container.Env, env)
+	}
+	for _, mount := range injection.VolumeMounts {
+		container.VolumeMounts = setVolumeMount(container.VolumeMounts, mount)
+	}
+}
+
+func setEnvVar(envVars []corev1.EnvVar, envVar corev1.EnvVar) []corev1.EnvVar {
+	for i := range envVars {
+		if envVars[i].Name == envVar.Name {
+			envVars[i] = envVar
+			return envVars
+		}
+	}
+	return append(envVars, envVar)
+}
+
+func setVolume(volumes []corev1.Volume, volume corev1.Volume) []corev1.Volume {
+	for i := range volumes {
+		if volumes[i].Name == volume.Name {
+			volumes[i] = volume
+			return volumes
+		}
+	}
+	return append(volumes, volume)
+}
+
+func setVolumeMount(mounts []corev1.VolumeMount, mount corev1.VolumeMount) []corev1.VolumeMount {
+	for i := range mounts {
+		if mounts[i].Name == mount.Name && mounts[i].MountPath == mount.MountPath {
+			mounts[i] = mount
+			return mounts
+		}
+	}
+	return append(mounts, mount)
+}
+
+func objectRef(source *Source) *resourcehash.ObjectReference {
+	ref := resourcehash.NewObjectRef().InNamespace(source.Namespace).Named(source.Name)
+	if source.Kind == SecretSource {
+		return ref.ForSecret()
+	}
+	return ref.ForConfigMap()
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
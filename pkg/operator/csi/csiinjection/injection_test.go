@@ -0,0 +1,144 @@
+package csiinjection
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const namespace = "openshift-cluster-csi-drivers"
+
+func TestInject(t *testing.T) {
+	cloudConfig := Injection{
+		ContainerNames: []string{"csi-driver"},
+		Env:            []corev1.EnvVar{{Name: "CLOUD_CONFIG", Value: "/etc/cloud/config"}},
+		Volumes: []corev1.Volume{{
+			Name:         "cloud-config",
+			VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "cloud-config"}}},
+		}},
+		VolumeMounts: []corev1.VolumeMount{{Name: "cloud-config", MountPath: "/etc/cloud"}},
+		Source:       &Source{Kind: ConfigMapSource, Namespace: namespace, Name: "cloud-config", RequiredKeys: []string{"config"}},
+	}
+	credentials := Injection{
+		Env:    []corev1.EnvVar{{Name: "CREDENTIALS_FILE", Value: "/etc/credentials/credentials"}},
+		Source: &Source{Kind: SecretSource, Namespace: namespace, Name: "credentials"},
+	}
+	static := Injection{
+		ContainerNames: []string{"csi-provisioner"},
+		Env:            []corev1.EnvVar{{Name: "FEATURE", Value: "enabled"}},
+	}
+
+	testCases := []struct {
+		name           string
+		configMaps     []*corev1.ConfigMap
+		secrets        []*corev1.Secret
+		expectedPod    *corev1.PodSpec
+		expectedHashes int
+	}{
+		{
+			name:        "sources missing",
+			expectedPod: makePodSpec(withEnv("csi-provisioner", corev1.EnvVar{Name: "FEATURE", Value: "enabled"})),
+		},
+		{
+			name:       "ConfigMap without required key",
+			configMaps: []*corev1.ConfigMap{makeConfigMap("cloud-config", map[string]string{"other": "value"})},
+			secrets:    []*corev1.Secret{makeSecret("credentials")},
+			expectedPod: makePodSpec(
+				withEnv("csi-driver", corev1.EnvVar{Name: "CREDENTIALS_FILE", Value: "/etc/credentials/credentials"}),
+				withEnv("csi-provisioner", corev1.EnvVar{Name: "CREDENTIALS_FILE", Value: "/etc/credentials/credentials"}, corev1.EnvVar{Name: "FEATURE", Value: "enabled"}),
+			),
+			expectedHashes: 1,
+		},
+		{
+			name:       "all sources present",
+			configMaps: []*corev1.ConfigMap{makeConfigMap("cloud-config", map[string]string{"config": "value"})},
+			secrets:    []*corev1.Secret{makeSecret("credentials")},
+			expectedPod: makePodSpec(
+				withEnv("csi-driver", corev1.EnvVar{Name: "CLOUD_CONFIG", Value: "/etc/cloud/config"}, corev1.EnvVar{Name: "CREDENTIALS_FILE", Value: "/etc/credentials/credentials"}),
+				withEnv("csi-provisioner", corev1.EnvVar{Name: "CREDENTIALS_FILE", Value: "/etc/credentials/credentials"}, corev1.EnvVar{Name: "FEATURE", Value: "enabled"}),
+				withVolume(cloudConfig.Volumes[0], "csi-driver", cloudConfig.VolumeMounts[0]),
+			),
+			expectedHashes: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, cm := range tc.configMaps {
+				cmIndexer.Add(cm)
+			}
+			secretIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, secret := range tc.secrets {
+				secretIndexer.Add(secret)
+			}
+			injector := Injector{ConfigMapLister: corev1listers.NewConfigMapLister(cmIndexer), SecretLister: corev1listers.NewSecretLister(secretIndexer)}
+
+			podSpec := makePodSpec()
+			hashes, err := injector.Inject(podSpec, cloudConfig, credentials, static)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !equality.Semantic.DeepEqual(podSpec, tc.expectedPod) {
+				t.Errorf("unexpected pod spec:\n%s", cmp.Diff(tc.expectedPod, podSpec))
+			}
+			if len(hashes) != tc.expectedHashes {
+				t.Errorf("expected %d source hashes, got %v", tc.expectedHashes, hashes)
+			}
+
+			// injecting again must not duplicate anything
+			if _, err := injector.Inject(podSpec, cloudConfig, credentials, static); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !equality.Semantic.DeepEqual(podSpec, tc.expectedPod) {
+				t.Errorf("second injection changed the pod spec:\n%s", cmp.Diff(tc.expectedPod, podSpec))
+			}
+		})
+	}
+}
+
+type podSpecModifier func(*corev1.PodSpec)
+
+func makePodSpec(modifiers ...podSpecModifier) *corev1.PodSpec {
+	podSpec := &corev1.PodSpec{
+		Containers: []corev1.Container{{Name: "csi-driver"}, {Name: "csi-provisioner"}},
+	}
+	for _, modify := range modifiers {
+		modify(podSpec)
+	}
+	return podSpec
+}
+
+func withEnv(containerName string, env ...corev1.EnvVar) podSpecModifier {
+	return func(podSpec *corev1.PodSpec) {
+		for i := range podSpec.Containers {
+			if podSpec.Containers[i].Name == containerName {
+				podSpec.Containers[i].Env = append(podSpec.Containers[i].Env, env...)
+			}
+		}
+	}
+}
+
+func withVolume(volume corev1.Volume, containerName string, mount corev1.VolumeMount) podSpecModifier {
+	return func(podSpec *corev1.PodSpec) {
+		podSpec.Volumes = append(podSpec.Volumes, volume)
+		for i := range podSpec.Containers {
+			if podSpec.Containers[i].Name == containerName {
+				podSpec.Containers[i].VolumeMounts = append(podSpec.Containers[i].VolumeMounts, mount)
+			}
+		}
+	}
+}
+
+func makeConfigMap(name string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}, Data: data}
+}
+
+func makeSecret(name string) *corev1.Secret {
+	return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}, Data: map[string][]byte{"credentials": []byte("secret")}}
+}
@@ -0,0 +1,98 @@
+package csirollout
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	k8smetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	namespace = "csi_operator"
+	subsystem = "operand"
+)
+
+// metrics provides access to all CSI operand rollout metrics.
+var metrics *rolloutMetrics
+
+func init() {
+	metrics = newRolloutMetrics(legacyregistry.Register)
+}
+
+// rolloutMetrics instruments the rollout of CSI operands with prometheus metrics.
+type rolloutMetrics struct {
+	daemonSetNodes          *k8smetrics.GaugeVec
+	deploymentGenerationLag *k8smetrics.GaugeVec
+	rolloutDuration         *k8smetrics.HistogramVec
+}
+
+// newRolloutMetrics creates a new rolloutMetrics, configured with default metric names.
+func newRolloutMetrics(registerFunc func(k8smetrics.Registerable) error) *rolloutMetrics {
+	daemonSetNodes := k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "daemonset_nodes",
+			Help:      "The number of nodes covered by a CSI node DaemonSet, labeled with the DaemonSet namespace and name and the state (eligible, scheduled, ready, available, updated)",
+		}, []string{"namespace", "name", "state"})
+	registerFunc(daemonSetNodes)
+
+	deploymentGenerationLag := k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "deployment_generation_lag",
+			Help:      "The number of generations of a CSI controller Deployment not yet observed by the Deployment controller, labeled with the Deployment namespace and name",
+		}, []string{"namespace", "name"})
+	registerFunc(deploymentGenerationLag)
+
+	rolloutDuration := k8smetrics.NewHistogramVec(
+		&k8smetrics.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "rollout_duration_seconds",
+			Help:      "How long it takes to roll out a CSI operand after a change of its images, labeled with the kind, namespace and name of the workload",
+			Buckets:   prometheus.ExponentialBuckets(15, 2, 10),
+		}, []string{"kind", "namespace", "name"})
+	registerFunc(rolloutDuration)
+
+	return &rolloutMetrics{
+		daemonSetNodes:          daemonSetNodes,
+		deploymentGenerationLag: deploymentGenerationLag,
+		rolloutDuration:         rolloutDuration,
+	}
+}
+
+// daemonSetStates are the states of the nodes reported by daemonSetNodes.
+var daemonSetStates = []string{"eligible", "scheduled", "ready", "available", "updated"}
+
+// ObserveDaemonSetNodes records the node coverage of a DaemonSet.
+func (m *rolloutMetrics) ObserveDaemonSetNodes(namespace, name string, eligible, scheduled, ready, available, updated int32) {
+	for i, value := range []int32{eligible, scheduled, ready, available, updated} {
+		m.daemonSetNodes.WithLabelValues(namespace, name, daemonSetStates[i]).Set(float64(value))
+	}
+}
+
+// ObserveDeploymentGenerationLag records the generation lag of a Deployment.
+func (m *rolloutMetrics) ObserveDeploymentGenerationLag(namespace, name string, lag int64) {
+	m.deploymentGenerationLag.WithLabelValues(namespace, name).Set(float64(lag))
+}
+
+// ObserveRolloutDuration records how long a rollout took in seconds.
+func (m *rolloutMetrics) ObserveRolloutDuration(kind, namespace, name string, seconds float64) {
+	m.rolloutDuration.WithLabelValues(kind, namespace, name).Observe(seconds)
+}
+
+// ForgetDaemonSet removes the metrics of a DaemonSet.
+func (m *rolloutMetrics) ForgetDaemonSet(namespace, name string) {
+	for _, state := range daemonSetStates {
+		m.daemonSetNodes.Delete(map[string]string{"namespace": namespace, "name": name, "state": state})
+	}
+	m.rolloutDuration.Delete(map[string]string{"kind": "DaemonSet", "namespace": namespace, "name": name})
+}
+
+// ForgetDeployment removes the metrics of a Deployment.
+func (m *rolloutMetrics) ForgetDeployment(namespace, name string) {
+	m.deploymentGenerationLag.Delete(map[string]string{"namespace": namespace, "name": name})
+	m.rolloutDuration.Delete(map[string]string{"kind": "Deployment", "namespace": namespace, "name": name})
+}
@@ -0,0 +1,187 @@
+package csirollout
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/clock"
+)
+
+// RolloutTracker records metrics about the rollout of the CSI operands in a namespace:
+//
+//   - the node coverage of DaemonSets (eligible vs. scheduled vs. ready vs. available vs. updated nodes),
+//   - the generation lag of Deployments,
+//   - the time it takes to roll out a Deployment or DaemonSet after one of its images changed.
+type RolloutTracker struct {
+	namespace string
+	names     sets.String
+	clock     clock.PassiveClock
+
+	lock sync.Mutex
+	// rollouts holds the images and the start time of the last rollout per workload
+	rollouts map[string]*rollout
+}
+
+type rollout struct {
+	images  string
+	started time.Time
+	done    bool
+}
+
+// NewRolloutTracker returns a tracker of the Deployments and DaemonSets called names in namespace, i.e. the operands
+// of the CSI driver. The other workloads of the namespace are ignored.
+func NewRolloutTracker(namespace string, names ...string) *RolloutTracker {
+	return &RolloutTracker{
+		namespace: namespace,
+		names:     sets.NewString(names...),
+		clock:     clock.RealClock{},
+		rollouts:  map[string]*rollout{},
+	}
+}
+
+// AddInformers makes the tracker observe the Deployments and DaemonSets of the given informers.
+// Either informer may be nil.
+func (t *RolloutTracker) AddInformers(deploymentInformer, daemonSetInformer cache.SharedIndexInformer) error {
+	for _, informer := range []cache.SharedIndexInformer{deploymentInformer, daemonSetInformer} {
+		if informer == nil {
+			continue
+		}
+		if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { t.observe(obj) },
+			UpdateFunc: func(_, obj interface{}) { t.observe(obj) },
+			DeleteFunc: func(obj interface{}) { t.forget(obj) },
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *RolloutTracker) observe(obj interface{}) {
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		t.ObserveDeployment(o)
+	case *appsv1.DaemonSet:
+		t.ObserveDaemonSet(o)
+	}
+}
+
+// ObserveDeployment records the metrics of a Deployment.
+func (t *RolloutTracker) ObserveDeployment(deployment *appsv1.Deployment) {
+	if !t.tracks(deployment.Namespace, deployment.Name) {
+		return
+	}
+	lag := deployment.Generation - deployment.Status.ObservedGeneration
+	if lag < 0 {
+		lag = 0
+	}
+	metrics.ObserveDeploymentGenerationLag(deployment.Namespace, deployment.Name, lag)
+
+	var replicas int32 = 1
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	rolledOut := lag == 0 &&
+		deployment.Status.UpdatedReplicas == replicas &&
+		deployment.Status.AvailableReplicas == replicas &&
+		deployment.Status.UnavailableReplicas == 0
+	t.trackRollout("Deployment", deployment.Name, &deployment.Spec.Template.Spec, rolledOut)
+}
+
+// ObserveDaemonSet records the metrics of a DaemonSet.
+func (t *RolloutTracker) ObserveDaemonSet(daemonSet *appsv1.DaemonSet) {
+	if !t.tracks(daemonSet.Namespace, daemonSet.Name) {
+		return
+	}
+	status := daemonSet.Status
+	metrics.ObserveDaemonSetNodes(daemonSet.Namespace, daemonSet.Name, status.DesiredNumberScheduled, status.CurrentNumberScheduled, status.NumberReady, status.NumberAvailable, status.UpdatedNumberScheduled)
+
+	rolledOut := daemonSet.Generation == status.ObservedGeneration &&
+		status.UpdatedNumberScheduled == status.DesiredNumberScheduled &&
+		status.NumberAvailable == status.DesiredNumberScheduled
+	t.trackRollout("DaemonSet", daemonSet.Name, &daemonSet.Spec.Template.Spec, rolledOut)
+}
+
+// ForgetDeployment removes the metrics of a deleted Deployment.
+func (t *RolloutTracker) ForgetDeployment(deployment *appsv1.Deployment) {
+	if !t.tracks(deployment.Namespace, deployment.Name) {
+		return
+	}
+	metrics.ForgetDeployment(deployment.Namespace, deployment.Name)
+	t.forgetRollout("Deployment", deployment.Name)
+}
+
+// ForgetDaemonSet removes the metrics of a deleted DaemonSet.
+func (t *RolloutTracker) ForgetDaemonSet(daemonSet *appsv1.DaemonSet) {
+	if !t.tracks(daemonSet.Namespace, daemonSet.Name) {
+		return
+	}
+	metrics.ForgetDaemonSet(daemonSet.Namespace, daemonSet.Name)
+	t.forgetRollout("DaemonSet", daemonSet.Name)
+}
+
+func (t *RolloutTracker) forget(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		t.ForgetDeployment(o)
+	case *appsv1.DaemonSet:
+		t.ForgetDaemonSet(o)
+	}
+}
+
+func (t *RolloutTracker) tracks(namespace, name string) bool {
+	return namespace == t.namespace && t.names.Has(name)
+}
+
+func (t *RolloutTracker) forgetRollout(kind, name string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.rollouts, kind+"/"+name)
+}
+
+// trackRollout starts measuring a rollout when the images of the workload change
+// and records its duration once the workload is rolled out.
+func (t *RolloutTracker) trackRollout(kind, name string, podSpec *corev1.PodSpec, rolledOut bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	key := kind + "/" + name
+	images := imagesOf(podSpec)
+	current, found := t.rollouts[key]
+	switch {
+	case !found:
+		// the first observation, e.g. after a restart of the operator, is not a change of the images
+		t.rollouts[key] = &rollout{images: images, started: t.clock.Now(), done: true}
+		return
+	case current.images != images:
+		current.images = images
+		current.started = t.clock.Now()
+		current.done = false
+	}
+
+	if rolledOut && !current.done {
+		current.done = true
+		metrics.ObserveRolloutDuration(kind, t.namespace, name, t.clock.Now().Sub(current.started).Seconds())
+	}
+}
+
+func imagesOf(podSpec *corev1.PodSpec) string {
+	var images []string
+	for _, c := range podSpec.InitContainers {
+		images = append(images, c.Name+"="+c.Image)
+	}
+	for _, c := range podSpec.Containers {
+		images = append(images, c.Name+"="+c.Image)
+	}
+	sort.Strings(images)
+	return strings.Join(images, ",")
+}
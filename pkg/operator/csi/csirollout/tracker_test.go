@@ -0,0 +1,109 @@
+package csirollout
+
+import (
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics/testutil"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestRolloutTracker(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := NewRolloutTracker("test", "node")
+	fakeClock := clocktesting.NewFakePassiveClock(now)
+	tracker.clock = fakeClock
+
+	ds := makeDaemonSet("node", "driver:1")
+	ds.Status = appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, CurrentNumberScheduled: 3, NumberReady: 3, NumberAvailable: 3, UpdatedNumberScheduled: 3}
+	tracker.ObserveDaemonSet(ds)
+	expectRollouts(t, "node", 0)
+
+	// new image, rollout in progress
+	now = now.Add(time.Minute)
+	fakeClock.SetTime(now)
+	ds = makeDaemonSet("node", "driver:2")
+	ds.Generation = 2
+	ds.Status = appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, CurrentNumberScheduled: 3, NumberReady: 2, NumberAvailable: 2, UpdatedNumberScheduled: 1}
+	tracker.ObserveDaemonSet(ds)
+	expectRollouts(t, "node", 0)
+	if value, _ := testutil.GetGaugeMetricValue(metrics.daemonSetNodes.WithLabelValues("test", "node", "updated")); value != 1 {
+		t.Errorf("expected 1 updated node, got %v", value)
+	}
+
+	// rolled out 2 minutes after the image change
+	now = now.Add(2 * time.Minute)
+	fakeClock.SetTime(now)
+	ds.Status = appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, CurrentNumberScheduled: 3, NumberReady: 3, NumberAvailable: 3, UpdatedNumberScheduled: 3}
+	tracker.ObserveDaemonSet(ds)
+	expectRollouts(t, "node", 1)
+	if sum, _ := testutil.GetHistogramMetricValue(metrics.rolloutDuration.WithLabelValues("DaemonSet", "test", "node")); sum != 120 {
+		t.Errorf("expected a rollout of 120 seconds, got %v", sum)
+	}
+
+	// resyncs don't record the rollout again
+	tracker.ObserveDaemonSet(ds)
+	expectRollouts(t, "node", 1)
+
+	// objects of other namespaces are ignored
+	other := makeDaemonSet("node", "driver:3")
+	other.Namespace = "other"
+	tracker.ObserveDaemonSet(other)
+	if tracker.rollouts["DaemonSet/node"].images != "csi-driver=driver:2" {
+		t.Errorf("unexpected tracked images %q", tracker.rollouts["DaemonSet/node"].images)
+	}
+
+	// the other workloads of the namespace are ignored
+	tracker.ObserveDaemonSet(makeDaemonSet("unrelated", "other:1"))
+	if _, found := tracker.rollouts["DaemonSet/unrelated"]; found {
+		t.Error("unexpected tracking of an unrelated DaemonSet")
+	}
+
+	// the metrics of a deleted DaemonSet are removed
+	tracker.forget(cache.DeletedFinalStateUnknown{Key: "test/node", Obj: ds})
+	if _, found := tracker.rollouts["DaemonSet/node"]; found {
+		t.Error("expected the rollout of the deleted DaemonSet to be forgotten")
+	}
+	if metrics.daemonSetNodes.Delete(map[string]string{"namespace": "test", "name": "node", "state": "ready"}) {
+		t.Error("expected the node coverage of the deleted DaemonSet to be removed")
+	}
+}
+
+func TestDeploymentGenerationLag(t *testing.T) {
+	tracker := NewRolloutTracker("test", "controller")
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "controller", Generation: 5},
+		Status:     appsv1.DeploymentStatus{ObservedGeneration: 3},
+	}
+	tracker.ObserveDeployment(deployment)
+	if value, _ := testutil.GetGaugeMetricValue(metrics.deploymentGenerationLag.WithLabelValues("test", "controller")); value != 2 {
+		t.Errorf("expected a generation lag of 2, got %v", value)
+	}
+}
+
+func makeDaemonSet(name, image string) *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: name, Generation: 1},
+		Spec: appsv1.DaemonSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "csi-driver", Image: image}}},
+			},
+		},
+		Status: appsv1.DaemonSetStatus{ObservedGeneration: 1},
+	}
+}
+
+func expectRollouts(t *testing.T, name string, expected uint64) {
+	t.Helper()
+	count, err := testutil.GetHistogramMetricCount(metrics.rolloutDuration.WithLabelValues("DaemonSet", "test", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != expected {
+		t.Errorf("expected %d recorded rollouts, got %d", expected, count)
+	}
+}
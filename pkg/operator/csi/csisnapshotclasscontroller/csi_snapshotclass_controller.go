@@ -0,0 +1,156 @@
+package csisnapshotclasscontroller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	operatorapi "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// ManagedAnnotationKey can be set to "false" on a VolumeSnapshotClass by users
+	// to prevent the controller from overwriting their changes.
+	ManagedAnnotationKey = "csi.openshift.io/managed"
+)
+
+var volumeSnapshotClassGVR = schema.GroupVersionResource{
+	Group:    resourceapply.VolumeSnapshotClassGroup,
+	Version:  resourceapply.VolumeSnapshotClassVersion,
+	Resource: resourceapply.VolumeSnapshotClassResource,
+}
+
+// VolumeSnapshotClassHookFunc is a hook function to modify a VolumeSnapshotClass.
+type VolumeSnapshotClassHookFunc func(*operatorapi.OperatorSpec, *unstructured.Unstructured) error
+
+// This Controller deploys VolumeSnapshotClasses provided by CSI driver operator.
+// On every sync the VolumeSnapshotClasses are read from the asset files, modified by the optional hooks
+// and applied, i.e. any drift of their driver, parameters and deletionPolicy is reverted.
+// Users can opt out of the reconciliation of a single VolumeSnapshotClass by setting
+// the ManagedAnnotationKey annotation to "false" on it.
+// Metadata of existing VolumeSnapshotClasses is never overwritten, so that e.g. the choice of a default
+// VolumeSnapshotClass made by the user is preserved.
+// It produces following Conditions:
+// VolumeSnapshotClassControllerDegraded - failed to apply VolumeSnapshotClass provided
+type CSISnapshotClassController struct {
+	name                string
+	assetFunc           resourceapply.AssetFunc
+	files               []string
+	dynamicClient       dynamic.Interface
+	snapshotClassLister cache.GenericLister
+	operatorClient      v1helpers.OperatorClient
+	eventRecorder       events.Recorder
+	// Optional hook functions to modify the VolumeSnapshotClass.
+	// If one of these functions returns an error, the sync
+	// fails indicating the ordinal position of the failed function.
+	// Also, in that scenario the Degraded status is set to True.
+	optionalVolumeSnapshotClassHooks []VolumeSnapshotClassHookFunc
+}
+
+func NewCSISnapshotClassController(
+	name string,
+	assetFunc resourceapply.AssetFunc,
+	files []string,
+	dynamicClient dynamic.Interface,
+	dynamicInformerFactory dynamicinformer.DynamicSharedInformerFactory,
+	operatorClient v1helpers.OperatorClient,
+	eventRecorder events.Recorder,
+	optionalVolumeSnapshotClassHooks ...VolumeSnapshotClassHookFunc) factory.Controller {
+	snapshotClassInformer := dynamicInformerFactory.ForResource(volumeSnapshotClassGVR)
+	c := &CSISnapshotClassController{
+		name:                             name,
+		assetFunc:                        assetFunc,
+		files:                            files,
+		dynamicClient:                    dynamicClient,
+		snapshotClassLister:              snapshotClassInformer.Lister(),
+		operatorClient:                   operatorClient,
+		eventRecorder:                    eventRecorder,
+		optionalVolumeSnapshotClassHooks: optionalVolumeSnapshotClassHooks,
+	}
+
+	return factory.New().WithSync(
+		c.Sync,
+	).ResyncEvery(
+		time.Minute,
+	).WithSyncDegradedOnError(
+		operatorClient,
+	).WithInformers(
+		operatorClient.Informer(),
+		snapshotClassInformer.Informer(),
+	).ToController(
+		"VolumeSnapshotClassController",
+		eventRecorder,
+	)
+}
+
+func (c *CSISnapshotClassController) Sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	klog.V(4).Infof("VolumeSnapshotClassController sync started")
+	defer klog.V(4).Infof("VolumeSnapshotClassController sync finished")
+
+	opSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if opSpec.ManagementState != operatorapi.Managed {
+		return nil
+	}
+
+	for _, file := range c.files {
+		if err := c.syncVolumeSnapshotClass(ctx, opSpec, file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *CSISnapshotClassController) syncVolumeSnapshotClass(ctx context.Context, opSpec *operatorapi.OperatorSpec, assetFile string) error {
+	expectedVSCBytes, err := c.assetFunc(assetFile)
+	if err != nil {
+		return err
+	}
+
+	expectedVSC := resourceread.ReadUnstructuredOrDie(expectedVSCBytes)
+
+	for i := range c.optionalVolumeSnapshotClassHooks {
+		err := c.optionalVolumeSnapshotClassHooks[i](opSpec, expectedVSC)
+		if err != nil {
+			return fmt.Errorf("error running hook function (index=%d): %w", i, err)
+		}
+	}
+
+	existingObj, err := c.snapshotClassLister.Get(expectedVSC.GetName())
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		existingVSC, ok := existingObj.(*unstructured.Unstructured)
+		if !ok {
+			return fmt.Errorf("unexpected type %T of VolumeSnapshotClass %s", existingObj, expectedVSC.GetName())
+		}
+		if !IsManaged(existingVSC) {
+			klog.V(4).Infof("VolumeSnapshotClass %s is not managed, skipping", existingVSC.GetName())
+			return nil
+		}
+	}
+
+	_, _, err = resourceapply.ApplyVolumeSnapshotClass(ctx, c.dynamicClient, c.eventRecorder, expectedVSC)
+	return err
+}
+
+// IsManaged returns false when the user opted the VolumeSnapshotClass out of reconciliation.
+func IsManaged(vsc *unstructured.Unstructured) bool {
+	return vsc.GetAnnotations()[ManagedAnnotationKey] != "false"
+}
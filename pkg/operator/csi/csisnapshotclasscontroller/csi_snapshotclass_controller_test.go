@@ -0,0 +1,133 @@
+package csisnapshotclasscontroller
+
+import (
+	"context"
+	"testing"
+
+	opv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+const snapshotClassAsset = `
+apiVersion: snapshot.storage.k8s.io/v1
+kind: VolumeSnapshotClass
+metadata:
+  name: test-csi-vsc
+  annotations:
+    snapshot.storage.kubernetes.io/is-default-class: "true"
+driver: test.csi.example.com
+deletionPolicy: Delete
+parameters:
+  type: snap
+`
+
+func fakeAssetFunc(string) ([]byte, error) {
+	return []byte(snapshotClassAsset), nil
+}
+
+func TestSync(t *testing.T) {
+	driftedVSC := func(annotations map[string]string) *unstructured.Unstructured {
+		vsc := resourceread.ReadUnstructuredOrDie([]byte(snapshotClassAsset))
+		vsc.SetAnnotations(annotations)
+		if err := unstructured.SetNestedField(vsc.Object, "Retain", "deletionPolicy"); err != nil {
+			t.Fatal(err)
+		}
+		return vsc
+	}
+
+	testCases := []struct {
+		name                   string
+		managementState        opv1.ManagementState
+		existing               *unstructured.Unstructured
+		hooks                  []VolumeSnapshotClassHookFunc
+		expectedDeletionPolicy string
+		expectedParameter      string
+		expectErr              bool
+	}{
+		{
+			name:                   "VolumeSnapshotClass created",
+			managementState:        opv1.Managed,
+			expectedDeletionPolicy: "Delete",
+			expectedParameter:      "snap",
+		},
+		{
+			name:                   "drift reverted",
+			managementState:        opv1.Managed,
+			existing:               driftedVSC(nil),
+			expectedDeletionPolicy: "Delete",
+			expectedParameter:      "snap",
+		},
+		{
+			name:                   "user opted out",
+			managementState:        opv1.Managed,
+			existing:               driftedVSC(map[string]string{ManagedAnnotationKey: "false"}),
+			expectedDeletionPolicy: "Retain",
+			expectedParameter:      "snap",
+		},
+		{
+			name:            "hook applied",
+			managementState: opv1.Managed,
+			hooks: []VolumeSnapshotClassHookFunc{
+				func(_ *opv1.OperatorSpec, vsc *unstructured.Unstructured) error {
+					return unstructured.SetNestedField(vsc.Object, "hooked", "parameters", "type")
+				},
+			},
+			expectedDeletionPolicy: "Delete",
+			expectedParameter:      "hooked",
+		},
+		{
+			name:            "unmanaged operator",
+			managementState: opv1.Unmanaged,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			var initialObjects []runtime.Object
+			if test.existing != nil {
+				initialObjects = append(initialObjects, test.existing)
+			}
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), initialObjects...)
+			dynamicInformerFactory := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, 0)
+			if test.existing != nil {
+				dynamicInformerFactory.ForResource(volumeSnapshotClassGVR).Informer().GetIndexer().Add(test.existing)
+			}
+			operatorClient := v1helpers.NewFakeOperatorClient(&opv1.OperatorSpec{ManagementState: test.managementState}, &opv1.OperatorStatus{}, nil)
+			recorder := events.NewInMemoryRecorder("test")
+
+			controller := NewCSISnapshotClassController("test", fakeAssetFunc, []string{"vsc.yaml"}, dynamicClient, dynamicInformerFactory, operatorClient, recorder, test.hooks...)
+			err := controller.Sync(context.TODO(), factory.NewSyncContext("test", recorder))
+			if err != nil && !test.expectErr {
+				t.Fatalf("sync() returned unexpected error: %v", err)
+			}
+			if err == nil && test.expectErr {
+				t.Fatal("sync() unexpectedly succeeded when error was expected")
+			}
+
+			actual, err := dynamicClient.Resource(volumeSnapshotClassGVR).Get(context.TODO(), "test-csi-vsc", metav1.GetOptions{})
+			if len(test.expectedDeletionPolicy) == 0 {
+				if err == nil {
+					t.Fatalf("expected no VolumeSnapshotClass, got %v", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if deletionPolicy, _, _ := unstructured.NestedString(actual.Object, "deletionPolicy"); deletionPolicy != test.expectedDeletionPolicy {
+				t.Errorf("expected deletionPolicy %q, got %q", test.expectedDeletionPolicy, deletionPolicy)
+			}
+			if parameter, _, _ := unstructured.NestedString(actual.Object, "parameters", "type"); parameter != test.expectedParameter {
+				t.Errorf("expected parameter %q, got %q", test.expectedParameter, parameter)
+			}
+		})
+	}
+}
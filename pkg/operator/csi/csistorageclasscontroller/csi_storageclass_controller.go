@@ -2,7 +2,9 @@ package csistorageclasscontroller
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
+	"strings"
 	"time"
 
 	operatorapi "github.com/openshift/api/operator/v1"
@@ -14,6 +16,7 @@ import (
 	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
@@ -23,6 +26,10 @@ import (
 
 const (
 	defaultScAnnotationKey = "storageclass.kubernetes.io/is-default-class"
+
+	storageClassControllerName = "StorageClassController"
+	degradedCondition          = storageClassControllerName + "Degraded"
+	recreateFailedReason       = "StorageClassRecreateFailed"
 )
 
 // StorageClassHookFunc is a hook function to modify a StorageClass.
@@ -39,8 +46,13 @@ type StorageClassHookFunc func(*operatorapi.OperatorSpec, *storagev1.StorageClas
 // overwriting value that user might have manually changed.
 // If the asset file does not have defaultScAnnotationKey set at all, controller
 // just skips any checks and modifications and applies the StorageClass as it is.
+// Immutable fields (parameters, provisioner, reclaimPolicy and volumeBindingMode) that
+// drifted from the asset can be reconciled only by re-creating the StorageClass.
 // It produces following Conditions:
-// StorageClassControllerDegraded - failed to apply StorageClass provided
+// StorageClassControllerDegraded - failed to apply StorageClass provided. The reason is
+// StorageClassRecreateFailed when the StorageClass had to be re-created and that failed,
+// SyncError for any other failure. A warning event with the reason is emitted when the
+// condition becomes True or its reason changes, not on every sync.
 type CSIStorageClassController struct {
 	name               string
 	assetFunc          resourceapply.AssetFunc
@@ -89,18 +101,31 @@ func NewCSIStorageClassController(
 		c.Sync,
 	).ResyncEvery(
 		time.Minute,
-	).WithSyncDegradedOnError(
-		operatorClient,
 	).WithInformers(
 		operatorClient.Informer(),
 		informerFactory.Storage().V1().StorageClasses().Informer(),
 		operatorInformer.Operator().V1().ClusterCSIDrivers().Informer(),
 	).ToController(
-		"StorageClassController",
+		storageClassControllerName,
 		eventRecorder,
 	)
 }
 
+// storageClassRecreateError is returned when a StorageClass with drifted immutable fields could not be re-created.
+type storageClassRecreateError struct {
+	name           string
+	driftedFields  []string
+	recreateFailed error
+}
+
+func (e *storageClassRecreateError) Error() string {
+	return fmt.Sprintf("failed to re-create StorageClass %s with changed %s: %v", e.name, strings.Join(e.driftedFields, ", "), e.recreateFailed)
+}
+
+func (e *storageClassRecreateError) Unwrap() error {
+	return e.recreateFailed
+}
+
 func (c *CSIStorageClassController) Sync(ctx context.Context, syncCtx factory.SyncContext) error {
 	klog.V(4).Infof("StorageClassController sync started")
 	defer klog.V(4).Infof("StorageClassController sync finished")
@@ -113,13 +138,52 @@ func (c *CSIStorageClassController) Sync(ctx context.Context, syncCtx factory.Sy
 		return nil
 	}
 
+	var syncErr error
 	for _, file := range c.files {
-		if err := c.syncStorageClass(ctx, opSpec, file); err != nil {
-			return err
+		if syncErr = c.syncStorageClass(ctx, opSpec, file); syncErr != nil {
+			break
 		}
 	}
 
-	return nil
+	return c.reportDegraded(ctx, syncErr)
+}
+
+// reportDegraded sets the Degraded condition with a reason describing the error, if any. It emits a warning event
+// only when the condition transitions, so that a persistent failure is not reported again by every sync.
+func (c *CSIStorageClassController) reportDegraded(ctx context.Context, syncErr error) error {
+	cond := operatorapi.OperatorCondition{
+		Type:   degradedCondition,
+		Status: operatorapi.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	if syncErr != nil {
+		cond.Status = operatorapi.ConditionTrue
+		cond.Reason = "SyncError"
+		cond.Message = syncErr.Error()
+		var recreateErr *storageClassRecreateError
+		if goerrors.As(syncErr, &recreateErr) {
+			cond.Reason = recreateFailedReason
+		}
+	}
+	_, oldStatus, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		if syncErr != nil {
+			return syncErr
+		}
+		return err
+	}
+	oldCond := v1helpers.FindOperatorCondition(oldStatus.Conditions, degradedCondition)
+
+	if _, _, err := v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(cond)); err != nil {
+		if syncErr != nil {
+			return syncErr
+		}
+		return err
+	}
+	if cond.Status == operatorapi.ConditionTrue && (oldCond == nil || oldCond.Status != cond.Status || oldCond.Reason != cond.Reason) {
+		c.eventRecorder.Warning(cond.Reason, cond.Message)
+	}
+	return syncErr
 }
 
 func (c *CSIStorageClassController) syncStorageClass(ctx context.Context, opSpec *operatorapi.OperatorSpec, assetFile string) error {
@@ -142,7 +206,23 @@ func (c *CSIStorageClassController) syncStorageClass(ctx context.Context, opSpec
 		return err
 	}
 
-	return c.scStateEvaluator.EvalAndApplyStorageClass(ctx, expectedSC)
+	scState := c.scStateEvaluator.GetStorageClassState(expectedSC.Provisioner)
+	var driftedFields []string
+	if c.scStateEvaluator.IsManaged(scState) {
+		existingSC, err := c.storageClassLister.Get(expectedSC.Name)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		if existingSC != nil {
+			driftedFields = resourceapply.StorageClassImmutableFieldsDrift(existingSC, expectedSC)
+		}
+	}
+
+	err = c.scStateEvaluator.ApplyStorageClass(ctx, expectedSC, scState)
+	if err != nil && len(driftedFields) > 0 {
+		return &storageClassRecreateError{name: expectedSC.Name, driftedFields: driftedFields, recreateFailed: err}
+	}
+	return err
 }
 
 func SetDefaultStorageClass(storageClassLister v1.StorageClassLister, storageClass *storagev1.StorageClass) error {
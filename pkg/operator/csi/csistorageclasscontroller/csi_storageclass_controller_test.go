@@ -2,6 +2,7 @@ package csistorageclasscontroller
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -22,6 +23,7 @@ import (
 	v1 "k8s.io/client-go/informers/storage/v1"
 	"k8s.io/client-go/kubernetes"
 	fakecore "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
 )
 
 const (
@@ -49,6 +51,7 @@ type testContext struct {
 	operatorClient v1helpers.OperatorClient
 	kubeClient     kubernetes.Interface
 	scInformer     v1.StorageClassInformer
+	recorder       events.InMemoryRecorder
 }
 
 // defaultScAnnotation accepts values "true", "false", ""
@@ -217,6 +220,7 @@ func newTestContext(test testCase, t *testing.T) *testContext {
 	fakeOperatorInformer := operatorinformer.NewSharedInformerFactory(typedVersionedOperatorClient, 1*time.Minute)
 	fakeOperatorInformer.Operator().V1().ClusterCSIDrivers().Informer().GetIndexer().Add(testCCD)
 
+	recorder := events.NewInMemoryRecorder(operandName)
 	controller := NewCSIStorageClassController(
 		controllerName,
 		fakeAssetFuncFactory(test.appliedAnnotation),
@@ -225,11 +229,12 @@ func newTestContext(test testCase, t *testing.T) *testContext {
 		coreInformerFactory,
 		fakeOperatorClient,
 		fakeOperatorInformer,
-		events.NewInMemoryRecorder(operandName),
+		recorder,
 		test.hooks...,
 	)
 
 	return &testContext{
+		recorder:       recorder,
 		controller:     controller,
 		operatorClient: fakeOperatorClient,
 		kubeClient:     kubeClient,
@@ -567,3 +572,85 @@ type fakeDriverInstance struct {
 	Spec   opv1.OperatorSpec
 	Status opv1.OperatorStatus
 }
+
+func TestSyncImmutableFieldsDrift(t *testing.T) {
+	testCases := []struct {
+		name           string
+		failDelete     bool
+		expectErr      bool
+		expectedStatus opv1.ConditionStatus
+		expectedReason string
+		// expectedEvents is the number of StorageClassRecreateFailed events after two syncs
+		expectedEvents int
+	}{
+		{
+			name:           "StorageClass re-created",
+			expectedStatus: opv1.ConditionFalse,
+			expectedReason: "AsExpected",
+		},
+		{
+			name:           "StorageClass re-creation failed",
+			failDelete:     true,
+			expectErr:      true,
+			expectedStatus: opv1.ConditionTrue,
+			expectedReason: "StorageClassRecreateFailed",
+			expectedEvents: 1,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			drifted := fakeAssetFuncToScObject(fakeAssetFuncFactory("true"))
+			drifted.Parameters = map[string]string{"type": "drifted"}
+			ctx := newTestContext(testCase{
+				scState:           opv1.ManagedStorageClass,
+				initialObjects:    testObjects{storageClasses: []*storagev1.StorageClass{drifted}},
+				appliedAnnotation: "true",
+			}, t)
+			if test.failDelete {
+				ctx.kubeClient.(*fakecore.Clientset).PrependReactor("delete", "storageclasses", func(action clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, fmt.Errorf("fake delete error")
+				})
+			}
+
+			// the second sync must not report the same failure again
+			for i := 0; i < 2; i++ {
+				err := ctx.controller.Sync(context.TODO(), factory.NewSyncContext(controllerName, events.NewInMemoryRecorder("test-csi-driver")))
+				if err != nil && !test.expectErr {
+					t.Errorf("sync() returned unexpected error: %v", err)
+				}
+				if err == nil && test.expectErr {
+					t.Error("sync() unexpectedly succeeded when error was expected")
+				}
+			}
+			warnings := 0
+			for _, event := range ctx.recorder.Events() {
+				if event.Reason == recreateFailedReason {
+					warnings++
+				}
+			}
+			if warnings != test.expectedEvents {
+				t.Errorf("expected %d %s events, got %d", test.expectedEvents, recreateFailedReason, warnings)
+			}
+
+			_, status, _, err := ctx.operatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			cond := v1helpers.FindOperatorCondition(status.Conditions, "StorageClassControllerDegraded")
+			if cond == nil || cond.Status != test.expectedStatus || cond.Reason != test.expectedReason {
+				t.Errorf("expected StorageClassControllerDegraded=%s with reason %s, got %#v", test.expectedStatus, test.expectedReason, cond)
+			}
+
+			if !test.failDelete {
+				actual, err := ctx.kubeClient.StorageV1().StorageClasses().Get(context.TODO(), drifted.Name, metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual.Parameters["type"] != "available" {
+					t.Errorf("expected the StorageClass parameters to be reconciled, got %v", actual.Parameters)
+				}
+			}
+		})
+	}
+}
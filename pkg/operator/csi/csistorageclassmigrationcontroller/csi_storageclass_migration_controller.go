@@ -0,0 +1,269 @@
+package csistorageclassmigrationcontroller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	operatorapi "github.com/openshift/api/operator/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	storagelisters "k8s.io/client-go/listers/storage/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+const (
+	defaultScAnnotationKey = "storageclass.kubernetes.io/is-default-class"
+
+	storageClassMigrationControllerName = "StorageClassMigrationController"
+	deprecatedInUseCondition            = storageClassMigrationControllerName + "DeprecatedStorageClassesInUse"
+)
+
+// StorageClassMigration describes the replacement of a deprecated StorageClass whose parameters
+// were renamed in a new version of the CSI driver.
+type StorageClassMigration struct {
+	// OldName is the name of the deprecated StorageClass.
+	OldName string
+	// NewName is the name of the replacement StorageClass.
+	NewName string
+	// ParameterNames maps deprecated parameter names to their new names.
+	// Parameters not listed here are copied to the replacement as they are.
+	ParameterNames map[string]string
+	// DroppedParameters are not copied to the replacement.
+	DroppedParameters []string
+	// MoveDefault moves the default StorageClass annotation from the deprecated StorageClass to the replacement.
+	MoveDefault bool
+}
+
+// This Controller replaces deprecated StorageClasses of a CSI driver.
+// For each StorageClassMigration whose deprecated StorageClass exists, it creates the replacement
+// StorageClass with the renamed parameters. An existing replacement is never overwritten, so that
+// changes made by users are preserved. The deprecated StorageClass is not removed, PersistentVolumes
+// provisioned from it keep referencing it.
+// If requested, the default StorageClass annotation is moved to the replacement: it's set on the
+// replacement first and removed from the deprecated StorageClass afterwards.
+// It produces following Conditions:
+// StorageClassMigrationControllerDeprecatedStorageClassesInUse - True when PersistentVolumes still use
+// a deprecated StorageClass, listing the number of PersistentVolumes per StorageClass.
+// StorageClassMigrationControllerDegraded - failed to migrate a StorageClass.
+type CSIStorageClassMigrationController struct {
+	name               string
+	migrations         []StorageClassMigration
+	kubeClient         kubernetes.Interface
+	storageClassLister storagelisters.StorageClassLister
+	pvLister           corelisters.PersistentVolumeLister
+	operatorClient     v1helpers.OperatorClient
+	eventRecorder      events.Recorder
+}
+
+func NewCSIStorageClassMigrationController(
+	name string,
+	migrations []StorageClassMigration,
+	kubeClient kubernetes.Interface,
+	informerFactory informers.SharedInformerFactory,
+	operatorClient v1helpers.OperatorClient,
+	eventRecorder events.Recorder) factory.Controller {
+	c := &CSIStorageClassMigrationController{
+		name:               name,
+		migrations:         migrations,
+		kubeClient:         kubeClient,
+		storageClassLister: informerFactory.Storage().V1().StorageClasses().Lister(),
+		pvLister:           informerFactory.Core().V1().PersistentVolumes().Lister(),
+		operatorClient:     operatorClient,
+		eventRecorder:      eventRecorder,
+	}
+
+	return factory.New().WithSync(
+		c.Sync,
+	).ResyncEvery(
+		time.Minute,
+	).WithInformers(
+		operatorClient.Informer(),
+		informerFactory.Storage().V1().StorageClasses().Informer(),
+		informerFactory.Core().V1().PersistentVolumes().Informer(),
+	).WithSyncDegradedOnError(
+		operatorClient,
+	).ToController(
+		storageClassMigrationControllerName,
+		eventRecorder,
+	)
+}
+
+func (c *CSIStorageClassMigrationController) Sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	klog.V(4).Infof("StorageClassMigrationController sync started")
+	defer klog.V(4).Infof("StorageClassMigrationController sync finished")
+
+	opSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if opSpec.ManagementState != operatorapi.Managed {
+		return nil
+	}
+
+	for _, migration := range c.migrations {
+		if err := c.migrate(ctx, migration); err != nil {
+			return fmt.Errorf("failed to migrate StorageClass %s to %s: %w", migration.OldName, migration.NewName, err)
+		}
+	}
+
+	inUse, err := c.deprecatedStorageClassesInUse()
+	if err != nil {
+		return err
+	}
+	_, _, err = v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(inUseCondition(inUse)))
+	return err
+}
+
+func (c *CSIStorageClassMigrationController) migrate(ctx context.Context, migration StorageClassMigration) error {
+	oldSC, err := c.storageClassLister.Get(migration.OldName)
+	if errors.IsNotFound(err) {
+		// nothing to migrate, either a new cluster or the deprecated StorageClass was removed by the user
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	newSC, err := c.storageClassLister.Get(migration.NewName)
+	if errors.IsNotFound(err) {
+		required := ReplacementStorageClass(oldSC, migration)
+		newSC, err = c.kubeClient.StorageV1().StorageClasses().Create(ctx, required, metav1.CreateOptions{})
+		if err != nil {
+			c.eventRecorder.Warningf("StorageClassMigrationFailed", "Failed to create StorageClass %s replacing %s: %v", migration.NewName, migration.OldName, err)
+			return err
+		}
+		c.eventRecorder.Eventf("StorageClassMigrated", "Created StorageClass %s replacing the deprecated StorageClass %s", migration.NewName, migration.OldName)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !migration.MoveDefault || oldSC.Annotations[defaultScAnnotationKey] != "true" {
+		return nil
+	}
+	if newSC.Annotations[defaultScAnnotationKey] != "true" {
+		newSC = newSC.DeepCopy()
+		if newSC.Annotations == nil {
+			newSC.Annotations = map[string]string{}
+		}
+		newSC.Annotations[defaultScAnnotationKey] = "true"
+		if _, err := c.kubeClient.StorageV1().StorageClasses().Update(ctx, newSC, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+	oldSC = oldSC.DeepCopy()
+	delete(oldSC.Annotations, defaultScAnnotationKey)
+	if _, err := c.kubeClient.StorageV1().StorageClasses().Update(ctx, oldSC, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	c.eventRecorder.Eventf("DefaultStorageClassMoved", "Moved the default StorageClass annotation from %s to %s", migration.OldName, migration.NewName)
+	return nil
+}
+
+// ReplacementStorageClass returns the StorageClass replacing oldSC according to the migration.
+func ReplacementStorageClass(oldSC *storagev1.StorageClass, migration StorageClassMigration) *storagev1.StorageClass {
+	dropped := map[string]bool{}
+	for _, name := range migration.DroppedParameters {
+		dropped[name] = true
+	}
+
+	newSC := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        migration.NewName,
+			Labels:      copyMap(oldSC.Labels),
+			Annotations: copyMap(oldSC.Annotations),
+		},
+		Provisioner:          oldSC.Provisioner,
+		ReclaimPolicy:        oldSC.ReclaimPolicy,
+		MountOptions:         append([]string(nil), oldSC.MountOptions...),
+		AllowVolumeExpansion: oldSC.AllowVolumeExpansion,
+		VolumeBindingMode:    oldSC.VolumeBindingMode,
+		AllowedTopologies:    append([]corev1.TopologySelectorTerm(nil), oldSC.AllowedTopologies...),
+	}
+	// the default annotation is moved explicitly, if at all
+	delete(newSC.Annotations, defaultScAnnotationKey)
+
+	if oldSC.Parameters != nil {
+		newSC.Parameters = map[string]string{}
+		for name, value := range oldSC.Parameters {
+			if dropped[name] {
+				continue
+			}
+			if newName, ok := migration.ParameterNames[name]; ok {
+				name = newName
+			}
+			newSC.Parameters[name] = value
+		}
+	}
+	return newSC
+}
+
+// deprecatedStorageClassesInUse returns the number of PersistentVolumes per deprecated StorageClass.
+func (c *CSIStorageClassMigrationController) deprecatedStorageClassesInUse() (map[string]int, error) {
+	deprecated := map[string]bool{}
+	for _, migration := range c.migrations {
+		deprecated[migration.OldName] = true
+	}
+
+	pvs, err := c.pvLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	inUse := map[string]int{}
+	for _, pv := range pvs {
+		if deprecated[pv.Spec.StorageClassName] {
+			inUse[pv.Spec.StorageClassName]++
+		}
+	}
+	return inUse, nil
+}
+
+func inUseCondition(inUse map[string]int) operatorapi.OperatorCondition {
+	if len(inUse) == 0 {
+		return operatorapi.OperatorCondition{
+			Type:   deprecatedInUseCondition,
+			Status: operatorapi.ConditionFalse,
+			Reason: "AsExpected",
+		}
+	}
+
+	names := make([]string, 0, len(inUse))
+	for name := range inUse {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	usages := make([]string, 0, len(names))
+	for _, name := range names {
+		usages = append(usages, fmt.Sprintf("%s (%d)", name, inUse[name]))
+	}
+	return operatorapi.OperatorCondition{
+		Type:    deprecatedInUseCondition,
+		Status:  operatorapi.ConditionTrue,
+		Reason:  "PersistentVolumesUseDeprecatedStorageClasses",
+		Message: fmt.Sprintf("PersistentVolumes still use deprecated StorageClasses: %s", strings.Join(usages, ", ")),
+	}
+}
+
+func copyMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	ret := make(map[string]string, len(m))
+	for k, v := range m {
+		ret[k] = v
+	}
+	return ret
+}
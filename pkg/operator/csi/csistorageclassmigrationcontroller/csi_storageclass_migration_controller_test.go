@@ -0,0 +1,162 @@
+package csistorageclassmigrationcontroller
+
+import (
+	"context"
+	"testing"
+
+	opv1 "github.com/openshift/api/operator/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	coreinformers "k8s.io/client-go/informers"
+	fakecore "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+var migration = StorageClassMigration{
+	OldName:           "standard-csi",
+	NewName:           "standard-csi-v2",
+	ParameterNames:    map[string]string{"type": "volumeType"},
+	DroppedParameters: []string{"legacy"},
+}
+
+func TestSync(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		moveDefault            bool
+		storageClasses         []*storagev1.StorageClass
+		pvs                    []*corev1.PersistentVolume
+		expectedStorageClasses []*storagev1.StorageClass
+		expectedInUse          opv1.ConditionStatus
+		expectedInUseMessage   string
+	}{
+		{
+			name: "no deprecated StorageClass",
+			storageClasses: []*storagev1.StorageClass{
+				makeStorageClass("other", false, map[string]string{"type": "ssd"}),
+			},
+			expectedStorageClasses: []*storagev1.StorageClass{
+				makeStorageClass("other", false, map[string]string{"type": "ssd"}),
+			},
+			expectedInUse: opv1.ConditionFalse,
+		},
+		{
+			name: "replacement created, default kept",
+			storageClasses: []*storagev1.StorageClass{
+				makeStorageClass("standard-csi", true, map[string]string{"type": "ssd", "legacy": "true", "encrypted": "true"}),
+			},
+			pvs: []*corev1.PersistentVolume{makePV("pv1", "standard-csi"), makePV("pv2", "standard-csi"), makePV("pv3", "other")},
+			expectedStorageClasses: []*storagev1.StorageClass{
+				makeStorageClass("standard-csi", true, map[string]string{"type": "ssd", "legacy": "true", "encrypted": "true"}),
+				makeStorageClass("standard-csi-v2", false, map[string]string{"volumeType": "ssd", "encrypted": "true"}),
+			},
+			expectedInUse:        opv1.ConditionTrue,
+			expectedInUseMessage: "PersistentVolumes still use deprecated StorageClasses: standard-csi (2)",
+		},
+		{
+			name:        "replacement created, default moved",
+			moveDefault: true,
+			storageClasses: []*storagev1.StorageClass{
+				makeStorageClass("standard-csi", true, map[string]string{"type": "ssd"}),
+			},
+			expectedStorageClasses: []*storagev1.StorageClass{
+				makeStorageClass("standard-csi", false, map[string]string{"type": "ssd"}),
+				makeStorageClass("standard-csi-v2", true, map[string]string{"volumeType": "ssd"}),
+			},
+			expectedInUse: opv1.ConditionFalse,
+		},
+		{
+			name: "existing replacement is not overwritten",
+			storageClasses: []*storagev1.StorageClass{
+				makeStorageClass("standard-csi", false, map[string]string{"type": "ssd"}),
+				makeStorageClass("standard-csi-v2", false, map[string]string{"volumeType": "hdd"}),
+			},
+			expectedStorageClasses: []*storagev1.StorageClass{
+				makeStorageClass("standard-csi", false, map[string]string{"type": "ssd"}),
+				makeStorageClass("standard-csi-v2", false, map[string]string{"volumeType": "hdd"}),
+			},
+			expectedInUse: opv1.ConditionFalse,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var objects []runtime.Object
+			for _, sc := range tc.storageClasses {
+				objects = append(objects, sc)
+			}
+			for _, pv := range tc.pvs {
+				objects = append(objects, pv)
+			}
+			kubeClient := fakecore.NewSimpleClientset(objects...)
+			informerFactory := coreinformers.NewSharedInformerFactory(kubeClient, 0)
+			for _, sc := range tc.storageClasses {
+				informerFactory.Storage().V1().StorageClasses().Informer().GetIndexer().Add(sc)
+			}
+			for _, pv := range tc.pvs {
+				informerFactory.Core().V1().PersistentVolumes().Informer().GetIndexer().Add(pv)
+			}
+			operatorClient := v1helpers.NewFakeOperatorClient(&opv1.OperatorSpec{ManagementState: opv1.Managed}, &opv1.OperatorStatus{}, nil)
+
+			m := migration
+			m.MoveDefault = tc.moveDefault
+			recorder := events.NewInMemoryRecorder("test")
+			controller := NewCSIStorageClassMigrationController("test", []StorageClassMigration{m}, kubeClient, informerFactory, operatorClient, recorder)
+
+			if err := controller.Sync(context.TODO(), factory.NewSyncContext("test", recorder)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			scs, err := kubeClient.StorageV1().StorageClasses().List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(scs.Items) != len(tc.expectedStorageClasses) {
+				t.Fatalf("expected %d StorageClasses, got %d", len(tc.expectedStorageClasses), len(scs.Items))
+			}
+			for _, expected := range tc.expectedStorageClasses {
+				actual, err := kubeClient.StorageV1().StorageClasses().Get(context.TODO(), expected.Name, metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !equality.Semantic.DeepEqual(actual.Parameters, expected.Parameters) ||
+					actual.Annotations[defaultScAnnotationKey] != expected.Annotations[defaultScAnnotationKey] {
+					t.Errorf("unexpected StorageClass %s: parameters %v, annotations %v", actual.Name, actual.Parameters, actual.Annotations)
+				}
+			}
+
+			_, status, _, err := operatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			cond := v1helpers.FindOperatorCondition(status.Conditions, deprecatedInUseCondition)
+			if cond == nil || cond.Status != tc.expectedInUse || cond.Message != tc.expectedInUseMessage {
+				t.Errorf("unexpected condition %#v", cond)
+			}
+		})
+	}
+}
+
+func makeStorageClass(name string, isDefault bool, parameters map[string]string) *storagev1.StorageClass {
+	sc := &storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: name},
+		Provisioner: "test.csi.example.com",
+		Parameters:  parameters,
+	}
+	if isDefault {
+		sc.Annotations = map[string]string{defaultScAnnotationKey: "true"}
+	}
+	return sc
+}
+
+func makePV(name, storageClassName string) *corev1.PersistentVolume {
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.PersistentVolumeSpec{StorageClassName: storageClassName},
+	}
+}
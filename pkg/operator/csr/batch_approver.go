@@ -0,0 +1,124 @@
+package csr
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	certapiv1 "k8s.io/api/certificates/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	certv1informers "k8s.io/client-go/informers/certificates/v1"
+	certv1client "k8s.io/client-go/kubernetes/typed/certificates/v1"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// CSRBatchOptions configure how a batch CSR approver processes the pending CSRs.
+type CSRBatchOptions struct {
+	// QPS is the maximum rate of approvals and denials per second. Zero means unlimited.
+	QPS float32
+	// Burst is the maximum burst of approvals and denials above QPS. It defaults to 1.
+	Burst int
+	// Concurrency is the number of CSRs evaluated in parallel. It defaults to 1.
+	Concurrency int
+}
+
+type batchCSRApproverController struct {
+	*csrApproverController
+	concurrency int
+}
+
+// NewBatchCSRApproverController returns a controller that approves, denies or leaves the CSRs like
+// NewCSRApproverController, but processes all pending CSRs matching csrFilter in a single sync, oldest first.
+// A burst of new CSRs, like during a large node scale-up, is then handled by one sync instead of one sync per CSR.
+//
+// The CSRs are evaluated by batchOptions.Concurrency workers, and the approvals and denials are throttled to
+// batchOptions.QPS to protect the API server. Every approval and denial is still recorded in its own event.
+func NewBatchCSRApproverController(
+	controllerName string,
+	operatorClient v1helpers.OperatorClient,
+	csrClient certv1client.CertificateSigningRequestInterface,
+	csrInformers certv1informers.CertificateSigningRequestInformer,
+	csrFilter CSRFilter,
+	csrApprover CSRApprover,
+	batchOptions CSRBatchOptions,
+	eventsRecorder events.Recorder,
+) factory.Controller {
+	addPendingCSRIndex(csrInformers.Informer())
+	c := &batchCSRApproverController{
+		csrApproverController: &csrApproverController{
+			name:        controllerName,
+			csrClient:   csrClient,
+			csrLister:   csrInformers.Lister(),
+			csrIndexer:  csrInformers.Informer().GetIndexer(),
+			csrFilter:   csrFilter,
+			csrApprover: csrApprover,
+		},
+		concurrency: batchOptions.Concurrency,
+	}
+	if c.concurrency < 1 {
+		c.concurrency = 1
+	}
+	if batchOptions.QPS > 0 {
+		burst := batchOptions.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		c.updateLimiter = flowcontrol.NewTokenBucketRateLimiter(batchOptions.QPS, burst)
+	}
+
+	csrFilterConverted := func(csr interface{}) bool {
+		csrObj, ok := csr.(*certapiv1.CertificateSigningRequest)
+		if !ok {
+			return false
+		}
+		return csrFilter.Matches(csrObj)
+	}
+
+	f := factory.New().
+		WithSync(c.sync).
+		ResyncEvery(time.Minute).
+		WithFilteredEventsInformers(csrFilterConverted, csrInformers.Informer())
+
+	if operatorClient != nil {
+		f.WithSyncDegradedOnError(operatorClient)
+	}
+
+	return f.ToController(
+		"BatchCSRApprover_"+controllerName,
+		eventsRecorder.WithComponentSuffix("batch-csr-approver-"+controllerName),
+	)
+}
+
+func (c *batchCSRApproverController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	if err := c.observeOldestPendingCSR(); err != nil {
+		return err
+	}
+
+	pending, err := c.pendingCSRs()
+	if err != nil {
+		return err
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].CreationTimestamp.Before(&pending[j].CreationTimestamp)
+	})
+
+	var (
+		errsLock sync.Mutex
+		errs     []error
+	)
+	workqueue.ParallelizeUntil(ctx, c.concurrency, len(pending), func(i int) {
+		if err := c.processCSR(ctx, pending[i], syncCtx.Recorder()); err != nil {
+			errsLock.Lock()
+			defer errsLock.Unlock()
+			errs = append(errs, fmt.Errorf("CSR %q: %w", pending[i].Name, err))
+		}
+	})
+	return utilerrors.NewAggregate(errs)
+}
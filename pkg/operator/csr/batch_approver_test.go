@@ -0,0 +1,129 @@
+package csr
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	certapiv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	certv1listers "k8s.io/client-go/listers/certificates/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestBatchCSRApproverController(t *testing.T) {
+	var alwaysApprove alwaysApproveApprover
+	csrIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{pendingCSRIndex: pendingCSRIndexFunc})
+	fakeObjects := []runtime.Object{}
+	addCSR := func(csr *certapiv1.CertificateSigningRequest) {
+		fakeObjects = append(fakeObjects, csr)
+		require.NoError(t, csrIndexer.Add(csr))
+	}
+
+	var pending []string
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("node-csr-%d", i)
+		pending = append(pending, name)
+		addCSR(&certapiv1.CertificateSigningRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"batch": "true"}},
+			Spec:       certapiv1.CertificateSigningRequestSpec{Request: genCSR(t, "somesubject")},
+		})
+	}
+	addCSR(&certapiv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "already-approved", Labels: map[string]string{"batch": "true"}},
+		Spec:       certapiv1.CertificateSigningRequestSpec{Request: genCSR(t, "somesubject")},
+		Status: certapiv1.CertificateSigningRequestStatus{
+			Conditions: []certapiv1.CertificateSigningRequestCondition{{Type: certapiv1.CertificateApproved, Status: corev1.ConditionTrue}},
+		},
+	})
+	addCSR(&certapiv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "not-matching"},
+		Spec:       certapiv1.CertificateSigningRequestSpec{Request: genCSR(t, "somesubject")},
+	})
+	addCSR(&certapiv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "invalid", Labels: map[string]string{"batch": "true"}},
+		Spec:       certapiv1.CertificateSigningRequestSpec{Request: []byte("invalid")},
+	})
+
+	fakeClient := fake.NewSimpleClientset(fakeObjects...)
+	c := &batchCSRApproverController{
+		csrApproverController: &csrApproverController{
+			name:          "TestBatchCSRApproverController",
+			csrClient:     fakeClient.CertificatesV1().CertificateSigningRequests(),
+			csrLister:     certv1listers.NewCertificateSigningRequestLister(csrIndexer),
+			csrIndexer:    csrIndexer,
+			csrFilter:     NewLabelFilter(labels.SelectorFromSet(labels.Set{"batch": "true"})),
+			csrApprover:   &alwaysApprove,
+			updateLimiter: flowcontrol.NewTokenBucketRateLimiter(1000, 1),
+		},
+		concurrency: 4,
+	}
+	recorder := events.NewInMemoryRecorder("batch-csr-approver-test")
+	err := c.sync(context.Background(), fakeSyncContext{queueKey: "key", eventRecorder: recorder})
+	require.EqualError(t, err, `CSR "invalid": failed to PEM-parse the CSR block in .spec.request: no CSRs were found`)
+
+	approvals := 0
+	for _, event := range recorder.Events() {
+		if event.Reason == "CSRApproval" {
+			approvals++
+		}
+	}
+	require.Equal(t, len(pending), approvals, "expected an event for every approved CSR")
+
+	updates := 0
+	for _, action := range fakeClient.Actions() {
+		if action.GetVerb() == "update" && action.GetSubresource() == "approval" {
+			updates++
+		}
+	}
+	require.Equal(t, len(pending), updates, "expected only the pending CSRs matching the filter to be approved")
+	for _, name := range pending {
+		csr, err := fakeClient.CertificatesV1().CertificateSigningRequests().Get(context.Background(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		approved, _ := getCertApprovalCondition(&csr.Status)
+		require.True(t, approved, "expected %s to be approved", name)
+	}
+}
+
+func TestBatchCSRApproverRateLimit(t *testing.T) {
+	var alwaysApprove alwaysApproveApprover
+	csrIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{pendingCSRIndex: pendingCSRIndexFunc})
+	fakeObjects := []runtime.Object{}
+	for i := 0; i < 3; i++ {
+		csr := &certapiv1.CertificateSigningRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node-csr-%d", i)},
+			Spec:       certapiv1.CertificateSigningRequestSpec{Request: genCSR(t, "somesubject")},
+		}
+		fakeObjects = append(fakeObjects, csr)
+		require.NoError(t, csrIndexer.Add(csr))
+	}
+
+	fakeClient := fake.NewSimpleClientset(fakeObjects...)
+	c := &batchCSRApproverController{
+		csrApproverController: &csrApproverController{
+			name:          "TestBatchCSRApproverRateLimit",
+			csrClient:     fakeClient.CertificatesV1().CertificateSigningRequests(),
+			csrLister:     certv1listers.NewCertificateSigningRequestLister(csrIndexer),
+			csrIndexer:    csrIndexer,
+			csrFilter:     NewLabelFilter(labels.Everything()),
+			csrApprover:   &alwaysApprove,
+			updateLimiter: flowcontrol.NewTokenBucketRateLimiter(10, 1),
+		},
+		concurrency: 3,
+	}
+
+	// the first approval uses the burst, the two others wait for 100ms each
+	start := time.Now()
+	require.NoError(t, c.sync(context.Background(), fakeSyncContext{queueKey: "key", eventRecorder: events.NewInMemoryRecorder("batch-csr-approver-test")}))
+	require.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}
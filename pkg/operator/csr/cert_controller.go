@@ -2,7 +2,6 @@ package csr
 
 import (
 	"context"
-	"crypto/tls"
 	"crypto/x509/pkix"
 	"fmt"
 	"math/rand"
@@ -10,6 +9,7 @@ import (
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
 
 	certificates "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -49,6 +49,12 @@ type CSROption struct {
 	DNSNames []string
 	// SignerName is the name of the signer specified in the created csrs
 	SignerName string
+	// SignerCABundle optionally contains the CA certificates of the signer. When set, the issued certificates must
+	// verify against it.
+	SignerCABundle []byte
+	// ApprovalTimeout is how long a csr may wait for its approval and issuance before the controller reports an
+	// error, while it keeps waiting. Zero means forever.
+	ApprovalTimeout time.Duration
 
 	// EventFilterFunc matches csrs created with above options
 	EventFilterFunc factory.EventFilterFunc
@@ -102,6 +108,34 @@ func NewClientCertificateController(
 	spokeCoreClient corev1client.CoreV1Interface,
 	recorder events.Recorder,
 	controllerName string,
+) (factory.Controller, error) {
+	return NewClientCertificateControllerWithStatus(
+		clientCertOption,
+		csrOption,
+		nil,
+		hubCSRInformer,
+		hubCSRClient,
+		spokeSecretInformer,
+		spokeCoreClient,
+		recorder,
+		controllerName,
+	)
+}
+
+// NewClientCertificateControllerWithStatus returns a client certificate controller like NewClientCertificateController
+// that reports its errors in the <controllerName>Degraded condition of operatorClient, i.e. when a csr is denied,
+// when the issued certificate is invalid, or when the issuance takes longer than the ApprovalTimeout of csrOption.
+// If operatorClient is nil, the errors are only logged.
+func NewClientCertificateControllerWithStatus(
+	clientCertOption ClientCertOption,
+	csrOption CSROption,
+	operatorClient v1helpers.OperatorClient,
+	hubCSRInformer certificatesinformers.CertificateSigningRequestInformer,
+	hubCSRClient csrclient.CertificateSigningRequestInterface,
+	spokeSecretInformer corev1informers.SecretInformer,
+	spokeCoreClient corev1client.CoreV1Interface,
+	recorder events.Recorder,
+	controllerName string,
 ) (factory.Controller, error) {
 	if len(csrOption.ObjectMeta.Name) > 0 {
 		return nil, fmt.Errorf("the CSR controller does not allow specifying static names for the CSRs")
@@ -116,7 +150,7 @@ func NewClientCertificateController(
 		controllerName:   controllerName,
 	}
 
-	return factory.New().
+	f := factory.New().
 		WithFilteredEventsInformersQueueKeyFunc(func(obj runtime.Object) string {
 			key, _ := cache.MetaNamespaceKeyFunc(obj)
 			return key
@@ -136,8 +170,13 @@ func NewClientCertificateController(
 			return accessor.GetName()
 		}, c.EventFilterFunc, hubCSRInformer.Informer()).
 		WithSync(c.sync).
-		ResyncEvery(ControllerResyncInterval).
-		ToController(controllerName, recorder), nil
+		ResyncEvery(ControllerResyncInterval)
+
+	if operatorClient != nil {
+		f.WithSyncDegradedOnError(operatorClient)
+	}
+
+	return f.ToController(controllerName, recorder), nil
 }
 
 func (c *clientCertificateController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
@@ -158,6 +197,10 @@ func (c *clientCertificateController) sync(ctx context.Context, syncCtx factory.
 	// reconcile pending csr if exists
 	if len(c.csrName) > 0 {
 		newSecretConfig, err := c.syncCSR(secret)
+		if isIssuanceBlocked(err) {
+			// keep waiting for the csr
+			return err
+		}
 		if err != nil {
 			c.reset()
 			return err
@@ -169,6 +212,8 @@ func (c *clientCertificateController) sync(ctx context.Context, syncCtx factory.
 		for k, v := range c.AdditonalSecretData {
 			newSecretConfig[k] = v
 		}
+		// the certificate and its key are swapped with a single write, which fails on a conflicting change
+		secret = secret.DeepCopy()
 		secret.Data = newSecretConfig
 		// save the changes into secret
 		if err := c.saveSecret(secret); err != nil {
@@ -237,13 +282,16 @@ func (c *clientCertificateController) syncCSR(secret *corev1.Secret) (map[string
 		return nil, err
 	}
 
-	// skip if csr is not approved yet
-	if !isCSRApproved(csr) {
-		return nil, nil
+	// a denied or failed csr never gets a certificate
+	if reason, failed := isCSRDeniedOrFailed(csr); failed {
+		return nil, fmt.Errorf("csr %q will not be issued: %s", c.csrName, reason)
 	}
 
-	// skip if csr has no certificate in its status yet
-	if len(csr.Status.Certificate) == 0 {
+	// skip if csr is not approved yet, or has no certificate in its status yet
+	if !isCSRApproved(csr) || len(csr.Status.Certificate) == 0 {
+		if pending := time.Since(csr.CreationTimestamp.Time); c.ApprovalTimeout > 0 && pending > c.ApprovalTimeout {
+			return nil, &issuanceBlockedError{csrName: c.csrName, approved: isCSRApproved(csr), pending: pending}
+		}
 		return nil, nil
 	}
 
@@ -252,9 +300,8 @@ func (c *clientCertificateController) syncCSR(secret *corev1.Secret) (map[string
 	if c.keyData == nil {
 		return nil, fmt.Errorf("No private key found for certificate in csr: %s", c.csrName)
 	}
-	_, err = tls.X509KeyPair(csr.Status.Certificate, c.keyData)
-	if err != nil {
-		return nil, fmt.Errorf("Private key does not match with the certificate in csr: %s", c.csrName)
+	if err := validateIssuedCertificate(csr.Status.Certificate, c.keyData, c.Subject, c.SignerCABundle); err != nil {
+		return nil, fmt.Errorf("invalid certificate in csr %s: %w", c.csrName, err)
 	}
 
 	data := map[string][]byte{
@@ -305,6 +352,25 @@ func (c *clientCertificateController) saveSecret(secret *corev1.Secret) error {
 	return err
 }
 
+// issuanceBlockedError is returned while a csr waits for its approval or issuance for longer than the approval timeout.
+type issuanceBlockedError struct {
+	csrName  string
+	approved bool
+	pending  time.Duration
+}
+
+func (e *issuanceBlockedError) Error() string {
+	if e.approved {
+		return fmt.Sprintf("csr %q was approved but has not been issued for %v", e.csrName, e.pending.Round(time.Second))
+	}
+	return fmt.Sprintf("csr %q has been waiting for approval for %v", e.csrName, e.pending.Round(time.Second))
+}
+
+func isIssuanceBlocked(err error) bool {
+	_, ok := err.(*issuanceBlockedError)
+	return ok
+}
+
 func (c *clientCertificateController) reset() {
 	c.csrName = ""
 	c.keyData = nil
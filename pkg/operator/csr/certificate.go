@@ -1,6 +1,8 @@
 package csr
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"crypto/x509/pkix"
 	"errors"
 	"fmt"
@@ -117,6 +119,62 @@ func BuildKubeconfig(clientConfig *restclient.Config, certPath, keyPath string)
 	return kubeconfig
 }
 
+// isCSRDeniedOrFailed returns whether the given csr was denied or failed, and why.
+func isCSRDeniedOrFailed(csr *certificatesv1.CertificateSigningRequest) (string, bool) {
+	for _, condition := range csr.Status.Conditions {
+		if condition.Type != certificatesv1.CertificateDenied && condition.Type != certificatesv1.CertificateFailed {
+			continue
+		}
+		if condition.Status == corev1.ConditionFalse {
+			continue
+		}
+		return fmt.Sprintf("%s: %s %s", condition.Type, condition.Reason, condition.Message), true
+	}
+	return "", false
+}
+
+// validateIssuedCertificate checks that the leaf of the issued certificate chain is a currently valid client
+// certificate for the subject and the private key, and that the chain verifies against the CA bundle, when set.
+func validateIssuedCertificate(certData, keyData []byte, subject *pkix.Name, caBundle []byte) error {
+	if _, err := tls.X509KeyPair(certData, keyData); err != nil {
+		return fmt.Errorf("private key does not match with the certificate: %w", err)
+	}
+	certs, err := certutil.ParseCertsPEM(certData)
+	if err != nil {
+		return fmt.Errorf("unable to parse certificate: %w", err)
+	}
+	leaf := certs[0]
+
+	now := time.Now()
+	if now.Before(leaf.NotBefore) || now.After(leaf.NotAfter) {
+		return fmt.Errorf("the certificate is only valid from %v to %v", leaf.NotBefore, leaf.NotAfter)
+	}
+	if subject != nil && leaf.Subject.CommonName != subject.CommonName {
+		return fmt.Errorf("the certificate was issued for cn=%s instead of cn=%s", leaf.Subject.CommonName, subject.CommonName)
+	}
+
+	if len(caBundle) == 0 {
+		return nil
+	}
+	roots, err := certutil.NewPoolFromBytes(caBundle)
+	if err != nil {
+		return fmt.Errorf("invalid signer CA bundle: %w", err)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return fmt.Errorf("the certificate does not verify against the signer CA bundle: %w", err)
+	}
+	return nil
+}
+
 // isCSRApproved returns true if the given csr has been approved
 func isCSRApproved(csr *certificatesv1.CertificateSigningRequest) bool {
 	approved := false
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/x509/pkix"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -190,3 +191,100 @@ func TestSync(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncBlockedIssuance(t *testing.T) {
+	testSubject := &pkix.Name{
+		CommonName: commonName,
+	}
+
+	cases := []struct {
+		name            string
+		csr             *certificates.CertificateSigningRequest
+		issuedCert      *csrtestinghelpers.TestCert
+		csrOption       CSROption
+		expectedErr     string
+		csrNameExpected bool
+	}{
+		{
+			name:        "denied csr",
+			csr:         csrtestinghelpers.NewDeniedCSR(csrtestinghelpers.CSRHolder{Name: testCSRName}),
+			expectedErr: `csr "testcsr" will not be issued: Denied:  `,
+		},
+		{
+			name:        "certificate for another subject",
+			csr:         csrtestinghelpers.NewApprovedCSR(csrtestinghelpers.CSRHolder{Name: testCSRName}),
+			issuedCert:  csrtestinghelpers.NewTestCert("someone-else", time.Hour),
+			expectedErr: "invalid certificate in csr testcsr: the certificate was issued for cn=someone-else instead of cn=" + commonName,
+		},
+		{
+			name:        "certificate from another signer",
+			csr:         csrtestinghelpers.NewApprovedCSR(csrtestinghelpers.CSRHolder{Name: testCSRName}),
+			issuedCert:  csrtestinghelpers.NewTestCert(commonName, time.Hour),
+			csrOption:   CSROption{SignerCABundle: csrtestinghelpers.NewTestCert("other-signer", time.Hour).Cert},
+			expectedErr: "invalid certificate in csr testcsr: the certificate does not verify against the signer CA bundle: x509: certificate signed by unknown authority",
+		},
+		{
+			name: "approval timeout",
+			csr: func() *certificates.CertificateSigningRequest {
+				csr := csrtestinghelpers.NewCSR(csrtestinghelpers.CSRHolder{Name: testCSRName})
+				csr.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+				return csr
+			}(),
+			csrOption:       CSROption{ApprovalTimeout: 10 * time.Minute},
+			expectedErr:     `csr "testcsr" has been waiting for approval for 1h0m`,
+			csrNameExpected: true,
+		},
+		{
+			name: "issuance timeout",
+			csr: func() *certificates.CertificateSigningRequest {
+				csr := csrtestinghelpers.NewApprovedCSR(csrtestinghelpers.CSRHolder{Name: testCSRName})
+				csr.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+				return csr
+			}(),
+			csrOption:       CSROption{ApprovalTimeout: 10 * time.Minute},
+			expectedErr:     `csr "testcsr" was approved but has not been issued for 1h0m`,
+			csrNameExpected: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			keyData := csrtestinghelpers.NewTestCert(commonName, time.Hour).Key
+			if c.issuedCert != nil {
+				c.csr.Status.Certificate = c.issuedCert.Cert
+				keyData = c.issuedCert.Key
+			}
+			hubKubeClient := kubefake.NewSimpleClientset(c.csr)
+			hubInformerFactory := informers.NewSharedInformerFactory(hubKubeClient, 3*time.Minute)
+			agentKubeClient := kubefake.NewSimpleClientset(
+				csrtestinghelpers.NewHubKubeconfigSecret(testNamespace, testSecretName, "1", nil, map[string][]byte{}),
+			)
+
+			csrOption := c.csrOption
+			csrOption.Subject = testSubject
+			csrOption.SignerName = certificates.KubeAPIServerClientSignerName
+			controller := &clientCertificateController{
+				ClientCertOption: ClientCertOption{
+					SecretNamespace: testNamespace,
+					SecretName:      testSecretName,
+				},
+				CSROption:       csrOption,
+				hubCSRLister:    hubInformerFactory.Certificates().V1().CertificateSigningRequests().Lister(),
+				hubCSRClient:    hubKubeClient.CertificatesV1().CertificateSigningRequests(),
+				spokeCoreClient: agentKubeClient.CoreV1(),
+				controllerName:  "test-agent",
+				csrName:         testCSRName,
+				keyData:         keyData,
+			}
+
+			err := controller.sync(context.TODO(), csrtestinghelpers.NewFakeSyncContext(t, testSecretName))
+			if err == nil || !strings.HasPrefix(err.Error(), c.expectedErr) {
+				t.Errorf("expected error %q, got %v", c.expectedErr, err)
+			}
+
+			if hasCSRName := controller.csrName != ""; c.csrNameExpected != hasCSRName {
+				t.Errorf("expected csrName set to be %v, got %q", c.csrNameExpected, controller.csrName)
+			}
+			csrtestinghelpers.AssertNoActions(t, agentKubeClient.Actions()[1:])
+		})
+	}
+}
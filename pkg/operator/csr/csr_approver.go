@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"time"
 
@@ -17,6 +18,9 @@ import (
 	certv1informers "k8s.io/client-go/informers/certificates/v1"
 	certv1client "k8s.io/client-go/kubernetes/typed/certificates/v1"
 	certv1listers "k8s.io/client-go/listers/certificates/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
@@ -36,11 +40,59 @@ type CSRApprover interface {
 	Approve(csrObj *certapiv1.CertificateSigningRequest, x509CSR *x509.CertificateRequest) (approvalStatus CSRApprovalDecision, denyReason string, err error)
 }
 
+// TransientApprovalError is returned by a CSRApprover that cannot decide yet, e.g. because a lister failed or an
+// object the decision depends on does not exist yet. The CSR is retried instead of being denied.
+type TransientApprovalError struct {
+	Err error
+}
+
+func (e *TransientApprovalError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TransientApprovalError) Unwrap() error {
+	return e.Err
+}
+
+// pendingCSRIndex indexes the CSRs neither approved nor denied yet under pendingCSRIndexValue, so that the controllers
+// find the pending CSRs without listing all of them on every sync.
+const (
+	pendingCSRIndex      = "pending"
+	pendingCSRIndexValue = "true"
+)
+
+func pendingCSRIndexFunc(obj interface{}) ([]string, error) {
+	csr, ok := obj.(*certapiv1.CertificateSigningRequest)
+	if !ok {
+		return nil, nil
+	}
+	if approved, denied := getCertApprovalCondition(&csr.Status); approved || denied {
+		return nil, nil
+	}
+	return []string{pendingCSRIndexValue}, nil
+}
+
+// addPendingCSRIndex adds the pendingCSRIndex to the CSR informer, unless another controller sharing it already did.
+func addPendingCSRIndex(informer cache.SharedIndexInformer) {
+	if _, ok := informer.GetIndexer().GetIndexers()[pendingCSRIndex]; ok {
+		return
+	}
+	if err := informer.AddIndexers(cache.Indexers{pendingCSRIndex: pendingCSRIndexFunc}); err != nil {
+		panic(fmt.Errorf("failed to add the %q index to the CSR informer: %w", pendingCSRIndex, err))
+	}
+}
+
 type csrApproverController struct {
-	csrClient certv1client.CertificateSigningRequestInterface
-	csrLister certv1listers.CertificateSigningRequestLister
+	name       string
+	csrClient  certv1client.CertificateSigningRequestInterface
+	csrLister  certv1listers.CertificateSigningRequestLister
+	csrIndexer cache.Indexer
 
+	csrFilter   CSRFilter
 	csrApprover CSRApprover
+
+	// updateLimiter throttles the approvals and denials, when set
+	updateLimiter flowcontrol.RateLimiter
 }
 
 // NewCSRApproverController returns a controller that is observing the CSR API
@@ -49,6 +101,10 @@ type csrApproverController struct {
 //
 // If operatorClient is nil, the controller will log the errors instead of reporting
 // them in an operator status.
+//
+// The decisions of the controller are counted in the csr_approval_decisions_total metric, by signer name,
+// and the age of the oldest CSR that matches csrFilter and is still pending is exported in
+// the csr_approval_oldest_pending_age_seconds gauge. Both are labeled with the controllerName.
 func NewCSRApproverController(
 	controllerName string,
 	operatorClient v1helpers.OperatorClient,
@@ -58,9 +114,13 @@ func NewCSRApproverController(
 	csrApprover CSRApprover,
 	eventsRecorder events.Recorder,
 ) factory.Controller {
+	addPendingCSRIndex(csrInformers.Informer())
 	c := &csrApproverController{
+		name:        controllerName,
 		csrClient:   csrClient,
 		csrLister:   csrInformers.Lister(),
+		csrIndexer:  csrInformers.Informer().GetIndexer(),
+		csrFilter:   csrFilter,
 		csrApprover: csrApprover,
 	}
 
@@ -88,6 +148,10 @@ func NewCSRApproverController(
 }
 
 func (c *csrApproverController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	if err := c.observeOldestPendingCSR(); err != nil {
+		return err
+	}
+
 	csr, err := c.csrLister.Get(syncCtx.QueueKey())
 	if err != nil {
 		if apierrors.IsNotFound(err) {
@@ -96,12 +160,15 @@ func (c *csrApproverController) sync(ctx context.Context, syncCtx factory.SyncCo
 		return err
 	}
 
+	return c.processCSR(ctx, csr, syncCtx.Recorder())
+}
+
+// processCSR approves or denies a pending CSR according to the decision of the approver.
+func (c *csrApproverController) processCSR(ctx context.Context, csr *certapiv1.CertificateSigningRequest, eventsRecorder events.Recorder) error {
 	if approved, denied := getCertApprovalCondition(&csr.Status); approved || denied {
 		return nil
 	}
 
-	csrCopy := csr.DeepCopy()
-
 	csrPEM, _ := pem.Decode(csr.Spec.Request)
 	if csrPEM == nil {
 		return fmt.Errorf("failed to PEM-parse the CSR block in .spec.request: no CSRs were found")
@@ -112,56 +179,139 @@ func (c *csrApproverController) sync(ctx context.Context, syncCtx factory.SyncCo
 		return fmt.Errorf("failed to parse the CSR bytes: %v", err)
 	}
 
-	if x509CSR.Subject.CommonName == csr.Spec.Username {
-		c.denyCSR(ctx, csrCopy, "IllegitimateRequester", "requester cannot request certificates for themselves", syncCtx.Recorder())
+	// kubelets legitimately request their serving certificates for themselves
+	if x509CSR.Subject.CommonName == csr.Spec.Username && csr.Spec.SignerName != certapiv1.KubeletServingSignerName {
+		metrics.ObserveDecision(c.name, csr.Spec.SignerName, CSRDenied)
+		return c.denyCSR(ctx, csr.Name, "IllegitimateRequester", "requester cannot request certificates for themselves", eventsRecorder)
 	}
 
 	csrDecision, denyReason, err := c.csrApprover.Approve(csr, x509CSR)
+	var transientErr *TransientApprovalError
+	if errors.As(err, &transientErr) {
+		return err
+	}
 	if err != nil {
-		return c.denyCSR(ctx, csrCopy, "CSRApprovingFailed", fmt.Sprintf("there was an error during CSR approval: %v", err), syncCtx.Recorder())
+		metrics.ObserveDecision(c.name, csr.Spec.SignerName, CSRDenied)
+		return c.denyCSR(ctx, csr.Name, "CSRApprovingFailed", fmt.Sprintf("there was an error during CSR approval: %v", err), eventsRecorder)
 	}
 
 	switch csrDecision {
 	case CSRDenied:
-		return c.denyCSR(ctx, csrCopy, "CSRDenied", denyReason, syncCtx.Recorder())
+		metrics.ObserveDecision(c.name, csr.Spec.SignerName, CSRDenied)
+		return c.denyCSR(ctx, csr.Name, "CSRDenied", denyReason, eventsRecorder)
 	case CSRApproved:
-		return c.approveCSR(ctx, csrCopy, syncCtx.Recorder())
+		metrics.ObserveDecision(c.name, csr.Spec.SignerName, CSRApproved)
+		return c.approveCSR(ctx, csr.Name, eventsRecorder)
 	case CSRNoOpinion:
 		fallthrough
 	default:
+		metrics.ObserveDecision(c.name, csr.Spec.SignerName, CSRNoOpinion)
 		return nil
 	}
 }
 
-func (c *csrApproverController) denyCSR(ctx context.Context, csrCopy *certapiv1.CertificateSigningRequest, reason, message string, eventsRecorder events.Recorder) error {
-	csrCopy.Status.Conditions = append(csrCopy.Status.Conditions,
-		certapiv1.CertificateSigningRequestCondition{
-			Type:    certapiv1.CertificateDenied,
-			Status:  corev1.ConditionTrue,
-			Reason:  reason,
-			Message: message,
-		},
-	)
+// observeOldestPendingCSR exports the age of the oldest pending CSR that matches the filter of the controller.
+func (c *csrApproverController) observeOldestPendingCSR() error {
+	if c.csrFilter == nil {
+		return nil
+	}
+	pending, err := c.pendingCSRs()
+	if err != nil {
+		return err
+	}
+	var oldest *certapiv1.CertificateSigningRequest
+	for _, csr := range pending {
+		if oldest == nil || csr.CreationTimestamp.Before(&oldest.CreationTimestamp) {
+			oldest = csr
+		}
+	}
+	if oldest == nil {
+		metrics.ObserveOldestPendingAge(c.name, 0)
+		return nil
+	}
+	metrics.ObserveOldestPendingAge(c.name, time.Since(oldest.CreationTimestamp.Time))
+	return nil
+}
 
-	eventsRecorder.Eventf("CSRDenial", "The CSR %q has been denied: %s - %s", csrCopy.Name, reason, message)
-	_, err := c.csrClient.UpdateApproval(ctx, csrCopy.Name, csrCopy, v1.UpdateOptions{})
-	return err
+// pendingCSRs returns the CSRs neither approved nor denied yet which match the filter of the controller.
+func (c *csrApproverController) pendingCSRs() ([]*certapiv1.CertificateSigningRequest, error) {
+	objs, err := c.csrIndexer.ByIndex(pendingCSRIndex, pendingCSRIndexValue)
+	if err != nil {
+		return nil, err
+	}
+	var pending []*certapiv1.CertificateSigningRequest
+	for _, obj := range objs {
+		csr, ok := obj.(*certapiv1.CertificateSigningRequest)
+		if !ok || !c.csrFilter.Matches(csr) {
+			continue
+		}
+		pending = append(pending, csr)
+	}
+	return pending, nil
 }
 
-func (c *csrApproverController) approveCSR(ctx context.Context, csrCopy *certapiv1.CertificateSigningRequest, eventsRecorder events.Recorder) error {
-	csrCopy.Status.Conditions = append(csrCopy.Status.Conditions,
-		certapiv1.CertificateSigningRequestCondition{
-			Type:    certapiv1.CertificateApproved,
-			Status:  corev1.ConditionTrue,
-			Reason:  "AutoApproved",
-			Message: fmt.Sprintf("Auto-approved CSR %q", csrCopy.Name),
-		})
+func (c *csrApproverController) denyCSR(ctx context.Context, name, reason, message string, eventsRecorder events.Recorder) error {
+	updated, err := c.updateApproval(ctx, name, certapiv1.CertificateSigningRequestCondition{
+		Type:    certapiv1.CertificateDenied,
+		Status:  corev1.ConditionTrue,
+		Reason:  reason,
+		Message: message,
+	})
+	if updated {
+		eventsRecorder.Eventf("CSRDenial", "The CSR %q has been denied: %s - %s", name, reason, message)
+	}
+	return err
+}
 
-	eventsRecorder.Eventf("CSRApproval", "The CSR %q has been approved", csrCopy.Name)
-	_, err := c.csrClient.UpdateApproval(ctx, csrCopy.Name, csrCopy, v1.UpdateOptions{})
+func (c *csrApproverController) approveCSR(ctx context.Context, name string, eventsRecorder events.Recorder) error {
+	updated, err := c.updateApproval(ctx, name, certapiv1.CertificateSigningRequestCondition{
+		Type:    certapiv1.CertificateApproved,
+		Status:  corev1.ConditionTrue,
+		Reason:  "AutoApproved",
+		Message: fmt.Sprintf("Auto-approved CSR %q", name),
+	})
+	if updated {
+		eventsRecorder.Eventf("CSRApproval", "The CSR %q has been approved", name)
+	}
 	return err
 }
 
+// updateApproval adds the approval condition to the CSR read from the API server, not to the informer copy the
+// decision was taken on, which can be stale by the time the update is throttled through. It returns false without
+// error when the CSR was deleted, approved or denied meanwhile.
+func (c *csrApproverController) updateApproval(ctx context.Context, name string, condition certapiv1.CertificateSigningRequestCondition) (bool, error) {
+	if err := c.waitForUpdate(ctx); err != nil {
+		return false, err
+	}
+	updated := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		csr, err := c.csrClient.Get(ctx, name, v1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if approved, denied := getCertApprovalCondition(&csr.Status); approved || denied {
+			return nil
+		}
+		csr.Status.Conditions = append(csr.Status.Conditions, condition)
+		if _, err := c.csrClient.UpdateApproval(ctx, name, csr, v1.UpdateOptions{}); err != nil {
+			return err
+		}
+		updated = true
+		return nil
+	})
+	return updated, err
+}
+
+func (c *csrApproverController) waitForUpdate(ctx context.Context) error {
+	if c.updateLimiter == nil {
+		return nil
+	}
+	return c.updateLimiter.Wait(ctx)
+}
+
 func getCertApprovalCondition(status *certapiv1.CertificateSigningRequestStatus) (approved bool, denied bool) {
 	for _, c := range status.Conditions {
 		if c.Type == certapiv1.CertificateApproved {
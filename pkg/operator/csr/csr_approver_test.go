@@ -10,6 +10,7 @@ import (
 	"encoding/pem"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -17,10 +18,12 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes/fake"
 	certv1listers "k8s.io/client-go/listers/certificates/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
+	"k8s.io/component-base/metrics/testutil"
 
 	"github.com/openshift/library-go/pkg/operator/events"
 )
@@ -29,6 +32,7 @@ func Test_csrApproverController_sync(t *testing.T) {
 	var alwaysApprove alwaysApproveApprover
 	var deny denyApprover
 	var noOpinion noOpinionApprover
+	var transient transientApprover
 
 	tests := []struct {
 		name           string
@@ -83,6 +87,50 @@ func Test_csrApproverController_sync(t *testing.T) {
 			expectApproved: corev1.ConditionUnknown,
 			expectDenied:   corev1.ConditionUnknown,
 		},
+		{
+			name:        "CSR waiting for approval - transient error",
+			csrApprover: &transient,
+			csrName:     "test-csr",
+			csrs: []*certapiv1.CertificateSigningRequest{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-csr"},
+					Spec:       certapiv1.CertificateSigningRequestSpec{Request: genCSR(t, "somesubject")},
+				},
+			},
+			expectApproved: corev1.ConditionUnknown,
+			expectDenied:   corev1.ConditionUnknown,
+			wantErr:        true,
+		},
+		{
+			name:        "requester asking for itself - denied",
+			csrApprover: &alwaysApprove,
+			csrName:     "test-csr",
+			csrs: []*certapiv1.CertificateSigningRequest{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-csr"},
+					Spec:       certapiv1.CertificateSigningRequestSpec{Request: genCSR(t, "somesubject"), Username: "somesubject"},
+				},
+			},
+			expectApproved: corev1.ConditionUnknown,
+			expectDenied:   corev1.ConditionTrue,
+		},
+		{
+			name:        "kubelet asking for its serving certificate - approved",
+			csrApprover: &alwaysApprove,
+			csrName:     "test-csr",
+			csrs: []*certapiv1.CertificateSigningRequest{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-csr"},
+					Spec: certapiv1.CertificateSigningRequestSpec{
+						Request:    genCSR(t, "system:node:worker-0"),
+						Username:   "system:node:worker-0",
+						SignerName: certapiv1.KubeletServingSignerName,
+					},
+				},
+			},
+			expectApproved: corev1.ConditionTrue,
+			expectDenied:   corev1.ConditionUnknown,
+		},
 		{
 			name:        "CSR waiting for approval - invalid CSR in request",
 			csrApprover: &noOpinion,
@@ -106,7 +154,7 @@ func Test_csrApproverController_sync(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			fakeObjects := []runtime.Object{}
-			csrIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			csrIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{pendingCSRIndex: pendingCSRIndexFunc})
 			for _, c := range tt.csrs {
 				fakeObjects = append(fakeObjects, c)
 				require.NoError(t, csrIndexer.Add(c))
@@ -118,6 +166,7 @@ func Test_csrApproverController_sync(t *testing.T) {
 			c := &csrApproverController{
 				csrClient:   fakeClient.CertificatesV1().CertificateSigningRequests(),
 				csrLister:   csrLister,
+				csrIndexer:  csrIndexer,
 				csrApprover: tt.csrApprover,
 			}
 			if err := c.sync(
@@ -282,6 +331,7 @@ func TestServiceAccountApprover(t *testing.T) {
 type denyApprover func(_ *certapiv1.CertificateSigningRequest, _ *x509.CertificateRequest) (CSRApprovalDecision, string, error)
 type alwaysApproveApprover func(_ *certapiv1.CertificateSigningRequest, _ *x509.CertificateRequest) (CSRApprovalDecision, string, error)
 type noOpinionApprover func(_ *certapiv1.CertificateSigningRequest, _ *x509.CertificateRequest) (CSRApprovalDecision, string, error)
+type transientApprover func(_ *certapiv1.CertificateSigningRequest, _ *x509.CertificateRequest) (CSRApprovalDecision, string, error)
 
 func (a *denyApprover) Approve(_ *certapiv1.CertificateSigningRequest, _ *x509.CertificateRequest) (CSRApprovalDecision, string, error) {
 	return CSRDenied, "BecauseReasons", nil
@@ -295,6 +345,10 @@ func (a *noOpinionApprover) Approve(_ *certapiv1.CertificateSigningRequest, _ *x
 	return CSRNoOpinion, "", nil
 }
 
+func (a *transientApprover) Approve(_ *certapiv1.CertificateSigningRequest, _ *x509.CertificateRequest) (CSRApprovalDecision, string, error) {
+	return CSRNoOpinion, "", &TransientApprovalError{Err: fmt.Errorf("not yet")}
+}
+
 func genCSR(t *testing.T, subjectCN string) []byte {
 	key, err := rsa.GenerateKey(rand.Reader, 1024)
 	require.NoError(t, err, "failed to generate a private key: %v", err)
@@ -341,3 +395,105 @@ func (c fakeSyncContext) QueueKey() string {
 func (c fakeSyncContext) Recorder() events.Recorder {
 	return c.eventRecorder
 }
+
+func TestCSRApproverMetrics(t *testing.T) {
+	var alwaysApprove alwaysApproveApprover
+	const signerName = "example.com/test-signer"
+	csrs := []*certapiv1.CertificateSigningRequest{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "old-csr", CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour))},
+			Spec:       certapiv1.CertificateSigningRequestSpec{Request: genCSR(t, "somesubject"), SignerName: signerName},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "older-csr-of-someone-else", CreationTimestamp: metav1.NewTime(time.Now().Add(-2 * time.Hour))},
+			Spec:       certapiv1.CertificateSigningRequestSpec{Request: genCSR(t, "somesubject"), SignerName: signerName},
+		},
+	}
+	fakeObjects := []runtime.Object{}
+	csrIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{pendingCSRIndex: pendingCSRIndexFunc})
+	for _, c := range csrs {
+		fakeObjects = append(fakeObjects, c)
+		require.NoError(t, csrIndexer.Add(c))
+	}
+
+	fakeClient := fake.NewSimpleClientset(fakeObjects...)
+	c := &csrApproverController{
+		name:        "TestCSRApproverMetrics",
+		csrClient:   fakeClient.CertificatesV1().CertificateSigningRequests(),
+		csrLister:   certv1listers.NewCertificateSigningRequestLister(csrIndexer),
+		csrIndexer:  csrIndexer,
+		csrFilter:   NewNamesFilter("old-csr"),
+		csrApprover: &alwaysApprove,
+	}
+	require.NoError(t, c.sync(context.Background(), fakeSyncContext{queueKey: "old-csr", eventRecorder: events.NewInMemoryRecorder("csr-approver-test")}))
+
+	approved, err := testutil.GetCounterMetricValue(metrics.decisions.WithLabelValues(c.name, signerName, string(CSRApproved)))
+	require.NoError(t, err)
+	require.Equal(t, 1.0, approved)
+
+	// only the CSRs matching the filter count
+	age, err := testutil.GetGaugeMetricValue(metrics.oldestPendingAge.WithLabelValues(c.name))
+	require.NoError(t, err)
+	require.InDelta(t, time.Hour.Seconds(), age, time.Minute.Seconds())
+
+	// the approved CSR is not pending anymore
+	approvedCSR, err := fakeClient.CertificatesV1().CertificateSigningRequests().Get(context.Background(), "old-csr", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NoError(t, csrIndexer.Update(approvedCSR))
+	require.NoError(t, c.sync(context.Background(), fakeSyncContext{queueKey: "old-csr", eventRecorder: events.NewInMemoryRecorder("csr-approver-test")}))
+	age, err = testutil.GetGaugeMetricValue(metrics.oldestPendingAge.WithLabelValues(c.name))
+	require.NoError(t, err)
+	require.Equal(t, 0.0, age)
+}
+
+func TestPendingCSRIndexSharedInformer(t *testing.T) {
+	informers := kubeinformers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	csrInformer := informers.Certificates().V1().CertificateSigningRequests()
+	var alwaysApprove alwaysApproveApprover
+	recorder := events.NewInMemoryRecorder("csr-approver-test")
+
+	// two controllers sharing the informer share the index
+	NewCSRApproverController("first", nil, nil, csrInformer, NewNamesFilter("csr"), &alwaysApprove, recorder)
+	NewBatchCSRApproverController("second", nil, nil, csrInformer, NewNamesFilter("csr"), &alwaysApprove, CSRBatchOptions{}, recorder)
+
+	indexer := csrInformer.Informer().GetIndexer()
+	require.NoError(t, indexer.Add(&certapiv1.CertificateSigningRequest{ObjectMeta: metav1.ObjectMeta{Name: "csr"}}))
+	require.NoError(t, indexer.Add(&certapiv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "approved"},
+		Status: certapiv1.CertificateSigningRequestStatus{
+			Conditions: []certapiv1.CertificateSigningRequestCondition{{Type: certapiv1.CertificateApproved, Status: corev1.ConditionTrue}},
+		},
+	}))
+	pending, err := indexer.ByIndex(pendingCSRIndex, pendingCSRIndexValue)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, "csr", pending[0].(*certapiv1.CertificateSigningRequest).Name)
+}
+
+func TestCSRApproverControllerStaleInformerCopy(t *testing.T) {
+	var alwaysApprove alwaysApproveApprover
+	pending := &certapiv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "csr"},
+		Spec:       certapiv1.CertificateSigningRequestSpec{Request: genCSR(t, "somesubject")},
+	}
+	// the CSR was denied meanwhile, the informer has not seen it yet
+	denied := pending.DeepCopy()
+	denied.Status.Conditions = []certapiv1.CertificateSigningRequestCondition{{Type: certapiv1.CertificateDenied, Status: corev1.ConditionTrue, Reason: "ByAdmin"}}
+	csrIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{pendingCSRIndex: pendingCSRIndexFunc})
+	require.NoError(t, csrIndexer.Add(pending))
+
+	fakeClient := fake.NewSimpleClientset(denied)
+	c := &csrApproverController{
+		csrClient:   fakeClient.CertificatesV1().CertificateSigningRequests(),
+		csrLister:   certv1listers.NewCertificateSigningRequestLister(csrIndexer),
+		csrIndexer:  csrIndexer,
+		csrApprover: &alwaysApprove,
+	}
+	recorder := events.NewInMemoryRecorder("csr-approver-test")
+	require.NoError(t, c.sync(context.Background(), fakeSyncContext{queueKey: "csr", eventRecorder: recorder}))
+
+	actual, err := fakeClient.CertificatesV1().CertificateSigningRequests().Get(context.Background(), "csr", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, denied.Status.Conditions, actual.Status.Conditions)
+	require.Empty(t, recorder.Events())
+}
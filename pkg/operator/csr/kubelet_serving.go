@@ -0,0 +1,145 @@
+package csr
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strings"
+
+	certapiv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+)
+
+const (
+	nodeUserPrefix = "system:node:"
+	nodesGroup     = "system:nodes"
+)
+
+// KubeletServingNodeName returns the name of the node that requested a kubelet serving certificate, and whether the CSR
+// was created by a node at all.
+func KubeletServingNodeName(csrObj *certapiv1.CertificateSigningRequest) (string, bool) {
+	if !strings.HasPrefix(csrObj.Spec.Username, nodeUserPrefix) {
+		return "", false
+	}
+	nodeName := strings.TrimPrefix(csrObj.Spec.Username, nodeUserPrefix)
+	return nodeName, len(nodeName) > 0
+}
+
+// ValidateKubeletServingRequester checks that the CSR uses the kubelet serving signer and was created by a node for
+// itself: the requester is in the system:nodes group, and the subject is the node user in the system:nodes organization.
+func ValidateKubeletServingRequester(csrObj *certapiv1.CertificateSigningRequest, x509CSR *x509.CertificateRequest) error {
+	if csrObj.Spec.SignerName != certapiv1.KubeletServingSignerName {
+		return fmt.Errorf("unexpected signer %q", csrObj.Spec.SignerName)
+	}
+	if _, ok := KubeletServingNodeName(csrObj); !ok {
+		return fmt.Errorf("CSR was not created by a node but by %q", csrObj.Spec.Username)
+	}
+	if !sets.NewString(csrObj.Spec.Groups...).Has(nodesGroup) {
+		return fmt.Errorf("CSR was created by a user that is not in the %s group", nodesGroup)
+	}
+	if x509CSR.Subject.CommonName != csrObj.Spec.Username {
+		return fmt.Errorf("subject common name %q does not match the requester %q", x509CSR.Subject.CommonName, csrObj.Spec.Username)
+	}
+	if organizations := x509CSR.Subject.Organization; len(organizations) != 1 || organizations[0] != nodesGroup {
+		return fmt.Errorf("subject organization must be %s, got %v", nodesGroup, organizations)
+	}
+	return nil
+}
+
+// ValidateKubeletServingUsages checks that the CSR requests the server auth usage, with the digital signature and
+// optionally the key encipherment usage, and nothing else.
+func ValidateKubeletServingUsages(csrObj *certapiv1.CertificateSigningRequest) error {
+	allowed := sets.NewString(string(certapiv1.UsageDigitalSignature), string(certapiv1.UsageKeyEncipherment), string(certapiv1.UsageServerAuth))
+	required := sets.NewString(string(certapiv1.UsageDigitalSignature), string(certapiv1.UsageServerAuth))
+
+	usages := sets.NewString()
+	for _, usage := range csrObj.Spec.Usages {
+		usages.Insert(string(usage))
+	}
+	if unexpected := usages.Difference(allowed); unexpected.Len() > 0 {
+		return fmt.Errorf("unexpected usages %v", unexpected.List())
+	}
+	if missing := required.Difference(usages); missing.Len() > 0 {
+		return fmt.Errorf("missing usages %v", missing.List())
+	}
+	return nil
+}
+
+// ValidateKubeletServingSANs checks that the subject alternative names of the CSR are addresses of the node: DNS
+// names must be its hostname or DNS addresses, IP addresses its internal or external IPs. Email addresses and URIs are
+// not allowed, and at least one DNS name or IP address is required.
+func ValidateKubeletServingSANs(x509CSR *x509.CertificateRequest, node *corev1.Node) error {
+	if len(x509CSR.EmailAddresses) > 0 || len(x509CSR.URIs) > 0 {
+		return fmt.Errorf("email addresses and URIs are not allowed")
+	}
+	if len(x509CSR.DNSNames) == 0 && len(x509CSR.IPAddresses) == 0 {
+		return fmt.Errorf("at least one DNS name or IP address is required")
+	}
+
+	dnsNames := sets.NewString()
+	var ips []net.IP
+	for _, address := range node.Status.Addresses {
+		switch address.Type {
+		case corev1.NodeHostName, corev1.NodeInternalDNS, corev1.NodeExternalDNS:
+			dnsNames.Insert(strings.ToLower(address.Address))
+		case corev1.NodeInternalIP, corev1.NodeExternalIP:
+			if ip := net.ParseIP(address.Address); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+	}
+
+	for _, name := range x509CSR.DNSNames {
+		if !dnsNames.Has(strings.ToLower(name)) {
+			return fmt.Errorf("DNS name %q is not an address of node %s", name, node.Name)
+		}
+	}
+	for _, requested := range x509CSR.IPAddresses {
+		found := false
+		for _, ip := range ips {
+			if ip.Equal(requested) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("IP address %s is not an address of node %s", requested, node.Name)
+		}
+	}
+	return nil
+}
+
+// NewKubeletServingPolicy returns a policy that approves kubelet serving CSRs passing all validations against the
+// Node object of the requester, and denies those that fail them. It abstains for other signers. While the Node object
+// of the requester does not exist or cannot be listed, it returns a TransientApprovalError so that the CSR is retried.
+func NewKubeletServingPolicy(nodeLister corev1listers.NodeLister) CSRApprovalPolicy {
+	return CSRApprovalPolicy{
+		Name: "kubelet-serving",
+		Approver: CSRApproverFunc(func(csrObj *certapiv1.CertificateSigningRequest, x509CSR *x509.CertificateRequest) (CSRApprovalDecision, string, error) {
+			if csrObj.Spec.SignerName != certapiv1.KubeletServingSignerName {
+				return CSRNoOpinion, "", nil
+			}
+			if err := ValidateKubeletServingRequester(csrObj, x509CSR); err != nil {
+				return CSRDenied, err.Error(), nil
+			}
+			if err := ValidateKubeletServingUsages(csrObj); err != nil {
+				return CSRDenied, err.Error(), nil
+			}
+			nodeName, _ := KubeletServingNodeName(csrObj)
+			node, err := nodeLister.Get(nodeName)
+			if apierrors.IsNotFound(err) {
+				return CSRNoOpinion, "", &TransientApprovalError{Err: fmt.Errorf("node %s does not exist yet", nodeName)}
+			}
+			if err != nil {
+				return CSRNoOpinion, "", &TransientApprovalError{Err: err}
+			}
+			if err := ValidateKubeletServingSANs(x509CSR, node); err != nil {
+				return CSRDenied, err.Error(), nil
+			}
+			return CSRApproved, "", nil
+		}),
+	}
+}
@@ -0,0 +1,132 @@
+package csr
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	certapiv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestKubeletServingPolicy(t *testing.T) {
+	nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, nodeIndexer.Add(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-0"},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeHostName, Address: "worker-0"},
+				{Type: corev1.NodeInternalDNS, Address: "worker-0.example.com"},
+				{Type: corev1.NodeInternalIP, Address: "10.0.0.10"},
+			},
+		},
+	}))
+	policy := NewKubeletServingPolicy(corev1listers.NewNodeLister(nodeIndexer))
+
+	validCSR := func() (*certapiv1.CertificateSigningRequest, *x509.CertificateRequest) {
+		return &certapiv1.CertificateSigningRequest{
+			Spec: certapiv1.CertificateSigningRequestSpec{
+				SignerName: certapiv1.KubeletServingSignerName,
+				Username:   "system:node:worker-0",
+				Groups:     []string{"system:nodes", "system:authenticated"},
+				Usages:     []certapiv1.KeyUsage{certapiv1.UsageDigitalSignature, certapiv1.UsageKeyEncipherment, certapiv1.UsageServerAuth},
+			},
+		}, &x509.CertificateRequest{
+			Subject:     pkix.Name{CommonName: "system:node:worker-0", Organization: []string{"system:nodes"}},
+			DNSNames:    []string{"worker-0", "worker-0.example.com"},
+			IPAddresses: []net.IP{net.ParseIP("10.0.0.10")},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		modify          func(*certapiv1.CertificateSigningRequest, *x509.CertificateRequest)
+		expectDecision  CSRApprovalDecision
+		expectReason    string
+		expectTransient bool
+	}{
+		{
+			name:           "valid",
+			modify:         func(*certapiv1.CertificateSigningRequest, *x509.CertificateRequest) {},
+			expectDecision: CSRApproved,
+		},
+		{
+			name: "other signer",
+			modify: func(csrObj *certapiv1.CertificateSigningRequest, _ *x509.CertificateRequest) {
+				csrObj.Spec.SignerName = certapiv1.KubeAPIServerClientSignerName
+			},
+			expectDecision: CSRNoOpinion,
+		},
+		{
+			name: "not a node",
+			modify: func(csrObj *certapiv1.CertificateSigningRequest, _ *x509.CertificateRequest) {
+				csrObj.Spec.Username = "system:serviceaccount:default:default"
+			},
+			expectDecision: CSRDenied,
+			expectReason:   `CSR was not created by a node but by "system:serviceaccount:default:default"`,
+		},
+		{
+			name: "certificate for another node",
+			modify: func(_ *certapiv1.CertificateSigningRequest, x509CSR *x509.CertificateRequest) {
+				x509CSR.Subject.CommonName = "system:node:worker-1"
+			},
+			expectDecision: CSRDenied,
+			expectReason:   `subject common name "system:node:worker-1" does not match the requester "system:node:worker-0"`,
+		},
+		{
+			name: "client usage",
+			modify: func(csrObj *certapiv1.CertificateSigningRequest, _ *x509.CertificateRequest) {
+				csrObj.Spec.Usages = append(csrObj.Spec.Usages, certapiv1.UsageClientAuth)
+			},
+			expectDecision: CSRDenied,
+			expectReason:   "unexpected usages [client auth]",
+		},
+		{
+			name: "foreign IP",
+			modify: func(_ *certapiv1.CertificateSigningRequest, x509CSR *x509.CertificateRequest) {
+				x509CSR.IPAddresses = append(x509CSR.IPAddresses, net.ParseIP("10.0.0.11"))
+			},
+			expectDecision: CSRDenied,
+			expectReason:   "IP address 10.0.0.11 is not an address of node worker-0",
+		},
+		{
+			name: "foreign DNS name",
+			modify: func(_ *certapiv1.CertificateSigningRequest, x509CSR *x509.CertificateRequest) {
+				x509CSR.DNSNames = []string{"api.example.com"}
+			},
+			expectDecision: CSRDenied,
+			expectReason:   `DNS name "api.example.com" is not an address of node worker-0`,
+		},
+		{
+			name: "unknown node",
+			modify: func(csrObj *certapiv1.CertificateSigningRequest, x509CSR *x509.CertificateRequest) {
+				csrObj.Spec.Username = "system:node:worker-9"
+				x509CSR.Subject.CommonName = "system:node:worker-9"
+			},
+			expectDecision:  CSRNoOpinion,
+			expectTransient: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csrObj, x509CSR := validCSR()
+			tt.modify(csrObj, x509CSR)
+			decision, reason, err := policy.Approver.Approve(csrObj, x509CSR)
+			if tt.expectTransient {
+				var transientErr *TransientApprovalError
+				require.ErrorAs(t, err, &transientErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.expectDecision, decision)
+			require.Equal(t, tt.expectReason, reason)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package csr
+
+import (
+	"time"
+
+	k8smetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	namespace = "csr_approval"
+)
+
+// metrics provides access to all CSR approval metrics.
+var metrics *csrApprovalMetrics
+
+func init() {
+	metrics = newCSRApprovalMetrics(legacyregistry.Register)
+}
+
+// csrApprovalMetrics instruments the CSR approval policies with prometheus metrics.
+type csrApprovalMetrics struct {
+	policyVerdicts   *k8smetrics.CounterVec
+	decisions        *k8smetrics.CounterVec
+	oldestPendingAge *k8smetrics.GaugeVec
+}
+
+// newCSRApprovalMetrics creates a new csrApprovalMetrics, configured with default metric names.
+func newCSRApprovalMetrics(registerFunc func(k8smetrics.Registerable) error) *csrApprovalMetrics {
+	policyVerdicts := k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: namespace,
+			Name:      "policy_verdicts_total",
+			Help:      "The number of verdicts of a CSR approval policy, labeled with the policy chain, the policy and the verdict (Approved, Denied, NoOpinion or Error)",
+		}, []string{"chain", "policy", "verdict"})
+	registerFunc(policyVerdicts)
+
+	decisions := k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: namespace,
+			Name:      "decisions_total",
+			Help:      "The number of CSRs handled by a CSR approver, labeled with the controller, the signer name and the decision (Approved, Denied or Ignored)",
+		}, []string{"controller", "signer", "decision"})
+	registerFunc(decisions)
+
+	oldestPendingAge := k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: namespace,
+			Name:      "oldest_pending_age_seconds",
+			Help:      "The age of the oldest pending CSR matching the filter of a CSR approver, 0 when there is none, labeled with the controller",
+		}, []string{"controller"})
+	registerFunc(oldestPendingAge)
+
+	return &csrApprovalMetrics{
+		policyVerdicts:   policyVerdicts,
+		decisions:        decisions,
+		oldestPendingAge: oldestPendingAge,
+	}
+}
+
+// ObserveVerdict counts a verdict of a policy.
+func (m *csrApprovalMetrics) ObserveVerdict(chain, policy, verdict string) {
+	m.policyVerdicts.WithLabelValues(chain, policy, verdict).Inc()
+}
+
+// ObserveDecision counts a decision of a CSR approver. CSRNoOpinion is counted as Ignored.
+func (m *csrApprovalMetrics) ObserveDecision(controller, signer string, decision CSRApprovalDecision) {
+	label := string(decision)
+	if decision == CSRNoOpinion {
+		label = "Ignored"
+	}
+	m.decisions.WithLabelValues(controller, signer, label).Inc()
+}
+
+// ObserveOldestPendingAge records the age of the oldest pending CSR.
+func (m *csrApprovalMetrics) ObserveOldestPendingAge(controller string, age time.Duration) {
+	m.oldestPendingAge.WithLabelValues(controller).Set(age.Seconds())
+}
@@ -0,0 +1,176 @@
+package csr
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	certapiv1 "k8s.io/api/certificates/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// CSRApprovalPolicy is a named CSRApprover in a policy chain. Its CSRNoOpinion decision is an abstention.
+type CSRApprovalPolicy struct {
+	// Name identifies the policy in deny reasons and metrics.
+	Name     string
+	Approver CSRApprover
+}
+
+// PolicyChainApprover is a CSRApprover that evaluates a chain of policies, in order:
+//   - a CSR is denied as soon as one policy denies it, or fails to evaluate it,
+//   - otherwise it is approved when at least one policy approves it,
+//   - otherwise, when all policies abstain, the CSR is left untouched.
+//
+// This makes it safe to compose policies that approve some requesters with constraints that only deny, like
+// NewExpiryPolicy or NewSANPolicy: a constraint can never be bypassed by another policy approving the CSR.
+type PolicyChainApprover struct {
+	chainName string
+	policies  []CSRApprovalPolicy
+}
+
+var _ CSRApprover = &PolicyChainApprover{}
+
+// NewPolicyChainApprover returns a PolicyChainApprover evaluating the policies in order. The verdicts of every
+// policy are counted in the csr_approval_policy_verdicts_total metric, labeled with chainName.
+func NewPolicyChainApprover(chainName string, policies ...CSRApprovalPolicy) *PolicyChainApprover {
+	return &PolicyChainApprover{
+		chainName: chainName,
+		policies:  policies,
+	}
+}
+
+func (c *PolicyChainApprover) Approve(csrObj *certapiv1.CertificateSigningRequest, x509CSR *x509.CertificateRequest) (CSRApprovalDecision, string, error) {
+	if csrObj == nil || x509CSR == nil {
+		return CSRDenied, "Error", fmt.Errorf("received a 'nil' CSR")
+	}
+
+	decision := CSRNoOpinion
+	for _, policy := range c.policies {
+		verdict, denyReason, err := policy.Approver.Approve(csrObj, x509CSR)
+		if err != nil {
+			metrics.ObserveVerdict(c.chainName, policy.Name, "Error")
+			return CSRDenied, "", fmt.Errorf("policy %q: %w", policy.Name, err)
+		}
+		metrics.ObserveVerdict(c.chainName, policy.Name, string(verdict))
+
+		switch verdict {
+		case CSRDenied:
+			return CSRDenied, fmt.Sprintf("denied by policy %q: %s", policy.Name, denyReason), nil
+		case CSRApproved:
+			decision = CSRApproved
+		}
+	}
+	return decision, "", nil
+}
+
+// CSRApproverFunc adapts a function to the CSRApprover interface.
+type CSRApproverFunc func(csrObj *certapiv1.CertificateSigningRequest, x509CSR *x509.CertificateRequest) (CSRApprovalDecision, string, error)
+
+func (f CSRApproverFunc) Approve(csrObj *certapiv1.CertificateSigningRequest, x509CSR *x509.CertificateRequest) (CSRApprovalDecision, string, error) {
+	return f(csrObj, x509CSR)
+}
+
+// NewRequesterPolicy returns a policy that approves CSRs created by one of the usernames, or by a user in one of the
+// groups, and abstains otherwise.
+func NewRequesterPolicy(usernames, groups []string) CSRApprovalPolicy {
+	allowedUsers := sets.NewString(usernames...)
+	allowedGroups := sets.NewString(groups...)
+	return CSRApprovalPolicy{
+		Name: "requester",
+		Approver: CSRApproverFunc(func(csrObj *certapiv1.CertificateSigningRequest, _ *x509.CertificateRequest) (CSRApprovalDecision, string, error) {
+			if allowedUsers.Has(csrObj.Spec.Username) || allowedGroups.HasAny(csrObj.Spec.Groups...) {
+				return CSRApproved, "", nil
+			}
+			return CSRNoOpinion, "", nil
+		}),
+	}
+}
+
+// NewExpiryPolicy returns a policy that denies CSRs requesting a certificate valid for longer than maxDuration, and
+// abstains otherwise. A CSR without an expirationSeconds gets the default duration of its signer, it is denied when
+// requireExpiration is set.
+func NewExpiryPolicy(maxDuration time.Duration, requireExpiration bool) CSRApprovalPolicy {
+	return CSRApprovalPolicy{
+		Name: "expiry",
+		Approver: CSRApproverFunc(func(csrObj *certapiv1.CertificateSigningRequest, _ *x509.CertificateRequest) (CSRApprovalDecision, string, error) {
+			if csrObj.Spec.ExpirationSeconds == nil {
+				if requireExpiration {
+					return CSRDenied, "the CSR must set expirationSeconds", nil
+				}
+				return CSRNoOpinion, "", nil
+			}
+			if requested := time.Duration(*csrObj.Spec.ExpirationSeconds) * time.Second; requested > maxDuration {
+				return CSRDenied, fmt.Sprintf("the requested expiration %s exceeds the maximum of %s", requested, maxDuration), nil
+			}
+			return CSRNoOpinion, "", nil
+		}),
+	}
+}
+
+// SANConstraints are the subject alternative names a CSR may request. Everything else is denied.
+type SANConstraints struct {
+	// DNSNames are the allowed DNS names. A name starting with "*." is a wildcard for a single label,
+	// i.e. "*.example.com" allows "a.example.com" but neither "example.com" nor "a.b.example.com".
+	DNSNames []string
+	// IPNetworks are the networks of the allowed IP addresses.
+	IPNetworks []*net.IPNet
+	// AllowEmailAddresses allows any email address.
+	AllowEmailAddresses bool
+	// AllowURIs allows any URI.
+	AllowURIs bool
+}
+
+// NewSANPolicy returns a policy that denies CSRs requesting subject alternative names not allowed by the constraints,
+// and abstains otherwise.
+func NewSANPolicy(constraints SANConstraints) CSRApprovalPolicy {
+	return CSRApprovalPolicy{
+		Name: "san",
+		Approver: CSRApproverFunc(func(_ *certapiv1.CertificateSigningRequest, x509CSR *x509.CertificateRequest) (CSRApprovalDecision, string, error) {
+			for _, name := range x509CSR.DNSNames {
+				if !constraints.allowsDNSName(name) {
+					return CSRDenied, fmt.Sprintf("DNS name %q is not allowed", name), nil
+				}
+			}
+			for _, ip := range x509CSR.IPAddresses {
+				if !constraints.allowsIP(ip) {
+					return CSRDenied, fmt.Sprintf("IP address %s is not allowed", ip), nil
+				}
+			}
+			if len(x509CSR.EmailAddresses) > 0 && !constraints.AllowEmailAddresses {
+				return CSRDenied, "email addresses are not allowed", nil
+			}
+			if len(x509CSR.URIs) > 0 && !constraints.AllowURIs {
+				return CSRDenied, "URIs are not allowed", nil
+			}
+			return CSRNoOpinion, "", nil
+		}),
+	}
+}
+
+func (c SANConstraints) allowsDNSName(name string) bool {
+	name = strings.ToLower(name)
+	for _, allowed := range c.DNSNames {
+		allowed = strings.ToLower(allowed)
+		if suffix := strings.TrimPrefix(allowed, "*"); suffix != allowed {
+			if strings.HasSuffix(name, suffix) && len(name) > len(suffix) && !strings.Contains(strings.TrimSuffix(name, suffix), ".") {
+				return true
+			}
+			continue
+		}
+		if name == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func (c SANConstraints) allowsIP(ip net.IP) bool {
+	for _, network := range c.IPNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package csr
+
+import (
+	"crypto/x509"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	certapiv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestPolicyChainApprover(t *testing.T) {
+	_, podNetwork, _ := net.ParseCIDR("10.128.0.0/14")
+	chain := NewPolicyChainApprover("test",
+		NewRequesterPolicy([]string{"system:serviceaccount:openshift-test:approved"}, []string{"system:approved-group"}),
+		NewExpiryPolicy(24*time.Hour, false),
+		NewSANPolicy(SANConstraints{
+			DNSNames:   []string{"*.apps.example.com", "api.example.com"},
+			IPNetworks: []*net.IPNet{podNetwork},
+		}),
+	)
+
+	tests := []struct {
+		name              string
+		username          string
+		groups            []string
+		expirationSeconds *int32
+		request           *x509.CertificateRequest
+		expectDecision    CSRApprovalDecision
+		expectReason      string
+	}{
+		{
+			name:           "approved user",
+			username:       "system:serviceaccount:openshift-test:approved",
+			request:        &x509.CertificateRequest{DNSNames: []string{"api.example.com", "console.apps.example.com"}, IPAddresses: []net.IP{net.ParseIP("10.128.0.5")}},
+			expectDecision: CSRApproved,
+		},
+		{
+			name:           "approved group",
+			username:       "someone",
+			groups:         []string{"system:authenticated", "system:approved-group"},
+			request:        &x509.CertificateRequest{},
+			expectDecision: CSRApproved,
+		},
+		{
+			name:           "unknown requester",
+			username:       "someone",
+			groups:         []string{"system:authenticated"},
+			request:        &x509.CertificateRequest{},
+			expectDecision: CSRNoOpinion,
+		},
+		{
+			name:              "expiry too long",
+			username:          "system:serviceaccount:openshift-test:approved",
+			expirationSeconds: pointer.Int32(2 * 24 * 60 * 60),
+			request:           &x509.CertificateRequest{},
+			expectDecision:    CSRDenied,
+			expectReason:      `denied by policy "expiry"`,
+		},
+		{
+			name:           "nested wildcard name",
+			username:       "system:serviceaccount:openshift-test:approved",
+			request:        &x509.CertificateRequest{DNSNames: []string{"a.console.apps.example.com"}},
+			expectDecision: CSRDenied,
+			expectReason:   `DNS name "a.console.apps.example.com" is not allowed`,
+		},
+		{
+			name:           "IP outside of the allowed networks",
+			username:       "system:serviceaccount:openshift-test:approved",
+			request:        &x509.CertificateRequest{IPAddresses: []net.IP{net.ParseIP("192.168.0.1")}},
+			expectDecision: CSRDenied,
+			expectReason:   "IP address 192.168.0.1 is not allowed",
+		},
+		{
+			name:           "URI",
+			username:       "system:serviceaccount:openshift-test:approved",
+			request:        &x509.CertificateRequest{URIs: []*url.URL{{Scheme: "spiffe", Host: "example.com"}}},
+			expectDecision: CSRDenied,
+			expectReason:   "URIs are not allowed",
+		},
+		{
+			name:           "constraints deny unknown requesters too",
+			username:       "someone",
+			request:        &x509.CertificateRequest{EmailAddresses: []string{"someone@example.com"}},
+			expectDecision: CSRDenied,
+			expectReason:   "email addresses are not allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csrObj := &certapiv1.CertificateSigningRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-csr"},
+				Spec: certapiv1.CertificateSigningRequestSpec{
+					Username:          tt.username,
+					Groups:            tt.groups,
+					ExpirationSeconds: tt.expirationSeconds,
+				},
+			}
+			decision, reason, err := chain.Approve(csrObj, tt.request)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if decision != tt.expectDecision {
+				t.Errorf("expected decision %s, got %s (%s)", tt.expectDecision, decision, reason)
+			}
+			if !strings.Contains(reason, tt.expectReason) {
+				t.Errorf("expected %q in the deny reason %q", tt.expectReason, reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package dependencyrestartcontroller
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/deploymentcontroller"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcehash"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+const (
+	// DependenciesHashAnnotation is set on the pod template of the target Deployments to the hash of the content of their
+	// dependencies, so that a change of a dependency rolls them out.
+	DependenciesHashAnnotation = "operator.openshift.io/dependencies-hash"
+	// RestartedAtAnnotation is set on the target Deployments to the time they were last restarted for their dependencies,
+	// to cap their restart rate across restarts of the operator.
+	RestartedAtAnnotation = "operator.openshift.io/dependencies-restarted-at"
+)
+
+// DeploymentReference references a target Deployment.
+type DeploymentReference struct {
+	Namespace string
+	Name      string
+}
+
+// DependencyRestartController decides when the target Deployments are rolled out for a change of the content of their
+// dependencies, secrets and config maps, e.g. when the certificates they mount are rotated. A new hash of the content
+// of the dependencies is released to a Deployment:
+//   - once the content has not changed for the debounce duration, so that the dependencies updated one after the
+//     other, like a rotated certificate and its CA bundle, cause a single rollout;
+//   - and at most once per minRestartInterval for each Deployment.
+//
+// The controller does not write the Deployments: the operator applying them sets the released hash in the
+// DependenciesHashAnnotation of their pod template with the hook returned by NewDependencyRestartController, so that
+// the rollouts do not fight the apply. The Deployments without the annotation are rolled out once to set it.
+type DependencyRestartController struct {
+	dependencies       []*resourcehash.ObjectReference
+	targets            []DeploymentReference
+	debounce           time.Duration
+	minRestartInterval time.Duration
+
+	configMapLister   corev1listers.ConfigMapLister
+	secretLister      corev1listers.SecretLister
+	deploymentListers map[string]appsv1listers.DeploymentLister
+
+	clock clock.PassiveClock
+
+	lock sync.Mutex
+	// pendingHash is the last hash of the dependencies observed, since pendingSince.
+	pendingHash  string
+	pendingSince time.Time
+	// released are the hashes released to the targets, initialized from the applied Deployments.
+	released map[DeploymentReference]releasedHash
+}
+
+// releasedHash is a hash released to a target, and when it was released.
+type releasedHash struct {
+	hash string
+	at   time.Time
+}
+
+// DependencyRestartControllerOption configures the DependencyRestartController.
+type DependencyRestartControllerOption func(c *DependencyRestartController)
+
+// WithClock sets the clock the controller reads the time from, e.g. a fake clock in unit tests.
+func WithClock(clock clock.PassiveClock) DependencyRestartControllerOption {
+	return func(c *DependencyRestartController) {
+		c.clock = clock
+	}
+}
+
+// NewDependencyRestartController returns a controller deciding when the target Deployments are rolled out for the
+// config maps and the secrets they depend on, and the hook with which the operator applying the Deployments sets the
+// hash of their dependencies, e.g. with the optional Deployment hooks of the deploymentcontroller. The released hashes
+// are picked up by the next sync of the operator, which must resync periodically.
+// kubeInformersForNamespaces must have informers for the namespaces of the dependencies and of the targets.
+func NewDependencyRestartController(
+	name string,
+	dependencies []*resourcehash.ObjectReference,
+	targets []DeploymentReference,
+	debounce, minRestartInterval time.Duration,
+	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces,
+	eventRecorder events.Recorder,
+	options ...DependencyRestartControllerOption,
+) (factory.Controller, deploymentcontroller.DeploymentHookFunc) {
+	c := &DependencyRestartController{
+		dependencies:       dependencies,
+		targets:            targets,
+		debounce:           debounce,
+		minRestartInterval: minRestartInterval,
+		configMapLister:    kubeInformersForNamespaces.ConfigMapLister(),
+		secretLister:       kubeInformersForNamespaces.SecretLister(),
+		deploymentListers:  map[string]appsv1listers.DeploymentLister{},
+		released:           map[DeploymentReference]releasedHash{},
+		clock:              clock.RealClock{},
+	}
+	for _, option := range options {
+		option(c)
+	}
+
+	var informers []factory.Informer
+	dependencyNamespaces := sets.NewString()
+	for _, dependency := range dependencies {
+		dependencyNamespaces.Insert(dependency.Namespace)
+	}
+	for _, namespace := range dependencyNamespaces.List() {
+		informers = append(informers,
+			kubeInformersForNamespaces.InformersFor(namespace).Core().V1().ConfigMaps().Informer(),
+			kubeInformersForNamespaces.InformersFor(namespace).Core().V1().Secrets().Informer(),
+		)
+	}
+	for _, target := range targets {
+		if _, ok := c.deploymentListers[target.Namespace]; ok {
+			continue
+		}
+		deploymentInformer := kubeInformersForNamespaces.InformersFor(target.Namespace).Apps().V1().Deployments()
+		c.deploymentListers[target.Namespace] = deploymentInformer.Lister()
+		informers = append(informers, deploymentInformer.Informer())
+	}
+
+	controller := factory.New().WithInformers(informers...).WithSync(c.sync).ToController(
+		name+"DependencyRestartController",
+		eventRecorder.WithComponentSuffix("dependency-restart-controller"),
+	)
+	return controller, c.deploymentHook
+}
+
+func (c *DependencyRestartController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	hashes, err := resourcehash.MultipleObjectHashStringMapForObjectReferenceFromLister(c.configMapLister, c.secretLister, c.dependencies...)
+	if err != nil {
+		return err
+	}
+	hash, err := dependenciesHash(hashes)
+	if err != nil {
+		return err
+	}
+
+	now := c.clock.Now()
+	if wait := c.settle(hash, now); wait > 0 {
+		syncCtx.Queue().AddAfter(syncCtx.QueueKey(), wait)
+		return nil
+	}
+
+	var errs []error
+	for _, target := range c.targets {
+		released, found, err := c.releasedTo(target)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !found || released.hash == hash {
+			continue
+		}
+
+		if wait := released.at.Add(c.minRestartInterval).Sub(now); wait > 0 {
+			klog.V(2).Infof("Delaying the restart of deployment/%s -n %s for its dependencies by %v", target.Name, target.Namespace, wait)
+			syncCtx.Queue().AddAfter(syncCtx.QueueKey(), wait)
+			continue
+		}
+
+		c.release(target, releasedHash{hash: hash, at: now})
+		syncCtx.Recorder().Eventf("DeploymentRestarted", "Restarting deployment/%s -n %s because its dependencies changed", target.Name, target.Namespace)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// settle returns how long to wait for the hash to be stable for the debounce duration.
+func (c *DependencyRestartController) settle(hash string, now time.Time) time.Duration {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if hash != c.pendingHash {
+		c.pendingHash = hash
+		c.pendingSince = now
+	}
+	return c.pendingSince.Add(c.debounce).Sub(now)
+}
+
+// releasedTo returns the hash released to the target. Until the controller releases one, it is the hash applied to the
+// Deployment, so that the restarts of the operator do not roll it out. It is not found when the Deployment does not
+// exist yet.
+func (c *DependencyRestartController) releasedTo(target DeploymentReference) (releasedHash, bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if released, ok := c.released[target]; ok {
+		return released, true, nil
+	}
+
+	deployment, err := c.deploymentListers[target.Namespace].Deployments(target.Namespace).Get(target.Name)
+	if apierrors.IsNotFound(err) {
+		return releasedHash{}, false, nil
+	}
+	if err != nil {
+		return releasedHash{}, false, err
+	}
+	released := releasedHash{hash: deployment.Spec.Template.Annotations[DependenciesHashAnnotation]}
+	if restartedAt, err := time.Parse(time.RFC3339, deployment.Annotations[RestartedAtAnnotation]); err == nil {
+		released.at = restartedAt
+	}
+	c.released[target] = released
+	return released, true, nil
+}
+
+func (c *DependencyRestartController) release(target DeploymentReference, released releasedHash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.released[target] = released
+}
+
+// deploymentHook sets the hash released to the Deployment in its pod template, and the time it was released. The
+// Deployments which are not targets are left untouched.
+func (c *DependencyRestartController) deploymentHook(_ *operatorv1.OperatorSpec, deployment *appsv1.Deployment) error {
+	target := DeploymentReference{Namespace: deployment.Namespace, Name: deployment.Name}
+	if !c.isTarget(target) {
+		return nil
+	}
+	released, found, err := c.releasedTo(target)
+	if err != nil {
+		return err
+	}
+	if !found || len(released.hash) == 0 {
+		return nil
+	}
+
+	if deployment.Spec.Template.Annotations == nil {
+		deployment.Spec.Template.Annotations = map[string]string{}
+	}
+	deployment.Spec.Template.Annotations[DependenciesHashAnnotation] = released.hash
+	if !released.at.IsZero() {
+		if deployment.Annotations == nil {
+			deployment.Annotations = map[string]string{}
+		}
+		deployment.Annotations[RestartedAtAnnotation] = released.at.UTC().Format(time.RFC3339)
+	}
+	return nil
+}
+
+func (c *DependencyRestartController) isTarget(reference DeploymentReference) bool {
+	for _, target := range c.targets {
+		if target == reference {
+			return true
+		}
+	}
+	return false
+}
+
+// dependenciesHash returns a hash of the hashes of the dependencies.
+func dependenciesHash(hashes map[string]string) (string, error) {
+	hasher := fnv.New32()
+	// the keys of the maps are sorted by the encoder
+	if err := json.NewEncoder(hasher).Encode(hashes); err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(hasher.Sum(nil)), nil
+}
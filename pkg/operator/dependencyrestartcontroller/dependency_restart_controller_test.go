@@ -0,0 +1,172 @@
+package dependencyrestartcontroller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcehash"
+)
+
+func TestDependencyRestartController(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "serving-cert"},
+		Data:       map[string][]byte{"tls.crt": []byte("cert-1")},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "apiserver"},
+	}
+
+	newIndexer := func() cache.Indexer {
+		return cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	}
+	secretIndexer, deploymentIndexer := newIndexer(), newIndexer()
+	if err := secretIndexer.Add(secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := deploymentIndexer.Add(deployment); err != nil {
+		t.Fatal(err)
+	}
+
+	fakeClock := clocktesting.NewFakeClock(time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC))
+	c := &DependencyRestartController{
+		dependencies:       []*resourcehash.ObjectReference{resourcehash.NewObjectRef().ForSecret().InNamespace("ns").Named("serving-cert")},
+		targets:            []DeploymentReference{{Namespace: "ns", Name: "apiserver"}},
+		debounce:           time.Minute,
+		minRestartInterval: time.Hour,
+		configMapLister:    corev1listers.NewConfigMapLister(newIndexer()),
+		secretLister:       corev1listers.NewSecretLister(secretIndexer),
+		deploymentListers:  map[string]appsv1listers.DeploymentLister{"ns": appsv1listers.NewDeploymentLister(deploymentIndexer)},
+		released:           map[DeploymentReference]releasedHash{},
+		clock:              fakeClock,
+	}
+
+	recorder := events.NewInMemoryRecorder("test")
+	// apply simulates the operator applying the deployment with the hook
+	apply := func() *appsv1.Deployment {
+		t.Helper()
+		required := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "apiserver"}}
+		if err := c.deploymentHook(&operatorv1.OperatorSpec{}, required); err != nil {
+			t.Fatal(err)
+		}
+		if err := deploymentIndexer.Update(required); err != nil {
+			t.Fatal(err)
+		}
+		return required
+	}
+	sync := func() {
+		t.Helper()
+		syncCtx := factory.NewSyncContext("test", recorder)
+		if err := c.sync(context.TODO(), syncCtx); err != nil {
+			t.Fatal(err)
+		}
+		apply()
+	}
+	restarts := func() int {
+		count := 0
+		for _, event := range recorder.Events() {
+			if event.Reason == "DeploymentRestarted" {
+				count++
+			}
+		}
+		return count
+	}
+	templateHash := func() string {
+		obj, _, err := deploymentIndexer.GetByKey("ns/apiserver")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return obj.(*appsv1.Deployment).Spec.Template.Annotations[DependenciesHashAnnotation]
+	}
+
+	// the dependencies must settle before a restart
+	sync()
+	if restarts() != 0 {
+		t.Fatalf("expected no restart before the debounce, got %v", recorder.Events())
+	}
+	fakeClock.Step(time.Minute)
+	sync()
+	if restarts() != 1 {
+		t.Fatalf("expected a restart once the dependencies settled, got %v", recorder.Events())
+	}
+	firstHash := templateHash()
+	if len(firstHash) == 0 {
+		t.Fatal("expected the hash of the dependencies in the pod template")
+	}
+
+	// no restart without a change
+	fakeClock.Step(2 * time.Hour)
+	sync()
+	if restarts() != 1 {
+		t.Fatalf("expected no restart without a change, got %v", recorder.Events())
+	}
+
+	// a change is rolled out after the debounce
+	rotated := secret.DeepCopy()
+	rotated.Data["tls.crt"] = []byte("cert-2")
+	if err := secretIndexer.Update(rotated); err != nil {
+		t.Fatal(err)
+	}
+	sync()
+	fakeClock.Step(time.Minute)
+	sync()
+	if restarts() != 2 || templateHash() == firstHash {
+		t.Fatalf("expected a restart for the changed dependency, got %v", recorder.Events())
+	}
+
+	// the restart rate is capped
+	rotated = rotated.DeepCopy()
+	rotated.Data["tls.crt"] = []byte("cert-3")
+	if err := secretIndexer.Update(rotated); err != nil {
+		t.Fatal(err)
+	}
+	sync()
+	fakeClock.Step(time.Minute)
+	sync()
+	if restarts() != 2 {
+		t.Fatalf("expected the restart to be delayed by the restart interval, got %v", recorder.Events())
+	}
+	fakeClock.Step(time.Hour)
+	sync()
+	if restarts() != 3 {
+		t.Fatalf("expected a restart after the restart interval, got %v", recorder.Events())
+	}
+
+	if restartedAt := apply().Annotations[RestartedAtAnnotation]; restartedAt != fakeClock.Now().Format(time.RFC3339) {
+		t.Errorf("expected the deployment to record the restart time, got %q", restartedAt)
+	}
+
+	// the released hash survives a restart of the operator
+	restarted := &DependencyRestartController{
+		targets:           c.targets,
+		deploymentListers: c.deploymentListers,
+		released:          map[DeploymentReference]releasedHash{},
+	}
+	required := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "apiserver"}}
+	if err := restarted.deploymentHook(&operatorv1.OperatorSpec{}, required); err != nil {
+		t.Fatal(err)
+	}
+	if required.Spec.Template.Annotations[DependenciesHashAnnotation] != templateHash() {
+		t.Errorf("expected the applied hash to be kept after a restart, got %v", required.Spec.Template.Annotations)
+	}
+
+	// the other deployments are left untouched
+	other := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "other"}}
+	if err := c.deploymentHook(&operatorv1.OperatorSpec{}, other); err != nil {
+		t.Fatal(err)
+	}
+	if len(other.Annotations) != 0 || len(other.Spec.Template.Annotations) != 0 {
+		t.Errorf("expected the other deployments to be left untouched, got %v", other)
+	}
+}
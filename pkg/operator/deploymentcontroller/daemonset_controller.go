@@ -0,0 +1,336 @@
+package deploymentcontroller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appsinformersv1 "k8s.io/client-go/informers/apps/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	opv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+	"github.com/openshift/library-go/pkg/operator/status"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// DaemonSetHookFunc is a hook function to modify the DaemonSet.
+type DaemonSetHookFunc func(*opv1.OperatorSpec, *appsv1.DaemonSet) error
+
+// DaemonSetController is a generic controller that manages a DaemonSet, the way DeploymentController manages a Deployment.
+//
+// This controller supports removable operands, as configured in pkg/operator/management.
+//
+// This controller produces the following conditions:
+// <name>Available: indicates that the DaemonSet was successfully deployed and at least one DaemonSet pod is available.
+// <name>Progressing: indicates that the DaemonSet is in progress.
+// <name>Degraded: produced when the sync() method returns an error.
+//
+// With WithDaemonSetOperandVersions, it reports the versions of the operands once the DaemonSet is rolled out and ready
+// on all nodes.
+type DaemonSetController struct {
+	name           string
+	manifest       []byte
+	operatorClient v1helpers.OperatorClientWithFinalizers
+	kubeClient     kubernetes.Interface
+	dsInformer     appsinformersv1.DaemonSetInformer
+	// Optional hook functions to modify the DaemonSet manifest.
+	// If one of these functions returns an error, the sync
+	// fails indicating the ordinal position of the failed function.
+	// Also, in that scenario the Degraded status is set to True.
+	optionalManifestHooks []ManifestHookFunc
+	// Optional hook functions to modify the DaemonSet.
+	// If one of these functions returns an error, the sync
+	// fails indicating the ordinal position of the failed function.
+	// Also, in that scenario the Degraded status is set to True.
+	optionalDaemonSetHooks []DaemonSetHookFunc
+	// Optional version recorder and the versions of the operands it reports.
+	versionRecorder status.VersionGetter
+	operandVersions []OperandVersion
+}
+
+func NewDaemonSetController(
+	name string,
+	manifest []byte,
+	recorder events.Recorder,
+	operatorClient v1helpers.OperatorClientWithFinalizers,
+	kubeClient kubernetes.Interface,
+	dsInformer appsinformersv1.DaemonSetInformer,
+	optionalInformers []factory.Informer,
+	optionalManifestHooks []ManifestHookFunc,
+	optionalDaemonSetHooks ...DaemonSetHookFunc,
+) factory.Controller {
+	return NewDaemonSetControllerWithOptions(
+		name,
+		manifest,
+		recorder,
+		operatorClient,
+		kubeClient,
+		dsInformer,
+		optionalInformers,
+		optionalManifestHooks,
+		optionalDaemonSetHooks,
+	)
+}
+
+// NewDaemonSetControllerWithVersion returns a DaemonSetController like NewDaemonSetController that reports targetVersion
+// as the version of the operand to versionRecorder once the DaemonSet is rolled out.
+func NewDaemonSetControllerWithVersion(
+	name string,
+	manifest []byte,
+	recorder events.Recorder,
+	operatorClient v1helpers.OperatorClientWithFinalizers,
+	kubeClient kubernetes.Interface,
+	dsInformer appsinformersv1.DaemonSetInformer,
+	optionalInformers []factory.Informer,
+	optionalManifestHooks []ManifestHookFunc,
+	versionRecorder status.VersionGetter,
+	targetVersion string,
+	optionalDaemonSetHooks ...DaemonSetHookFunc,
+) factory.Controller {
+	return NewDaemonSetControllerWithOptions(
+		name,
+		manifest,
+		recorder,
+		operatorClient,
+		kubeClient,
+		dsInformer,
+		optionalInformers,
+		optionalManifestHooks,
+		optionalDaemonSetHooks,
+		WithDaemonSetOperandVersions(versionRecorder, OperandVersion{Version: targetVersion}),
+	)
+}
+
+// DaemonSetControllerOption configures an optional feature of the DaemonSetController.
+type DaemonSetControllerOption func(c *DaemonSetController, informers []factory.Informer) []factory.Informer
+
+// WithDaemonSetOperandVersions makes the DaemonSetController report the versions to versionRecorder once the DaemonSet
+// is rolled out and ready on all nodes.
+func WithDaemonSetOperandVersions(versionRecorder status.VersionGetter, versions ...OperandVersion) DaemonSetControllerOption {
+	return func(c *DaemonSetController, informers []factory.Informer) []factory.Informer {
+		c.versionRecorder = versionRecorder
+		c.operandVersions = versions
+		return informers
+	}
+}
+
+// NewDaemonSetControllerWithOptions returns a DaemonSetController like NewDaemonSetController with the optional
+// features configured by options, e.g. WithDaemonSetOperandVersions.
+func NewDaemonSetControllerWithOptions(
+	name string,
+	manifest []byte,
+	recorder events.Recorder,
+	operatorClient v1helpers.OperatorClientWithFinalizers,
+	kubeClient kubernetes.Interface,
+	dsInformer appsinformersv1.DaemonSetInformer,
+	optionalInformers []factory.Informer,
+	optionalManifestHooks []ManifestHookFunc,
+	optionalDaemonSetHooks []DaemonSetHookFunc,
+	options ...DaemonSetControllerOption,
+) factory.Controller {
+	c := &DaemonSetController{
+		name:                   name,
+		manifest:               manifest,
+		operatorClient:         operatorClient,
+		kubeClient:             kubeClient,
+		dsInformer:             dsInformer,
+		optionalManifestHooks:  optionalManifestHooks,
+		optionalDaemonSetHooks: optionalDaemonSetHooks,
+	}
+
+	informers := append(
+		optionalInformers,
+		operatorClient.Informer(),
+		dsInformer.Informer(),
+	)
+	for _, option := range options {
+		informers = option(c, informers)
+	}
+
+	return factory.New().WithInformers(
+		informers...,
+	).WithSync(
+		c.sync,
+	).ResyncEvery(
+		time.Minute,
+	).WithSyncDegradedOnError(
+		operatorClient,
+	).ToController(
+		c.name,
+		recorder.WithComponentSuffix(strings.ToLower(name)+"-daemonset-controller-"),
+	)
+}
+
+func (c *DaemonSetController) Name() string {
+	return c.name
+}
+
+func (c *DaemonSetController) sync(ctx context.Context, syncContext factory.SyncContext) error {
+	opSpec, opStatus, _, err := c.operatorClient.GetOperatorState()
+	if apierrors.IsNotFound(err) && management.IsOperatorRemovable() {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if opSpec.ManagementState != opv1.Managed {
+		return nil
+	}
+
+	meta, err := c.operatorClient.GetObjectMeta()
+	if err != nil {
+		return err
+	}
+	if management.IsOperatorRemovable() && meta.DeletionTimestamp != nil {
+		return c.syncDeleting(ctx, opSpec)
+	}
+	return c.syncManaged(ctx, opSpec, opStatus, syncContext)
+}
+
+func (c *DaemonSetController) syncManaged(ctx context.Context, opSpec *opv1.OperatorSpec, opStatus *opv1.OperatorStatus, syncContext factory.SyncContext) error {
+	klog.V(4).Infof("syncManaged")
+
+	if management.IsOperatorRemovable() {
+		if err := v1helpers.EnsureFinalizer(ctx, c.operatorClient, c.name); err != nil {
+			return err
+		}
+	}
+	required, err := c.getDaemonSet(opSpec)
+	if err != nil {
+		return err
+	}
+
+	daemonSet, _, err := resourceapply.ApplyDaemonSet(
+		ctx,
+		c.kubeClient.AppsV1(),
+		syncContext.Recorder(),
+		required,
+		resourcemerge.ExpectedDaemonSetGeneration(required, opStatus.Generations),
+	)
+	if err != nil {
+		return err
+	}
+
+	availableCondition := opv1.OperatorCondition{
+		Type:   c.name + opv1.OperatorStatusTypeAvailable,
+		Status: opv1.ConditionTrue,
+	}
+
+	if daemonSet.Status.NumberAvailable > 0 {
+		availableCondition.Status = opv1.ConditionTrue
+	} else {
+		availableCondition.Status = opv1.ConditionFalse
+		availableCondition.Message = "Waiting for DaemonSet"
+		availableCondition.Reason = "Deploying"
+	}
+
+	progressingCondition := opv1.OperatorCondition{
+		Type:   c.name + opv1.OperatorStatusTypeProgressing,
+		Status: opv1.ConditionFalse,
+	}
+
+	if ok, msg := isDaemonSetProgressing(daemonSet); ok {
+		progressingCondition.Status = opv1.ConditionTrue
+		progressingCondition.Message = msg
+		progressingCondition.Reason = "Deploying"
+	}
+
+	updateStatusFn := func(newStatus *opv1.OperatorStatus) error {
+		resourcemerge.SetDaemonSetGeneration(&newStatus.Generations, daemonSet)
+		return nil
+	}
+
+	_, _, err = v1helpers.UpdateStatus(
+		ctx,
+		c.operatorClient,
+		updateStatusFn,
+		v1helpers.UpdateConditionFn(availableCondition),
+		v1helpers.UpdateConditionFn(progressingCondition),
+	)
+	if err != nil {
+		return err
+	}
+
+	return c.reportOperandVersions(daemonSet)
+}
+
+// reportOperandVersions validates the operand versions of the DaemonSet and reports them when it rolled out.
+func (c *DaemonSetController) reportOperandVersions(daemonSet *appsv1.DaemonSet) error {
+	if c.versionRecorder == nil {
+		return nil
+	}
+	versions, err := resolveOperandVersions(c.operandVersions, daemonSet.Name, &daemonSet.Spec.Template)
+	if err != nil {
+		return err
+	}
+	if !isDaemonSetConverged(daemonSet) {
+		return nil
+	}
+	for operand, version := range versions {
+		c.versionRecorder.SetVersion(operand, version)
+	}
+	return nil
+}
+
+func (c *DaemonSetController) syncDeleting(ctx context.Context, opSpec *opv1.OperatorSpec) error {
+	klog.V(4).Infof("syncDeleting")
+	required, err := c.getDaemonSet(opSpec)
+	if err != nil {
+		return err
+	}
+
+	err = c.kubeClient.AppsV1().DaemonSets(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	} else {
+		klog.V(2).Infof("Deleted DaemonSet %s/%s", required.Namespace, required.Name)
+	}
+
+	// All removed, remove the finalizer as the last step
+	return v1helpers.RemoveFinalizer(ctx, c.operatorClient, c.name)
+}
+
+func (c *DaemonSetController) getDaemonSet(opSpec *opv1.OperatorSpec) (*appsv1.DaemonSet, error) {
+	manifest := c.manifest
+	for i := range c.optionalManifestHooks {
+		var err error
+		manifest, err = c.optionalManifestHooks[i](opSpec, manifest)
+		if err != nil {
+			return nil, fmt.Errorf("error running hook function (index=%d): %w", i, err)
+		}
+	}
+
+	required := resourceread.ReadDaemonSetV1OrDie(manifest)
+
+	for i := range c.optionalDaemonSetHooks {
+		err := c.optionalDaemonSetHooks[i](opSpec, required)
+		if err != nil {
+			return nil, fmt.Errorf("error running hook function (index=%d): %w", i, err)
+		}
+	}
+	return required, nil
+}
+
+func isDaemonSetProgressing(daemonSet *appsv1.DaemonSet) (bool, string) {
+	switch {
+	case daemonSet.Generation != daemonSet.Status.ObservedGeneration:
+		return true, "Waiting for DaemonSet to act on changes"
+	case daemonSet.Status.NumberUnavailable > 0:
+		return true, "Waiting for DaemonSet to deploy pods"
+	case daemonSet.Status.UpdatedNumberScheduled < daemonSet.Status.DesiredNumberScheduled:
+		return true, "Waiting for DaemonSet to update pods"
+	}
+	return false, ""
+}
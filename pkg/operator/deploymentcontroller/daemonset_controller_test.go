@@ -0,0 +1,175 @@
+package deploymentcontroller
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	opv1 "github.com/openshift/api/operator/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	coreinformers "k8s.io/client-go/informers"
+	fakecore "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/status"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+const daemonSetName = "dummy-daemonset"
+
+func makeFakeDaemonSetManifest() []byte {
+	return []byte(`
+kind: DaemonSet
+apiVersion: apps/v1
+metadata:
+  name: dummy-daemonset
+  namespace: openshift-dummy-test-deployment
+spec:
+  selector:
+    matchLabels:
+      app: dummy
+  template:
+    metadata:
+      labels:
+        app: dummy
+    spec:
+      containers:
+        - name: dummy
+          image: ${IMAGE}
+`)
+}
+
+func TestDaemonSetSync(t *testing.T) {
+	testCases := []struct {
+		name              string
+		daemonSetStatus   *appsv1.DaemonSetStatus
+		expectAvailable   opv1.ConditionStatus
+		expectProgressing opv1.ConditionStatus
+		expectVersion     bool
+	}{
+		{
+			name:              "created",
+			expectAvailable:   opv1.ConditionFalse,
+			expectProgressing: opv1.ConditionTrue,
+		},
+		{
+			name:              "rolling out",
+			daemonSetStatus:   &appsv1.DaemonSetStatus{ObservedGeneration: 1, DesiredNumberScheduled: 3, NumberAvailable: 2, NumberUnavailable: 1, UpdatedNumberScheduled: 2},
+			expectAvailable:   opv1.ConditionTrue,
+			expectProgressing: opv1.ConditionTrue,
+		},
+		{
+			name:              "rolled out",
+			daemonSetStatus:   &appsv1.DaemonSetStatus{ObservedGeneration: 1, DesiredNumberScheduled: 3, CurrentNumberScheduled: 3, NumberReady: 3, NumberAvailable: 3, UpdatedNumberScheduled: 3},
+			expectAvailable:   opv1.ConditionTrue,
+			expectProgressing: opv1.ConditionFalse,
+			expectVersion:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			management.SetOperatorNotRemovable()
+			var initialObjects []runtime.Object
+			var generations []int64
+			if tc.daemonSetStatus != nil {
+				// the DaemonSet as it was created by a previous sync
+				existing := makeExistingDaemonSet(t)
+				existing.Generation = 1
+				existing.Status = *tc.daemonSetStatus
+				resourceapply.SetSpecHashAnnotation(&existing.ObjectMeta, existing.Spec)
+				initialObjects = append(initialObjects, existing)
+				generations = append(generations, 1)
+			}
+			coreClient := fakecore.NewSimpleClientset(initialObjects...)
+			addDaemonSetGenerationReactor(coreClient)
+			coreInformerFactory := coreinformers.NewSharedInformerFactory(coreClient, 0 /*no resync */)
+
+			instance := makeFakeOperatorInstance()
+			for _, generation := range generations {
+				instance.Status.Generations = append(instance.Status.Generations, opv1.GenerationStatus{
+					Group:          appsv1.GroupName,
+					Resource:       "daemonsets",
+					Namespace:      operandNamespace,
+					Name:           daemonSetName,
+					LastGeneration: generation,
+				})
+			}
+			fakeOperatorClient := v1helpers.NewFakeOperatorClientWithObjectMeta(&instance.ObjectMeta, &instance.Spec, &instance.Status, nil /*triggerErr func*/)
+			versionRecorder := status.NewVersionGetter()
+
+			controller := NewDaemonSetControllerWithVersion(
+				controllerName,
+				makeFakeDaemonSetManifest(),
+				events.NewInMemoryRecorder(operandName),
+				fakeOperatorClient,
+				coreClient,
+				coreInformerFactory.Apps().V1().DaemonSets(),
+				nil,
+				[]ManifestHookFunc{withImage},
+				versionRecorder,
+				"4.15.0",
+			)
+
+			if err := controller.Sync(context.TODO(), factory.NewSyncContext(controllerName, events.NewInMemoryRecorder(operandName))); err != nil {
+				t.Fatalf("sync() returned unexpected error: %v", err)
+			}
+
+			daemonSet, err := coreClient.AppsV1().DaemonSets(operandNamespace).Get(context.TODO(), daemonSetName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Failed to get DaemonSet %s: %v", daemonSetName, err)
+			}
+			if image := daemonSet.Spec.Template.Spec.Containers[0].Image; image != "quay.io/dummy:v2" {
+				t.Errorf("expected the manifest hook to set the image, got %q", image)
+			}
+
+			_, opStatus, _, _ := fakeOperatorClient.GetOperatorState()
+			if cond := v1helpers.FindOperatorCondition(opStatus.Conditions, conditionAvailable); cond == nil || cond.Status != tc.expectAvailable {
+				t.Errorf("expected %s %s, got %v", conditionAvailable, tc.expectAvailable, cond)
+			}
+			if cond := v1helpers.FindOperatorCondition(opStatus.Conditions, conditionProgressing); cond == nil || cond.Status != tc.expectProgressing {
+				t.Errorf("expected %s %s, got %v", conditionProgressing, tc.expectProgressing, cond)
+			}
+			if _, found := versionRecorder.GetVersions()[daemonSetName]; found != tc.expectVersion {
+				t.Errorf("expected version reported %v, got %v", tc.expectVersion, versionRecorder.GetVersions())
+			}
+		})
+	}
+}
+
+func withImage(_ *opv1.OperatorSpec, manifest []byte) ([]byte, error) {
+	return bytes.ReplaceAll(manifest, []byte("${IMAGE}"), []byte("quay.io/dummy:v2")), nil
+}
+
+// makeExistingDaemonSet returns the DaemonSet as created by a previous sync.
+func makeExistingDaemonSet(t *testing.T) *appsv1.DaemonSet {
+	c := &DaemonSetController{manifest: makeFakeDaemonSetManifest(), optionalManifestHooks: []ManifestHookFunc{withImage}}
+	ds, err := c.getDaemonSet(&opv1.OperatorSpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ds
+}
+
+// addDaemonSetGenerationReactor bumps the DaemonSet generation when it gets created or updated.
+func addDaemonSetGenerationReactor(client *fakecore.Clientset) {
+	client.PrependReactor("*", "daemonsets", func(action core.Action) (handled bool, ret runtime.Object, err error) {
+		switch a := action.(type) {
+		case core.CreateActionImpl:
+			daemonSet := a.GetObject().(*appsv1.DaemonSet)
+			daemonSet.Generation++
+			return false, daemonSet, nil
+		case core.UpdateActionImpl:
+			daemonSet := a.GetObject().(*appsv1.DaemonSet)
+			daemonSet.Generation++
+			return false, daemonSet, nil
+		}
+		return false, nil, nil
+	})
+}
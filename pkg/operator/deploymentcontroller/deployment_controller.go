@@ -10,6 +10,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	appsinformersv1 "k8s.io/client-go/informers/apps/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
@@ -20,6 +21,7 @@ import (
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
 	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+	"github.com/openshift/library-go/pkg/operator/status"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 )
 
@@ -30,6 +32,15 @@ type DeploymentHookFunc func(*opv1.OperatorSpec, *appsv1.Deployment) error
 // The hook must not modify the original manifest!
 type ManifestHookFunc func(*opv1.OperatorSpec, []byte) ([]byte, error)
 
+// PreconditionFunc is checked before the Deployment is rolled out. When it returns false, the Deployment is left as it
+// is and the message is reported in the Progressing condition, e.g. while a config migration is still running.
+type PreconditionFunc func(ctx context.Context, opSpec *opv1.OperatorSpec) (bool, string, error)
+
+// PostRolloutHookFunc is called once per generation of the Deployment, after the Deployment observed the generation,
+// finished its rollout and is available, e.g. to clean up after a config migration. It is retried on the next sync
+// when it returns an error. The hooks must be idempotent: they are called again when the operator restarts.
+type PostRolloutHookFunc func(ctx context.Context, opSpec *opv1.OperatorSpec, deployment *appsv1.Deployment) error
+
 // DeploymentController is a generic controller that manages a deployment.
 //
 // This controller supports removable operands, as configured in pkg/operator/management.
@@ -38,6 +49,8 @@ type ManifestHookFunc func(*opv1.OperatorSpec, []byte) ([]byte, error)
 // <name>Available: indicates that the deployment controller  was successfully deployed and at least one Deployment replica is available.
 // <name>Progressing: indicates that the Deployment is in progress.
 // <name>Degraded: produced when the sync() method returns an error.
+// <name>PodDisruptionBudgetBlockingDrain: produced only WithPodDisruptionBudget, see there.
+// <name>RolloutDegraded: produced only WithProgressDeadline, see there.
 type DeploymentController struct {
 	name           string
 	manifest       []byte
@@ -57,6 +70,39 @@ type DeploymentController struct {
 	// fails indicating the ordinal position of the failed function.
 	// Also, in that scenario the Degraded status is set to True.
 	optionalDeploymentHooks []DeploymentHookFunc
+	// Optional functions that must all be fulfilled before the Deployment is rolled out.
+	preconditions []PreconditionFunc
+	// Optional hook functions to call once a generation of the Deployment is rolled out.
+	postRolloutHooks []PostRolloutHookFunc
+	// rolledOut is the UID and generation of the Deployment the post-rollout hooks were last called for.
+	rolledOut rolloutKey
+	// Optional PodDisruptionBudget of the Deployment, see WithPodDisruptionBudget.
+	podDisruptionBudget *podDisruptionBudgetConfig
+	// Optional deadline of the rollout of the Deployment, see WithProgressDeadline.
+	progressDeadline *progressDeadlineConfig
+	// Optional version recorder and the versions of the operands it reports, see WithOperandVersions.
+	versionRecorder status.VersionGetter
+	operandVersions []OperandVersion
+}
+
+// DeploymentControllerOption configures an optional feature of the DeploymentController.
+type DeploymentControllerOption func(c *DeploymentController, informers []factory.Informer) []factory.Informer
+
+// WithRolloutHooks makes the DeploymentController roll out the Deployment only once all preconditions are fulfilled,
+// and call the postRolloutHooks, in order, once each generation of the Deployment is rolled out. This allows
+// sequencing operand rollouts with config migrations. The informers the preconditions depend on should be passed in
+// optionalInformers.
+func WithRolloutHooks(preconditions []PreconditionFunc, postRolloutHooks []PostRolloutHookFunc) DeploymentControllerOption {
+	return func(c *DeploymentController, informers []factory.Informer) []factory.Informer {
+		c.preconditions = append(c.preconditions, preconditions...)
+		c.postRolloutHooks = append(c.postRolloutHooks, postRolloutHooks...)
+		return informers
+	}
+}
+
+type rolloutKey struct {
+	uid        types.UID
+	generation int64
 }
 
 func NewDeploymentController(
@@ -69,6 +115,34 @@ func NewDeploymentController(
 	optionalInformers []factory.Informer,
 	optionalManifestHooks []ManifestHookFunc,
 	optionalDeploymentHooks ...DeploymentHookFunc,
+) factory.Controller {
+	return NewDeploymentControllerWithOptions(
+		name,
+		manifest,
+		recorder,
+		operatorClient,
+		kubeClient,
+		deployInformer,
+		optionalInformers,
+		optionalManifestHooks,
+		optionalDeploymentHooks,
+	)
+}
+
+// NewDeploymentControllerWithOptions returns a DeploymentController like NewDeploymentController with the optional
+// features configured by options, e.g. WithRolloutHooks, WithPodDisruptionBudget, WithProgressDeadline or
+// WithOperandVersions.
+func NewDeploymentControllerWithOptions(
+	name string,
+	manifest []byte,
+	recorder events.Recorder,
+	operatorClient v1helpers.OperatorClientWithFinalizers,
+	kubeClient kubernetes.Interface,
+	deployInformer appsinformersv1.DeploymentInformer,
+	optionalInformers []factory.Informer,
+	optionalManifestHooks []ManifestHookFunc,
+	optionalDeploymentHooks []DeploymentHookFunc,
+	options ...DeploymentControllerOption,
 ) factory.Controller {
 	c := &DeploymentController{
 		name:                    name,
@@ -85,6 +159,9 @@ func NewDeploymentController(
 		operatorClient.Informer(),
 		deployInformer.Informer(),
 	)
+	for _, option := range options {
+		informers = option(c, informers)
+	}
 
 	return factory.New().WithInformers(
 		informers...,
@@ -140,6 +217,17 @@ func (c *DeploymentController) syncManaged(ctx context.Context, opSpec *opv1.Ope
 		return err
 	}
 
+	fulfilled, message, err := c.checkPreconditions(ctx, opSpec)
+	if err != nil {
+		return err
+	}
+	if !fulfilled {
+		return c.syncWaitingForPreconditions(ctx, required, message)
+	}
+	if c.progressDeadline != nil {
+		required.Spec.ProgressDeadlineSeconds = c.progressDeadline.seconds()
+	}
+
 	deployment, _, err := resourceapply.ApplyDeployment(
 		ctx,
 		c.kubeClient.AppsV1(),
@@ -151,6 +239,11 @@ func (c *DeploymentController) syncManaged(ctx context.Context, opSpec *opv1.Ope
 		return err
 	}
 
+	optionalConditions, err := c.syncPodDisruptionBudget(ctx, deployment, syncContext.Recorder())
+	if err != nil {
+		return err
+	}
+
 	availableCondition := opv1.OperatorCondition{
 		Type:   c.name + opv1.OperatorStatusTypeAvailable,
 		Status: opv1.ConditionTrue,
@@ -175,6 +268,21 @@ func (c *DeploymentController) syncManaged(ctx context.Context, opSpec *opv1.Ope
 		progressingCondition.Reason = "Deploying"
 	}
 
+	stuck := false
+	if c.progressDeadline != nil {
+		var rolloutDegradedCondition opv1.OperatorCondition
+		stuck, rolloutDegradedCondition, err = c.checkProgressDeadline(deployment)
+		if err != nil {
+			return err
+		}
+		if stuck {
+			progressingCondition.Status = opv1.ConditionFalse
+			progressingCondition.Reason = "ProgressDeadlineExceeded"
+			progressingCondition.Message = rolloutDegradedCondition.Message
+		}
+		optionalConditions = append(optionalConditions, v1helpers.UpdateConditionFn(rolloutDegradedCondition))
+	}
+
 	updateStatusFn := func(newStatus *opv1.OperatorStatus) error {
 		// TODO: set ObservedGeneration (the last stable generation change we dealt with)
 		resourcemerge.SetDeploymentGeneration(&newStatus.Generations, deployment)
@@ -184,14 +292,91 @@ func (c *DeploymentController) syncManaged(ctx context.Context, opSpec *opv1.Ope
 	_, _, err = v1helpers.UpdateStatus(
 		ctx,
 		c.operatorClient,
-		updateStatusFn,
+		append([]v1helpers.UpdateStatusFunc{
+			updateStatusFn,
+			v1helpers.UpdateConditionFn(availableCondition),
+			v1helpers.UpdateConditionFn(progressingCondition),
+		}, optionalConditions...)...,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := c.reportOperandVersions(deployment); err != nil {
+		return err
+	}
+
+	if progressingCondition.Status == opv1.ConditionFalse && !stuck && deployment.Status.AvailableReplicas > 0 {
+		return c.runPostRolloutHooks(ctx, opSpec, deployment)
+	}
+	return nil
+}
+
+// checkPreconditions returns whether all preconditions are fulfilled, and the messages of those that are not.
+func (c *DeploymentController) checkPreconditions(ctx context.Context, opSpec *opv1.OperatorSpec) (bool, string, error) {
+	var messages []string
+	for i := range c.preconditions {
+		fulfilled, message, err := c.preconditions[i](ctx, opSpec)
+		if err != nil {
+			return false, "", fmt.Errorf("error checking precondition (index=%d): %w", i, err)
+		}
+		if !fulfilled {
+			messages = append(messages, message)
+		}
+	}
+	return len(messages) == 0, strings.Join(messages, "\n"), nil
+}
+
+// syncWaitingForPreconditions reports the conditions of the existing Deployment, without rolling out the required one.
+func (c *DeploymentController) syncWaitingForPreconditions(ctx context.Context, required *appsv1.Deployment, message string) error {
+	availableCondition := opv1.OperatorCondition{
+		Type:    c.name + opv1.OperatorStatusTypeAvailable,
+		Status:  opv1.ConditionFalse,
+		Reason:  "PreconditionNotMet",
+		Message: "Waiting for preconditions to deploy: " + message,
+	}
+	existing, err := c.deployInformer.Lister().Deployments(required.Namespace).Get(required.Name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if existing != nil && existing.Status.AvailableReplicas > 0 {
+		availableCondition = opv1.OperatorCondition{
+			Type:   c.name + opv1.OperatorStatusTypeAvailable,
+			Status: opv1.ConditionTrue,
+		}
+	}
+
+	progressingCondition := opv1.OperatorCondition{
+		Type:    c.name + opv1.OperatorStatusTypeProgressing,
+		Status:  opv1.ConditionTrue,
+		Reason:  "PreconditionNotMet",
+		Message: "Waiting for preconditions to roll out: " + message,
+	}
+
+	_, _, err = v1helpers.UpdateStatus(
+		ctx,
+		c.operatorClient,
 		v1helpers.UpdateConditionFn(availableCondition),
 		v1helpers.UpdateConditionFn(progressingCondition),
 	)
-
 	return err
 }
 
+// runPostRolloutHooks calls the post-rollout hooks once per generation of the rolled out deployment.
+func (c *DeploymentController) runPostRolloutHooks(ctx context.Context, opSpec *opv1.OperatorSpec, deployment *appsv1.Deployment) error {
+	key := rolloutKey{uid: deployment.UID, generation: deployment.Generation}
+	if len(c.postRolloutHooks) == 0 || c.rolledOut == key {
+		return nil
+	}
+	for i := range c.postRolloutHooks {
+		if err := c.postRolloutHooks[i](ctx, opSpec, deployment); err != nil {
+			return fmt.Errorf("error running post-rollout hook function (index=%d): %w", i, err)
+		}
+	}
+	c.rolledOut = key
+	return nil
+}
+
 func (c *DeploymentController) syncDeleting(ctx context.Context, opSpec *opv1.OperatorSpec, opStatus *opv1.OperatorStatus, syncContext factory.SyncContext) error {
 	klog.V(4).Infof("syncDeleting")
 	required, err := c.getDeployment(opSpec)
@@ -206,6 +391,12 @@ func (c *DeploymentController) syncDeleting(ctx context.Context, opSpec *opv1.Op
 		klog.V(2).Infof("Deleted Deployment %s/%s", required.Namespace, required.Name)
 	}
 
+	if c.podDisruptionBudget != nil {
+		if _, _, err := resourceapply.DeletePodDisruptionBudget(ctx, c.kubeClient.PolicyV1(), syncContext.Recorder(), podDisruptionBudgetFor(required)); err != nil {
+			return err
+		}
+	}
+
 	// All removed, remove the finalizer as the last step
 	return v1helpers.RemoveFinalizer(ctx, c.operatorClient, c.name)
 }
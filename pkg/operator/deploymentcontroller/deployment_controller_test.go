@@ -683,3 +683,79 @@ func TestSync(t *testing.T) {
 		})
 	}
 }
+
+func TestRolloutHooks(t *testing.T) {
+	management.SetOperatorNotRemovable()
+	coreClient := fakecore.NewSimpleClientset()
+	addGenerationReactor(coreClient)
+	coreInformerFactory := coreinformers.NewSharedInformerFactory(coreClient, 0 /*no resync */)
+	driverInstance := makeFakeOperatorInstance()
+	fakeOperatorClient := v1helpers.NewFakeOperatorClientWithObjectMeta(&driverInstance.ObjectMeta, &driverInstance.Spec, &driverInstance.Status, nil /*triggerErr func*/)
+
+	migrated := false
+	precondition := func(ctx context.Context, opSpec *opv1.OperatorSpec) (bool, string, error) {
+		return migrated, "config not migrated yet", nil
+	}
+	hookCalls := 0
+	postRolloutHook := func(ctx context.Context, opSpec *opv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		hookCalls++
+		return nil
+	}
+	controller := NewDeploymentControllerWithOptions(
+		controllerName,
+		makeFakeManifest(),
+		events.NewInMemoryRecorder(operandName),
+		fakeOperatorClient,
+		coreClient,
+		coreInformerFactory.Apps().V1().Deployments(),
+		nil,
+		nil,
+		nil,
+		WithRolloutHooks([]PreconditionFunc{precondition}, []PostRolloutHookFunc{postRolloutHook}),
+	)
+	sync := func() {
+		t.Helper()
+		if err := controller.Sync(context.TODO(), factory.NewSyncContext(controllerName, events.NewInMemoryRecorder(operandName))); err != nil {
+			t.Fatalf("sync() returned unexpected error: %v", err)
+		}
+	}
+	expectCondition := func(conditionType string, status opv1.ConditionStatus, reason string) {
+		t.Helper()
+		_, opStatus, _, _ := fakeOperatorClient.GetOperatorState()
+		cond := v1helpers.FindOperatorCondition(opStatus.Conditions, conditionType)
+		if cond == nil || cond.Status != status || cond.Reason != reason {
+			t.Errorf("expected %s to be %s with reason %q, got %v", conditionType, status, reason, cond)
+		}
+	}
+
+	// precondition not fulfilled: nothing is rolled out
+	sync()
+	if _, err := coreClient.AppsV1().Deployments(operandNamespace).Get(context.TODO(), deploymentName, metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Fatalf("expected no Deployment, got %v", err)
+	}
+	expectCondition(conditionProgressing, opv1.ConditionTrue, "PreconditionNotMet")
+	expectCondition(conditionAvailable, opv1.ConditionFalse, "PreconditionNotMet")
+
+	// precondition fulfilled: the Deployment is rolled out, the hook waits for it to be available
+	migrated = true
+	sync()
+	deployment, err := coreClient.AppsV1().Deployments(operandNamespace).Get(context.TODO(), deploymentName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get Deployment %s: %v", deploymentName, err)
+	}
+	if hookCalls != 0 {
+		t.Errorf("expected no post-rollout hook call before the rollout finished, got %d", hookCalls)
+	}
+
+	// the rollout finished: the hook is called once
+	deployment.Status = appsv1.DeploymentStatus{ObservedGeneration: deployment.Generation, Replicas: 1, ReadyReplicas: 1, AvailableReplicas: 1, UpdatedReplicas: 1}
+	if err := coreClient.Tracker().Update(appsv1.SchemeGroupVersion.WithResource("deployments"), deployment, operandNamespace); err != nil {
+		t.Fatal(err)
+	}
+	sync()
+	sync()
+	if hookCalls != 1 {
+		t.Errorf("expected one post-rollout hook call, got %d", hookCalls)
+	}
+	expectCondition(conditionProgressing, opv1.ConditionFalse, "")
+}
@@ -0,0 +1,134 @@
+package deploymentcontroller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	policyinformersv1 "k8s.io/client-go/informers/policy/v1"
+	policylistersv1 "k8s.io/client-go/listers/policy/v1"
+	"k8s.io/klog/v2"
+
+	opv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+const conditionPodDisruptionBudgetBlocking = "PodDisruptionBudgetBlockingDrain"
+
+// IsSingleNodeFunc returns whether the cluster topology is single node, and whether the topology is known yet.
+// See common.NewIsSingleNodePlatformFn in pkg/operator/staticpod/controller/common.
+type IsSingleNodeFunc func() (isSNO, preconditionFulfilled bool, err error)
+
+type podDisruptionBudgetConfig struct {
+	lister policylistersv1.PodDisruptionBudgetLister
+	isSNO  IsSingleNodeFunc
+}
+
+// WithPodDisruptionBudget makes the DeploymentController manage a PodDisruptionBudget named like the Deployment, which
+// allows one unavailable replica. The PodDisruptionBudget is removed when the Deployment has a single replica or when
+// isSNO reports a single node cluster, because it would block node drains. isSNO is optional.
+//
+// The controller additionally produces the condition <name>PodDisruptionBudgetBlockingDrain, which is True when the
+// PodDisruptionBudget allows no disruption and therefore blocks node drains, e.g. because replicas are unhealthy.
+func WithPodDisruptionBudget(pdbInformer policyinformersv1.PodDisruptionBudgetInformer, isSNO IsSingleNodeFunc) DeploymentControllerOption {
+	return func(c *DeploymentController, informers []factory.Informer) []factory.Informer {
+		c.podDisruptionBudget = &podDisruptionBudgetConfig{lister: pdbInformer.Lister(), isSNO: isSNO}
+		return append(informers, pdbInformer.Informer())
+	}
+}
+
+// syncPodDisruptionBudget applies or removes the PodDisruptionBudget of the deployment and returns the functions that
+// update its condition. It does nothing while the topology is not known yet.
+func (c *DeploymentController) syncPodDisruptionBudget(ctx context.Context, deployment *appsv1.Deployment, recorder events.Recorder) ([]v1helpers.UpdateStatusFunc, error) {
+	if c.podDisruptionBudget == nil {
+		return nil, nil
+	}
+
+	isSNO := false
+	if c.podDisruptionBudget.isSNO != nil {
+		var known bool
+		var err error
+		isSNO, known, err = c.podDisruptionBudget.isSNO()
+		if err != nil {
+			return nil, err
+		}
+		if !known {
+			klog.V(4).Infof("Waiting for the cluster topology to sync the PodDisruptionBudget of %s/%s", deployment.Namespace, deployment.Name)
+			return nil, nil
+		}
+	}
+
+	blockingCondition := opv1.OperatorCondition{
+		Type:   c.name + conditionPodDisruptionBudgetBlocking,
+		Status: opv1.ConditionFalse,
+	}
+
+	required := podDisruptionBudgetFor(deployment)
+	if isSNO || replicas(deployment) < 2 {
+		_, err := c.podDisruptionBudget.lister.PodDisruptionBudgets(required.Namespace).Get(required.Name)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		if err == nil {
+			if _, _, err := resourceapply.DeletePodDisruptionBudget(ctx, c.kubeClient.PolicyV1(), recorder, required); err != nil {
+				return nil, err
+			}
+		}
+		blockingCondition.Reason = "NoPodDisruptionBudget"
+		return []v1helpers.UpdateStatusFunc{v1helpers.UpdateConditionFn(blockingCondition)}, nil
+	}
+
+	pdb, _, err := resourceapply.ApplyPodDisruptionBudget(ctx, c.kubeClient.PolicyV1(), recorder, required)
+	if err != nil {
+		return nil, err
+	}
+	if blocking, msg := isBlockingDrain(pdb); blocking {
+		blockingCondition.Status = opv1.ConditionTrue
+		blockingCondition.Reason = "NoDisruptionsAllowed"
+		blockingCondition.Message = msg
+	}
+	return []v1helpers.UpdateStatusFunc{v1helpers.UpdateConditionFn(blockingCondition)}, nil
+}
+
+// podDisruptionBudgetFor returns the PodDisruptionBudget of the deployment.
+func podDisruptionBudgetFor(deployment *appsv1.Deployment) *policyv1.PodDisruptionBudget {
+	maxUnavailable := intstr.FromInt(1)
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deployment.Name,
+			Namespace: deployment.Namespace,
+			Labels:    deployment.Labels,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailable,
+			Selector:       deployment.Spec.Selector,
+		},
+	}
+}
+
+func replicas(deployment *appsv1.Deployment) int32 {
+	if deployment.Spec.Replicas == nil {
+		return 1
+	}
+	return *deployment.Spec.Replicas
+}
+
+// isBlockingDrain returns whether the PodDisruptionBudget currently prevents evicting any of its pods.
+func isBlockingDrain(pdb *policyv1.PodDisruptionBudget) (bool, string) {
+	if pdb.Status.ObservedGeneration != pdb.Generation || pdb.Status.ExpectedPods == 0 {
+		// not evaluated by the disruption controller yet
+		return false, ""
+	}
+	if pdb.Status.DisruptionsAllowed > 0 {
+		return false, ""
+	}
+	return true, fmt.Sprintf("PodDisruptionBudget %s/%s blocks node drains: %d of %d pods are healthy, %d are required",
+		pdb.Namespace, pdb.Name, pdb.Status.CurrentHealthy, pdb.Status.ExpectedPods, pdb.Status.DesiredHealthy)
+}
@@ -0,0 +1,138 @@
+package deploymentcontroller
+
+import (
+	"context"
+	"testing"
+
+	opv1 "github.com/openshift/api/operator/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	coreinformers "k8s.io/client-go/informers"
+	fakecore "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func TestPodDisruptionBudget(t *testing.T) {
+	conditionBlocking := controllerName + conditionPodDisruptionBudgetBlocking
+	existingPDB := func(status policyv1.PodDisruptionBudgetStatus) *policyv1.PodDisruptionBudget {
+		pdb := podDisruptionBudgetFor(&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: deploymentName, Namespace: operandNamespace},
+			Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test-csi-driver-controller"}}},
+		})
+		pdb.Status = status
+		return pdb
+	}
+
+	testCases := []struct {
+		name           string
+		replicas       int32
+		isSNO          IsSingleNodeFunc
+		existing       *policyv1.PodDisruptionBudget
+		expectPDB      bool
+		expectBlocking opv1.ConditionStatus
+		expectReason   string
+	}{
+		{
+			name:           "single replica",
+			replicas:       1,
+			existing:       existingPDB(policyv1.PodDisruptionBudgetStatus{}),
+			expectBlocking: opv1.ConditionFalse,
+			expectReason:   "NoPodDisruptionBudget",
+		},
+		{
+			name:           "HA",
+			replicas:       3,
+			expectPDB:      true,
+			expectBlocking: opv1.ConditionFalse,
+		},
+		{
+			name:           "SNO",
+			replicas:       3,
+			isSNO:          func() (bool, bool, error) { return true, true, nil },
+			existing:       existingPDB(policyv1.PodDisruptionBudgetStatus{}),
+			expectBlocking: opv1.ConditionFalse,
+			expectReason:   "NoPodDisruptionBudget",
+		},
+		{
+			name:     "unknown topology",
+			replicas: 3,
+			isSNO:    func() (bool, bool, error) { return false, false, nil },
+		},
+		{
+			name:           "blocking drains",
+			replicas:       3,
+			isSNO:          func() (bool, bool, error) { return false, true, nil },
+			existing:       existingPDB(policyv1.PodDisruptionBudgetStatus{ExpectedPods: 3, CurrentHealthy: 2, DesiredHealthy: 2}),
+			expectPDB:      true,
+			expectBlocking: opv1.ConditionTrue,
+			expectReason:   "NoDisruptionsAllowed",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			management.SetOperatorNotRemovable()
+			var initialObjects []runtime.Object
+			if tc.existing != nil {
+				initialObjects = append(initialObjects, tc.existing)
+			}
+			coreClient := fakecore.NewSimpleClientset(initialObjects...)
+			coreInformerFactory := coreinformers.NewSharedInformerFactory(coreClient, 0 /*no resync */)
+			pdbInformer := coreInformerFactory.Policy().V1().PodDisruptionBudgets()
+			if tc.existing != nil {
+				pdbInformer.Informer().GetIndexer().Add(tc.existing)
+			}
+			instance := makeFakeOperatorInstance()
+			fakeOperatorClient := v1helpers.NewFakeOperatorClientWithObjectMeta(&instance.ObjectMeta, &instance.Spec, &instance.Status, nil /*triggerErr func*/)
+
+			withReplicas := func(_ *opv1.OperatorSpec, deployment *appsv1.Deployment) error {
+				deployment.Spec.Replicas = &tc.replicas
+				return nil
+			}
+			controller := NewDeploymentControllerWithOptions(
+				controllerName,
+				makeFakeManifest(),
+				events.NewInMemoryRecorder(operandName),
+				fakeOperatorClient,
+				coreClient,
+				coreInformerFactory.Apps().V1().Deployments(),
+				nil,
+				nil,
+				[]DeploymentHookFunc{withReplicas},
+				WithPodDisruptionBudget(pdbInformer, tc.isSNO),
+			)
+			if err := controller.Sync(context.TODO(), factory.NewSyncContext(controllerName, events.NewInMemoryRecorder(operandName))); err != nil {
+				t.Fatalf("sync() returned unexpected error: %v", err)
+			}
+
+			pdb, err := coreClient.PolicyV1().PodDisruptionBudgets(operandNamespace).Get(context.TODO(), deploymentName, metav1.GetOptions{})
+			switch {
+			case tc.expectPDB && err != nil:
+				t.Fatalf("expected a PodDisruptionBudget, got %v", err)
+			case tc.expectPDB && pdb.Spec.MaxUnavailable.IntValue() != 1:
+				t.Errorf("expected one unavailable replica to be allowed, got %v", pdb.Spec.MaxUnavailable)
+			case !tc.expectPDB && tc.existing != nil && !errors.IsNotFound(err):
+				t.Errorf("expected the PodDisruptionBudget to be removed, got %v", err)
+			}
+
+			_, opStatus, _, _ := fakeOperatorClient.GetOperatorState()
+			cond := v1helpers.FindOperatorCondition(opStatus.Conditions, conditionBlocking)
+			if len(tc.expectBlocking) == 0 {
+				if cond != nil {
+					t.Errorf("expected no %s condition, got %v", conditionBlocking, cond)
+				}
+				return
+			}
+			if cond == nil || cond.Status != tc.expectBlocking || cond.Reason != tc.expectReason {
+				t.Errorf("expected %s to be %s with reason %q, got %v", conditionBlocking, tc.expectBlocking, tc.expectReason, cond)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package deploymentcontroller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coreinformersv1 "k8s.io/client-go/informers/core/v1"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+
+	opv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+)
+
+// deploymentProgressDeadlineExceeded is the reason of the Progressing condition of a Deployment whose rollout exceeded
+// its progress deadline, as set by the kube-controller-manager.
+const deploymentProgressDeadlineExceeded = "ProgressDeadlineExceeded"
+
+// maxPodFailures limits the number of pod failures in the condition message.
+const maxPodFailures = 5
+
+type progressDeadlineConfig struct {
+	deadline  time.Duration
+	podLister corelistersv1.PodLister
+}
+
+func (c *progressDeadlineConfig) seconds() *int32 {
+	seconds := int32(c.deadline.Seconds())
+	return &seconds
+}
+
+// WithProgressDeadline sets the progress deadline of the Deployment to deadline. When a rollout does not make any
+// progress within the deadline, the controller stops reporting Progressing=True, and instead reports the condition
+// <name>RolloutDegraded=True with the failures of the pods of the Deployment, e.g. ImagePullBackOff, or why they
+// are unschedulable.
+func WithProgressDeadline(podInformer coreinformersv1.PodInformer, deadline time.Duration) DeploymentControllerOption {
+	return func(c *DeploymentController, informers []factory.Informer) []factory.Informer {
+		c.progressDeadline = &progressDeadlineConfig{deadline: deadline, podLister: podInformer.Lister()}
+		return append(informers, podInformer.Informer())
+	}
+}
+
+// checkProgressDeadline returns whether the rollout of the deployment exceeded its progress deadline, and the
+// <name>RolloutDegraded condition.
+func (c *DeploymentController) checkProgressDeadline(deployment *appsv1.Deployment) (bool, opv1.OperatorCondition, error) {
+	condition := opv1.OperatorCondition{
+		Type:   c.name + "Rollout" + opv1.OperatorStatusTypeDegraded,
+		Status: opv1.ConditionFalse,
+	}
+	if !isProgressDeadlineExceeded(deployment) {
+		return false, condition, nil
+	}
+
+	failures, err := podFailures(deployment, c.progressDeadline.podLister)
+	if err != nil {
+		return false, condition, err
+	}
+	message := fmt.Sprintf("Deployment %s/%s did not make progress within %s", deployment.Namespace, deployment.Name, c.progressDeadline.deadline)
+	if len(failures) > 0 {
+		message += ": " + strings.Join(failures, "; ")
+	}
+	condition.Status = opv1.ConditionTrue
+	condition.Reason = deploymentProgressDeadlineExceeded
+	condition.Message = message
+	return true, condition, nil
+}
+
+// isProgressDeadlineExceeded returns whether the current generation of the deployment exceeded its progress deadline.
+func isProgressDeadlineExceeded(deployment *appsv1.Deployment) bool {
+	if deployment.Generation != deployment.Status.ObservedGeneration {
+		return false
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing {
+			return condition.Status == corev1.ConditionFalse && condition.Reason == deploymentProgressDeadlineExceeded
+		}
+	}
+	return false
+}
+
+// podFailures returns why the pods of the deployment do not become ready, in human readable format. An unschedulable
+// pod is reported with the message of its PodScheduled condition, i.e. the last scheduling failure, the events are not
+// read.
+func podFailures(deployment *appsv1.Deployment, podLister corelistersv1.PodLister) ([]string, error) {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := podLister.Pods(deployment.Namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+
+	var failures []string
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse && condition.Reason == corev1.PodReasonUnschedulable {
+				failures = append(failures, fmt.Sprintf("pod %s is unschedulable: %s", pod.Name, condition.Message))
+			}
+		}
+		for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			switch {
+			case status.State.Waiting != nil && isContainerFailure(status.State.Waiting.Reason):
+				failures = append(failures, fmt.Sprintf("container %s of pod %s is waiting: %s", status.Name, pod.Name, containerStateMessage(status.State.Waiting.Reason, status.State.Waiting.Message)))
+			case status.State.Terminated != nil && status.State.Terminated.ExitCode != 0:
+				failures = append(failures, fmt.Sprintf("container %s of pod %s terminated: %s", status.Name, pod.Name, containerStateMessage(status.State.Terminated.Reason, status.State.Terminated.Message)))
+			}
+		}
+	}
+	if len(failures) > maxPodFailures {
+		failures = append(failures[:maxPodFailures], fmt.Sprintf("%d more", len(failures)-maxPodFailures))
+	}
+	return failures, nil
+}
+
+// isContainerFailure returns whether a container waits for a reason that does not resolve by itself.
+func isContainerFailure(reason string) bool {
+	switch reason {
+	case "ContainerCreating", "PodInitializing", "":
+		return false
+	}
+	return true
+}
+
+func containerStateMessage(reason, message string) string {
+	if len(message) == 0 {
+		return reason
+	}
+	return reason + " (" + message + ")"
+}
@@ -0,0 +1,148 @@
+package deploymentcontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	opv1 "github.com/openshift/api/operator/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coreinformers "k8s.io/client-go/informers"
+	fakecore "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func TestProgressDeadline(t *testing.T) {
+	conditionRolloutDegraded := controllerName + "Rollout" + opv1.OperatorStatusTypeDegraded
+	podLabels := map[string]string{"app": "test-csi-driver-controller"}
+
+	testCases := []struct {
+		name              string
+		deploymentStatus  appsv1.DeploymentStatus
+		pods              []*v1.Pod
+		expectDegraded    opv1.ConditionStatus
+		expectProgressing opv1.ConditionStatus
+		expectMessages    []string
+	}{
+		{
+			name: "progressing",
+			deploymentStatus: appsv1.DeploymentStatus{
+				Replicas:            1,
+				UnavailableReplicas: 1,
+				Conditions: []appsv1.DeploymentCondition{
+					{Type: appsv1.DeploymentProgressing, Status: v1.ConditionTrue, Reason: "ReplicaSetUpdated"},
+				},
+			},
+			expectDegraded:    opv1.ConditionFalse,
+			expectProgressing: opv1.ConditionTrue,
+		},
+		{
+			name: "deadline exceeded",
+			deploymentStatus: appsv1.DeploymentStatus{
+				Replicas:            2,
+				UnavailableReplicas: 2,
+				Conditions: []appsv1.DeploymentCondition{
+					{Type: appsv1.DeploymentProgressing, Status: v1.ConditionFalse, Reason: deploymentProgressDeadlineExceeded},
+				},
+			},
+			pods: []*v1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "pull", Namespace: operandNamespace, Labels: podLabels},
+					Status: v1.PodStatus{
+						ContainerStatuses: []v1.ContainerStatus{
+							{Name: "csi-driver", State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image"}}},
+							{Name: "csi-provisioner", State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ContainerCreating"}}},
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: operandNamespace, Labels: podLabels},
+					Status: v1.PodStatus{
+						Phase: v1.PodPending,
+						Conditions: []v1.PodCondition{
+							{Type: v1.PodScheduled, Status: v1.ConditionFalse, Reason: v1.PodReasonUnschedulable, Message: "0/3 nodes are available: 3 Insufficient cpu."},
+						},
+					},
+				},
+			},
+			expectDegraded:    opv1.ConditionTrue,
+			expectProgressing: opv1.ConditionFalse,
+			expectMessages: []string{
+				"container csi-driver of pod pull is waiting: ImagePullBackOff (Back-off pulling image)",
+				"pod pending is unschedulable: 0/3 nodes are available: 3 Insufficient cpu.",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			management.SetOperatorNotRemovable()
+			coreClient := fakecore.NewSimpleClientset()
+			coreInformerFactory := coreinformers.NewSharedInformerFactory(coreClient, 0 /*no resync */)
+			podInformer := coreInformerFactory.Core().V1().Pods()
+			for _, pod := range tc.pods {
+				podInformer.Informer().GetIndexer().Add(pod)
+			}
+			instance := makeFakeOperatorInstance()
+			fakeOperatorClient := v1helpers.NewFakeOperatorClientWithObjectMeta(&instance.ObjectMeta, &instance.Spec, &instance.Status, nil /*triggerErr func*/)
+
+			controller := NewDeploymentControllerWithOptions(
+				controllerName,
+				makeFakeManifest(),
+				events.NewInMemoryRecorder(operandName),
+				fakeOperatorClient,
+				coreClient,
+				coreInformerFactory.Apps().V1().Deployments(),
+				nil,
+				nil,
+				nil,
+				WithProgressDeadline(podInformer, 10*time.Minute),
+			)
+			sync := func() {
+				t.Helper()
+				if err := controller.Sync(context.TODO(), factory.NewSyncContext(controllerName, events.NewInMemoryRecorder(operandName))); err != nil {
+					t.Fatalf("sync() returned unexpected error: %v", err)
+				}
+			}
+
+			sync()
+			deployment, err := coreClient.AppsV1().Deployments(operandNamespace).Get(context.TODO(), deploymentName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Failed to get Deployment %s: %v", deploymentName, err)
+			}
+			if deadline := deployment.Spec.ProgressDeadlineSeconds; deadline == nil || *deadline != 600 {
+				t.Errorf("expected a progress deadline of 600 seconds, got %v", deadline)
+			}
+
+			deployment.Status = tc.deploymentStatus
+			if err := coreClient.Tracker().Update(appsv1.SchemeGroupVersion.WithResource("deployments"), deployment, operandNamespace); err != nil {
+				t.Fatal(err)
+			}
+			sync()
+
+			_, opStatus, _, _ := fakeOperatorClient.GetOperatorState()
+			degraded := v1helpers.FindOperatorCondition(opStatus.Conditions, conditionRolloutDegraded)
+			if degraded == nil || degraded.Status != tc.expectDegraded {
+				t.Fatalf("expected %s %s, got %v", conditionRolloutDegraded, tc.expectDegraded, degraded)
+			}
+			for _, expected := range tc.expectMessages {
+				if !strings.Contains(degraded.Message, expected) {
+					t.Errorf("expected %q in %q", expected, degraded.Message)
+				}
+			}
+			if strings.Contains(degraded.Message, "ContainerCreating") {
+				t.Errorf("expected containers that are being created to be ignored, got %q", degraded.Message)
+			}
+			if cond := v1helpers.FindOperatorCondition(opStatus.Conditions, conditionProgressing); cond == nil || cond.Status != tc.expectProgressing {
+				t.Errorf("expected %s %s, got %v", conditionProgressing, tc.expectProgressing, cond)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package deploymentcontroller
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	coreinformersv1 "k8s.io/client-go/informers/core/v1"
+
+	opv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcehash"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// DefaultTrustedCABundleConfigMapName is the usual name of the ConfigMap labeled with
+// config.openshift.io/inject-trusted-cabundle=true, into which the cluster network operator injects the trusted CA
+// bundle of the cluster.
+const DefaultTrustedCABundleConfigMapName = "trusted-ca-bundle"
+
+// trustedCABundleKey is the key of the trusted CA bundle in the ConfigMap.
+const trustedCABundleKey = "ca-bundle.crt"
+
+// WithTrustedCABundleAndProxy makes the DeploymentController inject the trusted CA bundle and the cluster proxy into
+// the containers of the Deployment with the given names:
+//   - The trusted CA bundle ConfigMap in the namespace of the Deployment (DefaultTrustedCABundleConfigMapName when
+//     configMapName is empty) is mounted as the system trust store, once it contains the bundle. The Deployment is
+//     rolled out again when the bundle changes.
+//   - The HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are set from the observed config at
+//     proxyConfigPath, as observed by proxy.NewProxyObserveFunc. The Deployment is rolled out again when they change.
+//     Nothing is injected when proxyConfigPath is empty.
+//
+// The configMapInformer must watch the namespace of the Deployment.
+func WithTrustedCABundleAndProxy(configMapInformer coreinformersv1.ConfigMapInformer, configMapName string, proxyConfigPath []string, containerNames ...string) DeploymentControllerOption {
+	if len(configMapName) == 0 {
+		configMapName = DefaultTrustedCABundleConfigMapName
+	}
+	return func(c *DeploymentController, informers []factory.Informer) []factory.Informer {
+		c.optionalDeploymentHooks = append(c.optionalDeploymentHooks, func(opSpec *opv1.OperatorSpec, deployment *appsv1.Deployment) error {
+			if len(proxyConfigPath) > 0 {
+				if err := v1helpers.InjectObservedProxyIntoContainers(&deployment.Spec.Template.Spec, containerNames, opSpec.ObservedConfig.Raw, proxyConfigPath...); err != nil {
+					return err
+				}
+			}
+			return injectTrustedCABundle(configMapInformer, configMapName, containerNames, deployment)
+		})
+		return append(informers, configMapInformer.Informer())
+	}
+}
+
+func injectTrustedCABundle(configMapInformer coreinformersv1.ConfigMapInformer, configMapName string, containerNames []string, deployment *appsv1.Deployment) error {
+	cm, err := configMapInformer.Lister().ConfigMaps(deployment.Namespace).Get(configMapName)
+	if apierrors.IsNotFound(err) {
+		// the pods would not start with a missing ConfigMap, the bundle is injected once it exists
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get ConfigMap %s/%s: %w", deployment.Namespace, configMapName, err)
+	}
+	if _, ok := cm.Data[trustedCABundleKey]; !ok {
+		return nil
+	}
+
+	if err := v1helpers.InjectTrustedCAIntoContainers(&deployment.Spec.Template.Spec, configMapName, containerNames); err != nil {
+		return err
+	}
+
+	inputHashes, err := resourcehash.MultipleObjectHashStringMapForObjectReferenceFromLister(
+		configMapInformer.Lister(),
+		nil,
+		resourcehash.NewObjectRef().ForConfigMap().InNamespace(deployment.Namespace).Named(configMapName),
+	)
+	if err != nil {
+		return fmt.Errorf("invalid dependency reference: %w", err)
+	}
+	addObjectHashAnnotations(deployment, inputHashes)
+	return nil
+}
+
+// addObjectHashAnnotations annotates the deployment and its pod template with the hashes of its inputs, so that the
+// Deployment is rolled out when they change.
+func addObjectHashAnnotations(deployment *appsv1.Deployment, inputHashes map[string]string) {
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	if deployment.Spec.Template.Annotations == nil {
+		deployment.Spec.Template.Annotations = map[string]string{}
+	}
+	for k, v := range inputHashes {
+		annotationKey := fmt.Sprintf("operator.openshift.io/dep-%s", k)
+		if len(annotationKey) > 63 {
+			hash := sha256.Sum256([]byte(k))
+			annotationKey = fmt.Sprintf("operator.openshift.io/dep-%x", hash)
+			annotationKey = annotationKey[:63]
+		}
+		deployment.Annotations[annotationKey] = v
+		deployment.Spec.Template.Annotations[annotationKey] = v
+	}
+}
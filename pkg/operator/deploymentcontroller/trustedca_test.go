@@ -0,0 +1,83 @@
+package deploymentcontroller
+
+import (
+	"testing"
+
+	opv1 "github.com/openshift/api/operator/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	coreinformers "k8s.io/client-go/informers"
+	fakecore "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestTrustedCABundleAndProxy(t *testing.T) {
+	coreInformerFactory := coreinformers.NewSharedInformerFactory(fakecore.NewSimpleClientset(), 0 /*no resync */)
+	configMapInformer := coreInformerFactory.Core().V1().ConfigMaps()
+	c := &DeploymentController{manifest: makeFakeManifest()}
+	WithTrustedCABundleAndProxy(configMapInformer, "", []string{"targetconfig", "proxy"}, "csi-driver")(c, nil)
+
+	opSpec := &opv1.OperatorSpec{
+		ObservedConfig: runtime.RawExtension{Raw: []byte(`{"targetconfig":{"proxy":{"HTTPS_PROXY":"https://proxy:3128","NO_PROXY":".cluster.local"}}}`)},
+	}
+	caBundleMounted := func(spec *v1.PodSpec) bool {
+		for _, volume := range spec.Volumes {
+			if volume.ConfigMap != nil && volume.ConfigMap.Name == DefaultTrustedCABundleConfigMapName {
+				return true
+			}
+		}
+		return false
+	}
+
+	// without the ConfigMap only the proxy is injected
+	deployment, err := c.getDeployment(opSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{}
+	for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	if env["HTTPS_PROXY"] != "https://proxy:3128" || env["NO_PROXY"] != ".cluster.local" {
+		t.Errorf("expected the proxy in the environment of csi-driver, got %v", env)
+	}
+	for _, e := range deployment.Spec.Template.Spec.Containers[1].Env {
+		if e.Name == "HTTPS_PROXY" {
+			t.Errorf("expected no proxy in the environment of %s", deployment.Spec.Template.Spec.Containers[1].Name)
+		}
+	}
+	if caBundleMounted(&deployment.Spec.Template.Spec) {
+		t.Error("expected no CA bundle before the ConfigMap exists")
+	}
+
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: DefaultTrustedCABundleConfigMapName, Namespace: operandNamespace},
+		Data:       map[string]string{trustedCABundleKey: "bundle-1"},
+	}
+	configMapInformer.Informer().GetIndexer().Add(configMap)
+	deployment, err = c.getDeployment(opSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !caBundleMounted(&deployment.Spec.Template.Spec) {
+		t.Error("expected the CA bundle to be mounted")
+	}
+	firstAnnotations := deployment.Spec.Template.Annotations
+	if len(firstAnnotations) == 0 {
+		t.Fatal("expected the hash of the CA bundle in the pod template")
+	}
+
+	// a new bundle changes the pod template
+	configMap = configMap.DeepCopy()
+	configMap.Data[trustedCABundleKey] = "bundle-2"
+	configMapInformer.Informer().GetIndexer().Update(configMap)
+	deployment, err = c.getDeployment(opSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range firstAnnotations {
+		if deployment.Spec.Template.Annotations[k] == v {
+			t.Errorf("expected annotation %s to change with the CA bundle", k)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package deploymentcontroller
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	imagereference "github.com/openshift/library-go/pkg/image/reference"
+	"github.com/openshift/library-go/pkg/operator/status"
+)
+
+// OperandVersion declares a version that is reported in status.versions once the workload rolled out. The version is
+// either fixed, or read from the pod template of the workload, which makes sure it is reported only when the pods that
+// run it are rolled out.
+type OperandVersion struct {
+	// Name of the operand in status.versions. It defaults to the name of the workload.
+	Name string
+	// Version is the fixed version of the operand.
+	Version string
+	// Container is the container of the pod template the version is read from, when Version is not set.
+	Container string
+	// EnvVar is the environment variable of the container that holds the version. When it is empty, the version is
+	// the tag of the image of the container.
+	EnvVar string
+}
+
+// resolve returns the name and the version of the operand in the pod template of the workload called name.
+func (v OperandVersion) resolve(name string, template *corev1.PodTemplateSpec) (string, string, error) {
+	if len(v.Name) > 0 {
+		name = v.Name
+	}
+	if len(v.Version) > 0 {
+		return name, v.Version, nil
+	}
+
+	var container *corev1.Container
+	for i := range template.Spec.Containers {
+		if template.Spec.Containers[i].Name == v.Container {
+			container = &template.Spec.Containers[i]
+			break
+		}
+	}
+	if container == nil {
+		return "", "", fmt.Errorf("version of operand %q: container %q not found", name, v.Container)
+	}
+
+	if len(v.EnvVar) > 0 {
+		for _, env := range container.Env {
+			if env.Name == v.EnvVar && len(env.Value) > 0 {
+				return name, env.Value, nil
+			}
+		}
+		return "", "", fmt.Errorf("version of operand %q: environment variable %q of container %q is not set", name, v.EnvVar, v.Container)
+	}
+
+	ref, err := imagereference.Parse(container.Image)
+	if err != nil {
+		return "", "", fmt.Errorf("version of operand %q: %w", name, err)
+	}
+	if len(ref.Tag) == 0 {
+		return "", "", fmt.Errorf("version of operand %q: image %q of container %q has no tag", name, container.Image, v.Container)
+	}
+	return name, ref.Tag, nil
+}
+
+// resolveOperandVersions returns the versions of the operands of the workload called name, by operand name.
+func resolveOperandVersions(versions []OperandVersion, name string, template *corev1.PodTemplateSpec) (map[string]string, error) {
+	ret := map[string]string{}
+	for _, v := range versions {
+		operand, version, err := v.resolve(name, template)
+		if err != nil {
+			return nil, err
+		}
+		ret[operand] = version
+	}
+	return ret, nil
+}
+
+// WithOperandVersions makes the DeploymentController report the versions to versionRecorder once the Deployment
+// fully converged: it observed its generation and all its replicas are updated, ready and available, with no old
+// replicas left.
+func WithOperandVersions(versionRecorder status.VersionGetter, versions ...OperandVersion) DeploymentControllerOption {
+	return func(c *DeploymentController, informers []factory.Informer) []factory.Informer {
+		c.versionRecorder = versionRecorder
+		c.operandVersions = versions
+		return informers
+	}
+}
+
+// reportOperandVersions validates the operand versions of the deployment and reports them when it rolled out.
+func (c *DeploymentController) reportOperandVersions(deployment *appsv1.Deployment) error {
+	if c.versionRecorder == nil {
+		return nil
+	}
+	versions, err := resolveOperandVersions(c.operandVersions, deployment.Name, &deployment.Spec.Template)
+	if err != nil {
+		return err
+	}
+	if !isDeploymentConverged(deployment) {
+		return nil
+	}
+	for operand, version := range versions {
+		c.versionRecorder.SetVersion(operand, version)
+	}
+	return nil
+}
+
+// isDeploymentConverged returns whether all replicas of the deployment run its current generation and are ready.
+func isDeploymentConverged(deployment *appsv1.Deployment) bool {
+	replicas := replicas(deployment)
+	return deployment.Generation == deployment.Status.ObservedGeneration &&
+		deployment.Status.Replicas == replicas &&
+		deployment.Status.UpdatedReplicas == replicas &&
+		deployment.Status.ReadyReplicas == replicas &&
+		deployment.Status.AvailableReplicas == replicas &&
+		deployment.Status.UnavailableReplicas == 0
+}
+
+// isDaemonSetConverged returns whether the DaemonSet pods on all nodes run its current generation and are ready.
+func isDaemonSetConverged(daemonSet *appsv1.DaemonSet) bool {
+	desired := daemonSet.Status.DesiredNumberScheduled
+	return daemonSet.Generation == daemonSet.Status.ObservedGeneration &&
+		daemonSet.Status.CurrentNumberScheduled == desired &&
+		daemonSet.Status.UpdatedNumberScheduled == desired &&
+		daemonSet.Status.NumberReady == desired &&
+		daemonSet.Status.NumberAvailable == desired &&
+		daemonSet.Status.NumberUnavailable == 0
+}
@@ -0,0 +1,142 @@
+package deploymentcontroller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coreinformers "k8s.io/client-go/informers"
+	fakecore "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/status"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func TestResolveOperandVersions(t *testing.T) {
+	template := &v1.PodTemplateSpec{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name:  "operand",
+					Image: "quay.io/openshift/operand:4.15.1",
+					Env:   []v1.EnvVar{{Name: "OPERAND_VERSION", Value: "4.15.2"}},
+				},
+				{
+					Name:  "sidecar",
+					Image: "quay.io/openshift/sidecar@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		versions  []OperandVersion
+		expected  map[string]string
+		expectErr bool
+	}{
+		{
+			name:     "fixed version named like the workload",
+			versions: []OperandVersion{{Version: "4.15.0"}},
+			expected: map[string]string{"workload": "4.15.0"},
+		},
+		{
+			name: "image tag and environment variable",
+			versions: []OperandVersion{
+				{Name: "image", Container: "operand"},
+				{Name: "env", Container: "operand", EnvVar: "OPERAND_VERSION"},
+			},
+			expected: map[string]string{"image": "4.15.1", "env": "4.15.2"},
+		},
+		{
+			name:      "missing container",
+			versions:  []OperandVersion{{Container: "missing"}},
+			expectErr: true,
+		},
+		{
+			name:      "missing environment variable",
+			versions:  []OperandVersion{{Container: "operand", EnvVar: "MISSING"}},
+			expectErr: true,
+		},
+		{
+			name:      "image without tag",
+			versions:  []OperandVersion{{Container: "sidecar"}},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			versions, err := resolveOperandVersions(tc.versions, "workload", template)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if !tc.expectErr && !reflect.DeepEqual(versions, tc.expected) {
+				t.Errorf("expected versions %v, got %v", tc.expected, versions)
+			}
+		})
+	}
+}
+
+func TestReportOperandVersions(t *testing.T) {
+	management.SetOperatorNotRemovable()
+	coreClient := fakecore.NewSimpleClientset()
+	coreInformerFactory := coreinformers.NewSharedInformerFactory(coreClient, 0 /*no resync */)
+	instance := makeFakeOperatorInstance()
+	fakeOperatorClient := v1helpers.NewFakeOperatorClientWithObjectMeta(&instance.ObjectMeta, &instance.Spec, &instance.Status, nil /*triggerErr func*/)
+	versionRecorder := status.NewVersionGetter()
+
+	controller := NewDeploymentControllerWithOptions(
+		controllerName,
+		makeFakeManifest(),
+		events.NewInMemoryRecorder(operandName),
+		fakeOperatorClient,
+		coreClient,
+		coreInformerFactory.Apps().V1().Deployments(),
+		nil,
+		nil,
+		nil,
+		WithOperandVersions(versionRecorder, OperandVersion{Name: "operand", Version: "4.15.0"}),
+	)
+	sync := func() {
+		t.Helper()
+		if err := controller.Sync(context.TODO(), factory.NewSyncContext(controllerName, events.NewInMemoryRecorder(operandName))); err != nil {
+			t.Fatalf("sync() returned unexpected error: %v", err)
+		}
+	}
+	setStatus := func(status appsv1.DeploymentStatus) {
+		t.Helper()
+		deployment, err := coreClient.AppsV1().Deployments(operandNamespace).Get(context.TODO(), deploymentName, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get Deployment %s: %v", deploymentName, err)
+		}
+		deployment.Status = status
+		if err := coreClient.Tracker().Update(appsv1.SchemeGroupVersion.WithResource("deployments"), deployment, operandNamespace); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sync()
+	if versions := versionRecorder.GetVersions(); len(versions) != 0 {
+		t.Errorf("expected no version before the rollout, got %v", versions)
+	}
+
+	// available, but an old replica is still running
+	setStatus(appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 1, ReadyReplicas: 2, AvailableReplicas: 2})
+	sync()
+	if versions := versionRecorder.GetVersions(); len(versions) != 0 {
+		t.Errorf("expected no version during the rollout, got %v", versions)
+	}
+
+	setStatus(appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, ReadyReplicas: 1, AvailableReplicas: 1})
+	sync()
+	if expected, versions := map[string]string{"operand": "4.15.0"}, versionRecorder.GetVersions(); !reflect.DeepEqual(versions, expected) {
+		t.Errorf("expected versions %v after the rollout, got %v", expected, versions)
+	}
+}
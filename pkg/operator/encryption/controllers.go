@@ -39,6 +39,7 @@ func NewControllers(
 	secretsClient corev1.SecretsGetter,
 	eventRecorder events.Recorder,
 	resourceSyncer *resourcesynccontroller.ResourceSyncController,
+	additionalPreconditions ...Precondition,
 ) (*Controllers, error) {
 	// avoid using the CachedSecretGetter as we need strong guarantees that our encryptionSecretSelector works
 	// otherwise we could see secrets from a different component (which will break our keyID invariants)
@@ -62,6 +63,12 @@ func NewControllers(
 		}
 	}
 
+	// honour the resources excluded from encryption on the operator CR
+	provider = controllers.NewExcludingProvider(provider, operatorClient)
+
+	// the encryption enabled check always goes first, additional preconditions are only consulted when there is work to do
+	preconditions := append(unionPrecondition{encryptionEnabledChecker}, additionalPreconditions...)
+
 	return &Controllers{
 		controllers: []runner{
 			controllers.NewKeyController(
@@ -69,7 +76,7 @@ func NewControllers(
 				unsupportedConfigPrefix,
 				provider,
 				deployer,
-				encryptionEnabledChecker.PreconditionFulfilled,
+				preconditions.PreconditionFulfilled,
 				operatorClient,
 				apiServerClient,
 				apiServerInformer,
@@ -82,7 +89,7 @@ func NewControllers(
 				component,
 				provider,
 				deployer,
-				encryptionEnabledChecker.PreconditionFulfilled,
+				preconditions.PreconditionFulfilled,
 				operatorClient,
 				apiServerInformer,
 				kubeInformersForNamespaces,
@@ -93,7 +100,7 @@ func NewControllers(
 			controllers.NewPruneController(
 				provider,
 				deployer,
-				encryptionEnabledChecker.PreconditionFulfilled,
+				preconditions.PreconditionFulfilled,
 				operatorClient,
 				apiServerInformer,
 				kubeInformersForNamespaces,
@@ -105,7 +112,7 @@ func NewControllers(
 				component,
 				provider,
 				deployer,
-				encryptionEnabledChecker.PreconditionFulfilled,
+				preconditions.PreconditionFulfilled,
 				migrator,
 				operatorClient,
 				apiServerInformer,
@@ -117,7 +124,7 @@ func NewControllers(
 			controllers.NewConditionController(
 				provider,
 				deployer,
-				encryptionEnabledChecker.PreconditionFulfilled,
+				preconditions.PreconditionFulfilled,
 				operatorClient,
 				apiServerInformer,
 				kubeInformersForNamespaces,
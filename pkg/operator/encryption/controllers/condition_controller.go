@@ -13,6 +13,7 @@ import (
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 
 	configv1informers "github.com/openshift/client-go/config/informers/externalversions/config/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/encryption/encryptionconfig"
 	"github.com/openshift/library-go/pkg/operator/encryption/state"
@@ -23,8 +24,11 @@ import (
 
 // conditionController maintains the Encrypted condition. It sets it to true iff there is a
 // fully migrated read-key in the current config, and no later key is of identity type.
+// It also maintains the EncryptionExclusionsDegraded condition listing the resources excluded from encryption
+// and the EncryptionModeTransitionProgressing condition narrating a switch of the encryption type.
 type conditionController struct {
-	operatorClient operatorv1helpers.OperatorClient
+	operatorClient        operatorv1helpers.OperatorClient
+	apiServerConfigLister configv1listers.APIServerLister
 
 	encryptionSecretSelector metav1.ListOptions
 
@@ -46,7 +50,8 @@ func NewConditionController(
 	eventRecorder events.Recorder,
 ) factory.Controller {
 	c := &conditionController{
-		operatorClient: operatorClient,
+		operatorClient:        operatorClient,
+		apiServerConfigLister: apiServerConfigInformer.Lister(),
 
 		encryptionSecretSelector: encryptionSecretSelector,
 		deployer:                 deployer,
@@ -65,11 +70,23 @@ func NewConditionController(
 
 func (c *conditionController) sync(ctx context.Context, _ factory.SyncContext) (err error) {
 	cond := &operatorv1.OperatorCondition{Type: "Encrypted", Status: operatorv1.ConditionFalse}
+	exclusionsCond := exclusionsCondition(c.provider)
+	var transitionCond *operatorv1.OperatorCondition
 	defer func() {
-		if cond == nil {
+		var updateFuncs []operatorv1helpers.UpdateStatusFunc
+		if cond != nil {
+			updateFuncs = append(updateFuncs, operatorv1helpers.UpdateConditionFn(*cond))
+		}
+		if transitionCond != nil {
+			updateFuncs = append(updateFuncs, operatorv1helpers.UpdateConditionFn(*transitionCond))
+		}
+		if exclusionsCond != nil {
+			updateFuncs = append(updateFuncs, operatorv1helpers.UpdateConditionFn(*exclusionsCond))
+		}
+		if len(updateFuncs) == 0 {
 			return
 		}
-		if _, _, updateError := operatorv1helpers.UpdateStatus(ctx, c.operatorClient, operatorv1helpers.UpdateConditionFn(*cond)); updateError != nil {
+		if _, _, updateError := operatorv1helpers.UpdateStatus(ctx, c.operatorClient, updateFuncs...); updateError != nil {
 			err = updateError
 		}
 	}()
@@ -88,7 +105,12 @@ func (c *conditionController) sync(ctx context.Context, _ factory.SyncContext) (
 		cond = nil
 		return err
 	}
-	currentState, _ := encryptionconfig.ToEncryptionState(currentConfig, foundSecrets)
+	currentState, backedKeys := encryptionconfig.ToEncryptionState(currentConfig, foundSecrets)
+
+	transitionCond, err = c.modeTransitionCondition(currentState, backedKeys, encryptedGRs)
+	if err != nil {
+		return err
+	}
 
 	cond.Status = operatorv1.ConditionTrue
 	cond.Reason = "EncryptionCompleted"
@@ -165,6 +187,26 @@ func (c *conditionController) sync(ctx context.Context, _ factory.SyncContext) (
 	return nil
 }
 
+// modeTransitionCondition narrates the phases of a switch of the encryption type, e.g. from aescbc to aesgcm.
+func (c *conditionController) modeTransitionCondition(currentState map[schema.GroupResource]state.GroupResourceState, backedKeys []state.KeyState, encryptedGRs []schema.GroupResource) (*operatorv1.OperatorCondition, error) {
+	apiServerConfig, err := c.apiServerConfigLister.Get("cluster")
+	if err != nil {
+		return nil, err
+	}
+	targetMode := state.Mode(apiServerConfig.Spec.Encryption.Type)
+	if len(targetMode) == 0 {
+		targetMode = state.DefaultMode
+	}
+
+	cond := &operatorv1.OperatorCondition{Type: "EncryptionModeTransitionProgressing", Status: operatorv1.ConditionFalse, Reason: "AsExpected"}
+	if transition := statemachine.GetModeTransition(currentState, backedKeys, targetMode, encryptedGRs); transition != nil {
+		cond.Status = operatorv1.ConditionTrue
+		cond.Reason = string(transition.Phase)
+		cond.Message = transition.Message
+	}
+	return cond, nil
+}
+
 func allMigrated(toBeEncrypted, migrated []schema.GroupResource) bool {
 	s := migratedSet(migrated)
 	for _, gr := range toBeEncrypted {
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/clock"
 
 	"github.com/openshift/library-go/pkg/operator/management"
 	operatorv1helpers "github.com/openshift/library-go/pkg/operator/v1helpers"
@@ -10,6 +11,28 @@ import (
 // preconditionsFulfilled a function that indicates whether all prerequisites are met and we can Sync.
 type preconditionsFulfilled func() (bool, error)
 
+// Option configures the time-based encryption controllers, i.e. the key and the migration controllers.
+type Option func(*options)
+
+type options struct {
+	clock clock.PassiveClock
+}
+
+// WithClock sets the clock the controller reads the time from, e.g. a fake clock in unit tests.
+func WithClock(clock clock.PassiveClock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{clock: clock.RealClock{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
 // Provider abstracts external dependencies and preconditions that need to be dynamic during a downgrade/upgrade
 type Provider interface {
 	// EncryptedGRs returns resources that need to be encrypted
@@ -19,6 +42,23 @@ type Provider interface {
 	ShouldRunEncryptionControllers() (bool, error)
 }
 
+// ManagementClusterAwareProvider is an optional interface a Provider can implement when the operand apiserver and etcd
+// don't live in the cluster the operator manages, e.g. in HyperShift-style hosted control planes.
+type ManagementClusterAwareProvider interface {
+	// HostedControlPlaneHealthy indicates whether the control plane running in the management cluster is healthy enough
+	// to roll out a new encryption key. New keys are not created while it returns false.
+	HostedControlPlaneHealthy() (bool, error)
+}
+
+// hostedControlPlaneHealthy returns true unless the provider is management cluster aware and reports an unhealthy control plane.
+func hostedControlPlaneHealthy(provider Provider) (bool, error) {
+	managementClusterAwareProvider, ok := provider.(ManagementClusterAwareProvider)
+	if !ok {
+		return true, nil
+	}
+	return managementClusterAwareProvider.HostedControlPlaneHealthy()
+}
+
 func shouldRunEncryptionController(operatorClient operatorv1helpers.OperatorClient, preconditionsFulfilledFn preconditionsFulfilled, shouldRunFn func() (bool, error)) (bool, error) {
 	if shouldRun, err := shouldRunFn(); !shouldRun || err != nil {
 		return false, err
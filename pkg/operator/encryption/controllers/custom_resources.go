@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"sort"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionslistersv1 "k8s.io/apiextensions-apiserver/pkg/client/listers/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+// EncryptedCustomResourcesLabel is set to "true" on a CustomResourceDefinition to have its custom resources encrypted
+// at rest along with the resources of the operator, when the operator wraps its provider with NewCustomResourceProvider.
+const EncryptedCustomResourcesLabel = "encryption.apiserver.operator.openshift.io/encrypted"
+
+// EncryptedCustomResourcesSelector selects the CustomResourceDefinitions labelled with EncryptedCustomResourcesLabel.
+var EncryptedCustomResourcesSelector = labels.SelectorFromSet(labels.Set{EncryptedCustomResourcesLabel: "true"})
+
+// customResourceProvider adds the custom resources of the selected CustomResourceDefinitions to the resources of the delegate.
+type customResourceProvider struct {
+	delegate  Provider
+	crdLister apiextensionslistersv1.CustomResourceDefinitionLister
+	selector  labels.Selector
+}
+
+var _ Provider = &customResourceProvider{}
+var _ ManagementClusterAwareProvider = &customResourceProvider{}
+
+// NewCustomResourceProvider wraps the given provider so that the custom resources of the CustomResourceDefinitions
+// matching the selector, e.g. EncryptedCustomResourcesSelector, are encrypted and migrated like the resources of the
+// delegate. Only established CustomResourceDefinitions are included, so that their resources can be migrated.
+//
+// The custom resources of a CustomResourceDefinition which is unlabelled, deleted or not established anymore are
+// not encrypted anymore, but they are kept in the encryption configuration with identity as write key until the
+// migration controller has migrated them back to identity.
+//
+// The informer of the lister must be started by the caller. Changes of the CustomResourceDefinitions are picked up
+// when the encryption controllers resync.
+func NewCustomResourceProvider(delegate Provider, crdLister apiextensionslistersv1.CustomResourceDefinitionLister, selector labels.Selector) Provider {
+	return &customResourceProvider{delegate: delegate, crdLister: crdLister, selector: selector}
+}
+
+// EncryptedGRs returns the resources of the delegate provider followed by the selected custom resources.
+// Custom resources dropped from the list are retired by the state machine, not by the provider.
+func (p *customResourceProvider) EncryptedGRs() []schema.GroupResource {
+	encryptedGRs := p.delegate.EncryptedGRs()
+	crds, err := p.crdLister.List(p.selector)
+	if err != nil {
+		// the lister of an informer does not fail, the delegate resources are still encrypted
+		klog.Warningf("failed to list the custom resource definitions to encrypt: %v", err)
+		return encryptedGRs
+	}
+
+	known := migratedSet(encryptedGRs)
+	var customGRs []schema.GroupResource
+	for _, crd := range crds {
+		if !crdEstablished(crd) {
+			continue
+		}
+		gr := schema.GroupResource{Group: crd.Spec.Group, Resource: crd.Spec.Names.Plural}
+		if known.Has(gr.String()) {
+			continue
+		}
+		known.Insert(gr.String())
+		customGRs = append(customGRs, gr)
+	}
+	sort.Slice(customGRs, func(i, j int) bool { return customGRs[i].String() < customGRs[j].String() })
+
+	if len(customGRs) == 0 {
+		return encryptedGRs
+	}
+	ret := make([]schema.GroupResource, 0, len(encryptedGRs)+len(customGRs))
+	ret = append(ret, encryptedGRs...)
+	return append(ret, customGRs...)
+}
+
+// ShouldRunEncryptionControllers delegates to the wrapped provider.
+func (p *customResourceProvider) ShouldRunEncryptionControllers() (bool, error) {
+	return p.delegate.ShouldRunEncryptionControllers()
+}
+
+// HostedControlPlaneHealthy delegates to the wrapped provider, if it is management cluster aware.
+func (p *customResourceProvider) HostedControlPlaneHealthy() (bool, error) {
+	return hostedControlPlaneHealthy(p.delegate)
+}
+
+func crdEstablished(crd *apiextensionsv1.CustomResourceDefinition) bool {
+	for _, condition := range crd.Status.Conditions {
+		if condition.Type == apiextensionsv1.Established {
+			return condition.Status == apiextensionsv1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionslistersv1 "k8s.io/apiextensions-apiserver/pkg/client/listers/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestCustomResourceProvider(t *testing.T) {
+	managedGRs := []schema.GroupResource{
+		{Group: "", Resource: "secrets"},
+		{Group: "route.openshift.io", Resource: "routes"},
+	}
+	crd := func(name, group, plural string, encrypted, established bool) *apiextensionsv1.CustomResourceDefinition {
+		crd := &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+				Group: group,
+				Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: plural},
+			},
+		}
+		if encrypted {
+			crd.Labels = map[string]string{EncryptedCustomResourcesLabel: "true"}
+		}
+		status := apiextensionsv1.ConditionFalse
+		if established {
+			status = apiextensionsv1.ConditionTrue
+		}
+		crd.Status.Conditions = []apiextensionsv1.CustomResourceDefinitionCondition{{Type: apiextensionsv1.Established, Status: status}}
+		return crd
+	}
+
+	scenarios := []struct {
+		name                 string
+		crds                 []*apiextensionsv1.CustomResourceDefinition
+		expectedEncryptedGRs []schema.GroupResource
+	}{
+		{
+			name:                 "no custom resource definition",
+			expectedEncryptedGRs: managedGRs,
+		},
+		{
+			name: "labelled custom resources added in order",
+			crds: []*apiextensionsv1.CustomResourceDefinition{
+				crd("widgets.example.com", "example.com", "widgets", true, true),
+				crd("credentials.example.com", "example.com", "credentials", true, true),
+				crd("gadgets.example.com", "example.com", "gadgets", false, true),
+			},
+			expectedEncryptedGRs: append(append([]schema.GroupResource{}, managedGRs...),
+				schema.GroupResource{Group: "example.com", Resource: "credentials"},
+				schema.GroupResource{Group: "example.com", Resource: "widgets"},
+			),
+		},
+		{
+			name: "custom resources not established yet skipped",
+			crds: []*apiextensionsv1.CustomResourceDefinition{
+				crd("widgets.example.com", "example.com", "widgets", true, false),
+			},
+			expectedEncryptedGRs: managedGRs,
+		},
+		{
+			name: "managed resources not duplicated",
+			crds: []*apiextensionsv1.CustomResourceDefinition{
+				crd("routes.route.openshift.io", "route.openshift.io", "routes", true, true),
+			},
+			expectedEncryptedGRs: managedGRs,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, crd := range scenario.crds {
+				if err := indexer.Add(crd); err != nil {
+					t.Fatal(err)
+				}
+			}
+			target := NewCustomResourceProvider(newTestProvider(managedGRs), apiextensionslistersv1.NewCustomResourceDefinitionLister(indexer), EncryptedCustomResourcesSelector)
+
+			if actual := target.EncryptedGRs(); !reflect.DeepEqual(actual, scenario.expectedEncryptedGRs) {
+				t.Errorf("expected encrypted resources %v, got %v", scenario.expectedEncryptedGRs, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	operatorv1helpers "github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// EncryptionExcludedResourcesAnnotation is set on the operator CR to exclude group resources from encryption management.
+// The value is a comma separated list of group resources in the "resource.group" form, e.g. "configmaps,routes.route.openshift.io".
+//
+// Only resources managed by the operator can be excluded. An annotation with invalid entries is rejected as a whole
+// and reported via the EncryptionExclusionsDegraded condition. Excluding a resource has the same effect as removing it
+// from the list of resources returned by the Provider: already encrypted resources are kept in the encryption
+// configuration with identity as write key until they have been migrated back to identity, then they are dropped.
+const EncryptionExcludedResourcesAnnotation = "encryption.apiserver.operator.openshift.io/excluded-resources"
+
+// excludingProvider filters out the group resources excluded via EncryptionExcludedResourcesAnnotation.
+type excludingProvider struct {
+	delegate       Provider
+	operatorClient operatorv1helpers.OperatorClient
+}
+
+var _ Provider = &excludingProvider{}
+var _ ManagementClusterAwareProvider = &excludingProvider{}
+
+// NewExcludingProvider wraps the given provider so that resources listed in EncryptionExcludedResourcesAnnotation
+// on the operator CR are not encrypted.
+func NewExcludingProvider(delegate Provider, operatorClient operatorv1helpers.OperatorClient) Provider {
+	return &excludingProvider{delegate: delegate, operatorClient: operatorClient}
+}
+
+// EncryptedGRs returns the resources of the delegate provider without the excluded ones. If the exclusion annotation
+// is invalid, no resource is excluded.
+func (p *excludingProvider) EncryptedGRs() []schema.GroupResource {
+	encryptedGRs := p.delegate.EncryptedGRs()
+	excludedGRs, err := p.excludedGRs(encryptedGRs)
+	if err != nil {
+		// the condition controller reports the invalid annotation as degraded
+		klog.Warningf("ignoring the encryption exclusions because of invalid entries: %v", err)
+		return encryptedGRs
+	}
+	if len(excludedGRs) == 0 {
+		return encryptedGRs
+	}
+
+	excluded := migratedSet(excludedGRs)
+	ret := make([]schema.GroupResource, 0, len(encryptedGRs))
+	for _, gr := range encryptedGRs {
+		if !excluded.Has(gr.String()) {
+			ret = append(ret, gr)
+		}
+	}
+	return ret
+}
+
+// ShouldRunEncryptionControllers delegates to the wrapped provider.
+func (p *excludingProvider) ShouldRunEncryptionControllers() (bool, error) {
+	return p.delegate.ShouldRunEncryptionControllers()
+}
+
+// HostedControlPlaneHealthy delegates to the wrapped provider, if it is management cluster aware.
+func (p *excludingProvider) HostedControlPlaneHealthy() (bool, error) {
+	return hostedControlPlaneHealthy(p.delegate)
+}
+
+// excludedGRs parses the exclusion annotation. It returns the valid exclusions
+// along with an aggregated error describing the invalid ones.
+func (p *excludingProvider) excludedGRs(managedGRs []schema.GroupResource) ([]schema.GroupResource, error) {
+	meta, err := p.operatorClient.GetObjectMeta()
+	if err != nil {
+		return nil, err
+	}
+	value, ok := meta.Annotations[EncryptionExcludedResourcesAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	managed := migratedSet(managedGRs)
+	var excluded []schema.GroupResource
+	var errs []error
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		gr := schema.ParseGroupResource(entry)
+		if !managed.Has(gr.String()) {
+			errs = append(errs, fmt.Errorf("%q is not a resource managed by the encryption controllers", entry))
+			continue
+		}
+		excluded = append(excluded, gr)
+	}
+	return excluded, utilerrors.NewAggregate(errs)
+}
+
+// exclusionsCondition returns the EncryptionExclusionsDegraded condition listing the excluded resources.
+// It returns nil for providers that don't support exclusions.
+func exclusionsCondition(provider Provider) *operatorv1.OperatorCondition {
+	p, ok := provider.(*excludingProvider)
+	if !ok {
+		return nil
+	}
+
+	cond := &operatorv1.OperatorCondition{Type: "EncryptionExclusionsDegraded", Status: operatorv1.ConditionFalse, Reason: "AsExpected"}
+	excludedGRs, err := p.excludedGRs(p.delegate.EncryptedGRs())
+	if len(excludedGRs) > 0 {
+		cond.Reason = "ResourcesExcluded"
+		cond.Message = fmt.Sprintf("Resources excluded from encryption: %s", grString(excludedGRs))
+	}
+	if err != nil {
+		cond.Status = operatorv1.ConditionTrue
+		cond.Reason = "InvalidExclusions"
+		cond.Message = fmt.Sprintf("Invalid %s annotation, no resource is excluded from encryption: %v", EncryptionExcludedResourcesAnnotation, err)
+	}
+	return cond
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func TestExcludingProvider(t *testing.T) {
+	managedGRs := []schema.GroupResource{
+		{Group: "", Resource: "secrets"},
+		{Group: "", Resource: "configmaps"},
+		{Group: "route.openshift.io", Resource: "routes"},
+	}
+
+	scenarios := []struct {
+		name                  string
+		annotations           map[string]string
+		expectedEncryptedGRs  []schema.GroupResource
+		expectedConditionFunc func(t *testing.T, cond *operatorv1.OperatorCondition)
+	}{
+		{
+			name:                 "no annotation",
+			expectedEncryptedGRs: managedGRs,
+			expectedConditionFunc: func(t *testing.T, cond *operatorv1.OperatorCondition) {
+				if cond.Status != operatorv1.ConditionFalse || cond.Reason != "AsExpected" || len(cond.Message) > 0 {
+					t.Errorf("unexpected condition %#v", cond)
+				}
+			},
+		},
+		{
+			name:                 "core and non-core resources excluded",
+			annotations:          map[string]string{EncryptionExcludedResourcesAnnotation: "configmaps, routes.route.openshift.io"},
+			expectedEncryptedGRs: []schema.GroupResource{{Group: "", Resource: "secrets"}},
+			expectedConditionFunc: func(t *testing.T, cond *operatorv1.OperatorCondition) {
+				if cond.Status != operatorv1.ConditionFalse || cond.Reason != "ResourcesExcluded" || cond.Message != "Resources excluded from encryption: configmaps, routes.route.openshift.io" {
+					t.Errorf("unexpected condition %#v", cond)
+				}
+			},
+		},
+		{
+			name:                 "unmanaged resources reject the whole annotation",
+			annotations:          map[string]string{EncryptionExcludedResourcesAnnotation: "configmaps,oauthaccesstokens.oauth.openshift.io"},
+			expectedEncryptedGRs: managedGRs,
+			expectedConditionFunc: func(t *testing.T, cond *operatorv1.OperatorCondition) {
+				if cond.Status != operatorv1.ConditionTrue || cond.Reason != "InvalidExclusions" {
+					t.Errorf("unexpected condition %#v", cond)
+				}
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			operatorClient := v1helpers.NewFakeOperatorClientWithObjectMeta(&metav1.ObjectMeta{Name: "cluster", Annotations: scenario.annotations}, &operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			target := NewExcludingProvider(newTestProvider(managedGRs), operatorClient)
+
+			if actual := target.EncryptedGRs(); !reflect.DeepEqual(actual, scenario.expectedEncryptedGRs) {
+				t.Errorf("expected encrypted resources %v, got %v", scenario.expectedEncryptedGRs, actual)
+			}
+			scenario.expectedConditionFunc(t, exclusionsCondition(target))
+		})
+	}
+}
@@ -38,3 +38,12 @@ func (p *testProvider) EncryptedGRs() []schema.GroupResource {
 func (p *testProvider) ShouldRunEncryptionControllers() (bool, error) {
 	return true, nil
 }
+
+type testManagementClusterAwareProvider struct {
+	Provider
+	healthy bool
+}
+
+func (p *testManagementClusterAwareProvider) HostedControlPlaneHealthy() (bool, error) {
+	return p.healthy, nil
+}
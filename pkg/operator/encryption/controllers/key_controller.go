@@ -18,6 +18,7 @@ import (
 	apiserverv1 "k8s.io/apiserver/pkg/apis/config/v1"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 	"k8s.io/utils/pointer"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
@@ -25,6 +26,7 @@ import (
 	configv1informers "github.com/openshift/client-go/config/informers/externalversions/config/v1"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/libraryversion"
 	"github.com/openshift/library-go/pkg/operator/encryption/crypto"
 	"github.com/openshift/library-go/pkg/operator/encryption/secrets"
 	"github.com/openshift/library-go/pkg/operator/encryption/state"
@@ -70,6 +72,7 @@ type keyController struct {
 	secretClient             corev1client.SecretsGetter
 	provider                 Provider
 	preconditionsFulfilledFn preconditionsFulfilled
+	clock                    clock.PassiveClock
 
 	unsupportedConfigPrefix []string
 }
@@ -87,6 +90,7 @@ func NewKeyController(
 	secretClient corev1client.SecretsGetter,
 	encryptionSecretSelector metav1.ListOptions,
 	eventRecorder events.Recorder,
+	opts ...Option,
 ) factory.Controller {
 	c := &keyController{
 		operatorClient:  operatorClient,
@@ -101,6 +105,7 @@ func NewKeyController(
 		provider:                 provider,
 		preconditionsFulfilledFn: preconditionsFulfilledFn,
 		secretClient:             secretClient,
+		clock:                    newOptions(opts...).clock,
 	}
 
 	return factory.New().
@@ -173,8 +178,12 @@ func (c *keyController) checkAndCreateKeys(ctx context.Context, syncContext fact
 	// are missing.
 
 	var commonReason *string
+	encrypted := migratedSet(encryptedGRs)
 	for gr, grKeys := range desiredEncryptionState {
-		latestKeyID, internalReason, needed := needsNewKey(grKeys, currentMode, externalReason, encryptedGRs)
+		if !encrypted.Has(gr.String()) {
+			continue // the resource is being migrated back to identity, it needs no new key
+		}
+		latestKeyID, internalReason, needed := needsNewKey(grKeys, currentMode, externalReason, encryptedGRs, c.clock.Now())
 		if !needed {
 			continue
 		}
@@ -195,6 +204,16 @@ func (c *keyController) checkAndCreateKeys(ctx context.Context, syncContext fact
 	if !newKeyRequired {
 		return nil
 	}
+
+	// in external topologies don't start a rotation unless the hosted control plane is able to roll it out
+	if healthy, err := hostedControlPlaneHealthy(c.provider); err != nil {
+		return err
+	} else if !healthy {
+		klog.V(2).Infof("a new encryption key is required (%s) but the hosted control plane is not healthy, postponing key rotation", strings.Join(reasons, ", "))
+		syncContext.Queue().AddAfter(syncContext.QueueKey(), 2*time.Minute)
+		return nil
+	}
+
 	if commonReason != nil && len(*commonReason) > 0 && len(reasons) > 1 {
 		reasons = []string{*commonReason} // don't repeat reasons
 	}
@@ -248,6 +267,10 @@ func (c *keyController) generateKeySecret(keyID uint64, currentMode state.Mode,
 		Mode:           currentMode,
 		InternalReason: internalReason,
 		ExternalReason: externalReason,
+		Provenance: state.KeyProvenance{
+			ControllerVersion: libraryversion.Get(),
+			FIPSMode:          crypto.FIPSModeEnabled(),
+		},
 	}
 	return secrets.FromKeyState(c.component, ks)
 }
@@ -281,7 +304,7 @@ func (c *keyController) getCurrentModeAndExternalReason(ctx context.Context) (st
 
 // needsNewKey checks whether a new key must be created for the given resource. If true, it also returns the latest
 // used key ID and a reason string.
-func needsNewKey(grKeys state.GroupResourceState, currentMode state.Mode, externalReason string, encryptedGRs []schema.GroupResource) (uint64, string, bool) {
+func needsNewKey(grKeys state.GroupResourceState, currentMode state.Mode, externalReason string, encryptedGRs []schema.GroupResource, now time.Time) (uint64, string, bool) {
 	// we always need to have some encryption keys unless we are turned off
 	if len(grKeys.ReadKeys) == 0 {
 		return 0, "key-does-not-exist", currentMode != state.Identity
@@ -331,7 +354,7 @@ func needsNewKey(grKeys state.GroupResourceState, currentMode state.Mode, extern
 
 	// we check for encryptionSecretMigratedTimestamp set by migration controller to determine when migration completed
 	// this also generates back pressure for key rotation when migration takes a long time or was recently completed
-	return latestKeyID, "rotation-interval-has-passed", time.Since(latestKey.Migrated.Timestamp) > encryptionSecretMigrationInterval
+	return latestKeyID, "rotation-interval-has-passed", now.Sub(latestKey.Migrated.Timestamp) > encryptionSecretMigrationInterval
 }
 
 // TODO make this un-settable once set
@@ -17,6 +17,8 @@ import (
 	apiserverconfigv1 "k8s.io/apiserver/pkg/apis/config/v1"
 	"k8s.io/client-go/kubernetes/fake"
 	clientgotesting "k8s.io/client-go/testing"
+	"k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
 
 	configv1 "github.com/openshift/api/config/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
@@ -51,6 +53,10 @@ func TestKeyController(t *testing.T) {
 		validateFunc               func(ts *testing.T, actions []clientgotesting.Action, targetNamespace string, targetGRs []schema.GroupResource)
 		validateOperatorClientFunc func(ts *testing.T, operatorClient v1helpers.OperatorClient)
 		expectedError              error
+		// hostedControlPlaneUnhealthy makes the provider report an unhealthy control plane in the management cluster
+		hostedControlPlaneUnhealthy bool
+		// clock is the clock of the controller, the real clock when nil
+		clock clock.PassiveClock
 	}{
 		{
 			name: "no apiservers config",
@@ -115,6 +121,11 @@ func TestKeyController(t *testing.T) {
 						if !equality.Semantic.DeepEqual(actualSecret, expectedSecret) {
 							ts.Errorf(diff.ObjectDiff(expectedSecret, actualSecret))
 						}
+						for _, provenanceAnnotation := range []string{"encryption.apiserver.operator.openshift.io/controller-version", "encryption.apiserver.operator.openshift.io/fips-mode"} {
+							if len(actualSecret.Annotations[provenanceAnnotation]) == 0 {
+								ts.Errorf("expected the %s provenance annotation to be set", provenanceAnnotation)
+							}
+						}
 						if err := encryptiontesting.ValidateEncryptionKey(actualSecret); err != nil {
 							ts.Error(err)
 						}
@@ -128,6 +139,20 @@ func TestKeyController(t *testing.T) {
 			},
 		},
 
+		{
+			name: "no key is created when the hosted control plane is unhealthy",
+			targetGRs: []schema.GroupResource{
+				{Group: "", Resource: "secrets"},
+			},
+			targetNamespace: "kms",
+			expectedActions: []string{"list:pods:kms", "get:secrets:kms", "list:secrets:openshift-config-managed"},
+			initialObjects: []runtime.Object{
+				encryptiontesting.CreateDummyKubeAPIPod("kube-apiserver-1", "kms", "node-1"),
+			},
+			apiServerObjects:            []runtime.Object{apiServerWithAESCBC},
+			hostedControlPlaneUnhealthy: true,
+		},
+
 		{
 			name: "no-op when a valid write key exists, but is not migrated",
 			targetGRs: []schema.GroupResource{
@@ -206,6 +231,21 @@ func TestKeyController(t *testing.T) {
 			},
 		},
 
+		{
+			name: "creates a new write key because the rotation interval passed on the clock",
+			targetGRs: []schema.GroupResource{
+				{Group: "", Resource: "secrets"},
+			},
+			initialObjects: []runtime.Object{
+				encryptiontesting.CreateDummyKubeAPIPod("kube-apiserver-1", "kms", "node-1"),
+				encryptiontesting.CreateMigratedEncryptionKeySecretWithRawKey("kms", []schema.GroupResource{{Group: "", Resource: "secrets"}}, 7, []byte("61def964fb967f5d7c44a2af8dab6865"), time.Now()),
+			},
+			apiServerObjects: []runtime.Object{apiServerWithAESCBC},
+			targetNamespace:  "kms",
+			clock:            clocktesting.NewFakePassiveClock(time.Now().Add(encryptionSecretMigrationInterval + time.Hour)),
+			expectedActions:  []string{"list:pods:kms", "get:secrets:kms", "list:secrets:openshift-config-managed", "create:secrets:openshift-config-managed", "create:events:kms"},
+		},
+
 		{
 			name: "create a new write key when the previous key expired and another read key exists",
 			targetGRs: []schema.GroupResource{
@@ -369,9 +409,16 @@ func TestKeyController(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			provider := newTestProvider(scenario.targetGRs)
+			var provider Provider = newTestProvider(scenario.targetGRs)
+			if scenario.hostedControlPlaneUnhealthy {
+				provider = &testManagementClusterAwareProvider{Provider: provider, healthy: false}
+			}
 
-			target := NewKeyController(scenario.targetNamespace, nil, provider, deployer, alwaysFulfilledPreconditions, fakeOperatorClient, fakeApiServerClient, fakeApiServerInformer, kubeInformers, fakeSecretClient, scenario.encryptionSecretSelector, eventRecorder)
+			var opts []Option
+			if scenario.clock != nil {
+				opts = append(opts, WithClock(scenario.clock))
+			}
+			target := NewKeyController(scenario.targetNamespace, nil, provider, deployer, alwaysFulfilledPreconditions, fakeOperatorClient, fakeApiServerClient, fakeApiServerInformer, kubeInformers, fakeSecretClient, scenario.encryptionSecretSelector, eventRecorder, opts...)
 
 			// act
 			err = target.Sync(context.TODO(), factory.NewSyncContext("test", eventRecorder))
@@ -16,6 +16,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 
@@ -35,6 +36,10 @@ import (
 const (
 	// how long to wait until we retry a migration when it failed with unknown errors.
 	migrationRetryDuration = time.Minute * 5
+
+	// identityMigrationWriteKey is passed to the migrator as write key when resources that are not to be
+	// encrypted anymore are migrated back to identity.
+	identityMigrationWriteKey = "identity"
 )
 
 // The migrationController controller migrates resources to a new write key
@@ -68,6 +73,7 @@ type migrationController struct {
 	migrator                 migrators.Migrator
 	provider                 Provider
 	preconditionsFulfilledFn preconditionsFulfilled
+	clock                    clock.PassiveClock
 }
 
 func NewMigrationController(
@@ -82,6 +88,7 @@ func NewMigrationController(
 	secretClient corev1client.SecretsGetter,
 	encryptionSecretSelector metav1.ListOptions,
 	eventRecorder events.Recorder,
+	opts ...Option,
 ) factory.Controller {
 	c := &migrationController{
 		component:      component,
@@ -94,6 +101,7 @@ func NewMigrationController(
 		migrator:                 migrator,
 		provider:                 provider,
 		preconditionsFulfilledFn: preconditionsFulfilledFn,
+		clock:                    newOptions(opts...).clock,
 	}
 
 	return factory.New().ResyncEvery(time.Minute).WithSync(c.sync).WithInformers(
@@ -190,26 +198,36 @@ func (c *migrationController) migrateKeysIfNeededAndRevisionStable(ctx context.C
 	// we never want to migrate during an intermediate state because that could lead to one API server
 	// using a write key that another API server has not observed
 	// this could lead to etcd storing data that not all API servers can decrypt
+	encrypted := migratedSet(encryptedGRs)
 	var errs []error
 	for _, gr := range grs {
 		grActualKeys := currentState[gr]
-		if !grActualKeys.HasWriteKey() {
-			continue // no write key to migrate to
-		}
+		migrateTo, recordKey, setMigrated := grActualKeys.WriteKey.Key.Name, grActualKeys.WriteKey, setResourceMigrated
+		if !encrypted.Has(gr.String()) {
+			// the resource is not to be encrypted anymore. Migrate it back to identity before it is dropped from the config.
+			if !state.NeedsIdentityMigration(gr, grActualKeys) {
+				continue
+			}
+			migrateTo, recordKey, setMigrated = identityMigrationWriteKey, grActualKeys.ReadKeys[0], setResourceIdentityMigrated
+		} else {
+			if !grActualKeys.HasWriteKey() {
+				continue // no write key to migrate to
+			}
 
-		if alreadyMigrated, _, _ := state.MigratedFor([]schema.GroupResource{gr}, grActualKeys.WriteKey); alreadyMigrated {
-			continue
+			if alreadyMigrated, _, _ := state.MigratedFor([]schema.GroupResource{gr}, grActualKeys.WriteKey); alreadyMigrated {
+				continue
+			}
 		}
 
 		// idem-potent migration start
-		finished, result, when, err := c.migrator.EnsureMigration(gr, grActualKeys.WriteKey.Key.Name)
-		if err == nil && finished && result != nil && time.Since(when) > migrationRetryDuration {
+		finished, result, when, err := c.migrator.EnsureMigration(gr, migrateTo)
+		if err == nil && finished && result != nil && c.clock.Since(when) > migrationRetryDuration {
 			// last migration error is far enough ago. Prune and retry.
 			if err := c.migrator.PruneMigration(gr); err != nil {
 				errs = append(errs, err)
 				continue
 			}
-			finished, result, when, err = c.migrator.EnsureMigration(gr, grActualKeys.WriteKey.Key.Name)
+			finished, result, when, err = c.migrator.EnsureMigration(gr, migrateTo)
 
 		}
 		if err != nil {
@@ -227,7 +245,7 @@ func (c *migrationController) migrateKeysIfNeededAndRevisionStable(ctx context.C
 		}
 
 		// update secret annotations
-		oldWriteKey, err := secrets.FromKeyState(c.component, grActualKeys.WriteKey)
+		oldWriteKey, err := secrets.FromKeyState(c.component, recordKey)
 		if err != nil {
 			errs = append(errs, result)
 			continue
@@ -238,7 +256,7 @@ func (c *migrationController) migrateKeysIfNeededAndRevisionStable(ctx context.C
 				return fmt.Errorf("failed to get key secret %s/%s: %v", oldWriteKey.Namespace, oldWriteKey.Name, err)
 			}
 
-			changed, err := setResourceMigrated(gr, s)
+			changed, err := setMigrated(gr, s, c.clock.Now())
 			if err != nil {
 				return err
 			}
@@ -257,45 +275,90 @@ func (c *migrationController) migrateKeysIfNeededAndRevisionStable(ctx context.C
 	return migratingResources, errors.NewAggregate(errs)
 }
 
-func setResourceMigrated(gr schema.GroupResource, s *corev1.Secret) (bool, error) {
-	migratedGRs := secrets.MigratedGroupResources{}
-	if existing, found := s.Annotations[secrets.EncryptionSecretMigratedResources]; found {
-		if err := json.Unmarshal([]byte(existing), &migratedGRs); err != nil {
-			// ignore error and just start fresh, causing some more migration at worst
-			migratedGRs = secrets.MigratedGroupResources{}
-		}
-	}
-
-	alreadyMigrated := false
-	for _, existingGR := range migratedGRs.Resources {
-		if existingGR == gr {
-			alreadyMigrated = true
-			break
-		}
-	}
+func setResourceMigrated(gr schema.GroupResource, s *corev1.Secret, now time.Time) (bool, error) {
+	alreadyMigrated := hasGroupResourceAnnotation(s, secrets.EncryptionSecretMigratedResources, gr)
+	// forget an earlier migration back to identity, the resource has data encrypted with the key again
+	identityMigrated := hasGroupResourceAnnotation(s, secrets.EncryptionSecretIdentityMigratedResources, gr)
 
 	// update timestamp, if missing or first migration of gr
-	if _, found := s.Annotations[secrets.EncryptionSecretMigratedTimestamp]; found && alreadyMigrated {
+	if _, found := s.Annotations[secrets.EncryptionSecretMigratedTimestamp]; found && alreadyMigrated && !identityMigrated {
 		return false, nil
 	}
 	if s.Annotations == nil {
 		s.Annotations = map[string]string{}
 	}
-	s.Annotations[secrets.EncryptionSecretMigratedTimestamp] = time.Now().Format(time.RFC3339)
+	s.Annotations[secrets.EncryptionSecretMigratedTimestamp] = now.Format(time.RFC3339)
 
-	// update resource list
+	// update resource lists
 	if !alreadyMigrated {
-		migratedGRs.Resources = append(migratedGRs.Resources, gr)
-		bs, err := json.Marshal(migratedGRs)
-		if err != nil {
-			return false, fmt.Errorf("failed to marshal %s annotation value %#v for key secret %s/%s", secrets.EncryptionSecretMigratedResources, migratedGRs, s.Namespace, s.Name)
+		if err := setGroupResourceAnnotation(s, secrets.EncryptionSecretMigratedResources, gr, true); err != nil {
+			return false, err
+		}
+	}
+	if identityMigrated {
+		if err := setGroupResourceAnnotation(s, secrets.EncryptionSecretIdentityMigratedResources, gr, false); err != nil {
+			return false, err
 		}
-		s.Annotations[secrets.EncryptionSecretMigratedResources] = string(bs)
 	}
 
 	return true, nil
 }
 
+// setResourceIdentityMigrated records on the most recent read key of a resource that is not to be encrypted anymore
+// that the resource has been migrated back to identity, i.e. that its read keys are not needed anymore.
+func setResourceIdentityMigrated(gr schema.GroupResource, s *corev1.Secret, _ time.Time) (bool, error) {
+	if hasGroupResourceAnnotation(s, secrets.EncryptionSecretIdentityMigratedResources, gr) {
+		return false, nil
+	}
+	if err := setGroupResourceAnnotation(s, secrets.EncryptionSecretIdentityMigratedResources, gr, true); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func hasGroupResourceAnnotation(s *corev1.Secret, annotation string, gr schema.GroupResource) bool {
+	grs := secrets.MigratedGroupResources{}
+	if existing, found := s.Annotations[annotation]; found {
+		if err := json.Unmarshal([]byte(existing), &grs); err != nil {
+			// ignore error and just start fresh, causing some more migration at worst
+			return false
+		}
+	}
+	return grs.HasResource(gr)
+}
+
+// setGroupResourceAnnotation adds the resource to or removes it from the resource list in the given annotation.
+// An empty list is kept as such because applying the secret does not remove annotations.
+func setGroupResourceAnnotation(s *corev1.Secret, annotation string, gr schema.GroupResource, add bool) error {
+	grs := secrets.MigratedGroupResources{}
+	if existing, found := s.Annotations[annotation]; found {
+		if err := json.Unmarshal([]byte(existing), &grs); err != nil {
+			// ignore error and just start fresh, causing some more migration at worst
+			grs = secrets.MigratedGroupResources{}
+		}
+	}
+
+	resources := make([]schema.GroupResource, 0, len(grs.Resources)+1)
+	for _, existingGR := range grs.Resources {
+		if existingGR != gr {
+			resources = append(resources, existingGR)
+		}
+	}
+	if add {
+		resources = append(resources, gr)
+	}
+
+	bs, err := json.Marshal(secrets.MigratedGroupResources{Resources: resources})
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s annotation value %#v for key secret %s/%s", annotation, resources, s.Namespace, s.Name)
+	}
+	if s.Annotations == nil {
+		s.Annotations = map[string]string{}
+	}
+	s.Annotations[annotation] = string(bs)
+	return nil
+}
+
 // groupToHumanReadable extracts a group from gr and makes it more readable, for example it converts an empty group to "core"
 // Note: do not use it to get resources from the server only when printing to a log file
 func groupToHumanReadable(gr schema.GroupResource) string {
@@ -274,6 +274,100 @@ func TestMigrationController(t *testing.T) {
 			},
 		},
 
+		{
+			name:            "configmaps are not encrypted anymore => they are migrated to identity",
+			targetNamespace: "kms",
+			targetGRs: []schema.GroupResource{
+				{Group: "", Resource: "secrets"},
+			},
+			targetAPIResources: []metav1.APIResource{
+				{
+					Name:       "secrets",
+					Namespaced: true,
+					Group:      "",
+					Version:    "v1",
+				},
+				{
+					Name:       "configmaps",
+					Namespaced: true,
+					Group:      "",
+					Version:    "v1",
+				},
+			},
+			initialResources: []runtime.Object{
+				encryptiontesting.CreateDummyKubeAPIPod("kube-apiserver-1", "kms", "node-1"),
+			},
+			initialSecrets: []*corev1.Secret{
+				func() *corev1.Secret {
+					s := encryptiontesting.CreateMigratedEncryptionKeySecretWithRawKey("kms", []schema.GroupResource{{Group: "", Resource: "secrets"}}, 1, []byte("71ea7c91419a68fd1224f88d50316b4e"), time.Now())
+					s.Kind = "Secret"
+					s.APIVersion = corev1.SchemeGroupVersion.String()
+					return s
+				}(),
+				func() *corev1.Secret {
+					keysResForSecrets := encryptiontesting.EncryptionKeysResourceTuple{
+						Resource: "secrets",
+						Keys: []apiserverconfigv1.Key{
+							{
+								Name:   "1",
+								Secret: "NzFlYTdjOTE0MTlhNjhmZDEyMjRmODhkNTAzMTZiNGU=",
+							},
+						},
+					}
+
+					ec := encryptiontesting.CreateEncryptionCfgWithWriteKey([]encryptiontesting.EncryptionKeysResourceTuple{keysResForSecrets})
+					retiring := encryptiontesting.CreateEncryptionCfgNoWriteKey("1", "NzFlYTdjOTE0MTlhNjhmZDEyMjRmODhkNTAzMTZiNGU=", "configmaps")
+					ec.Resources = append(retiring.Resources, ec.Resources...)
+					ecs := createEncryptionCfgSecret(t, "kms", "1", ec)
+					ecs.APIVersion = corev1.SchemeGroupVersion.String()
+
+					return ecs
+				}(),
+			},
+			migratorEnsureReplies: map[schema.GroupResource]map[string]finishedResultErr{
+				{Group: "", Resource: "configmaps"}: {"identity": {finished: true}},
+			},
+			expectedActions: []string{
+				"list:pods:kms",
+				"get:secrets:kms",
+				"list:secrets:openshift-config-managed",
+				"list:secrets:openshift-config-managed",
+				"get:secrets:openshift-config-managed",
+				"get:secrets:openshift-config-managed",
+				"update:secrets:openshift-config-managed",
+				"create:events:operator",
+			},
+			expectedMigratorCalls: []string{
+				"ensure:configmaps:identity",
+			},
+			validateFunc: func(ts *testing.T, actionsKube []clientgotesting.Action, initialSecrets []*corev1.Secret, targetGRs []schema.GroupResource, unstructuredObjs []runtime.Object) {
+				for _, action := range actionsKube {
+					if !action.Matches("update", "secrets") {
+						continue
+					}
+					actualSecret := action.(clientgotesting.UpdateAction).GetObject().(*corev1.Secret)
+					if v := actualSecret.Annotations[secrets.EncryptionSecretIdentityMigratedResources]; v != `{"resources":[{"Group":"","Resource":"configmaps"}]}` {
+						ts.Errorf("unexpected %s annotation on %s/%s: %q", secrets.EncryptionSecretIdentityMigratedResources, actualSecret.Namespace, actualSecret.Name, v)
+					}
+					return
+				}
+				ts.Errorf("missing update of the key secret")
+			},
+			validateOperatorClientFunc: func(ts *testing.T, operatorClient v1helpers.OperatorClient) {
+				expectedConditions := []operatorv1.OperatorCondition{
+					{
+						Type:   "EncryptionMigrationControllerDegraded",
+						Status: "False",
+					},
+					{
+						Type:   "EncryptionMigrationControllerProgressing",
+						Status: "False",
+					},
+				}
+				encryptiontesting.ValidateOperatorClientConditions(ts, operatorClient, expectedConditions)
+			},
+		},
+
 		{
 			name:            "all migrations are finished",
 			targetNamespace: "kms",
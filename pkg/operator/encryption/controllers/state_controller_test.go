@@ -633,6 +633,9 @@ func TestStateController(t *testing.T) {
 					},
 				}
 				ec := encryptiontesting.CreateEncryptionCfgWithWriteKey([]encryptiontesting.EncryptionKeysResourceTuple{keysRes})
+				// config maps are written in plain text, but can still be read until they are migrated to identity
+				retiring := encryptiontesting.CreateEncryptionCfgNoWriteKey("34", "MTcxNTgyYTBmY2Q2YzVmZGI2NWNiZjVhM2U5MjQ5ZDc=", "configmaps")
+				ec.Resources = append(retiring.Resources, ec.Resources...)
 				return ec
 			}(),
 			validateFunc: func(ts *testing.T, actions []clientgotesting.Action, destName string, expectedEncryptionCfg *apiserverconfigv1.EncryptionConfiguration) {
@@ -0,0 +1,18 @@
+package crypto
+
+import (
+	"os"
+	"strings"
+)
+
+// fipsEnabledPath is the kernel setting which is 1 when the host runs in FIPS mode.
+var fipsEnabledPath = "/proc/sys/crypto/fips_enabled"
+
+// FIPSModeEnabled returns whether the host runs in FIPS mode.
+func FIPSModeEnabled() bool {
+	data, err := os.ReadFile(fipsEnabledPath)
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(data)) == "1"
+}
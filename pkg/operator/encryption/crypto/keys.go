@@ -2,6 +2,8 @@ package crypto
 
 import (
 	"crypto/rand"
+	"fmt"
+	"math"
 
 	"github.com/openshift/library-go/pkg/operator/encryption/state"
 )
@@ -26,3 +28,43 @@ func NewAES256Key() []byte {
 func NewIdentityKey() []byte {
 	return make([]byte, 16) // the key is not used to perform encryption but must be a valid AES key
 }
+
+const (
+	// minKeyLength is the minimum length of the keys of the encrypting modes, the length of the generated keys.
+	minKeyLength = 32
+	// minKeyEntropy is the minimum Shannon entropy, in bits per byte, of the keys of the encrypting modes. The entropy
+	// of random keys is close to log2(len(key)), a key below the minimum is corrupted.
+	minKeyEntropy = 2.0
+)
+
+// ValidateKey returns an error when the key of the given mode is too short or its entropy too low to have been
+// generated by ModeToNewKeyFunc. The keys of the identity mode are not used to encrypt, they are not validated.
+func ValidateKey(mode state.Mode, key []byte) error {
+	if mode == state.Identity {
+		return nil
+	}
+	if len(key) < minKeyLength {
+		return fmt.Errorf("key of mode %q has %d bytes, at least %d are required", mode, len(key), minKeyLength)
+	}
+	if entropy := shannonEntropy(key); entropy < minKeyEntropy {
+		return fmt.Errorf("key of mode %q has an entropy of %.2f bits per byte, at least %.2f are required", mode, entropy, minKeyEntropy)
+	}
+	return nil
+}
+
+// shannonEntropy returns the Shannon entropy of the distribution of the bytes of data, in bits per byte.
+func shannonEntropy(data []byte) float64 {
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+	var entropy float64
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / float64(len(data))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,51 @@
+package crypto
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/operator/encryption/state"
+)
+
+func TestValidateGeneratedKeys(t *testing.T) {
+	for mode, newKeyFunc := range ModeToNewKeyFunc {
+		if err := ValidateKey(mode, newKeyFunc()); err != nil {
+			t.Errorf("expected the generated key of mode %q to be valid, got %v", mode, err)
+		}
+	}
+	if err := ValidateKey(state.AESCBC, []byte("abababababababababababababababab")); err == nil {
+		t.Errorf("expected a key of low entropy to be invalid")
+	}
+}
+
+func TestFIPSModeEnabled(t *testing.T) {
+	defer func(path string) { fipsEnabledPath = path }(fipsEnabledPath)
+
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name     string
+		content  *string
+		expected bool
+	}{
+		{name: "enabled", content: stringPtr("1\n"), expected: true},
+		{name: "disabled", content: stringPtr("0\n")},
+		{name: "unsupported"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fipsEnabledPath = filepath.Join(dir, tc.name)
+			if tc.content != nil {
+				if err := os.WriteFile(fipsEnabledPath, []byte(*tc.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if actual := FIPSModeEnabled(); actual != tc.expected {
+				t.Errorf("expected FIPS mode %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
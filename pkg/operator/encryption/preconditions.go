@@ -9,9 +9,87 @@ import (
 	operatorv1helpers "github.com/openshift/library-go/pkg/operator/v1helpers"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
 	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
 )
 
+// Precondition determines whether the encryption controllers are allowed to synchronise.
+// Operators running in external topologies (i.e. where the operand apiserver and etcd
+// don't run in the cluster the operator manages) can provide additional preconditions
+// to NewControllers, for example to wait for a hosted control plane to become healthy.
+type Precondition interface {
+	// PreconditionFulfilled indicates whether all prerequisites are met and the controllers can Sync.
+	PreconditionFulfilled() (bool, error)
+}
+
+// PreconditionFunc adapts an ordinary function to the Precondition interface.
+type PreconditionFunc func() (bool, error)
+
+// PreconditionFulfilled calls f().
+func (f PreconditionFunc) PreconditionFulfilled() (bool, error) {
+	return f()
+}
+
+// unionPrecondition is fulfilled only when all of its preconditions are fulfilled.
+type unionPrecondition []Precondition
+
+// PreconditionFulfilled evaluates the preconditions in order and stops at the first one that is not fulfilled.
+func (u unionPrecondition) PreconditionFulfilled() (bool, error) {
+	for _, precondition := range u {
+		if fulfilled, err := precondition.PreconditionFulfilled(); err != nil || !fulfilled {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+type deploymentAvailablePrecondition struct {
+	namespace        string
+	name             string
+	deploymentLister appsv1listers.DeploymentNamespaceLister
+}
+
+// NewDeploymentAvailablePrecondition returns a precondition that is fulfilled only when the given deployment
+// has rolled out completely and all of its replicas are available.
+//
+// It is meant for topologies where the operand apiserver runs as a deployment in a management cluster
+// (e.g. a hosted control plane), so that key rotation and migration are not started while that apiserver is unhealthy.
+// The lister must be backed by an informer running against the management cluster.
+func NewDeploymentAvailablePrecondition(deploymentLister appsv1listers.DeploymentLister, namespace, name string) Precondition {
+	return &deploymentAvailablePrecondition{
+		namespace:        namespace,
+		name:             name,
+		deploymentLister: deploymentLister.Deployments(namespace),
+	}
+}
+
+// PreconditionFulfilled checks that the deployment has been observed by its controller, is fully updated and available.
+func (pc *deploymentAvailablePrecondition) PreconditionFulfilled() (bool, error) {
+	deployment, err := pc.deploymentLister.Get(pc.name)
+	if errors.IsNotFound(err) {
+		klog.V(4).Infof("deployment %s/%s not found, encryption controllers will wait", pc.namespace, pc.name)
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if deployment.Generation != deployment.Status.ObservedGeneration {
+		return false, nil // the rollout has not been observed yet
+	}
+
+	desiredReplicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desiredReplicas = *deployment.Spec.Replicas
+	}
+	if deployment.Status.UpdatedReplicas != desiredReplicas || deployment.Status.AvailableReplicas != desiredReplicas || deployment.Status.UnavailableReplicas > 0 {
+		klog.V(4).Infof("deployment %s/%s is not available yet (desired=%d, updated=%d, available=%d), encryption controllers will wait",
+			pc.namespace, pc.name, desiredReplicas, deployment.Status.UpdatedReplicas, deployment.Status.AvailableReplicas)
+		return false, nil
+	}
+	return true, nil
+}
+
 type preconditionChecker struct {
 	component                string
 	encryptionSecretSelector labels.Selector
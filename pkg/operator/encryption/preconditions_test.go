@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"testing"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 
@@ -106,3 +108,69 @@ func TestEncryptionEnabledPrecondition(t *testing.T) {
 		})
 	}
 }
+
+func TestDeploymentAvailablePrecondition(t *testing.T) {
+	replicas := int32(3)
+	deployment := func(modify func(*appsv1.Deployment)) *appsv1.Deployment {
+		d := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-apiserver", Namespace: "clusters-hosted", Generation: 2},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, UpdatedReplicas: 3, AvailableReplicas: 3},
+		}
+		if modify != nil {
+			modify(d)
+		}
+		return d
+	}
+
+	scenarios := []struct {
+		name                           string
+		existingDeployment             *appsv1.Deployment
+		expectedPreconditionsToBeReady bool
+	}{
+		{
+			name: "deployment missing",
+		},
+		{
+			name:                           "deployment available",
+			existingDeployment:             deployment(nil),
+			expectedPreconditionsToBeReady: true,
+		},
+		{
+			name:               "new generation not observed",
+			existingDeployment: deployment(func(d *appsv1.Deployment) { d.Generation = 3 }),
+		},
+		{
+			name:               "rollout in progress",
+			existingDeployment: deployment(func(d *appsv1.Deployment) { d.Status.UpdatedReplicas = 2 }),
+		},
+		{
+			name: "replica unavailable",
+			existingDeployment: deployment(func(d *appsv1.Deployment) {
+				d.Status.AvailableReplicas = 2
+				d.Status.UnavailableReplicas = 1
+			}),
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if scenario.existingDeployment != nil {
+				indexer.Add(scenario.existingDeployment)
+			}
+
+			target := unionPrecondition{
+				PreconditionFunc(func() (bool, error) { return true, nil }),
+				NewDeploymentAvailablePrecondition(appsv1listers.NewDeploymentLister(indexer), "clusters-hosted", "kube-apiserver"),
+			}
+			preconditionsReady, err := target.PreconditionFulfilled()
+			if err != nil {
+				t.Fatalf("unexpected error was returned, err = %v", err)
+			}
+			if scenario.expectedPreconditionsToBeReady != preconditionsReady {
+				t.Errorf("expected precondition to be ready = %v but got ready = %v", scenario.expectedPreconditionsToBeReady, preconditionsReady)
+			}
+		})
+	}
+}
@@ -14,6 +14,7 @@ import (
 	apiserverconfigv1 "k8s.io/apiserver/pkg/apis/config/v1"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 
+	"github.com/openshift/library-go/pkg/operator/encryption/crypto"
 	"github.com/openshift/library-go/pkg/operator/encryption/state"
 )
 
@@ -51,12 +52,30 @@ func ToKeyState(s *corev1.Secret) (state.KeyState, error) {
 		key.Migrated.Resources = migrated.Resources
 	}
 
+	if v, ok := s.Annotations[EncryptionSecretIdentityMigratedResources]; ok && len(v) > 0 {
+		migrated := &MigratedGroupResources{}
+		if err := json.Unmarshal([]byte(v), migrated); err != nil {
+			return state.KeyState{}, fmt.Errorf("secret %s/%s has invalid %s annotation: %v", s.Namespace, s.Name, EncryptionSecretIdentityMigratedResources, err)
+		}
+		key.IdentityMigratedResources = migrated.Resources
+	}
+
 	if v, ok := s.Annotations[encryptionSecretInternalReason]; ok && len(v) > 0 {
 		key.InternalReason = v
 	}
 	if v, ok := s.Annotations[encryptionSecretExternalReason]; ok && len(v) > 0 {
 		key.ExternalReason = v
 	}
+	if v, ok := s.Annotations[encryptionSecretControllerVersion]; ok && len(v) > 0 {
+		key.Provenance.ControllerVersion = v
+	}
+	if v, ok := s.Annotations[encryptionSecretFIPSMode]; ok && len(v) > 0 {
+		fipsMode, err := strconv.ParseBool(v)
+		if err != nil {
+			return state.KeyState{}, fmt.Errorf("secret %s/%s has invalid %s annotation: %v", s.Namespace, s.Name, encryptionSecretFIPSMode, err)
+		}
+		key.Provenance.FIPSMode = fipsMode
+	}
 
 	keyMode := state.Mode(s.Annotations[encryptionSecretMode])
 	switch keyMode {
@@ -68,6 +87,9 @@ func ToKeyState(s *corev1.Secret) (state.KeyState, error) {
 	if keyMode != state.Identity && len(data) == 0 {
 		return state.KeyState{}, fmt.Errorf("secret %s/%s of mode %q must have non-empty key", s.Namespace, s.Name, keyMode)
 	}
+	if err := crypto.ValidateKey(keyMode, data); err != nil {
+		return state.KeyState{}, fmt.Errorf("secret %s/%s has invalid key: %v", s.Namespace, s.Name, err)
+	}
 
 	return key, nil
 }
@@ -101,6 +123,10 @@ func FromKeyState(component string, ks state.KeyState) (*corev1.Secret, error) {
 		Type: corev1.SecretTypeOpaque,
 	}
 
+	if len(ks.Provenance.ControllerVersion) > 0 {
+		s.Annotations[encryptionSecretControllerVersion] = ks.Provenance.ControllerVersion
+		s.Annotations[encryptionSecretFIPSMode] = strconv.FormatBool(ks.Provenance.FIPSMode)
+	}
 	if !ks.Migrated.Timestamp.IsZero() {
 		s.Annotations[EncryptionSecretMigratedTimestamp] = ks.Migrated.Timestamp.Format(time.RFC3339)
 	}
@@ -112,6 +138,14 @@ func FromKeyState(component string, ks state.KeyState) (*corev1.Secret, error) {
 		}
 		s.Annotations[EncryptionSecretMigratedResources] = string(bs)
 	}
+	if len(ks.IdentityMigratedResources) > 0 {
+		migrated := MigratedGroupResources{Resources: ks.IdentityMigratedResources}
+		bs, err := json.Marshal(migrated)
+		if err != nil {
+			return nil, err
+		}
+		s.Annotations[EncryptionSecretIdentityMigratedResources] = string(bs)
+	}
 
 	return s, nil
 }
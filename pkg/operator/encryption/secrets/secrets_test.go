@@ -27,7 +27,7 @@ func TestRoundtrip(t *testing.T) {
 			ks: state.KeyState{
 				Key: v1.Key{
 					Name:   "54",
-					Secret: base64.StdEncoding.EncodeToString([]byte("abcdef")),
+					Secret: base64.StdEncoding.EncodeToString([]byte("71ea7c91419a68fd1224f88d50316b4e")),
 				},
 				Backed: true, // this will be set by ToKeyState()
 				Mode:   "aescbc",
@@ -41,6 +41,10 @@ func TestRoundtrip(t *testing.T) {
 				},
 				InternalReason: "internal",
 				ExternalReason: "external",
+				Provenance: state.KeyProvenance{
+					ControllerVersion: "v0.0.0-test",
+					FIPSMode:          true,
+				},
 			},
 		},
 		{
@@ -49,7 +53,7 @@ func TestRoundtrip(t *testing.T) {
 			ks: state.KeyState{
 				Key: v1.Key{
 					Name:   "54",
-					Secret: base64.StdEncoding.EncodeToString([]byte("abcdef")),
+					Secret: base64.StdEncoding.EncodeToString([]byte("71ea7c91419a68fd1224f88d50316b4e")),
 				},
 				Backed: true, // this will be set by ToKeyState()
 				Mode:   "aescbc",
@@ -61,7 +65,7 @@ func TestRoundtrip(t *testing.T) {
 			ks: state.KeyState{
 				Key: v1.Key{
 					Name:   "54",
-					Secret: base64.StdEncoding.EncodeToString([]byte("abcdef")),
+					Secret: base64.StdEncoding.EncodeToString([]byte("71ea7c91419a68fd1224f88d50316b4e")),
 				},
 				Backed: true, // this will be set by ToKeyState()
 				Mode:   "aesgcm",
@@ -83,7 +87,7 @@ func TestRoundtrip(t *testing.T) {
 			ks: state.KeyState{
 				Key: v1.Key{
 					Name:   "54",
-					Secret: base64.StdEncoding.EncodeToString([]byte("abcdef")),
+					Secret: base64.StdEncoding.EncodeToString([]byte("71ea7c91419a68fd1224f88d50316b4e")),
 				},
 				Backed: true, // this will be set by ToKeyState()
 				Mode:   "aesgcm",
@@ -128,3 +132,62 @@ func TestRoundtrip(t *testing.T) {
 		})
 	}
 }
+
+func TestToKeyStateRejectsInvalidKeys(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          state.Mode
+		key           []byte
+		fipsMode      string
+		expectedError string
+	}{
+		{
+			name:          "truncated",
+			mode:          state.AESCBC,
+			key:           []byte("71ea7c91419a68fd"),
+			expectedError: `secret openshift-config-managed/encryption-key-kms-1 has invalid key: key of mode "aescbc" has 16 bytes, at least 32 are required`,
+		},
+		{
+			name:          "zeroed",
+			mode:          state.SecretBox,
+			key:           make([]byte, 32),
+			expectedError: `secret openshift-config-managed/encryption-key-kms-1 has invalid key: key of mode "secretbox" has an entropy of 0.00 bits per byte, at least 2.00 are required`,
+		},
+		{
+			name:          "invalid fips mode",
+			mode:          state.AESGCM,
+			key:           []byte("71ea7c91419a68fd1224f88d50316b4e"),
+			fipsMode:      "maybe",
+			expectedError: `secret openshift-config-managed/encryption-key-kms-1 has invalid encryption.apiserver.operator.openshift.io/fips-mode annotation: strconv.ParseBool: parsing "maybe": invalid syntax`,
+		},
+		{
+			name: "identity",
+			mode: state.Identity,
+			key:  make([]byte, 16),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := FromKeyState("kms", state.KeyState{
+				Key:  v1.Key{Name: "1", Secret: base64.StdEncoding.EncodeToString(tt.key)},
+				Mode: tt.mode,
+			})
+			if err != nil {
+				t.Fatalf("unexpected FromKeyState() error: %v", err)
+			}
+			if len(tt.fipsMode) > 0 {
+				s.Annotations[encryptionSecretFIPSMode] = tt.fipsMode
+			}
+			_, err = ToKeyState(s)
+			if len(tt.expectedError) == 0 {
+				if err != nil {
+					t.Errorf("unexpected ToKeyState() error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.expectedError {
+				t.Errorf("expected error %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
@@ -24,6 +24,9 @@ const (
 	// The list of resources that were migrated when encryptionSecretMigratedTimestamp was set.
 	// See the MigratedGroupResources struct below to understand the JSON encoding used.
 	EncryptionSecretMigratedResources = "encryption.apiserver.operator.openshift.io/migrated-resources"
+	// The list of resources that were migrated from this key back to identity after they stopped being encrypted.
+	// It uses the same JSON encoding as EncryptionSecretMigratedResources.
+	EncryptionSecretIdentityMigratedResources = "encryption.apiserver.operator.openshift.io/identity-migrated-resources"
 
 	// encryptionSecretMode is the annotation that determines how the provider associated with a given key is
 	// configured.  For example, a key could be used with AES-CBC or Secretbox.  This allows for algorithm
@@ -41,6 +44,15 @@ const (
 	// determine if a new key should be created even if encryptionSecretMigrationInterval has not been reached.
 	encryptionSecretExternalReason = "encryption.apiserver.operator.openshift.io/external-reason"
 
+	// encryptionSecretControllerVersion is the annotation that records the version of library-go the key minting
+	// controller which generated the key was built with.  Together with encryptionSecretFIPSMode and the reasons,
+	// it gives auditors the provenance of the key.
+	encryptionSecretControllerVersion = "encryption.apiserver.operator.openshift.io/controller-version"
+
+	// encryptionSecretFIPSMode is the annotation that records whether the host of the key minting controller was
+	// in FIPS mode when it generated the key.
+	encryptionSecretFIPSMode = "encryption.apiserver.operator.openshift.io/fips-mode"
+
 	// In the data field of the secret API object, this (map) key is used to hold the actual encryption key
 	// (i.e. for AES-CBC mode the value associated with this map key is 32 bytes of random noise).
 	EncryptionSecretKeyDataKey = "encryption.apiserver.operator.openshift.io-key"
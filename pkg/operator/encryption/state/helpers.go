@@ -34,6 +34,34 @@ func MigratedFor(grs []schema.GroupResource, km KeyState) (ok bool, missing []sc
 	return true, nil, ""
 }
 
+// NeedsIdentityMigration returns whether a resource which is not to be encrypted anymore might still have data
+// persisted with one of its read keys, i.e. whether it must be migrated to identity before it can be dropped
+// from the encryption config.
+func NeedsIdentityMigration(gr schema.GroupResource, grState GroupResourceState) bool {
+	if len(grState.ReadKeys) == 0 {
+		return false
+	}
+
+	latestKey := grState.ReadKeys[0]
+	if latestKey.Mode == Identity {
+		if migrated, _, _ := MigratedFor([]schema.GroupResource{gr}, latestKey); migrated {
+			return false
+		}
+	}
+	for _, mgr := range latestKey.IdentityMigratedResources {
+		if mgr == gr {
+			return false
+		}
+	}
+
+	for _, rk := range grState.ReadKeys {
+		if rk.Mode != Identity {
+			return true
+		}
+	}
+	return false
+}
+
 // KeysWithPotentiallyPersistedDataAndNextReadKey returns the minimal, recent secrets which have migrated all given GRs.
 func KeysWithPotentiallyPersistedDataAndNextReadKey(grs []schema.GroupResource, recentFirstSortedKeys []KeyState) []KeyState {
 	for i, k := range recentFirstSortedKeys {
@@ -36,10 +36,23 @@ type KeyState struct {
 	// described whether it is backed by a secret.
 	Backed   bool
 	Migrated MigrationState
+	// the resources that were migrated off this key back to identity after they stopped being encrypted.
+	IdentityMigratedResources []schema.GroupResource
 	// some controller logic caused this secret to be created by the key controller.
 	InternalReason string
 	// the user via unsupportConfigOverrides.encryption.reason triggered this key.
 	ExternalReason string
+	// how the key controller generated this key.
+	Provenance KeyProvenance
+}
+
+// KeyProvenance records how a key was generated, for the auditors. The reason of the generation is recorded by the
+// InternalReason and the ExternalReason of the key. The provenance is empty for the keys generated before it was recorded.
+type KeyProvenance struct {
+	// the version of library-go the key controller was built with.
+	ControllerVersion string
+	// whether the host of the key controller was in FIPS mode.
+	FIPSMode bool
 }
 
 type MigrationState struct {
@@ -0,0 +1,153 @@
+package statemachine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/openshift/library-go/pkg/operator/encryption/state"
+)
+
+// ModeTransitionPhase describes how far a switch of the encryption type on a live cluster
+// (e.g. from aescbc to aesgcm) has progressed. The phases are passed in order:
+//
+//  1. KeyCreation: the key controller has yet to create a key of the new type.
+//  2. ReadKeyRollout: a combined configuration with providers of both types is rolled out,
+//     the new key is only a read-key so that every instance is able to read data written with it.
+//  3. WriteKeySwitch: the new key becomes the write-key on all instances.
+//  4. Migration: all resources are rewritten with the new key.
+//  5. OldProviderRetirement: the providers of the old type are removed from the configuration.
+type ModeTransitionPhase string
+
+const (
+	ModeTransitionKeyCreation           ModeTransitionPhase = "KeyCreation"
+	ModeTransitionReadKeyRollout        ModeTransitionPhase = "ReadKeyRollout"
+	ModeTransitionWriteKeySwitch        ModeTransitionPhase = "WriteKeySwitch"
+	ModeTransitionMigration             ModeTransitionPhase = "Migration"
+	ModeTransitionOldProviderRetirement ModeTransitionPhase = "OldProviderRetirement"
+)
+
+// ModeTransition describes an ongoing switch of the encryption type.
+type ModeTransition struct {
+	Phase ModeTransitionPhase
+	// FromModes are the encryption types still present in the deployed configuration.
+	FromModes []state.Mode
+	ToMode    state.Mode
+	// Message narrates the phase for humans.
+	Message string
+}
+
+// GetModeTransition computes the phase of a switch from one encryption type to another from the deployed state
+// (as returned by encryptionconfig.ToEncryptionState), the backed keys sorted recent first and the configured mode.
+// It returns nil if no such switch is in progress. Enabling and disabling encryption are not considered a switch
+// of the encryption type.
+func GetModeTransition(currentState map[schema.GroupResource]state.GroupResourceState, backedKeys []state.KeyState, targetMode state.Mode, encryptedGRs []schema.GroupResource) *ModeTransition {
+	if targetMode == state.Identity || len(currentState) == 0 {
+		return nil
+	}
+
+	fromModes := previousModes(currentState, targetMode)
+	if len(fromModes) == 0 {
+		return nil
+	}
+	transition := &ModeTransition{FromModes: fromModes, ToMode: targetMode}
+	from := modesString(fromModes)
+
+	if len(backedKeys) == 0 || backedKeys[0].Mode != targetMode {
+		transition.Phase = ModeTransitionKeyCreation
+		transition.Message = fmt.Sprintf("Switching encryption from %s to %s: waiting for a new %s key to be created", from, targetMode, targetMode)
+		return transition
+	}
+	newKey := backedKeys[0]
+
+	for _, gr := range encryptedGRs {
+		if !hasReadKey(currentState[gr].ReadKeys, newKey) {
+			transition.Phase = ModeTransitionReadKeyRollout
+			transition.Message = fmt.Sprintf("Switching encryption from %s to %s: rolling out a combined configuration with key %s as read-key", from, targetMode, newKey.Key.Name)
+			return transition
+		}
+	}
+
+	for _, gr := range encryptedGRs {
+		writeKey := currentState[gr].WriteKey
+		if !state.EqualKeyAndEqualID(&writeKey, &newKey) {
+			transition.Phase = ModeTransitionWriteKeySwitch
+			transition.Message = fmt.Sprintf("Switching encryption from %s to %s: rolling out key %s as write-key", from, targetMode, newKey.Key.Name)
+			return transition
+		}
+	}
+
+	if migrated, missing, _ := state.MigratedFor(encryptedGRs, newKey); !migrated {
+		transition.Phase = ModeTransitionMigration
+		transition.Message = fmt.Sprintf("Switching encryption from %s to %s: migrating %s to key %s", from, targetMode, grsString(missing), newKey.Key.Name)
+		return transition
+	}
+
+	transition.Phase = ModeTransitionOldProviderRetirement
+	transition.Message = fmt.Sprintf("Switching encryption from %s to %s: retiring the %s provider", from, targetMode, from)
+	return transition
+}
+
+// retireReadKeysOfPreviousMode drops read-keys of an encryption type different from the write-key's.
+// It must only be called when all resources have been migrated to the write-key.
+// Identity read-keys are left alone, they are handled by the ordinary pruning.
+func retireReadKeysOfPreviousMode(readKeys []state.KeyState, writeKey state.KeyState) []state.KeyState {
+	if writeKey.Mode == state.Identity {
+		return readKeys
+	}
+
+	ret := make([]state.KeyState, 0, len(readKeys))
+	for _, rk := range readKeys {
+		if rk.Mode != writeKey.Mode && rk.Mode != state.Identity {
+			continue
+		}
+		ret = append(ret, rk)
+	}
+	return ret
+}
+
+// previousModes returns the non-identity modes of the deployed keys that differ from the target mode.
+func previousModes(currentState map[schema.GroupResource]state.GroupResourceState, targetMode state.Mode) []state.Mode {
+	found := map[state.Mode]bool{}
+	for _, grState := range currentState {
+		for _, rk := range grState.ReadKeys {
+			if rk.Mode != state.Identity && rk.Mode != targetMode {
+				found[rk.Mode] = true
+			}
+		}
+	}
+
+	modes := make([]state.Mode, 0, len(found))
+	for mode := range found {
+		modes = append(modes, mode)
+	}
+	sort.Slice(modes, func(i, j int) bool { return modes[i] < modes[j] })
+	return modes
+}
+
+func hasReadKey(readKeys []state.KeyState, key state.KeyState) bool {
+	for _, rk := range readKeys {
+		if state.EqualKeyAndEqualID(&rk, &key) {
+			return true
+		}
+	}
+	return false
+}
+
+func modesString(modes []state.Mode) string {
+	ss := make([]string, 0, len(modes))
+	for _, mode := range modes {
+		ss = append(ss, string(mode))
+	}
+	return strings.Join(ss, ", ")
+}
+
+func grsString(grs []schema.GroupResource) string {
+	ss := make([]string, 0, len(grs))
+	for _, gr := range grs {
+		ss = append(ss, gr.String())
+	}
+	return strings.Join(ss, ", ")
+}
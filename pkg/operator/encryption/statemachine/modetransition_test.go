@@ -0,0 +1,143 @@
+package statemachine
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/openshift/library-go/pkg/operator/encryption/encryptionconfig"
+	"github.com/openshift/library-go/pkg/operator/encryption/secrets"
+	"github.com/openshift/library-go/pkg/operator/encryption/state"
+	encryptiontesting "github.com/openshift/library-go/pkg/operator/encryption/testing"
+)
+
+func TestGetModeTransition(t *testing.T) {
+	grs := []schema.GroupResource{{Group: "", Resource: "secrets"}}
+
+	cbcKey := backedKeyState(t, encryptiontesting.CreateEncryptionKeySecretWithRawKeyWithMode("kms", grs, 1, []byte("61def964fb967f5d7c44a2af8dab6865"), "aescbc"))
+	gcmKey := backedKeyState(t, encryptiontesting.CreateEncryptionKeySecretWithRawKeyWithMode("kms", nil, 2, []byte("71ea7c91419a68fd1224f88d50316b4e"), "aesgcm"))
+	migratedGCMKey := backedKeyState(t, encryptiontesting.CreateEncryptionKeySecretWithRawKeyWithMode("kms", grs, 2, []byte("71ea7c91419a68fd1224f88d50316b4e"), "aesgcm"))
+
+	grState := func(writeKey state.KeyState, readKeys ...state.KeyState) map[schema.GroupResource]state.GroupResourceState {
+		return map[schema.GroupResource]state.GroupResourceState{grs[0]: {WriteKey: writeKey, ReadKeys: readKeys}}
+	}
+
+	scenarios := []struct {
+		name          string
+		currentState  map[schema.GroupResource]state.GroupResourceState
+		backedKeys    []state.KeyState
+		targetMode    state.Mode
+		expectedPhase ModeTransitionPhase
+	}{
+		{
+			name:         "same mode, no transition",
+			currentState: grState(cbcKey, cbcKey),
+			backedKeys:   []state.KeyState{cbcKey},
+			targetMode:   state.AESCBC,
+		},
+		{
+			name:         "decryption is not a transition",
+			currentState: grState(cbcKey, cbcKey),
+			backedKeys:   []state.KeyState{cbcKey},
+			targetMode:   state.Identity,
+		},
+		{
+			name:          "new key missing",
+			currentState:  grState(cbcKey, cbcKey),
+			backedKeys:    []state.KeyState{cbcKey},
+			targetMode:    state.AESGCM,
+			expectedPhase: ModeTransitionKeyCreation,
+		},
+		{
+			name:          "combined configuration not deployed yet",
+			currentState:  grState(cbcKey, cbcKey),
+			backedKeys:    []state.KeyState{gcmKey, cbcKey},
+			targetMode:    state.AESGCM,
+			expectedPhase: ModeTransitionReadKeyRollout,
+		},
+		{
+			name:          "combined configuration deployed, write-key not switched",
+			currentState:  grState(cbcKey, gcmKey, cbcKey),
+			backedKeys:    []state.KeyState{gcmKey, cbcKey},
+			targetMode:    state.AESGCM,
+			expectedPhase: ModeTransitionWriteKeySwitch,
+		},
+		{
+			name:          "write-key switched, not migrated",
+			currentState:  grState(gcmKey, gcmKey, cbcKey),
+			backedKeys:    []state.KeyState{gcmKey, cbcKey},
+			targetMode:    state.AESGCM,
+			expectedPhase: ModeTransitionMigration,
+		},
+		{
+			name:          "migrated, old provider still deployed",
+			currentState:  grState(migratedGCMKey, migratedGCMKey, cbcKey),
+			backedKeys:    []state.KeyState{migratedGCMKey, cbcKey},
+			targetMode:    state.AESGCM,
+			expectedPhase: ModeTransitionOldProviderRetirement,
+		},
+		{
+			name:         "old provider retired",
+			currentState: grState(migratedGCMKey, migratedGCMKey),
+			backedKeys:   []state.KeyState{migratedGCMKey, cbcKey},
+			targetMode:   state.AESGCM,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			transition := GetModeTransition(scenario.currentState, scenario.backedKeys, scenario.targetMode, grs)
+			if len(scenario.expectedPhase) == 0 {
+				if transition != nil {
+					t.Fatalf("expected no transition, got %#v", transition)
+				}
+				return
+			}
+			if transition == nil {
+				t.Fatalf("expected transition in phase %q, got none", scenario.expectedPhase)
+			}
+			if transition.Phase != scenario.expectedPhase {
+				t.Errorf("expected phase %q, got %q (%s)", scenario.expectedPhase, transition.Phase, transition.Message)
+			}
+			if len(transition.FromModes) != 1 || transition.FromModes[0] != state.AESCBC || transition.ToMode != state.AESGCM {
+				t.Errorf("unexpected modes in %#v", transition)
+			}
+		})
+	}
+}
+
+func TestGetDesiredEncryptionStateRetiresPreviousMode(t *testing.T) {
+	grs := []schema.GroupResource{{Group: "", Resource: "secrets"}}
+	encryptionSecrets := []*corev1.Secret{
+		encryptiontesting.CreateEncryptionKeySecretWithRawKeyWithMode("kms", grs, 1, []byte("61def964fb967f5d7c44a2af8dab6865"), "aescbc"),
+		encryptiontesting.CreateEncryptionKeySecretWithRawKeyWithMode("kms", grs, 2, []byte("71ea7c91419a68fd1224f88d50316b4e"), "aesgcm"),
+	}
+	cbcKey := backedKeyState(t, encryptionSecrets[0])
+	gcmKey := backedKeyState(t, encryptionSecrets[1])
+
+	// the combined configuration with the aesgcm write-key, everything migrated
+	currentConfig := encryptionconfig.FromEncryptionState(map[schema.GroupResource]state.GroupResourceState{
+		grs[0]: {WriteKey: gcmKey, ReadKeys: []state.KeyState{gcmKey, cbcKey}},
+	})
+
+	desired := getDesiredEncryptionState(currentConfig, encryptionSecrets, grs)
+	readKeys := desired[grs[0]].ReadKeys
+	if len(readKeys) != 1 || readKeys[0].Mode != state.AESGCM || readKeys[0].Key.Name != "2" {
+		t.Fatalf("expected the aescbc provider to be retired, got read-keys %#v", readKeys)
+	}
+	if desired[grs[0]].WriteKey.Key.Name != "2" {
+		t.Errorf("expected key 2 to remain the write-key, got %#v", desired[grs[0]].WriteKey)
+	}
+}
+
+func backedKeyState(t *testing.T, secret *corev1.Secret) state.KeyState {
+	t.Helper()
+
+	ks, err := secrets.ToKeyState(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks.Backed = true
+	return ks
+}
@@ -70,10 +70,14 @@ func GetEncryptionConfigAndState(
 //
 // The basic rules are:
 //
-// 1. don't do anything if there are key secrets.
-// 2. every GR must have all the read-keys (existing as secrets) since last complete migration.
-// 3. if (2) is the case, the write-key must be the most recent key.
-// 4. if (2) and (3) are the case, all non-write keys should be removed.
+//  1. don't do anything if there are key secrets.
+//  2. every GR must have all the read-keys (existing as secrets) since last complete migration.
+//  3. if (2) is the case, the write-key must be the most recent key.
+//  4. if (2) and (3) are the case, all non-write keys should be removed. Read-keys of an encryption
+//     type different from the write-key's are retired once the write-key has been fully migrated.
+//
+// GRs of the old config which are not to be encrypted anymore keep their read-keys with identity as write-key,
+// untouched by the rules above, until they have been migrated to identity. Only then they are dropped.
 func getDesiredEncryptionState(oldEncryptionConfig *apiserverconfigv1.EncryptionConfiguration, encryptionSecrets []*corev1.Secret, toBeEncryptedGRs []schema.GroupResource) map[schema.GroupResource]state.GroupResourceState {
 	//
 	// STEP 0: start with old encryption config, and alter it towards the desired state in the following STEPs.
@@ -95,8 +99,11 @@ func getDesiredEncryptionState(oldEncryptionConfig *apiserverconfigv1.Encryption
 
 	// remove unused GRs from the desired encryption configuration
 	// toBeEncryptedGRs is not static and can change over time
-	// here we are removing resources that his operator doesn't manage anymore
-	for actualGR := range desiredEncryptionState {
+	// here we are removing resources that his operator doesn't manage anymore.
+	// Resources that might still have encrypted data are kept with identity as write key
+	// until the migration controller has migrated them back to identity.
+	retiringGRs := map[schema.GroupResource]bool{}
+	for actualGR, grState := range desiredEncryptionState {
 		found := false
 		for _, desiredGR := range toBeEncryptedGRs {
 			if actualGR == desiredGR {
@@ -104,11 +111,20 @@ func getDesiredEncryptionState(oldEncryptionConfig *apiserverconfigv1.Encryption
 				break
 			}
 		}
+		if found {
+			continue
+		}
 
-		if !found {
-			delete(desiredEncryptionState, actualGR)
-			klog.V(4).Infof("removed %s from the encryption config as this operator doesn't manage this GR anymore", actualGR.String())
+		if state.NeedsIdentityMigration(actualGR, grState) {
+			grState.WriteKey = state.KeyState{}
+			desiredEncryptionState[actualGR] = grState
+			retiringGRs[actualGR] = true
+			klog.V(4).Infof("keeping %s with identity write key in the encryption config until it is migrated to identity", actualGR.String())
+			continue
 		}
+
+		delete(desiredEncryptionState, actualGR)
+		klog.V(4).Infof("removed %s from the encryption config as this operator doesn't manage this GR anymore", actualGR.String())
 	}
 
 	//
@@ -126,7 +142,6 @@ func getDesiredEncryptionState(oldEncryptionConfig *apiserverconfigv1.Encryption
 	// Note: we never drop keys here. Dropping only happens in STEP 4.
 	// Note: only keysWithPotentiallyPersistedData are considered. There might be more which are not pruned yet by the pruning controller.
 	//
-	// TODO: allow removing resources (e.g. on downgrades) and transition back to identity.
 	allReadSecretsAsExpected := true
 	currentlyEncryptedGRs := oldEncryptedGRs
 	if oldEncryptionConfig == nil {
@@ -136,6 +151,9 @@ func getDesiredEncryptionState(oldEncryptionConfig *apiserverconfigv1.Encryption
 	}
 	expectedReadSecrets := state.KeysWithPotentiallyPersistedDataAndNextReadKey(currentlyEncryptedGRs, backedKeys)
 	for gr, grState := range desiredEncryptionState {
+		if retiringGRs[gr] {
+			continue
+		}
 		changed := false
 		for _, expected := range expectedReadSecrets {
 			found := false
@@ -174,6 +192,9 @@ func getDesiredEncryptionState(oldEncryptionConfig *apiserverconfigv1.Encryption
 	writeKey := backedKeys[0]
 	allWriteSecretsAsExpected := true
 	for gr, grState := range desiredEncryptionState {
+		if retiringGRs[gr] {
+			continue
+		}
 		if !grState.HasWriteKey() || !state.EqualKeyAndEqualID(&grState.WriteKey, &writeKey) {
 			allWriteSecretsAsExpected = false
 			klog.V(4).Infof("encrypted resource %s does not have write key %s", gr, writeKey.Key.Name)
@@ -183,6 +204,9 @@ func getDesiredEncryptionState(oldEncryptionConfig *apiserverconfigv1.Encryption
 	if !allWriteSecretsAsExpected {
 		klog.V(4).Infof("not all write secrets in sync")
 		for gr := range desiredEncryptionState {
+			if retiringGRs[gr] {
+				continue
+			}
 			grState := desiredEncryptionState[gr]
 			grState.WriteKey = writeKey
 			desiredEncryptionState[gr] = grState
@@ -200,6 +224,9 @@ func getDesiredEncryptionState(oldEncryptionConfig *apiserverconfigv1.Encryption
 		return desiredEncryptionState
 	}
 	for gr := range desiredEncryptionState {
+		if retiringGRs[gr] {
+			continue
+		}
 		grState := desiredEncryptionState[gr]
 
 		// cut down read keys to all expected read keys, and everything in between
@@ -215,6 +242,9 @@ func getDesiredEncryptionState(oldEncryptionConfig *apiserverconfigv1.Encryption
 			}
 		}
 
+		// after a switch of the encryption type everything has been migrated to the new write-key, retire the old provider
+		grState.ReadKeys = retireReadKeysOfPreviousMode(grState.ReadKeys, writeKey)
+
 		desiredEncryptionState[gr] = grState
 	}
 	klog.V(4).Infof("write key %s set as sole write key", writeKey.Key.Name)
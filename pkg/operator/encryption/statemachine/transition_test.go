@@ -13,6 +13,7 @@ import (
 	"k8s.io/utils/diff"
 
 	"github.com/openshift/library-go/pkg/operator/encryption/encryptionconfig"
+	"github.com/openshift/library-go/pkg/operator/encryption/secrets"
 	"github.com/openshift/library-go/pkg/operator/encryption/state"
 	encryptiontesting "github.com/openshift/library-go/pkg/operator/encryption/testing"
 )
@@ -209,7 +210,7 @@ func TestGetDesiredEncryptionState(t *testing.T) {
 			}),
 		},
 		{
-			"config exists with two resources, GRs reduced => only one resource stays",
+			"config exists with two resources, GRs reduced => removed resource is kept with identity write key until migrated",
 			args{
 				&apiserverconfigv1.EncryptionConfiguration{
 					Resources: []apiserverconfigv1.ResourceConfiguration{{
@@ -244,6 +245,73 @@ func TestGetDesiredEncryptionState(t *testing.T) {
 				},
 				[]schema.GroupResource{{Group: "", Resource: "configmaps"}},
 			},
+			equalsConfig(&apiserverconfigv1.EncryptionConfiguration{
+				Resources: []apiserverconfigv1.ResourceConfiguration{
+					{
+						Resources: []string{"configmaps"},
+						Providers: []apiserverconfigv1.ProviderConfiguration{{
+							AESCBC: &apiserverconfigv1.AESConfiguration{
+								Keys: []apiserverconfigv1.Key{{
+									Name:   "1",
+									Secret: base64.StdEncoding.EncodeToString([]byte("71ea7c91419a68fd1224f88d50316b4e")),
+								}},
+							},
+						}, {
+							Identity: &apiserverconfigv1.IdentityConfiguration{},
+						}},
+					},
+					{
+						Resources: []string{"secrets"},
+						Providers: []apiserverconfigv1.ProviderConfiguration{{
+							Identity: &apiserverconfigv1.IdentityConfiguration{},
+						}, {
+							AESCBC: &apiserverconfigv1.AESConfiguration{
+								Keys: []apiserverconfigv1.Key{{
+									Name:   "1",
+									Secret: base64.StdEncoding.EncodeToString([]byte("71ea7c91419a68fd1224f88d50316b4e")),
+								}},
+							},
+						}},
+					},
+				},
+			}),
+		},
+		{
+			"config exists with two resources, GRs reduced and removed resource migrated to identity => only one resource stays",
+			args{
+				&apiserverconfigv1.EncryptionConfiguration{
+					Resources: []apiserverconfigv1.ResourceConfiguration{{
+						Resources: []string{"configmaps"},
+						Providers: []apiserverconfigv1.ProviderConfiguration{{
+							AESCBC: &apiserverconfigv1.AESConfiguration{
+								Keys: []apiserverconfigv1.Key{{
+									Name:   "1",
+									Secret: base64.StdEncoding.EncodeToString([]byte("71ea7c91419a68fd1224f88d50316b4e")),
+								}},
+							},
+						}, {
+							Identity: &apiserverconfigv1.IdentityConfiguration{},
+						}},
+					}, {
+						Resources: []string{"secrets"},
+						Providers: []apiserverconfigv1.ProviderConfiguration{{
+							AESCBC: &apiserverconfigv1.AESConfiguration{
+								Keys: []apiserverconfigv1.Key{{
+									Name:   "1",
+									Secret: base64.StdEncoding.EncodeToString([]byte("71ea7c91419a68fd1224f88d50316b4e")),
+								}},
+							},
+						}, {
+							Identity: &apiserverconfigv1.IdentityConfiguration{},
+						}},
+					}},
+				},
+				"kms",
+				[]*corev1.Secret{
+					withIdentityMigratedResources(encryptiontesting.CreateEncryptionKeySecretWithRawKey("kms", nil, 1, []byte("71ea7c91419a68fd1224f88d50316b4e")), `{"resources":[{"Group":"","Resource":"secrets"}]}`),
+				},
+				[]schema.GroupResource{{Group: "", Resource: "configmaps"}},
+			},
 			equalsConfig(&apiserverconfigv1.EncryptionConfiguration{
 				Resources: []apiserverconfigv1.ResourceConfiguration{
 					{
@@ -995,3 +1063,8 @@ func TestGetDesiredEncryptionState(t *testing.T) {
 		})
 	}
 }
+
+func withIdentityMigratedResources(s *corev1.Secret, value string) *corev1.Secret {
+	s.Annotations[secrets.EncryptionSecretIdentityMigratedResources] = value
+	return s
+}
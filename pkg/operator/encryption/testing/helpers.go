@@ -24,6 +24,8 @@ const (
 	encryptionSecretKeyDataForTest           = "encryption.apiserver.operator.openshift.io-key"
 	encryptionSecretMigratedTimestampForTest = "encryption.apiserver.operator.openshift.io/migrated-timestamp"
 	encryptionSecretMigratedResourcesForTest = "encryption.apiserver.operator.openshift.io/migrated-resources"
+	encryptionSecretControllerVersionForTest = "encryption.apiserver.operator.openshift.io/controller-version"
+	encryptionSecretFIPSModeForTest          = "encryption.apiserver.operator.openshift.io/fips-mode"
 )
 
 func CreateEncryptionKeySecretNoData(targetNS string, grs []schema.GroupResource, keyID uint64) *corev1.Secret {
@@ -81,6 +83,12 @@ func CreateEncryptionKeySecretWithKeyFromExistingSecretWithMode(targetNS string,
 	if rawKey, exist := existingSecret.Data[encryptionSecretKeyDataForTest]; exist {
 		secret.Data[encryptionSecretKeyDataForTest] = rawKey
 	}
+	// the provenance depends on the build and the host, like the key it is taken from the existing secret
+	for _, provenanceAnnotation := range []string{encryptionSecretControllerVersionForTest, encryptionSecretFIPSModeForTest} {
+		if v, exist := existingSecret.Annotations[provenanceAnnotation]; exist {
+			secret.Annotations[provenanceAnnotation] = v
+		}
+	}
 	return secret
 }
 
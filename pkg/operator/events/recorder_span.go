@@ -0,0 +1,72 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spanRecorder adds the events to a trace span before passing them to the delegate recorder.
+type spanRecorder struct {
+	delegate Recorder
+	span     trace.Span
+}
+
+var _ Recorder = &spanRecorder{}
+
+// NewSpanRecorder returns a recorder adding the events to the span as span events, e.g. so that the events recorded
+// during a controller sync appear in its trace, before passing them to the delegate recorder.
+func NewSpanRecorder(delegate Recorder, span trace.Span) Recorder {
+	return &spanRecorder{delegate: delegate, span: span}
+}
+
+func (r *spanRecorder) addSpanEvent(eventType, reason, message string) {
+	r.span.AddEvent("event", trace.WithAttributes(
+		attribute.String("event.type", eventType),
+		attribute.String("event.reason", reason),
+		attribute.String("event.message", message),
+		attribute.String("event.component", r.delegate.ComponentName()),
+	))
+}
+
+func (r *spanRecorder) Event(reason, message string) {
+	r.addSpanEvent(corev1.EventTypeNormal, reason, message)
+	r.delegate.Event(reason, message)
+}
+
+func (r *spanRecorder) Eventf(reason, messageFmt string, args ...interface{}) {
+	r.Event(reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *spanRecorder) Warning(reason, message string) {
+	r.addSpanEvent(corev1.EventTypeWarning, reason, message)
+	r.delegate.Warning(reason, message)
+}
+
+func (r *spanRecorder) Warningf(reason, messageFmt string, args ...interface{}) {
+	r.Warning(reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *spanRecorder) ForComponent(componentName string) Recorder {
+	return &spanRecorder{delegate: r.delegate.ForComponent(componentName), span: r.span}
+}
+
+func (r *spanRecorder) WithComponentSuffix(componentNameSuffix string) Recorder {
+	return &spanRecorder{delegate: r.delegate.WithComponentSuffix(componentNameSuffix), span: r.span}
+}
+
+func (r *spanRecorder) WithContext(ctx context.Context) Recorder {
+	return &spanRecorder{delegate: r.delegate.WithContext(ctx), span: r.span}
+}
+
+func (r *spanRecorder) ComponentName() string {
+	return r.delegate.ComponentName()
+}
+
+func (r *spanRecorder) Shutdown() {
+	r.delegate.Shutdown()
+}
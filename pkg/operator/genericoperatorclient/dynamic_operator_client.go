@@ -24,7 +24,7 @@ import (
 
 const defaultConfigName = "cluster"
 
-func newClusterScopedOperatorClient(config *rest.Config, gvr schema.GroupVersionResource) (*dynamicOperatorClient, dynamicinformer.DynamicSharedInformerFactory, error) {
+func newClusterScopedOperatorClient(config *rest.Config, gvr schema.GroupVersionResource, configName string, opts []Option) (*dynamicOperatorClient, dynamicinformer.DynamicSharedInformerFactory, error) {
 	dynamicClient, err := dynamic.NewForConfig(config)
 	if err != nil {
 		return nil, nil, err
@@ -34,39 +34,46 @@ func newClusterScopedOperatorClient(config *rest.Config, gvr schema.GroupVersion
 	informers := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, 12*time.Hour)
 	informer := informers.ForResource(gvr)
 
-	return &dynamicOperatorClient{
-		informer: informer,
-		client:   client,
-	}, informers, nil
-}
-
-func NewClusterScopedOperatorClient(config *rest.Config, gvr schema.GroupVersionResource) (v1helpers.OperatorClientWithFinalizers, dynamicinformer.DynamicSharedInformerFactory, error) {
-	d, informers, err := newClusterScopedOperatorClient(config, gvr)
-	if err != nil {
+	d := &dynamicOperatorClient{
+		configName: configName,
+		resource:   gvr.GroupResource().String(),
+		informer:   informer,
+		client:     client,
+	}
+	if err := d.applyOptions(opts); err != nil {
 		return nil, nil, err
 	}
-	d.configName = defaultConfigName
 	return d, informers, nil
+}
 
+func NewClusterScopedOperatorClient(config *rest.Config, gvr schema.GroupVersionResource, opts ...Option) (v1helpers.OperatorClientWithFinalizers, dynamicinformer.DynamicSharedInformerFactory, error) {
+	return newClusterScopedOperatorClient(config, gvr, defaultConfigName, opts)
 }
 
-func NewClusterScopedOperatorClientWithConfigName(config *rest.Config, gvr schema.GroupVersionResource, configName string) (v1helpers.OperatorClientWithFinalizers, dynamicinformer.DynamicSharedInformerFactory, error) {
+func NewClusterScopedOperatorClientWithConfigName(config *rest.Config, gvr schema.GroupVersionResource, configName string, opts ...Option) (v1helpers.OperatorClientWithFinalizers, dynamicinformer.DynamicSharedInformerFactory, error) {
 	if len(configName) < 1 {
 		return nil, nil, fmt.Errorf("config name cannot be empty")
 	}
-	d, informers, err := newClusterScopedOperatorClient(config, gvr)
-	if err != nil {
-		return nil, nil, err
-	}
-	d.configName = configName
-	return d, informers, nil
-
+	return newClusterScopedOperatorClient(config, gvr, configName, opts)
 }
 
 type dynamicOperatorClient struct {
 	configName string
+	resource   string
 	informer   informers.GenericInformer
 	client     dynamic.ResourceInterface
+
+	// liveReadFallback is set by WithLiveReadFallback.
+	liveReadFallback *liveReadFallback
+}
+
+func (c *dynamicOperatorClient) applyOptions(opts []Option) error {
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (c dynamicOperatorClient) Informer() cache.SharedIndexInformer {
@@ -74,20 +81,18 @@ func (c dynamicOperatorClient) Informer() cache.SharedIndexInformer {
 }
 
 func (c dynamicOperatorClient) GetObjectMeta() (*metav1.ObjectMeta, error) {
-	uncastInstance, err := c.informer.Lister().Get(c.configName)
+	instance, err := c.getInstance()
 	if err != nil {
 		return nil, err
 	}
-	instance := uncastInstance.(*unstructured.Unstructured)
 	return getObjectMetaFromUnstructured(instance.UnstructuredContent())
 }
 
 func (c dynamicOperatorClient) GetOperatorState() (*operatorv1.OperatorSpec, *operatorv1.OperatorStatus, string, error) {
-	uncastInstance, err := c.informer.Lister().Get(c.configName)
+	instance, err := c.getInstance()
 	if err != nil {
 		return nil, nil, "", err
 	}
-	instance := uncastInstance.(*unstructured.Unstructured)
 
 	spec, err := getOperatorSpecFromUnstructured(instance.UnstructuredContent())
 	if err != nil {
@@ -105,11 +110,10 @@ func (c dynamicOperatorClient) GetOperatorState() (*operatorv1.OperatorSpec, *op
 // in operatorv1.OperatorSpec while preserving pre-existing spec fields that have
 // no correspondence in operatorv1.OperatorSpec.
 func (c dynamicOperatorClient) UpdateOperatorSpec(ctx context.Context, resourceVersion string, spec *operatorv1.OperatorSpec) (*operatorv1.OperatorSpec, string, error) {
-	uncastOriginal, err := c.informer.Lister().Get(c.configName)
+	original, err := c.getInstanceForUpdate(ctx, resourceVersion)
 	if err != nil {
 		return nil, "", err
 	}
-	original := uncastOriginal.(*unstructured.Unstructured)
 
 	copy := original.DeepCopy()
 	copy.SetResourceVersion(resourceVersion)
@@ -133,11 +137,10 @@ func (c dynamicOperatorClient) UpdateOperatorSpec(ctx context.Context, resourceV
 // in operatorv1.OperatorStatus while preserving pre-existing status fields that have
 // no correspondence in operatorv1.OperatorStatus.
 func (c dynamicOperatorClient) UpdateOperatorStatus(ctx context.Context, resourceVersion string, status *operatorv1.OperatorStatus) (*operatorv1.OperatorStatus, error) {
-	uncastOriginal, err := c.informer.Lister().Get(c.configName)
+	original, err := c.getInstanceForUpdate(ctx, resourceVersion)
 	if err != nil {
 		return nil, err
 	}
-	original := uncastOriginal.(*unstructured.Unstructured)
 
 	copy := original.DeepCopy()
 	copy.SetResourceVersion(resourceVersion)
@@ -158,12 +161,11 @@ func (c dynamicOperatorClient) UpdateOperatorStatus(ctx context.Context, resourc
 }
 
 func (c dynamicOperatorClient) EnsureFinalizer(ctx context.Context, finalizer string) error {
-	uncastInstance, err := c.informer.Lister().Get(c.configName)
+	instance, err := c.getInstanceWithContext(ctx)
 	if err != nil {
 		return err
 	}
 
-	instance := uncastInstance.(*unstructured.Unstructured)
 	finalizers := instance.GetFinalizers()
 	for _, f := range finalizers {
 		if f == finalizer {
@@ -183,12 +185,11 @@ func (c dynamicOperatorClient) EnsureFinalizer(ctx context.Context, finalizer st
 }
 
 func (c dynamicOperatorClient) RemoveFinalizer(ctx context.Context, finalizer string) error {
-	uncastInstance, err := c.informer.Lister().Get(c.configName)
+	instance, err := c.getInstanceWithContext(ctx)
 	if err != nil {
 		return err
 	}
 
-	instance := uncastInstance.(*unstructured.Unstructured)
 	finalizers := instance.GetFinalizers()
 	found := false
 	newFinalizers := make([]string, 0, len(finalizers))
@@ -18,7 +18,7 @@ import (
 	"k8s.io/client-go/rest"
 )
 
-func NewStaticPodOperatorClient(config *rest.Config, gvr schema.GroupVersionResource) (v1helpers.StaticPodOperatorClient, dynamicinformer.DynamicSharedInformerFactory, error) {
+func NewStaticPodOperatorClient(config *rest.Config, gvr schema.GroupVersionResource, opts ...Option) (v1helpers.StaticPodOperatorClient, dynamicinformer.DynamicSharedInformerFactory, error) {
 	dynamicClient, err := dynamic.NewForConfig(config)
 	if err != nil {
 		return nil, nil, err
@@ -28,13 +28,18 @@ func NewStaticPodOperatorClient(config *rest.Config, gvr schema.GroupVersionReso
 	informers := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, 12*time.Hour)
 	informer := informers.ForResource(gvr)
 
-	return &dynamicStaticPodOperatorClient{
+	d := &dynamicStaticPodOperatorClient{
 		dynamicOperatorClient: dynamicOperatorClient{
 			configName: defaultConfigName,
+			resource:   gvr.GroupResource().String(),
 			informer:   informer,
 			client:     client,
 		},
-	}, informers, nil
+	}
+	if err := d.applyOptions(opts); err != nil {
+		return nil, nil, err
+	}
+	return d, informers, nil
 }
 
 type dynamicStaticPodOperatorClient struct {
@@ -42,11 +47,10 @@ type dynamicStaticPodOperatorClient struct {
 }
 
 func (c dynamicStaticPodOperatorClient) GetStaticPodOperatorState() (*operatorv1.StaticPodOperatorSpec, *operatorv1.StaticPodOperatorStatus, string, error) {
-	uncastInstance, err := c.informer.Lister().Get("cluster")
+	instance, err := c.getInstance()
 	if err != nil {
 		return nil, nil, "", err
 	}
-	instance := uncastInstance.(*unstructured.Unstructured)
 
 	spec, err := getStaticPodOperatorSpecFromUnstructured(instance.UnstructuredContent())
 	if err != nil {
@@ -79,11 +83,10 @@ func (c dynamicStaticPodOperatorClient) GetStaticPodOperatorStateWithQuorum(ctx
 }
 
 func (c dynamicStaticPodOperatorClient) UpdateStaticPodOperatorSpec(ctx context.Context, resourceVersion string, spec *operatorv1.StaticPodOperatorSpec) (*operatorv1.StaticPodOperatorSpec, string, error) {
-	uncastOriginal, err := c.informer.Lister().Get("cluster")
+	original, err := c.getInstanceForUpdate(ctx, resourceVersion)
 	if err != nil {
 		return nil, "", err
 	}
-	original := uncastOriginal.(*unstructured.Unstructured)
 
 	copy := original.DeepCopy()
 	copy.SetResourceVersion(resourceVersion)
@@ -104,11 +107,10 @@ func (c dynamicStaticPodOperatorClient) UpdateStaticPodOperatorSpec(ctx context.
 }
 
 func (c dynamicStaticPodOperatorClient) UpdateStaticPodOperatorStatus(ctx context.Context, resourceVersion string, status *operatorv1.StaticPodOperatorStatus) (*operatorv1.StaticPodOperatorStatus, error) {
-	uncastOriginal, err := c.informer.Lister().Get("cluster")
+	original, err := c.getInstanceForUpdate(ctx, resourceVersion)
 	if err != nil {
 		return nil, err
 	}
-	original := uncastOriginal.(*unstructured.Unstructured)
 
 	copy := original.DeepCopy()
 	copy.SetResourceVersion(resourceVersion)
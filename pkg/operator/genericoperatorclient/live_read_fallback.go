@@ -0,0 +1,144 @@
+package genericoperatorclient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// liveReadTimeout bounds the live reads of the getters, which take no context.
+const liveReadTimeout = 30 * time.Second
+
+// Option configures the generic operator clients.
+type Option func(*dynamicOperatorClient) error
+
+// WithLiveReadFallback makes the client read the operator resource from the API server instead of the informer cache
+// when the informer has not synced yet, or when the cache has not been confirmed to hold the latest version of the
+// resource for longer than the threshold, e.g. right after a leadership change. The cache is confirmed by the events of
+// the informer and by the live reads which find the cached version. Each live read is counted by the
+// generic_operator_client_live_read_fallbacks_total metric.
+//
+// This applies to the reads of the operator state and metadata, and to the object the updates are built from. Updates
+// are always made against the resource version given by the caller.
+func WithLiveReadFallback(threshold time.Duration) Option {
+	return func(c *dynamicOperatorClient) error {
+		fallback := &liveReadFallback{
+			resource:  c.resource,
+			threshold: threshold,
+			hasSynced: c.informer.Informer().HasSynced,
+			clock:     clock.RealClock{},
+		}
+		_, err := c.informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { fallback.observed(c.configName, obj) },
+			UpdateFunc: func(_, obj interface{}) { fallback.observed(c.configName, obj) },
+		})
+		if err != nil {
+			return err
+		}
+		c.liveReadFallback = fallback
+		return nil
+	}
+}
+
+// liveReadFallback tracks when the informer cache was last known to be fresh.
+type liveReadFallback struct {
+	resource  string
+	threshold time.Duration
+	hasSynced func() bool
+	clock     clock.PassiveClock
+
+	lock      sync.Mutex
+	confirmed time.Time
+}
+
+// observed records that the cache holds the latest version of the operator resource when obj is that resource.
+func (f *liveReadFallback) observed(configName string, obj interface{}) {
+	if accessor, ok := obj.(metav1.Object); !ok || accessor.GetName() != configName {
+		return
+	}
+	f.confirm()
+}
+
+func (f *liveReadFallback) confirm() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.confirmed = f.clock.Now()
+}
+
+// reason returns why the cache must not be trusted, or an empty string when it can be.
+func (f *liveReadFallback) reason() string {
+	if !f.hasSynced() {
+		return "NotSynced"
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.clock.Now().Sub(f.confirmed) > f.threshold {
+		return "Stale"
+	}
+	return ""
+}
+
+// getInstance returns the operator resource, from the informer cache unless the live read fallback distrusts it.
+// A live read is bounded by liveReadTimeout because the getters of the operator clients take no context.
+func (c dynamicOperatorClient) getInstance() (*unstructured.Unstructured, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), liveReadTimeout)
+	defer cancel()
+	return c.getInstanceWithContext(ctx)
+}
+
+// getInstanceWithContext is getInstance with the context of the caller for the live read.
+func (c dynamicOperatorClient) getInstanceWithContext(ctx context.Context) (*unstructured.Unstructured, error) {
+	if c.liveReadFallback == nil {
+		return c.getCachedInstance()
+	}
+	reason := c.liveReadFallback.reason()
+	if len(reason) == 0 {
+		return c.getCachedInstance()
+	}
+	return c.getLiveInstance(ctx, reason)
+}
+
+// getInstanceForUpdate returns the operator resource to apply an update with the given resource version to. With the
+// live read fallback, the resource is read from the API server when the cached version differs from the one given by
+// the caller, so that the fields not covered by the update are not reverted to their cached values.
+func (c dynamicOperatorClient) getInstanceForUpdate(ctx context.Context, resourceVersion string) (*unstructured.Unstructured, error) {
+	if c.liveReadFallback == nil {
+		return c.getCachedInstance()
+	}
+	reason := c.liveReadFallback.reason()
+	if len(reason) == 0 {
+		instance, err := c.getCachedInstance()
+		if err == nil && instance.GetResourceVersion() == resourceVersion {
+			return instance, nil
+		}
+		reason = "ResourceVersionMismatch"
+	}
+	return c.getLiveInstance(ctx, reason)
+}
+
+func (c dynamicOperatorClient) getLiveInstance(ctx context.Context, reason string) (*unstructured.Unstructured, error) {
+	metrics.ObserveLiveReadFallback(c.liveReadFallback.resource, reason)
+	klog.V(4).Infof("Reading %s %q from the API server: %s", c.liveReadFallback.resource, c.configName, reason)
+	instance, err := c.client.Get(ctx, c.configName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if cached, err := c.getCachedInstance(); err == nil && cached.GetResourceVersion() == instance.GetResourceVersion() {
+		c.liveReadFallback.confirm()
+	}
+	return instance, nil
+}
+
+func (c dynamicOperatorClient) getCachedInstance() (*unstructured.Unstructured, error) {
+	uncastInstance, err := c.informer.Lister().Get(c.configName)
+	if err != nil {
+		return nil, err
+	}
+	return uncastInstance.(*unstructured.Unstructured), nil
+}
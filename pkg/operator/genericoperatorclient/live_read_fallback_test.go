@@ -0,0 +1,102 @@
+package genericoperatorclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestLiveReadFallback(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "operator.openshift.io", Version: "v1", Resource: "tests"}
+	operator := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "operator.openshift.io/v1",
+		"kind":       "Test",
+		"metadata":   map[string]interface{}{"name": "cluster", "resourceVersion": "1"},
+		"spec":       map[string]interface{}{"managementState": "Managed"},
+	}}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{gvr: "TestList"}, operator)
+	informers := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, 0)
+
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	c := &dynamicOperatorClient{
+		configName: defaultConfigName,
+		resource:   gvr.GroupResource().String(),
+		informer:   informers.ForResource(gvr),
+		client:     dynamicClient.Resource(gvr),
+	}
+	if err := c.applyOptions([]Option{WithLiveReadFallback(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	fakeClock := clocktesting.NewFakePassiveClock(now)
+	c.liveReadFallback.clock = fakeClock
+
+	// getOperatorState reads the operator state and returns whether it was read from the API server
+	getOperatorState := func(t *testing.T) bool {
+		t.Helper()
+		dynamicClient.ClearActions()
+		spec, _, _, err := c.GetOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if spec.ManagementState != "Managed" {
+			t.Errorf("unexpected spec %v", spec)
+		}
+		for _, action := range dynamicClient.Actions() {
+			if action.GetVerb() == "get" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if reason := c.liveReadFallback.reason(); reason != "NotSynced" {
+		t.Errorf("expected the cache to be distrusted until synced, got %q", reason)
+	}
+	if !getOperatorState(t) {
+		t.Errorf("expected a live read before the informer is synced")
+	}
+	status, err := c.UpdateOperatorStatus(context.Background(), "1", &operatorv1.OperatorStatus{ObservedGeneration: 1})
+	if err != nil {
+		t.Fatalf("expected the status to be updated before the informer is synced, got %v", err)
+	}
+	if status.ObservedGeneration != 1 {
+		t.Errorf("unexpected status %v", status)
+	}
+	if updated, err := dynamicClient.Resource(gvr).Get(context.Background(), "cluster", metav1.GetOptions{}); err != nil {
+		t.Fatal(err)
+	} else if managementState, _, _ := unstructured.NestedString(updated.Object, "spec", "managementState"); managementState != "Managed" {
+		t.Errorf("expected the spec to be preserved by the status update, got %v", updated.Object)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	informers.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), c.informer.Informer().HasSynced) {
+		t.Fatal("failed to sync the informer")
+	}
+	if getOperatorState(t) {
+		t.Errorf("expected a cached read once the informer observed the resource")
+	}
+
+	now = now.Add(2 * time.Minute)
+	fakeClock.SetTime(now)
+	if reason := c.liveReadFallback.reason(); reason != "Stale" {
+		t.Errorf("expected the cache to be distrusted after the threshold, got %q", reason)
+	}
+	if !getOperatorState(t) {
+		t.Errorf("expected a live read once the cache is stale")
+	}
+	if getOperatorState(t) {
+		t.Errorf("expected a cached read once the live read confirmed the cached version")
+	}
+}
@@ -0,0 +1,38 @@
+package genericoperatorclient
+
+import (
+	k8smetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+// metrics provides access to all generic operator client metrics.
+var metrics *operatorClientMetrics
+
+func init() {
+	metrics = newOperatorClientMetrics(legacyregistry.Register)
+}
+
+// operatorClientMetrics instruments the generic operator clients with prometheus metrics.
+type operatorClientMetrics struct {
+	liveReadFallbacks *k8smetrics.CounterVec
+}
+
+// newOperatorClientMetrics creates a new operatorClientMetrics, configured with default metric names.
+func newOperatorClientMetrics(registerFunc func(k8smetrics.Registerable) error) *operatorClientMetrics {
+	liveReadFallbacks := k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Namespace: "generic_operator_client",
+			Name:      "live_read_fallbacks_total",
+			Help:      "The number of reads of the operator resource from the API server instead of the informer cache, labeled with the resource and the reason (NotSynced or Stale)",
+		}, []string{"resource", "reason"})
+	registerFunc(liveReadFallbacks)
+
+	return &operatorClientMetrics{
+		liveReadFallbacks: liveReadFallbacks,
+	}
+}
+
+// ObserveLiveReadFallback counts a live read of the operator resource.
+func (m *operatorClientMetrics) ObserveLiveReadFallback(resource, reason string) {
+	m.liveReadFallbacks.WithLabelValues(resource, reason).Inc()
+}
@@ -0,0 +1,205 @@
+package genericoperatorclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// MirroredConditionsAnnotation records in the remote resource the types of the conditions mirrored by each field
+// manager, as a JSON object mapping the field managers to the lists of condition types.
+const MirroredConditionsAnnotation = "operator.openshift.io/mirrored-conditions"
+
+// NewStatusMirrorController returns a controller which mirrors the conditions of the status of the operator resource
+// of operatorClient into the status of the resource name of remoteClient, e.g. the operator resource of a hosted
+// cluster for a control plane operator running in the management cluster. The controllers using operatorClient report
+// into both resources without any change.
+//
+// The controller syncs on every change of the operator resource, and periodically to revert the drift of the remote
+// resource. The mirror owns the types of the conditions it writes, which are recorded per fieldManager in the
+// MirroredConditionsAnnotation of the remote resource, so that the conditions removed locally are removed remotely
+// across restarts. The other conditions of the remote resource are preserved, so that several writers can share it.
+// The remote updates rely on the resource version to never overwrite a concurrent update.
+func NewStatusMirrorController(
+	operatorClient v1helpers.OperatorClient,
+	remoteClient dynamic.ResourceInterface,
+	name, fieldManager string,
+	recorder events.Recorder,
+) factory.Controller {
+	m := &statusMirror{
+		operatorClient: operatorClient,
+		remoteClient:   remoteClient,
+		name:           name,
+		fieldManager:   fieldManager,
+	}
+	return factory.New().
+		WithInformers(operatorClient.Informer()).
+		ResyncEvery(time.Minute).
+		WithSync(m.sync).
+		ToController("StatusMirrorController", recorder.WithComponentSuffix("status-mirror-controller"))
+}
+
+type statusMirror struct {
+	operatorClient v1helpers.OperatorClient
+
+	remoteClient dynamic.ResourceInterface
+	name         string
+	fieldManager string
+}
+
+func (m *statusMirror) sync(ctx context.Context, _ factory.SyncContext) error {
+	_, status, _, err := m.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	return m.mirror(ctx, status.Conditions)
+}
+
+// mirror sets the conditions in the status of the remote resource. The desired condition types are added to the
+// recorded ownership before the status is written, and the ownership is narrowed to them once it is, so that a
+// failure in between never loses track of a mirrored condition.
+func (m *statusMirror) mirror(ctx context.Context, conditions []operatorv1.OperatorCondition) error {
+	desiredTypes := sets.NewString()
+	for _, condition := range conditions {
+		desiredTypes.Insert(condition.Type)
+	}
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		remote, err := m.remoteClient.Get(ctx, m.name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		owned, err := m.ownedConditionTypes(remote)
+		if err != nil {
+			return err
+		}
+
+		if !owned.IsSuperset(desiredTypes) {
+			owned = owned.Union(desiredTypes)
+			if remote, err = m.recordOwnedConditionTypes(ctx, remote, owned); err != nil {
+				return err
+			}
+		}
+
+		remoteStatus, err := getOperatorStatusFromUnstructured(remote.UnstructuredContent())
+		if err != nil {
+			return err
+		}
+		merged := mergeOwnedConditions(remoteStatus.Conditions, conditions, owned)
+		if !equality.Semantic.DeepEqual(merged, remoteStatus.Conditions) {
+			remoteStatus.Conditions = merged
+			if err := setOperatorStatusFromUnstructured(remote.UnstructuredContent(), remoteStatus); err != nil {
+				return err
+			}
+			if remote, err = m.remoteClient.UpdateStatus(ctx, remote, metav1.UpdateOptions{FieldManager: m.fieldManager}); err != nil {
+				return fmt.Errorf("failed to update the status of %q: %w", m.name, err)
+			}
+		}
+
+		if !owned.Equal(desiredTypes) {
+			if _, err := m.recordOwnedConditionTypes(ctx, remote, desiredTypes); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ownedConditionTypes returns the condition types recorded for the field manager in the remote resource.
+func (m *statusMirror) ownedConditionTypes(remote *unstructured.Unstructured) (sets.String, error) {
+	owners, err := mirroredConditions(remote)
+	if err != nil {
+		return nil, err
+	}
+	return sets.NewString(owners[m.fieldManager]...), nil
+}
+
+// recordOwnedConditionTypes records the condition types for the field manager in the remote resource.
+func (m *statusMirror) recordOwnedConditionTypes(ctx context.Context, remote *unstructured.Unstructured, owned sets.String) (*unstructured.Unstructured, error) {
+	owners, err := mirroredConditions(remote)
+	if err != nil {
+		return nil, err
+	}
+	if owned.Len() == 0 {
+		delete(owners, m.fieldManager)
+	} else {
+		owners[m.fieldManager] = owned.List()
+	}
+
+	remote = remote.DeepCopy()
+	annotations := remote.GetAnnotations()
+	if len(owners) == 0 {
+		delete(annotations, MirroredConditionsAnnotation)
+	} else {
+		value, err := json.Marshal(owners)
+		if err != nil {
+			return nil, err
+		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[MirroredConditionsAnnotation] = string(value)
+	}
+	remote.SetAnnotations(annotations)
+
+	updated, err := m.remoteClient.Update(ctx, remote, metav1.UpdateOptions{FieldManager: m.fieldManager})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record the mirrored conditions of %q: %w", m.name, err)
+	}
+	return updated, nil
+}
+
+// mirroredConditions returns the content of the MirroredConditionsAnnotation of the remote resource.
+func mirroredConditions(remote *unstructured.Unstructured) (map[string][]string, error) {
+	owners := map[string][]string{}
+	value, ok := remote.GetAnnotations()[MirroredConditionsAnnotation]
+	if !ok {
+		return owners, nil
+	}
+	if err := json.Unmarshal([]byte(value), &owners); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", MirroredConditionsAnnotation, err)
+	}
+	return owners, nil
+}
+
+// mergeOwnedConditions returns the existing conditions with the owned ones replaced by the desired conditions: the
+// owned conditions which are not desired anymore are removed, the others are kept in place.
+func mergeOwnedConditions(existing, desired []operatorv1.OperatorCondition, owned sets.String) []operatorv1.OperatorCondition {
+	desiredByType := map[string]operatorv1.OperatorCondition{}
+	for _, condition := range desired {
+		desiredByType[condition.Type] = condition
+	}
+
+	var merged []operatorv1.OperatorCondition
+	seen := sets.NewString()
+	for _, condition := range existing {
+		if !owned.Has(condition.Type) {
+			merged = append(merged, condition)
+			continue
+		}
+		if desiredCondition, ok := desiredByType[condition.Type]; ok && !seen.Has(condition.Type) {
+			merged = append(merged, desiredCondition)
+			seen.Insert(condition.Type)
+		}
+	}
+	for _, condition := range desired {
+		if !seen.Has(condition.Type) {
+			merged = append(merged, condition)
+			seen.Insert(condition.Type)
+		}
+	}
+	return merged
+}
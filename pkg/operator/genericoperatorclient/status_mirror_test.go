@@ -0,0 +1,155 @@
+package genericoperatorclient
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func TestStatusMirroringOperatorClient(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "operator.openshift.io", Version: "v1", Resource: "tests"}
+	remoteOperator := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "operator.openshift.io/v1",
+		"kind":       "Test",
+		"metadata":   map[string]interface{}{"name": "cluster"},
+		"spec":       map[string]interface{}{"managementState": "Managed"},
+		"status": map[string]interface{}{
+			"readyReplicas": int64(3),
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "OtherDegraded", "status": "False"},
+			},
+		},
+	}}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{gvr: "TestList"}, remoteOperator)
+	remoteClient := dynamicClient.Resource(gvr)
+
+	local := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+	// a new mirror is created for every sync, the ownership of the conditions must survive restarts
+	newMirror := func() *statusMirror {
+		return &statusMirror{operatorClient: local, remoteClient: remoteClient, name: "cluster", fieldManager: "control-plane-operator"}
+	}
+	if controller := NewStatusMirrorController(local, remoteClient, "cluster", "control-plane-operator", events.NewInMemoryRecorder("test")); controller == nil {
+		t.Fatal("expected a controller")
+	}
+
+	updateConditions := func(t *testing.T, conditions ...operatorv1.OperatorCondition) {
+		t.Helper()
+		_, status, resourceVersion, err := local.GetOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		status = status.DeepCopy()
+		status.Conditions = conditions
+		if _, err := local.UpdateOperatorStatus(context.TODO(), resourceVersion, status); err != nil {
+			t.Fatal(err)
+		}
+		if err := newMirror().sync(context.TODO(), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	remoteStatus := func(t *testing.T) *operatorv1.OperatorStatus {
+		t.Helper()
+		remote, err := remoteClient.Get(context.TODO(), "cluster", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		status, err := getOperatorStatusFromUnstructured(remote.UnstructuredContent())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+	conditionTypes := func(conditions []operatorv1.OperatorCondition) []string {
+		var types []string
+		for _, condition := range conditions {
+			types = append(types, condition.Type)
+		}
+		return types
+	}
+
+	available := operatorv1.OperatorCondition{Type: "Available", Status: operatorv1.ConditionTrue}
+	degraded := operatorv1.OperatorCondition{Type: "Degraded", Status: operatorv1.ConditionTrue, Reason: "Broken"}
+	updateConditions(t, available, degraded)
+
+	_, localStatus, _, err := local.GetOperatorState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := []string{"Available", "Degraded"}, conditionTypes(localStatus.Conditions); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected the local conditions %v, got %v", expected, actual)
+	}
+	status := remoteStatus(t)
+	if expected, actual := []string{"OtherDegraded", "Available", "Degraded"}, conditionTypes(status.Conditions); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected the remote conditions %v, got %v", expected, actual)
+	}
+	if status.ReadyReplicas != 3 {
+		t.Errorf("expected the other remote status fields to be preserved, got %v", status)
+	}
+
+	remote, err := remoteClient.Get(context.TODO(), "cluster", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := `{"control-plane-operator":["Available","Degraded"]}`, remote.GetAnnotations()[MirroredConditionsAnnotation]; expected != actual {
+		t.Errorf("expected the mirrored conditions %s, got %s", expected, actual)
+	}
+
+	// the conditions removed locally are removed from the remote resource
+	updateConditions(t, available)
+	if expected, actual := []string{"OtherDegraded", "Available"}, conditionTypes(remoteStatus(t).Conditions); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected the remote conditions %v, got %v", expected, actual)
+	}
+	remote, err = remoteClient.Get(context.TODO(), "cluster", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := `{"control-plane-operator":["Available"]}`, remote.GetAnnotations()[MirroredConditionsAnnotation]; expected != actual {
+		t.Errorf("expected the mirrored conditions %s, got %s", expected, actual)
+	}
+
+	// the drift of the remote resource is reverted by the controller
+	remote, err = remoteClient.Get(context.TODO(), "cluster", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := unstructured.SetNestedSlice(remote.Object, []interface{}{
+		map[string]interface{}{"type": "OtherDegraded", "status": "True"},
+		map[string]interface{}{"type": "Available", "status": "False"},
+	}, "status", "conditions"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := remoteClient.UpdateStatus(context.TODO(), remote, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := newMirror().sync(context.TODO(), nil); err != nil {
+		t.Fatal(err)
+	}
+	expected := []operatorv1.OperatorCondition{
+		{Type: "OtherDegraded", Status: operatorv1.ConditionTrue},
+		available,
+	}
+	if actual := remoteStatus(t).Conditions; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected the remote conditions %v, got %v", expected, actual)
+	}
+
+	// nothing is written without a change
+	dynamicClient.ClearActions()
+	if err := newMirror().sync(context.TODO(), nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, action := range dynamicClient.Actions() {
+		if action.GetVerb() == "update" {
+			t.Errorf("expected no update without a change, got %v", action)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package managedresourcescontroller
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/managedresources"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+)
+
+// ManagedResourcesKey is the key of the config map holding the JSON list of the managed objects.
+const ManagedResourcesKey = "managed-resources.json"
+
+// ManagedResourcesController dumps the objects managed by the controllers of the process into a config map, so that
+// must-gather and uninstall tooling can read what the operator owns without reaching the process.
+type ManagedResourcesController struct {
+	namespace, name string
+	configMapClient corev1client.ConfigMapsGetter
+}
+
+// NewManagedResourcesController creates ManagedResourcesController writing the config map namespace/name every minute.
+func NewManagedResourcesController(
+	namespace, name string,
+	configMapClient corev1client.ConfigMapsGetter,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &ManagedResourcesController{
+		namespace:       namespace,
+		name:            name,
+		configMapClient: configMapClient,
+	}
+	return factory.New().ResyncEvery(time.Minute).WithSync(c.sync).ToController("ManagedResourcesController", eventRecorder)
+}
+
+func (c *ManagedResourcesController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	data, err := json.MarshalIndent(managedresources.List(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, _, err = resourceapply.ApplyConfigMap(ctx, c.configMapClient, syncCtx.Recorder(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: c.namespace, Name: c.name},
+		Data:       map[string]string{ManagedResourcesKey: string(data)},
+	})
+	return err
+}
@@ -0,0 +1,114 @@
+// Package managedresources records the objects managed by the controllers of the process, so that what an operator owns
+// can be listed programmatically, e.g. by must-gather or uninstall tooling. The objects applied with resourceapply are
+// registered automatically with the component name of the event recorder as their controller once they are applied,
+// and unregistered when they are deleted with resourceapply.
+package managedresources
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// ManagedResource is an object managed by the controllers of the process.
+type ManagedResource struct {
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Controllers are the controllers managing the object, sorted.
+	Controllers []string `json:"controllers"`
+}
+
+type resourceKey struct {
+	group     string
+	kind      string
+	namespace string
+	name      string
+}
+
+// managedResources holds the controllers managing each object registered since the process started.
+var managedResources = struct {
+	lock      sync.RWMutex
+	resources map[resourceKey]sets.String
+}{resources: map[resourceKey]sets.String{}}
+
+// Register records that the controller manages the object. The object stays registered until it is unregistered,
+// when it is deleted.
+func Register(controller string, gk schema.GroupKind, namespace, name string) {
+	key := resourceKey{group: gk.Group, kind: gk.Kind, namespace: namespace, name: name}
+
+	managedResources.lock.RLock()
+	registered := managedResources.resources[key].Has(controller)
+	managedResources.lock.RUnlock()
+	if registered {
+		return
+	}
+
+	managedResources.lock.Lock()
+	defer managedResources.lock.Unlock()
+	if _, ok := managedResources.resources[key]; !ok {
+		managedResources.resources[key] = sets.NewString()
+	}
+	managedResources.resources[key].Insert(controller)
+}
+
+// Unregister records that the object was deleted: it is no longer managed by any controller.
+func Unregister(gk schema.GroupKind, namespace, name string) {
+	managedResources.lock.Lock()
+	defer managedResources.lock.Unlock()
+	delete(managedResources.resources, resourceKey{group: gk.Group, kind: gk.Kind, namespace: namespace, name: name})
+}
+
+// List returns the objects managed by the controllers of the process, sorted by group, kind, namespace and name.
+func List() []ManagedResource {
+	managedResources.lock.RLock()
+	defer managedResources.lock.RUnlock()
+
+	ret := make([]ManagedResource, 0, len(managedResources.resources))
+	for key, controllers := range managedResources.resources {
+		ret = append(ret, ManagedResource{
+			Group:       key.group,
+			Kind:        key.kind,
+			Namespace:   key.namespace,
+			Name:        key.name,
+			Controllers: controllers.List(),
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		a, b := ret[i], ret[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return ret
+}
+
+// NewDebugHandler returns a handler serving the JSON list of the objects managed by the controllers of the process.
+func NewDebugHandler() http.Handler {
+	return &debugHTTPHandler{}
+}
+
+type debugHTTPHandler struct{}
+
+func (h *debugHTTPHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	data, err := json.Marshal(List())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
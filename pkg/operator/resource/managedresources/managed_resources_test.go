@@ -0,0 +1,61 @@
+package managedresources
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestRegister(t *testing.T) {
+	managedResources.lock.Lock()
+	managedResources.resources = map[resourceKey]sets.String{}
+	managedResources.lock.Unlock()
+
+	Register("operator-static-resources", schema.GroupKind{Kind: "ConfigMap"}, "ns", "config")
+	Register("operator-static-resources", schema.GroupKind{Group: "apps", Kind: "Deployment"}, "ns", "operand")
+	Register("operator-target-config", schema.GroupKind{Kind: "ConfigMap"}, "ns", "config")
+	Register("operator-static-resources", schema.GroupKind{Kind: "ConfigMap"}, "ns", "config")
+	Register("operator-static-resources", schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}, "", "operand")
+
+	expected := []ManagedResource{
+		{Kind: "ConfigMap", Namespace: "ns", Name: "config", Controllers: []string{"operator-static-resources", "operator-target-config"}},
+		{Group: "apps", Kind: "Deployment", Namespace: "ns", Name: "operand", Controllers: []string{"operator-static-resources"}},
+		{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "operand", Controllers: []string{"operator-static-resources"}},
+	}
+	if actual := List(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	w := httptest.NewRecorder()
+	NewDebugHandler().ServeHTTP(w, httptest.NewRequest("GET", "/debug/managed-resources", nil))
+	var served []ManagedResource
+	if err := json.Unmarshal(w.Body.Bytes(), &served); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(served, expected) {
+		t.Errorf("expected the handler to serve %v, got %v", expected, served)
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	managedResources.lock.Lock()
+	managedResources.resources = map[resourceKey]sets.String{}
+	managedResources.lock.Unlock()
+
+	Register("operator-static-resources", schema.GroupKind{Kind: "ConfigMap"}, "ns", "config")
+	Register("operator-target-config", schema.GroupKind{Kind: "ConfigMap"}, "ns", "config")
+	Register("operator-static-resources", schema.GroupKind{Kind: "ConfigMap"}, "ns", "other")
+	Unregister(schema.GroupKind{Kind: "ConfigMap"}, "ns", "config")
+	Unregister(schema.GroupKind{Kind: "ConfigMap"}, "ns", "missing")
+
+	expected := []ManagedResource{
+		{Kind: "ConfigMap", Namespace: "ns", Name: "other", Controllers: []string{"operator-static-resources"}},
+	}
+	if actual := List(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
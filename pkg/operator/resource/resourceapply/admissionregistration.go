@@ -21,15 +21,17 @@ import (
 // and an update performed if the mutatingwebhookconfiguration spec and metadata differ from
 // the previously required spec and metadata based on generation change.
 func ApplyMutatingWebhookConfigurationImproved(ctx context.Context, client admissionregistrationclientv1.MutatingWebhookConfigurationsGetter, recorder events.Recorder,
-	requiredOriginal *admissionregistrationv1.MutatingWebhookConfiguration, cache ResourceCache) (*admissionregistrationv1.MutatingWebhookConfiguration, bool, error) {
+	requiredOriginal *admissionregistrationv1.MutatingWebhookConfiguration, cache ResourceCache) (_ *admissionregistrationv1.MutatingWebhookConfiguration, _ bool, err error) {
 
 	if requiredOriginal == nil {
 		return nil, false, fmt.Errorf("Unexpected nil instead of an object")
 	}
+	defer registerManagedResource(recorder, requiredOriginal, &err)
 
 	existing, err := client.MutatingWebhookConfigurations().Get(ctx, requiredOriginal.GetName(), metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		required := requiredOriginal.DeepCopy()
+		setAuditAnnotations(recorder, required, requiredOriginal)
 		actual, err := client.MutatingWebhookConfigurations().Create(
 			ctx, resourcemerge.WithCleanLabelsAndAnnotations(required).(*admissionregistrationv1.MutatingWebhookConfiguration), metav1.CreateOptions{})
 		reportCreateEvent(recorder, required, err)
@@ -65,6 +67,7 @@ func ApplyMutatingWebhookConfigurationImproved(ctx context.Context, client admis
 
 	klog.V(4).Infof("MutatingWebhookConfiguration %q changes: %v", required.GetNamespace()+"/"+required.GetName(), JSONPatchNoError(existing, toWrite))
 
+	setAuditAnnotations(recorder, toWrite, requiredOriginal)
 	actual, err := client.MutatingWebhookConfigurations().Update(ctx, toWrite, metav1.UpdateOptions{})
 	reportUpdateEvent(recorder, required, err)
 	if err != nil {
@@ -97,14 +100,16 @@ func copyMutatingWebhookCABundle(from, to *admissionregistrationv1.MutatingWebho
 // and an update performed if the validatingwebhookconfiguration spec and metadata differ from
 // the previously required spec and metadata based on generation change.
 func ApplyValidatingWebhookConfigurationImproved(ctx context.Context, client admissionregistrationclientv1.ValidatingWebhookConfigurationsGetter, recorder events.Recorder,
-	requiredOriginal *admissionregistrationv1.ValidatingWebhookConfiguration, cache ResourceCache) (*admissionregistrationv1.ValidatingWebhookConfiguration, bool, error) {
+	requiredOriginal *admissionregistrationv1.ValidatingWebhookConfiguration, cache ResourceCache) (_ *admissionregistrationv1.ValidatingWebhookConfiguration, _ bool, err error) {
 	if requiredOriginal == nil {
 		return nil, false, fmt.Errorf("Unexpected nil instead of an object")
 	}
+	defer registerManagedResource(recorder, requiredOriginal, &err)
 
 	existing, err := client.ValidatingWebhookConfigurations().Get(ctx, requiredOriginal.GetName(), metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		required := requiredOriginal.DeepCopy()
+		setAuditAnnotations(recorder, required, requiredOriginal)
 		actual, err := client.ValidatingWebhookConfigurations().Create(
 			ctx, resourcemerge.WithCleanLabelsAndAnnotations(required).(*admissionregistrationv1.ValidatingWebhookConfiguration), metav1.CreateOptions{})
 		reportCreateEvent(recorder, required, err)
@@ -140,6 +145,7 @@ func ApplyValidatingWebhookConfigurationImproved(ctx context.Context, client adm
 
 	klog.V(4).Infof("ValidatingWebhookConfiguration %q changes: %v", required.GetNamespace()+"/"+required.GetName(), JSONPatchNoError(existing, toWrite))
 
+	setAuditAnnotations(recorder, toWrite, requiredOriginal)
 	actual, err := client.ValidatingWebhookConfigurations().Update(ctx, toWrite, metav1.UpdateOptions{})
 	reportUpdateEvent(recorder, required, err)
 	if err != nil {
@@ -13,10 +13,12 @@ import (
 )
 
 // ApplyCustomResourceDefinitionV1 applies the required CustomResourceDefinition to the cluster.
-func ApplyCustomResourceDefinitionV1(ctx context.Context, client apiextclientv1.CustomResourceDefinitionsGetter, recorder events.Recorder, required *apiextensionsv1.CustomResourceDefinition) (*apiextensionsv1.CustomResourceDefinition, bool, error) {
+func ApplyCustomResourceDefinitionV1(ctx context.Context, client apiextclientv1.CustomResourceDefinitionsGetter, recorder events.Recorder, required *apiextensionsv1.CustomResourceDefinition) (_ *apiextensionsv1.CustomResourceDefinition, _ bool, err error) {
+	defer registerManagedResourceKind(recorder, apiextensionsv1.Kind("CustomResourceDefinition"), required, &err)
 	existing, err := client.CustomResourceDefinitions().Get(ctx, required.Name, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
+		setAuditAnnotations(recorder, requiredCopy, required)
 		actual, err := client.CustomResourceDefinitions().Create(
 			ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*apiextensionsv1.CustomResourceDefinition), metav1.CreateOptions{})
 		reportCreateEvent(recorder, required, err)
@@ -37,6 +39,7 @@ func ApplyCustomResourceDefinitionV1(ctx context.Context, client apiextclientv1.
 		klog.Infof("CustomResourceDefinition %q changes: %s", existing.Name, JSONPatchNoError(existing, existingCopy))
 	}
 
+	setAuditAnnotations(recorder, existingCopy, required)
 	actual, err := client.CustomResourceDefinitions().Update(ctx, existingCopy, metav1.UpdateOptions{})
 	reportUpdateEvent(recorder, required, err)
 
@@ -46,11 +49,13 @@ func ApplyCustomResourceDefinitionV1(ctx context.Context, client apiextclientv1.
 func DeleteCustomResourceDefinitionV1(ctx context.Context, client apiextclientv1.CustomResourceDefinitionsGetter, recorder events.Recorder, required *apiextensionsv1.CustomResourceDefinition) (*apiextensionsv1.CustomResourceDefinition, bool, error) {
 	err := client.CustomResourceDefinitions().Delete(ctx, required.Name, metav1.DeleteOptions{})
 	if err != nil && apierrors.IsNotFound(err) {
+		unregisterManagedResourceKind(apiextensionsv1.Kind("CustomResourceDefinition"), required)
 		return nil, false, nil
 	}
 	if err != nil {
 		return nil, false, err
 	}
 	reportDeleteEvent(recorder, required, err)
+	unregisterManagedResourceKind(apiextensionsv1.Kind("CustomResourceDefinition"), required)
 	return nil, true, nil
 }
@@ -15,10 +15,12 @@ import (
 )
 
 // ApplyAPIService merges objectmeta and requires apiservice coordinates.  It does not touch CA bundles, which should be managed via service CA controller.
-func ApplyAPIService(ctx context.Context, client apiregistrationv1client.APIServicesGetter, recorder events.Recorder, required *apiregistrationv1.APIService) (*apiregistrationv1.APIService, bool, error) {
+func ApplyAPIService(ctx context.Context, client apiregistrationv1client.APIServicesGetter, recorder events.Recorder, required *apiregistrationv1.APIService) (_ *apiregistrationv1.APIService, _ bool, err error) {
+	defer registerManagedResourceKind(recorder, apiregistrationv1.SchemeGroupVersion.WithKind("APIService").GroupKind(), required, &err)
 	existing, err := client.APIServices().Get(ctx, required.Name, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
+		setAuditAnnotations(recorder, requiredCopy, required)
 		actual, err := client.APIServices().Create(
 			ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*apiregistrationv1.APIService), metav1.CreateOptions{})
 		reportCreateEvent(recorder, required, err)
@@ -45,6 +47,7 @@ func ApplyAPIService(ctx context.Context, client apiregistrationv1client.APIServ
 	if klog.V(4).Enabled() {
 		klog.Infof("APIService %q changes: %s", existing.Name, JSONPatchNoError(existing, existingCopy))
 	}
+	setAuditAnnotations(recorder, existingCopy, required)
 	actual, err := client.APIServices().Update(ctx, existingCopy, metav1.UpdateOptions{})
 	reportUpdateEvent(recorder, required, err)
 	return actual, true, err
@@ -104,7 +104,8 @@ func ApplyDeployment(ctx context.Context, client appsclientv1.DeploymentsGetter,
 //
 // DEPRECATED - This method will be removed in 4.6 and callers will need to migrate to ApplyDeployment before then.
 func ApplyDeploymentWithForce(ctx context.Context, client appsclientv1.DeploymentsGetter, recorder events.Recorder, requiredOriginal *appsv1.Deployment, expectedGeneration int64,
-	forceRollout bool) (*appsv1.Deployment, bool, error) {
+	forceRollout bool) (_ *appsv1.Deployment, _ bool, err error) {
+	defer registerManagedResource(recorder, requiredOriginal, &err)
 
 	required := requiredOriginal.DeepCopy()
 	if required.Annotations == nil {
@@ -117,6 +118,7 @@ func ApplyDeploymentWithForce(ctx context.Context, client appsclientv1.Deploymen
 	}
 	existing, err := client.Deployments(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
+		setAuditAnnotations(recorder, required, requiredOriginal)
 		actual, err := client.Deployments(required.Namespace).Create(ctx, required, metav1.CreateOptions{})
 		reportCreateEvent(recorder, required, err)
 		return actual, true, err
@@ -154,6 +156,7 @@ func ApplyDeploymentWithForce(ctx context.Context, client appsclientv1.Deploymen
 		klog.Infof("Deployment %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, toWrite))
 	}
 
+	setAuditAnnotations(recorder, toWrite, requiredOriginal)
 	actual, err := client.Deployments(required.Namespace).Update(ctx, toWrite, metav1.UpdateOptions{})
 	reportUpdateEvent(recorder, required, err)
 	return actual, true, err
@@ -192,7 +195,8 @@ func ApplyDaemonSet(ctx context.Context, client appsclientv1.DaemonSetsGetter, r
 
 // ApplyDaemonSetWithForce merges objectmeta and requires matching generation. It returns the final Object, whether any change as made, and an error
 // DEPRECATED - This method will be removed in 4.6 and callers will need to migrate to ApplyDaemonSet before then.
-func ApplyDaemonSetWithForce(ctx context.Context, client appsclientv1.DaemonSetsGetter, recorder events.Recorder, requiredOriginal *appsv1.DaemonSet, expectedGeneration int64, forceRollout bool) (*appsv1.DaemonSet, bool, error) {
+func ApplyDaemonSetWithForce(ctx context.Context, client appsclientv1.DaemonSetsGetter, recorder events.Recorder, requiredOriginal *appsv1.DaemonSet, expectedGeneration int64, forceRollout bool) (_ *appsv1.DaemonSet, _ bool, err error) {
+	defer registerManagedResource(recorder, requiredOriginal, &err)
 	required := requiredOriginal.DeepCopy()
 	if required.Annotations == nil {
 		required.Annotations = map[string]string{}
@@ -204,6 +208,7 @@ func ApplyDaemonSetWithForce(ctx context.Context, client appsclientv1.DaemonSets
 	}
 	existing, err := client.DaemonSets(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
+		setAuditAnnotations(recorder, required, requiredOriginal)
 		actual, err := client.DaemonSets(required.Namespace).Create(ctx, required, metav1.CreateOptions{})
 		reportCreateEvent(recorder, required, err)
 		return actual, true, err
@@ -240,6 +245,7 @@ func ApplyDaemonSetWithForce(ctx context.Context, client appsclientv1.DaemonSets
 	if klog.V(4).Enabled() {
 		klog.Infof("DaemonSet %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, toWrite))
 	}
+	setAuditAnnotations(recorder, toWrite, requiredOriginal)
 	actual, err := client.DaemonSets(required.Namespace).Update(ctx, toWrite, metav1.UpdateOptions{})
 	reportUpdateEvent(recorder, required, err)
 	return actual, true, err
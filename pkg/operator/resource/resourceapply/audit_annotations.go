@@ -0,0 +1,141 @@
+package resourceapply
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	"github.com/openshift/library-go/pkg/libraryversion"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+const (
+	// CreatedByControllerAnnotation is the component name of the event recorder of the controller which created the
+	// object.
+	CreatedByControllerAnnotation = "operator.openshift.io/created-by-controller"
+	// LibraryGoVersionAnnotation is the version of library-go which last wrote the object.
+	LibraryGoVersionAnnotation = "operator.openshift.io/library-go-version"
+	// LastAppliedHashAnnotation is the hash of the required object last written. The values of the secrets are not
+	// hashed, only their keys are.
+	LastAppliedHashAnnotation = "operator.openshift.io/last-applied-hash"
+	// LastAppliedTimeAnnotation is the time the object was last written.
+	LastAppliedTimeAnnotation = "operator.openshift.io/last-applied-time"
+)
+
+// auditAnnotationsRecorder is a recorder whose apply functions set the audit annotations.
+type auditAnnotationsRecorder struct {
+	events.Recorder
+	version string
+	clock   clock.PassiveClock
+}
+
+// WithAuditAnnotations returns a recorder which makes the apply functions it is passed to stamp the objects they
+// create or update with the controller which created them, the version of library-go, the hash of the required object
+// and the time of the write, so that the fleet tooling can tell who manages an object and when it last changed without
+// the audit logs. The recorders derived from it with ForComponent, WithComponentSuffix and WithContext set them too.
+// The annotations are only set when the object is written: an apply which does not change the object does not update
+// them.
+func WithAuditAnnotations(recorder events.Recorder) events.Recorder {
+	if _, ok := recorder.(*auditAnnotationsRecorder); ok {
+		return recorder
+	}
+	return &auditAnnotationsRecorder{Recorder: recorder, version: libraryversion.Get(), clock: clock.RealClock{}}
+}
+
+func (r *auditAnnotationsRecorder) ForComponent(componentName string) events.Recorder {
+	return r.withRecorder(r.Recorder.ForComponent(componentName))
+}
+
+func (r *auditAnnotationsRecorder) WithComponentSuffix(componentNameSuffix string) events.Recorder {
+	return r.withRecorder(r.Recorder.WithComponentSuffix(componentNameSuffix))
+}
+
+func (r *auditAnnotationsRecorder) WithContext(ctx context.Context) events.Recorder {
+	return r.withRecorder(r.Recorder.WithContext(ctx))
+}
+
+func (r *auditAnnotationsRecorder) withRecorder(recorder events.Recorder) events.Recorder {
+	return &auditAnnotationsRecorder{Recorder: recorder, version: r.version, clock: r.clock}
+}
+
+// setAuditAnnotations stamps obj, about to be written for required, with the audit annotations when the recorder was
+// given by WithAuditAnnotations.
+func setAuditAnnotations(recorder events.Recorder, obj, required runtime.Object) {
+	auditRecorder, ok := recorder.(*auditAnnotationsRecorder)
+	if !ok {
+		return
+	}
+
+	hash, err := appliedHash(required)
+	if err != nil {
+		klog.Warningf("Failed to hash the applied object: %v", err)
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	annotations := accessor.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	if _, ok := annotations[CreatedByControllerAnnotation]; !ok {
+		annotations[CreatedByControllerAnnotation] = recorder.ComponentName()
+	}
+	annotations[LibraryGoVersionAnnotation] = auditRecorder.version
+	if len(hash) > 0 {
+		annotations[LastAppliedHashAnnotation] = hash
+	} else {
+		delete(annotations, LastAppliedHashAnnotation)
+	}
+	annotations[LastAppliedTimeAnnotation] = auditRecorder.clock.Now().UTC().Format(time.RFC3339)
+	accessor.SetAnnotations(annotations)
+}
+
+// appliedHash returns the hash of the content of the required object: the metadata set by the server, the audit
+// annotations and the values of the secrets are not hashed.
+func appliedHash(required runtime.Object) (string, error) {
+	required = required.DeepCopyObject()
+	if secret, ok := required.(*corev1.Secret); ok {
+		for key := range secret.Data {
+			secret.Data[key] = nil
+		}
+		for key := range secret.StringData {
+			secret.StringData[key] = ""
+		}
+	}
+	accessor, err := meta.Accessor(required)
+	if err != nil {
+		return "", err
+	}
+	accessor.SetUID("")
+	accessor.SetResourceVersion("")
+	accessor.SetGeneration(0)
+	accessor.SetCreationTimestamp(metav1.Time{})
+	accessor.SetManagedFields(nil)
+	if annotations := accessor.GetAnnotations(); len(annotations) > 0 {
+		filtered := map[string]string{}
+		for key, value := range annotations {
+			switch key {
+			case CreatedByControllerAnnotation, LibraryGoVersionAnnotation, LastAppliedHashAnnotation, LastAppliedTimeAnnotation:
+			default:
+				filtered[key] = value
+			}
+		}
+		accessor.SetAnnotations(filtered)
+	}
+
+	jsonBytes, err := json.Marshal(required)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(jsonBytes)), nil
+}
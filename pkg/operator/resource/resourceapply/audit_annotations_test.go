@@ -0,0 +1,106 @@
+package resourceapply
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestAuditAnnotations(t *testing.T) {
+	start := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	fakeClock := clocktesting.NewFakePassiveClock(start)
+	withAuditAnnotations := func(recorder events.Recorder) events.Recorder {
+		return &auditAnnotationsRecorder{Recorder: recorder, version: "v0.0.0-test", clock: fakeClock}
+	}
+
+	client := fake.NewSimpleClientset()
+	apply := func(recorder events.Recorder, data string) *corev1.ConfigMap {
+		t.Helper()
+		required := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "config"},
+			Data:       map[string]string{"config.yaml": data},
+		}
+		if _, _, err := ApplyConfigMap(context.TODO(), client.CoreV1(), recorder, required); err != nil {
+			t.Fatal(err)
+		}
+		actual, err := client.CoreV1().ConfigMaps("ns").Get(context.TODO(), "config", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return actual
+	}
+
+	created := apply(withAuditAnnotations(events.NewInMemoryRecorder("creator")), "a")
+	expected := map[string]string{
+		CreatedByControllerAnnotation: "creator",
+		LibraryGoVersionAnnotation:    "v0.0.0-test",
+		LastAppliedTimeAnnotation:     "2023-06-01T00:00:00Z",
+	}
+	for key, value := range expected {
+		if created.Annotations[key] != value {
+			t.Errorf("expected %s=%q on creation, got %q", key, value, created.Annotations[key])
+		}
+	}
+	if len(created.Annotations[LastAppliedHashAnnotation]) == 0 {
+		t.Errorf("expected %s on creation", LastAppliedHashAnnotation)
+	}
+
+	// an apply without change does not touch the annotations
+	fakeClock.SetTime(start.Add(time.Hour))
+	if unchanged := apply(withAuditAnnotations(events.NewInMemoryRecorder("updater")), "a"); unchanged.Annotations[LastAppliedTimeAnnotation] != "2023-06-01T00:00:00Z" {
+		t.Errorf("expected the annotations to be preserved without change, got %v", unchanged.Annotations)
+	}
+
+	// a change updates the annotations, except the creator, also with a derived recorder
+	updated := apply(withAuditAnnotations(events.NewInMemoryRecorder("updater")).WithComponentSuffix("sub"), "b")
+	if updated.Annotations[CreatedByControllerAnnotation] != "creator" {
+		t.Errorf("expected the creator to be preserved, got %q", updated.Annotations[CreatedByControllerAnnotation])
+	}
+	if updated.Annotations[LastAppliedTimeAnnotation] != "2023-06-01T01:00:00Z" {
+		t.Errorf("expected the apply time to be updated, got %q", updated.Annotations[LastAppliedTimeAnnotation])
+	}
+	if updated.Annotations[LastAppliedHashAnnotation] == created.Annotations[LastAppliedHashAnnotation] {
+		t.Errorf("expected the applied hash to change")
+	}
+
+	// the other recorders do not set the annotations
+	fakeClock.SetTime(start.Add(2 * time.Hour))
+	if untouched := apply(events.NewInMemoryRecorder("other"), "c"); untouched.Annotations[LastAppliedTimeAnnotation] != "2023-06-01T01:00:00Z" {
+		t.Errorf("expected the annotations not to be updated without WithAuditAnnotations, got %v", untouched.Annotations)
+	}
+}
+
+func TestAppliedHashRedactsSecrets(t *testing.T) {
+	secret := func(data map[string][]byte) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "secret"},
+			Data:       data,
+		}
+	}
+	hash := func(obj *corev1.Secret) string {
+		t.Helper()
+		hash, err := appliedHash(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+
+	original := secret(map[string][]byte{"password": []byte("hunter2")})
+	if hash(original) != hash(secret(map[string][]byte{"password": []byte("correct horse")})) {
+		t.Errorf("expected the values of the secrets not to be hashed")
+	}
+	if hash(original) == hash(secret(map[string][]byte{"token": []byte("hunter2")})) {
+		t.Errorf("expected the keys of the secrets to be hashed")
+	}
+	if string(original.Data["password"]) != "hunter2" {
+		t.Errorf("expected the secret not to be mutated, got %v", original.Data)
+	}
+}
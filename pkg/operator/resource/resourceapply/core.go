@@ -3,6 +3,8 @@ package resourceapply
 import (
 	"bytes"
 	"context"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"sort"
 	"strings"
@@ -88,10 +90,12 @@ func ApplySecret(ctx context.Context, client coreclientv1.SecretsGetter, recorde
 }
 
 // ApplyNamespace merges objectmeta, does not worry about anything else
-func ApplyNamespaceImproved(ctx context.Context, client coreclientv1.NamespacesGetter, recorder events.Recorder, required *corev1.Namespace, cache ResourceCache) (*corev1.Namespace, bool, error) {
+func ApplyNamespaceImproved(ctx context.Context, client coreclientv1.NamespacesGetter, recorder events.Recorder, required *corev1.Namespace, cache ResourceCache) (_ *corev1.Namespace, _ bool, err error) {
+	defer registerManagedResource(recorder, required, &err)
 	existing, err := client.Namespaces().Get(ctx, required.Name, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
+		setAuditAnnotations(recorder, requiredCopy, required)
 		actual, err := client.Namespaces().
 			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.Namespace), metav1.CreateOptions{})
 		reportCreateEvent(recorder, requiredCopy, err)
@@ -119,6 +123,7 @@ func ApplyNamespaceImproved(ctx context.Context, client coreclientv1.NamespacesG
 		klog.Infof("Namespace %q changes: %v", required.Name, JSONPatchNoError(existing, existingCopy))
 	}
 
+	setAuditAnnotations(recorder, existingCopy, required)
 	actual, err := client.Namespaces().Update(ctx, existingCopy, metav1.UpdateOptions{})
 	reportUpdateEvent(recorder, required, err)
 	cache.UpdateCachedResourceMetadata(required, actual)
@@ -129,9 +134,10 @@ func ApplyNamespaceImproved(ctx context.Context, client coreclientv1.NamespacesG
 // It detects changes in `required`, i.e. an operator needs .spec changes and overwrites existing .spec with those.
 // TODO, since this cannot determine whether changes in `existing` are due to legitimate actors (api server) or illegitimate ones (users), we cannot update.
 // TODO I've special cased the selector for now
-func ApplyServiceImproved(ctx context.Context, client coreclientv1.ServicesGetter, recorder events.Recorder, requiredOriginal *corev1.Service, cache ResourceCache) (*corev1.Service, bool, error) {
+func ApplyServiceImproved(ctx context.Context, client coreclientv1.ServicesGetter, recorder events.Recorder, requiredOriginal *corev1.Service, cache ResourceCache) (_ *corev1.Service, _ bool, err error) {
+	defer registerManagedResource(recorder, requiredOriginal, &err)
 	required := requiredOriginal.DeepCopy()
-	err := SetSpecHashAnnotation(&required.ObjectMeta, required.Spec)
+	err = SetSpecHashAnnotation(&required.ObjectMeta, required.Spec)
 	if err != nil {
 		return nil, false, err
 	}
@@ -139,6 +145,7 @@ func ApplyServiceImproved(ctx context.Context, client coreclientv1.ServicesGette
 	existing, err := client.Services(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
+		setAuditAnnotations(recorder, requiredCopy, requiredOriginal)
 		actual, err := client.Services(requiredCopy.Namespace).
 			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.Service), metav1.CreateOptions{})
 		reportCreateEvent(recorder, requiredCopy, err)
@@ -180,6 +187,7 @@ func ApplyServiceImproved(ctx context.Context, client coreclientv1.ServicesGette
 		klog.Infof("Service %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, required))
 	}
 
+	setAuditAnnotations(recorder, existingCopy, requiredOriginal)
 	actual, err := client.Services(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
 	reportUpdateEvent(recorder, required, err)
 	cache.UpdateCachedResourceMetadata(required, actual)
@@ -187,10 +195,12 @@ func ApplyServiceImproved(ctx context.Context, client coreclientv1.ServicesGette
 }
 
 // ApplyPod merges objectmeta, does not worry about anything else
-func ApplyPodImproved(ctx context.Context, client coreclientv1.PodsGetter, recorder events.Recorder, required *corev1.Pod, cache ResourceCache) (*corev1.Pod, bool, error) {
+func ApplyPodImproved(ctx context.Context, client coreclientv1.PodsGetter, recorder events.Recorder, required *corev1.Pod, cache ResourceCache) (_ *corev1.Pod, _ bool, err error) {
+	defer registerManagedResource(recorder, required, &err)
 	existing, err := client.Pods(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
+		setAuditAnnotations(recorder, requiredCopy, required)
 		actual, err := client.Pods(requiredCopy.Namespace).
 			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.Pod), metav1.CreateOptions{})
 		reportCreateEvent(recorder, requiredCopy, err)
@@ -218,6 +228,7 @@ func ApplyPodImproved(ctx context.Context, client coreclientv1.PodsGetter, recor
 		klog.Infof("Pod %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, required))
 	}
 
+	setAuditAnnotations(recorder, existingCopy, required)
 	actual, err := client.Pods(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
 	reportUpdateEvent(recorder, required, err)
 	cache.UpdateCachedResourceMetadata(required, actual)
@@ -225,10 +236,12 @@ func ApplyPodImproved(ctx context.Context, client coreclientv1.PodsGetter, recor
 }
 
 // ApplyServiceAccount merges objectmeta, does not worry about anything else
-func ApplyServiceAccountImproved(ctx context.Context, client coreclientv1.ServiceAccountsGetter, recorder events.Recorder, required *corev1.ServiceAccount, cache ResourceCache) (*corev1.ServiceAccount, bool, error) {
+func ApplyServiceAccountImproved(ctx context.Context, client coreclientv1.ServiceAccountsGetter, recorder events.Recorder, required *corev1.ServiceAccount, cache ResourceCache) (_ *corev1.ServiceAccount, _ bool, err error) {
+	defer registerManagedResource(recorder, required, &err)
 	existing, err := client.ServiceAccounts(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
+		setAuditAnnotations(recorder, requiredCopy, required)
 		actual, err := client.ServiceAccounts(requiredCopy.Namespace).
 			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.ServiceAccount), metav1.CreateOptions{})
 		reportCreateEvent(recorder, requiredCopy, err)
@@ -254,6 +267,7 @@ func ApplyServiceAccountImproved(ctx context.Context, client coreclientv1.Servic
 	if klog.V(4).Enabled() {
 		klog.Infof("ServiceAccount %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, required))
 	}
+	setAuditAnnotations(recorder, existingCopy, required)
 	actual, err := client.ServiceAccounts(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
 	reportUpdateEvent(recorder, required, err)
 	cache.UpdateCachedResourceMetadata(required, actual)
@@ -261,10 +275,12 @@ func ApplyServiceAccountImproved(ctx context.Context, client coreclientv1.Servic
 }
 
 // ApplyConfigMap merges objectmeta, requires data
-func ApplyConfigMapImproved(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, required *corev1.ConfigMap, cache ResourceCache) (*corev1.ConfigMap, bool, error) {
+func ApplyConfigMapImproved(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, required *corev1.ConfigMap, cache ResourceCache) (_ *corev1.ConfigMap, _ bool, err error) {
+	defer registerManagedResource(recorder, required, &err)
 	existing, err := client.ConfigMaps(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
+		setAuditAnnotations(recorder, requiredCopy, required)
 		actual, err := client.ConfigMaps(requiredCopy.Namespace).
 			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.ConfigMap), metav1.CreateOptions{})
 		reportCreateEvent(recorder, requiredCopy, err)
@@ -330,6 +346,7 @@ func ApplyConfigMapImproved(ctx context.Context, client coreclientv1.ConfigMapsG
 		existingCopy.Data["ca-bundle.crt"] = existingCABundle
 	}
 
+	setAuditAnnotations(recorder, existingCopy, required)
 	actual, err := client.ConfigMaps(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
 
 	var details string
@@ -346,7 +363,8 @@ func ApplyConfigMapImproved(ctx context.Context, client coreclientv1.ConfigMapsG
 }
 
 // ApplySecret merges objectmeta, requires data
-func ApplySecretImproved(ctx context.Context, client coreclientv1.SecretsGetter, recorder events.Recorder, requiredInput *corev1.Secret, cache ResourceCache) (*corev1.Secret, bool, error) {
+func ApplySecretImproved(ctx context.Context, client coreclientv1.SecretsGetter, recorder events.Recorder, requiredInput *corev1.Secret, cache ResourceCache) (_ *corev1.Secret, _ bool, err error) {
+	defer registerManagedResource(recorder, requiredInput, &err)
 	// copy the stringData to data.  Error on a data content conflict inside required.  This is usually a bug.
 
 	existing, err := client.Secrets(requiredInput.Namespace).Get(ctx, requiredInput.Name, metav1.GetOptions{})
@@ -374,6 +392,7 @@ func ApplySecretImproved(ctx context.Context, client coreclientv1.SecretsGetter,
 
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
+		setAuditAnnotations(recorder, requiredCopy, requiredInput)
 		actual, err := client.Secrets(requiredCopy.Namespace).
 			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.Secret), metav1.CreateOptions{})
 		reportCreateEvent(recorder, requiredCopy, err)
@@ -427,6 +446,7 @@ func ApplySecretImproved(ctx context.Context, client coreclientv1.SecretsGetter,
 	 * We need to explicitly opt for delete+create in that case.
 	 */
 	if existingCopy.Type == existing.Type {
+		setAuditAnnotations(recorder, existingCopy, requiredInput)
 		actual, err = client.Secrets(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
 		reportUpdateEvent(recorder, existingCopy, err)
 
@@ -444,6 +464,7 @@ func ApplySecretImproved(ctx context.Context, client coreclientv1.SecretsGetter,
 
 	// clear the RV and track the original actual and error for the return like our create value.
 	existingCopy.ResourceVersion = ""
+	setAuditAnnotations(recorder, existingCopy, requiredInput)
 	actual, err = client.Secrets(required.Namespace).Create(ctx, existingCopy, metav1.CreateOptions{})
 	reportCreateEvent(recorder, existingCopy, err)
 	cache.UpdateCachedResourceMetadata(requiredInput, actual)
@@ -458,6 +479,13 @@ func SyncConfigMap(ctx context.Context, client coreclientv1.ConfigMapsGetter, re
 // SyncPartialConfigMap does what SyncConfigMap does but it only synchronizes a subset of keys given by `syncedKeys`.
 // SyncPartialConfigMap will delete the target if `syncedKeys` are set but the source does not contain any of these keys.
 func SyncPartialConfigMap(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, sourceNamespace, sourceName, targetNamespace, targetName string, syncedKeys sets.String, ownerRefs []metav1.OwnerReference) (*corev1.ConfigMap, bool, error) {
+	return SyncPartialConfigMapWithTransform(ctx, client, recorder, sourceNamespace, sourceName, targetNamespace, targetName, syncedKeys, nil, ownerRefs)
+}
+
+// SyncPartialConfigMapWithTransform does what SyncPartialConfigMap does, but it calls `transform` with the copy of the source
+// after the keys were filtered and before it is applied. The target is deleted if the transformation removed all data.
+// A nil `transform` leaves the copy unchanged.
+func SyncPartialConfigMapWithTransform(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, sourceNamespace, sourceName, targetNamespace, targetName string, syncedKeys sets.String, transform func(*corev1.ConfigMap) error, ownerRefs []metav1.OwnerReference) (*corev1.ConfigMap, bool, error) {
 	source, err := client.ConfigMaps(sourceNamespace).Get(ctx, sourceName, metav1.GetOptions{})
 	switch {
 	case apierrors.IsNotFound(err):
@@ -485,6 +513,18 @@ func SyncPartialConfigMap(ctx context.Context, client coreclientv1.ConfigMapsGet
 			}
 		}
 
+		if transform != nil {
+			hadData := len(source.Data)+len(source.BinaryData) > 0
+			if err := transform(source); err != nil {
+				return nil, false, fmt.Errorf("failed to transform configmap %s/%s: %w", sourceNamespace, sourceName, err)
+			}
+			// remove the synced CM if the transformation removed all data
+			if hadData && len(source.Data)+len(source.BinaryData) == 0 {
+				modified, err := deleteConfigMapSyncTarget(ctx, client, recorder, targetNamespace, targetName)
+				return nil, modified, err
+			}
+		}
+
 		source.Namespace = targetNamespace
 		source.Name = targetName
 		source.ResourceVersion = ""
@@ -519,6 +559,13 @@ func SyncSecret(ctx context.Context, client coreclientv1.SecretsGetter, recorder
 // SyncPartialSecret does what SyncSecret does but it only synchronizes a subset of keys given by `syncedKeys`.
 // SyncPartialSecret will delete the target if `syncedKeys` are set but the source does not contain any of these keys.
 func SyncPartialSecret(ctx context.Context, client coreclientv1.SecretsGetter, recorder events.Recorder, sourceNamespace, sourceName, targetNamespace, targetName string, syncedKeys sets.String, ownerRefs []metav1.OwnerReference) (*corev1.Secret, bool, error) {
+	return SyncPartialSecretWithTransform(ctx, client, recorder, sourceNamespace, sourceName, targetNamespace, targetName, syncedKeys, nil, ownerRefs)
+}
+
+// SyncPartialSecretWithTransform does what SyncPartialSecret does, but it calls `transform` with the copy of the source
+// after the keys were filtered and before it is applied. The target is deleted if the transformation removed all data.
+// A nil `transform` leaves the copy unchanged.
+func SyncPartialSecretWithTransform(ctx context.Context, client coreclientv1.SecretsGetter, recorder events.Recorder, sourceNamespace, sourceName, targetNamespace, targetName string, syncedKeys sets.String, transform func(*corev1.Secret) error, ownerRefs []metav1.OwnerReference) (*corev1.Secret, bool, error) {
 	source, err := client.Secrets(sourceNamespace).Get(ctx, sourceName, metav1.GetOptions{})
 	switch {
 	case apierrors.IsNotFound(err):
@@ -564,6 +611,18 @@ func SyncPartialSecret(ctx context.Context, client coreclientv1.SecretsGetter, r
 			}
 		}
 
+		if transform != nil {
+			hadData := len(source.Data)+len(source.StringData) > 0
+			if err := transform(source); err != nil {
+				return nil, false, fmt.Errorf("failed to transform secret %s/%s: %w", sourceNamespace, sourceName, err)
+			}
+			// remove the synced secret if the transformation removed all data
+			if hadData && len(source.Data)+len(source.StringData) == 0 {
+				modified, err := deleteSecretSyncTarget(ctx, client, recorder, targetNamespace, targetName)
+				return nil, modified, err
+			}
+		}
+
 		source.Namespace = targetNamespace
 		source.Name = targetName
 		source.ResourceVersion = ""
@@ -584,74 +643,325 @@ func deleteSecretSyncTarget(ctx context.Context, client coreclientv1.SecretsGett
 	return false, err
 }
 
+// SyncConfigMapKey copies the value of `sourceKey` of the ConfigMap `sourceNamespace/sourceName` to `targetKey` of the ConfigMap
+// `targetNamespace/targetName`, leaving the other keys of the target untouched. The target is created if it does not exist.
+// If the source or its key does not exist, or `sourceName` is empty, `targetKey` is removed from the target, but the target
+// itself is kept.
+func SyncConfigMapKey(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, sourceNamespace, sourceName, sourceKey, targetNamespace, targetName, targetKey string) (*corev1.ConfigMap, bool, error) {
+	var value *string
+	var binaryValue []byte
+	if len(sourceName) > 0 {
+		source, err := client.ConfigMaps(sourceNamespace).Get(ctx, sourceName, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+		case err != nil:
+			return nil, false, err
+		default:
+			if v, ok := source.Data[sourceKey]; ok {
+				value = &v
+			} else if v, ok := source.BinaryData[sourceKey]; ok {
+				binaryValue = v
+			}
+		}
+	}
+	found := value != nil || binaryValue != nil
+
+	existing, err := client.ConfigMaps(targetNamespace).Get(ctx, targetName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if !found {
+			return nil, false, nil
+		}
+		required := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: targetName}}
+		setConfigMapKey(required, targetKey, value, binaryValue)
+		setAuditAnnotations(recorder, required, required)
+		actual, err := client.ConfigMaps(targetNamespace).Create(ctx, required, metav1.CreateOptions{})
+		reportCreateEvent(recorder, required, err)
+		return actual, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	existingCopy := existing.DeepCopy()
+	if !setConfigMapKey(existingCopy, targetKey, value, binaryValue) {
+		return existing, false, nil
+	}
+	setAuditAnnotations(recorder, existingCopy, existingCopy)
+	actual, err := client.ConfigMaps(targetNamespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
+	reportUpdateEvent(recorder, existingCopy, err, fmt.Sprintf("synced key %q from %s/%s", targetKey, sourceNamespace, sourceName))
+	return actual, true, err
+}
+
+// setConfigMapKey sets key to value or binaryValue, or removes it when both are nil, and returns whether the configmap changed.
+func setConfigMapKey(configMap *corev1.ConfigMap, key string, value *string, binaryValue []byte) bool {
+	existingValue, inData := configMap.Data[key]
+	existingBinaryValue, inBinaryData := configMap.BinaryData[key]
+	switch {
+	case value != nil:
+		if inData && existingValue == *value && !inBinaryData {
+			return false
+		}
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data[key] = *value
+		delete(configMap.BinaryData, key)
+	case binaryValue != nil:
+		if inBinaryData && bytes.Equal(existingBinaryValue, binaryValue) && !inData {
+			return false
+		}
+		if configMap.BinaryData == nil {
+			configMap.BinaryData = map[string][]byte{}
+		}
+		configMap.BinaryData[key] = binaryValue
+		delete(configMap.Data, key)
+	default:
+		if !inData && !inBinaryData {
+			return false
+		}
+		delete(configMap.Data, key)
+		delete(configMap.BinaryData, key)
+	}
+	return true
+}
+
+// SyncSecretKey copies the value of `sourceKey` of the Secret `sourceNamespace/sourceName` to `targetKey` of the Secret
+// `targetNamespace/targetName`, leaving the other keys of the target untouched. The target is created if it does not exist.
+// If the source or its key does not exist, or `sourceName` is empty, `targetKey` is removed from the target, but the target
+// itself is kept.
+func SyncSecretKey(ctx context.Context, client coreclientv1.SecretsGetter, recorder events.Recorder, sourceNamespace, sourceName, sourceKey, targetNamespace, targetName, targetKey string) (*corev1.Secret, bool, error) {
+	var value []byte
+	if len(sourceName) > 0 {
+		source, err := client.Secrets(sourceNamespace).Get(ctx, sourceName, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+		case err != nil:
+			return nil, false, err
+		default:
+			value = source.Data[sourceKey]
+		}
+	}
+
+	existing, err := client.Secrets(targetNamespace).Get(ctx, targetName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if value == nil {
+			return nil, false, nil
+		}
+		required := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: targetName},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{targetKey: value},
+		}
+		setAuditAnnotations(recorder, required, required)
+		actual, err := client.Secrets(targetNamespace).Create(ctx, required, metav1.CreateOptions{})
+		reportCreateEvent(recorder, required, err)
+		return actual, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	existingValue, exists := existing.Data[targetKey]
+	if (value == nil && !exists) || (value != nil && exists && bytes.Equal(existingValue, value)) {
+		return existing, false, nil
+	}
+	existingCopy := existing.DeepCopy()
+	if value == nil {
+		delete(existingCopy.Data, targetKey)
+	} else {
+		if existingCopy.Data == nil {
+			existingCopy.Data = map[string][]byte{}
+		}
+		existingCopy.Data[targetKey] = value
+	}
+	setAuditAnnotations(recorder, existingCopy, existingCopy)
+	actual, err := client.Secrets(targetNamespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
+	reportUpdateEvent(recorder, existingCopy, err, fmt.Sprintf("synced key %q from %s/%s", targetKey, sourceNamespace, sourceName))
+	return actual, true, err
+}
+
+// SyncSecretKeysToConfigMap publishes the `keys` of the Secret `sourceNamespace/sourceName` as the ConfigMap `targetNamespace/targetName`,
+// e.g. the CA certificate of a TLS secret. Only the given keys are copied and they must hold PEM encoded certificates,
+// because configmaps are not protected like secrets. The target is deleted if the source or all of the keys do not exist.
+func SyncSecretKeysToConfigMap(ctx context.Context, secrets coreclientv1.SecretsGetter, configMaps coreclientv1.ConfigMapsGetter, recorder events.Recorder, sourceNamespace, sourceName, targetNamespace, targetName string, keys []string, ownerRefs []metav1.OwnerReference) (*corev1.ConfigMap, bool, error) {
+	if len(keys) == 0 {
+		return nil, false, fmt.Errorf("no keys to sync from secret %s/%s", sourceNamespace, sourceName)
+	}
+	source, err := secrets.Secrets(sourceNamespace).Get(ctx, sourceName, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		modified, err := deleteConfigMapSyncTarget(ctx, configMaps, recorder, targetNamespace, targetName)
+		return nil, modified, err
+	case err != nil:
+		return nil, false, err
+	}
+
+	required := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: targetName, OwnerReferences: ownerRefs},
+		Data:       map[string]string{},
+	}
+	for _, key := range keys {
+		value, ok := source.Data[key]
+		if !ok {
+			continue
+		}
+		if err := validateCertificatesPEM(value); err != nil {
+			return nil, false, fmt.Errorf("refusing to publish key %q of secret %s/%s in a configmap: %w", key, sourceNamespace, sourceName, err)
+		}
+		required.Data[key] = string(value)
+	}
+	// remove the synced CM if the requested keys are not present in source
+	if len(required.Data) == 0 {
+		modified, err := deleteConfigMapSyncTarget(ctx, configMaps, recorder, targetNamespace, targetName)
+		return nil, modified, err
+	}
+	return ApplyConfigMap(ctx, configMaps, recorder, required)
+}
+
+// validateCertificatesPEM fails unless data holds only PEM encoded x509 certificates, so that nothing else, e.g. a private
+// key, is published.
+func validateCertificatesPEM(data []byte) error {
+	rest := data
+	count := 0
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return fmt.Errorf("it holds a %q PEM block", block.Type)
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("it holds an invalid certificate: %w", err)
+		}
+		count++
+	}
+	if count == 0 {
+		return fmt.Errorf("it holds no certificate")
+	}
+	if len(bytes.TrimSpace(rest)) > 0 {
+		return fmt.Errorf("it holds data that is not PEM encoded")
+	}
+	return nil
+}
+
+// SyncConfigMapKeysToSecret copies the `keys` of the ConfigMap `sourceNamespace/sourceName` into the Opaque Secret `targetNamespace/targetName`.
+// All keys are copied when `keys` is empty. The target is deleted if the source or all of the keys do not exist.
+func SyncConfigMapKeysToSecret(ctx context.Context, configMaps coreclientv1.ConfigMapsGetter, secrets coreclientv1.SecretsGetter, recorder events.Recorder, sourceNamespace, sourceName, targetNamespace, targetName string, keys []string, ownerRefs []metav1.OwnerReference) (*corev1.Secret, bool, error) {
+	source, err := configMaps.ConfigMaps(sourceNamespace).Get(ctx, sourceName, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		modified, err := deleteSecretSyncTarget(ctx, secrets, recorder, targetNamespace, targetName)
+		return nil, modified, err
+	case err != nil:
+		return nil, false, err
+	}
+
+	syncedKeys := sets.NewString(keys...)
+	required := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: targetName, OwnerReferences: ownerRefs},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{},
+	}
+	for key, value := range source.Data {
+		if len(syncedKeys) == 0 || syncedKeys.Has(key) {
+			required.Data[key] = []byte(value)
+		}
+	}
+	for key, value := range source.BinaryData {
+		if len(syncedKeys) == 0 || syncedKeys.Has(key) {
+			required.Data[key] = value
+		}
+	}
+	// remove the synced secret if the requested keys are not present in source
+	if len(required.Data) == 0 {
+		modified, err := deleteSecretSyncTarget(ctx, secrets, recorder, targetNamespace, targetName)
+		return nil, modified, err
+	}
+	return ApplySecret(ctx, secrets, recorder, required)
+}
+
 func DeleteNamespace(ctx context.Context, client coreclientv1.NamespacesGetter, recorder events.Recorder, required *corev1.Namespace) (*corev1.Namespace, bool, error) {
 	err := client.Namespaces().Delete(ctx, required.Name, metav1.DeleteOptions{})
 	if err != nil && apierrors.IsNotFound(err) {
+		unregisterManagedResource(required)
 		return nil, false, nil
 	}
 	if err != nil {
 		return nil, false, err
 	}
 	reportDeleteEvent(recorder, required, err)
+	unregisterManagedResource(required)
 	return nil, true, nil
 }
 
 func DeleteService(ctx context.Context, client coreclientv1.ServicesGetter, recorder events.Recorder, required *corev1.Service) (*corev1.Service, bool, error) {
 	err := client.Services(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
 	if err != nil && apierrors.IsNotFound(err) {
+		unregisterManagedResource(required)
 		return nil, false, nil
 	}
 	if err != nil {
 		return nil, false, err
 	}
 	reportDeleteEvent(recorder, required, err)
+	unregisterManagedResource(required)
 	return nil, true, nil
 }
 
 func DeletePod(ctx context.Context, client coreclientv1.PodsGetter, recorder events.Recorder, required *corev1.Pod) (*corev1.Pod, bool, error) {
 	err := client.Pods(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
 	if err != nil && apierrors.IsNotFound(err) {
+		unregisterManagedResource(required)
 		return nil, false, nil
 	}
 	if err != nil {
 		return nil, false, err
 	}
 	reportDeleteEvent(recorder, required, err)
+	unregisterManagedResource(required)
 	return nil, true, nil
 }
 
 func DeleteServiceAccount(ctx context.Context, client coreclientv1.ServiceAccountsGetter, recorder events.Recorder, required *corev1.ServiceAccount) (*corev1.ServiceAccount, bool, error) {
 	err := client.ServiceAccounts(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
 	if err != nil && apierrors.IsNotFound(err) {
+		unregisterManagedResource(required)
 		return nil, false, nil
 	}
 	if err != nil {
 		return nil, false, err
 	}
 	reportDeleteEvent(recorder, required, err)
+	unregisterManagedResource(required)
 	return nil, true, nil
 }
 
 func DeleteConfigMap(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, required *corev1.ConfigMap) (*corev1.ConfigMap, bool, error) {
 	err := client.ConfigMaps(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
 	if err != nil && apierrors.IsNotFound(err) {
+		unregisterManagedResource(required)
 		return nil, false, nil
 	}
 	if err != nil {
 		return nil, false, err
 	}
 	reportDeleteEvent(recorder, required, err)
+	unregisterManagedResource(required)
 	return nil, true, nil
 }
 
 func DeleteSecret(ctx context.Context, client coreclientv1.SecretsGetter, recorder events.Recorder, required *corev1.Secret) (*corev1.Secret, bool, error) {
 	err := client.Secrets(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
 	if err != nil && apierrors.IsNotFound(err) {
+		unregisterManagedResource(required)
 		return nil, false, nil
 	}
 	if err != nil {
 		return nil, false, err
 	}
 	reportDeleteEvent(recorder, required, err)
+	unregisterManagedResource(required)
 	return nil, true, nil
 }
@@ -1023,6 +1023,114 @@ func TestSyncSecret(t *testing.T) {
 	}
 }
 
+func TestSyncConfigMapKey(t *testing.T) {
+	source := func(data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "sourceNamespace", Name: "sourceName"}, Data: data}
+	}
+	target := func(data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "targetNamespace", Name: "targetName"}, Data: data}
+	}
+
+	tt := []struct {
+		name            string
+		sourceName      string
+		existingObjects []runtime.Object
+		expectedData    map[string]string
+		expectedChanged bool
+		expectedMissing bool
+	}{
+		{
+			name:            "target is created with the key only",
+			sourceName:      "sourceName",
+			existingObjects: []runtime.Object{source(map[string]string{"ca.crt": "ca", "other": "value"})},
+			expectedData:    map[string]string{"ca-bundle.crt": "ca"},
+			expectedChanged: true,
+		},
+		{
+			name:            "key is merged into the existing target",
+			sourceName:      "sourceName",
+			existingObjects: []runtime.Object{source(map[string]string{"ca.crt": "ca"}), target(map[string]string{"owned": "elsewhere", "ca-bundle.crt": "old"})},
+			expectedData:    map[string]string{"owned": "elsewhere", "ca-bundle.crt": "ca"},
+			expectedChanged: true,
+		},
+		{
+			name:            "up to date target is not changed",
+			sourceName:      "sourceName",
+			existingObjects: []runtime.Object{source(map[string]string{"ca.crt": "ca"}), target(map[string]string{"owned": "elsewhere", "ca-bundle.crt": "ca"})},
+			expectedData:    map[string]string{"owned": "elsewhere", "ca-bundle.crt": "ca"},
+		},
+		{
+			name:            "missing source key removes the key only",
+			sourceName:      "sourceName",
+			existingObjects: []runtime.Object{source(map[string]string{"other": "value"}), target(map[string]string{"owned": "elsewhere", "ca-bundle.crt": "ca"})},
+			expectedData:    map[string]string{"owned": "elsewhere"},
+			expectedChanged: true,
+		},
+		{
+			name:            "empty source removes the key only",
+			existingObjects: []runtime.Object{target(map[string]string{"owned": "elsewhere", "ca-bundle.crt": "ca"})},
+			expectedData:    map[string]string{"owned": "elsewhere"},
+			expectedChanged: true,
+		},
+		{
+			name:            "missing source and target",
+			sourceName:      "sourceName",
+			expectedMissing: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(tc.existingObjects...)
+			_, changed, err := SyncConfigMapKey(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test"), "sourceNamespace", tc.sourceName, "ca.crt", "targetNamespace", "targetName", "ca-bundle.crt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != tc.expectedChanged {
+				t.Errorf("expected changed %t, got %t", tc.expectedChanged, changed)
+			}
+
+			actual, err := client.CoreV1().ConfigMaps("targetNamespace").Get(context.TODO(), "targetName", metav1.GetOptions{})
+			if tc.expectedMissing {
+				if err == nil {
+					t.Errorf("expected no target, got %v", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equality.Semantic.DeepEqual(actual.Data, tc.expectedData) {
+				t.Errorf("data differs: %s", cmp.Diff(tc.expectedData, actual.Data))
+			}
+		})
+	}
+}
+
+func TestSyncSecretKey(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "sourceNamespace", Name: "sourceName"}, Data: map[string][]byte{"tls.crt": []byte("crt"), "tls.key": []byte("key")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "targetNamespace", Name: "targetName"}, Data: map[string][]byte{"owned": []byte("elsewhere")}},
+	)
+	for _, expectedChanged := range []bool{true, false} {
+		_, changed, err := SyncSecretKey(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test"), "sourceNamespace", "sourceName", "tls.crt", "targetNamespace", "targetName", "ca.crt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed != expectedChanged {
+			t.Errorf("expected changed %t, got %t", expectedChanged, changed)
+		}
+	}
+
+	actual, err := client.CoreV1().Secrets("targetNamespace").Get(context.TODO(), "targetName", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := map[string][]byte{"owned": []byte("elsewhere"), "ca.crt": []byte("crt")}; !equality.Semantic.DeepEqual(actual.Data, expected) {
+		t.Errorf("data differs: %s", cmp.Diff(expected, actual.Data))
+	}
+}
+
 func TestSyncPartialSync(t *testing.T) {
 	tt := []struct {
 		name                        string
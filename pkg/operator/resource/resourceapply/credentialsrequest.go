@@ -49,7 +49,8 @@ func ApplyCredentialsRequest(
 	recorder events.Recorder,
 	required *unstructured.Unstructured,
 	expectedGeneration int64,
-) (*unstructured.Unstructured, bool, error) {
+) (_ *unstructured.Unstructured, _ bool, err error) {
+	defer registerManagedResource(recorder, required, &err)
 	if required.GetName() == "" {
 		return nil, false, fmt.Errorf("invalid object: name cannot be empty")
 	}
@@ -61,6 +62,7 @@ func ApplyCredentialsRequest(
 	crClient := client.Resource(credentialsRequestResourceGVR).Namespace(required.GetNamespace())
 	existing, err := crClient.Get(ctx, required.GetName(), metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
+		setAuditAnnotations(recorder, required, required)
 		actual, err := crClient.Create(ctx, required, metav1.CreateOptions{})
 		if err == nil {
 			recorder.Eventf(
@@ -98,6 +100,7 @@ func ApplyCredentialsRequest(
 
 	requiredCopy := required.DeepCopy()
 	existing.Object["spec"] = requiredCopy.Object["spec"]
+	setAuditAnnotations(recorder, existing, required)
 	actual, err := crClient.Update(ctx, existing, metav1.UpdateOptions{})
 	if err != nil {
 		return nil, false, err
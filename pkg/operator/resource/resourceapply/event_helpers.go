@@ -4,11 +4,14 @@ import (
 	"fmt"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	openshiftapi "github.com/openshift/api"
 
 	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/managedresources"
 	"github.com/openshift/library-go/pkg/operator/resource/resourcehelper"
 )
 
@@ -54,3 +57,37 @@ func reportDeleteEvent(recorder events.Recorder, obj runtime.Object, originalErr
 		recorder.Eventf(fmt.Sprintf("%sDeleted", gvk.Kind), "Deleted %s:\n%s", resourcehelper.FormatResourceForCLIWithNamespace(obj), strings.Join(details, "\n"))
 	}
 }
+
+// registerManagedResource records that the controller of the recorder manages the object once it is applied. The
+// apply functions defer it with their error result, and the object is only recorded when the apply succeeded.
+func registerManagedResource(recorder events.Recorder, obj runtime.Object, err *error) {
+	registerManagedResourceKind(recorder, resourcehelper.GuessObjectGroupVersionKind(obj).GroupKind(), obj, err)
+}
+
+// registerManagedResourceKind is like registerManagedResource for the objects whose kind cannot be guessed from the
+// kubernetes and openshift schemes.
+func registerManagedResourceKind(recorder events.Recorder, gk schema.GroupKind, obj runtime.Object, err *error) {
+	if *err != nil {
+		return
+	}
+	accessor, accessorErr := meta.Accessor(obj)
+	if accessorErr != nil {
+		return
+	}
+	managedresources.Register(recorder.ComponentName(), gk, accessor.GetNamespace(), accessor.GetName())
+}
+
+// unregisterManagedResource records that the object was deleted, and is no longer managed by any controller.
+func unregisterManagedResource(obj runtime.Object) {
+	unregisterManagedResourceKind(resourcehelper.GuessObjectGroupVersionKind(obj).GroupKind(), obj)
+}
+
+// unregisterManagedResourceKind is like unregisterManagedResource for the objects whose kind cannot be guessed from
+// the kubernetes and openshift schemes.
+func unregisterManagedResourceKind(gk schema.GroupKind, obj runtime.Object) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	managedresources.Unregister(gk, accessor.GetNamespace(), accessor.GetName())
+}
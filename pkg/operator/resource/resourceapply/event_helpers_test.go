@@ -1,14 +1,18 @@
 package resourceapply
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
 
 	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/managedresources"
 )
 
 func TestReportCreateEvent(t *testing.T) {
@@ -222,3 +226,40 @@ func TestReportDeleteEvent(t *testing.T) {
 		})
 	}
 }
+
+func TestManagedResourceRegistration(t *testing.T) {
+	registered := func(name string) bool {
+		for _, resource := range managedresources.List() {
+			if resource.Kind == "ConfigMap" && resource.Namespace == "managed-resources-test" && resource.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+	required := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "managed-resources-test", Name: "config"}}
+
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("test")
+	})
+	if _, _, err := ApplyConfigMap(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test"), required); err == nil {
+		t.Fatal("expected the apply to fail")
+	}
+	if registered("config") {
+		t.Errorf("the config map was registered although it was not created")
+	}
+
+	client = fake.NewSimpleClientset()
+	if _, _, err := ApplyConfigMap(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test"), required); err != nil {
+		t.Fatal(err)
+	}
+	if !registered("config") {
+		t.Errorf("the config map was not registered")
+	}
+	if _, _, err := DeleteConfigMap(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test"), required); err != nil {
+		t.Fatal(err)
+	}
+	if registered("config") {
+		t.Errorf("the config map is still registered after it was deleted")
+	}
+}
@@ -15,11 +15,13 @@ import (
 )
 
 // ApplyStorageVersionMigration merges objectmeta and required data.
-func ApplyStorageVersionMigration(ctx context.Context, client migrationclientv1alpha1.Interface, recorder events.Recorder, required *migrationv1alpha1.StorageVersionMigration) (*migrationv1alpha1.StorageVersionMigration, bool, error) {
+func ApplyStorageVersionMigration(ctx context.Context, client migrationclientv1alpha1.Interface, recorder events.Recorder, required *migrationv1alpha1.StorageVersionMigration) (_ *migrationv1alpha1.StorageVersionMigration, _ bool, err error) {
+	defer registerManagedResourceKind(recorder, migrationv1alpha1.SchemeGroupVersion.WithKind("StorageVersionMigration").GroupKind(), required, &err)
 	clientInterface := client.MigrationV1alpha1().StorageVersionMigrations()
 	existing, err := clientInterface.Get(ctx, required.Name, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
+		setAuditAnnotations(recorder, requiredCopy, required)
 		actual, err := clientInterface.Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*v1alpha1.StorageVersionMigration), metav1.CreateOptions{})
 		reportCreateEvent(recorder, requiredCopy, err)
 		return actual, true, err
@@ -40,6 +42,7 @@ func ApplyStorageVersionMigration(ctx context.Context, client migrationclientv1a
 	}
 
 	required.Spec.Resource.DeepCopyInto(&existingCopy.Spec.Resource)
+	setAuditAnnotations(recorder, existingCopy, required)
 	actual, err := clientInterface.Update(ctx, existingCopy, metav1.UpdateOptions{})
 	reportUpdateEvent(recorder, required, err)
 	return actual, true, err
@@ -49,11 +52,13 @@ func DeleteStorageVersionMigration(ctx context.Context, client migrationclientv1
 	clientInterface := client.MigrationV1alpha1().StorageVersionMigrations()
 	err := clientInterface.Delete(ctx, required.Name, metav1.DeleteOptions{})
 	if err != nil && apierrors.IsNotFound(err) {
+		unregisterManagedResourceKind(migrationv1alpha1.SchemeGroupVersion.WithKind("StorageVersionMigration").GroupKind(), required)
 		return nil, false, nil
 	}
 	if err != nil {
 		return nil, false, err
 	}
 	reportDeleteEvent(recorder, required, err)
+	unregisterManagedResourceKind(migrationv1alpha1.SchemeGroupVersion.WithKind("StorageVersionMigration").GroupKind(), required)
 	return nil, true, nil
 }
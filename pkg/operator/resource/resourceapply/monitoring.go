@@ -55,11 +55,14 @@ type equalityChecker interface {
 }
 
 // ApplyServiceMonitor applies the Prometheus service monitor.
-func ApplyServiceMonitor(ctx context.Context, client dynamic.Interface, recorder events.Recorder, required *unstructured.Unstructured) (*unstructured.Unstructured, bool, error) {
+func ApplyServiceMonitor(ctx context.Context, client dynamic.Interface, recorder events.Recorder, required *unstructured.Unstructured) (_ *unstructured.Unstructured, _ bool, err error) {
+	defer registerManagedResource(recorder, required, &err)
 	namespace := required.GetNamespace()
 	existing, err := client.Resource(serviceMonitorGVR).Namespace(namespace).Get(ctx, required.GetName(), metav1.GetOptions{})
 	if errors.IsNotFound(err) {
-		newObj, createErr := client.Resource(serviceMonitorGVR).Namespace(namespace).Create(ctx, required, metav1.CreateOptions{})
+		requiredCopy := required.DeepCopy()
+		setAuditAnnotations(recorder, requiredCopy, required)
+		newObj, createErr := client.Resource(serviceMonitorGVR).Namespace(namespace).Create(ctx, requiredCopy, metav1.CreateOptions{})
 		if createErr != nil {
 			recorder.Warningf("ServiceMonitorCreateFailed", "Failed to create ServiceMonitor.monitoring.coreos.com/v1: %v", createErr)
 			return nil, true, createErr
@@ -86,6 +89,7 @@ func ApplyServiceMonitor(ctx context.Context, client dynamic.Interface, recorder
 		klog.Infof("ServiceMonitor %q changes: %v", namespace+"/"+required.GetName(), JSONPatchNoError(existing, toUpdate))
 	}
 
+	setAuditAnnotations(recorder, toUpdate, required)
 	newObj, err := client.Resource(serviceMonitorGVR).Namespace(namespace).Update(ctx, toUpdate, metav1.UpdateOptions{})
 	if err != nil {
 		recorder.Warningf("ServiceMonitorUpdateFailed", "Failed to update ServiceMonitor.monitoring.coreos.com/v1: %v", err)
@@ -99,12 +103,15 @@ func ApplyServiceMonitor(ctx context.Context, client dynamic.Interface, recorder
 var prometheusRuleGVR = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "prometheusrules"}
 
 // ApplyPrometheusRule applies the PrometheusRule
-func ApplyPrometheusRule(ctx context.Context, client dynamic.Interface, recorder events.Recorder, required *unstructured.Unstructured) (*unstructured.Unstructured, bool, error) {
+func ApplyPrometheusRule(ctx context.Context, client dynamic.Interface, recorder events.Recorder, required *unstructured.Unstructured) (_ *unstructured.Unstructured, _ bool, err error) {
+	defer registerManagedResource(recorder, required, &err)
 	namespace := required.GetNamespace()
 
 	existing, err := client.Resource(prometheusRuleGVR).Namespace(namespace).Get(ctx, required.GetName(), metav1.GetOptions{})
 	if errors.IsNotFound(err) {
-		newObj, createErr := client.Resource(prometheusRuleGVR).Namespace(namespace).Create(ctx, required, metav1.CreateOptions{})
+		requiredCopy := required.DeepCopy()
+		setAuditAnnotations(recorder, requiredCopy, required)
+		newObj, createErr := client.Resource(prometheusRuleGVR).Namespace(namespace).Create(ctx, requiredCopy, metav1.CreateOptions{})
 		if createErr != nil {
 			recorder.Warningf("PrometheusRuleCreateFailed", "Failed to create PrometheusRule.monitoring.coreos.com/v1: %v", createErr)
 			return nil, true, createErr
@@ -131,6 +138,7 @@ func ApplyPrometheusRule(ctx context.Context, client dynamic.Interface, recorder
 		klog.Infof("PrometheusRule %q changes: %v", namespace+"/"+required.GetName(), JSONPatchNoError(existing, toUpdate))
 	}
 
+	setAuditAnnotations(recorder, toUpdate, required)
 	newObj, err := client.Resource(prometheusRuleGVR).Namespace(namespace).Update(ctx, toUpdate, metav1.UpdateOptions{})
 	if err != nil {
 		recorder.Warningf("PrometheusRuleUpdateFailed", "Failed to update PrometheusRule.monitoring.coreos.com/v1: %v", err)
@@ -145,12 +153,14 @@ func DeletePrometheusRule(ctx context.Context, client dynamic.Interface, recorde
 	namespace := required.GetNamespace()
 	err := client.Resource(prometheusRuleGVR).Namespace(namespace).Delete(ctx, required.GetName(), metav1.DeleteOptions{})
 	if err != nil && errors.IsNotFound(err) {
+		unregisterManagedResource(required)
 		return nil, false, nil
 	}
 	if err != nil {
 		return nil, false, err
 	}
 	reportDeleteEvent(recorder, required, err)
+	unregisterManagedResource(required)
 	return nil, true, nil
 }
 
@@ -158,11 +168,13 @@ func DeleteServiceMonitor(ctx context.Context, client dynamic.Interface, recorde
 	namespace := required.GetNamespace()
 	err := client.Resource(serviceMonitorGVR).Namespace(namespace).Delete(ctx, required.GetName(), metav1.DeleteOptions{})
 	if err != nil && errors.IsNotFound(err) {
+		unregisterManagedResource(required)
 		return nil, false, nil
 	}
 	if err != nil {
 		return nil, false, err
 	}
 	reportDeleteEvent(recorder, required, err)
+	unregisterManagedResource(required)
 	return nil, true, nil
 }
@@ -0,0 +1,350 @@
+package resourceapply
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+)
+
+// RunLevelAnnotation orders the manifests of a payload directory: manifests with a lower run-level are applied first.
+// Manifests without the annotation have run-level 0.
+const RunLevelAnnotation = "release.openshift.io/run-level"
+
+// Intrinsic tiers of the manifests sharing a run-level.
+const (
+	namespaceTier = iota
+	crdTier
+	defaultTier
+	customResourceTier
+)
+
+// ManifestApplyResult is the result of applying a manifest of a payload directory.
+type ManifestApplyResult struct {
+	ApplyResult
+	// RunLevel is the effective run-level of the manifest: its own run-level, raised to the run-level of the
+	// namespace and of the CRD it depends on.
+	RunLevel int
+	// Skipped is true when the manifest was not applied because a manifest it depends on failed.
+	Skipped bool
+}
+
+// ManifestProgressFunc is called after each manifest of a payload directory is processed, with the number of
+// manifests processed so far and the total number of manifests.
+type ManifestProgressFunc func(result ManifestApplyResult, processed, total int)
+
+// payloadManifest is a single object of a payload directory.
+type payloadManifest struct {
+	// name is the file name, suffixed with the index of the document in multi-document files.
+	name      string
+	raw       []byte
+	obj       *unstructured.Unstructured
+	runLevel  int
+	tier      int
+	dependsOn []*payloadManifest
+	// mapping is the resource of a custom resource of a CRD of the payload.
+	mapping *meta.RESTMapping
+	result  *ManifestApplyResult
+}
+
+// ApplyPayloadDirectory applies the manifests of the .yaml, .yml and .json files of dir, which may contain several
+// documents. The manifests are applied in run-level order, and within a run-level namespaces first, then CRDs, then
+// the other objects and finally the custom resources of the CRDs of the directory. A namespaced object is never
+// applied before its namespace, nor a custom resource before its CRD, regardless of their run-levels, and it is
+// skipped when they fail to apply. The custom resources of the CRDs of the directory are applied with the dynamic
+// client of clients, to the resource defined by their CRD.
+// An error is returned when the directory cannot be read or a manifest cannot be parsed, in which case nothing is
+// applied. Otherwise the results of all manifests are returned in application order, and progress, when not nil,
+// is called after each of them.
+func ApplyPayloadDirectory(ctx context.Context, clients *ClientHolder, recorder events.Recorder, cache ResourceCache, dir string, progress ManifestProgressFunc) ([]ManifestApplyResult, error) {
+	manifests, err := readPayloadDirectory(dir)
+	if err != nil {
+		return nil, err
+	}
+	orderPayloadManifests(manifests)
+
+	assets := make(map[string][]byte, len(manifests))
+	for _, manifest := range manifests {
+		assets[manifest.name] = manifest.raw
+	}
+	assetFunc := func(name string) ([]byte, error) {
+		if raw, ok := assets[name]; ok {
+			return raw, nil
+		}
+		return nil, fmt.Errorf("unknown manifest %q", name)
+	}
+
+	results := make([]ManifestApplyResult, 0, len(manifests))
+	for i, manifest := range manifests {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		result := ManifestApplyResult{RunLevel: manifest.runLevel}
+		if failed := failedDependency(manifest); failed != nil {
+			result.ApplyResult = ApplyResult{File: manifest.name}
+			if obj, err := resourceread.ReadGenericWithUnstructured(manifest.raw); err == nil {
+				result.Type = fmt.Sprintf("%T", obj)
+			}
+			result.Skipped = true
+			result.Error = fmt.Errorf("skipped because %s %q from %q was not applied", failed.obj.GetKind(), failed.obj.GetName(), failed.name)
+		} else if manifest.mapping != nil {
+			result.ApplyResult = applyPayloadCustomResource(ctx, clients, recorder, manifest)
+		} else {
+			result.ApplyResult = ApplyDirectly(ctx, clients, recorder, cache, assetFunc, manifest.name)[0]
+		}
+		manifest.result = &result
+		results = append(results, result)
+		if progress != nil {
+			progress(result, i+1, len(manifests))
+		}
+	}
+	return results, nil
+}
+
+// applyPayloadCustomResource applies a custom resource of a CRD of the payload: its content other than the metadata
+// and the status replaces the one of the existing resource, and its labels and annotations are merged.
+func applyPayloadCustomResource(ctx context.Context, clients *ClientHolder, recorder events.Recorder, manifest *payloadManifest) ApplyResult {
+	result := ApplyResult{File: manifest.name, Type: fmt.Sprintf("%T", manifest.obj)}
+	if clients.dynamicClient == nil {
+		result.Error = fmt.Errorf("missing dynamicClient")
+		return result
+	}
+	// decode the numbers of the manifest as the API server does
+	required := &unstructured.Unstructured{}
+	if err := required.UnmarshalJSON(manifest.raw); err != nil {
+		result.Error = fmt.Errorf("cannot decode %q: %v", manifest.name, err)
+		return result
+	}
+	resourceClient := clients.dynamicClient.Resource(manifest.mapping.Resource)
+	var client dynamic.ResourceInterface = resourceClient
+	if manifest.mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		client = resourceClient.Namespace(required.GetNamespace())
+	}
+
+	defer registerManagedResource(recorder, required, &result.Error)
+	existing, err := client.Get(ctx, required.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		requiredCopy := required.DeepCopy()
+		setAuditAnnotations(recorder, requiredCopy, required)
+		result.Result, result.Error = client.Create(ctx, requiredCopy, metav1.CreateOptions{})
+		result.Changed = true
+		reportCreateEvent(recorder, required, result.Error)
+		return result
+	}
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	toUpdate := existing.DeepCopy()
+	for key, value := range required.Object {
+		switch key {
+		case "apiVersion", "kind", "metadata", "status":
+			continue
+		}
+		toUpdate.Object[key] = runtime.DeepCopyJSONValue(value)
+	}
+	modified := !equality.Semantic.DeepEqual(existing, toUpdate)
+	labels, annotations := toUpdate.GetLabels(), toUpdate.GetAnnotations()
+	metadataModified := false
+	resourcemerge.MergeMap(&metadataModified, &labels, required.GetLabels())
+	resourcemerge.MergeMap(&metadataModified, &annotations, required.GetAnnotations())
+	if metadataModified {
+		toUpdate.SetLabels(labels)
+		toUpdate.SetAnnotations(annotations)
+	}
+	if !modified && !metadataModified {
+		result.Result = existing
+		return result
+	}
+
+	if klog.V(4).Enabled() {
+		klog.Infof("%s %q changes: %v", required.GetKind(), required.GetNamespace()+"/"+required.GetName(), JSONPatchNoError(existing, toUpdate))
+	}
+	setAuditAnnotations(recorder, toUpdate, required)
+	result.Result, result.Error = client.Update(ctx, toUpdate, metav1.UpdateOptions{})
+	result.Changed = true
+	reportUpdateEvent(recorder, required, result.Error)
+	return result
+}
+
+func failedDependency(manifest *payloadManifest) *payloadManifest {
+	for _, dependency := range manifest.dependsOn {
+		if dependency.result == nil || dependency.result.Error != nil {
+			return dependency
+		}
+	}
+	return nil
+}
+
+// readPayloadDirectory reads the manifests of the directory, in file name order.
+func readPayloadDirectory(dir string) ([]*payloadManifest, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifests []*payloadManifest
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		fileManifests, err := parsePayloadFile(entry.Name(), content)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, fileManifests...)
+	}
+	return manifests, nil
+}
+
+func parsePayloadFile(fileName string, content []byte) ([]*payloadManifest, error) {
+	var manifests []*payloadManifest
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 4096)
+	for {
+		obj := map[string]interface{}{}
+		if err := decoder.Decode(&obj); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("cannot parse %q: %v", fileName, err)
+		}
+		// skip empty documents
+		if len(obj) == 0 {
+			continue
+		}
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %q: %v", fileName, err)
+		}
+		manifest := &payloadManifest{raw: raw, obj: &unstructured.Unstructured{Object: obj}}
+		if len(manifest.obj.GetKind()) == 0 || len(manifest.obj.GetName()) == 0 {
+			return nil, fmt.Errorf("cannot parse %q: document %d has no kind or name", fileName, len(manifests))
+		}
+		if value, ok := manifest.obj.GetAnnotations()[RunLevelAnnotation]; ok {
+			manifest.runLevel, err = strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse %q: invalid %s annotation of %s %q: %v", fileName, RunLevelAnnotation, manifest.obj.GetKind(), manifest.obj.GetName(), err)
+			}
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	for i, manifest := range manifests {
+		manifest.name = fileName
+		if len(manifests) > 1 {
+			manifest.name = fmt.Sprintf("%s#%d", fileName, i)
+		}
+	}
+	return manifests, nil
+}
+
+// orderPayloadManifests resolves the dependencies of the manifests on the namespaces and CRDs of the payload, and
+// sorts them in application order. The file name order is kept for manifests of the same run-level and tier.
+func orderPayloadManifests(manifests []*payloadManifest) {
+	namespaces := map[string]*payloadManifest{}
+	crds := map[schema.GroupKind]*payloadManifest{}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, manifest := range manifests {
+		gvk := manifest.obj.GroupVersionKind()
+		switch {
+		case gvk.Group == "" && gvk.Kind == "Namespace":
+			manifest.tier = namespaceTier
+			namespaces[manifest.obj.GetName()] = manifest
+		case gvk.Group == "apiextensions.k8s.io" && gvk.Kind == "CustomResourceDefinition":
+			manifest.tier = crdTier
+			group, _, _ := unstructured.NestedString(manifest.obj.Object, "spec", "group")
+			kind, _, _ := unstructured.NestedString(manifest.obj.Object, "spec", "names", "kind")
+			crds[schema.GroupKind{Group: group, Kind: kind}] = manifest
+			addCRDToRESTMapper(mapper, manifest.obj, group, kind)
+		default:
+			manifest.tier = defaultTier
+		}
+	}
+
+	for _, manifest := range manifests {
+		if namespace, ok := namespaces[manifest.obj.GetNamespace()]; ok {
+			manifest.dependsOn = append(manifest.dependsOn, namespace)
+		}
+		if crd, ok := crds[manifest.obj.GroupVersionKind().GroupKind()]; ok {
+			manifest.tier = customResourceTier
+			manifest.dependsOn = append(manifest.dependsOn, crd)
+			gvk := manifest.obj.GroupVersionKind()
+			// a version the CRD does not define is left to fail in ApplyDirectly
+			if mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err == nil {
+				manifest.mapping = mapping
+			}
+		}
+	}
+	// namespaces and CRDs have no dependencies, a single pass raises all run-levels
+	for _, manifest := range manifests {
+		for _, dependency := range manifest.dependsOn {
+			if dependency.runLevel > manifest.runLevel {
+				manifest.runLevel = dependency.runLevel
+			}
+		}
+	}
+
+	sort.SliceStable(manifests, func(i, j int) bool {
+		if manifests[i].runLevel != manifests[j].runLevel {
+			return manifests[i].runLevel < manifests[j].runLevel
+		}
+		return manifests[i].tier < manifests[j].tier
+	})
+}
+
+// addCRDToRESTMapper adds the resources of the versions of a CRD to the mapper.
+func addCRDToRESTMapper(mapper *meta.DefaultRESTMapper, crd *unstructured.Unstructured, group, kind string) {
+	plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+	singular, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "singular")
+	if len(singular) == 0 {
+		singular = strings.ToLower(kind)
+	}
+	scope := meta.RESTScopeNamespace
+	if scopeName, _, _ := unstructured.NestedString(crd.Object, "spec", "scope"); scopeName == "Cluster" {
+		scope = meta.RESTScopeRoot
+	}
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	for _, version := range versions {
+		versionMap, ok := version.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(versionMap, "name")
+		if len(name) == 0 {
+			continue
+		}
+		gv := schema.GroupVersion{Group: group, Version: name}
+		mapper.AddSpecific(gv.WithKind(kind), gv.WithResource(plural), gv.WithResource(singular), scope)
+	}
+}
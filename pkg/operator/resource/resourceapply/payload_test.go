@@ -0,0 +1,212 @@
+package resourceapply
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+const (
+	payloadConfigMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: payload
+`
+	payloadNamespace = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: payload
+  annotations:
+    release.openshift.io/run-level: "20"
+`
+	payloadServiceAccounts = `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: early
+  namespace: other
+  annotations:
+    release.openshift.io/run-level: "10"
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: operator
+  namespace: payload
+  annotations:
+    release.openshift.io/run-level: "10"
+`
+	payloadCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+`
+	payloadWidget = `{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "widget", "namespace": "payload"}}`
+)
+
+func writePayload(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestApplyPayloadDirectory(t *testing.T) {
+	dir := writePayload(t, map[string]string{
+		"00_configmap.yaml":       payloadConfigMap,
+		"01_widget.json":          payloadWidget,
+		"02_serviceaccounts.yaml": payloadServiceAccounts,
+		"03_crd.yaml":             payloadCRD,
+		"04_namespace.yml":        payloadNamespace,
+		"README.md":               "not a manifest",
+		"05_empty-documents.yaml": "---\n---\n",
+	})
+
+	kubeClient := fake.NewSimpleClientset()
+	var progress []string
+	results, err := ApplyPayloadDirectory(context.TODO(), NewKubeClientHolder(kubeClient), events.NewInMemoryRecorder("payload"), NewResourceCache(), dir,
+		func(result ManifestApplyResult, processed, total int) {
+			if total != 6 || processed != len(progress)+1 {
+				t.Errorf("unexpected progress %d/%d", processed, total)
+			}
+			progress = append(progress, result.File)
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type expectedResult struct {
+		file     string
+		runLevel int
+		failed   bool
+		skipped  bool
+	}
+	expected := []expectedResult{
+		// the CRD fails without the apiextensions client
+		{file: "03_crd.yaml", failed: true},
+		{file: "02_serviceaccounts.yaml#0", runLevel: 10},
+		{file: "04_namespace.yml", runLevel: 20},
+		{file: "00_configmap.yaml", runLevel: 20},
+		{file: "02_serviceaccounts.yaml#1", runLevel: 20},
+		// the custom resource comes after its namespace, and is skipped because of its CRD
+		{file: "01_widget.json", runLevel: 20, failed: true, skipped: true},
+	}
+	var actual []expectedResult
+	for _, result := range results {
+		actual = append(actual, expectedResult{file: result.File, runLevel: result.RunLevel, failed: result.Error != nil, skipped: result.Skipped})
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("unexpected results:\n%#v\nexpected:\n%#v", actual, expected)
+	}
+	if !reflect.DeepEqual(progress, []string{"03_crd.yaml", "02_serviceaccounts.yaml#0", "04_namespace.yml", "00_configmap.yaml", "02_serviceaccounts.yaml#1", "01_widget.json"}) {
+		t.Errorf("unexpected progress: %v", progress)
+	}
+	if results[5].Type != "*unstructured.Unstructured" {
+		t.Errorf("unexpected type of the skipped manifest: %s", results[5].Type)
+	}
+
+	if _, err := kubeClient.CoreV1().ConfigMaps("payload").Get(context.TODO(), "config", metav1.GetOptions{}); err != nil {
+		t.Error(err)
+	}
+	if _, err := kubeClient.CoreV1().ServiceAccounts("payload").Get(context.TODO(), "operator", metav1.GetOptions{}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestApplyPayloadDirectoryInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{
+			name:  "invalid run-level",
+			files: map[string]string{"namespace.yaml": "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: payload\n  annotations:\n    release.openshift.io/run-level: early\n"},
+		},
+		{
+			name:  "no name",
+			files: map[string]string{"namespace.yaml": "apiVersion: v1\nkind: Namespace\n"},
+		},
+		{
+			name:  "invalid yaml",
+			files: map[string]string{"configmap.yaml": payloadConfigMap, "namespace.yaml": "kind: [Namespace\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset()
+			results, err := ApplyPayloadDirectory(context.TODO(), NewKubeClientHolder(kubeClient), events.NewInMemoryRecorder("payload"), NewResourceCache(), writePayload(t, tt.files), nil)
+			if err == nil {
+				t.Fatalf("expected error, got %v", results)
+			}
+			if actions := kubeClient.Actions(); len(actions) > 0 {
+				t.Errorf("unexpected actions: %v", actions)
+			}
+		})
+	}
+}
+
+func TestApplyPayloadDirectoryCustomResources(t *testing.T) {
+	widgetsGVR := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{widgetsGVR: "WidgetList"})
+	clients := NewKubeClientHolder(fake.NewSimpleClientset()).WithAPIExtensionsClient(apiextensionsfake.NewSimpleClientset()).WithDynamicClient(dynamicClient)
+
+	apply := func(widget string) {
+		t.Helper()
+		dir := writePayload(t, map[string]string{
+			"00_crd.yaml":    payloadCRD,
+			"01_widget.yaml": widget,
+		})
+		results, err := ApplyPayloadDirectory(context.TODO(), clients, events.NewInMemoryRecorder("payload"), NewResourceCache(), dir, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, result := range results {
+			if result.Error != nil {
+				t.Fatalf("failed to apply %s: %v", result.File, result.Error)
+			}
+		}
+	}
+	widgetSize := func() int64 {
+		t.Helper()
+		widget, err := dynamicClient.Resource(widgetsGVR).Namespace("payload").Get(context.TODO(), "widget", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		size, _, _ := unstructured.NestedInt64(widget.Object, "spec", "size")
+		return size
+	}
+
+	apply(`{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "widget", "namespace": "payload"}, "spec": {"size": 1}}`)
+	if size := widgetSize(); size != 1 {
+		t.Errorf("expected the widget to be created, got size %d", size)
+	}
+	apply(`{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "widget", "namespace": "payload"}, "spec": {"size": 2}}`)
+	if size := widgetSize(); size != 2 {
+		t.Errorf("expected the widget to be updated, got size %d", size)
+	}
+}
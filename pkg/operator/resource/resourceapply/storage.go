@@ -3,6 +3,7 @@ package resourceapply
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -28,11 +29,13 @@ var (
 )
 
 // ApplyStorageClass merges objectmeta, tries to write everything else
-func ApplyStorageClass(ctx context.Context, client storageclientv1.StorageClassesGetter, recorder events.Recorder, required *storagev1.StorageClass) (*storagev1.StorageClass, bool,
-	error) {
+func ApplyStorageClass(ctx context.Context, client storageclientv1.StorageClassesGetter, recorder events.Recorder, required *storagev1.StorageClass) (_ *storagev1.StorageClass, _ bool,
+	err error) {
+	defer registerManagedResource(recorder, required, &err)
 	existing, err := client.StorageClasses().Get(ctx, required.Name, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
+		setAuditAnnotations(recorder, requiredCopy, required)
 		actual, err := client.StorageClasses().Create(
 			ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*storagev1.StorageClass), metav1.CreateOptions{})
 		reportCreateEvent(recorder, required, err)
@@ -70,6 +73,7 @@ func ApplyStorageClass(ctx context.Context, client storageclientv1.StorageClasse
 		if err != nil && !apierrors.IsNotFound(err) {
 			return existing, false, err
 		}
+		setAuditAnnotations(recorder, requiredCopy, required)
 		actual, err := client.StorageClasses().Create(ctx, requiredCopy, metav1.CreateOptions{})
 		if err != nil && apierrors.IsAlreadyExists(err) {
 			// Delete() few lines above did not really delete the object,
@@ -86,38 +90,46 @@ func ApplyStorageClass(ctx context.Context, client storageclientv1.StorageClasse
 	}
 
 	// Only mutable fields need a change
+	setAuditAnnotations(recorder, requiredCopy, required)
 	actual, err := client.StorageClasses().Update(ctx, requiredCopy, metav1.UpdateOptions{})
 	reportUpdateEvent(recorder, required, err)
 	return actual, true, err
 }
 
 func storageClassNeedsRecreate(oldSC, newSC *storagev1.StorageClass) bool {
+	return len(StorageClassImmutableFieldsDrift(oldSC, newSC)) > 0
+}
+
+// StorageClassImmutableFieldsDrift returns the names of the immutable fields that differ between
+// the two StorageClasses. A StorageClass must be re-created to change any of them.
+func StorageClassImmutableFieldsDrift(oldSC, newSC *storagev1.StorageClass) []string {
+	var drifted []string
 	// Based on kubernetes/kubernetes/pkg/apis/storage/validation/validation.go,
 	// these fields are immutable.
 	if !equality.Semantic.DeepEqual(oldSC.Parameters, newSC.Parameters) {
-		return true
+		drifted = append(drifted, "parameters")
 	}
 	if oldSC.Provisioner != newSC.Provisioner {
-		return true
+		drifted = append(drifted, "provisioner")
 	}
 
 	// In theory, ReclaimPolicy is always set, just in case:
 	if (oldSC.ReclaimPolicy == nil && newSC.ReclaimPolicy != nil) ||
-		(oldSC.ReclaimPolicy != nil && newSC.ReclaimPolicy == nil) {
-		return true
-	}
-	if oldSC.ReclaimPolicy != nil && newSC.ReclaimPolicy != nil && *oldSC.ReclaimPolicy != *newSC.ReclaimPolicy {
-		return true
+		(oldSC.ReclaimPolicy != nil && newSC.ReclaimPolicy == nil) ||
+		(oldSC.ReclaimPolicy != nil && newSC.ReclaimPolicy != nil && *oldSC.ReclaimPolicy != *newSC.ReclaimPolicy) {
+		drifted = append(drifted, "reclaimPolicy")
 	}
 
 	if !equality.Semantic.DeepEqual(oldSC.VolumeBindingMode, newSC.VolumeBindingMode) {
-		return true
+		drifted = append(drifted, "volumeBindingMode")
 	}
-	return false
+	return drifted
 }
 
-// ApplyCSIDriver merges objectmeta, does not worry about anything else
-func ApplyCSIDriver(ctx context.Context, client storageclientv1.CSIDriversGetter, recorder events.Recorder, requiredOriginal *storagev1.CSIDriver) (*storagev1.CSIDriver, bool, error) {
+// ApplyCSIDriver merges objectmeta and spec. Mutable spec fields are updated in place, while a change
+// of an immutable spec field (see CSIDriverImmutableFieldsDrift) deletes and re-creates the CSIDriver.
+func ApplyCSIDriver(ctx context.Context, client storageclientv1.CSIDriversGetter, recorder events.Recorder, requiredOriginal *storagev1.CSIDriver) (_ *storagev1.CSIDriver, _ bool, err error) {
+	defer registerManagedResource(recorder, requiredOriginal, &err)
 
 	required := requiredOriginal.DeepCopy()
 	if required.Annotations == nil {
@@ -133,6 +145,7 @@ func ApplyCSIDriver(ctx context.Context, client storageclientv1.CSIDriversGetter
 	existing, err := client.CSIDrivers().Get(ctx, required.Name, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
+		setAuditAnnotations(recorder, requiredCopy, requiredOriginal)
 		actual, err := client.CSIDrivers().Create(
 			ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*storagev1.CSIDriver), metav1.CreateOptions{})
 		reportCreateEvent(recorder, required, err)
@@ -155,10 +168,13 @@ func ApplyCSIDriver(ctx context.Context, client storageclientv1.CSIDriversGetter
 	existingCopy := existing.DeepCopy()
 	resourcemerge.EnsureObjectMeta(metadataModified, &existingCopy.ObjectMeta, required.ObjectMeta)
 
+	// The spec hash covers changes of the required spec between releases, the drift
+	// of the immutable fields covers CSIDrivers re-created by someone else.
 	requiredSpecHash := required.Annotations[specHashAnnotation]
 	existingSpecHash := existing.Annotations[specHashAnnotation]
 	sameSpec := requiredSpecHash == existingSpecHash
-	if sameSpec && !*metadataModified {
+	drifted := CSIDriverImmutableFieldsDrift(existing, required)
+	if sameSpec && len(drifted) == 0 && !*metadataModified {
 		return existing, false, nil
 	}
 
@@ -166,8 +182,15 @@ func ApplyCSIDriver(ctx context.Context, client storageclientv1.CSIDriversGetter
 		klog.Infof("CSIDriver %q changes: %v", required.Name, JSONPatchNoError(existing, existingCopy))
 	}
 
-	if sameSpec {
-		// Update metadata by a simple Update call
+	if len(drifted) == 0 {
+		// Only metadata or mutable fields changed, update them by a simple Update call.
+		// The immutable fields are kept as they are, they may have been defaulted by the API server.
+		existingCopy.Spec = required.Spec
+		existingCopy.Spec.AttachRequired = existing.Spec.AttachRequired
+		existingCopy.Spec.PodInfoOnMount = existing.Spec.PodInfoOnMount
+		existingCopy.Spec.VolumeLifecycleModes = existing.Spec.VolumeLifecycleModes
+		existingCopy.Spec.FSGroupPolicy = existing.Spec.FSGroupPolicy
+		setAuditAnnotations(recorder, existingCopy, requiredOriginal)
 		actual, err := client.CSIDrivers().Update(ctx, existingCopy, metav1.UpdateOptions{})
 		reportUpdateEvent(recorder, required, err)
 		return actual, true, err
@@ -175,12 +198,13 @@ func ApplyCSIDriver(ctx context.Context, client storageclientv1.CSIDriversGetter
 
 	existingCopy.Spec = required.Spec
 	existingCopy.ObjectMeta.ResourceVersion = ""
-	// Spec is read-only after creation. Delete and re-create the object
+	// Immutable fields changed. Delete and re-create the object
 	err = client.CSIDrivers().Delete(ctx, existingCopy.Name, metav1.DeleteOptions{})
-	reportDeleteEvent(recorder, existingCopy, err, "Deleting CSIDriver to re-create it with updated parameters")
+	reportDeleteEvent(recorder, existingCopy, err, fmt.Sprintf("Deleting CSIDriver to re-create it with updated immutable fields: %s", strings.Join(drifted, ", ")))
 	if err != nil && !apierrors.IsNotFound(err) {
 		return existing, false, err
 	}
+	setAuditAnnotations(recorder, existingCopy, requiredOriginal)
 	actual, err := client.CSIDrivers().Create(ctx, existingCopy, metav1.CreateOptions{})
 	if err != nil && apierrors.IsAlreadyExists(err) {
 		// Delete() few lines above did not really delete the object,
@@ -196,6 +220,28 @@ func ApplyCSIDriver(ctx context.Context, client storageclientv1.CSIDriversGetter
 	return actual, true, err
 }
 
+// CSIDriverImmutableFieldsDrift returns the names of the immutable fields set in the required CSIDriver that differ
+// from the existing one. Fields not set in the required CSIDriver are skipped, the API server defaults them.
+// A CSIDriver must be re-created to change any of them.
+func CSIDriverImmutableFieldsDrift(existing, required *storagev1.CSIDriver) []string {
+	var drifted []string
+	// Based on kubernetes/kubernetes/pkg/apis/storage/validation/validation.go,
+	// these fields are immutable.
+	if required.Spec.AttachRequired != nil && !equality.Semantic.DeepEqual(existing.Spec.AttachRequired, required.Spec.AttachRequired) {
+		drifted = append(drifted, "attachRequired")
+	}
+	if required.Spec.PodInfoOnMount != nil && !equality.Semantic.DeepEqual(existing.Spec.PodInfoOnMount, required.Spec.PodInfoOnMount) {
+		drifted = append(drifted, "podInfoOnMount")
+	}
+	if len(required.Spec.VolumeLifecycleModes) > 0 && !equality.Semantic.DeepEqual(existing.Spec.VolumeLifecycleModes, required.Spec.VolumeLifecycleModes) {
+		drifted = append(drifted, "volumeLifecycleModes")
+	}
+	if required.Spec.FSGroupPolicy != nil && !equality.Semantic.DeepEqual(existing.Spec.FSGroupPolicy, required.Spec.FSGroupPolicy) {
+		drifted = append(drifted, "fsGroupPolicy")
+	}
+	return drifted
+}
+
 func validateRequiredCSIDriverLabels(required *storagev1.CSIDriver) error {
 	supportsEphemeralVolumes := false
 	for _, mode := range required.Spec.VolumeLifecycleModes {
@@ -219,23 +265,27 @@ func DeleteStorageClass(ctx context.Context, client storageclientv1.StorageClass
 	error) {
 	err := client.StorageClasses().Delete(ctx, required.Name, metav1.DeleteOptions{})
 	if err != nil && apierrors.IsNotFound(err) {
+		unregisterManagedResource(required)
 		return nil, false, nil
 	}
 	if err != nil {
 		return nil, false, err
 	}
 	reportDeleteEvent(recorder, required, err)
+	unregisterManagedResource(required)
 	return nil, true, nil
 }
 
 func DeleteCSIDriver(ctx context.Context, client storageclientv1.CSIDriversGetter, recorder events.Recorder, required *storagev1.CSIDriver) (*storagev1.CSIDriver, bool, error) {
 	err := client.CSIDrivers().Delete(ctx, required.Name, metav1.DeleteOptions{})
 	if err != nil && apierrors.IsNotFound(err) {
+		unregisterManagedResource(required)
 		return nil, false, nil
 	}
 	if err != nil {
 		return nil, false, err
 	}
 	reportDeleteEvent(recorder, required, err)
+	unregisterManagedResource(required)
 	return nil, true, nil
 }
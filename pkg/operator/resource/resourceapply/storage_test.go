@@ -368,11 +368,18 @@ func TestApplyStorageClass(t *testing.T) {
 	}
 }
 
+func fsGroupPolicyPtr(policy storagev1.FSGroupPolicy) *storagev1.FSGroupPolicy {
+	return &policy
+}
+
 func TestApplyCSIDriver(t *testing.T) {
 	tests := []struct {
 		name     string
 		existing []*storagev1.CSIDriver
 		input    *storagev1.CSIDriver
+		// existingRecreated sets the spec hash of the existing CSIDrivers to the input's,
+		// as if they were re-created by someone else
+		existingRecreated bool
 
 		expectedModified bool
 		expectedError    error
@@ -469,6 +476,80 @@ func TestApplyCSIDriver(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "mutable spec field updated in place",
+			existing: []*storagev1.CSIDriver{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+					Spec: storagev1.CSIDriverSpec{
+						AttachRequired:  resourcemerge.BoolPtr(true),
+						StorageCapacity: resourcemerge.BoolPtr(false),
+					},
+				},
+			},
+			input: &storagev1.CSIDriver{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				Spec: storagev1.CSIDriverSpec{
+					StorageCapacity: resourcemerge.BoolPtr(true),
+				},
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "csidrivers") {
+					t.Error(spew.Sdump(actions))
+				}
+				expected := &storagev1.CSIDriver{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+					Spec: storagev1.CSIDriverSpec{
+						StorageCapacity: resourcemerge.BoolPtr(true),
+					},
+				}
+				SetSpecHashAnnotation(&expected.ObjectMeta, expected.Spec)
+				// the immutable field defaulted in the existing object is kept
+				expected.Spec.AttachRequired = resourcemerge.BoolPtr(true)
+				actual := actions[1].(clienttesting.UpdateAction).GetObject().(*storagev1.CSIDriver)
+				if !equality.Semantic.DeepEqual(expected, actual) {
+					t.Error(JSONPatchNoError(expected, actual))
+				}
+			},
+		},
+		{
+			name: "immutable spec field drifted in a re-created object",
+			existing: []*storagev1.CSIDriver{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+					Spec: storagev1.CSIDriverSpec{
+						FSGroupPolicy: fsGroupPolicyPtr(storagev1.NoneFSGroupPolicy),
+					},
+				},
+			},
+			existingRecreated: true,
+			input: &storagev1.CSIDriver{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				Spec: storagev1.CSIDriverSpec{
+					FSGroupPolicy: fsGroupPolicyPtr(storagev1.FileFSGroupPolicy),
+				},
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 3 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("delete", "csidrivers") {
+					t.Error(spew.Sdump(actions))
+				}
+				if !actions[2].Matches("create", "csidrivers") {
+					t.Error(spew.Sdump(actions))
+				}
+				actual := actions[2].(clienttesting.CreateAction).GetObject().(*storagev1.CSIDriver)
+				if *actual.Spec.FSGroupPolicy != storagev1.FileFSGroupPolicy {
+					t.Errorf("expected re-created CSIDriver with fsGroupPolicy File, got %v", *actual.Spec.FSGroupPolicy)
+				}
+			},
+		},
 		{
 			name: "no change",
 			existing: []*storagev1.CSIDriver{
@@ -664,6 +745,9 @@ func TestApplyCSIDriver(t *testing.T) {
 			for i, csiDriver := range test.existing {
 				// Add spec hash annotation
 				SetSpecHashAnnotation(&csiDriver.ObjectMeta, csiDriver.Spec)
+				if test.existingRecreated {
+					SetSpecHashAnnotation(&csiDriver.ObjectMeta, test.input.Spec)
+				}
 				// Convert *CSIDriver to *Object
 				objs[i] = csiDriver
 			}
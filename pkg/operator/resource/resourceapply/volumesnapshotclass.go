@@ -77,10 +77,13 @@ func ensureGenericVolumeSnapshotClass(required, existing *unstructured.Unstructu
 }
 
 // ApplyVolumeSnapshotClass applies Volume Snapshot Class.
-func ApplyVolumeSnapshotClass(ctx context.Context, client dynamic.Interface, recorder events.Recorder, required *unstructured.Unstructured) (*unstructured.Unstructured, bool, error) {
+func ApplyVolumeSnapshotClass(ctx context.Context, client dynamic.Interface, recorder events.Recorder, required *unstructured.Unstructured) (_ *unstructured.Unstructured, _ bool, err error) {
+	defer registerManagedResource(recorder, required, &err)
 	existing, err := client.Resource(volumeSnapshotClassResourceGVR).Get(ctx, required.GetName(), metav1.GetOptions{})
 	if errors.IsNotFound(err) {
-		newObj, createErr := client.Resource(volumeSnapshotClassResourceGVR).Create(ctx, required, metav1.CreateOptions{})
+		requiredCopy := required.DeepCopy()
+		setAuditAnnotations(recorder, requiredCopy, required)
+		newObj, createErr := client.Resource(volumeSnapshotClassResourceGVR).Create(ctx, requiredCopy, metav1.CreateOptions{})
 		if createErr != nil {
 			recorder.Warningf("VolumeSnapshotClassCreateFailed", "Failed to create VolumeSnapshotClass.snapshot.storage.k8s.io/v1: %v", createErr)
 			return nil, true, createErr
@@ -105,6 +108,7 @@ func ApplyVolumeSnapshotClass(ctx context.Context, client dynamic.Interface, rec
 		klog.Infof("VolumeSnapshotClass %q changes: %v", required.GetName(), JSONPatchNoError(existing, toUpdate))
 	}
 
+	setAuditAnnotations(recorder, toUpdate, required)
 	newObj, err := client.Resource(volumeSnapshotClassResourceGVR).Update(ctx, toUpdate, metav1.UpdateOptions{})
 	if err != nil {
 		recorder.Warningf("VolumeSnapshotClassFailed", "Failed to update VolumeSnapshotClass.snapshot.storage.k8s.io/v1: %v", err)
@@ -119,11 +123,13 @@ func DeleteVolumeSnapshotClass(ctx context.Context, client dynamic.Interface, re
 	namespace := required.GetNamespace()
 	err := client.Resource(volumeSnapshotClassResourceGVR).Namespace(namespace).Delete(ctx, required.GetName(), metav1.DeleteOptions{})
 	if err != nil && errors.IsNotFound(err) {
+		unregisterManagedResource(required)
 		return nil, false, nil
 	}
 	if err != nil {
 		return nil, false, err
 	}
 	reportDeleteEvent(recorder, required, err)
+	unregisterManagedResource(required)
 	return nil, true, nil
 }
@@ -0,0 +1,385 @@
+package resourcesynccontroller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+)
+
+// FanOutSourceAnnotation is set on the destinations of a fan-out to the namespace/name of the source. It identifies
+// the copies to clean up after a restart.
+const FanOutSourceAnnotation = "operator.openshift.io/resource-sync-fan-out-source"
+
+// FanOutDestination describes the destinations of a source that is synced into many namespaces.
+type FanOutDestination struct {
+	// Name is the name of the destinations. It defaults to the name of the source.
+	Name string `json:"name,omitempty"`
+	// Namespaces are destination namespaces. They must be watched by the controller.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// NamespaceSelector selects more destination namespaces, it is re-evaluated on every sync. It requires
+	// the cluster-wide informers of the controller, i.e. the "" namespace.
+	NamespaceSelector labels.Selector `json:"-"`
+}
+
+// fanOutRule is a source synced to a FanOutDestination.
+type fanOutRule struct {
+	source      ResourceLocation
+	destination FanOutDestination
+
+	lock sync.Mutex
+	// synced are the namespaces the source was synced to by the last sync, to clean up the destinations of namespaces
+	// that are not selected anymore. It is nil until it is rebuilt from the copies in the cluster by the first sync.
+	synced sets.String
+}
+
+type fanOutRules map[fanOutKey]*fanOutRule
+
+type fanOutKey struct {
+	source ResourceLocation
+	name   string
+}
+
+// SyncConfigMapToMany indicates that a configmap should be copied from the source to every namespace of the destination,
+// replacing individual SyncConfigMap calls. It will also mirror a deletion from the source. When a namespace is not
+// selected anymore, its copy is deleted. Namespaces that are being deleted, or that disappeared, are skipped.
+// Rules with a NamespaceSelector must be added before the informers are started.
+// Errors of all namespaces are reported as one.
+func (c *ResourceSyncController) SyncConfigMapToMany(destination FanOutDestination, source ResourceLocation) error {
+	return c.addFanOutRule(c.configMapFanOutRules, destination, source)
+}
+
+// SyncSecretToMany indicates that a secret should be copied from the source to every namespace of the destination.
+// It behaves like SyncConfigMapToMany.
+func (c *ResourceSyncController) SyncSecretToMany(destination FanOutDestination, source ResourceLocation) error {
+	return c.addFanOutRule(c.secretFanOutRules, destination, source)
+}
+
+func (c *ResourceSyncController) addFanOutRule(rules fanOutRules, destination FanOutDestination, source ResourceLocation) error {
+	if source == emptyResourceLocation {
+		return fmt.Errorf("the source of a fan-out must be set")
+	}
+	if !c.knownNamespaces.Has(source.Namespace) {
+		return fmt.Errorf("not watching namespace %q", source.Namespace)
+	}
+	for _, namespace := range destination.Namespaces {
+		if !c.knownNamespaces.Has(namespace) {
+			return fmt.Errorf("not watching namespace %q", namespace)
+		}
+	}
+	if destination.NamespaceSelector != nil {
+		if !c.knownNamespaces.Has("") {
+			return fmt.Errorf("a namespace selector requires cluster-wide informers")
+		}
+		if err := c.ensureNamespaceLister(); err != nil {
+			return err
+		}
+	}
+	if len(destination.Name) == 0 {
+		destination.Name = source.Name
+	}
+
+	c.syncRuleLock.Lock()
+	defer c.syncRuleLock.Unlock()
+	rules[fanOutKey{source: source, name: destination.Name}] = &fanOutRule{
+		source:      source,
+		destination: destination,
+	}
+
+	// make sure the new rule is picked up
+	c.syncCtx.Queue().Add(c.syncCtx.QueueKey())
+	return nil
+}
+
+// ensureNamespaceLister starts watching namespaces to resync when the selected namespaces change.
+func (c *ResourceSyncController) ensureNamespaceLister() error {
+	c.namespaceListerOnce.Do(func() {
+		informer := c.kubeInformersForNamespaces.InformersFor("").Core().V1().Namespaces()
+		_, c.namespaceListerErr = informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(interface{}) { c.syncCtx.Queue().Add(c.syncCtx.QueueKey()) },
+			UpdateFunc: func(interface{}, interface{}) { c.syncCtx.Queue().Add(c.syncCtx.QueueKey()) },
+			DeleteFunc: func(interface{}) { c.syncCtx.Queue().Add(c.syncCtx.QueueKey()) },
+		})
+		if c.namespaceListerErr != nil {
+			c.namespaceListerErr = fmt.Errorf("failed to watch namespaces: %w", c.namespaceListerErr)
+			return
+		}
+		c.namespaceLister = informer.Lister()
+	})
+	return c.namespaceListerErr
+}
+
+// namespaces returns the namespaces the source of the rule is synced to now.
+func (r *fanOutRule) namespaces(namespaceLister corev1listers.NamespaceLister) (sets.String, error) {
+	namespaces := sets.NewString(r.destination.Namespaces...)
+	if r.destination.NamespaceSelector == nil {
+		return namespaces, nil
+	}
+	selected, err := namespaceLister.List(r.destination.NamespaceSelector)
+	if err != nil {
+		return nil, err
+	}
+	for _, namespace := range selected {
+		if namespace.Status.Phase == corev1.NamespaceTerminating {
+			continue
+		}
+		namespaces.Insert(namespace.Name)
+	}
+	return namespaces, nil
+}
+
+// sync syncs the source to all namespaces of the rule with syncFn and deletes the destinations in namespaces that are
+// not selected anymore with deleteFn. The first sync finds the namespaces of the existing copies with copiesFn, so that
+// the copies synced before a restart are cleaned up too. It returns one error for all namespaces.
+func (r *fanOutRule) sync(namespaceLister corev1listers.NamespaceLister, copiesFn func() ([]string, error), syncFn, deleteFn func(namespace string) error) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.synced == nil {
+		copies, err := copiesFn()
+		if err != nil {
+			return errorWithProvider(r.source.Provider, fmt.Errorf("failed to find the copies of %s/%s: %w", r.source.Namespace, r.source.Name, err))
+		}
+		r.synced = sets.NewString(copies...)
+	}
+
+	namespaces, err := r.namespaces(namespaceLister)
+	if err != nil {
+		return errorWithProvider(r.source.Provider, fmt.Errorf("failed to list the destination namespaces of %s/%s: %w", r.source.Namespace, r.source.Name, err))
+	}
+
+	var errs []error
+	var failed []string
+	synced := sets.NewString()
+	for _, namespace := range namespaces.List() {
+		// a missing namespace has nothing to sync to, it's picked up again if it is created
+		if err := syncFn(namespace); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, err)
+			failed = append(failed, namespace)
+		}
+		synced.Insert(namespace)
+	}
+	for _, namespace := range r.synced.Difference(namespaces).List() {
+		if err := deleteFn(namespace); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, err)
+			failed = append(failed, namespace)
+			// retry the cleanup on the next sync
+			synced.Insert(namespace)
+		}
+	}
+	r.synced = synced
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errorWithProvider(r.source.Provider, fmt.Errorf("failed to sync %s/%s to %s in namespaces %s: %w",
+		r.source.Namespace, r.source.Name, r.destination.Name, strings.Join(failed, ", "), utilerrors.NewAggregate(errs)))
+}
+
+func (c *ResourceSyncController) syncFanOuts(ctx context.Context, recorder events.Recorder) []error {
+	configMaps := &namespaceRoutingConfigMapGetter{cached: c.configMapGetter, direct: c.configMapClient, knownNamespaces: c.knownNamespaces}
+	secrets := &namespaceRoutingSecretGetter{cached: c.secretGetter, direct: c.secretClient, knownNamespaces: c.knownNamespaces}
+
+	var errs []error
+	for _, rule := range c.configMapFanOutRules {
+		name := rule.destination.Name
+		source := fanOutSource(rule.source)
+		err := rule.sync(c.namespaceLister,
+			func() ([]string, error) {
+				return c.configMapCopies(ctx, rule)
+			},
+			func(namespace string) error {
+				_, _, err := resourceapply.SyncPartialConfigMapWithTransform(ctx, configMaps, recorder, rule.source.Namespace, rule.source.Name, namespace, name, nil,
+					func(configMap *corev1.ConfigMap) error {
+						if configMap.Annotations == nil {
+							configMap.Annotations = map[string]string{}
+						}
+						configMap.Annotations[FanOutSourceAnnotation] = source
+						return nil
+					}, []metav1.OwnerReference{})
+				return err
+			},
+			func(namespace string) error {
+				return configMaps.ConfigMaps(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+			})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, rule := range c.secretFanOutRules {
+		name := rule.destination.Name
+		source := fanOutSource(rule.source)
+		err := rule.sync(c.namespaceLister,
+			func() ([]string, error) {
+				return c.secretCopies(ctx, rule)
+			},
+			func(namespace string) error {
+				_, _, err := resourceapply.SyncPartialSecretWithTransform(ctx, secrets, recorder, rule.source.Namespace, rule.source.Name, namespace, name, nil,
+					func(secret *corev1.Secret) error {
+						if secret.Annotations == nil {
+							secret.Annotations = map[string]string{}
+						}
+						secret.Annotations[FanOutSourceAnnotation] = source
+						return nil
+					}, []metav1.OwnerReference{})
+				return err
+			},
+			func(namespace string) error {
+				return secrets.Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+			})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	// map iteration is random, keep the condition message stable
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errs
+}
+
+func fanOutSource(source ResourceLocation) string {
+	return source.Namespace + "/" + source.Name
+}
+
+// configMapCopies returns the namespaces of the copies of the rule in the cluster. Rules with a namespace selector look
+// in all namespaces, the others in the watched namespaces.
+func (c *ResourceSyncController) configMapCopies(ctx context.Context, rule *fanOutRule) ([]string, error) {
+	name, source := rule.destination.Name, fanOutSource(rule.source)
+	var copies []string
+	if rule.destination.NamespaceSelector != nil {
+		list, err := c.configMapClient.ConfigMaps(metav1.NamespaceAll).List(ctx, metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("metadata.name", name).String()})
+		if err != nil {
+			return nil, err
+		}
+		for _, configMap := range list.Items {
+			if configMap.Name == name && configMap.Annotations[FanOutSourceAnnotation] == source {
+				copies = append(copies, configMap.Namespace)
+			}
+		}
+		return copies, nil
+	}
+	for _, namespace := range c.knownNamespaces.List() {
+		if len(namespace) == 0 {
+			continue
+		}
+		configMap, err := c.configMapGetter.ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if configMap.Annotations[FanOutSourceAnnotation] == source {
+			copies = append(copies, namespace)
+		}
+	}
+	return copies, nil
+}
+
+// secretCopies returns the namespaces of the copies of the rule in the cluster, like configMapCopies.
+func (c *ResourceSyncController) secretCopies(ctx context.Context, rule *fanOutRule) ([]string, error) {
+	name, source := rule.destination.Name, fanOutSource(rule.source)
+	var copies []string
+	if rule.destination.NamespaceSelector != nil {
+		list, err := c.secretClient.Secrets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("metadata.name", name).String()})
+		if err != nil {
+			return nil, err
+		}
+		for _, secret := range list.Items {
+			if secret.Name == name && secret.Annotations[FanOutSourceAnnotation] == source {
+				copies = append(copies, secret.Namespace)
+			}
+		}
+		return copies, nil
+	}
+	for _, namespace := range c.knownNamespaces.List() {
+		if len(namespace) == 0 {
+			continue
+		}
+		secret, err := c.secretGetter.Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if secret.Annotations[FanOutSourceAnnotation] == source {
+			copies = append(copies, namespace)
+		}
+	}
+	return copies, nil
+}
+
+// namespaceRoutingConfigMapGetter uses the cached getter for the watched namespaces and the client for the others,
+// i.e. the namespaces selected by a label selector.
+type namespaceRoutingConfigMapGetter struct {
+	cached          corev1client.ConfigMapsGetter
+	direct          corev1client.ConfigMapsGetter
+	knownNamespaces sets.String
+}
+
+func (g *namespaceRoutingConfigMapGetter) ConfigMaps(namespace string) corev1client.ConfigMapInterface {
+	if g.knownNamespaces.Has(namespace) {
+		return g.cached.ConfigMaps(namespace)
+	}
+	return g.direct.ConfigMaps(namespace)
+}
+
+// namespaceRoutingSecretGetter uses the cached getter for the watched namespaces and the client for the others,
+// i.e. the namespaces selected by a label selector.
+type namespaceRoutingSecretGetter struct {
+	cached          corev1client.SecretsGetter
+	direct          corev1client.SecretsGetter
+	knownNamespaces sets.String
+}
+
+func (g *namespaceRoutingSecretGetter) Secrets(namespace string) corev1client.SecretInterface {
+	if g.knownNamespaces.Has(namespace) {
+		return g.cached.Secrets(namespace)
+	}
+	return g.direct.Secrets(namespace)
+}
+
+// ResourceFanOutRule is a fan-out rule in the debug output.
+type ResourceFanOutRule struct {
+	Source            ResourceLocation  `json:"source"`
+	Destination       FanOutDestination `json:"destination"`
+	NamespaceSelector string            `json:"namespaceSelector,omitempty"`
+	SyncedNamespaces  []string          `json:"syncedNamespaces"`
+}
+
+func resourceFanOutRuleList(rules fanOutRules) []ResourceFanOutRule {
+	ret := make([]ResourceFanOutRule, 0, len(rules))
+	for _, rule := range rules {
+		rule.lock.Lock()
+		debugRule := ResourceFanOutRule{
+			Source:           rule.source,
+			Destination:      rule.destination,
+			SyncedNamespaces: rule.synced.List(),
+		}
+		rule.lock.Unlock()
+		if rule.destination.NamespaceSelector != nil {
+			debugRule.NamespaceSelector = rule.destination.NamespaceSelector.String()
+		}
+		ret = append(ret, debugRule)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		a, b := ret[i], ret[j]
+		return a.Source.Namespace+"/"+a.Source.Name+"/"+a.Destination.Name < b.Source.Namespace+"/"+b.Source.Name+"/"+b.Destination.Name
+	})
+	return ret
+}
@@ -0,0 +1,212 @@
+package resourcesynccontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func TestSyncToMany(t *testing.T) {
+	namespace := func(name string, labels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	kubeClient := fake.NewSimpleClientset(
+		namespace("tenant-a", map[string]string{"ca": "inject"}),
+		namespace("tenant-b", map[string]string{"ca": "inject"}),
+		namespace("tenant-c", nil),
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "config", Name: "ca"},
+			Data:       map[string]string{"ca.crt": "ca"},
+		},
+	)
+	clusterInformers := informers.NewSharedInformerFactory(kubeClient, time.Minute)
+	operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+	c := NewResourceSyncController(
+		operatorClient,
+		v1helpers.NewFakeKubeInformersForNamespaces(map[string]informers.SharedInformerFactory{
+			"":         clusterInformers,
+			"config":   informers.NewSharedInformerFactoryWithOptions(kubeClient, time.Minute, informers.WithNamespace("config")),
+			"operator": informers.NewSharedInformerFactoryWithOptions(kubeClient, time.Minute, informers.WithNamespace("operator")),
+		}),
+		kubeClient.CoreV1(),
+		kubeClient.CoreV1(),
+		events.NewInMemoryRecorder("test"),
+	)
+	c.configMapGetter = kubeClient.CoreV1()
+	c.secretGetter = kubeClient.CoreV1()
+
+	if err := c.SyncConfigMapToMany(FanOutDestination{Namespaces: []string{"unknown"}}, ResourceLocation{Namespace: "config", Name: "ca"}); err == nil {
+		t.Error("expected an error for a namespace that is not watched")
+	}
+	if err := c.SyncConfigMapToMany(FanOutDestination{
+		Name:              "injected-ca",
+		Namespaces:        []string{"operator"},
+		NamespaceSelector: labels.SelectorFromSet(labels.Set{"ca": "inject"}),
+	}, ResourceLocation{Namespace: "config", Name: "ca"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clusterInformers.Start(ctx.Done())
+	clusterInformers.WaitForCacheSync(ctx.Done())
+
+	expectCopies := func(expected ...string) {
+		t.Helper()
+		if err := c.Sync(ctx, factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+			t.Fatal(err)
+		}
+		_, status, _, _ := operatorClient.GetOperatorState()
+		if cond := v1helpers.FindOperatorCondition(status.Conditions, "ResourceSyncControllerDegraded"); cond == nil || cond.Status != operatorv1.ConditionFalse {
+			t.Errorf("unexpected degraded condition: %v", cond)
+		}
+
+		list, err := kubeClient.CoreV1().ConfigMaps("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := sets.NewString()
+		for _, configMap := range list.Items {
+			if configMap.Name == "injected-ca" {
+				actual.Insert(configMap.Namespace)
+			}
+		}
+		if !actual.Equal(sets.NewString(expected...)) {
+			t.Errorf("expected copies in %v, got %v", expected, actual.List())
+		}
+	}
+
+	expectCopies("operator", "tenant-a", "tenant-b")
+
+	// a namespace that is not selected anymore is cleaned up
+	if _, err := kubeClient.CoreV1().Namespaces().Update(ctx, namespace("tenant-b", nil), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// a new namespace is picked up
+	if _, err := kubeClient.CoreV1().Namespaces().Update(ctx, namespace("tenant-c", map[string]string{"ca": "inject"}), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := wait.PollImmediate(10*time.Millisecond, 10*time.Second, func() (bool, error) {
+		selected, err := c.namespaceLister.List(labels.SelectorFromSet(labels.Set{"ca": "inject"}))
+		return len(selected) == 2 && selected[0].Name != "tenant-b" && selected[1].Name != "tenant-b", err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	expectCopies("operator", "tenant-a", "tenant-c")
+}
+
+func TestFanOutRuleAggregatesErrors(t *testing.T) {
+	rule := &fanOutRule{
+		source:      ResourceLocation{Namespace: "config", Name: "ca", Provider: "the provider"},
+		destination: FanOutDestination{Name: "ca", Namespaces: []string{"a", "b", "c"}},
+		synced:      sets.NewString("d"),
+	}
+	err := rule.sync(nil, nil,
+		func(namespace string) error {
+			if namespace == "b" {
+				return context.DeadlineExceeded
+			}
+			return nil
+		},
+		func(namespace string) error {
+			return context.Canceled
+		})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, expected := range []string{"namespaces b, d", "context deadline exceeded", "context canceled", "the provider"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in %q", expected, err.Error())
+		}
+	}
+	// the failed cleanup is retried
+	if expected := sets.NewString("a", "b", "c", "d"); !rule.synced.Equal(expected) {
+		t.Errorf("expected synced namespaces %v, got %v", expected.List(), rule.synced.List())
+	}
+}
+
+func TestSyncToManyAfterRestart(t *testing.T) {
+	copyOf := func(namespace, source string) *corev1.ConfigMap {
+		configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "injected-ca"}}
+		if len(source) > 0 {
+			configMap.Annotations = map[string]string{FanOutSourceAnnotation: source}
+		}
+		return configMap
+	}
+	kubeClient := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", Labels: map[string]string{"ca": "inject"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-b"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-c"}},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "config", Name: "ca"},
+			Data:       map[string]string{"ca.crt": "ca"},
+		},
+		// synced before the restart, tenant-b is not selected anymore
+		copyOf("tenant-b", "config/ca"),
+		// not synced by the rule
+		copyOf("tenant-c", ""),
+		copyOf("operator", "config/other"),
+	)
+	clusterInformers := informers.NewSharedInformerFactory(kubeClient, time.Minute)
+	operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+	c := NewResourceSyncController(
+		operatorClient,
+		v1helpers.NewFakeKubeInformersForNamespaces(map[string]informers.SharedInformerFactory{
+			"":       clusterInformers,
+			"config": informers.NewSharedInformerFactoryWithOptions(kubeClient, time.Minute, informers.WithNamespace("config")),
+		}),
+		kubeClient.CoreV1(),
+		kubeClient.CoreV1(),
+		events.NewInMemoryRecorder("test"),
+	)
+	c.configMapGetter = kubeClient.CoreV1()
+	c.secretGetter = kubeClient.CoreV1()
+
+	if err := c.SyncConfigMapToMany(FanOutDestination{
+		Name:              "injected-ca",
+		NamespaceSelector: labels.SelectorFromSet(labels.Set{"ca": "inject"}),
+	}, ResourceLocation{Namespace: "config", Name: "ca"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clusterInformers.Start(ctx.Done())
+	clusterInformers.WaitForCacheSync(ctx.Done())
+
+	if err := c.Sync(ctx, factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := kubeClient.CoreV1().ConfigMaps("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := sets.NewString()
+	for _, configMap := range list.Items {
+		if configMap.Name == "injected-ca" {
+			actual.Insert(configMap.Namespace)
+		}
+		if configMap.Namespace == "tenant-a" && configMap.Annotations[FanOutSourceAnnotation] != "config/ca" {
+			t.Errorf("expected the copy to be annotated with its source, got %v", configMap.Annotations)
+		}
+	}
+	if expected := sets.NewString("operator", "tenant-a", "tenant-c"); !actual.Equal(expected) {
+		t.Errorf("expected copies in %v, got %v", expected.List(), actual.List())
+	}
+}
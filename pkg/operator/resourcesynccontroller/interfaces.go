@@ -20,12 +20,26 @@ func alwaysFulfilledPreconditions() (bool, error) { return true, nil }
 
 type syncRuleSource struct {
 	ResourceLocation
+	// Transforms are the names of the transformations applied during the sync, in order.
+	Transforms []string `json:"transforms,omitempty"`
+
 	syncedKeys               sets.String            // defines the set of keys to sync from source to dest
 	preconditionsFulfilledFn preconditionsFulfilled // preconditions to fulfill before syncing the resource
+	configMapTransforms      []ConfigMapTransform   // transformations of configmaps before they are applied
+	secretTransforms         []SecretTransform      // transformations of secrets before they are applied
 }
 
 type syncRules map[ResourceLocation]syncRuleSource
 
+// ResourceKeyLocation describes coordinates for a single key of a resource to be synced
+type ResourceKeyLocation struct {
+	ResourceLocation
+	Key string `json:"key"`
+}
+
+// keySyncRules is a map from destination key location to source key location
+type keySyncRules map[ResourceKeyLocation]ResourceKeyLocation
+
 var (
 	emptyResourceLocation = ResourceLocation{}
 )
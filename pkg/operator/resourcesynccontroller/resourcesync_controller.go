@@ -14,6 +14,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 
@@ -35,15 +36,35 @@ type ResourceSyncController struct {
 	configMapSyncRules syncRules
 	// secretSyncRules is a map from destination location to source location
 	secretSyncRules syncRules
+	// configMapKeySyncRules is a map from destination key location to source key location
+	configMapKeySyncRules keySyncRules
+	// secretKeySyncRules is a map from destination key location to source key location
+	secretKeySyncRules keySyncRules
+	// secretToConfigMapSyncRules is a map from destination configmap location to source secret location
+	secretToConfigMapSyncRules syncRules
+	// configMapToSecretSyncRules is a map from destination secret location to source configmap location
+	configMapToSecretSyncRules syncRules
+	// configMapFanOutRules are the configmaps synced to many namespaces
+	configMapFanOutRules fanOutRules
+	// secretFanOutRules are the secrets synced to many namespaces
+	secretFanOutRules fanOutRules
 
 	// knownNamespaces is the list of namespaces we are watching.
 	knownNamespaces sets.String
 
 	configMapGetter            corev1client.ConfigMapsGetter
 	secretGetter               corev1client.SecretsGetter
+	configMapClient            corev1client.ConfigMapsGetter
+	secretClient               corev1client.SecretsGetter
 	kubeInformersForNamespaces v1helpers.KubeInformersForNamespaces
 	operatorConfigClient       v1helpers.OperatorClient
 
+	// namespaceLister lists the namespaces selected by fan-out rules, it is set by the first rule with a selector
+	namespaceLister     corev1listers.NamespaceLister
+	namespaceListerOnce sync.Once
+	// namespaceListerErr is the error starting to watch namespaces, returned to all rules with a selector
+	namespaceListerErr error
+
 	runFn   func(ctx context.Context, workers int)
 	syncCtx factory.SyncContext
 }
@@ -65,11 +86,19 @@ func NewResourceSyncController(
 
 		configMapSyncRules:         syncRules{},
 		secretSyncRules:            syncRules{},
+		configMapKeySyncRules:      keySyncRules{},
+		secretKeySyncRules:         keySyncRules{},
+		secretToConfigMapSyncRules: syncRules{},
+		configMapToSecretSyncRules: syncRules{},
+		configMapFanOutRules:       fanOutRules{},
+		secretFanOutRules:          fanOutRules{},
 		kubeInformersForNamespaces: kubeInformersForNamespaces,
 		knownNamespaces:            kubeInformersForNamespaces.Namespaces(),
 
 		configMapGetter: v1helpers.CachedConfigMapGetter(configMapsGetter, kubeInformersForNamespaces),
 		secretGetter:    v1helpers.CachedSecretGetter(secretsGetter, kubeInformersForNamespaces),
+		configMapClient: configMapsGetter,
+		secretClient:    secretsGetter,
 		syncCtx:         factory.NewSyncContext("ResourceSyncController", eventRecorder.WithComponentSuffix("resource-sync-controller")),
 	}
 
@@ -113,21 +142,43 @@ func (c *ResourceSyncController) SyncConfigMapConditionally(destination, source
 	return c.syncConfigMap(destination, source, preconditionsFulfilledFn)
 }
 
+// SyncConfigMapWithTransforms adds a new configmap that the resource sync controller will synchronise after applying
+// the given transformations to the copy of the source, in order. Their names are recorded in the
+// TransformAnnotation of the destination.
+func (c *ResourceSyncController) SyncConfigMapWithTransforms(destination, source ResourceLocation, transforms ...ConfigMapTransform) error {
+	return c.syncConfigMapRule(destination, syncRuleSource{
+		ResourceLocation:         source,
+		Transforms:               configMapTransformNames(transforms),
+		preconditionsFulfilledFn: alwaysFulfilledPreconditions,
+		configMapTransforms:      transforms,
+	})
+}
+
 func (c *ResourceSyncController) syncConfigMap(destination ResourceLocation, source ResourceLocation, preconditionsFulfilledFn preconditionsFulfilled, keys ...string) error {
+	return c.syncConfigMapRule(destination, syncRuleSource{
+		ResourceLocation:         source,
+		syncedKeys:               sets.NewString(keys...),
+		preconditionsFulfilledFn: preconditionsFulfilledFn,
+	})
+}
+
+func (c *ResourceSyncController) syncConfigMapRule(destination ResourceLocation, source syncRuleSource) error {
 	if !c.knownNamespaces.Has(destination.Namespace) {
 		return fmt.Errorf("not watching namespace %q", destination.Namespace)
 	}
-	if source != emptyResourceLocation && !c.knownNamespaces.Has(source.Namespace) {
+	if source.ResourceLocation != emptyResourceLocation && !c.knownNamespaces.Has(source.Namespace) {
 		return fmt.Errorf("not watching namespace %q", source.Namespace)
 	}
 
 	c.syncRuleLock.Lock()
 	defer c.syncRuleLock.Unlock()
-	c.configMapSyncRules[destination] = syncRuleSource{
-		ResourceLocation:         source,
-		syncedKeys:               sets.NewString(keys...),
-		preconditionsFulfilledFn: preconditionsFulfilledFn,
+	if hasKeySyncRule(c.configMapKeySyncRules, destination) {
+		return fmt.Errorf("configmap %s/%s is already synced by key", destination.Namespace, destination.Name)
+	}
+	if _, ok := c.secretToConfigMapSyncRules[destination]; ok {
+		return fmt.Errorf("configmap %s/%s is already synced from a secret", destination.Namespace, destination.Name)
 	}
+	c.configMapSyncRules[destination] = source
 
 	// make sure the new rule is picked up
 	c.syncCtx.Queue().Add(c.syncCtx.QueueKey())
@@ -148,7 +199,108 @@ func (c *ResourceSyncController) SyncSecretConditionally(destination, source Res
 	return c.syncSecret(destination, source, preconditionsFulfilledFn)
 }
 
+// SyncSecretWithTransforms adds a new secret that the resource sync controller will synchronise after applying
+// the given transformations to the copy of the source, in order. Their names are recorded in the
+// TransformAnnotation of the destination.
+func (c *ResourceSyncController) SyncSecretWithTransforms(destination, source ResourceLocation, transforms ...SecretTransform) error {
+	return c.syncSecretRule(destination, syncRuleSource{
+		ResourceLocation:         source,
+		Transforms:               secretTransformNames(transforms),
+		preconditionsFulfilledFn: alwaysFulfilledPreconditions,
+		secretTransforms:         transforms,
+	})
+}
+
 func (c *ResourceSyncController) syncSecret(destination, source ResourceLocation, preconditionsFulfilledFn preconditionsFulfilled, keys ...string) error {
+	return c.syncSecretRule(destination, syncRuleSource{
+		ResourceLocation:         source,
+		syncedKeys:               sets.NewString(keys...),
+		preconditionsFulfilledFn: preconditionsFulfilledFn,
+	})
+}
+
+func (c *ResourceSyncController) syncSecretRule(destination ResourceLocation, source syncRuleSource) error {
+	if !c.knownNamespaces.Has(destination.Namespace) {
+		return fmt.Errorf("not watching namespace %q", destination.Namespace)
+	}
+	if source.ResourceLocation != emptyResourceLocation && !c.knownNamespaces.Has(source.Namespace) {
+		return fmt.Errorf("not watching namespace %q", source.Namespace)
+	}
+
+	c.syncRuleLock.Lock()
+	defer c.syncRuleLock.Unlock()
+	if hasKeySyncRule(c.secretKeySyncRules, destination) {
+		return fmt.Errorf("secret %s/%s is already synced by key", destination.Namespace, destination.Name)
+	}
+	if _, ok := c.configMapToSecretSyncRules[destination]; ok {
+		return fmt.Errorf("secret %s/%s is already synced from a configmap", destination.Namespace, destination.Name)
+	}
+	c.secretSyncRules[destination] = source
+
+	// make sure the new rule is picked up
+	c.syncCtx.Queue().Add(c.syncCtx.QueueKey())
+	return nil
+}
+
+// SyncConfigMapKey indicates that a single key of a configmap should be copied from the source to the destination, leaving
+// the other keys of the destination, e.g. owned by another component, untouched. The destination is created if needed.
+// It will also mirror a deletion of the source or its key by removing the key from the destination. If the source is
+// a zero object the key will be removed. Multiple keys of a destination can be synced from different sources, but a
+// destination cannot be synced both by key and as a whole.
+func (c *ResourceSyncController) SyncConfigMapKey(destination, source ResourceKeyLocation) error {
+	if err := c.validateKeySyncRule(destination, source); err != nil {
+		return err
+	}
+
+	c.syncRuleLock.Lock()
+	defer c.syncRuleLock.Unlock()
+	if _, ok := c.configMapSyncRules[destination.ResourceLocation]; ok {
+		return fmt.Errorf("configmap %s/%s is already synced as a whole", destination.Namespace, destination.Name)
+	}
+	c.configMapKeySyncRules[destination] = source
+
+	// make sure the new rule is picked up
+	c.syncCtx.Queue().Add(c.syncCtx.QueueKey())
+	return nil
+}
+
+// SyncSecretKey indicates that a single key of a secret should be copied from the source to the destination, leaving
+// the other keys of the destination untouched. It behaves like SyncConfigMapKey.
+func (c *ResourceSyncController) SyncSecretKey(destination, source ResourceKeyLocation) error {
+	if err := c.validateKeySyncRule(destination, source); err != nil {
+		return err
+	}
+
+	c.syncRuleLock.Lock()
+	defer c.syncRuleLock.Unlock()
+	if _, ok := c.secretSyncRules[destination.ResourceLocation]; ok {
+		return fmt.Errorf("secret %s/%s is already synced as a whole", destination.Namespace, destination.Name)
+	}
+	c.secretKeySyncRules[destination] = source
+
+	// make sure the new rule is picked up
+	c.syncCtx.Queue().Add(c.syncCtx.QueueKey())
+	return nil
+}
+
+// SyncSecretToConfigMap indicates that the given keys of a secret should be published in a configmap, e.g. the CA
+// certificate of a TLS secret. Keys holding a private key are refused during the sync. It will also mirror a deletion
+// from the source. If the source is a zero object the destination will be deleted.
+func (c *ResourceSyncController) SyncSecretToConfigMap(destination, source ResourceLocation, keys ...string) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("the keys to publish must be set")
+	}
+	return c.syncCrossKind(c.secretToConfigMapSyncRules, c.configMapSyncRules, "configmap", destination, source, keys)
+}
+
+// SyncConfigMapToSecret indicates that the given keys of a configmap, or all of them when none is given, should be copied
+// into a secret. It will also mirror a deletion from the source. If the source is a zero object the destination will
+// be deleted.
+func (c *ResourceSyncController) SyncConfigMapToSecret(destination, source ResourceLocation, keys ...string) error {
+	return c.syncCrossKind(c.configMapToSecretSyncRules, c.secretSyncRules, "secret", destination, source, keys)
+}
+
+func (c *ResourceSyncController) syncCrossKind(rules, sameKindRules syncRules, kind string, destination, source ResourceLocation, keys []string) error {
 	if !c.knownNamespaces.Has(destination.Namespace) {
 		return fmt.Errorf("not watching namespace %q", destination.Namespace)
 	}
@@ -158,10 +310,13 @@ func (c *ResourceSyncController) syncSecret(destination, source ResourceLocation
 
 	c.syncRuleLock.Lock()
 	defer c.syncRuleLock.Unlock()
-	c.secretSyncRules[destination] = syncRuleSource{
+	if _, ok := sameKindRules[destination]; ok {
+		return fmt.Errorf("%s %s/%s is already synced", kind, destination.Namespace, destination.Name)
+	}
+	rules[destination] = syncRuleSource{
 		ResourceLocation:         source,
 		syncedKeys:               sets.NewString(keys...),
-		preconditionsFulfilledFn: preconditionsFulfilledFn,
+		preconditionsFulfilledFn: alwaysFulfilledPreconditions,
 	}
 
 	// make sure the new rule is picked up
@@ -169,6 +324,28 @@ func (c *ResourceSyncController) syncSecret(destination, source ResourceLocation
 	return nil
 }
 
+func (c *ResourceSyncController) validateKeySyncRule(destination, source ResourceKeyLocation) error {
+	if !c.knownNamespaces.Has(destination.Namespace) {
+		return fmt.Errorf("not watching namespace %q", destination.Namespace)
+	}
+	if source.ResourceLocation != emptyResourceLocation && !c.knownNamespaces.Has(source.Namespace) {
+		return fmt.Errorf("not watching namespace %q", source.Namespace)
+	}
+	if len(destination.Key) == 0 || (source.ResourceLocation != emptyResourceLocation && len(source.Key) == 0) {
+		return fmt.Errorf("the keys of the source and the destination must be set")
+	}
+	return nil
+}
+
+func hasKeySyncRule(rules keySyncRules, destination ResourceLocation) bool {
+	for keyDestination := range rules {
+		if keyDestination.ResourceLocation == destination {
+			return true
+		}
+	}
+	return false
+}
+
 // errorWithProvider provides a finger of blame in case a source resource cannot be retrieved.
 func errorWithProvider(provider string, err error) error {
 	if len(provider) > 0 {
@@ -215,7 +392,7 @@ func (c *ResourceSyncController) Sync(ctx context.Context, syncCtx factory.SyncC
 			continue
 		}
 
-		_, _, err := resourceapply.SyncPartialConfigMap(ctx, c.configMapGetter, syncCtx.Recorder(), source.Namespace, source.Name, destination.Namespace, destination.Name, source.syncedKeys, []metav1.OwnerReference{})
+		_, _, err := resourceapply.SyncPartialConfigMapWithTransform(ctx, c.configMapGetter, syncCtx.Recorder(), source.Namespace, source.Name, destination.Namespace, destination.Name, source.syncedKeys, transformConfigMap(source.configMapTransforms), []metav1.OwnerReference{})
 		if err != nil {
 			errors = append(errors, errorWithProvider(source.Provider, err))
 		}
@@ -243,11 +420,48 @@ func (c *ResourceSyncController) Sync(ctx context.Context, syncCtx factory.SyncC
 			continue
 		}
 
-		_, _, err := resourceapply.SyncPartialSecret(ctx, c.secretGetter, syncCtx.Recorder(), source.Namespace, source.Name, destination.Namespace, destination.Name, source.syncedKeys, []metav1.OwnerReference{})
+		_, _, err := resourceapply.SyncPartialSecretWithTransform(ctx, c.secretGetter, syncCtx.Recorder(), source.Namespace, source.Name, destination.Namespace, destination.Name, source.syncedKeys, transformSecret(source.secretTransforms), []metav1.OwnerReference{})
 		if err != nil {
 			errors = append(errors, errorWithProvider(source.Provider, err))
 		}
 	}
+	for destination, source := range c.configMapKeySyncRules {
+		_, _, err := resourceapply.SyncConfigMapKey(ctx, c.configMapGetter, syncCtx.Recorder(), source.Namespace, source.Name, source.Key, destination.Namespace, destination.Name, destination.Key)
+		if err != nil {
+			errors = append(errors, errorWithProvider(source.Provider, err))
+		}
+	}
+	for destination, source := range c.secretKeySyncRules {
+		_, _, err := resourceapply.SyncSecretKey(ctx, c.secretGetter, syncCtx.Recorder(), source.Namespace, source.Name, source.Key, destination.Namespace, destination.Name, destination.Key)
+		if err != nil {
+			errors = append(errors, errorWithProvider(source.Provider, err))
+		}
+	}
+	for destination, source := range c.secretToConfigMapSyncRules {
+		if source.ResourceLocation == emptyResourceLocation {
+			if err := c.configMapGetter.ConfigMaps(destination.Namespace).Delete(ctx, destination.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				errors = append(errors, err)
+			}
+			continue
+		}
+		_, _, err := resourceapply.SyncSecretKeysToConfigMap(ctx, c.secretGetter, c.configMapGetter, syncCtx.Recorder(), source.Namespace, source.Name, destination.Namespace, destination.Name, source.syncedKeys.List(), []metav1.OwnerReference{})
+		if err != nil {
+			errors = append(errors, errorWithProvider(source.Provider, err))
+		}
+	}
+	for destination, source := range c.configMapToSecretSyncRules {
+		if source.ResourceLocation == emptyResourceLocation {
+			if err := c.secretGetter.Secrets(destination.Namespace).Delete(ctx, destination.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				errors = append(errors, err)
+			}
+			continue
+		}
+		_, _, err := resourceapply.SyncConfigMapKeysToSecret(ctx, c.configMapGetter, c.secretGetter, syncCtx.Recorder(), source.Namespace, source.Name, destination.Namespace, destination.Name, source.syncedKeys.List(), []metav1.OwnerReference{})
+		if err != nil {
+			errors = append(errors, errorWithProvider(source.Provider, err))
+		}
+	}
+	errors = append(errors, c.syncFanOuts(ctx, syncCtx.Recorder())...)
 
 	if len(errors) > 0 {
 		cond := operatorv1.OperatorCondition{
@@ -302,19 +516,37 @@ func (l ResourceSyncRuleList) Less(i, j int) bool {
 	return false
 }
 
+type ResourceKeySyncRule struct {
+	Destination ResourceKeyLocation `json:"destination"`
+	Source      ResourceKeyLocation `json:"source"`
+}
+
 type ControllerSyncRules struct {
-	Secrets ResourceSyncRuleList `json:"secrets"`
-	Configs ResourceSyncRuleList `json:"configs"`
+	Secrets       ResourceSyncRuleList  `json:"secrets"`
+	Configs       ResourceSyncRuleList  `json:"configs"`
+	SecretKeys    []ResourceKeySyncRule `json:"secretKeys,omitempty"`
+	ConfigKeys    []ResourceKeySyncRule `json:"configKeys,omitempty"`
+	SecretFanOuts []ResourceFanOutRule  `json:"secretFanOuts,omitempty"`
+	ConfigFanOuts []ResourceFanOutRule  `json:"configFanOuts,omitempty"`
+	// SecretsToConfigs are configmaps synced from secrets, ConfigsToSecrets secrets synced from configmaps.
+	SecretsToConfigs ResourceSyncRuleList `json:"secretsToConfigs,omitempty"`
+	ConfigsToSecrets ResourceSyncRuleList `json:"configsToSecrets,omitempty"`
 }
 
 // ServeSyncRules provides a handler function to return the sync rules of the controller
 func (h *debugHTTPHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	syncRules := ControllerSyncRules{ResourceSyncRuleList{}, ResourceSyncRuleList{}}
+	syncRules := ControllerSyncRules{Secrets: ResourceSyncRuleList{}, Configs: ResourceSyncRuleList{}}
 
 	h.controller.syncRuleLock.RLock()
 	defer h.controller.syncRuleLock.RUnlock()
 	syncRules.Secrets = append(syncRules.Secrets, resourceSyncRuleList(h.controller.secretSyncRules)...)
 	syncRules.Configs = append(syncRules.Configs, resourceSyncRuleList(h.controller.configMapSyncRules)...)
+	syncRules.SecretKeys = resourceKeySyncRuleList(h.controller.secretKeySyncRules)
+	syncRules.ConfigKeys = resourceKeySyncRuleList(h.controller.configMapKeySyncRules)
+	syncRules.SecretFanOuts = resourceFanOutRuleList(h.controller.secretFanOutRules)
+	syncRules.ConfigFanOuts = resourceFanOutRuleList(h.controller.configMapFanOutRules)
+	syncRules.SecretsToConfigs = resourceSyncRuleList(h.controller.secretToConfigMapSyncRules)
+	syncRules.ConfigsToSecrets = resourceSyncRuleList(h.controller.configMapToSecretSyncRules)
 
 	data, err := json.Marshal(syncRules)
 	if err != nil {
@@ -338,3 +570,15 @@ func resourceSyncRuleList(syncRules syncRules) ResourceSyncRuleList {
 	sort.Sort(rules)
 	return rules
 }
+
+func resourceKeySyncRuleList(syncRules keySyncRules) []ResourceKeySyncRule {
+	rules := make([]ResourceKeySyncRule, 0, len(syncRules))
+	for dest, src := range syncRules {
+		rules = append(rules, ResourceKeySyncRule{Source: src, Destination: dest})
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		a, b := rules[i].Destination, rules[j].Destination
+		return a.Namespace+"/"+a.Name+"/"+a.Key < b.Namespace+"/"+b.Name+"/"+b.Key
+	})
+	return rules
+}
@@ -0,0 +1,266 @@
+package resourcesynccontroller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/cert"
+)
+
+// TransformAnnotation is set on destinations of transformed syncs to the JSON list of the names of the
+// transformations, in order.
+const TransformAnnotation = "operator.openshift.io/resource-sync-transforms"
+
+// ConfigMapTransform is a named transformation of a configmap, applied to the copy of the source before it is
+// synced to the destination. The name identifies the transformation in the TransformAnnotation, so it should
+// include its parameters.
+type ConfigMapTransform struct {
+	Name      string
+	Transform func(configMap *corev1.ConfigMap) error
+}
+
+// SecretTransform is a named transformation of a secret, applied to the copy of the source before it is
+// synced to the destination. The name identifies the transformation in the TransformAnnotation, so it should
+// include its parameters.
+type SecretTransform struct {
+	Name      string
+	Transform func(secret *corev1.Secret) error
+}
+
+// RenameConfigMapKey renames the key from to to, in data or binary data. A missing key is not an error.
+func RenameConfigMapKey(from, to string) ConfigMapTransform {
+	return ConfigMapTransform{
+		Name: fmt.Sprintf("rename(%s,%s)", from, to),
+		Transform: func(configMap *corev1.ConfigMap) error {
+			if value, ok := configMap.Data[from]; ok {
+				delete(configMap.Data, from)
+				configMap.Data[to] = value
+			}
+			if value, ok := configMap.BinaryData[from]; ok {
+				delete(configMap.BinaryData, from)
+				configMap.BinaryData[to] = value
+			}
+			return nil
+		},
+	}
+}
+
+// FilterConfigMapKeys removes all keys but the given ones.
+func FilterConfigMapKeys(keys ...string) ConfigMapTransform {
+	return ConfigMapTransform{
+		Name: fmt.Sprintf("filter(%s)", strings.Join(keys, ",")),
+		Transform: func(configMap *corev1.ConfigMap) error {
+			for key := range configMap.Data {
+				if !contains(keys, key) {
+					delete(configMap.Data, key)
+				}
+			}
+			for key := range configMap.BinaryData {
+				if !contains(keys, key) {
+					delete(configMap.BinaryData, key)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// ConcatenateCABundles replaces the given keys by destinationKey holding the certificates of all of them, in order,
+// without duplicates. The keys are read from data or binary data. Missing keys are skipped, invalid certificates fail
+// the sync.
+func ConcatenateCABundles(destinationKey string, keys ...string) ConfigMapTransform {
+	return ConfigMapTransform{
+		Name: fmt.Sprintf("concatenate-ca-bundles(%s;%s)", strings.Join(keys, ","), destinationKey),
+		Transform: func(configMap *corev1.ConfigMap) error {
+			var bundle []byte
+			seen := [][]byte{}
+			for _, key := range keys {
+				value, ok := configMap.Data[key]
+				if !ok {
+					binaryValue, ok := configMap.BinaryData[key]
+					if !ok {
+						continue
+					}
+					value = string(binaryValue)
+				}
+				delete(configMap.Data, key)
+				delete(configMap.BinaryData, key)
+				if len(strings.TrimSpace(value)) == 0 {
+					continue
+				}
+				certs, err := cert.ParseCertsPEM([]byte(value))
+				if err != nil {
+					return fmt.Errorf("invalid CA bundle in %q: %w", key, err)
+				}
+				for _, c := range certs {
+					if containsBytes(seen, c.Raw) {
+						continue
+					}
+					seen = append(seen, c.Raw)
+					encoded, err := cert.EncodeCertificates(c)
+					if err != nil {
+						return err
+					}
+					bundle = append(bundle, encoded...)
+				}
+			}
+			if len(bundle) == 0 {
+				return nil
+			}
+			if configMap.Data == nil {
+				configMap.Data = map[string]string{}
+			}
+			configMap.Data[destinationKey] = string(bundle)
+			return nil
+		},
+	}
+}
+
+// ReencodeConfigMapKey converts the value of key with reencode, e.g. from PEM to DER. The value is read from data or
+// binary data, and it is written back to the same place. encoding names the target format in the transformation name.
+// A missing key is not an error.
+func ReencodeConfigMapKey(key, encoding string, reencode func([]byte) ([]byte, error)) ConfigMapTransform {
+	return ConfigMapTransform{
+		Name: fmt.Sprintf("reencode(%s,%s)", key, encoding),
+		Transform: func(configMap *corev1.ConfigMap) error {
+			if value, ok := configMap.Data[key]; ok {
+				reencoded, err := reencode([]byte(value))
+				if err != nil {
+					return fmt.Errorf("failed to reencode %q to %s: %w", key, encoding, err)
+				}
+				configMap.Data[key] = string(reencoded)
+			}
+			if value, ok := configMap.BinaryData[key]; ok {
+				reencoded, err := reencode(value)
+				if err != nil {
+					return fmt.Errorf("failed to reencode %q to %s: %w", key, encoding, err)
+				}
+				configMap.BinaryData[key] = reencoded
+			}
+			return nil
+		},
+	}
+}
+
+// RenameSecretKey renames the key from to to. A missing key is not an error.
+func RenameSecretKey(from, to string) SecretTransform {
+	return SecretTransform{
+		Name: fmt.Sprintf("rename(%s,%s)", from, to),
+		Transform: func(secret *corev1.Secret) error {
+			if value, ok := secret.Data[from]; ok {
+				delete(secret.Data, from)
+				secret.Data[to] = value
+			}
+			return nil
+		},
+	}
+}
+
+// FilterSecretKeys removes all keys but the given ones.
+func FilterSecretKeys(keys ...string) SecretTransform {
+	return SecretTransform{
+		Name: fmt.Sprintf("filter(%s)", strings.Join(keys, ",")),
+		Transform: func(secret *corev1.Secret) error {
+			for key := range secret.Data {
+				if !contains(keys, key) {
+					delete(secret.Data, key)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// transformConfigMap returns the function applying the transformations and recording them in the TransformAnnotation,
+// nil when there are none.
+func transformConfigMap(transforms []ConfigMapTransform) func(*corev1.ConfigMap) error {
+	if len(transforms) == 0 {
+		return nil
+	}
+	return func(configMap *corev1.ConfigMap) error {
+		for _, t := range transforms {
+			if err := t.Transform(configMap); err != nil {
+				return fmt.Errorf("%s: %w", t.Name, err)
+			}
+		}
+		if configMap.Annotations == nil {
+			configMap.Annotations = map[string]string{}
+		}
+		annotation, err := transformAnnotation(configMapTransformNames(transforms))
+		if err != nil {
+			return err
+		}
+		configMap.Annotations[TransformAnnotation] = annotation
+		return nil
+	}
+}
+
+// transformSecret returns the function applying the transformations and recording them in the TransformAnnotation,
+// nil when there are none.
+func transformSecret(transforms []SecretTransform) func(*corev1.Secret) error {
+	if len(transforms) == 0 {
+		return nil
+	}
+	return func(secret *corev1.Secret) error {
+		for _, t := range transforms {
+			if err := t.Transform(secret); err != nil {
+				return fmt.Errorf("%s: %w", t.Name, err)
+			}
+		}
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		annotation, err := transformAnnotation(secretTransformNames(transforms))
+		if err != nil {
+			return err
+		}
+		secret.Annotations[TransformAnnotation] = annotation
+		return nil
+	}
+}
+
+// transformAnnotation encodes the names as a JSON list, because the names can contain any separator.
+func transformAnnotation(names []string) (string, error) {
+	encoded, err := json.Marshal(names)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+func configMapTransformNames(transforms []ConfigMapTransform) []string {
+	names := make([]string, 0, len(transforms))
+	for _, t := range transforms {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+func secretTransformNames(transforms []SecretTransform) []string {
+	names := make([]string, 0, len(transforms))
+	for _, t := range transforms {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsBytes(values [][]byte, value []byte) bool {
+	for _, v := range values {
+		if bytes.Equal(v, value) {
+			return true
+		}
+	}
+	return false
+}
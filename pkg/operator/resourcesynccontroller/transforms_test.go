@@ -0,0 +1,174 @@
+package resourcesynccontroller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func newCAPEM(t *testing.T, name string) string {
+	ca, err := crypto.MakeSelfSignedCAConfigForDuration(name, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := crypto.EncodeCertificates(ca.Certs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(certPEM)
+}
+
+func TestConfigMapTransforms(t *testing.T) {
+	ca1 := newCAPEM(t, "ca-1")
+	ca2 := newCAPEM(t, "ca-2")
+
+	tests := []struct {
+		name           string
+		transform      ConfigMapTransform
+		data           map[string]string
+		binary         map[string][]byte
+		expected       map[string]string
+		expectedBinary map[string][]byte
+		expectErr      bool
+	}{
+		{
+			name:      "rename",
+			transform: RenameConfigMapKey("ca.crt", "ca-bundle.crt"),
+			data:      map[string]string{"ca.crt": "a", "other": "b"},
+			expected:  map[string]string{"ca-bundle.crt": "a", "other": "b"},
+		},
+		{
+			name:      "rename missing key",
+			transform: RenameConfigMapKey("ca.crt", "ca-bundle.crt"),
+			data:      map[string]string{"other": "b"},
+			expected:  map[string]string{"other": "b"},
+		},
+		{
+			name:      "filter",
+			transform: FilterConfigMapKeys("a"),
+			data:      map[string]string{"a": "1", "b": "2"},
+			binary:    map[string][]byte{"c": []byte("3")},
+			expected:  map[string]string{"a": "1"},
+		},
+		{
+			name:      "concatenate CA bundles without duplicates",
+			transform: ConcatenateCABundles("ca-bundle.crt", "first", "second", "missing"),
+			data:      map[string]string{"first": ca1, "second": ca2 + ca1, "other": "b"},
+			expected:  map[string]string{"ca-bundle.crt": ca1 + ca2, "other": "b"},
+		},
+		{
+			name:           "concatenate CA bundles from binary data",
+			transform:      ConcatenateCABundles("ca-bundle.crt", "first", "second"),
+			data:           map[string]string{"first": ca1},
+			binary:         map[string][]byte{"second": []byte(ca2), "other": []byte("b")},
+			expected:       map[string]string{"ca-bundle.crt": ca1 + ca2},
+			expectedBinary: map[string][]byte{"other": []byte("b")},
+		},
+		{
+			name:      "concatenate invalid CA bundle",
+			transform: ConcatenateCABundles("ca-bundle.crt", "first"),
+			data:      map[string]string{"first": "not a certificate"},
+			expectErr: true,
+		},
+		{
+			name: "reencode",
+			transform: ReencodeConfigMapKey("a", "upper", func(value []byte) ([]byte, error) {
+				return []byte(string(value) + "!"), nil
+			}),
+			data:     map[string]string{"a": "1", "b": "2"},
+			expected: map[string]string{"a": "1!", "b": "2"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			configMap := &corev1.ConfigMap{Data: tc.data, BinaryData: tc.binary}
+			err := tc.transform.Transform(configMap)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if tc.expectErr {
+				return
+			}
+			if !reflect.DeepEqual(configMap.Data, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, configMap.Data)
+			}
+			if len(tc.expectedBinary)+len(configMap.BinaryData) > 0 && !reflect.DeepEqual(configMap.BinaryData, tc.expectedBinary) {
+				t.Errorf("expected binary data %v, got %v", tc.expectedBinary, configMap.BinaryData)
+			}
+		})
+	}
+}
+
+func TestSyncWithTransforms(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "config", Name: "ca"},
+			Data:       map[string]string{"ca.crt": "a", "other": "b"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "config", Name: "serving"},
+			Data:       map[string][]byte{"tls.crt": []byte("crt"), "tls.key": []byte("key")},
+		},
+	)
+	operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+	c := NewResourceSyncController(
+		operatorClient,
+		v1helpers.NewFakeKubeInformersForNamespaces(map[string]informers.SharedInformerFactory{
+			"config":   informers.NewSharedInformerFactoryWithOptions(kubeClient, time.Minute, informers.WithNamespace("config")),
+			"operator": informers.NewSharedInformerFactoryWithOptions(kubeClient, time.Minute, informers.WithNamespace("operator")),
+		}),
+		kubeClient.CoreV1(),
+		kubeClient.CoreV1(),
+		events.NewInMemoryRecorder("test"),
+	)
+	c.configMapGetter = kubeClient.CoreV1()
+	c.secretGetter = kubeClient.CoreV1()
+
+	if err := c.SyncConfigMapWithTransforms(ResourceLocation{Namespace: "operator", Name: "ca"}, ResourceLocation{Namespace: "config", Name: "ca"},
+		FilterConfigMapKeys("ca.crt"), RenameConfigMapKey("ca.crt", "ca-bundle.crt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SyncSecretWithTransforms(ResourceLocation{Namespace: "operator", Name: "serving"}, ResourceLocation{Namespace: "config", Name: "serving"},
+		FilterSecretKeys("tls.crt"), RenameSecretKey("tls.crt", "ca.crt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+		t.Fatal(err)
+	}
+
+	configMap, err := kubeClient.CoreV1().ConfigMaps("operator").Get(context.TODO(), "ca", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := map[string]string{"ca-bundle.crt": "a"}; !reflect.DeepEqual(configMap.Data, expected) {
+		t.Errorf("expected configmap data %v, got %v", expected, configMap.Data)
+	}
+	if expected := `["filter(ca.crt)","rename(ca.crt,ca-bundle.crt)"]`; configMap.Annotations[TransformAnnotation] != expected {
+		t.Errorf("expected annotation %q, got %q", expected, configMap.Annotations[TransformAnnotation])
+	}
+
+	secret, err := kubeClient.CoreV1().Secrets("operator").Get(context.TODO(), "serving", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := map[string][]byte{"ca.crt": []byte("crt")}; !reflect.DeepEqual(secret.Data, expected) {
+		t.Errorf("expected secret data %v, got %v", expected, secret.Data)
+	}
+	if expected := `["filter(tls.crt)","rename(tls.crt,ca.crt)"]`; secret.Annotations[TransformAnnotation] != expected {
+		t.Errorf("expected annotation %q, got %q", expected, secret.Annotations[TransformAnnotation])
+	}
+}
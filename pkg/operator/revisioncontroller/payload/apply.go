@@ -0,0 +1,78 @@
+package payload
+
+import (
+	"context"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+)
+
+// ApplyConfigMap applies the configmap like resourceapply.ApplyConfigMap, encoding its content when it is above
+// CompressionThreshold. It is used by the producers of the configmaps copied into the revisions, which may otherwise
+// exceed the size limit of the objects. The configmap holding the first part is returned, the other parts are applied
+// before it so that it is complete once it changes, and the parts left over from a larger content are deleted.
+func ApplyConfigMap(ctx context.Context, client corev1client.ConfigMapsGetter, recorder events.Recorder, required *corev1.ConfigMap) (*corev1.ConfigMap, bool, error) {
+	parts, err := Encode(required)
+	if err != nil {
+		return nil, false, err
+	}
+	if !IsEncoded(parts[0]) {
+		// the annotations of the existing configmap are merged, the encoding of a previous content must be removed
+		if parts[0].Annotations == nil {
+			parts[0].Annotations = map[string]string{}
+		}
+		parts[0].Annotations[ContentEncodingAnnotation+"-"] = ""
+		parts[0].Annotations[PartsAnnotation+"-"] = ""
+	}
+
+	previousParts := 1
+	existing, err := client.ConfigMaps(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
+	if err == nil {
+		if n, err := strconv.Atoi(existing.Annotations[PartsAnnotation]); err == nil {
+			previousParts = n
+		}
+	} else if !apierrors.IsNotFound(err) {
+		return nil, false, err
+	}
+
+	modified := false
+	for _, part := range parts[1:] {
+		_, partModified, err := resourceapply.ApplyConfigMap(ctx, client, recorder, part)
+		if err != nil {
+			return nil, false, err
+		}
+		modified = modified || partModified
+	}
+	actual, firstModified, err := resourceapply.ApplyConfigMap(ctx, client, recorder, parts[0])
+	if err != nil {
+		return nil, false, err
+	}
+	modified = modified || firstModified
+
+	for i := len(parts); i < previousParts; i++ {
+		if _, _, err := resourceapply.DeleteConfigMap(ctx, client, recorder, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: required.Namespace, Name: PartName(required.Name, i)},
+		}); err != nil {
+			return nil, false, err
+		}
+	}
+	return actual, modified, nil
+}
+
+// Get returns the named configmap with its content decoded. It is used by the consumers of the configmaps applied with
+// ApplyConfigMap and of the revisions.
+func Get(ctx context.Context, client corev1client.ConfigMapsGetter, namespace, name string) (*corev1.ConfigMap, error) {
+	configMap, err := client.ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return Decode(configMap, func(name string) (*corev1.ConfigMap, error) {
+		return client.ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	})
+}
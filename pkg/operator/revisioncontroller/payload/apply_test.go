@@ -0,0 +1,60 @@
+package payload
+
+import (
+	"context"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestApplyConfigMap(t *testing.T) {
+	// random data compresses poorly, so that the configmap is split
+	random := make([]byte, 800*1024)
+	rand.New(rand.NewSource(1)).Read(random)
+	largeData := hex.EncodeToString(random)
+
+	client := fake.NewSimpleClientset()
+	recorder := events.NewInMemoryRecorder("test")
+	apply := func(data string) {
+		t.Helper()
+		required := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "config"},
+			Data:       map[string]string{"key": data},
+		}
+		if _, _, err := ApplyConfigMap(context.TODO(), client.CoreV1(), recorder, required); err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := Get(context.TODO(), client.CoreV1(), "ns", "config")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Data["key"] != data {
+			t.Errorf("expected the decoded content to match the applied one")
+		}
+	}
+
+	apply(largeData)
+	if _, err := client.CoreV1().ConfigMaps("ns").Get(context.TODO(), PartName("config", 1), metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected the content to be split: %v", err)
+	}
+
+	// the encoding and the parts of the previous content are removed
+	apply("small")
+	configMap, err := client.CoreV1().ConfigMaps("ns").Get(context.TODO(), "config", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsEncoded(configMap) || len(configMap.BinaryData) != 0 {
+		t.Errorf("expected the configmap not to be encoded anymore, got %v", configMap)
+	}
+	if _, err := client.CoreV1().ConfigMaps("ns").Get(context.TODO(), PartName("config", 1), metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the left over part to be deleted, got %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package payload
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ContentEncodingAnnotation is set on the revision configmaps whose content is encoded, to the encoding.
+	ContentEncodingAnnotation = "revision.operator.openshift.io/content-encoding"
+	// PartsAnnotation is set on the encoded revision configmaps to the number of configmaps their content is split
+	// into, the configmap itself holding the first part.
+	PartsAnnotation = "revision.operator.openshift.io/parts"
+	// GzipEncoding is the gzip compressed JSON of the data and binary data of the configmap.
+	GzipEncoding = "gzip"
+
+	// CompressionThreshold is the size of the content of a configmap above which it is compressed.
+	CompressionThreshold = 512 * 1024
+	// MaxPartSize is the maximum size of the compressed content held by a configmap, leaving room below the 1MiB
+	// limit of the objects for the metadata.
+	MaxPartSize = 768 * 1024
+
+	// payloadKey is the key of the compressed content in the binary data of the configmaps.
+	payloadKey = "payload.json.gz"
+)
+
+// content is the encoded content of a configmap.
+type content struct {
+	Data       map[string]string `json:"data,omitempty"`
+	BinaryData map[string][]byte `json:"binaryData,omitempty"`
+}
+
+// PartName returns the name of the configmap holding the part of the content of the named configmap. The first part
+// is held by the configmap itself.
+func PartName(name string, part int) string {
+	if part == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s-part-%d", name, part)
+}
+
+// Size returns the size of the content of the configmap.
+func Size(configMap *corev1.ConfigMap) int {
+	size := 0
+	for key, value := range configMap.Data {
+		size += len(key) + len(value)
+	}
+	for key, value := range configMap.BinaryData {
+		size += len(key) + len(value)
+	}
+	return size
+}
+
+// IsEncoded returns whether the content of the configmap is encoded.
+func IsEncoded(configMap *corev1.ConfigMap) bool {
+	_, ok := configMap.Annotations[ContentEncodingAnnotation]
+	return ok
+}
+
+// Encode returns the configmap unchanged when its content is below CompressionThreshold. Otherwise its content is
+// compressed and split in parts of MaxPartSize, and the configmaps holding the parts are returned, the first one with
+// the name of the configmap. The configmaps holding the other parts have the same namespace, labels and owner
+// references.
+func Encode(configMap *corev1.ConfigMap) ([]*corev1.ConfigMap, error) {
+	return encode(configMap, CompressionThreshold, MaxPartSize)
+}
+
+func encode(configMap *corev1.ConfigMap, threshold, partSize int) ([]*corev1.ConfigMap, error) {
+	if Size(configMap) <= threshold {
+		return []*corev1.ConfigMap{configMap.DeepCopy()}, nil
+	}
+
+	raw, err := json.Marshal(content{Data: configMap.Data, BinaryData: configMap.BinaryData})
+	if err != nil {
+		return nil, err
+	}
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(raw); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	payload := compressed.Bytes()
+	var parts [][]byte
+	for len(payload) > partSize {
+		parts = append(parts, payload[:partSize])
+		payload = payload[partSize:]
+	}
+	parts = append(parts, payload)
+
+	var configMaps []*corev1.ConfigMap
+	for i, part := range parts {
+		encoded := &corev1.ConfigMap{}
+		configMap.ObjectMeta.DeepCopyInto(&encoded.ObjectMeta)
+		encoded.Name = PartName(configMap.Name, i)
+		if i > 0 {
+			// only the metadata relating the parts to the revision is kept
+			encoded.ObjectMeta.Annotations = nil
+			encoded.ObjectMeta.ResourceVersion = ""
+			encoded.ObjectMeta.UID = ""
+		}
+		if encoded.Annotations == nil {
+			encoded.Annotations = map[string]string{}
+		}
+		encoded.Annotations[ContentEncodingAnnotation] = GzipEncoding
+		encoded.Annotations[PartsAnnotation] = strconv.Itoa(len(parts))
+		encoded.BinaryData = map[string][]byte{payloadKey: part}
+		configMaps = append(configMaps, encoded)
+	}
+	return configMaps, nil
+}
+
+// Decode returns a copy of the configmap with its content decoded, getting the configmaps holding its other parts by
+// name, or the configmap itself when its content is not encoded.
+func Decode(configMap *corev1.ConfigMap, getPart func(name string) (*corev1.ConfigMap, error)) (*corev1.ConfigMap, error) {
+	decoded := configMap.DeepCopy()
+	encoding, ok := configMap.Annotations[ContentEncodingAnnotation]
+	if !ok {
+		return decoded, nil
+	}
+	if encoding != GzipEncoding {
+		return nil, fmt.Errorf("configmap %s/%s has the unsupported content encoding %q", configMap.Namespace, configMap.Name, encoding)
+	}
+	parts, err := strconv.Atoi(configMap.Annotations[PartsAnnotation])
+	if err != nil || parts < 1 {
+		return nil, fmt.Errorf("configmap %s/%s has an invalid number of parts %q", configMap.Namespace, configMap.Name, configMap.Annotations[PartsAnnotation])
+	}
+
+	var compressed bytes.Buffer
+	compressed.Write(configMap.BinaryData[payloadKey])
+	for i := 1; i < parts; i++ {
+		part, err := getPart(PartName(configMap.Name, i))
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the part %d of configmap %s/%s: %v", i, configMap.Namespace, configMap.Name, err)
+		}
+		compressed.Write(part.BinaryData[payloadKey])
+	}
+
+	reader, err := gzip.NewReader(&compressed)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decompress configmap %s/%s: %v", configMap.Namespace, configMap.Name, err)
+	}
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decompress configmap %s/%s: %v", configMap.Namespace, configMap.Name, err)
+	}
+	var c content
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("unable to decode configmap %s/%s: %v", configMap.Namespace, configMap.Name, err)
+	}
+
+	delete(decoded.Annotations, ContentEncodingAnnotation)
+	delete(decoded.Annotations, PartsAnnotation)
+	decoded.Data = c.Data
+	decoded.BinaryData = c.BinaryData
+	return decoded, nil
+}
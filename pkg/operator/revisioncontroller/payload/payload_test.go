@@ -0,0 +1,133 @@
+package payload
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	var large strings.Builder
+	for i := 0; large.Len() < 64*1024; i++ {
+		fmt.Fprintf(&large, "%d:%x\n", i, i*7919)
+	}
+	source := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "ns",
+			Name:            "config-1",
+			Labels:          map[string]string{"revision": "1"},
+			Annotations:     map[string]string{"existing": "annotation"},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ConfigMap", Name: "revision-status-1"}},
+		},
+		Data:       map[string]string{"ca-bundle.crt": large.String(), "policy.yaml": "rules: []"},
+		BinaryData: map[string][]byte{"binary": {0, 1, 2}},
+	}
+
+	testCases := []struct {
+		name          string
+		threshold     int
+		partSize      int
+		expectedParts int
+		encoded       bool
+	}{
+		{name: "below the threshold", threshold: 1024 * 1024, partSize: 1024, expectedParts: 1},
+		{name: "compressed", threshold: 1024, partSize: 1024 * 1024, expectedParts: 1, encoded: true},
+		{name: "compressed and split", threshold: 1024, partSize: 4 * 1024, expectedParts: 0, encoded: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parts, err := encode(source, tc.threshold, tc.partSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.expectedParts != 0 && len(parts) != tc.expectedParts {
+				t.Fatalf("expected %d parts, got %d", tc.expectedParts, len(parts))
+			}
+			if tc.expectedParts == 0 && len(parts) < 2 {
+				t.Fatalf("expected the content to be split, got %d parts", len(parts))
+			}
+			if IsEncoded(parts[0]) != tc.encoded {
+				t.Fatalf("expected encoded %v, got annotations %v", tc.encoded, parts[0].Annotations)
+			}
+
+			byName := map[string]*corev1.ConfigMap{}
+			for i, part := range parts {
+				if part.Name != PartName("config-1", i) || part.Namespace != "ns" {
+					t.Errorf("unexpected part %s/%s", part.Namespace, part.Name)
+				}
+				if !reflect.DeepEqual(part.OwnerReferences, source.OwnerReferences) || !reflect.DeepEqual(part.Labels, source.Labels) {
+					t.Errorf("expected the part %s to keep the labels and owner references", part.Name)
+				}
+				if tc.encoded && Size(part) > tc.partSize+len(payloadKey) {
+					t.Errorf("part %s is too large: %d", part.Name, Size(part))
+				}
+				byName[part.Name] = part
+			}
+
+			decoded, err := Decode(parts[0], func(name string) (*corev1.ConfigMap, error) {
+				part, ok := byName[name]
+				if !ok {
+					return nil, fmt.Errorf("not found")
+				}
+				return part, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded, source) {
+				t.Errorf("expected the decoded configmap to match the source, got %#v", decoded.ObjectMeta)
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	source := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "config-1"},
+		Data:       map[string]string{"key": strings.Repeat("0123456789abcdef", 1024)},
+	}
+	parts, err := encode(source, 1024, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notFound := func(name string) (*corev1.ConfigMap, error) { return nil, fmt.Errorf("not found") }
+
+	testCases := []struct {
+		name          string
+		configMap     *corev1.ConfigMap
+		expectedError string
+	}{
+		{
+			name:          "missing part",
+			configMap:     parts[0],
+			expectedError: "unable to get the part 1 of configmap ns/config-1: not found",
+		},
+		{
+			name: "unsupported encoding",
+			configMap: &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "config-1", Annotations: map[string]string{
+				ContentEncodingAnnotation: "zstd",
+			}}},
+			expectedError: `configmap ns/config-1 has the unsupported content encoding "zstd"`,
+		},
+		{
+			name: "invalid parts",
+			configMap: &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "config-1", Annotations: map[string]string{
+				ContentEncodingAnnotation: GzipEncoding,
+				PartsAnnotation:           "0",
+			}}},
+			expectedError: `configmap ns/config-1 has an invalid number of parts "0"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Decode(tc.configMap, notFound)
+			if err == nil || err.Error() != tc.expectedError {
+				t.Errorf("expected error %q, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/management"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/revisioncontroller/payload"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 )
 
@@ -48,6 +49,9 @@ type RevisionController struct {
 	operatorClient  LatestRevisionClient
 	configMapGetter corev1client.ConfigMapsGetter
 	secretGetter    corev1client.SecretsGetter
+
+	// statusDataFn returns additional data to record in the status configmap of a new revision
+	statusDataFn func(ctx context.Context, revision int32) (map[string]string, error)
 }
 
 type RevisionResource struct {
@@ -55,6 +59,29 @@ type RevisionResource struct {
 	Optional bool
 }
 
+// Option configures the RevisionController.
+type Option func(*RevisionController)
+
+// WithStatusData records the data returned by statusDataFn in the status configmap of every new revision, e.g. the
+// installer pinned to the revision. The revision is not created when statusDataFn fails.
+func WithStatusData(statusDataFn func(ctx context.Context, revision int32) (map[string]string, error)) Option {
+	return func(c *RevisionController) {
+		c.statusDataFn = statusDataFn
+	}
+}
+
+const statusConfigMapName = "revision-status"
+
+// StatusConfigMapName returns the name of the status configmap of revision, which owns the resources of the revision.
+func StatusConfigMapName(revision int32) string {
+	return nameFor(statusConfigMapName, revision)
+}
+
+// IsStatusConfigMapName returns whether name is the name of the status configmap of a revision.
+func IsStatusConfigMapName(name string) bool {
+	return strings.HasPrefix(name, statusConfigMapName+"-")
+}
+
 // NewRevisionController create a new revision controller.
 func NewRevisionController(
 	targetNamespace string,
@@ -65,6 +92,7 @@ func NewRevisionController(
 	configMapGetter corev1client.ConfigMapsGetter,
 	secretGetter corev1client.SecretsGetter,
 	eventRecorder events.Recorder,
+	opts ...Option,
 ) factory.Controller {
 	c := &RevisionController{
 		targetNamespace: targetNamespace,
@@ -75,6 +103,9 @@ func NewRevisionController(
 		configMapGetter: configMapGetter,
 		secretGetter:    secretGetter,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
 
 	return factory.New().WithInformers(
 		operatorClient.Informer(),
@@ -132,14 +163,18 @@ func (c RevisionController) isLatestRevisionCurrent(ctx context.Context, revisio
 		requiredData := map[string]string{}
 		existingData := map[string]string{}
 
-		required, err := c.configMapGetter.ConfigMaps(c.targetNamespace).Get(ctx, cm.Name, metav1.GetOptions{})
+		// the configmaps may be encoded by their producer and by the revision, their decoded content is compared
+		required, err := payload.Get(ctx, c.configMapGetter, c.targetNamespace, cm.Name)
 		if apierrors.IsNotFound(err) && !cm.Optional {
 			return false, err.Error()
 		}
-		existing, err := c.configMapGetter.ConfigMaps(c.targetNamespace).Get(ctx, nameFor(cm.Name, revision), metav1.GetOptions{})
+		existing, err := payload.Get(ctx, c.configMapGetter, c.targetNamespace, nameFor(cm.Name, revision))
 		if apierrors.IsNotFound(err) && !cm.Optional {
 			return false, err.Error()
 		}
+		if err != nil && !apierrors.IsNotFound(err) {
+			return false, err.Error()
+		}
 		if required != nil {
 			requiredData = required.Data
 		}
@@ -213,13 +248,21 @@ func (c RevisionController) createNewRevision(ctx context.Context, recorder even
 	statusConfigMap := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: c.targetNamespace,
-			Name:      nameFor("revision-status", revision),
-		},
-		Data: map[string]string{
-			"revision": fmt.Sprintf("%d", revision),
-			"reason":   reason,
+			Name:      StatusConfigMapName(revision),
 		},
+		Data: map[string]string{},
 	}
+	if c.statusDataFn != nil {
+		data, err := c.statusDataFn(ctx, revision)
+		if err != nil {
+			return err
+		}
+		for k, v := range data {
+			statusConfigMap.Data[k] = v
+		}
+	}
+	statusConfigMap.Data["revision"] = fmt.Sprintf("%d", revision)
+	statusConfigMap.Data["reason"] = reason
 	statusConfigMap, _, err := resourceapply.ApplyConfigMap(ctx, c.configMapGetter, recorder, statusConfigMap)
 	if err != nil {
 		return err
@@ -233,6 +276,14 @@ func (c RevisionController) createNewRevision(ctx context.Context, recorder even
 	}}
 
 	for _, cm := range c.configMaps {
+		// the configmaps encoded by their producer are copied with all their parts, and encoded again in the revision
+		source, err := c.configMapGetter.ConfigMaps(c.targetNamespace).Get(ctx, cm.Name, metav1.GetOptions{})
+		if err == nil && payload.IsEncoded(source) {
+			if err := c.applyEncodedConfigMap(ctx, recorder, source, nameFor(cm.Name, revision), ownerRefs); err != nil {
+				return err
+			}
+			continue
+		}
 		obj, _, err := resourceapply.SyncConfigMap(ctx, c.configMapGetter, recorder, c.targetNamespace, cm.Name, c.targetNamespace, nameFor(cm.Name, revision), ownerRefs)
 		if err != nil {
 			return err
@@ -263,7 +314,7 @@ func (c RevisionController) getLatestAvailableRevision(ctx context.Context) (int
 	}
 	var latestRevision int32
 	for _, configMap := range configMaps.Items {
-		if !strings.HasPrefix(configMap.Name, "revision-status-") {
+		if !IsStatusConfigMapName(configMap.Name) {
 			continue
 		}
 		if revision, ok := configMap.Data["revision"]; ok {
@@ -333,3 +384,29 @@ func (c RevisionController) sync(ctx context.Context, syncCtx factory.SyncContex
 
 	return err
 }
+
+// applyEncodedConfigMap creates the target configmap with the content of the encoded source, encoded again with the
+// configmaps holding the other parts of the content when it is split.
+func (c RevisionController) applyEncodedConfigMap(ctx context.Context, recorder events.Recorder, source *corev1.ConfigMap, targetName string, ownerRefs []metav1.OwnerReference) error {
+	source, err := payload.Decode(source, func(name string) (*corev1.ConfigMap, error) {
+		return c.configMapGetter.ConfigMaps(c.targetNamespace).Get(ctx, name, metav1.GetOptions{})
+	})
+	if err != nil {
+		return err
+	}
+	target := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       c.targetNamespace,
+			Name:            targetName,
+			Labels:          source.Labels,
+			Annotations:     source.Annotations,
+			OwnerReferences: ownerRefs,
+		},
+		Data:       source.Data,
+		BinaryData: source.BinaryData,
+	}
+	if _, _, err := payload.ApplyConfigMap(ctx, c.configMapGetter, recorder, target); err != nil {
+		return fmt.Errorf("failed to copy configmap %s/%s: %w", source.Namespace, source.Name, err)
+	}
+	return nil
+}
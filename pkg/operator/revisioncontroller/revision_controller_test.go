@@ -2,6 +2,8 @@ package revisioncontroller
 
 import (
 	"context"
+	"encoding/hex"
+	"math/rand"
 	"strings"
 	"testing"
 	"time"
@@ -18,6 +20,7 @@ import (
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/revisioncontroller/payload"
 )
 
 func filterCreateActions(actions []clienttesting.Action) []runtime.Object {
@@ -55,6 +58,19 @@ func filterUpdateActions(actions []clienttesting.Action) []runtime.Object {
 const targetNamespace = "copy-resources"
 
 func TestRevisionController(t *testing.T) {
+	// random data compresses poorly, so that the copy of the configmap is split
+	random := make([]byte, 800*1024)
+	rand.New(rand.NewSource(1)).Read(random)
+	largeData := hex.EncodeToString(random)
+	// the producer of the source configmap encodes it in two parts
+	largeSource, err := payload.Encode(&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test-config", Namespace: targetNamespace}, Data: map[string]string{"large": largeData}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(largeSource) != 2 {
+		t.Fatalf("expected the source to be split in 2 parts, got %d", len(largeSource))
+	}
+
 	tests := []struct {
 		testName                string
 		targetNamespace         string
@@ -62,6 +78,7 @@ func TestRevisionController(t *testing.T) {
 		testConfigs             []RevisionResource
 		startingObjects         []runtime.Object
 		staticPodOperatorClient v1helpers.StaticPodOperatorClient
+		options                 []Option
 		validateActions         func(t *testing.T, actions []clienttesting.Action, kclient *fake.Clientset)
 		validateStatus          func(t *testing.T, status *operatorv1.StaticPodOperatorStatus)
 		expectSyncError         string
@@ -278,6 +295,102 @@ func TestRevisionController(t *testing.T) {
 				}
 			},
 		},
+		{
+			testName:        "copy-large-resources",
+			targetNamespace: targetNamespace,
+			staticPodOperatorClient: v1helpers.NewFakeStaticPodOperatorClient(
+				&operatorv1.StaticPodOperatorSpec{
+					OperatorSpec: operatorv1.OperatorSpec{
+						ManagementState: operatorv1.Managed,
+					},
+				},
+				&operatorv1.StaticPodOperatorStatus{
+					LatestAvailableRevision: 0,
+					NodeStatuses: []operatorv1.NodeStatus{
+						{
+							NodeName:        "test-node-1",
+							CurrentRevision: 0,
+							TargetRevision:  0,
+						},
+					},
+				},
+				nil,
+				nil,
+			),
+			startingObjects: []runtime.Object{
+				largeSource[0],
+				largeSource[1],
+				&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "revision-status", Namespace: targetNamespace}},
+			},
+			testConfigs: []RevisionResource{{Name: "test-config"}},
+			validateActions: func(t *testing.T, actions []clienttesting.Action, kclient *fake.Clientset) {
+				createdObjects := filterCreateActions(actions)
+				if createdObjectCount := len(createdObjects); createdObjectCount != 3 {
+					t.Errorf("expected 3 objects to be created, got %d", createdObjectCount)
+					return
+				}
+				part, hasPart := createdObjects[1].(*v1.ConfigMap)
+				if !hasPart {
+					t.Errorf("expected config part to be created")
+					return
+				}
+				if part.Name != "test-config-1-part-1" {
+					t.Errorf("expected config part to have name 'test-config-1-part-1', got %q", part.Name)
+				}
+				if len(part.OwnerReferences) != 1 {
+					t.Errorf("expected config part to have ownerreferences set, got %+v", part.OwnerReferences)
+				}
+				config, hasConfig := createdObjects[2].(*v1.ConfigMap)
+				if !hasConfig {
+					t.Errorf("expected config to be created")
+					return
+				}
+				if config.Name != "test-config-1" || config.Annotations[payload.PartsAnnotation] != "2" {
+					t.Errorf("expected config 'test-config-1' encoded in 2 parts, got %q with annotations %v", config.Name, config.Annotations)
+				}
+				decoded, err := payload.Decode(config, func(name string) (*v1.ConfigMap, error) {
+					return kclient.CoreV1().ConfigMaps(targetNamespace).Get(context.TODO(), name, metav1.GetOptions{})
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if decoded.Data["large"] != largeData {
+					t.Errorf("expected the decoded config to have the source data")
+				}
+			},
+		},
+		{
+			testName:        "record-status-data",
+			targetNamespace: targetNamespace,
+			staticPodOperatorClient: v1helpers.NewFakeStaticPodOperatorClient(
+				&operatorv1.StaticPodOperatorSpec{
+					OperatorSpec: operatorv1.OperatorSpec{
+						ManagementState: operatorv1.Managed,
+					},
+				},
+				&operatorv1.StaticPodOperatorStatus{
+					LatestAvailableRevision: 0,
+				},
+				nil,
+				nil,
+			),
+			startingObjects: []runtime.Object{
+				&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test-config", Namespace: targetNamespace}},
+			},
+			testConfigs: []RevisionResource{{Name: "test-config"}},
+			options: []Option{WithStatusData(func(ctx context.Context, revision int32) (map[string]string, error) {
+				return map[string]string{"installer-image": "installer", "revision": "overridden"}, nil
+			})},
+			validateActions: func(t *testing.T, actions []clienttesting.Action, kclient *fake.Clientset) {
+				statusConfigMap, err := kclient.CoreV1().ConfigMaps(targetNamespace).Get(context.TODO(), StatusConfigMapName(1), metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if statusConfigMap.Data["installer-image"] != "installer" || statusConfigMap.Data["revision"] != "1" {
+					t.Errorf("expected the status data to be recorded, got %v", statusConfigMap.Data)
+				}
+			},
+		},
 		{
 			testName:        "copy-resources-opt",
 			targetNamespace: targetNamespace,
@@ -510,6 +623,7 @@ func TestRevisionController(t *testing.T) {
 				kubeClient.CoreV1(),
 				kubeClient.CoreV1(),
 				eventRecorder,
+				tc.options...,
 			)
 			syncErr := c.Sync(context.TODO(), factory.NewSyncContext("RevisionController", eventRecorder))
 			if tc.validateStatus != nil {
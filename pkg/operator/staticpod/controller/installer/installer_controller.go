@@ -3,6 +3,7 @@ package installer
 import (
 	"context"
 	_ "embed"
+	"encoding/json"
 	"fmt"
 	"math"
 	"os"
@@ -20,7 +21,9 @@ import (
 	"github.com/openshift/library-go/pkg/operator/management"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+	"github.com/openshift/library-go/pkg/operator/revisioncontroller"
 	"github.com/openshift/library-go/pkg/operator/staticpod/controller/revision"
+	"github.com/openshift/library-go/pkg/operator/staticpod/controller/safety"
 	"github.com/openshift/library-go/pkg/operator/staticpod/startupmonitor/annotations"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 	corev1 "k8s.io/api/core/v1"
@@ -44,6 +47,9 @@ const (
 	nodeStatusOperandFailedReason         = "OperandFailed"
 	nodeStatusInstalledFailedReason       = "InstallerFailed"
 	nodeStatusOperandFailedFallbackReason = "OperandFailedFallback"
+
+	// safetyRecheckInterval is how often an installation vetoed by the safety checker is checked again
+	safetyRecheckInterval = 30 * time.Second
 )
 
 //go:embed manifests/installer-pod.yaml
@@ -94,6 +100,13 @@ type InstallerController struct {
 
 	startupMonitorEnabled func() (bool, error)
 
+	// safetyChecker vetoes the installations which are unsafe for the operand
+	safetyChecker safety.Checker
+
+	// pinInstallerPerRevision installs the revisions with the installer pinned in their revision status configmap, see
+	// InstallerPin
+	pinInstallerPerRevision bool
+
 	factory          *factory.Factory
 	clock            clock.Clock
 	installerBackOff func(count int) time.Duration
@@ -128,6 +141,23 @@ func (c *InstallerController) WithStartupMonitorSupport(startupMonitorEnabled fu
 	return c
 }
 
+// WithSafetyChecker sets the checker consulted before rolling a new revision out to a node. The installation is delayed
+// while the checker deems it unsafe for the operand.
+func (c *InstallerController) WithSafetyChecker(safetyChecker safety.Checker) *InstallerController {
+	c.safetyChecker = safetyChecker
+	return c
+}
+
+// WithInstallerPinnedPerRevision installs the revisions with the installer image, command and arguments pinned in their
+// revision status configmap when they were created, see InstallerPin. During an operator upgrade the in-flight
+// installations keep using the installer compatible with the content of the revision they install, and only the new
+// revisions are installed with the installer of the new operator. The revisions created without a pinned installer are
+// installed with the current one.
+func (c *InstallerController) WithInstallerPinnedPerRevision() *InstallerController {
+	c.pinInstallerPerRevision = true
+	return c
+}
+
 // staticPodState is the status of a static pod that has been installed to a node.
 type staticPodState int
 
@@ -286,6 +316,10 @@ type staticPodStateFunc func(ctx context.Context, nodeName string) (state static
 // - not updating
 // - ready
 // - at the revision claimed in CurrentRevision.
+//
+// The exception is a node which joined an installed control plane, to replace a removed node or to scale it up: it is
+// returned once no node is updating and no node failed a newer revision, even if nodes < i are not ready or not at
+// their claimed revision, because it does not run the operand yet and installing it does not take an operand down.
 func nodeToStartRevisionWith(ctx context.Context, getStaticPodStateFn staticPodStateFunc, nodes []operatorv1.NodeStatus) (int, string, error) {
 	if len(nodes) == 0 {
 		return 0, "", fmt.Errorf("nodes array cannot be empty")
@@ -311,6 +345,17 @@ func nodeToStartRevisionWith(ctx context.Context, getStaticPodStateFn staticPodS
 		}
 	}
 
+	// bring the nodes which joined an installed control plane, to replace a removed node or to scale it up, to the latest
+	// revision first as they do not run the operand yet
+	if mostCurrent > 0 {
+		for i := range nodes {
+			if nodes[i].CurrentRevision == 0 {
+				reason := fmt.Sprintf("node %s is new and has no revision yet", nodes[i].NodeName)
+				return i, reason, nil
+			}
+		}
+	}
+
 	// otherwise try to find a node that is not ready. Take the oldest one.
 	oldestNotReadyRevisionNode := -1
 	oldestNotReadyRevision := math.MaxInt32
@@ -523,6 +568,17 @@ func (c *InstallerController) manageInstallationPods(ctx context.Context, operat
 
 		klog.Infof("%s and needs new revision %d", nodeChoiceReason, revisionToStart)
 
+		if c.safetyChecker != nil {
+			safe, reason, err := c.safetyChecker.IsSafe(ctx, safety.OperationInstall, currNodeState.NodeName)
+			if err != nil {
+				return true, 0, fmt.Errorf("failed to check whether installing revision %d on node %q is safe: %w", revisionToStart, currNodeState.NodeName, err)
+			}
+			if !safe {
+				klog.Infof("Delaying the installation of revision %d on node %q because %s", revisionToStart, currNodeState.NodeName, reason)
+				return true, safetyRecheckInterval, nil
+			}
+		}
+
 		newCurrNodeState := currNodeState.DeepCopy()
 		newCurrNodeState.TargetRevision = revisionToStart
 
@@ -850,27 +906,62 @@ func (c *InstallerController) ensureInstallerPod(ctx context.Context, operatorSp
 	pod.Spec.Containers[0].Image = c.installerPodImageFn()
 	pod.Spec.Containers[0].Command = c.command
 
+	var args []string
+	pinned := false
+	if c.pinInstallerPerRevision {
+		image, command, pinnedArgs, err := c.pinnedInstaller(ctx, ns.TargetRevision)
+		if err != nil {
+			return fmt.Errorf("unable to get the pinned installer of revision %d: %w", ns.TargetRevision, err)
+		}
+		if len(image) > 0 {
+			pod.Spec.Containers[0].Image = image
+			pod.Spec.Containers[0].Command = command
+			args, pinned = pinnedArgs, true
+		}
+	}
+	if !pinned {
+		var err error
+		if args, err = c.installerArgs(ns.TargetRevision); err != nil {
+			return err
+		}
+	}
+
 	ownerRefs, err := c.ownerRefsFn(ctx, ns.TargetRevision)
 	if err != nil {
 		return fmt.Errorf("unable to set installer pod ownerrefs: %+v", err)
 	}
 	pod.OwnerReferences = ownerRefs
 
+	// the verbosity follows the operator spec
+	pod.Spec.Containers[0].Args = append([]string{fmt.Sprintf("-v=%d", loglevel.LogLevelToVerbosity(operatorSpec.LogLevel))}, args...)
+
+	// Some owners need to change aspects of the pod.  Things like arguments for instance
+	for _, fn := range c.installerPodMutationFns {
+		if err := fn(pod, ns.NodeName, operatorSpec, ns.TargetRevision); err != nil {
+			return err
+		}
+	}
+
+	_, _, err = resourceapply.ApplyPod(ctx, c.podsGetter, c.eventRecorder, pod)
+	return err
+}
+
+// installerArgs returns the arguments of the installer of revision, but the verbosity.
+func (c *InstallerController) installerArgs(revision int32) ([]string, error) {
 	if c.configMaps[0].Optional {
-		return fmt.Errorf("pod configmap %s is required, cannot be optional", c.configMaps[0].Name)
+		return nil, fmt.Errorf("pod configmap %s is required, cannot be optional", c.configMaps[0].Name)
 	}
 
 	// if the startup monitor is enabled we need to acquire an exclusive lock
 	// to coordinate the work between the installer and the monitor
 	withStartupMonitorSupport, err := c.startupMonitorEnabled()
 	if err != nil {
-		return fmt.Errorf("unable to determine if the startup monitor should be enabled: %v", err)
+		return nil, fmt.Errorf("unable to determine if the startup monitor should be enabled: %v", err)
 	}
 
 	args := []string{
-		fmt.Sprintf("-v=%d", loglevel.LogLevelToVerbosity(operatorSpec.LogLevel)),
-		fmt.Sprintf("--revision=%d", ns.TargetRevision),
-		fmt.Sprintf("--namespace=%s", pod.Namespace),
+		fmt.Sprintf("--revision=%d", revision),
+		fmt.Sprintf("--namespace=%s", c.targetNamespace),
 		fmt.Sprintf("--pod=%s", c.configMaps[0].Name),
 		fmt.Sprintf("--resource-dir=%s", hostResourceDirDir),
 		fmt.Sprintf("--pod-manifest-dir=%s", hostPodManifestDir),
@@ -910,23 +1001,12 @@ func (c *InstallerController) ensureInstallerPod(ctx context.Context, operatorSp
 			}
 		}
 	}
-
-	pod.Spec.Containers[0].Args = args
-
-	// Some owners need to change aspects of the pod.  Things like arguments for instance
-	for _, fn := range c.installerPodMutationFns {
-		if err := fn(pod, ns.NodeName, operatorSpec, ns.TargetRevision); err != nil {
-			return err
-		}
-	}
-
-	_, _, err = resourceapply.ApplyPod(ctx, c.podsGetter, c.eventRecorder, pod)
-	return err
+	return args, nil
 }
 
 func (c *InstallerController) setOwnerRefs(ctx context.Context, revision int32) ([]metav1.OwnerReference, error) {
 	ownerReferences := []metav1.OwnerReference{}
-	statusConfigMap, err := c.configMapsGetter.ConfigMaps(c.targetNamespace).Get(ctx, fmt.Sprintf("revision-status-%d", revision), metav1.GetOptions{})
+	statusConfigMap, err := c.configMapsGetter.ConfigMaps(c.targetNamespace).Get(ctx, revisioncontroller.StatusConfigMapName(revision), metav1.GetOptions{})
 	if err == nil {
 		ownerReferences = append(ownerReferences, metav1.OwnerReference{
 			APIVersion: "v1",
@@ -938,6 +1018,60 @@ func (c *InstallerController) setOwnerRefs(ctx context.Context, revision int32)
 	return ownerReferences, err
 }
 
+const (
+	// installerImageKey is the key of the installer image pinned in a revision status configmap.
+	installerImageKey = "installer-image"
+	// installerCommandKey is the key of the JSON encoded installer command pinned in a revision status configmap.
+	installerCommandKey = "installer-command"
+	// installerArgsKey is the key of the JSON encoded installer arguments, but the verbosity, pinned in a revision
+	// status configmap.
+	installerArgsKey = "installer-args"
+)
+
+// InstallerPin returns the installer image, command and arguments of revision to pin in its revision status configmap.
+// It is meant to be recorded by the revision controller when it creates the revision, see
+// revisioncontroller.WithStatusData, for the installer controller WithInstallerPinnedPerRevision.
+func (c *InstallerController) InstallerPin(ctx context.Context, revision int32) (map[string]string, error) {
+	args, err := c.installerArgs(revision)
+	if err != nil {
+		return nil, err
+	}
+	commandBytes, err := json.Marshal(c.command)
+	if err != nil {
+		return nil, err
+	}
+	argsBytes, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		installerImageKey:   c.installerPodImageFn(),
+		installerCommandKey: string(commandBytes),
+		installerArgsKey:    string(argsBytes),
+	}, nil
+}
+
+// pinnedInstaller returns the installer image, command and arguments pinned in the revision status configmap of
+// revision, or an empty image when the revision has no pinned installer.
+func (c *InstallerController) pinnedInstaller(ctx context.Context, revision int32) (string, []string, []string, error) {
+	statusConfigMap, err := c.configMapsGetter.ConfigMaps(c.targetNamespace).Get(ctx, revisioncontroller.StatusConfigMapName(revision), metav1.GetOptions{})
+	if err != nil {
+		return "", nil, nil, err
+	}
+	image, ok := statusConfigMap.Data[installerImageKey]
+	if !ok {
+		return "", nil, nil, nil
+	}
+	var command, args []string
+	if err := json.Unmarshal([]byte(statusConfigMap.Data[installerCommandKey]), &command); err != nil {
+		return "", nil, nil, fmt.Errorf("invalid %s in configmap %s: %w", installerCommandKey, statusConfigMap.Name, err)
+	}
+	if err := json.Unmarshal([]byte(statusConfigMap.Data[installerArgsKey]), &args); err != nil {
+		return "", nil, nil, fmt.Errorf("invalid %s in configmap %s: %w", installerArgsKey, statusConfigMap.Name, err)
+	}
+	return image, command, args, nil
+}
+
 func getInstallerPodImageFromEnv() string {
 	return os.Getenv("OPERATOR_IMAGE")
 }
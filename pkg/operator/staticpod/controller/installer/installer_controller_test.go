@@ -16,6 +16,7 @@ import (
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
 	"github.com/openshift/library-go/pkg/operator/staticpod/controller/revision"
+	"github.com/openshift/library-go/pkg/operator/staticpod/controller/safety"
 	"github.com/openshift/library-go/pkg/operator/staticpod/startupmonitor/annotations"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 	corev1 "k8s.io/api/core/v1"
@@ -1156,6 +1157,82 @@ func TestCreateInstallerPod(t *testing.T) {
 	}
 }
 
+func TestInstallationVetoedBySafetyChecker(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "test-config"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "test-secret"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: fmt.Sprintf("%s-%d", "test-secret", 1)}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: fmt.Sprintf("%s-%d", "test-config", 1)}},
+	)
+	kubeInformers := informers.NewSharedInformerFactoryWithOptions(kubeClient, 1*time.Minute, informers.WithNamespace("test"))
+	fakeStaticPodOperatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+		&operatorv1.StaticPodOperatorSpec{
+			OperatorSpec: operatorv1.OperatorSpec{
+				ManagementState: operatorv1.Managed,
+			},
+		},
+		&operatorv1.StaticPodOperatorStatus{
+			LatestAvailableRevision: 1,
+			NodeStatuses: []operatorv1.NodeStatus{
+				{NodeName: "test-node-1"},
+			},
+		},
+		nil,
+		nil,
+	)
+	eventRecorder := events.NewInMemoryRecorder("test")
+
+	safe := false
+	var checkedOperation safety.Operation
+	var checkedNode string
+	c := NewInstallerController(
+		"test", "test-pod",
+		[]revision.RevisionResource{{Name: "test-config"}},
+		[]revision.RevisionResource{{Name: "test-secret"}},
+		[]string{"/bin/true"},
+		kubeInformers,
+		fakeStaticPodOperatorClient,
+		kubeClient.CoreV1(),
+		kubeClient.CoreV1(),
+		kubeClient.CoreV1(),
+		eventRecorder,
+	).WithSafetyChecker(safety.CheckerFunc(func(ctx context.Context, operation safety.Operation, nodeName string) (bool, string, error) {
+		checkedOperation, checkedNode = operation, nodeName
+		return safe, "the operand is unhealthy", nil
+	}))
+	c.ownerRefsFn = func(ctx context.Context, revision int32) ([]metav1.OwnerReference, error) {
+		return []metav1.OwnerReference{}, nil
+	}
+	c.installerPodImageFn = func() string { return "docker.io/foo/bar" }
+
+	targetRevision := func() int32 {
+		t.Helper()
+		_, status, _, err := fakeStaticPodOperatorClient.GetStaticPodOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status.NodeStatuses[0].TargetRevision
+	}
+
+	if err := c.Sync(context.TODO(), factory.NewSyncContext("InstallerController", eventRecorder)); err != nil {
+		t.Fatal(err)
+	}
+	if revision := targetRevision(); revision != 0 {
+		t.Fatalf("expected the vetoed installation to be delayed, got target revision %d", revision)
+	}
+	if checkedOperation != safety.OperationInstall || checkedNode != "test-node-1" {
+		t.Errorf("expected the installation on test-node-1 to be checked, got %q on %q", checkedOperation, checkedNode)
+	}
+
+	safe = true
+	if err := c.Sync(context.TODO(), factory.NewSyncContext("InstallerController", eventRecorder)); err != nil {
+		t.Fatal(err)
+	}
+	if revision := targetRevision(); revision != 1 {
+		t.Fatalf("expected the installation to proceed once safe, got target revision %d", revision)
+	}
+}
+
 func TestEnsureInstallerPod(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -2255,6 +2332,20 @@ func TestNodeToStartRevisionWith(t *testing.T) {
 			},
 			expected: 1,
 		},
+		{
+			name: "new node before not ready",
+			pods: []StaticPod{
+				{"a", staticPodStateReady, 2},
+				{"b", staticPodStatePending, 1},
+				{"c", staticPodStateReady, 1},
+			},
+			nodes: []operatorv1.NodeStatus{
+				newNode("a", 2, 0),
+				newNode("b", 1, 0),
+				newNode("c", 0, 0),
+			},
+			expected: 2,
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			fakeGetStaticPodState := func(ctx context.Context, nodeName string) (state staticPodState, revision, reason string, errs []string, ts time.Time, err error) {
@@ -2617,3 +2708,83 @@ func metav1TimestampPtr(s string) *metav1.Time {
 	t := metav1Timestamp(s)
 	return &t
 }
+
+func TestPinnedInstaller(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "revision-status-1"}, Data: map[string]string{"revision": "1"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "revision-status-2"}, Data: map[string]string{"revision": "2"}},
+	)
+	newController := func(image string, command []string, startupMonitor bool) *InstallerController {
+		c := NewInstallerController(
+			"test", "test-pod",
+			[]revision.RevisionResource{{Name: "test-config"}},
+			[]revision.RevisionResource{{Name: "test-secret"}},
+			command,
+			informers.NewSharedInformerFactoryWithOptions(kubeClient, 1*time.Minute, informers.WithNamespace("test")),
+			v1helpers.NewFakeStaticPodOperatorClient(&operatorv1.StaticPodOperatorSpec{}, &operatorv1.StaticPodOperatorStatus{}, nil, nil),
+			kubeClient.CoreV1(),
+			kubeClient.CoreV1(),
+			kubeClient.CoreV1(),
+			events.NewInMemoryRecorder("test"),
+		).WithStartupMonitorSupport(func() (bool, error) {
+			return startupMonitor, nil
+		}).WithInstallerPinnedPerRevision()
+		c.installerPodImageFn = func() string { return image }
+		return c
+	}
+	installerPod := func(c *InstallerController, revision int32) *corev1.Pod {
+		t.Helper()
+		ns := &operatorv1.NodeStatus{NodeName: "test-node-1", TargetRevision: revision}
+		if err := c.ensureInstallerPod(context.TODO(), &operatorv1.StaticPodOperatorSpec{}, ns); err != nil {
+			t.Fatal(err)
+		}
+		pod, err := kubeClient.CoreV1().Pods("test").Get(context.TODO(), getInstallerPodName(ns), metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pod
+	}
+
+	// the revision controller of the old operator pins the installer of revision 1 when it creates it
+	oldController := newController("installer:old", []string{"cluster-operator", "installer"}, false)
+	pin, err := oldController.InstallerPin(context.TODO(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statusConfigMap, err := kubeClient.CoreV1().ConfigMaps("test").Get(context.TODO(), "revision-status-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range pin {
+		statusConfigMap.Data[k] = v
+	}
+	if _, err := kubeClient.CoreV1().ConfigMaps("test").Update(context.TODO(), statusConfigMap, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the upgraded operator installs revision 1 with the pinned installer, including its arguments
+	upgradedController := newController("installer:new", []string{"cluster-operator", "install"}, true)
+	pod := installerPod(upgradedController, 1)
+	if container := pod.Spec.Containers[0]; container.Image != "installer:old" || !reflect.DeepEqual(container.Command, []string{"cluster-operator", "installer"}) {
+		t.Errorf("expected the pinned installer, got %s %v", container.Image, container.Command)
+	}
+	for _, arg := range pod.Spec.Containers[0].Args {
+		if strings.HasPrefix(arg, "--pod-manifests-lock-file") {
+			t.Errorf("expected the pinned arguments, got %v", pod.Spec.Containers[0].Args)
+		}
+	}
+
+	// and installs the revisions without a pinned installer with its own
+	pod = installerPod(upgradedController, 2)
+	if container := pod.Spec.Containers[0]; container.Image != "installer:new" || !reflect.DeepEqual(container.Command, []string{"cluster-operator", "install"}) {
+		t.Errorf("expected the current installer for an unpinned revision, got %s %v", container.Image, container.Command)
+	}
+	if args := pod.Spec.Containers[0].Args; !strings.Contains(strings.Join(args, " "), "--pod-manifests-lock-file=/var/lock/test-pod-installer.lock") {
+		t.Errorf("expected the current arguments for an unpinned revision, got %v", args)
+	}
+
+	// a missing revision status configmap is an error
+	if _, _, _, err := upgradedController.pinnedInstaller(context.TODO(), 3); !errors.IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
@@ -20,6 +20,7 @@ import (
 )
 
 // NodeController watches for new master nodes and adds them to the node status list in the operator config status.
+// It reports the replacement and the scale up of the master nodes until the new nodes reach a revision.
 type NodeController struct {
 	operatorClient v1helpers.StaticPodOperatorClient
 	nodeLister     corelisterv1.NodeLister
@@ -54,6 +55,7 @@ func (c NodeController) sync(ctx context.Context, syncCtx factory.SyncContext) e
 	}
 
 	newTargetNodeStates := []operatorv1.NodeStatus{}
+	removedNodes := []string{}
 	// remove entries for missing nodes
 	for i, nodeState := range originalOperatorStatus.NodeStatuses {
 		found := false
@@ -66,6 +68,7 @@ func (c NodeController) sync(ctx context.Context, syncCtx factory.SyncContext) e
 			newTargetNodeStates = append(newTargetNodeStates, originalOperatorStatus.NodeStatuses[i])
 		} else {
 			syncCtx.Recorder().Warningf("MasterNodeRemoved", "Observed removal of master node %s", nodeState.NodeName)
+			removedNodes = append(removedNodes, nodeState.NodeName)
 		}
 	}
 
@@ -114,12 +117,19 @@ func (c NodeController) sync(ctx context.Context, syncCtx factory.SyncContext) e
 		newCondition.Message = "All master nodes are ready"
 	}
 
+	scalingCondition := verticalScalingCondition(originalOperatorStatus.Conditions, newTargetNodeStates, removedNodes)
+	updateScalingCondition := v1helpers.UpdateStaticPodConditionFn(scalingCondition)
+	if scalingCondition.Reason == "AsExpected" && v1helpers.FindOperatorCondition(originalOperatorStatus.Conditions, scalingCondition.Type) == nil {
+		// the control plane never scaled, do not clutter the status
+		updateScalingCondition = func(*operatorv1.StaticPodOperatorStatus) error { return nil }
+	}
+
 	oldStatus := &operatorv1.StaticPodOperatorStatus{}
 	_, updated, updateError := v1helpers.UpdateStaticPodStatus(ctx, c.operatorClient, func(status *operatorv1.StaticPodOperatorStatus) error {
 		//a hack for storing the old status (before we mutate it)
 		oldStatus = status
 		return nil
-	}, v1helpers.UpdateStaticPodConditionFn(newCondition), func(status *operatorv1.StaticPodOperatorStatus) error {
+	}, v1helpers.UpdateStaticPodConditionFn(newCondition), updateScalingCondition, func(status *operatorv1.StaticPodOperatorStatus) error {
 		status.NodeStatuses = newTargetNodeStates
 		return nil
 	})
@@ -136,10 +146,64 @@ func (c NodeController) sync(ctx context.Context, syncCtx factory.SyncContext) e
 	if oldNodeDegradedCondition == nil || oldNodeDegradedCondition.Message != newCondition.Message {
 		syncCtx.Recorder().Eventf("MasterNodesReadyChanged", newCondition.Message)
 	}
+	oldScalingCondition := v1helpers.FindOperatorCondition(oldStatus.Conditions, condition.NodeControllerProgressingConditionType)
+	if oldScalingCondition == nil && scalingCondition.Reason != "AsExpected" ||
+		oldScalingCondition != nil && (oldScalingCondition.Status != scalingCondition.Status || oldScalingCondition.Reason != scalingCondition.Reason) {
+		syncCtx.Recorder().Eventf("MasterNodesVerticalScalingChanged", scalingCondition.Message)
+	}
 
 	return nil
 }
 
+// verticalScalingCondition reports the master nodes which joined an installed control plane, to replace a removed
+// node or to scale it up, and have not reached a revision yet. The installer controller rolls the latest revision out
+// to these nodes first. The removal of master nodes is remembered in the reason of the condition until the new nodes
+// reach a revision, so that their addition is reported as Replacing even when it is observed in a later sync.
+func verticalScalingCondition(oldConditions []operatorv1.OperatorCondition, nodeStates []operatorv1.NodeStatus, removedNodes []string) operatorv1.OperatorCondition {
+	installed := false
+	newNodes := []string{}
+	for _, nodeState := range nodeStates {
+		if nodeState.CurrentRevision > 0 {
+			installed = true
+		} else {
+			newNodes = append(newNodes, nodeState.NodeName)
+		}
+	}
+
+	oldCondition := v1helpers.FindOperatorCondition(oldConditions, condition.NodeControllerProgressingConditionType)
+	replacing := len(removedNodes) > 0 || (oldCondition != nil && (oldCondition.Reason == "MasterNodesRemoved" || oldCondition.Reason == "Replacing"))
+
+	switch {
+	case installed && len(newNodes) > 0:
+		reason := "ScalingUp"
+		if replacing {
+			reason = "Replacing"
+		}
+		return operatorv1.OperatorCondition{
+			Type:    condition.NodeControllerProgressingConditionType,
+			Status:  operatorv1.ConditionTrue,
+			Reason:  reason,
+			Message: fmt.Sprintf("The new master nodes are waiting for their first revision: %s", strings.Join(newNodes, ", ")),
+		}
+	case len(removedNodes) > 0:
+		return operatorv1.OperatorCondition{
+			Type:    condition.NodeControllerProgressingConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "MasterNodesRemoved",
+			Message: fmt.Sprintf("The master nodes were removed and not replaced yet: %s", strings.Join(removedNodes, ", ")),
+		}
+	case len(newNodes) == 0 && oldCondition != nil && oldCondition.Reason == "MasterNodesRemoved":
+		// keep waiting for the replacement
+		return *oldCondition
+	}
+	return operatorv1.OperatorCondition{
+		Type:    condition.NodeControllerProgressingConditionType,
+		Status:  operatorv1.ConditionFalse,
+		Reason:  "AsExpected",
+		Message: "All master nodes are at a revision",
+	}
+}
+
 func nodeConditionFinder(status *coreapiv1.NodeStatus, condType coreapiv1.NodeConditionType) *coreapiv1.NodeCondition {
 	for i := range status.Conditions {
 		if status.Conditions[i].Type == condType {
@@ -309,3 +309,144 @@ func TestNewNodeController(t *testing.T) {
 
 	}
 }
+
+func TestNodeControllerProgressingCondition(t *testing.T) {
+	tests := []struct {
+		name              string
+		startNodes        []runtime.Object
+		startNodeStatus   []operatorv1.NodeStatus
+		startConditions   []operatorv1.OperatorCondition
+		expectedCondition *operatorv1.OperatorCondition
+	}{
+		{
+			name:            "initial installation",
+			startNodes:      []runtime.Object{fakeMasterNode("test-node-1"), fakeMasterNode("test-node-2")},
+			startNodeStatus: []operatorv1.NodeStatus{{NodeName: "test-node-1"}},
+		},
+		{
+			name:            "node added",
+			startNodes:      []runtime.Object{fakeMasterNode("test-node-1"), fakeMasterNode("test-node-2")},
+			startNodeStatus: []operatorv1.NodeStatus{{NodeName: "test-node-1", CurrentRevision: 1}},
+			expectedCondition: &operatorv1.OperatorCondition{
+				Status:  operatorv1.ConditionTrue,
+				Reason:  "ScalingUp",
+				Message: "The new master nodes are waiting for their first revision: test-node-2",
+			},
+		},
+		{
+			name:            "node replaced",
+			startNodes:      []runtime.Object{fakeMasterNode("test-node-1"), fakeMasterNode("test-node-3")},
+			startNodeStatus: []operatorv1.NodeStatus{{NodeName: "test-node-1", CurrentRevision: 1}, {NodeName: "test-node-2", CurrentRevision: 1}},
+			expectedCondition: &operatorv1.OperatorCondition{
+				Status:  operatorv1.ConditionTrue,
+				Reason:  "Replacing",
+				Message: "The new master nodes are waiting for their first revision: test-node-3",
+			},
+		},
+		{
+			name:            "node removed",
+			startNodes:      []runtime.Object{fakeMasterNode("test-node-1")},
+			startNodeStatus: []operatorv1.NodeStatus{{NodeName: "test-node-1", CurrentRevision: 1}, {NodeName: "test-node-2", CurrentRevision: 1}},
+			expectedCondition: &operatorv1.OperatorCondition{
+				Status:  operatorv1.ConditionFalse,
+				Reason:  "MasterNodesRemoved",
+				Message: "The master nodes were removed and not replaced yet: test-node-2",
+			},
+		},
+		{
+			name:            "waiting for the replacement",
+			startNodes:      []runtime.Object{fakeMasterNode("test-node-1")},
+			startNodeStatus: []operatorv1.NodeStatus{{NodeName: "test-node-1", CurrentRevision: 1}},
+			startConditions: []operatorv1.OperatorCondition{
+				{Type: condition.NodeControllerProgressingConditionType, Status: operatorv1.ConditionFalse, Reason: "MasterNodesRemoved", Message: "The master nodes were removed and not replaced yet: test-node-2"},
+			},
+			expectedCondition: &operatorv1.OperatorCondition{
+				Status:  operatorv1.ConditionFalse,
+				Reason:  "MasterNodesRemoved",
+				Message: "The master nodes were removed and not replaced yet: test-node-2",
+			},
+		},
+		{
+			name:            "node replaced after its removal",
+			startNodes:      []runtime.Object{fakeMasterNode("test-node-1"), fakeMasterNode("test-node-3")},
+			startNodeStatus: []operatorv1.NodeStatus{{NodeName: "test-node-1", CurrentRevision: 1}},
+			startConditions: []operatorv1.OperatorCondition{
+				{Type: condition.NodeControllerProgressingConditionType, Status: operatorv1.ConditionFalse, Reason: "MasterNodesRemoved"},
+			},
+			expectedCondition: &operatorv1.OperatorCondition{
+				Status:  operatorv1.ConditionTrue,
+				Reason:  "Replacing",
+				Message: "The new master nodes are waiting for their first revision: test-node-3",
+			},
+		},
+		{
+			name:            "replacement in progress",
+			startNodes:      []runtime.Object{fakeMasterNode("test-node-1"), fakeMasterNode("test-node-3")},
+			startNodeStatus: []operatorv1.NodeStatus{{NodeName: "test-node-1", CurrentRevision: 1}, {NodeName: "test-node-3"}},
+			startConditions: []operatorv1.OperatorCondition{
+				{Type: condition.NodeControllerProgressingConditionType, Status: operatorv1.ConditionTrue, Reason: "Replacing"},
+			},
+			expectedCondition: &operatorv1.OperatorCondition{
+				Status:  operatorv1.ConditionTrue,
+				Reason:  "Replacing",
+				Message: "The new master nodes are waiting for their first revision: test-node-3",
+			},
+		},
+		{
+			name:            "replacement done",
+			startNodes:      []runtime.Object{fakeMasterNode("test-node-1"), fakeMasterNode("test-node-3")},
+			startNodeStatus: []operatorv1.NodeStatus{{NodeName: "test-node-1", CurrentRevision: 1}, {NodeName: "test-node-3", CurrentRevision: 1}},
+			startConditions: []operatorv1.OperatorCondition{
+				{Type: condition.NodeControllerProgressingConditionType, Status: operatorv1.ConditionTrue, Reason: "Replacing"},
+			},
+			expectedCondition: &operatorv1.OperatorCondition{
+				Status:  operatorv1.ConditionFalse,
+				Reason:  "AsExpected",
+				Message: "All master nodes are at a revision",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset(test.startNodes...)
+			fakeStaticPodOperatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+				&operatorv1.StaticPodOperatorSpec{
+					OperatorSpec: operatorv1.OperatorSpec{
+						ManagementState: operatorv1.Managed,
+					},
+				},
+				&operatorv1.StaticPodOperatorStatus{
+					OperatorStatus:          operatorv1.OperatorStatus{Conditions: test.startConditions},
+					LatestAvailableRevision: 1,
+					NodeStatuses:            test.startNodeStatus,
+				},
+				nil,
+				nil,
+			)
+
+			c := &NodeController{
+				operatorClient: fakeStaticPodOperatorClient,
+				nodeLister:     v1helpers.NewFakeNodeLister(kubeClient),
+			}
+			if err := c.sync(context.TODO(), factory.NewSyncContext("NodeController", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatal(err)
+			}
+
+			_, status, _, _ := fakeStaticPodOperatorClient.GetStaticPodOperatorState()
+			actual := v1helpers.FindOperatorCondition(status.Conditions, condition.NodeControllerProgressingConditionType)
+			if test.expectedCondition == nil {
+				if actual != nil {
+					t.Errorf("expected no progressing condition, got %v", actual)
+				}
+				return
+			}
+			if actual == nil {
+				t.Fatalf("expected the progressing condition %v, got none", test.expectedCondition)
+			}
+			if actual.Status != test.expectedCondition.Status || actual.Reason != test.expectedCondition.Reason || actual.Message != test.expectedCondition.Message {
+				t.Errorf("expected the progressing condition %v, got %v", test.expectedCondition, actual)
+			}
+		})
+	}
+}
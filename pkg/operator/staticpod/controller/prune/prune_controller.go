@@ -7,6 +7,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -18,6 +19,8 @@ import (
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+	"github.com/openshift/library-go/pkg/operator/revisioncontroller"
+	"github.com/openshift/library-go/pkg/operator/staticpod/controller/safety"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 )
 
@@ -40,11 +43,27 @@ type PruneController struct {
 	configMapGetter corev1client.ConfigMapsGetter
 	secretGetter    corev1client.SecretsGetter
 	podGetter       corev1client.PodsGetter
+
+	// safetyChecker vetoes the pruning of the nodes when it is unsafe for the operand
+	safetyChecker safety.Checker
+}
+
+// Option configures the PruneController.
+type Option func(*PruneController)
+
+// WithSafetyChecker sets the checker consulted before pruning the revisions of a node. The pruning of a node is delayed
+// while the checker deems it unsafe for the operand.
+func WithSafetyChecker(safetyChecker safety.Checker) Option {
+	return func(c *PruneController) {
+		c.safetyChecker = safetyChecker
+	}
 }
 
 const (
-	statusConfigMapName  = "revision-status-"
 	defaultRevisionLimit = int32(5)
+
+	// safetyRecheckInterval is how often a pruning vetoed by the safety checker is checked again
+	safetyRecheckInterval = 30 * time.Second
 )
 
 // NewPruneController creates a new pruning controller
@@ -58,6 +77,7 @@ func NewPruneController(
 	podGetter corev1client.PodsGetter,
 	operatorClient v1helpers.StaticPodOperatorClient,
 	eventRecorder events.Recorder,
+	opts ...Option,
 ) factory.Controller {
 	c := &PruneController{
 		targetNamespace:   targetNamespace,
@@ -73,6 +93,9 @@ func NewPruneController(
 		prunerPodImageFn: getPrunerPodImageFromEnv,
 	}
 	c.retrieveStatusConfigMapOwnerRefsFn = c.createStatusConfigMapOwnerRefs
+	for _, opt := range opts {
+		opt(c)
+	}
 
 	return factory.New().WithInformers(operatorClient.Informer()).WithSync(c.sync).ToController("PruneController", eventRecorder)
 }
@@ -146,15 +169,29 @@ func int32RangeBelowOrEqual(upper int32, num int) []int32 {
 	return ret
 }
 
-func (c *PruneController) pruneDiskResources(ctx context.Context, recorder events.Recorder, operatorStatus *operatorv1.StaticPodOperatorStatus, toKeep []int32) error {
+// pruneDiskResources prunes the revisions from the disk of the nodes, and returns whether the pruning of a node was
+// vetoed by the safety checker.
+func (c *PruneController) pruneDiskResources(ctx context.Context, recorder events.Recorder, operatorStatus *operatorv1.StaticPodOperatorStatus, toKeep []int32) (bool, error) {
+	vetoed := false
 	// Run pruning pod on each node and pin it to that node
 	for _, nodeStatus := range operatorStatus.NodeStatuses {
+		if c.safetyChecker != nil {
+			safe, reason, err := c.safetyChecker.IsSafe(ctx, safety.OperationPrune, nodeStatus.NodeName)
+			if err != nil {
+				return vetoed, fmt.Errorf("failed to check whether pruning node %q is safe: %w", nodeStatus.NodeName, err)
+			}
+			if !safe {
+				klog.Infof("Delaying the pruning of node %q because %s", nodeStatus.NodeName, reason)
+				vetoed = true
+				continue
+			}
+		}
 		// note: we attach the pod (via owner-ref) to the latestAvailable
 		if err := c.ensurePrunePod(ctx, recorder, nodeStatus.NodeName, operatorStatus.LatestAvailableRevision, toKeep, operatorStatus.LatestAvailableRevision); err != nil {
-			return err
+			return vetoed, err
 		}
 	}
-	return nil
+	return vetoed, nil
 }
 
 func (c *PruneController) pruneAPIResources(ctx context.Context, toKeep sets.Int32, latestAvailableRevision int32) error {
@@ -163,7 +200,7 @@ func (c *PruneController) pruneAPIResources(ctx context.Context, toKeep sets.Int
 		return err
 	}
 	for _, cm := range statusConfigMaps.Items {
-		if !strings.HasPrefix(cm.Name, statusConfigMapName) {
+		if !revisioncontroller.IsStatusConfigMapName(cm.Name) {
 			continue
 		}
 
@@ -219,7 +256,7 @@ func (c *PruneController) ensurePrunePod(ctx context.Context, recorder events.Re
 }
 
 func (c *PruneController) createStatusConfigMapOwnerRefs(ctx context.Context, revision int32) ([]metav1.OwnerReference, error) {
-	statusConfigMap, err := c.configMapGetter.ConfigMaps(c.targetNamespace).Get(ctx, fmt.Sprintf("revision-status-%d", revision), metav1.GetOptions{})
+	statusConfigMap, err := c.configMapGetter.ConfigMaps(c.targetNamespace).Get(ctx, revisioncontroller.StatusConfigMapName(revision), metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -271,9 +308,13 @@ func (c *PruneController) sync(ctx context.Context, syncCtx factory.SyncContext)
 	}
 
 	errs := []error{}
-	if diskErr := c.pruneDiskResources(ctx, syncCtx.Recorder(), operatorStatus, toKeep.List()); diskErr != nil {
+	vetoed, diskErr := c.pruneDiskResources(ctx, syncCtx.Recorder(), operatorStatus, toKeep.List())
+	if diskErr != nil {
 		errs = append(errs, diskErr)
 	}
+	if vetoed {
+		syncCtx.Queue().AddAfter(syncCtx.QueueKey(), safetyRecheckInterval)
+	}
 	if apiErr := c.pruneAPIResources(ctx, toKeep, operatorStatus.LatestAvailableRevision); apiErr != nil {
 		errs = append(errs, apiErr)
 	}
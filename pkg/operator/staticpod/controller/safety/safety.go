@@ -0,0 +1,140 @@
+// Package safety lets the operands of the static pod controllers veto the disruptive actions on their nodes which
+// would break their own consistency requirements, like the quorum of a consensus service or the replicas of a
+// database. The installer controller consults a Checker before rolling a new revision out to a node, and the prune
+// controller before pruning the revisions of a node.
+package safety
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// Operation is a disruptive action of the static pod controllers on a node.
+type Operation string
+
+const (
+	// OperationInstall rolls a new revision out to the node, which restarts the operand of the node.
+	OperationInstall Operation = "Install"
+	// OperationPrune deletes the old revisions from the disk of the node, which are not available for a fallback
+	// anymore.
+	OperationPrune Operation = "Prune"
+)
+
+// Checker decides whether a disruptive operation on a node is safe for the operand.
+type Checker interface {
+	// IsSafe returns whether operation can be performed on nodeName now, and the reason when it cannot.
+	IsSafe(ctx context.Context, operation Operation, nodeName string) (safe bool, reason string, err error)
+}
+
+// CheckerFunc is a Checker function.
+type CheckerFunc func(ctx context.Context, operation Operation, nodeName string) (bool, string, error)
+
+// IsSafe calls f.
+func (f CheckerFunc) IsSafe(ctx context.Context, operation Operation, nodeName string) (bool, string, error) {
+	return f(ctx, operation, nodeName)
+}
+
+// All returns a Checker for which an operation is safe when it is safe for all the checkers.
+func All(checkers ...Checker) Checker {
+	return CheckerFunc(func(ctx context.Context, operation Operation, nodeName string) (bool, string, error) {
+		var reasons []string
+		for _, checker := range checkers {
+			safe, reason, err := checker.IsSafe(ctx, operation, nodeName)
+			if err != nil {
+				return false, "", err
+			}
+			if !safe {
+				reasons = append(reasons, reason)
+			}
+		}
+		return len(reasons) == 0, strings.Join(reasons, "; "), nil
+	})
+}
+
+// quorumChecker vetoes the installations which would break the quorum of the operand members, one per node.
+type quorumChecker struct {
+	operatorClient v1helpers.StaticPodOperatorClient
+	podLister      corev1listers.PodLister
+	namespace      string
+	staticPodName  string
+}
+
+// NewQuorumChecker returns a Checker which vetoes the installations on a node while restarting its member would
+// compound the failure of another member and break the quorum of the operand, like an etcd cluster. The operand has
+// a member on each node of the operator status, a member is healthy when the mirror pod of its static pod is ready.
+//
+// An installation is safe when it does not reduce the healthy members: when the member of the node is already
+// unhealthy, e.g. to let a broken member be fixed by a new revision while another member is down. Otherwise it is safe
+// when all the other members are healthy, which lets the single and two member operands roll out, or when the other
+// healthy members form a quorum. The pruning does not restart the operand, it is always safe.
+func NewQuorumChecker(operatorClient v1helpers.StaticPodOperatorClient, podLister corev1listers.PodLister, namespace, staticPodName string) Checker {
+	return &quorumChecker{
+		operatorClient: operatorClient,
+		podLister:      podLister,
+		namespace:      namespace,
+		staticPodName:  staticPodName,
+	}
+}
+
+func (c *quorumChecker) IsSafe(_ context.Context, operation Operation, nodeName string) (bool, string, error) {
+	if operation != OperationInstall {
+		return true, "", nil
+	}
+	_, status, _, err := c.operatorClient.GetStaticPodOperatorState()
+	if err != nil {
+		return false, "", err
+	}
+
+	members := len(status.NodeStatuses)
+	var otherMembers int
+	var unhealthy []string
+	for _, nodeStatus := range status.NodeStatuses {
+		healthy, err := c.isHealthy(nodeStatus)
+		if err != nil {
+			return false, "", err
+		}
+		if nodeStatus.NodeName == nodeName {
+			if !healthy {
+				// restarting an unhealthy member leaves the healthy members as they are
+				return true, "", nil
+			}
+			continue
+		}
+		otherMembers++
+		if !healthy {
+			unhealthy = append(unhealthy, nodeStatus.NodeName)
+		}
+	}
+
+	healthyOthers := otherMembers - len(unhealthy)
+	quorum := members/2 + 1
+	if len(unhealthy) == 0 || healthyOthers >= quorum {
+		return true, "", nil
+	}
+	return false, fmt.Sprintf("restarting the member of node %s would leave %d healthy members of %d, below the quorum of %d, while the members of nodes %s are unhealthy",
+		nodeName, healthyOthers, members, quorum, strings.Join(unhealthy, ", ")), nil
+}
+
+func (c *quorumChecker) isHealthy(nodeStatus operatorv1.NodeStatus) (bool, error) {
+	pod, err := c.podLister.Pods(c.namespace).Get(c.staticPodName + "-" + nodeStatus.NodeName)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,148 @@
+package safety
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func TestQuorumChecker(t *testing.T) {
+	testCases := []struct {
+		name      string
+		operation Operation
+		// members maps the nodes to the readiness of their member, a missing member is represented by nil
+		members      map[string]*bool
+		expectedSafe bool
+	}{
+		{
+			name:         "all members healthy",
+			operation:    OperationInstall,
+			members:      map[string]*bool{"node-a": readiness(true), "node-b": readiness(true), "node-c": readiness(true)},
+			expectedSafe: true,
+		},
+		{
+			name:      "another member unhealthy",
+			operation: OperationInstall,
+			members:   map[string]*bool{"node-a": readiness(true), "node-b": readiness(false), "node-c": readiness(true)},
+		},
+		{
+			name:      "another member missing",
+			operation: OperationInstall,
+			members:   map[string]*bool{"node-a": readiness(true), "node-b": nil, "node-c": readiness(true)},
+		},
+		{
+			name:         "the member of the node unhealthy",
+			operation:    OperationInstall,
+			members:      map[string]*bool{"node-a": readiness(false), "node-b": readiness(true), "node-c": readiness(true)},
+			expectedSafe: true,
+		},
+		{
+			name:         "the member of the node and another member unhealthy",
+			operation:    OperationInstall,
+			members:      map[string]*bool{"node-a": readiness(false), "node-b": readiness(false), "node-c": readiness(true)},
+			expectedSafe: true,
+		},
+		{
+			name:         "the member of the node missing and another member unhealthy",
+			operation:    OperationInstall,
+			members:      map[string]*bool{"node-a": nil, "node-b": readiness(false), "node-c": readiness(true)},
+			expectedSafe: true,
+		},
+		{
+			name:      "quorum kept with another member unhealthy",
+			operation: OperationInstall,
+			members: map[string]*bool{
+				"node-a": readiness(true), "node-b": readiness(false), "node-c": readiness(true), "node-d": readiness(true), "node-e": readiness(true),
+			},
+			expectedSafe: true,
+		},
+		{
+			name:         "single member",
+			operation:    OperationInstall,
+			members:      map[string]*bool{"node-a": readiness(true)},
+			expectedSafe: true,
+		},
+		{
+			name:         "pruning is not disruptive",
+			operation:    OperationPrune,
+			members:      map[string]*bool{"node-a": readiness(true), "node-b": readiness(false), "node-c": readiness(true)},
+			expectedSafe: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			status := &operatorv1.StaticPodOperatorStatus{}
+			for _, nodeName := range []string{"node-a", "node-b", "node-c", "node-d", "node-e"} {
+				ready, ok := tc.members[nodeName]
+				if !ok {
+					continue
+				}
+				status.NodeStatuses = append(status.NodeStatuses, operatorv1.NodeStatus{NodeName: nodeName})
+				if ready == nil {
+					continue
+				}
+				conditionStatus := corev1.ConditionFalse
+				if *ready {
+					conditionStatus = corev1.ConditionTrue
+				}
+				if err := indexer.Add(&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "operand", Name: fmt.Sprintf("operand-%s", nodeName)},
+					Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: conditionStatus}}},
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			operatorClient := v1helpers.NewFakeStaticPodOperatorClient(&operatorv1.StaticPodOperatorSpec{}, status, nil, nil)
+			checker := NewQuorumChecker(operatorClient, corev1listers.NewPodLister(indexer), "operand", "operand")
+
+			safe, reason, err := checker.IsSafe(context.TODO(), tc.operation, "node-a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if safe != tc.expectedSafe {
+				t.Errorf("expected safe %v, got %v: %s", tc.expectedSafe, safe, reason)
+			}
+			if !safe && len(reason) == 0 {
+				t.Errorf("expected a reason for the veto")
+			}
+		})
+	}
+}
+
+func TestAll(t *testing.T) {
+	checker := func(safe bool, reason string) Checker {
+		return CheckerFunc(func(context.Context, Operation, string) (bool, string, error) {
+			return safe, reason, nil
+		})
+	}
+
+	safe, reason, err := All(checker(true, ""), checker(false, "first"), checker(false, "second")).IsSafe(context.TODO(), OperationInstall, "node-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if safe || reason != "first; second" {
+		t.Errorf("expected the vetoes of all the checkers, got %v %q", safe, reason)
+	}
+
+	safe, _, err = All(checker(true, ""), checker(true, "")).IsSafe(context.TODO(), OperationInstall, "node-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !safe {
+		t.Errorf("expected the operation to be safe for all the checkers")
+	}
+}
+
+func readiness(ready bool) *bool {
+	return &ready
+}
@@ -18,6 +18,7 @@ import (
 	missingstaticpodcontroller "github.com/openshift/library-go/pkg/operator/staticpod/controller/missingstaticpod"
 	"github.com/openshift/library-go/pkg/operator/staticpod/controller/node"
 	"github.com/openshift/library-go/pkg/operator/staticpod/controller/prune"
+	"github.com/openshift/library-go/pkg/operator/staticpod/controller/safety"
 	"github.com/openshift/library-go/pkg/operator/staticpod/controller/startupmonitorcondition"
 	"github.com/openshift/library-go/pkg/operator/staticpod/controller/staticpodfallback"
 	"github.com/openshift/library-go/pkg/operator/staticpod/controller/staticpodstate"
@@ -61,6 +62,8 @@ type staticPodOperatorControllerBuilder struct {
 	installerPodMutationFunc installer.InstallerPodMutationFunc
 	minReadyDuration         time.Duration
 	enableStartMonitor       func() (bool, error)
+	safetyChecker            safety.Checker
+	pinInstaller             bool
 
 	// pruning information
 	pruneCommand []string
@@ -99,6 +102,12 @@ type Builder interface {
 	WithInstaller(command []string) Builder
 	WithMinReadyDuration(minReadyDuration time.Duration) Builder
 	WithStartupMonitor(enabledStartupMonitor func() (bool, error)) Builder
+	// WithSafetyChecker sets the checker consulted by the installer and prune controllers before their disruptive
+	// actions on a node, e.g. safety.NewQuorumChecker for a consensus service.
+	WithSafetyChecker(safetyChecker safety.Checker) Builder
+	// WithInstallerPinnedPerRevision installs every revision with the installer image, command and arguments of the
+	// operator which created it, so that an operator upgrade does not change the installer of the in-flight installations.
+	WithInstallerPinnedPerRevision() Builder
 
 	// WithCustomInstaller allows mutating the installer pod definition just before
 	// the installer pod is created for a revision.
@@ -157,6 +166,16 @@ func (b *staticPodOperatorControllerBuilder) WithStartupMonitor(enabledStartupMo
 	return b
 }
 
+func (b *staticPodOperatorControllerBuilder) WithSafetyChecker(safetyChecker safety.Checker) Builder {
+	b.safetyChecker = safetyChecker
+	return b
+}
+
+func (b *staticPodOperatorControllerBuilder) WithInstallerPinnedPerRevision() Builder {
+	b.pinInstaller = true
+	return b
+}
+
 // WithCustomInstaller allows mutating the installer pod definition just before
 // the installer pod is created for a revision.
 func (b *staticPodOperatorControllerBuilder) WithCustomInstaller(command []string, installerPodMutationFunc installer.InstallerPodMutationFunc) Builder {
@@ -203,23 +222,9 @@ func (b *staticPodOperatorControllerBuilder) ToControllers() (manager.Controller
 
 	var errs []error
 
-	if len(b.operandNamespace) > 0 {
-		manager.WithController(revisioncontroller.NewRevisionController(
-			b.operandNamespace,
-			b.revisionConfigMaps,
-			b.revisionSecrets,
-			operandInformers,
-			revisioncontroller.StaticPodLatestRevisionClient{StaticPodOperatorClient: b.staticPodOperatorClient},
-			configMapClient,
-			secretClient,
-			eventRecorder,
-		), 1)
-	} else {
-		errs = append(errs, fmt.Errorf("missing revisionController; cannot proceed"))
-	}
-
+	var installerController *installer.InstallerController
 	if len(b.installCommand) > 0 {
-		manager.WithController(installer.NewInstallerController(
+		installerController = installer.NewInstallerController(
 			b.operandNamespace,
 			b.staticPodName,
 			b.revisionConfigMaps,
@@ -239,7 +244,36 @@ func (b *staticPodOperatorControllerBuilder) ToControllers() (manager.Controller
 			b.installerPodMutationFunc,
 		).WithMinReadyDuration(
 			b.minReadyDuration,
+		).WithSafetyChecker(
+			b.safetyChecker,
+		)
+		if b.pinInstaller {
+			installerController = installerController.WithInstallerPinnedPerRevision()
+		}
+	}
+
+	var revisionOptions []revisioncontroller.Option
+	if installerController != nil && b.pinInstaller {
+		revisionOptions = append(revisionOptions, revisioncontroller.WithStatusData(installerController.InstallerPin))
+	}
+	if len(b.operandNamespace) > 0 {
+		manager.WithController(revisioncontroller.NewRevisionController(
+			b.operandNamespace,
+			b.revisionConfigMaps,
+			b.revisionSecrets,
+			operandInformers,
+			revisioncontroller.StaticPodLatestRevisionClient{StaticPodOperatorClient: b.staticPodOperatorClient},
+			configMapClient,
+			secretClient,
+			eventRecorder,
+			revisionOptions...,
 		), 1)
+	} else {
+		errs = append(errs, fmt.Errorf("missing revisionController; cannot proceed"))
+	}
+
+	if installerController != nil {
+		manager.WithController(installerController, 1)
 
 		manager.WithController(installerstate.NewInstallerStateController(
 			operandInformers,
@@ -281,6 +315,7 @@ func (b *staticPodOperatorControllerBuilder) ToControllers() (manager.Controller
 			podClient,
 			b.staticPodOperatorClient,
 			eventRecorder,
+			prune.WithSafetyChecker(b.safetyChecker),
 		), 1)
 	} else {
 		eventRecorder.Warning("PruningControllerMissing", "not enough information provided, not all functionality is present")
@@ -31,6 +31,7 @@ import (
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
 	"github.com/openshift/library-go/pkg/operator/resource/retry"
+	"github.com/openshift/library-go/pkg/operator/revisioncontroller/payload"
 	"github.com/openshift/library-go/pkg/operator/staticpod"
 	"github.com/openshift/library-go/pkg/operator/staticpod/internal"
 	"github.com/openshift/library-go/pkg/operator/staticpod/internal/flock"
@@ -245,6 +246,13 @@ func (o *InstallOptions) copySecretsAndConfigMaps(ctx context.Context, resourceD
 		}
 		// config is nil means the config was optional and we failed to get it.
 		if config != nil {
+			// the large configmaps of the revision are compressed and split by the revision controller
+			config, err = payload.Decode(config, func(name string) (*corev1.ConfigMap, error) {
+				return o.getConfigMapWithRetry(ctx, name, false)
+			})
+			if err != nil {
+				return err
+			}
 			configs = append(configs, o.substituteConfigMap(config))
 		}
 	}
@@ -330,7 +338,7 @@ func (o *InstallOptions) copyContent(ctx context.Context) error {
 
 	err := retry.RetryOnConnectionErrors(ctx, func(ctx context.Context) (bool, error) {
 		klog.Infof("Getting pod configmaps/%s -n %s", o.nameFor(o.PodConfigMapNamePrefix), o.Namespace)
-		podConfigMap, err := o.KubeClient.CoreV1().ConfigMaps(o.Namespace).Get(ctx, o.nameFor(o.PodConfigMapNamePrefix), metav1.GetOptions{})
+		podConfigMap, err := payload.Get(ctx, o.KubeClient.CoreV1(), o.Namespace, o.nameFor(o.PodConfigMapNamePrefix))
 		if err != nil {
 			return false, err
 		}
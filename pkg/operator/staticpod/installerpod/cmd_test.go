@@ -13,9 +13,11 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+	"github.com/openshift/library-go/pkg/operator/revisioncontroller/payload"
 )
 
 const podYaml = `
@@ -106,6 +108,47 @@ func TestCopyContent(t *testing.T) {
 				checkFileContentMatchesPod(t, path.Join(podDir, "kube-apiserver-pod.yaml"), podYaml)
 			},
 		},
+		{
+			name: "compressed-configmap",
+			o: InstallOptions{
+				Revision:               "006",
+				Namespace:              "some-ns",
+				PodConfigMapNamePrefix: "kube-apiserver-pod",
+				ConfigMapNamePrefixes:  []string{"alpha"},
+			},
+			client: func() *fake.Clientset {
+				parts, err := payload.Encode(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "some-ns", Name: "alpha-006"},
+					Data: map[string]string{
+						"apple-A.crt":  strings.Repeat("apple", 200*1024),
+						"banana-A.crt": "banana",
+					},
+				})
+				if err != nil {
+					panic(err)
+				}
+				podParts, err := payload.Encode(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "some-ns", Name: "kube-apiserver-pod-006"},
+					Data: map[string]string{
+						"pod.yaml": podYaml,
+						"padding":  strings.Repeat("pod", 200*1024),
+					},
+				})
+				if err != nil {
+					panic(err)
+				}
+				var objects []runtime.Object
+				for _, part := range append(parts, podParts...) {
+					objects = append(objects, part)
+				}
+				return fake.NewSimpleClientset(objects...)
+			},
+			expected: func(t *testing.T, resourceDir, podDir string) {
+				checkFileContent(t, path.Join(resourceDir, "kube-apiserver-pod-006", "configmaps", "alpha", "apple-A.crt"), strings.Repeat("apple", 200*1024))
+				checkFileContent(t, path.Join(resourceDir, "kube-apiserver-pod-006", "configmaps", "alpha", "banana-A.crt"), "banana")
+				checkFileContentMatchesPod(t, path.Join(podDir, "kube-apiserver-pod.yaml"), podYaml)
+			},
+		},
 		{
 			name: "optional-secrets-confmaps",
 			o: InstallOptions{
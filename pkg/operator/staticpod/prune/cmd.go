@@ -16,6 +16,7 @@ import (
 	"k8s.io/klog/v2"
 
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
 )
 
 type PruneOptions struct {
@@ -25,10 +26,15 @@ type PruneOptions struct {
 	ResourceDir   string
 	CertDir       string
 	StaticPodName string
+
+	// clock tells the age of the temporary certificate files.
+	clock clock.PassiveClock
 }
 
 func NewPruneOptions() *PruneOptions {
-	return &PruneOptions{}
+	return &PruneOptions{
+		clock: clock.RealClock{},
+	}
 }
 
 func NewPrune() *cobra.Command {
@@ -135,6 +141,7 @@ func (o *PruneOptions) Run() error {
 		return nil
 	}
 
+	now := o.clock.Now()
 	return filepath.Walk(path.Join(o.ResourceDir, o.CertDir),
 		func(filePath string, info os.FileInfo, err error) error {
 			if err != nil {
@@ -147,7 +154,7 @@ func (o *PruneOptions) Run() error {
 			if !strings.Contains(info.Name(), ".tmp") {
 				return nil
 			}
-			if time.Now().Sub(info.ModTime()) > 30*time.Minute {
+			if now.Sub(info.ModTime()) > 30*time.Minute {
 				klog.Infof("Removing %s, the last time it was modified was %v", filePath, info.ModTime())
 				if err := os.RemoveAll(filePath); err != nil {
 					return err
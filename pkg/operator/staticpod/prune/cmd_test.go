@@ -7,7 +7,9 @@ import (
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 
+	clocktesting "k8s.io/utils/clock/testing"
 	"vbom.ml/util/sortorder"
 )
 
@@ -84,6 +86,36 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func TestRunTemporaryCertFiles(t *testing.T) {
+	resourceDir := t.TempDir()
+	certDir := path.Join(resourceDir, "kube-apiserver-certs", "secrets", "serving-cert")
+	if err := os.MkdirAll(certDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"tls.key", "tls.key.tmp643092404"} {
+		if err := ioutil.WriteFile(path.Join(certDir, file), []byte("key"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	o := PruneOptions{
+		ResourceDir:   resourceDir,
+		CertDir:       "kube-apiserver-certs",
+		StaticPodName: "test",
+		clock:         clocktesting.NewFakePassiveClock(time.Now().Add(10 * time.Minute)),
+	}
+	if err := o.Run(); err != nil {
+		t.Fatal(err)
+	}
+	checkPruned(t, certDir, []string{"tls.key", "tls.key.tmp643092404"})
+
+	o.clock = clocktesting.NewFakePassiveClock(time.Now().Add(time.Hour))
+	if err := o.Run(); err != nil {
+		t.Fatal(err)
+	}
+	checkPruned(t, certDir, []string{"tls.key"})
+}
+
 func checkPruned(t *testing.T, resourceDir string, expected []string) {
 	files, err := ioutil.ReadDir(resourceDir)
 	if err != nil {
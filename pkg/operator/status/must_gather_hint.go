@@ -0,0 +1,86 @@
+package status
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	configv1helpers "github.com/openshift/library-go/pkg/config/clusteroperator/v1helpers"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// MustGatherHintReason is the reason of the event listing the data to collect for a support case about a degraded
+// operator.
+const MustGatherHintReason = "MustGatherHint"
+
+// mustGatherHint emits a single event per degraded episode of the ClusterOperator once it has been degraded for longer
+// than the threshold, with the `oc adm inspect` commands collecting its related objects. The episodes hinted at are
+// tracked in memory, the hint of the current episode is emitted again after a restart of the operator.
+type mustGatherHint struct {
+	threshold time.Duration
+
+	lock sync.Mutex
+	// hintedSince is the transition time of the degraded episode hinted at.
+	hintedSince metav1.Time
+}
+
+// hint emits the event when the ClusterOperator has been degraded for longer than the threshold at now, and the
+// episode was not hinted at yet.
+func (h *mustGatherHint) hint(clusterOperator *configv1.ClusterOperator, now time.Time, recorder events.Recorder) {
+	degraded := configv1helpers.FindStatusCondition(clusterOperator.Status.Conditions, configv1.OperatorDegraded)
+	if degraded == nil || degraded.Status != configv1.ConditionTrue {
+		return
+	}
+	if now.Sub(degraded.LastTransitionTime.Time) < h.threshold {
+		return
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.hintedSince.Equal(&degraded.LastTransitionTime) {
+		return
+	}
+	h.hintedSince = degraded.LastTransitionTime
+
+	recorder.Warningf(MustGatherHintReason, "clusteroperator/%s has been Degraded since %s (%s): %s\nCollect the data for a support case with:\n%s",
+		clusterOperator.Name, degraded.LastTransitionTime.UTC().Format(time.RFC3339), degraded.Reason, degraded.Message,
+		strings.Join(inspectCommands(clusterOperator.Name, clusterOperator.Status.RelatedObjects), "\n"))
+}
+
+// inspectCommands returns the `oc adm inspect` commands collecting the ClusterOperator and its related objects, one
+// for the cluster-scoped objects and one per namespace of the namespaced ones.
+func inspectCommands(clusterOperatorName string, relatedObjects []configv1.ObjectReference) []string {
+	clusterScoped := []string{"clusteroperator/" + clusterOperatorName}
+	namespaced := map[string][]string{}
+	for _, obj := range relatedObjects {
+		resource := obj.Resource
+		if len(obj.Group) > 0 {
+			resource += "." + obj.Group
+		}
+		target := resource
+		if len(obj.Name) > 0 {
+			target += "/" + obj.Name
+		}
+		if len(obj.Namespace) == 0 {
+			clusterScoped = append(clusterScoped, target)
+			continue
+		}
+		namespaced[obj.Namespace] = append(namespaced[obj.Namespace], target)
+	}
+
+	commands := []string{"oc adm inspect " + strings.Join(clusterScoped, " ")}
+	namespaces := make([]string, 0, len(namespaced))
+	for namespace := range namespaced {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	for _, namespace := range namespaces {
+		commands = append(commands, fmt.Sprintf("oc adm inspect -n %s %s", namespace, strings.Join(namespaced[namespace], " ")))
+	}
+	return commands
+}
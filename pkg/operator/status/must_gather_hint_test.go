@@ -0,0 +1,87 @@
+package status
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestInspectCommands(t *testing.T) {
+	actual := inspectCommands("kube-apiserver", []configv1.ObjectReference{
+		{Group: "operator.openshift.io", Resource: "kubeapiservers", Name: "cluster"},
+		{Resource: "namespaces", Name: "openshift-kube-apiserver-operator"},
+		{Resource: "namespaces", Name: "openshift-kube-apiserver"},
+		{Group: "apps", Resource: "deployments", Namespace: "openshift-kube-apiserver-operator", Name: "kube-apiserver-operator"},
+		{Resource: "secrets", Namespace: "openshift-kube-apiserver"},
+		{Resource: "configmaps", Namespace: "openshift-kube-apiserver", Name: "config"},
+	})
+	expected := []string{
+		"oc adm inspect clusteroperator/kube-apiserver kubeapiservers.operator.openshift.io/cluster namespaces/openshift-kube-apiserver-operator namespaces/openshift-kube-apiserver",
+		"oc adm inspect -n openshift-kube-apiserver secrets configmaps/config",
+		"oc adm inspect -n openshift-kube-apiserver-operator deployments.apps/kube-apiserver-operator",
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestMustGatherHint(t *testing.T) {
+	since := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	clusterOperator := func(status configv1.ConditionStatus, since time.Time) *configv1.ClusterOperator {
+		return &configv1.ClusterOperator{
+			ObjectMeta: metav1.ObjectMeta{Name: "OPERATOR_NAME"},
+			Status: configv1.ClusterOperatorStatus{
+				Conditions: []configv1.ClusterOperatorStatusCondition{
+					{Type: configv1.OperatorDegraded, Status: status, LastTransitionTime: metav1.NewTime(since), Reason: "NodeInstaller_InstallerPodFailed", Message: "pod failed"},
+				},
+				RelatedObjects: []configv1.ObjectReference{{Resource: "namespaces", Name: "openshift-operator"}},
+			},
+		}
+	}
+
+	recorder := events.NewInMemoryRecorder("status")
+	hint := &mustGatherHint{threshold: 10 * time.Minute}
+	hintEvents := func() int {
+		count := 0
+		for _, event := range recorder.Events() {
+			if event.Reason == MustGatherHintReason {
+				count++
+			}
+		}
+		return count
+	}
+
+	hint.hint(clusterOperator(configv1.ConditionFalse, since), since.Add(time.Hour), recorder)
+	if count := hintEvents(); count != 0 {
+		t.Fatalf("expected no hint when not degraded, got %d", count)
+	}
+
+	hint.hint(clusterOperator(configv1.ConditionTrue, since), since.Add(5*time.Minute), recorder)
+	if count := hintEvents(); count != 0 {
+		t.Fatalf("expected no hint before the threshold, got %d", count)
+	}
+
+	hint.hint(clusterOperator(configv1.ConditionTrue, since), since.Add(15*time.Minute), recorder)
+	hint.hint(clusterOperator(configv1.ConditionTrue, since), since.Add(20*time.Minute), recorder)
+	if count := hintEvents(); count != 1 {
+		t.Fatalf("expected a single hint for the degraded episode, got %d", count)
+	}
+	message := recorder.Events()[0].Message
+	for _, expected := range []string{"clusteroperator/OPERATOR_NAME has been Degraded since 2023-06-01T00:00:00Z", "oc adm inspect clusteroperator/OPERATOR_NAME namespaces/openshift-operator"} {
+		if !strings.Contains(message, expected) {
+			t.Errorf("expected the hint to contain %q, got %q", expected, message)
+		}
+	}
+
+	// a new episode is hinted again
+	hint.hint(clusterOperator(configv1.ConditionTrue, since.Add(time.Hour)), since.Add(2*time.Hour), recorder)
+	if count := hintEvents(); count != 2 {
+		t.Fatalf("expected a hint for the new degraded episode, got %d", count)
+	}
+}
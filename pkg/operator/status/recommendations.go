@@ -0,0 +1,124 @@
+package status
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+// RecommendationsAnnotation is set on the ClusterOperator to the JSON list of the recommendations for the operator
+// conditions which are not as expected, so that the console and Insights can point to the documented remediation without
+// parsing the condition messages.
+const RecommendationsAnnotation = "operator.openshift.io/recommendations"
+
+// Severity is how urgently a recommendation should be acted upon.
+type Severity string
+
+const (
+	SeverityInfo     Severity = "Info"
+	SeverityWarning  Severity = "Warning"
+	SeverityCritical Severity = "Critical"
+)
+
+// severityOrder sorts the published recommendations, the most severe first.
+var severityOrder = map[Severity]int{SeverityCritical: 0, SeverityWarning: 1, SeverityInfo: 2}
+
+// Recommendation is a machine readable remediation hint for an operator condition with a reason.
+type Recommendation struct {
+	// ConditionType is the type of the operator condition, e.g. "NodeInstallerDegraded".
+	ConditionType string `json:"conditionType"`
+	// Reason is the reason of the condition. An empty reason matches the conditions with a reason without a recommendation
+	// of its own.
+	Reason string `json:"reason,omitempty"`
+	// Severity is how urgently the recommendation should be acted upon.
+	Severity Severity `json:"severity"`
+	// URL points to the documented action.
+	URL string `json:"url"`
+}
+
+type recommendationKey struct {
+	conditionType string
+	reason        string
+}
+
+// Recommendations are the recommendations the controllers of an operator register for the reasons of their conditions,
+// published by the status controller for the conditions which are not as expected. It is safe for concurrent use.
+type Recommendations struct {
+	lock            sync.RWMutex
+	recommendations map[recommendationKey]Recommendation
+}
+
+// NewRecommendations returns an empty set of recommendations.
+func NewRecommendations() *Recommendations {
+	return &Recommendations{recommendations: map[recommendationKey]Recommendation{}}
+}
+
+// Add registers the recommendation for its condition type and reason, replacing the one registered before.
+func (r *Recommendations) Add(recommendations ...Recommendation) *Recommendations {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, recommendation := range recommendations {
+		r.recommendations[recommendationKey{conditionType: recommendation.ConditionType, reason: recommendation.Reason}] = recommendation
+	}
+	return r
+}
+
+// For returns the recommendations for the conditions which are not as expected, i.e. *Degraded or *Progressing conditions
+// which are true and *Available or *Upgradeable conditions which are false, the most severe first.
+func (r *Recommendations) For(conditions []operatorv1.OperatorCondition) []Recommendation {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var ret []Recommendation
+	for _, condition := range conditions {
+		if !unexpectedCondition(condition) {
+			continue
+		}
+		recommendation, ok := r.recommendations[recommendationKey{conditionType: condition.Type, reason: condition.Reason}]
+		if !ok {
+			recommendation, ok = r.recommendations[recommendationKey{conditionType: condition.Type}]
+		}
+		if !ok {
+			continue
+		}
+		recommendation.Reason = condition.Reason
+		ret = append(ret, recommendation)
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if severityOrder[ret[i].Severity] != severityOrder[ret[j].Severity] {
+			return severityOrder[ret[i].Severity] < severityOrder[ret[j].Severity]
+		}
+		return ret[i].ConditionType < ret[j].ConditionType
+	})
+	return ret
+}
+
+func unexpectedCondition(condition operatorv1.OperatorCondition) bool {
+	switch {
+	case strings.HasSuffix(condition.Type, "Degraded"), strings.HasSuffix(condition.Type, "Progressing"):
+		return condition.Status == operatorv1.ConditionTrue
+	case strings.HasSuffix(condition.Type, "Available"), strings.HasSuffix(condition.Type, "Upgradeable"):
+		return condition.Status == operatorv1.ConditionFalse
+	}
+	return false
+}
+
+// setRecommendationsAnnotation sets the annotation to the recommendations, or removes it when there are none.
+func setRecommendationsAnnotation(annotations *map[string]string, recommendations []Recommendation) error {
+	if len(recommendations) == 0 {
+		delete(*annotations, RecommendationsAnnotation)
+		return nil
+	}
+	value, err := json.Marshal(recommendations)
+	if err != nil {
+		return err
+	}
+	if *annotations == nil {
+		*annotations = map[string]string{}
+	}
+	(*annotations)[RecommendationsAnnotation] = string(value)
+	return nil
+}
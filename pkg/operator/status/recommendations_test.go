@@ -0,0 +1,124 @@
+package status
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/client-go/config/clientset/versioned/fake"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestRecommendationsFor(t *testing.T) {
+	recommendations := NewRecommendations().Add(
+		Recommendation{ConditionType: "NodeInstallerDegraded", Reason: "InstallerPodFailed", Severity: SeverityCritical, URL: "https://docs.example.com/installer-failed"},
+		Recommendation{ConditionType: "NodeInstallerDegraded", Severity: SeverityWarning, URL: "https://docs.example.com/installer"},
+		Recommendation{ConditionType: "StaticPodsAvailable", Severity: SeverityCritical, URL: "https://docs.example.com/static-pods"},
+		Recommendation{ConditionType: "CertRotationUpgradeable", Reason: "ExpiringSoon", Severity: SeverityInfo, URL: "https://docs.example.com/cert-rotation"},
+	)
+
+	testCases := []struct {
+		name       string
+		conditions []operatorv1.OperatorCondition
+		expected   []Recommendation
+	}{
+		{
+			name: "conditions as expected",
+			conditions: []operatorv1.OperatorCondition{
+				{Type: "NodeInstallerDegraded", Status: operatorv1.ConditionFalse, Reason: "InstallerPodFailed"},
+				{Type: "StaticPodsAvailable", Status: operatorv1.ConditionTrue},
+			},
+		},
+		{
+			name: "reason specific recommendation",
+			conditions: []operatorv1.OperatorCondition{
+				{Type: "NodeInstallerDegraded", Status: operatorv1.ConditionTrue, Reason: "InstallerPodFailed"},
+			},
+			expected: []Recommendation{
+				{ConditionType: "NodeInstallerDegraded", Reason: "InstallerPodFailed", Severity: SeverityCritical, URL: "https://docs.example.com/installer-failed"},
+			},
+		},
+		{
+			name: "recommendation for any reason",
+			conditions: []operatorv1.OperatorCondition{
+				{Type: "NodeInstallerDegraded", Status: operatorv1.ConditionTrue, Reason: "InstallerPodNetworking"},
+			},
+			expected: []Recommendation{
+				{ConditionType: "NodeInstallerDegraded", Reason: "InstallerPodNetworking", Severity: SeverityWarning, URL: "https://docs.example.com/installer"},
+			},
+		},
+		{
+			name: "most severe first",
+			conditions: []operatorv1.OperatorCondition{
+				{Type: "CertRotationUpgradeable", Status: operatorv1.ConditionFalse, Reason: "ExpiringSoon"},
+				{Type: "NodeInstallerDegraded", Status: operatorv1.ConditionTrue, Reason: "InstallerPodNetworking"},
+				{Type: "StaticPodsAvailable", Status: operatorv1.ConditionFalse, Reason: "ZeroNodesActive"},
+				{Type: "UnknownDegraded", Status: operatorv1.ConditionTrue, Reason: "Failed"},
+			},
+			expected: []Recommendation{
+				{ConditionType: "StaticPodsAvailable", Reason: "ZeroNodesActive", Severity: SeverityCritical, URL: "https://docs.example.com/static-pods"},
+				{ConditionType: "NodeInstallerDegraded", Reason: "InstallerPodNetworking", Severity: SeverityWarning, URL: "https://docs.example.com/installer"},
+				{ConditionType: "CertRotationUpgradeable", Reason: "ExpiringSoon", Severity: SeverityInfo, URL: "https://docs.example.com/cert-rotation"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := recommendations.For(tc.conditions); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected recommendations %#v, got %#v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRecommendationsAnnotation(t *testing.T) {
+	clusterOperator := &configv1.ClusterOperator{ObjectMeta: metav1.ObjectMeta{Name: "OPERATOR_NAME", ResourceVersion: "12"}}
+	clusterOperatorClient := fake.NewSimpleClientset(clusterOperator)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	statusClient := &statusClient{t: t}
+	controller := (&StatusSyncer{
+		clusterOperatorName:   "OPERATOR_NAME",
+		clusterOperatorClient: clusterOperatorClient.ConfigV1(),
+		clusterOperatorLister: configv1listers.NewClusterOperatorLister(indexer),
+		operatorClient:        statusClient,
+		versionGetter:         NewVersionGetter(),
+	}).WithRecommendations(NewRecommendations().Add(
+		Recommendation{ConditionType: "NodeInstallerDegraded", Severity: SeverityCritical, URL: "https://docs.example.com/installer"},
+	))
+
+	sync := func(conditions ...operatorv1.OperatorCondition) *configv1.ClusterOperator {
+		t.Helper()
+		existing, err := clusterOperatorClient.ConfigV1().ClusterOperators().Get(context.TODO(), "OPERATOR_NAME", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		indexer.Update(existing)
+		statusClient.status.Conditions = conditions
+		if err := controller.Sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("status"))); err != nil {
+			t.Fatalf("unexpected sync error: %v", err)
+		}
+		result, err := clusterOperatorClient.ConfigV1().ClusterOperators().Get(context.TODO(), "OPERATOR_NAME", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	result := sync(operatorv1.OperatorCondition{Type: "NodeInstallerDegraded", Status: operatorv1.ConditionTrue, Reason: "InstallerPodFailed", Message: "pod failed"})
+	expected := `[{"conditionType":"NodeInstallerDegraded","reason":"InstallerPodFailed","severity":"Critical","url":"https://docs.example.com/installer"}]`
+	if actual := result.Annotations[RecommendationsAnnotation]; actual != expected {
+		t.Errorf("expected annotation %s, got %s", expected, actual)
+	}
+
+	result = sync(operatorv1.OperatorCondition{Type: "NodeInstallerDegraded", Status: operatorv1.ConditionFalse})
+	if actual, ok := result.Annotations[RecommendationsAnnotation]; ok {
+		t.Errorf("expected the annotation to be removed, got %s", actual)
+	}
+}
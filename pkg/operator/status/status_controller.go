@@ -15,6 +15,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/utils/clock"
 
 	configv1helpers "github.com/openshift/library-go/pkg/config/clusteroperator/v1helpers"
 	"github.com/openshift/library-go/pkg/controller/factory"
@@ -50,6 +51,9 @@ type StatusSyncer struct {
 	degradedInertia   Inertia
 
 	removeUnusedVersions bool
+	recommendations      *Recommendations
+	mustGatherHint       *mustGatherHint
+	clock                clock.PassiveClock
 }
 
 var _ factory.Controller = &StatusSyncer{}
@@ -80,6 +84,7 @@ func NewClusterOperatorStatusController(
 			clusterOperatorInformer.Informer(),
 		),
 		recorder: recorder.WithComponentSuffix("status-controller"),
+		clock:    clock.RealClock{},
 	}
 }
 
@@ -118,6 +123,33 @@ func (c *StatusSyncer) WithVersionRemoval() *StatusSyncer {
 	return &output
 }
 
+// WithRecommendations returns a copy of the StatusSyncer that publishes the
+// recommendations for the operator conditions which are not as expected in
+// the RecommendationsAnnotation of the ClusterOperator.
+func (c *StatusSyncer) WithRecommendations(recommendations *Recommendations) *StatusSyncer {
+	output := *c
+	output.recommendations = recommendations
+	return &output
+}
+
+// WithMustGatherHint returns a copy of the StatusSyncer that emits a single
+// MustGatherHint event per degraded episode once the ClusterOperator has been
+// Degraded=True for longer than the threshold, listing the `oc adm inspect`
+// commands which collect its related objects for a support case.
+func (c *StatusSyncer) WithMustGatherHint(threshold time.Duration) *StatusSyncer {
+	output := *c
+	output.mustGatherHint = &mustGatherHint{threshold: threshold}
+	return &output
+}
+
+// WithClock returns a copy of the StatusSyncer that reads the time from the
+// clock, e.g. a fake clock in unit tests.
+func (c *StatusSyncer) WithClock(clock clock.PassiveClock) *StatusSyncer {
+	output := *c
+	output.clock = clock
+	return &output
+}
+
 // sync reacts to a change in prereqs by finding information that is required to match another value in the cluster. This
 // must be information that is logically "owned" by another component.
 func (c StatusSyncer) Sync(ctx context.Context, syncCtx factory.SyncContext) error {
@@ -206,12 +238,33 @@ func (c StatusSyncer) Sync(ctx context.Context, syncCtx factory.SyncContext) err
 
 	c.syncStatusVersions(clusterOperatorObj, syncCtx)
 
+	if c.recommendations != nil {
+		if err := setRecommendationsAnnotation(&clusterOperatorObj.Annotations, c.recommendations.For(currentDetailedStatus.Conditions)); err != nil {
+			return err
+		}
+	}
+
+	if c.mustGatherHint != nil {
+		c.mustGatherHint.hint(clusterOperatorObj, c.clock.Now(), syncCtx.Recorder())
+	}
+
 	// if we have no diff, just return
 	if equality.Semantic.DeepEqual(clusterOperatorObj, originalClusterOperatorObj) {
 		return nil
 	}
 	klog.V(2).Infof("clusteroperator/%s diff %v", c.clusterOperatorName, resourceapply.JSONPatchNoError(originalClusterOperatorObj, clusterOperatorObj))
 
+	// the annotations are not updated with the status
+	if !equality.Semantic.DeepEqual(clusterOperatorObj.Annotations, originalClusterOperatorObj.Annotations) {
+		updated, updateErr := c.clusterOperatorClient.ClusterOperators().Update(ctx, clusterOperatorObj, metav1.UpdateOptions{})
+		if updateErr != nil {
+			return updateErr
+		}
+		clusterOperatorObj.ResourceVersion = updated.ResourceVersion
+	}
+	if equality.Semantic.DeepEqual(clusterOperatorObj.Status, originalClusterOperatorObj.Status) {
+		return nil
+	}
 	if _, updateErr := c.clusterOperatorClient.ClusterOperators().UpdateStatus(ctx, clusterOperatorObj, metav1.UpdateOptions{}); updateErr != nil {
 		return updateErr
 	}
@@ -0,0 +1,189 @@
+package terminatingnamespacecontroller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/utils/clock"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/condition"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// blockingConditionTypes are the namespace conditions set by the namespace controller which explain why the deletion of a
+// namespace does not complete.
+var blockingConditionTypes = []corev1.NamespaceConditionType{
+	corev1.NamespaceContentRemaining,
+	corev1.NamespaceFinalizersRemaining,
+	corev1.NamespaceDeletionContentFailure,
+	corev1.NamespaceDeletionDiscoveryFailure,
+	corev1.NamespaceDeletionGVParsingFailure,
+}
+
+// StuckNamespace describes an operand namespace which has been terminating for too long.
+type StuckNamespace struct {
+	Namespace *corev1.Namespace
+	// Terminating is how long the namespace has been terminating.
+	Terminating time.Duration
+	// Finalizers are the finalizers of the namespace itself.
+	Finalizers []string
+	// Blocking are the messages of the namespace conditions naming the remaining resources, the finalizers remaining on
+	// them and the deletion failures.
+	Blocking []string
+}
+
+// RemediationFunc is called on every sync for each namespace stuck terminating, e.g. to remove the finalizers of operand
+// resources the operator knows are safe to drop. It must be idempotent.
+type RemediationFunc func(ctx context.Context, namespace StuckNamespace) error
+
+// TerminatingNamespaceController reports the operand namespaces stuck terminating in the
+// OperandNamespaceTerminationDegraded condition, naming what blocks their deletion, which otherwise only surfaces as
+// a rollout that never completes.
+type TerminatingNamespaceController struct {
+	namespaces      []string
+	threshold       time.Duration
+	namespaceLister corev1listers.NamespaceLister
+	operatorClient  v1helpers.OperatorClient
+	remediate       RemediationFunc
+
+	clock clock.PassiveClock
+}
+
+// NewTerminatingNamespaceController creates TerminatingNamespaceController for the given operand namespaces, which are
+// reported once they have been terminating for longer than the threshold. The remediation is opt-in: it is only
+// attempted when remediate is not nil.
+func NewTerminatingNamespaceController(
+	namespaces []string,
+	threshold time.Duration,
+	namespaceInformer corev1informers.NamespaceInformer,
+	operatorClient v1helpers.OperatorClient,
+	remediate RemediationFunc,
+	eventRecorder events.Recorder,
+) factory.Controller {
+	c := &TerminatingNamespaceController{
+		namespaces:      namespaces,
+		threshold:       threshold,
+		namespaceLister: namespaceInformer.Lister(),
+		operatorClient:  operatorClient,
+		remediate:       remediate,
+		clock:           clock.RealClock{},
+	}
+	return factory.New().
+		ResyncEvery(time.Minute).
+		WithSync(c.sync).
+		WithInformers(operatorClient.Informer(), namespaceInformer.Informer()).
+		ToController("TerminatingNamespaceController", eventRecorder)
+}
+
+func (c *TerminatingNamespaceController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	operatorSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	if !management.IsOperatorManaged(operatorSpec.ManagementState) {
+		return nil
+	}
+
+	stuck, err := c.stuckNamespaces()
+	if err != nil {
+		return err
+	}
+
+	cond := operatorv1.OperatorCondition{
+		Type:   condition.OperandNamespaceTerminationDegradedConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	var errs []error
+	if len(stuck) > 0 {
+		messages := make([]string, 0, len(stuck))
+		for _, namespace := range stuck {
+			messages = append(messages, namespace.String())
+		}
+		cond.Status = operatorv1.ConditionTrue
+		cond.Reason = "NamespaceTerminationStuck"
+		cond.Message = strings.Join(messages, "\n")
+
+		if c.remediate != nil {
+			for _, namespace := range stuck {
+				syncCtx.Recorder().Warningf("NamespaceTerminationRemediation", "Remediating namespace %q terminating for %v", namespace.Namespace.Name, namespace.Terminating)
+				if err := c.remediate(ctx, namespace); err != nil {
+					errs = append(errs, fmt.Errorf("failed to remediate namespace %q: %w", namespace.Namespace.Name, err))
+				}
+			}
+		}
+	}
+
+	if _, _, err := v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(cond)); err != nil {
+		errs = append(errs, err)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// stuckNamespaces returns the operand namespaces terminating for longer than the threshold.
+func (c *TerminatingNamespaceController) stuckNamespaces() ([]StuckNamespace, error) {
+	var stuck []StuckNamespace
+	for _, name := range c.namespaces {
+		namespace, err := c.namespaceLister.Get(name)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if namespace.DeletionTimestamp == nil {
+			continue
+		}
+		terminating := c.clock.Now().Sub(namespace.DeletionTimestamp.Time)
+		if terminating <= c.threshold {
+			continue
+		}
+
+		finalizers := make([]string, 0, len(namespace.Spec.Finalizers))
+		for _, finalizer := range namespace.Spec.Finalizers {
+			finalizers = append(finalizers, string(finalizer))
+		}
+		sort.Strings(finalizers)
+		var blocking []string
+		for _, conditionType := range blockingConditionTypes {
+			for _, namespaceCondition := range namespace.Status.Conditions {
+				if namespaceCondition.Type == conditionType && namespaceCondition.Status == corev1.ConditionTrue && len(namespaceCondition.Message) > 0 {
+					blocking = append(blocking, namespaceCondition.Message)
+				}
+			}
+		}
+		stuck = append(stuck, StuckNamespace{
+			Namespace:   namespace,
+			Terminating: terminating.Truncate(time.Second),
+			Finalizers:  finalizers,
+			Blocking:    blocking,
+		})
+	}
+	return stuck, nil
+}
+
+// String describes what blocks the deletion of the namespace. It tells since when the namespace is terminating rather
+// than for how long, so that the condition message does not change on every sync.
+func (n StuckNamespace) String() string {
+	message := fmt.Sprintf("namespace %q has been terminating since %s", n.Namespace.Name, n.Namespace.DeletionTimestamp.UTC().Format(time.RFC3339))
+	if len(n.Finalizers) > 0 {
+		message += fmt.Sprintf(", finalizers: %s", strings.Join(n.Finalizers, ", "))
+	}
+	if len(n.Blocking) > 0 {
+		message += ": " + strings.Join(n.Blocking, " ")
+	}
+	return message
+}
@@ -0,0 +1,131 @@
+package terminatingnamespacecontroller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/condition"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func TestTerminatingNamespaceController(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	namespace := func(name string, deleted time.Duration, finalizers []corev1.FinalizerName, conditions ...corev1.NamespaceCondition) *corev1.Namespace {
+		ns := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       corev1.NamespaceSpec{Finalizers: finalizers},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive, Conditions: conditions},
+		}
+		if deleted > 0 {
+			ns.DeletionTimestamp = &metav1.Time{Time: now.Add(-deleted)}
+			ns.Status.Phase = corev1.NamespaceTerminating
+		}
+		return ns
+	}
+
+	tests := []struct {
+		name               string
+		namespaces         []*corev1.Namespace
+		remediationErr     error
+		expectedStatus     operatorv1.ConditionStatus
+		expectedMessage    string
+		expectedRemediated []string
+		expectedErr        string
+	}{
+		{
+			name:           "active namespace",
+			namespaces:     []*corev1.Namespace{namespace("operand", 0, []corev1.FinalizerName{corev1.FinalizerKubernetes})},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name:           "missing namespace",
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name:           "terminating below the threshold",
+			namespaces:     []*corev1.Namespace{namespace("operand", 5*time.Minute, []corev1.FinalizerName{corev1.FinalizerKubernetes})},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name: "stuck terminating",
+			namespaces: []*corev1.Namespace{
+				namespace("operand", 20*time.Minute, []corev1.FinalizerName{corev1.FinalizerKubernetes},
+					corev1.NamespaceCondition{Type: corev1.NamespaceDeletionDiscoveryFailure, Status: corev1.ConditionFalse, Message: "All resources successfully discovered"},
+					corev1.NamespaceCondition{Type: corev1.NamespaceFinalizersRemaining, Status: corev1.ConditionTrue, Message: "Some content in the namespace has finalizers remaining: example.com/cleanup in 1 resource instances"},
+					corev1.NamespaceCondition{Type: corev1.NamespaceContentRemaining, Status: corev1.ConditionTrue, Message: "Some resources are remaining: widgets.example.com has 1 resource instances"},
+				),
+				namespace("other", 0, nil),
+			},
+			expectedStatus:     operatorv1.ConditionTrue,
+			expectedMessage:    `namespace "operand" has been terminating since 2023-06-01T11:40:00Z, finalizers: kubernetes: Some resources are remaining: widgets.example.com has 1 resource instances Some content in the namespace has finalizers remaining: example.com/cleanup in 1 resource instances`,
+			expectedRemediated: []string{"operand"},
+		},
+		{
+			name:               "remediation failure",
+			namespaces:         []*corev1.Namespace{namespace("operand", time.Hour, nil)},
+			remediationErr:     fmt.Errorf("forbidden"),
+			expectedStatus:     operatorv1.ConditionTrue,
+			expectedMessage:    `namespace "operand" has been terminating since 2023-06-01T11:00:00Z`,
+			expectedRemediated: []string{"operand"},
+			expectedErr:        `failed to remediate namespace "operand": forbidden`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, ns := range test.namespaces {
+				indexer.Add(ns)
+			}
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			var remediated []string
+			fakeClock := clocktesting.NewFakePassiveClock(now)
+			c := &TerminatingNamespaceController{
+				namespaces:      []string{"operand"},
+				threshold:       10 * time.Minute,
+				namespaceLister: corev1listers.NewNamespaceLister(indexer),
+				operatorClient:  operatorClient,
+				remediate: func(_ context.Context, namespace StuckNamespace) error {
+					remediated = append(remediated, namespace.Namespace.Name)
+					return test.remediationErr
+				},
+				clock: fakeClock,
+			}
+
+			err := c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test")))
+			if (err == nil) != (len(test.expectedErr) == 0) || (err != nil && err.Error() != test.expectedErr) {
+				t.Fatalf("expected error %q, got %v", test.expectedErr, err)
+			}
+			_, status, _, _ := operatorClient.GetOperatorState()
+			cond := v1helpers.FindOperatorCondition(status.Conditions, condition.OperandNamespaceTerminationDegradedConditionType)
+			if cond == nil {
+				t.Fatalf("expected the %s condition to be set", condition.OperandNamespaceTerminationDegradedConditionType)
+			}
+			if cond.Status != test.expectedStatus || cond.Message != test.expectedMessage {
+				t.Errorf("expected status %s with message %q, got %s with message %q", test.expectedStatus, test.expectedMessage, cond.Status, cond.Message)
+			}
+			if fmt.Sprint(remediated) != fmt.Sprint(test.expectedRemediated) {
+				t.Errorf("expected remediated namespaces %v, got %v", test.expectedRemediated, remediated)
+			}
+
+			// the condition does not change while the namespace keeps terminating
+			fakeClock.SetTime(now.Add(time.Minute))
+			_, _, resourceVersion, _ := operatorClient.GetOperatorState()
+			_ = c.sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test")))
+			if _, _, updatedResourceVersion, _ := operatorClient.GetOperatorState(); updatedResourceVersion != resourceVersion {
+				t.Errorf("expected the status not to be updated a minute later")
+			}
+		})
+	}
+}
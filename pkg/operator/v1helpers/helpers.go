@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -423,6 +424,79 @@ func InjectTrustedCAIntoContainers(podSpec *corev1.PodSpec, configMapName string
 	return nil
 }
 
+// SetContainerVerbosityFlags sets the verbosity flag of the containers in flagNames, which maps
+// container names to the name of their verbosity flag (e.g. "--v"). An existing occurrence of the flag
+// in the container args, either as "--v=2" or as "--v" "2", is overwritten. The flag is appended only when
+// the container has no verbosity arg, so that e.g. "-v=2" or "--log-level=debug" is left alone.
+func SetContainerVerbosityFlags(podSpec *corev1.PodSpec, verbosity int, flagNames map[string]string) {
+	for i := range podSpec.InitContainers {
+		if flagName, ok := flagNames[podSpec.InitContainers[i].Name]; ok && len(flagName) > 0 {
+			podSpec.InitContainers[i].Args = setVerbosityFlag(podSpec.InitContainers[i].Args, flagName, verbosity)
+		}
+	}
+	for i := range podSpec.Containers {
+		if flagName, ok := flagNames[podSpec.Containers[i].Name]; ok && len(flagName) > 0 {
+			podSpec.Containers[i].Args = setVerbosityFlag(podSpec.Containers[i].Args, flagName, verbosity)
+		}
+	}
+}
+
+// SetContainerFlag sets the flag flagName to value in the args of the containers in containerNames.
+// An existing occurrence of the flag, either as "--flag=value" or as "--flag" "value", is overwritten,
+// otherwise the flag is appended.
+func SetContainerFlag(podSpec *corev1.PodSpec, containerNames []string, flagName, value string) {
+	for _, containerName := range containerNames {
+		for i := range podSpec.InitContainers {
+			if podSpec.InitContainers[i].Name == containerName {
+				podSpec.InitContainers[i].Args = setFlag(podSpec.InitContainers[i].Args, flagName, value)
+			}
+		}
+		for i := range podSpec.Containers {
+			if podSpec.Containers[i].Name == containerName {
+				podSpec.Containers[i].Args = setFlag(podSpec.Containers[i].Args, flagName, value)
+			}
+		}
+	}
+}
+
+// verbosityFlagNames are the names of the usual verbosity flags of the containers.
+var verbosityFlagNames = []string{"-v", "--v", "-loglevel", "--loglevel", "-log-level", "--log-level"}
+
+func setVerbosityFlag(args []string, flagName string, verbosity int) []string {
+	if hasFlag(args, flagName) {
+		return setFlag(args, flagName, strconv.Itoa(verbosity))
+	}
+	for _, name := range verbosityFlagNames {
+		if hasFlag(args, name) {
+			return args
+		}
+	}
+	return append(args, flagName+"="+strconv.Itoa(verbosity))
+}
+
+func hasFlag(args []string, flagName string) bool {
+	for _, arg := range args {
+		if arg == flagName || strings.HasPrefix(arg, flagName+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+func setFlag(args []string, flagName, value string) []string {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, flagName+"=") {
+			args[i] = flagName + "=" + value
+			return args
+		}
+		if arg == flagName && i+1 < len(args) {
+			args[i+1] = value
+			return args
+		}
+	}
+	return append(args, flagName+"="+value)
+}
+
 func SetCondition(conditions *[]metav1.Condition, newCondition metav1.Condition) {
 	if conditions == nil {
 		conditions = &[]metav1.Condition{}
@@ -0,0 +1,288 @@
+package v1helpers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// CoalescingOperatorClient is an OperatorClient which coalesces the status updates of the controllers of an operator
+// into fewer updates of the underlying client, to reduce the writes of the operators with many controllers updating
+// their conditions.
+//
+// A status update is not written right away: it is kept pending, and returned by GetOperatorState, so that the next
+// status updates build on it. The pending status is written by Run at the end of the coalescing window started by the
+// first pending update, and at most once per minimum interval. When the resource is updated in the meantime, e.g. by a
+// spec change, the changes of the pending status are reapplied on top of the new status.
+//
+// This trades the durability of the status updates for fewer writes: a status update which was accepted is lost when
+// the process exits before the end of its window. When a write fails, the following status updates return the error,
+// so that the controllers retry, while the pending status is written again after the minimum interval.
+type CoalescingOperatorClient struct {
+	OperatorClient
+
+	window      time.Duration
+	minInterval time.Duration
+	clock       clock.Clock
+
+	// pendingCh is notified when a status update is pending.
+	pendingCh chan struct{}
+
+	lock sync.Mutex
+	// pending is the status to write, computed from base at resourceVersion.
+	pending         *operatorv1.OperatorStatus
+	base            *operatorv1.OperatorStatus
+	resourceVersion string
+	lastWrite       time.Time
+	// writeErr is the error of the last write, if it failed.
+	writeErr error
+}
+
+var _ OperatorClient = &CoalescingOperatorClient{}
+
+// NewCoalescingOperatorClient returns a client which writes the status updates made within window with a single
+// update of client, and makes at most one status update every minInterval. Run must be started for the status updates
+// to be written.
+func NewCoalescingOperatorClient(client OperatorClient, window, minInterval time.Duration) *CoalescingOperatorClient {
+	return &CoalescingOperatorClient{
+		OperatorClient: client,
+		window:         window,
+		minInterval:    minInterval,
+		clock:          clock.RealClock{},
+		pendingCh:      make(chan struct{}, 1),
+	}
+}
+
+// WithClock sets the clock used to schedule the status writes.
+func (c *CoalescingOperatorClient) WithClock(clock clock.Clock) *CoalescingOperatorClient {
+	c.clock = clock
+	return c
+}
+
+// GetOperatorState returns the state of the underlying client, with the pending status if any.
+func (c *CoalescingOperatorClient) GetOperatorState() (*operatorv1.OperatorSpec, *operatorv1.OperatorStatus, string, error) {
+	spec, status, resourceVersion, err := c.OperatorClient.GetOperatorState()
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.rebaseLocked(status, resourceVersion)
+	if c.pending == nil {
+		return spec, status, resourceVersion, nil
+	}
+	return spec, c.pending.DeepCopy(), resourceVersion, nil
+}
+
+// UpdateOperatorStatus makes status the pending status, to be written by Run. The status is kept pending when the last
+// write failed, but the error of that write is returned.
+func (c *CoalescingOperatorClient) UpdateOperatorStatus(ctx context.Context, resourceVersion string, status *operatorv1.OperatorStatus) (*operatorv1.OperatorStatus, error) {
+	_, current, currentResourceVersion, err := c.OperatorClient.GetOperatorState()
+	if err != nil {
+		return nil, err
+	}
+	if resourceVersion != currentResourceVersion {
+		return nil, errors.NewConflict(schema.GroupResource{Group: operatorv1.GroupName, Resource: "operators"}, "status", fmt.Errorf("the resource version %q is not the current one %q", resourceVersion, currentResourceVersion))
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.rebaseLocked(current, currentResourceVersion)
+	if c.pending == nil {
+		c.base = current.DeepCopy()
+		c.resourceVersion = currentResourceVersion
+	}
+	c.pending = status.DeepCopy()
+
+	select {
+	case c.pendingCh <- struct{}{}:
+	default:
+	}
+	if c.writeErr != nil {
+		return nil, fmt.Errorf("the status update is pending, the last write of the operator status failed: %w", c.writeErr)
+	}
+	return status.DeepCopy(), nil
+}
+
+// Run writes the pending status updates until ctx is done.
+func (c *CoalescingOperatorClient) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.pendingCh:
+		}
+
+		// let the updates made within the window coalesce, and respect the minimum interval between the writes
+		c.lock.Lock()
+		wait := c.minInterval - c.clock.Since(c.lastWrite)
+		c.lock.Unlock()
+		if wait < c.window {
+			wait = c.window
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.clock.After(wait):
+		}
+		select {
+		case <-c.pendingCh:
+		default:
+		}
+
+		if err := c.flush(ctx); err != nil {
+			utilruntime.HandleError(fmt.Errorf("failed to write the operator status: %w", err))
+			// retry after the minimum interval
+			select {
+			case c.pendingCh <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// flush writes the pending status. The lock is not held during the requests to the underlying client, so that the
+// status updates are not blocked by the writes.
+func (c *CoalescingOperatorClient) flush(ctx context.Context) error {
+	c.lock.Lock()
+	if c.pending == nil {
+		c.lock.Unlock()
+		return nil
+	}
+	c.lastWrite = c.clock.Now()
+	c.lock.Unlock()
+
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		_, current, currentResourceVersion, err := c.OperatorClient.GetOperatorState()
+		if err != nil {
+			return err
+		}
+		c.lock.Lock()
+		c.rebaseLocked(current, currentResourceVersion)
+		// the pending status is replaced, never modified, by the status updates
+		pending, resourceVersion := c.pending, c.resourceVersion
+		c.lock.Unlock()
+		if pending == nil {
+			return nil
+		}
+
+		if _, err := c.OperatorClient.UpdateOperatorStatus(ctx, resourceVersion, pending.DeepCopy()); err != nil {
+			return err
+		}
+
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		// a status update made during the write stays pending, it is rebased on the written status by the next read
+		if c.pending == pending {
+			c.pending, c.base, c.resourceVersion = nil, nil, ""
+		}
+		return nil
+	})
+	if errors.IsConflict(err) {
+		klog.V(2).Infof("The operator status was updated concurrently, the pending status will be rebased and written again: %v", err)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.writeErr = err
+	return err
+}
+
+// rebaseLocked reapplies the changes of the pending status on top of the current status when the resource was updated
+// since the pending status was computed.
+func (c *CoalescingOperatorClient) rebaseLocked(current *operatorv1.OperatorStatus, resourceVersion string) {
+	if c.pending == nil || c.resourceVersion == resourceVersion {
+		return
+	}
+	rebased := rebaseOperatorStatus(c.base, c.pending, current)
+	if equality.Semantic.DeepEqual(rebased, current) {
+		c.pending, c.base, c.resourceVersion = nil, nil, ""
+		return
+	}
+	c.pending, c.base, c.resourceVersion = rebased, current.DeepCopy(), resourceVersion
+}
+
+// rebaseOperatorStatus returns current with the changes made from base to desired.
+func rebaseOperatorStatus(base, desired, current *operatorv1.OperatorStatus) *operatorv1.OperatorStatus {
+	rebased := current.DeepCopy()
+
+	for _, condition := range desired.Conditions {
+		if baseCondition := FindOperatorCondition(base.Conditions, condition.Type); baseCondition != nil && equality.Semantic.DeepEqual(*baseCondition, condition) {
+			continue
+		}
+		if existing := FindOperatorCondition(rebased.Conditions, condition.Type); existing != nil {
+			*existing = condition
+		} else {
+			rebased.Conditions = append(rebased.Conditions, condition)
+		}
+	}
+	for _, condition := range base.Conditions {
+		if FindOperatorCondition(desired.Conditions, condition.Type) == nil {
+			RemoveOperatorCondition(&rebased.Conditions, condition.Type)
+		}
+	}
+
+	for _, generation := range desired.Generations {
+		if baseGeneration := findGenerationStatus(base.Generations, generation); baseGeneration != nil && *baseGeneration == generation {
+			continue
+		}
+		if existing := findGenerationStatus(rebased.Generations, generation); existing != nil {
+			*existing = generation
+		} else {
+			rebased.Generations = append(rebased.Generations, generation)
+		}
+	}
+	for _, generation := range base.Generations {
+		if findGenerationStatus(desired.Generations, generation) == nil {
+			rebased.Generations = removeGenerationStatus(rebased.Generations, generation)
+		}
+	}
+
+	// the other fields are replaced as a whole
+	rebasedValue, desiredValue, baseValue := reflect.ValueOf(rebased).Elem(), reflect.ValueOf(desired).Elem(), reflect.ValueOf(base).Elem()
+	for i := 0; i < rebasedValue.NumField(); i++ {
+		switch rebasedValue.Type().Field(i).Name {
+		case "Conditions", "Generations":
+			continue
+		}
+		if !equality.Semantic.DeepEqual(desiredValue.Field(i).Interface(), baseValue.Field(i).Interface()) {
+			rebasedValue.Field(i).Set(desiredValue.Field(i))
+		}
+	}
+	return rebased
+}
+
+func sameGenerationStatusResource(a, b operatorv1.GenerationStatus) bool {
+	return a.Group == b.Group && a.Resource == b.Resource && a.Namespace == b.Namespace && a.Name == b.Name
+}
+
+func findGenerationStatus(generations []operatorv1.GenerationStatus, generation operatorv1.GenerationStatus) *operatorv1.GenerationStatus {
+	for i := range generations {
+		if sameGenerationStatusResource(generations[i], generation) {
+			return &generations[i]
+		}
+	}
+	return nil
+}
+
+func removeGenerationStatus(generations []operatorv1.GenerationStatus, generation operatorv1.GenerationStatus) []operatorv1.GenerationStatus {
+	var kept []operatorv1.GenerationStatus
+	for _, existing := range generations {
+		if !sameGenerationStatusResource(existing, generation) {
+			kept = append(kept, existing)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,198 @@
+package v1helpers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestCoalescingOperatorClient(t *testing.T) {
+	writes := 0
+	underlying := NewFakeOperatorClient(&operatorv1.OperatorSpec{}, &operatorv1.OperatorStatus{
+		Conditions: []operatorv1.OperatorCondition{{Type: "FooDegraded", Status: operatorv1.ConditionTrue}},
+	}, func(rv string, status *operatorv1.OperatorStatus) error {
+		writes++
+		return nil
+	})
+	client := NewCoalescingOperatorClient(underlying, time.Second, time.Minute)
+
+	conditionTypes := func(status *operatorv1.OperatorStatus) []string {
+		var types []string
+		for _, condition := range status.Conditions {
+			types = append(types, condition.Type)
+		}
+		return types
+	}
+
+	// the updates build on each other without being written
+	for _, condition := range []operatorv1.OperatorCondition{
+		{Type: "FooDegraded", Status: operatorv1.ConditionFalse},
+		{Type: "BarDegraded", Status: operatorv1.ConditionTrue},
+		{Type: "BazAvailable", Status: operatorv1.ConditionTrue},
+	} {
+		if _, _, err := UpdateStatus(context.TODO(), client, UpdateConditionFn(condition)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if writes != 0 {
+		t.Fatalf("expected no write before the flush, got %d", writes)
+	}
+	_, status, _, err := client.GetOperatorState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := []string{"FooDegraded", "BarDegraded", "BazAvailable"}, conditionTypes(status); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected the pending conditions %v, got %v", expected, actual)
+	}
+
+	// the status is updated concurrently
+	_, status, resourceVersion, err := underlying.GetOperatorState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	status = status.DeepCopy()
+	status.Conditions = append(status.Conditions, operatorv1.OperatorCondition{Type: "QuxProgressing", Status: operatorv1.ConditionTrue})
+	if _, err := underlying.UpdateOperatorStatus(context.TODO(), resourceVersion, status); err != nil {
+		t.Fatal(err)
+	}
+	writes = 0
+
+	// the pending changes are written on top of the concurrent update in a single write
+	if err := client.flush(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if writes != 1 {
+		t.Errorf("expected a single write, got %d", writes)
+	}
+	_, status, _, err = underlying.GetOperatorState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := []string{"FooDegraded", "QuxProgressing", "BarDegraded", "BazAvailable"}, conditionTypes(status); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected the written conditions %v, got %v", expected, actual)
+	}
+	if status.Conditions[0].Status != operatorv1.ConditionFalse {
+		t.Errorf("expected the pending change of FooDegraded to be written, got %v", status.Conditions[0])
+	}
+
+	// nothing is written without a change
+	if _, _, err := UpdateStatus(context.TODO(), client, UpdateConditionFn(operatorv1.OperatorCondition{Type: "BarDegraded", Status: operatorv1.ConditionTrue})); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.flush(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if writes != 1 {
+		t.Errorf("expected no write without a change, got %d", writes)
+	}
+}
+
+func TestCoalescingOperatorClientRun(t *testing.T) {
+	start := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	fakeClock := clocktesting.NewFakeClock(start)
+	writes := make(chan time.Time, 10)
+	underlying := NewFakeOperatorClient(&operatorv1.OperatorSpec{}, &operatorv1.OperatorStatus{}, func(rv string, status *operatorv1.OperatorStatus) error {
+		writes <- fakeClock.Now()
+		return nil
+	})
+	client := NewCoalescingOperatorClient(underlying, time.Second, time.Minute).WithClock(fakeClock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go client.Run(ctx)
+
+	update := func(conditionType string) {
+		t.Helper()
+		if _, _, err := UpdateStatus(context.TODO(), client, UpdateConditionFn(operatorv1.OperatorCondition{Type: conditionType, Status: operatorv1.ConditionTrue})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// stepUntilWrite steps the clock until the pending status is written and returns the time of the write
+	stepUntilWrite := func(step time.Duration) time.Time {
+		t.Helper()
+		var written time.Time
+		err := wait.PollImmediate(time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+			select {
+			case written = <-writes:
+				return true, nil
+			default:
+			}
+			if fakeClock.HasWaiters() {
+				fakeClock.Step(step)
+			}
+			return false, nil
+		})
+		if err != nil {
+			t.Fatal("expected the pending status to be written")
+		}
+		// wait for the write to complete
+		err = wait.PollImmediate(time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+			client.lock.Lock()
+			defer client.lock.Unlock()
+			return client.pending == nil, nil
+		})
+		if err != nil {
+			t.Fatal("expected the write to complete")
+		}
+		return written
+	}
+
+	update("FooDegraded")
+	update("BarDegraded")
+	if written := stepUntilWrite(time.Second); !written.Equal(start.Add(time.Second)) {
+		t.Errorf("expected the status to be written after the window, got %v", written)
+	}
+	if _, status, _, _ := underlying.GetOperatorState(); len(status.Conditions) != 2 {
+		t.Errorf("expected the coalesced conditions to be written, got %v", status.Conditions)
+	}
+
+	update("BazDegraded")
+	if written := stepUntilWrite(time.Second); written.Sub(start) < time.Minute {
+		t.Errorf("expected the next write after the minimum interval, got %v", written)
+	}
+}
+
+func TestCoalescingOperatorClientWriteFailure(t *testing.T) {
+	var writeErr error
+	underlying := NewFakeOperatorClient(&operatorv1.OperatorSpec{}, &operatorv1.OperatorStatus{}, func(rv string, status *operatorv1.OperatorStatus) error {
+		return writeErr
+	})
+	client := NewCoalescingOperatorClient(underlying, time.Second, time.Minute)
+
+	update := func(status *operatorv1.OperatorStatus) error {
+		_, _, err := UpdateStatus(context.TODO(), client, func(oldStatus *operatorv1.OperatorStatus) error {
+			*oldStatus = *status
+			return nil
+		})
+		return err
+	}
+
+	if err := update(&operatorv1.OperatorStatus{ReadyReplicas: 2}); err != nil {
+		t.Fatal(err)
+	}
+	writeErr = fmt.Errorf("unavailable")
+	if err := client.flush(context.TODO()); err == nil {
+		t.Fatal("expected the write to fail")
+	}
+
+	// the failure is returned to the next status updates, which are still kept pending
+	if err := update(&operatorv1.OperatorStatus{ReadyReplicas: 3, Version: "1.0"}); err == nil {
+		t.Error("expected the status update to return the failure of the last write")
+	}
+	writeErr = nil
+	if err := client.flush(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if _, status, _, _ := underlying.GetOperatorState(); status.ReadyReplicas != 3 || status.Version != "1.0" {
+		t.Errorf("expected the pending status to be written, got %#v", status)
+	}
+	if err := update(&operatorv1.OperatorStatus{ReadyReplicas: 3, Version: "1.0"}); err != nil {
+		t.Errorf("expected the status update to succeed after a successful write, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/network/networkutils"
+)
+
+// defaultNoProxy are the destinations of every cluster that are never proxied.
+var defaultNoProxy = []string{"127.0.0.1", "localhost", ".cluster.local", ".svc"}
+
+// metadataEndpoint is the link-local address of the instance metadata service of the cloud platforms.
+const metadataEndpoint = "169.254.169.254"
+
+// platformNoProxy are the metadata endpoints of the platforms, in addition to the region specific ones.
+var platformNoProxy = map[configv1.PlatformType][]string{
+	configv1.AWSPlatformType:          {metadataEndpoint},
+	configv1.AzurePlatformType:        {metadataEndpoint},
+	configv1.GCPPlatformType:          {metadataEndpoint, "metadata", "metadata.google.internal", "metadata.google.internal."},
+	configv1.OpenStackPlatformType:    {metadataEndpoint},
+	configv1.AlibabaCloudPlatformType: {"100.100.100.200"},
+}
+
+// NoProxyConfig are the sources of the NO_PROXY of a cluster.
+type NoProxyConfig struct {
+	// Infrastructure provides the internal API server host name and the platform metadata endpoints.
+	Infrastructure *configv1.Infrastructure
+	// Network provides the cluster and service networks, from its status or from its spec when the status is not
+	// set yet.
+	Network *configv1.Network
+	// MachineNetworks are the CIDRs of the networks of the nodes, e.g. the machine networks of the install config.
+	MachineNetworks []string
+	// Additional are the custom destinations, e.g. the spec.noProxy of the cluster proxy. The entries may be comma
+	// separated lists.
+	Additional []string
+}
+
+// NoProxy returns the NO_PROXY value of a cluster: the local destinations, the cluster, service and machine networks,
+// the host name of the internal API server, the metadata endpoints of the platform and the custom destinations.
+// The entries are deduplicated, with the CIDRs and IP addresses normalized and the host names lowercased, and
+// sorted, so that every operator injecting the proxy environment variables computes the same value and only
+// updates its operands when the value actually changes. The custom destination "*" disables the proxy for all the
+// destinations, which is returned alone.
+func NoProxy(config NoProxyConfig) (string, error) {
+	entries := sets.NewString(defaultNoProxy...)
+
+	if infra := config.Infrastructure; infra != nil {
+		if apiInt := infra.Status.APIServerInternalURL; len(apiInt) > 0 {
+			u, err := url.Parse(apiInt)
+			if err != nil || len(u.Hostname()) == 0 {
+				return "", fmt.Errorf("invalid internal API server URL %q", apiInt)
+			}
+			entries.Insert(u.Hostname())
+		}
+		platform := infra.Status.Platform
+		if infra.Status.PlatformStatus != nil && len(infra.Status.PlatformStatus.Type) > 0 {
+			platform = infra.Status.PlatformStatus.Type
+		}
+		entries.Insert(platformNoProxy[platform]...)
+		if platform == configv1.AWSPlatformType && infra.Status.PlatformStatus != nil && infra.Status.PlatformStatus.AWS != nil {
+			// the private DNS names of the instances
+			if region := infra.Status.PlatformStatus.AWS.Region; region == "us-east-1" {
+				entries.Insert(".ec2.internal")
+			} else if len(region) > 0 {
+				entries.Insert("." + region + ".compute.internal")
+			}
+		}
+	}
+
+	var cidrs []string
+	if network := config.Network; network != nil {
+		clusterNetworks, serviceNetworks := network.Status.ClusterNetwork, network.Status.ServiceNetwork
+		if len(clusterNetworks) == 0 && len(serviceNetworks) == 0 {
+			clusterNetworks, serviceNetworks = network.Spec.ClusterNetwork, network.Spec.ServiceNetwork
+		}
+		for _, clusterNetwork := range clusterNetworks {
+			cidrs = append(cidrs, clusterNetwork.CIDR)
+		}
+		cidrs = append(cidrs, serviceNetworks...)
+	}
+	cidrs = append(cidrs, config.MachineNetworks...)
+	for _, cidr := range cidrs {
+		normalized, err := networkutils.NormalizeCIDR(cidr)
+		if err != nil {
+			return "", fmt.Errorf("invalid network %q: %v", cidr, err)
+		}
+		entries.Insert(normalized)
+	}
+
+	for _, additional := range config.Additional {
+		for _, entry := range strings.Split(additional, ",") {
+			entry = strings.TrimSpace(entry)
+			switch {
+			case len(entry) == 0:
+			case entry == "*":
+				return "*", nil
+			default:
+				entries.Insert(normalizeNoProxyEntry(entry))
+			}
+		}
+	}
+
+	return strings.Join(entries.List(), ","), nil
+}
+
+// normalizeNoProxyEntry normalizes the CIDRs and IP addresses and lowercases the host names, keeping the entries it
+// does not recognize, e.g. with a port, as they are.
+func normalizeNoProxyEntry(entry string) string {
+	if strings.Contains(entry, "/") {
+		if normalized, err := networkutils.NormalizeCIDR(entry); err == nil {
+			return normalized
+		}
+		return entry
+	}
+	if ip := net.ParseIP(entry); ip != nil {
+		return ip.String()
+	}
+	return strings.ToLower(entry)
+}
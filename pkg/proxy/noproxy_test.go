@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+func TestNoProxy(t *testing.T) {
+	infrastructure := func(platform configv1.PlatformType, region string) *configv1.Infrastructure {
+		infra := &configv1.Infrastructure{
+			Status: configv1.InfrastructureStatus{
+				APIServerInternalURL: "https://api-int.cluster.example.com:6443",
+				PlatformStatus:       &configv1.PlatformStatus{Type: platform},
+			},
+		}
+		if platform == configv1.AWSPlatformType {
+			infra.Status.PlatformStatus.AWS = &configv1.AWSPlatformStatus{Region: region}
+		}
+		return infra
+	}
+	network := &configv1.Network{
+		Spec: configv1.NetworkSpec{
+			ClusterNetwork: []configv1.ClusterNetworkEntry{{CIDR: "10.0.0.0/8"}},
+			ServiceNetwork: []string{"192.168.0.0/16"},
+		},
+		Status: configv1.NetworkStatus{
+			ClusterNetwork: []configv1.ClusterNetworkEntry{{CIDR: "10.128.0.0/14"}, {CIDR: "FD01::/48"}},
+			ServiceNetwork: []string{"172.30.0.0/16", "fd02::/112"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		config    NoProxyConfig
+		expected  string
+		expectErr bool
+	}{
+		{
+			name:     "defaults",
+			expected: ".cluster.local,.svc,127.0.0.1,localhost",
+		},
+		{
+			name: "aws",
+			config: NoProxyConfig{
+				Infrastructure:  infrastructure(configv1.AWSPlatformType, "eu-west-1"),
+				Network:         network,
+				MachineNetworks: []string{"10.0.0.0/16"},
+			},
+			expected: ".cluster.local,.eu-west-1.compute.internal,.svc,10.0.0.0/16,10.128.0.0/14,127.0.0.1,169.254.169.254," +
+				"172.30.0.0/16,api-int.cluster.example.com,fd01::/48,fd02::/112,localhost",
+		},
+		{
+			name: "aws us-east-1",
+			config: NoProxyConfig{
+				Infrastructure: infrastructure(configv1.AWSPlatformType, "us-east-1"),
+			},
+			expected: ".cluster.local,.ec2.internal,.svc,127.0.0.1,169.254.169.254,api-int.cluster.example.com,localhost",
+		},
+		{
+			name: "gcp",
+			config: NoProxyConfig{
+				Infrastructure: infrastructure(configv1.GCPPlatformType, ""),
+			},
+			expected: ".cluster.local,.svc,127.0.0.1,169.254.169.254,api-int.cluster.example.com,localhost,metadata," +
+				"metadata.google.internal,metadata.google.internal.",
+		},
+		{
+			name: "bare metal",
+			config: NoProxyConfig{
+				Infrastructure: infrastructure(configv1.BareMetalPlatformType, ""),
+			},
+			expected: ".cluster.local,.svc,127.0.0.1,api-int.cluster.example.com,localhost",
+		},
+		{
+			name: "network spec before status",
+			config: NoProxyConfig{
+				Network: &configv1.Network{Spec: network.Spec},
+			},
+			expected: ".cluster.local,.svc,10.0.0.0/8,127.0.0.1,192.168.0.0/16,localhost",
+		},
+		{
+			name: "additional entries are normalized and deduplicated",
+			config: NoProxyConfig{
+				Network:    network,
+				Additional: []string{"Registry.Example.com, 2001:0DB8::1,172.30.0.0/16", "registry.example.com,,.svc,10.1.2.3/16,host:5000"},
+			},
+			expected: ".cluster.local,.svc,10.1.2.3/16,10.128.0.0/14,127.0.0.1,172.30.0.0/16,2001:db8::1,fd01::/48,fd02::/112," +
+				"host:5000,localhost,registry.example.com",
+		},
+		{
+			name: "wildcard",
+			config: NoProxyConfig{
+				Network:    network,
+				Additional: []string{"example.com,*"},
+			},
+			expected: "*",
+		},
+		{
+			name: "invalid internal API server URL",
+			config: NoProxyConfig{
+				Infrastructure: &configv1.Infrastructure{Status: configv1.InfrastructureStatus{APIServerInternalURL: "://"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid machine network",
+			config: NoProxyConfig{
+				MachineNetworks: []string{"10.0.0.1/16"},
+			},
+			expectErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			noProxy, err := NoProxy(tc.config)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %t, got %v", tc.expectErr, err)
+			}
+			if noProxy != tc.expected {
+				t.Errorf("expected\n%s\ngot\n%s", tc.expected, noProxy)
+			}
+		})
+	}
+}
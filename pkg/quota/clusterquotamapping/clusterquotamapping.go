@@ -142,6 +142,8 @@ func (c *ClusterQuotaMappingController) Run(workers int, stopCh <-chan struct{})
 }
 
 func (c *ClusterQuotaMappingController) syncQuota(quota *quotav1.ClusterResourceQuota) error {
+	defer func(start time.Time) { metrics.ObserveRecalculation("quota", time.Since(start)) }(time.Now())
+
 	matcherFunc, err := GetObjectMatcher(quota.Spec.Selector)
 	if err != nil {
 		return err
@@ -188,6 +190,8 @@ func (c *ClusterQuotaMappingController) syncQuota(quota *quotav1.ClusterResource
 }
 
 func (c *ClusterQuotaMappingController) syncNamespace(namespace metav1.Object) error {
+	defer func(start time.Time) { metrics.ObserveRecalculation("namespace", time.Since(start)) }(time.Now())
+
 	allQuotas, err1 := c.quotaLister.List(labels.Everything())
 	if err1 != nil {
 		return err1
@@ -22,6 +22,16 @@ type ClusterQuotaMapper interface {
 	AddListener(listener MappingChangeListener)
 }
 
+// ClusterQuotaMappingSnapshotter is implemented by the ClusterQuotaMappers which can copy the whole mapping, like the
+// mapper of the ClusterQuotaMappingController. Callers check for it with a type assertion.
+type ClusterQuotaMappingSnapshotter interface {
+	// Snapshot returns a copy of the whole mapping, taken atomically so that it is consistent across quotas and
+	// namespaces.
+	Snapshot() *MappingSnapshot
+}
+
+var _ ClusterQuotaMappingSnapshotter = &clusterQuotaMapper{}
+
 // MappingChangeListener is notified of changes to the mapping.  It must not block.
 type MappingChangeListener interface {
 	AddMapping(quotaName, namespaceName string)
@@ -33,6 +43,31 @@ type SelectionFields struct {
 	Annotations map[string]string
 }
 
+// MappingSnapshot is a point in time copy of the mapping between cluster quotas and namespaces. It is not updated
+// with the mapping, and can be read without synchronization.
+type MappingSnapshot struct {
+	// QuotaToNamespaces are the names of the namespaces each cluster quota matches
+	QuotaToNamespaces map[string]sets.String
+	// NamespaceToQuotas are the names of the cluster quotas each namespace matches
+	NamespaceToQuotas map[string]sets.String
+	// QuotaSelectors are the latest selectors the mapping of each cluster quota was computed with, so that callers
+	// can determine staleness
+	QuotaSelectors map[string]quotav1.ClusterResourceQuotaSelector
+	// NamespaceSelectionFields are the latest selectionFields the mapping of each namespace was computed with, so
+	// that callers can determine staleness
+	NamespaceSelectionFields map[string]SelectionFields
+}
+
+// GetClusterQuotasFor returns the names of the cluster quotas the namespace matches.
+func (s *MappingSnapshot) GetClusterQuotasFor(namespaceName string) []string {
+	return s.NamespaceToQuotas[namespaceName].List()
+}
+
+// GetNamespacesFor returns the names of the namespaces the cluster quota matches.
+func (s *MappingSnapshot) GetNamespacesFor(quotaName string) []string {
+	return s.QuotaToNamespaces[quotaName].List()
+}
+
 // clusterQuotaMapper gives thread safe access to the actual mappings that are being stored.
 // Many method use a shareable read lock to check status followed by a non-shareable
 // write lock which double checks the condition before proceeding.  Since locks aren't escalatable
@@ -51,6 +86,8 @@ type clusterQuotaMapper struct {
 
 	quotaToNamespaces map[string]sets.String
 	namespaceToQuota  map[string]sets.String
+	// mappings is the number of namespaces in quotaToNamespaces, summed over the quotas
+	mappings int
 
 	listeners []MappingChangeListener
 }
@@ -89,6 +126,31 @@ func (m *clusterQuotaMapper) GetNamespacesFor(quotaName string) ([]string, quota
 	return namespaces.List(), m.completedQuotaToSelector[quotaName]
 }
 
+func (m *clusterQuotaMapper) Snapshot() *MappingSnapshot {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	snapshot := &MappingSnapshot{
+		QuotaToNamespaces:        make(map[string]sets.String, len(m.quotaToNamespaces)),
+		NamespaceToQuotas:        make(map[string]sets.String, len(m.namespaceToQuota)),
+		QuotaSelectors:           make(map[string]quotav1.ClusterResourceQuotaSelector, len(m.completedQuotaToSelector)),
+		NamespaceSelectionFields: make(map[string]SelectionFields, len(m.completedNamespaceToLabels)),
+	}
+	for quotaName, namespaces := range m.quotaToNamespaces {
+		snapshot.QuotaToNamespaces[quotaName] = sets.NewString(namespaces.UnsortedList()...)
+	}
+	for namespaceName, quotas := range m.namespaceToQuota {
+		snapshot.NamespaceToQuotas[namespaceName] = sets.NewString(quotas.UnsortedList()...)
+	}
+	for quotaName, selector := range m.completedQuotaToSelector {
+		snapshot.QuotaSelectors[quotaName] = *selector.DeepCopy()
+	}
+	for namespaceName, selectionFields := range m.completedNamespaceToLabels {
+		snapshot.NamespaceSelectionFields[namespaceName] = selectionFields
+	}
+	return snapshot
+}
+
 func (m *clusterQuotaMapper) AddListener(listener MappingChangeListener) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -130,9 +192,11 @@ func (m *clusterQuotaMapper) completeQuota(quota *quotav1.ClusterResourceQuota)
 func (m *clusterQuotaMapper) removeQuota(quotaName string) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
+	defer m.observeSizes()
 
 	delete(m.requiredQuotaToSelector, quotaName)
 	delete(m.completedQuotaToSelector, quotaName)
+	m.mappings -= m.quotaToNamespaces[quotaName].Len()
 	delete(m.quotaToNamespaces, quotaName)
 	for namespaceName, quotas := range m.namespaceToQuota {
 		if quotas.Has(quotaName) {
@@ -178,6 +242,7 @@ func (m *clusterQuotaMapper) completeNamespace(namespace metav1.Object) {
 func (m *clusterQuotaMapper) removeNamespace(namespaceName string) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
+	defer m.observeSizes()
 
 	delete(m.requiredNamespaceToLabels, namespaceName)
 	delete(m.completedNamespaceToLabels, namespaceName)
@@ -185,6 +250,7 @@ func (m *clusterQuotaMapper) removeNamespace(namespaceName string) {
 	for quotaName, namespaces := range m.quotaToNamespaces {
 		if namespaces.Has(namespaceName) {
 			namespaces.Delete(namespaceName)
+			m.mappings--
 			for _, listener := range m.listeners {
 				listener.RemoveMapping(quotaName, namespaceName)
 			}
@@ -226,14 +292,16 @@ func (m *clusterQuotaMapper) setMapping(quota *quotav1.ClusterResourceQuota, nam
 		return false, true, false
 	}
 
+	defer m.observeSizes()
 	if remove {
 		mutated := false
 
 		namespaces, ok := m.quotaToNamespaces[quota.Name]
 		if !ok {
 			m.quotaToNamespaces[quota.Name] = sets.String{}
-		} else {
-			mutated = namespaces.Has(namespace.GetName())
+		} else if namespaces.Has(namespace.GetName()) {
+			mutated = true
+			m.mappings--
 			namespaces.Delete(namespace.GetName())
 		}
 
@@ -259,9 +327,11 @@ func (m *clusterQuotaMapper) setMapping(quota *quotav1.ClusterResourceQuota, nam
 	namespaces, ok := m.quotaToNamespaces[quota.Name]
 	if !ok {
 		mutated = true
+		m.mappings++
 		m.quotaToNamespaces[quota.Name] = sets.NewString(namespace.GetName())
-	} else {
-		mutated = !namespaces.Has(namespace.GetName())
+	} else if !namespaces.Has(namespace.GetName()) {
+		mutated = true
+		m.mappings++
 		namespaces.Insert(namespace.GetName())
 	}
 
@@ -284,6 +354,11 @@ func (m *clusterQuotaMapper) setMapping(quota *quotav1.ClusterResourceQuota, nam
 
 }
 
+// observeSizes records the sizes of the mapping, the lock must be held
+func (m *clusterQuotaMapper) observeSizes() {
+	metrics.ObserveSizes(len(m.quotaToNamespaces), len(m.namespaceToQuota), m.mappings)
+}
+
 func GetSelectionFields(namespace metav1.Object) SelectionFields {
 	return SelectionFields{Labels: namespace.GetLabels(), Annotations: namespace.GetAnnotations()}
 }
@@ -0,0 +1,90 @@
+package clusterquotamapping
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8smetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/testutil"
+
+	quotav1 "github.com/openshift/api/quota/v1"
+)
+
+func TestClusterQuotaMapperSnapshot(t *testing.T) {
+	mapper := NewClusterQuotaMapper()
+	quota := func(name string) *quotav1.ClusterResourceQuota {
+		return &quotav1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: quotav1.ClusterResourceQuotaSpec{
+				Selector: quotav1.ClusterResourceQuotaSelector{AnnotationSelector: map[string]string{"owner": name}},
+			},
+		}
+	}
+	namespace := func(name string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"name": name}}}
+	}
+	set := func(q *quotav1.ClusterResourceQuota, ns *corev1.Namespace, remove bool) {
+		t.Helper()
+		mapper.requireQuota(q)
+		mapper.requireNamespace(ns)
+		if success, _, _ := mapper.setMapping(q, ns, remove); !success {
+			t.Fatalf("failed to set the mapping of %s and %s", q.Name, ns.Name)
+		}
+		mapper.completeQuota(q)
+		mapper.completeNamespace(ns)
+	}
+	expectSizes := func(quotas, namespaces, mappings float64) {
+		t.Helper()
+		for _, gauge := range []struct {
+			name     string
+			gauge    *k8smetrics.Gauge
+			expected float64
+		}{
+			{"quotas", metrics.quotas, quotas},
+			{"namespaces", metrics.namespaces, namespaces},
+			{"mappings", metrics.mappings, mappings},
+		} {
+			if value, _ := testutil.GetGaugeMetricValue(gauge.gauge); value != gauge.expected {
+				t.Errorf("expected %v %s, got %v", gauge.expected, gauge.name, value)
+			}
+		}
+	}
+
+	set(quota("a"), namespace("one"), false)
+	set(quota("a"), namespace("two"), false)
+	set(quota("b"), namespace("two"), false)
+	set(quota("b"), namespace("three"), true)
+	expectSizes(2, 3, 3)
+
+	snapshot := mapper.Snapshot()
+	if expected := []string{"one", "two"}; !reflect.DeepEqual(snapshot.GetNamespacesFor("a"), expected) {
+		t.Errorf("expected %v, got %v", expected, snapshot.GetNamespacesFor("a"))
+	}
+	if expected := []string{"a", "b"}; !reflect.DeepEqual(snapshot.GetClusterQuotasFor("two"), expected) {
+		t.Errorf("expected %v, got %v", expected, snapshot.GetClusterQuotasFor("two"))
+	}
+	if expected := []string{}; !reflect.DeepEqual(snapshot.GetClusterQuotasFor("missing"), expected) {
+		t.Errorf("expected %v, got %v", expected, snapshot.GetClusterQuotasFor("missing"))
+	}
+	if selector := snapshot.QuotaSelectors["b"]; selector.AnnotationSelector["owner"] != "b" {
+		t.Errorf("unexpected selector of b: %#v", selector)
+	}
+	if fields := snapshot.NamespaceSelectionFields["three"]; fields.Labels["name"] != "three" {
+		t.Errorf("unexpected selection fields of three: %#v", fields)
+	}
+
+	// the snapshot is not updated with the mapping
+	mapper.removeQuota("a")
+	expectSizes(1, 3, 1)
+	if expected := []string{"a", "b"}; !reflect.DeepEqual(snapshot.GetClusterQuotasFor("two"), expected) {
+		t.Errorf("expected the snapshot to be unchanged, got %v", snapshot.GetClusterQuotasFor("two"))
+	}
+	if expected := []string{"b"}; !reflect.DeepEqual(mapper.Snapshot().GetClusterQuotasFor("two"), expected) {
+		t.Errorf("expected %v, got %v", expected, mapper.Snapshot().GetClusterQuotasFor("two"))
+	}
+
+	mapper.removeNamespace("two")
+	expectSizes(1, 2, 0)
+}
@@ -0,0 +1,82 @@
+package clusterquotamapping
+
+import (
+	"time"
+
+	k8smetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	namespace = "cluster_quota_mapping"
+)
+
+// metrics provides access to all cluster quota mapping metrics.
+var metrics *mappingMetrics
+
+func init() {
+	metrics = newMappingMetrics(legacyregistry.Register)
+}
+
+// mappingMetrics instruments the cluster quota mapping with prometheus metrics.
+type mappingMetrics struct {
+	quotas       *k8smetrics.Gauge
+	namespaces   *k8smetrics.Gauge
+	mappings     *k8smetrics.Gauge
+	syncDuration *k8smetrics.HistogramVec
+}
+
+// newMappingMetrics creates a new mappingMetrics, configured with default metric names.
+func newMappingMetrics(registerFunc func(k8smetrics.Registerable) error) *mappingMetrics {
+	quotas := k8smetrics.NewGauge(
+		&k8smetrics.GaugeOpts{
+			Namespace: namespace,
+			Name:      "quotas",
+			Help:      "The number of cluster resource quotas in the mapping",
+		})
+	registerFunc(quotas)
+
+	namespaces := k8smetrics.NewGauge(
+		&k8smetrics.GaugeOpts{
+			Namespace: namespace,
+			Name:      "namespaces",
+			Help:      "The number of namespaces in the mapping",
+		})
+	registerFunc(namespaces)
+
+	mappings := k8smetrics.NewGauge(
+		&k8smetrics.GaugeOpts{
+			Namespace: namespace,
+			Name:      "mappings",
+			Help:      "The number of namespaces selected by cluster resource quotas, summed over the quotas",
+		})
+	registerFunc(mappings)
+
+	syncDuration := k8smetrics.NewHistogramVec(
+		&k8smetrics.HistogramOpts{
+			Namespace: namespace,
+			Name:      "recalculation_duration_seconds",
+			Help:      "How long it takes to recalculate the mapping of a cluster resource quota or a namespace, labeled with the kind (quota, namespace)",
+			Buckets:   k8smetrics.ExponentialBuckets(0.0001, 4, 10),
+		}, []string{"kind"})
+	registerFunc(syncDuration)
+
+	return &mappingMetrics{
+		quotas:       quotas,
+		namespaces:   namespaces,
+		mappings:     mappings,
+		syncDuration: syncDuration,
+	}
+}
+
+// ObserveSizes records the sizes of the mapping.
+func (m *mappingMetrics) ObserveSizes(quotas, namespaces, mappings int) {
+	m.quotas.Set(float64(quotas))
+	m.namespaces.Set(float64(namespaces))
+	m.mappings.Set(float64(mappings))
+}
+
+// ObserveRecalculation records the duration of the recalculation of the mapping of a quota or a namespace.
+func (m *mappingMetrics) ObserveRecalculation(kind string, duration time.Duration) {
+	m.syncDuration.WithLabelValues(kind).Observe(duration.Seconds())
+}
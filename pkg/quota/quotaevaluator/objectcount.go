@@ -0,0 +1,115 @@
+package quotaevaluator
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	quota "k8s.io/apiserver/pkg/quota/v1"
+	"k8s.io/apiserver/pkg/quota/v1/generic"
+)
+
+// UsageFunc returns the usage of an object beyond its count, e.g. the number of hosts of a route.
+type UsageFunc func(item runtime.Object) (corev1.ResourceList, error)
+
+// ObjectCountEvaluator is a quota evaluator counting the objects of a namespaced resource, optionally with their
+// usage of other quota resources, which the object count evaluator of the generic quota package does not support.
+// As the kube object count evaluators, it does not support quota scopes: a quota with scopes never matches the
+// objects.
+type ObjectCountEvaluator struct {
+	groupVersionResource schema.GroupVersionResource
+	listerForResource    quota.ListerForResourceFunc
+	// resourceNames are the names of the quota resources counting the objects
+	resourceNames []corev1.ResourceName
+	usageFunc     UsageFunc
+	// usageResourceNames are the names of the quota resources computed by usageFunc
+	usageResourceNames []corev1.ResourceName
+}
+
+var _ quota.Evaluator = &ObjectCountEvaluator{}
+
+// NewObjectCountEvaluator creates a new ObjectCountEvaluator counting the objects of the resource with the
+// generic.ObjectCountQuotaResourceNameFor the resource, and with the additional resource names, e.g. the legacy
+// openshift.io/imagestreams.
+func NewObjectCountEvaluator(groupVersionResource schema.GroupVersionResource, listerForResource quota.ListerForResourceFunc, resourceNames ...string) *ObjectCountEvaluator {
+	e := &ObjectCountEvaluator{
+		groupVersionResource: groupVersionResource,
+		listerForResource:    listerForResource,
+		resourceNames:        []corev1.ResourceName{generic.ObjectCountQuotaResourceNameFor(groupVersionResource.GroupResource())},
+	}
+	for _, name := range resourceNames {
+		e.resourceNames = append(e.resourceNames, corev1.ResourceName(name))
+	}
+	return e
+}
+
+// WithUsage makes the evaluator compute the usage of the quota resources with the usage function, in addition to
+// the count of the objects. As their usage may change, updates of the objects are then evaluated too.
+func (e *ObjectCountEvaluator) WithUsage(usageFunc UsageFunc, resourceNames ...corev1.ResourceName) *ObjectCountEvaluator {
+	e.usageFunc = usageFunc
+	e.usageResourceNames = resourceNames
+	return e
+}
+
+func (e *ObjectCountEvaluator) Constraints(required []corev1.ResourceName, item runtime.Object) error {
+	return nil
+}
+
+func (e *ObjectCountEvaluator) GroupResource() schema.GroupResource {
+	return e.groupVersionResource.GroupResource()
+}
+
+func (e *ObjectCountEvaluator) Handles(a admission.Attributes) bool {
+	if len(a.GetSubresource()) != 0 {
+		return false
+	}
+	switch a.GetOperation() {
+	case admission.Create:
+		return true
+	case admission.Update:
+		return e.usageFunc != nil
+	}
+	return false
+}
+
+func (e *ObjectCountEvaluator) Matches(resourceQuota *corev1.ResourceQuota, item runtime.Object) (bool, error) {
+	return generic.Matches(resourceQuota, item, e.MatchingResources, generic.MatchesNoScopeFunc)
+}
+
+func (e *ObjectCountEvaluator) MatchingScopes(item runtime.Object, scopes []corev1.ScopedResourceSelectorRequirement) ([]corev1.ScopedResourceSelectorRequirement, error) {
+	return []corev1.ScopedResourceSelectorRequirement{}, nil
+}
+
+func (e *ObjectCountEvaluator) UncoveredQuotaScopes(limitedScopes []corev1.ScopedResourceSelectorRequirement, matchedQuotaScopes []corev1.ScopedResourceSelectorRequirement) ([]corev1.ScopedResourceSelectorRequirement, error) {
+	return []corev1.ScopedResourceSelectorRequirement{}, nil
+}
+
+func (e *ObjectCountEvaluator) MatchingResources(input []corev1.ResourceName) []corev1.ResourceName {
+	return quota.Intersection(input, append(append([]corev1.ResourceName{}, e.resourceNames...), e.usageResourceNames...))
+}
+
+func (e *ObjectCountEvaluator) Usage(item runtime.Object) (corev1.ResourceList, error) {
+	usage := corev1.ResourceList{}
+	for _, name := range e.resourceNames {
+		usage[name] = *resource.NewQuantity(1, resource.DecimalSI)
+	}
+	if e.usageFunc == nil {
+		return usage, nil
+	}
+	objectUsage, err := e.usageFunc(item)
+	if err != nil {
+		return nil, err
+	}
+	for name, quantity := range objectUsage {
+		if quota.Contains(e.usageResourceNames, name) {
+			usage[name] = quantity
+		}
+	}
+	return usage, nil
+}
+
+func (e *ObjectCountEvaluator) UsageStats(options quota.UsageStatsOptions) (quota.UsageStats, error) {
+	listFunc := generic.ListResourceUsingListerFunc(e.listerForResource, e.groupVersionResource)
+	return generic.CalculateUsageStats(options, listFunc, generic.MatchesNoScopeFunc, e.Usage)
+}
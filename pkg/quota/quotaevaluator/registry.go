@@ -0,0 +1,120 @@
+package quotaevaluator
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	quota "k8s.io/apiserver/pkg/quota/v1"
+	"k8s.io/apiserver/pkg/quota/v1/generic"
+)
+
+// EvaluatorFactory builds the quota evaluator of a resource, listing the objects to evaluate with the listers of
+// the listerForResource function.
+type EvaluatorFactory func(listerForResource quota.ListerForResourceFunc) quota.Evaluator
+
+// EvaluatorRegistry holds the factories of the quota evaluators of additional resources, e.g. role bindings, routes
+// or custom resources, so that the products defining them can register their evaluators with the quota admission
+// plugin and controllers, which use the quota.Configuration and quota.Registry built from the factories.
+type EvaluatorRegistry struct {
+	lock      sync.RWMutex
+	factories map[schema.GroupResource]EvaluatorFactory
+}
+
+// NewEvaluatorRegistry creates a new empty EvaluatorRegistry.
+func NewEvaluatorRegistry() *EvaluatorRegistry {
+	return &EvaluatorRegistry{factories: map[schema.GroupResource]EvaluatorFactory{}}
+}
+
+// Register registers the factory of the evaluator of the resource. It fails if an evaluator is already registered
+// for the resource.
+func (r *EvaluatorRegistry) Register(groupResource schema.GroupResource, factory EvaluatorFactory) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, exists := r.factories[groupResource]; exists {
+		return fmt.Errorf("a quota evaluator is already registered for %s", groupResource)
+	}
+	r.factories[groupResource] = factory
+	return nil
+}
+
+// RegisterObjectCount registers an evaluator counting the objects of the resource: the object count evaluator of the
+// generic quota package, or an ObjectCountEvaluator when more than one additional resource name counts the objects.
+func (r *EvaluatorRegistry) RegisterObjectCount(groupVersionResource schema.GroupVersionResource, resourceNames ...string) error {
+	return r.Register(groupVersionResource.GroupResource(), func(listerForResource quota.ListerForResourceFunc) quota.Evaluator {
+		if len(resourceNames) > 1 {
+			return NewObjectCountEvaluator(groupVersionResource, listerForResource, resourceNames...)
+		}
+		var alias corev1.ResourceName
+		if len(resourceNames) == 1 {
+			alias = corev1.ResourceName(resourceNames[0])
+		}
+		listFunc := generic.ListResourceUsingListerFunc(listerForResource, groupVersionResource)
+		return generic.NewObjectCountEvaluator(groupVersionResource.GroupResource(), listFunc, alias)
+	})
+}
+
+// Registered returns the resources with a registered evaluator.
+func (r *EvaluatorRegistry) Registered() []schema.GroupResource {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.sortedLocked()
+}
+
+// Evaluators builds the registered evaluators, in the order of their resources.
+func (r *EvaluatorRegistry) Evaluators(listerForResource quota.ListerForResourceFunc) []quota.Evaluator {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var evaluators []quota.Evaluator
+	for _, groupResource := range r.sortedLocked() {
+		evaluators = append(evaluators, r.factories[groupResource](listerForResource))
+	}
+	return evaluators
+}
+
+func (r *EvaluatorRegistry) sortedLocked() []schema.GroupResource {
+	groupResources := make([]schema.GroupResource, 0, len(r.factories))
+	for groupResource := range r.factories {
+		groupResources = append(groupResources, groupResource)
+	}
+	sort.Slice(groupResources, func(i, j int) bool {
+		return groupResources[i].String() < groupResources[j].String()
+	})
+	return groupResources
+}
+
+// NewConfiguration returns the quota configuration of the base configuration, e.g. the one of the kube resources,
+// extended with the registered evaluators. The registered evaluators replace the evaluators of the base
+// configuration for the same resources, and their resources are no longer ignored. The base configuration may be nil.
+func (r *EvaluatorRegistry) NewConfiguration(base quota.Configuration, listerForResource quota.ListerForResourceFunc) quota.Configuration {
+	registered := r.Evaluators(listerForResource)
+	overridden := map[schema.GroupResource]bool{}
+	for _, evaluator := range registered {
+		overridden[evaluator.GroupResource()] = true
+	}
+
+	var evaluators []quota.Evaluator
+	ignored := map[schema.GroupResource]struct{}{}
+	if base != nil {
+		for groupResource := range base.IgnoredResources() {
+			if !overridden[groupResource] {
+				ignored[groupResource] = struct{}{}
+			}
+		}
+		for _, evaluator := range base.Evaluators() {
+			if !overridden[evaluator.GroupResource()] {
+				evaluators = append(evaluators, evaluator)
+			}
+		}
+	}
+	return generic.NewConfiguration(append(evaluators, registered...), ignored)
+}
+
+// NewRegistry returns a quota registry of the registered evaluators, e.g. for the quota usage calculations.
+func (r *EvaluatorRegistry) NewRegistry(listerForResource quota.ListerForResourceFunc) quota.Registry {
+	return generic.NewRegistry(r.Evaluators(listerForResource))
+}
@@ -0,0 +1,254 @@
+package quotaevaluator
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	quota "k8s.io/apiserver/pkg/quota/v1"
+	"k8s.io/apiserver/pkg/quota/v1/generic"
+	"k8s.io/client-go/tools/cache"
+
+	routev1 "github.com/openshift/api/route/v1"
+)
+
+var (
+	routesResource       = routev1.GroupVersion.WithResource("routes")
+	roleBindingsResource = rbacv1.SchemeGroupVersion.WithResource("rolebindings")
+)
+
+func listerForObjects(t *testing.T, objects ...runtime.Object) quota.ListerForResourceFunc {
+	return func(gvr schema.GroupVersionResource) (cache.GenericLister, error) {
+		indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		for _, obj := range objects {
+			var objectResource schema.GroupVersionResource
+			switch obj.(type) {
+			case *routev1.Route:
+				objectResource = routesResource
+			case *rbacv1.RoleBinding:
+				objectResource = roleBindingsResource
+			}
+			if objectResource != gvr {
+				continue
+			}
+			if err := indexer.Add(obj); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return cache.NewGenericLister(indexer, gvr.GroupResource()), nil
+	}
+}
+
+// fakeEvaluator stands for the evaluators of a base configuration.
+type fakeEvaluator struct {
+	quota.Evaluator
+	groupResource schema.GroupResource
+}
+
+func (e fakeEvaluator) GroupResource() schema.GroupResource {
+	return e.groupResource
+}
+
+type fakeConfiguration struct {
+	ignored    map[schema.GroupResource]struct{}
+	evaluators []quota.Evaluator
+}
+
+func (c fakeConfiguration) IgnoredResources() map[schema.GroupResource]struct{} { return c.ignored }
+func (c fakeConfiguration) Evaluators() []quota.Evaluator                       { return c.evaluators }
+
+func TestEvaluatorRegistry(t *testing.T) {
+	registry := NewEvaluatorRegistry()
+	if err := registry.RegisterObjectCount(routesResource, "openshift.io/routes"); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.RegisterObjectCount(roleBindingsResource); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.RegisterObjectCount(routesResource); err == nil {
+		t.Errorf("expected an error registering routes twice")
+	}
+	expected := []schema.GroupResource{roleBindingsResource.GroupResource(), routesResource.GroupResource()}
+	if registered := registry.Registered(); !reflect.DeepEqual(registered, expected) {
+		t.Errorf("expected %v, got %v", expected, registered)
+	}
+
+	listerForResource := listerForObjects(t)
+	podsResource := schema.GroupResource{Resource: "pods"}
+	base := fakeConfiguration{
+		ignored: map[schema.GroupResource]struct{}{
+			roleBindingsResource.GroupResource(): {},
+			{Resource: "events"}:                 {},
+		},
+		evaluators: []quota.Evaluator{
+			fakeEvaluator{groupResource: podsResource},
+			fakeEvaluator{groupResource: routesResource.GroupResource()},
+		},
+	}
+	config := registry.NewConfiguration(base, listerForResource)
+	if _, ignored := config.IgnoredResources()[roleBindingsResource.GroupResource()]; ignored {
+		t.Errorf("expected role bindings to no longer be ignored")
+	}
+	if _, ignored := config.IgnoredResources()[schema.GroupResource{Resource: "events"}]; !ignored {
+		t.Errorf("expected events to still be ignored")
+	}
+	var evaluated []schema.GroupResource
+	for _, evaluator := range config.Evaluators() {
+		evaluated = append(evaluated, evaluator.GroupResource())
+		if _, isFake := evaluator.(fakeEvaluator); isFake && evaluator.GroupResource() != podsResource {
+			t.Errorf("expected the base evaluator of %s to be replaced", evaluator.GroupResource())
+		}
+	}
+	if expected := []schema.GroupResource{podsResource, roleBindingsResource.GroupResource(), routesResource.GroupResource()}; !reflect.DeepEqual(evaluated, expected) {
+		t.Errorf("expected evaluators of %v, got %v", expected, evaluated)
+	}
+	if config := registry.NewConfiguration(nil, listerForResource); len(config.Evaluators()) != 2 {
+		t.Errorf("expected the 2 registered evaluators without base configuration, got %d", len(config.Evaluators()))
+	}
+
+	quotaRegistry := registry.NewRegistry(listerForResource)
+	if evaluator := quotaRegistry.Get(routesResource.GroupResource()); evaluator == nil {
+		t.Errorf("expected an evaluator for routes")
+	}
+	if evaluator := quotaRegistry.Get(podsResource); evaluator != nil {
+		t.Errorf("expected no evaluator for pods")
+	}
+	if evaluators := quotaRegistry.List(); len(evaluators) != 2 {
+		t.Errorf("expected 2 evaluators, got %d", len(evaluators))
+	}
+}
+
+func TestObjectCountEvaluator(t *testing.T) {
+	route := func(namespace, name string, alternateBackends int) *routev1.Route {
+		r := &routev1.Route{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+		for i := 0; i < alternateBackends; i++ {
+			r.Spec.AlternateBackends = append(r.Spec.AlternateBackends, routev1.RouteTargetReference{Name: fmt.Sprintf("backend-%d", i)})
+		}
+		return r
+	}
+	backendsResource := corev1.ResourceName("example.com/route-backends")
+	listerForResource := listerForObjects(t,
+		route("ns", "a", 0),
+		route("ns", "b", 2),
+		route("other", "c", 1),
+		&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "admin"}},
+	)
+	evaluator := NewObjectCountEvaluator(routesResource, listerForResource, "openshift.io/routes").
+		WithUsage(func(item runtime.Object) (corev1.ResourceList, error) {
+			r, ok := item.(*routev1.Route)
+			if !ok {
+				return nil, fmt.Errorf("not a route: %T", item)
+			}
+			return corev1.ResourceList{backendsResource: *resource.NewQuantity(int64(1+len(r.Spec.AlternateBackends)), resource.DecimalSI)}, nil
+		}, backendsResource)
+
+	countResource := corev1.ResourceName("count/routes.route.openshift.io")
+	if name := generic.ObjectCountQuotaResourceNameFor(routesResource.GroupResource()); name != countResource {
+		t.Errorf("expected %s, got %s", countResource, name)
+	}
+	if name := generic.ObjectCountQuotaResourceNameFor(schema.GroupResource{Resource: "secrets"}); name != "count/secrets" {
+		t.Errorf("expected count/secrets, got %s", name)
+	}
+
+	attributes := func(operation admission.Operation, subresource string) admission.Attributes {
+		return admission.NewAttributesRecord(nil, nil, routev1.GroupVersion.WithKind("Route"), "ns", "a", routesResource, subresource, operation, nil, false, nil)
+	}
+	for _, tc := range []struct {
+		attributes admission.Attributes
+		expected   bool
+	}{
+		{attributes(admission.Create, ""), true},
+		{attributes(admission.Update, ""), true},
+		{attributes(admission.Update, "status"), false},
+		{attributes(admission.Delete, ""), false},
+	} {
+		if handles := evaluator.Handles(tc.attributes); handles != tc.expected {
+			t.Errorf("expected Handles(%s %s) to be %t", tc.attributes.GetOperation(), tc.attributes.GetSubresource(), tc.expected)
+		}
+	}
+	if NewObjectCountEvaluator(routesResource, listerForResource).Handles(attributes(admission.Update, "")) {
+		t.Errorf("expected updates to be ignored without usage function")
+	}
+
+	for _, tc := range []struct {
+		name     string
+		quota    *corev1.ResourceQuota
+		expected bool
+	}{
+		{
+			name:     "count",
+			quota:    &corev1.ResourceQuota{Status: corev1.ResourceQuotaStatus{Hard: corev1.ResourceList{countResource: resource.MustParse("10")}}},
+			expected: true,
+		},
+		{
+			name:     "usage",
+			quota:    &corev1.ResourceQuota{Status: corev1.ResourceQuotaStatus{Hard: corev1.ResourceList{backendsResource: resource.MustParse("10")}}},
+			expected: true,
+		},
+		{
+			name:  "other resource",
+			quota: &corev1.ResourceQuota{Status: corev1.ResourceQuotaStatus{Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10")}}},
+		},
+		{
+			name: "scoped",
+			quota: &corev1.ResourceQuota{
+				Spec:   corev1.ResourceQuotaSpec{Scopes: []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort}},
+				Status: corev1.ResourceQuotaStatus{Hard: corev1.ResourceList{countResource: resource.MustParse("10")}},
+			},
+		},
+	} {
+		if matches, _ := evaluator.Matches(tc.quota, route("ns", "a", 0)); matches != tc.expected {
+			t.Errorf("%s: expected Matches to be %t", tc.name, tc.expected)
+		}
+	}
+
+	usage, err := evaluator.Usage(route("ns", "new", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedUsage := corev1.ResourceList{
+		countResource:         resource.MustParse("1"),
+		"openshift.io/routes": resource.MustParse("1"),
+		backendsResource:      resource.MustParse("2"),
+	}
+	if !quota.Equals(usage, expectedUsage) {
+		t.Errorf("expected usage %v, got %v", expectedUsage, usage)
+	}
+
+	// as with the generic evaluators, the usage stats are masked by the quota controller
+	statsResources := []corev1.ResourceName{countResource, backendsResource, corev1.ResourcePods}
+	stats, err := evaluator.UsageStats(quota.UsageStatsOptions{
+		Namespace: "ns",
+		Resources: statsResources,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedUsed := corev1.ResourceList{
+		countResource:       resource.MustParse("2"),
+		backendsResource:    resource.MustParse("4"),
+		corev1.ResourcePods: resource.MustParse("0"),
+	}
+	if used := quota.Mask(stats.Used, statsResources); !quota.Equals(used, expectedUsed) {
+		t.Errorf("expected used %v, got %v", expectedUsed, stats.Used)
+	}
+
+	stats, err = evaluator.UsageStats(quota.UsageStatsOptions{
+		Namespace: "ns",
+		Scopes:    []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort},
+		Resources: []corev1.ResourceName{countResource},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expectedUsed := (corev1.ResourceList{countResource: resource.MustParse("0")}); !quota.Equals(stats.Used, expectedUsed) {
+		t.Errorf("expected no usage for a scoped quota, got %v", stats.Used)
+	}
+}
@@ -0,0 +1,148 @@
+package hostassignment
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"fmt"
+	"strings"
+
+	kvalidation "k8s.io/apimachinery/pkg/util/validation"
+
+	routev1 "github.com/openshift/api/route/v1"
+)
+
+// DefaultHostnameTemplate is the template generating the same host names as SimpleAllocationPlugin.
+const DefaultHostnameTemplate = "${name}-${namespace}.${suffix}"
+
+// hostnameHashLength is the length of the hashes appended to truncated or conflicting labels.
+const hostnameHashLength = 8
+
+// RouteLister returns the existing routes the generated host names must not conflict with.
+type RouteLister func() ([]*routev1.Route, error)
+
+// HostnamePolicy generates the host names of routes from a template, e.g. ${name}.${namespace}.apps.example.com.
+// The template may use the ${name}, ${namespace} and ${suffix} variables. The labels of the host name longer than 63
+// characters are truncated and suffixed with a hash of the full label, so that the same route always gets the same
+// host name and routes whose labels only differ after the truncation get different ones. When routes are listed, a
+// host name already used by another route is made unique by suffixing its first label with a hash of the namespace
+// and name of the route.
+type HostnamePolicy struct {
+	template string
+	suffix   string
+	routes   RouteLister
+}
+
+var _ HostnameGenerator = &HostnamePolicy{}
+
+// NewHostnamePolicy creates a new HostnamePolicy generating the host names from the template, DefaultHostnameTemplate
+// when empty, and the DNS suffix, defaulting to the suffix of SimpleAllocationPlugin. The routes lister is optional,
+// without it the host names are not checked for conflicts.
+func NewHostnamePolicy(template, suffix string, routes RouteLister) (*HostnamePolicy, error) {
+	if len(template) == 0 {
+		template = DefaultHostnameTemplate
+	}
+	if len(suffix) == 0 {
+		suffix = defaultDNSSuffix
+	}
+	if errs := kvalidation.IsDNS1123Subdomain(suffix); len(errs) != 0 {
+		return nil, fmt.Errorf("invalid DNS suffix %s: %s", suffix, strings.Join(errs, ", "))
+	}
+	if !strings.Contains(template, "${name}") {
+		return nil, fmt.Errorf("invalid hostname template %s: ${name} is required", template)
+	}
+	rest := template
+	for _, variable := range []string{"${name}", "${namespace}", "${suffix}"} {
+		rest = strings.ReplaceAll(rest, variable, "")
+	}
+	if strings.Contains(rest, "${") {
+		return nil, fmt.Errorf("invalid hostname template %s: only ${name}, ${namespace} and ${suffix} are supported", template)
+	}
+	return &HostnamePolicy{template: template, suffix: suffix, routes: routes}, nil
+}
+
+// GenerateHostname generates the host name of the route from the template of the policy. As with
+// SimpleAllocationPlugin, no host name is generated for a route without name or namespace.
+func (p *HostnamePolicy) GenerateHostname(route *routev1.Route) (string, error) {
+	if len(route.Name) == 0 || len(route.Namespace) == 0 {
+		return "", nil
+	}
+	expanded := p.expand(route)
+	labels := strings.Split(expanded, ".")
+	for i, label := range labels {
+		labels[i] = truncateLabel(label, hostnameHash(label))
+	}
+	host := strings.Join(labels, ".")
+
+	if p.routes != nil {
+		conflict, err := p.hasConflict(route, host)
+		if err != nil {
+			return "", fmt.Errorf("unable to check the host name %s for conflicts: %v", host, err)
+		}
+		if conflict {
+			// the hash of the route is suffixed to the full first label, so that it does not collide with the
+			// truncation of a longer label of another route
+			routeHash := hostnameHash(route.Namespace + "/" + route.Name)
+			first := strings.SplitN(expanded, ".", 2)[0]
+			labels[0] = truncateLabel(first+"-"+routeHash, routeHash)
+			unique := strings.Join(labels, ".")
+			if conflict, err = p.hasConflict(route, unique); err != nil {
+				return "", fmt.Errorf("unable to check the host name %s for conflicts: %v", unique, err)
+			}
+			if conflict {
+				return "", fmt.Errorf("the host names %s and %s are already used by other routes", host, unique)
+			}
+			host = unique
+		}
+	}
+
+	if errs := kvalidation.IsDNS1123Subdomain(host); len(errs) != 0 {
+		return "", fmt.Errorf("the generated host name %s is not valid: %s", host, strings.Join(errs, ", "))
+	}
+	return host, nil
+}
+
+// expand returns the host name of the route before the truncation of its labels.
+func (p *HostnamePolicy) expand(route *routev1.Route) string {
+	return strings.NewReplacer(
+		"${name}", strings.ToLower(strings.ReplaceAll(route.Name, ".", "-")),
+		"${namespace}", strings.ToLower(route.Namespace),
+		"${suffix}", p.suffix,
+	).Replace(p.template)
+}
+
+// hasConflict returns whether another route than the given one already uses the host name.
+func (p *HostnamePolicy) hasConflict(route *routev1.Route, host string) (bool, error) {
+	routes, err := p.routes()
+	if err != nil {
+		return false, err
+	}
+	for _, existing := range routes {
+		if existing.Namespace == route.Namespace && existing.Name == route.Name {
+			continue
+		}
+		if strings.EqualFold(existing.Spec.Host, host) {
+			return true, nil
+		}
+		for _, ingress := range existing.Status.Ingress {
+			if strings.EqualFold(ingress.Host, host) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// truncateLabel truncates the label to the maximum length of DNS labels, replacing its end with the hash.
+func truncateLabel(label, hash string) string {
+	if len(label) <= kvalidation.DNS1123LabelMaxLength {
+		return label
+	}
+	prefix := strings.TrimRight(label[:kvalidation.DNS1123LabelMaxLength-len(hash)-1], "-")
+	return prefix + "-" + hash
+}
+
+// hostnameHash returns a short, stable and DNS label safe hash of the value.
+func hostnameHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(sum[:]))[:hostnameHashLength]
+}
@@ -0,0 +1,179 @@
+package hostassignment
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kvalidation "k8s.io/apimachinery/pkg/util/validation"
+
+	routev1 "github.com/openshift/api/route/v1"
+)
+
+func TestNewHostnamePolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		template  string
+		suffix    string
+		expectErr bool
+	}{
+		{name: "defaults"},
+		{name: "custom template", template: "${name}.${namespace}.${suffix}", suffix: "apps.example.com"},
+		{name: "literal suffix", template: "${name}.apps.example.com"},
+		{name: "missing name", template: "${namespace}.${suffix}", expectErr: true},
+		{name: "unknown variable", template: "${name}-${cluster}.${suffix}", expectErr: true},
+		{name: "invalid suffix", suffix: "bad wolf.whoswho", expectErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewHostnamePolicy(tc.template, tc.suffix, nil)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func hostRoute(namespace, name, host string) *routev1.Route {
+	return &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       routev1.RouteSpec{Host: host},
+	}
+}
+
+func TestHostnamePolicyGenerateHostname(t *testing.T) {
+	longName := strings.Repeat("a", 70)
+	tests := []struct {
+		name      string
+		template  string
+		route     *routev1.Route
+		existing  []*routev1.Route
+		listErr   error
+		expected  string
+		check     func(t *testing.T, host string)
+		expectErr bool
+	}{
+		{
+			name:     "default template",
+			route:    hostRoute("ns", "my.route", ""),
+			expected: "my-route-ns.apps.example.com",
+		},
+		{
+			name:     "custom template",
+			template: "${name}.${namespace}.${suffix}",
+			route:    hostRoute("NS", "Route", ""),
+			expected: "route.ns.apps.example.com",
+		},
+		{
+			name:  "no namespace",
+			route: hostRoute("", "route", ""),
+		},
+		{
+			name:  "long label is truncated",
+			route: hostRoute("ns", longName, ""),
+			check: func(t *testing.T, host string) {
+				label := strings.SplitN(host, ".", 2)[0]
+				if len(label) != kvalidation.DNS1123LabelMaxLength {
+					t.Errorf("expected a label of %d characters, got %q", kvalidation.DNS1123LabelMaxLength, label)
+				}
+				if expected := strings.Repeat("a", 54) + "-" + hostnameHash(longName+"-ns"); label != expected {
+					t.Errorf("expected label %q, got %q", expected, label)
+				}
+			},
+		},
+		{
+			name:  "labels differing after the truncation get different host names",
+			route: hostRoute("ns", longName+"b", ""),
+			check: func(t *testing.T, host string) {
+				other, _ := NewHostnamePolicy("", "apps.example.com", nil)
+				otherHost, _ := other.GenerateHostname(hostRoute("ns", longName+"c", ""))
+				if host == otherHost {
+					t.Errorf("expected different host names, got %s for both", host)
+				}
+			},
+		},
+		{
+			name:     "own route is not a conflict",
+			route:    hostRoute("ns", "route", ""),
+			existing: []*routev1.Route{hostRoute("ns", "route", "route-ns.apps.example.com")},
+			expected: "route-ns.apps.example.com",
+		},
+		{
+			// a-b in namespace c and a in namespace b-c both generate a-b-c
+			name:     "conflict with another route",
+			route:    hostRoute("c", "a-b", ""),
+			existing: []*routev1.Route{hostRoute("b-c", "a", "a-b-c.apps.example.com")},
+			expected: "a-b-c-" + hostnameHash("c/a-b") + ".apps.example.com",
+		},
+		{
+			name:  "conflict with the status of another route",
+			route: hostRoute("c", "a-b", ""),
+			existing: []*routev1.Route{{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "b-c", Name: "a"},
+				Status:     routev1.RouteStatus{Ingress: []routev1.RouteIngress{{Host: "a-b-c.apps.example.com"}}},
+			}},
+			expected: "a-b-c-" + hostnameHash("c/a-b") + ".apps.example.com",
+		},
+		{
+			name:  "conflict of a truncated label",
+			route: hostRoute("ns", longName, ""),
+			existing: []*routev1.Route{
+				hostRoute("other", "route", strings.Repeat("a", 54)+"-"+hostnameHash(longName+"-ns")+".apps.example.com"),
+			},
+			expected: strings.Repeat("a", 54) + "-" + hostnameHash("ns/"+longName) + ".apps.example.com",
+		},
+		{
+			name:  "unresolvable conflict",
+			route: hostRoute("c", "a-b", ""),
+			existing: []*routev1.Route{
+				hostRoute("b-c", "a", "a-b-c.apps.example.com"),
+				hostRoute("other", "route", "a-b-c-"+hostnameHash("c/a-b")+".apps.example.com"),
+			},
+			expectErr: true,
+		},
+		{
+			name:      "list error",
+			route:     hostRoute("ns", "route", ""),
+			listErr:   fmt.Errorf("cache not synced"),
+			expectErr: true,
+		},
+		{
+			name:      "host name too long",
+			template:  "${name}." + strings.Repeat(strings.Repeat("b", 63)+".", 4) + "${suffix}",
+			route:     hostRoute("ns", "route", ""),
+			expectErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var lister RouteLister
+			if tc.existing != nil || tc.listErr != nil {
+				lister = func() ([]*routev1.Route, error) { return tc.existing, tc.listErr }
+			}
+			policy, err := NewHostnamePolicy(tc.template, "apps.example.com", lister)
+			if err != nil {
+				t.Fatal(err)
+			}
+			host, err := policy.GenerateHostname(tc.route)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %t, got %v", tc.expectErr, err)
+			}
+			if tc.expectErr {
+				return
+			}
+			if len(host) > 0 {
+				if errs := kvalidation.IsDNS1123Subdomain(host); len(errs) != 0 {
+					t.Errorf("generated host name %s is not valid: %v", host, errs)
+				}
+			}
+			if tc.check != nil {
+				tc.check(t, host)
+				return
+			}
+			if host != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, host)
+			}
+		})
+	}
+}
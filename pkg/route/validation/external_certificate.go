@@ -0,0 +1,272 @@
+package validation
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	routev1 "github.com/openshift/api/route/v1"
+)
+
+// CertificateFindingReason identifies a problem of an external certificate.
+type CertificateFindingReason string
+
+const (
+	CertificateSecretInvalid       CertificateFindingReason = "SecretInvalid"
+	CertificateParseFailed         CertificateFindingReason = "CertificateParseFailed"
+	CertificateKeyParseFailed      CertificateFindingReason = "KeyParseFailed"
+	CertificateKeyMismatch         CertificateFindingReason = "KeyMismatch"
+	CertificateChainInvalid        CertificateFindingReason = "ChainInvalid"
+	CertificateHostMismatch        CertificateFindingReason = "HostMismatch"
+	CertificateNotYetValid         CertificateFindingReason = "NotYetValid"
+	CertificateExpired             CertificateFindingReason = "Expired"
+	CertificateExpiring            CertificateFindingReason = "Expiring"
+	CertificateTerminationMismatch CertificateFindingReason = "TerminationMismatch"
+)
+
+// CertificateFindingSeverity is the severity of a finding: errors reject the certificate, warnings do not.
+type CertificateFindingSeverity string
+
+const (
+	CertificateFindingError   CertificateFindingSeverity = "Error"
+	CertificateFindingWarning CertificateFindingSeverity = "Warning"
+)
+
+// CertificateFinding is a problem found validating an external certificate.
+type CertificateFinding struct {
+	Reason   CertificateFindingReason
+	Severity CertificateFindingSeverity
+	// Field is the field of the secret or the route the finding is about.
+	Field   *field.Path
+	Message string
+}
+
+// CertificateFindings are the findings of the validation of an external certificate.
+type CertificateFindings []CertificateFinding
+
+// HasErrors returns whether the certificate must be rejected.
+func (f CertificateFindings) HasErrors() bool {
+	for _, finding := range f {
+		if finding.Severity == CertificateFindingError {
+			return true
+		}
+	}
+	return false
+}
+
+// Errors returns the findings with the error severity as a field error list, e.g. to reject the route at admission.
+func (f CertificateFindings) Errors() field.ErrorList {
+	var errs field.ErrorList
+	for _, finding := range f {
+		if finding.Severity == CertificateFindingError {
+			errs = append(errs, field.Invalid(finding.Field, "redacted certificate data", fmt.Sprintf("%s: %s", finding.Reason, finding.Message)))
+		}
+	}
+	return errs
+}
+
+// Warnings returns the messages of the findings with the warning severity.
+func (f CertificateFindings) Warnings() []string {
+	var warnings []string
+	for _, finding := range f {
+		if finding.Severity == CertificateFindingWarning {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", finding.Field, finding.Message))
+		}
+	}
+	return warnings
+}
+
+// ExternalCertificateOptions configures the validation of external certificates.
+type ExternalCertificateOptions struct {
+	// Roots are the trusted certificate authorities the chain is verified against. When nil, the chain is only
+	// checked to be consistent, each certificate being signed by the next one, as the router serves the chain
+	// regardless of the trust of its clients.
+	Roots *x509.CertPool
+	// ExpiryWarningThreshold is the remaining validity below which a warning is reported, 30 days when zero.
+	ExpiryWarningThreshold time.Duration
+	// Now returns the current time, time.Now when nil.
+	Now func() time.Time
+}
+
+const defaultExpiryWarningThreshold = 30 * 24 * time.Hour
+
+// ValidateExternalCertificate validates the TLS secret referenced by the route as its certificate: the secret must
+// hold a PEM certificate chain and its private key, the leaf certificate must match the key and the host of the
+// route, be currently valid, and be followed by its intermediate certificates. The findings of the validation are
+// returned, the certificate being invalid when they have errors.
+func ValidateExternalCertificate(route *routev1.Route, secret *corev1.Secret, options ExternalCertificateOptions) CertificateFindings {
+	var findings CertificateFindings
+	addError := func(reason CertificateFindingReason, fldPath *field.Path, format string, args ...interface{}) {
+		findings = append(findings, CertificateFinding{Reason: reason, Severity: CertificateFindingError, Field: fldPath, Message: fmt.Sprintf(format, args...)})
+	}
+	secretPath := field.NewPath("secret").Child("data")
+	certPath := secretPath.Key(corev1.TLSCertKey)
+	keyPath := secretPath.Key(corev1.TLSPrivateKeyKey)
+	hostPath := field.NewPath("spec", "host")
+
+	if route.Spec.TLS == nil || route.Spec.TLS.Termination == routev1.TLSTerminationPassthrough {
+		addError(CertificateTerminationMismatch, field.NewPath("spec", "tls", "termination"), "external certificates require edge or reencrypt termination")
+	}
+	if secret.Type != corev1.SecretTypeTLS {
+		addError(CertificateSecretInvalid, field.NewPath("secret", "type"), "the secret %s/%s must be of type %s", secret.Namespace, secret.Name, corev1.SecretTypeTLS)
+	}
+	certData, keyData := secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey]
+	if len(certData) == 0 {
+		addError(CertificateSecretInvalid, certPath, "the secret %s/%s has no certificate", secret.Namespace, secret.Name)
+	}
+	if len(keyData) == 0 {
+		addError(CertificateSecretInvalid, keyPath, "the secret %s/%s has no private key", secret.Namespace, secret.Name)
+	}
+	if len(certData) == 0 {
+		return findings
+	}
+
+	chain, err := parseCertificateChain(certData)
+	if err != nil {
+		addError(CertificateParseFailed, certPath, "%v", err)
+		return findings
+	}
+	leaf := chain[0]
+
+	if len(keyData) > 0 {
+		if _, err := tls.X509KeyPair(certData, keyData); err != nil {
+			if block, _ := pem.Decode(keyData); block == nil || !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+				addError(CertificateKeyParseFailed, keyPath, "the private key is not a PEM encoded private key")
+			} else {
+				addError(CertificateKeyMismatch, keyPath, "the private key does not match the certificate: %v", err)
+			}
+		}
+	}
+
+	findings = append(findings, validateChain(chain, certPath, options.Roots)...)
+
+	if host := route.Spec.Host; len(host) > 0 {
+		checkHost := host
+		if route.Spec.WildcardPolicy == routev1.WildcardPolicySubdomain {
+			// the certificate must cover all the subdomains of the parent domain of the host
+			if i := strings.Index(host, "."); i != -1 {
+				checkHost = "*" + host[i:]
+			}
+		}
+		if !certificateCoversHost(leaf, checkHost) {
+			addError(CertificateHostMismatch, hostPath, "the certificate is not valid for %s, it is valid for %s", checkHost, describeCertificateNames(leaf))
+		}
+	}
+
+	now := time.Now
+	if options.Now != nil {
+		now = options.Now
+	}
+	threshold := options.ExpiryWarningThreshold
+	if threshold == 0 {
+		threshold = defaultExpiryWarningThreshold
+	}
+	current := now()
+	switch {
+	case current.Before(leaf.NotBefore):
+		addError(CertificateNotYetValid, certPath, "the certificate is not valid before %s", leaf.NotBefore.UTC().Format(time.RFC3339))
+	case current.After(leaf.NotAfter):
+		addError(CertificateExpired, certPath, "the certificate expired at %s", leaf.NotAfter.UTC().Format(time.RFC3339))
+	case leaf.NotAfter.Sub(current) < threshold:
+		findings = append(findings, CertificateFinding{
+			Reason:   CertificateExpiring,
+			Severity: CertificateFindingWarning,
+			Field:    certPath,
+			Message:  fmt.Sprintf("the certificate expires at %s", leaf.NotAfter.UTC().Format(time.RFC3339)),
+		})
+	}
+	return findings
+}
+
+// parseCertificateChain parses the PEM certificates of the data, the leaf certificate first.
+func parseCertificateChain(data []byte) ([]*x509.Certificate, error) {
+	var chain []*x509.Certificate
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("unexpected PEM block %q, expected only certificates", block.Type)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("certificate %d of the chain is not valid: %v", len(chain)+1, err)
+		}
+		chain = append(chain, cert)
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+	return chain, nil
+}
+
+// validateChain checks that each certificate of the chain is signed by the next one, and that the chain verifies
+// against the roots when they are set.
+func validateChain(chain []*x509.Certificate, fldPath *field.Path, roots *x509.CertPool) CertificateFindings {
+	var findings CertificateFindings
+	for i := 0; i+1 < len(chain); i++ {
+		if err := chain[i].CheckSignatureFrom(chain[i+1]); err != nil {
+			findings = append(findings, CertificateFinding{
+				Reason:   CertificateChainInvalid,
+				Severity: CertificateFindingError,
+				Field:    fldPath,
+				Message:  fmt.Sprintf("certificate %d (%s) is not signed by certificate %d (%s) of the chain: %v", i+1, chain[i].Subject, i+2, chain[i+1].Subject, err),
+			})
+		}
+	}
+	if roots == nil {
+		return findings
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	// the validity period is reported on its own
+	if _, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   chain[0].NotBefore.Add(chain[0].NotAfter.Sub(chain[0].NotBefore) / 2),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}); err != nil {
+		findings = append(findings, CertificateFinding{
+			Reason:   CertificateChainInvalid,
+			Severity: CertificateFindingError,
+			Field:    fldPath,
+			Message:  fmt.Sprintf("the certificate chain is not trusted: %v", err),
+		})
+	}
+	return findings
+}
+
+// certificateCoversHost returns whether the DNS names of the certificate cover the host, which may be a wildcard.
+func certificateCoversHost(cert *x509.Certificate, host string) bool {
+	if !strings.HasPrefix(host, "*.") {
+		return cert.VerifyHostname(host) == nil
+	}
+	for _, name := range cert.DNSNames {
+		if strings.EqualFold(name, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func describeCertificateNames(cert *x509.Certificate) string {
+	var names []string
+	names = append(names, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	if len(names) == 0 {
+		return "no host names"
+	}
+	return strings.Join(names, ", ")
+}
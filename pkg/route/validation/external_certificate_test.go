@@ -0,0 +1,197 @@
+package validation
+
+import (
+	"crypto/x509"
+	"sort"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	routev1 "github.com/openshift/api/route/v1"
+
+	"github.com/openshift/library-go/pkg/crypto"
+)
+
+func TestValidateExternalCertificate(t *testing.T) {
+	rootConfig, err := crypto.MakeSelfSignedCAConfigForDuration("root", 2*365*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := &crypto.CA{Config: rootConfig, SerialGenerator: &crypto.RandomSerialGenerator{}}
+	intermediateConfig, err := crypto.MakeCAConfigForDuration("intermediate", 2*365*24*time.Hour, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	intermediate := &crypto.CA{Config: intermediateConfig, SerialGenerator: &crypto.RandomSerialGenerator{}}
+	otherConfig, err := crypto.MakeSelfSignedCAConfigForDuration("other", 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverCert := func(lifetime time.Duration, hosts ...string) *crypto.TLSCertificateConfig {
+		cert, err := intermediate.MakeServerCertForDuration(sets.NewString(hosts...), lifetime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+	secretFor := func(cert *crypto.TLSCertificateConfig) *corev1.Secret {
+		certPEM, keyPEM, err := cert.GetPEMBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "cert", Namespace: "test"},
+			Type:       corev1.SecretTypeTLS,
+			Data:       map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: keyPEM},
+		}
+	}
+	routeFor := func(host string, termination routev1.TLSTerminationType, policy routev1.WildcardPolicyType) *routev1.Route {
+		return &routev1.Route{Spec: routev1.RouteSpec{Host: host, WildcardPolicy: policy, TLS: &routev1.TLSConfig{Termination: termination}}}
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(rootConfig.Certs[0])
+
+	valid := serverCert(365*24*time.Hour, "www.example.com", "*.apps.example.com")
+	expiring := serverCert(time.Hour, "www.example.com")
+	mismatchedKey := secretFor(valid)
+	mismatchedKey.Data[corev1.TLSPrivateKeyKey] = secretFor(expiring).Data[corev1.TLSPrivateKeyKey]
+	brokenChain := secretFor(valid)
+	otherPEM, _, _ := otherConfig.GetPEMBytes()
+	leafPEM, _ := crypto.EncodeCertificates(valid.Certs[0])
+	brokenChain.Data[corev1.TLSCertKey] = append(leafPEM, otherPEM...)
+	opaque := secretFor(valid)
+	opaque.Type = corev1.SecretTypeOpaque
+	notPEM := secretFor(valid)
+	notPEM.Data[corev1.TLSCertKey] = []byte("not a certificate")
+	delete(notPEM.Data, corev1.TLSPrivateKeyKey)
+
+	tests := []struct {
+		name     string
+		route    *routev1.Route
+		secret   *corev1.Secret
+		options  ExternalCertificateOptions
+		errors   []CertificateFindingReason
+		warnings []CertificateFindingReason
+	}{
+		{
+			name:    "valid",
+			route:   routeFor("www.example.com", routev1.TLSTerminationEdge, routev1.WildcardPolicyNone),
+			secret:  secretFor(valid),
+			options: ExternalCertificateOptions{Roots: roots},
+		},
+		{
+			name:   "wildcard route",
+			route:  routeFor("wildcard.apps.example.com", routev1.TLSTerminationReencrypt, routev1.WildcardPolicySubdomain),
+			secret: secretFor(valid),
+		},
+		{
+			name:   "wildcard route without wildcard certificate",
+			route:  routeFor("www.example.com", routev1.TLSTerminationEdge, routev1.WildcardPolicySubdomain),
+			secret: secretFor(valid),
+			errors: []CertificateFindingReason{CertificateHostMismatch},
+		},
+		{
+			name:   "host mismatch",
+			route:  routeFor("other.example.com", routev1.TLSTerminationEdge, routev1.WildcardPolicyNone),
+			secret: secretFor(valid),
+			errors: []CertificateFindingReason{CertificateHostMismatch},
+		},
+		{
+			name:   "passthrough",
+			route:  routeFor("www.example.com", routev1.TLSTerminationPassthrough, routev1.WildcardPolicyNone),
+			secret: secretFor(valid),
+			errors: []CertificateFindingReason{CertificateTerminationMismatch},
+		},
+		{
+			name:     "expiring",
+			route:    routeFor("www.example.com", routev1.TLSTerminationEdge, routev1.WildcardPolicyNone),
+			secret:   secretFor(expiring),
+			warnings: []CertificateFindingReason{CertificateExpiring},
+		},
+		{
+			name:    "expired",
+			route:   routeFor("www.example.com", routev1.TLSTerminationEdge, routev1.WildcardPolicyNone),
+			secret:  secretFor(expiring),
+			options: ExternalCertificateOptions{Now: func() time.Time { return time.Now().Add(2 * time.Hour) }},
+			errors:  []CertificateFindingReason{CertificateExpired},
+		},
+		{
+			name:    "not yet valid",
+			route:   routeFor("www.example.com", routev1.TLSTerminationEdge, routev1.WildcardPolicyNone),
+			secret:  secretFor(valid),
+			options: ExternalCertificateOptions{Now: func() time.Time { return time.Now().Add(-2 * time.Hour) }},
+			errors:  []CertificateFindingReason{CertificateNotYetValid},
+		},
+		{
+			name:   "key mismatch",
+			route:  routeFor("www.example.com", routev1.TLSTerminationEdge, routev1.WildcardPolicyNone),
+			secret: mismatchedKey,
+			errors: []CertificateFindingReason{CertificateKeyMismatch},
+		},
+		{
+			name:    "broken chain",
+			route:   routeFor("www.example.com", routev1.TLSTerminationEdge, routev1.WildcardPolicyNone),
+			secret:  brokenChain,
+			options: ExternalCertificateOptions{Roots: roots},
+			errors:  []CertificateFindingReason{CertificateChainInvalid, CertificateChainInvalid},
+		},
+		{
+			name:    "untrusted chain",
+			route:   routeFor("www.example.com", routev1.TLSTerminationEdge, routev1.WildcardPolicyNone),
+			secret:  secretFor(valid),
+			options: ExternalCertificateOptions{Roots: x509.NewCertPool()},
+			errors:  []CertificateFindingReason{CertificateChainInvalid},
+		},
+		{
+			name:   "not a TLS secret",
+			route:  routeFor("www.example.com", routev1.TLSTerminationEdge, routev1.WildcardPolicyNone),
+			secret: opaque,
+			errors: []CertificateFindingReason{CertificateSecretInvalid},
+		},
+		{
+			name:   "invalid certificate without key",
+			route:  routeFor("www.example.com", routev1.TLSTerminationEdge, routev1.WildcardPolicyNone),
+			secret: notPEM,
+			errors: []CertificateFindingReason{CertificateParseFailed, CertificateSecretInvalid},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			findings := ValidateExternalCertificate(test.route, test.secret, test.options)
+			var errs, warnings []CertificateFindingReason
+			for _, finding := range findings {
+				if finding.Severity == CertificateFindingError {
+					errs = append(errs, finding.Reason)
+				} else {
+					warnings = append(warnings, finding.Reason)
+				}
+			}
+			sort.Slice(errs, func(i, j int) bool { return errs[i] < errs[j] })
+			if !equalReasons(errs, test.errors) {
+				t.Errorf("expected errors %v, got %v", test.errors, findings)
+			}
+			if !equalReasons(warnings, test.warnings) {
+				t.Errorf("expected warnings %v, got %v", test.warnings, findings)
+			}
+			if findings.HasErrors() != (len(test.errors) > 0) || len(findings.Errors()) != len(test.errors) || len(findings.Warnings()) != len(test.warnings) {
+				t.Errorf("unexpected errors %v and warnings %v", findings.Errors(), findings.Warnings())
+			}
+		})
+	}
+}
+
+func equalReasons(a, b []CertificateFindingReason) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
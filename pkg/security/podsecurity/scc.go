@@ -0,0 +1,232 @@
+package podsecurity
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	securityv1 "github.com/openshift/api/security/v1"
+)
+
+// Level is a level of the Pod Security Standards, as set on the pod-security.kubernetes.io labels of the namespaces.
+type Level string
+
+const (
+	LevelPrivileged Level = "privileged"
+	LevelBaseline   Level = "baseline"
+	LevelRestricted Level = "restricted"
+)
+
+// levelOrder orders the levels from the most restrictive to the least restrictive.
+var levelOrder = map[Level]int{
+	LevelRestricted: 0,
+	LevelBaseline:   1,
+	LevelPrivileged: 2,
+}
+
+// LessRestrictiveThan returns whether the level allows more than the other level.
+func (l Level) LessRestrictiveThan(other Level) bool {
+	return levelOrder[l] > levelOrder[other]
+}
+
+// Incompatibility is a permission of a SecurityContextConstraints that the pods of a level are not allowed.
+type Incompatibility struct {
+	// Level is the most permissive level that does not allow the permission, the more restrictive levels not
+	// allowing it either.
+	Level Level
+	// Field is the field of the SecurityContextConstraints granting the permission.
+	Field   *field.Path
+	Message string
+}
+
+func (i Incompatibility) String() string {
+	return fmt.Sprintf("%s: %s (%s)", i.Field, i.Message, i.Level)
+}
+
+// Report is the mapping of a SecurityContextConstraints to the Pod Security Standards.
+type Report struct {
+	// Name is the name of the SecurityContextConstraints.
+	Name string
+	// Level is the most restrictive level allowing all the pods admitted by the SecurityContextConstraints.
+	Level Level
+	// Incompatibilities are the permissions of the SecurityContextConstraints that prevent a more restrictive level.
+	Incompatibilities []Incompatibility
+}
+
+// IncompatibleWith returns the permissions of the SecurityContextConstraints that the pods of the level are not
+// allowed.
+func (r *Report) IncompatibleWith(level Level) []Incompatibility {
+	var incompatibilities []Incompatibility
+	for _, incompatibility := range r.Incompatibilities {
+		if !level.LessRestrictiveThan(incompatibility.Level) {
+			incompatibilities = append(incompatibilities, incompatibility)
+		}
+	}
+	return incompatibilities
+}
+
+var (
+	// baselineCapabilities are the capabilities the baseline level allows to add.
+	baselineCapabilities = sets.NewString("AUDIT_WRITE", "CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID", "KILL", "MKNOD",
+		"NET_BIND_SERVICE", "SETFCAP", "SETGID", "SETPCAP", "SETUID", "SYS_CHROOT")
+	// restrictedCapabilities are the capabilities the restricted level allows to add.
+	restrictedCapabilities = sets.NewString("NET_BIND_SERVICE")
+	// restrictedVolumes are the volume types the restricted level allows.
+	restrictedVolumes = sets.NewString(string(securityv1.FSTypeConfigMap), string(securityv1.FSTypeCSI),
+		string(securityv1.FSTypeDownwardAPI), string(securityv1.FSTypeEmptyDir), string(securityv1.FSTypeEphemeral),
+		string(securityv1.FSTypePersistentVolumeClaim), string(securityv1.FSProjected), string(securityv1.FSTypeSecret),
+		string(securityv1.FSTypeNone))
+	// baselineSELinuxTypes are the SELinux types the baseline level allows.
+	baselineSELinuxTypes = sets.NewString("", "container_t", "container_init_t", "container_kvm_t", "container_engine_t")
+)
+
+// MapSecurityContextConstraints maps the SecurityContextConstraints to the most restrictive level of the Pod Security
+// Standards allowing all the pods it admits, e.g. to label the namespaces whose service accounts can use it. The
+// report lists each permission of the SecurityContextConstraints that is incompatible with a more restrictive level.
+func MapSecurityContextConstraints(scc *securityv1.SecurityContextConstraints) *Report {
+	r := &Report{Name: scc.Name, Level: LevelRestricted}
+	add := func(level Level, fldPath *field.Path, format string, args ...interface{}) {
+		r.Incompatibilities = append(r.Incompatibilities, Incompatibility{Level: level, Field: fldPath, Message: fmt.Sprintf(format, args...)})
+		if level == LevelBaseline {
+			r.Level = LevelPrivileged
+		} else if r.Level == LevelRestricted {
+			r.Level = LevelBaseline
+		}
+	}
+
+	// host namespaces and privileged containers
+	if scc.AllowPrivilegedContainer {
+		add(LevelBaseline, field.NewPath("allowPrivilegedContainer"), "privileged containers are allowed")
+	}
+	if scc.AllowHostNetwork {
+		add(LevelBaseline, field.NewPath("allowHostNetwork"), "the host network is allowed")
+	}
+	if scc.AllowHostPID {
+		add(LevelBaseline, field.NewPath("allowHostPID"), "the host PID namespace is allowed")
+	}
+	if scc.AllowHostIPC {
+		add(LevelBaseline, field.NewPath("allowHostIPC"), "the host IPC namespace is allowed")
+	}
+	if scc.AllowHostPorts {
+		add(LevelBaseline, field.NewPath("allowHostPorts"), "host ports are allowed")
+	}
+
+	// capabilities
+	for _, capabilities := range []struct {
+		fldPath      *field.Path
+		capabilities []corev1.Capability
+	}{
+		{field.NewPath("defaultAddCapabilities"), scc.DefaultAddCapabilities},
+		{field.NewPath("allowedCapabilities"), scc.AllowedCapabilities},
+	} {
+		var notBaseline, notRestricted []string
+		for _, capability := range capabilities.capabilities {
+			name := strings.TrimPrefix(strings.ToUpper(string(capability)), "CAP_")
+			switch {
+			case name == "*" || !baselineCapabilities.Has(name):
+				notBaseline = append(notBaseline, string(capability))
+			case !restrictedCapabilities.Has(name):
+				notRestricted = append(notRestricted, string(capability))
+			}
+		}
+		if len(notBaseline) > 0 {
+			add(LevelBaseline, capabilities.fldPath, "the capabilities %s are allowed", strings.Join(notBaseline, ", "))
+		}
+		if len(notRestricted) > 0 {
+			add(LevelRestricted, capabilities.fldPath, "the capabilities %s are allowed", strings.Join(notRestricted, ", "))
+		}
+	}
+	dropsAll := false
+	for _, capability := range scc.RequiredDropCapabilities {
+		if strings.EqualFold(string(capability), "ALL") {
+			dropsAll = true
+		}
+	}
+	if !dropsAll {
+		add(LevelRestricted, field.NewPath("requiredDropCapabilities"), "dropping all the capabilities is not required")
+	}
+
+	// volumes
+	volumes := sets.NewString()
+	for _, volume := range scc.Volumes {
+		volumes.Insert(string(volume))
+	}
+	switch {
+	case volumes.Has(string(securityv1.FSTypeAll)):
+		add(LevelBaseline, field.NewPath("volumes"), "all the volume types, including host paths, are allowed")
+	case scc.AllowHostDirVolumePlugin || volumes.Has(string(securityv1.FSTypeHostPath)):
+		add(LevelBaseline, field.NewPath("allowHostDirVolumePlugin"), "host path volumes are allowed")
+	}
+	if notRestricted := volumes.Difference(restrictedVolumes).Delete(string(securityv1.FSTypeAll), string(securityv1.FSTypeHostPath)); notRestricted.Len() > 0 {
+		add(LevelRestricted, field.NewPath("volumes"), "the volume types %s are allowed", strings.Join(notRestricted.List(), ", "))
+	}
+
+	// privilege escalation
+	if scc.AllowPrivilegeEscalation == nil || *scc.AllowPrivilegeEscalation {
+		add(LevelRestricted, field.NewPath("allowPrivilegeEscalation"), "privilege escalation is allowed")
+	}
+
+	// users
+	switch runAsUser := scc.RunAsUser; runAsUser.Type {
+	case securityv1.RunAsUserStrategyMustRunAs:
+		if runAsUser.UID == nil || *runAsUser.UID == 0 {
+			add(LevelRestricted, field.NewPath("runAsUser", "uid"), "containers may run as root")
+		}
+	case securityv1.RunAsUserStrategyMustRunAsRange:
+		if runAsUser.UIDRangeMin != nil && *runAsUser.UIDRangeMin == 0 {
+			add(LevelRestricted, field.NewPath("runAsUser", "uidRangeMin"), "containers may run as root")
+		}
+	case securityv1.RunAsUserStrategyMustRunAsNonRoot:
+	default:
+		add(LevelRestricted, field.NewPath("runAsUser", "type"), "containers may run as any user, including root")
+	}
+
+	// SELinux
+	switch seLinux := scc.SELinuxContext; seLinux.Type {
+	case securityv1.SELinuxStrategyMustRunAs:
+		if options := seLinux.SELinuxOptions; options != nil {
+			if !baselineSELinuxTypes.Has(options.Type) {
+				add(LevelBaseline, field.NewPath("seLinuxContext", "seLinuxOptions", "type"), "the SELinux type %s is allowed", options.Type)
+			}
+			if len(options.User) > 0 || len(options.Role) > 0 {
+				add(LevelBaseline, field.NewPath("seLinuxContext", "seLinuxOptions"), "custom SELinux users and roles are allowed")
+			}
+		}
+	default:
+		add(LevelBaseline, field.NewPath("seLinuxContext", "type"), "any SELinux context is allowed")
+	}
+
+	// seccomp
+	if len(scc.SeccompProfiles) == 0 {
+		add(LevelRestricted, field.NewPath("seccompProfiles"), "containers may run without seccomp profile")
+	}
+	for _, profile := range scc.SeccompProfiles {
+		if profile == "*" || profile == "unconfined" || profile == string(corev1.SeccompProfileTypeUnconfined) {
+			add(LevelBaseline, field.NewPath("seccompProfiles"), "containers may run unconfined by seccomp")
+			break
+		}
+	}
+
+	// sysctls
+	if len(scc.AllowedUnsafeSysctls) > 0 {
+		add(LevelBaseline, field.NewPath("allowedUnsafeSysctls"), "the unsafe sysctls %s are allowed", strings.Join(scc.AllowedUnsafeSysctls, ", "))
+	}
+
+	return r
+}
+
+// LeastRestrictiveLevel returns the least restrictive level of the reports, e.g. the level a namespace must be
+// labeled with for the pods of all the SecurityContextConstraints its service accounts can use, or the restricted
+// level without report.
+func LeastRestrictiveLevel(reports ...*Report) Level {
+	level := LevelRestricted
+	for _, report := range reports {
+		if report.Level.LessRestrictiveThan(level) {
+			level = report.Level
+		}
+	}
+	return level
+}
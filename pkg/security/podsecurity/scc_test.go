@@ -0,0 +1,183 @@
+package podsecurity
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	securityv1 "github.com/openshift/api/security/v1"
+)
+
+// restrictedV2 is the restricted-v2 SecurityContextConstraints of OpenShift.
+func restrictedV2() *securityv1.SecurityContextConstraints {
+	return &securityv1.SecurityContextConstraints{
+		ObjectMeta:               metav1.ObjectMeta{Name: "restricted-v2"},
+		AllowedCapabilities:      []corev1.Capability{"NET_BIND_SERVICE"},
+		RequiredDropCapabilities: []corev1.Capability{"ALL"},
+		AllowPrivilegeEscalation: pointer.Bool(false),
+		Volumes: []securityv1.FSType{"configMap", "csi", "downwardAPI", "emptyDir", "ephemeral", "persistentVolumeClaim",
+			"projected", "secret"},
+		RunAsUser:       securityv1.RunAsUserStrategyOptions{Type: securityv1.RunAsUserStrategyMustRunAsRange},
+		SELinuxContext:  securityv1.SELinuxContextStrategyOptions{Type: securityv1.SELinuxStrategyMustRunAs},
+		SeccompProfiles: []string{"runtime/default"},
+	}
+}
+
+func TestMapSecurityContextConstraints(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(scc *securityv1.SecurityContextConstraints)
+		// expectedLevel is the level of the SecurityContextConstraints
+		expectedLevel Level
+		// expectedFields are the fields of the incompatibilities
+		expectedFields []string
+	}{
+		{
+			name:          "restricted-v2",
+			modify:        func(scc *securityv1.SecurityContextConstraints) {},
+			expectedLevel: LevelRestricted,
+		},
+		{
+			name: "restricted",
+			modify: func(scc *securityv1.SecurityContextConstraints) {
+				scc.AllowedCapabilities = nil
+				scc.RequiredDropCapabilities = []corev1.Capability{"KILL", "MKNOD", "SETUID", "SETGID"}
+				scc.AllowPrivilegeEscalation = nil
+				scc.SeccompProfiles = nil
+			},
+			expectedLevel:  LevelBaseline,
+			expectedFields: []string{"requiredDropCapabilities", "allowPrivilegeEscalation", "seccompProfiles"},
+		},
+		{
+			name: "anyuid",
+			modify: func(scc *securityv1.SecurityContextConstraints) {
+				scc.RunAsUser.Type = securityv1.RunAsUserStrategyRunAsAny
+			},
+			expectedLevel:  LevelBaseline,
+			expectedFields: []string{"runAsUser.type"},
+		},
+		{
+			name: "root uid",
+			modify: func(scc *securityv1.SecurityContextConstraints) {
+				scc.RunAsUser = securityv1.RunAsUserStrategyOptions{Type: securityv1.RunAsUserStrategyMustRunAs, UID: pointer.Int64(0)}
+			},
+			expectedLevel:  LevelBaseline,
+			expectedFields: []string{"runAsUser.uid"},
+		},
+		{
+			name: "baseline capabilities",
+			modify: func(scc *securityv1.SecurityContextConstraints) {
+				scc.DefaultAddCapabilities = []corev1.Capability{"CHOWN", "CAP_NET_BIND_SERVICE"}
+			},
+			expectedLevel:  LevelBaseline,
+			expectedFields: []string{"defaultAddCapabilities"},
+		},
+		{
+			name: "nfs volumes",
+			modify: func(scc *securityv1.SecurityContextConstraints) {
+				scc.Volumes = append(scc.Volumes, securityv1.FSTypeNFS)
+			},
+			expectedLevel:  LevelBaseline,
+			expectedFields: []string{"volumes"},
+		},
+		{
+			name: "hostnetwork",
+			modify: func(scc *securityv1.SecurityContextConstraints) {
+				scc.AllowHostNetwork = true
+				scc.AllowHostPorts = true
+			},
+			expectedLevel:  LevelPrivileged,
+			expectedFields: []string{"allowHostNetwork", "allowHostPorts"},
+		},
+		{
+			name: "privileged",
+			modify: func(scc *securityv1.SecurityContextConstraints) {
+				scc.AllowPrivilegedContainer = true
+				scc.AllowHostDirVolumePlugin = true
+				scc.AllowHostIPC = true
+				scc.AllowHostNetwork = true
+				scc.AllowHostPID = true
+				scc.AllowHostPorts = true
+				scc.AllowPrivilegeEscalation = pointer.Bool(true)
+				scc.AllowedCapabilities = []corev1.Capability{"*"}
+				scc.RequiredDropCapabilities = nil
+				scc.Volumes = []securityv1.FSType{"*"}
+				scc.RunAsUser.Type = securityv1.RunAsUserStrategyRunAsAny
+				scc.SELinuxContext.Type = securityv1.SELinuxStrategyRunAsAny
+				scc.SeccompProfiles = []string{"*"}
+				scc.AllowedUnsafeSysctls = []string{"*"}
+			},
+			expectedLevel: LevelPrivileged,
+			expectedFields: []string{"allowPrivilegedContainer", "allowHostNetwork", "allowHostPID", "allowHostIPC",
+				"allowHostPorts", "allowedCapabilities", "requiredDropCapabilities", "volumes", "allowPrivilegeEscalation",
+				"runAsUser.type", "seLinuxContext.type", "seccompProfiles", "allowedUnsafeSysctls"},
+		},
+		{
+			name: "custom SELinux type",
+			modify: func(scc *securityv1.SecurityContextConstraints) {
+				scc.SELinuxContext.SELinuxOptions = &corev1.SELinuxOptions{Type: "spc_t"}
+			},
+			expectedLevel:  LevelPrivileged,
+			expectedFields: []string{"seLinuxContext.seLinuxOptions.type"},
+		},
+		{
+			name: "container SELinux type",
+			modify: func(scc *securityv1.SecurityContextConstraints) {
+				scc.SELinuxContext.SELinuxOptions = &corev1.SELinuxOptions{Type: "container_t", Level: "s0:c1,c2"}
+			},
+			expectedLevel: LevelRestricted,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			scc := restrictedV2()
+			tc.modify(scc)
+			report := MapSecurityContextConstraints(scc)
+			if report.Level != tc.expectedLevel {
+				t.Errorf("expected level %s, got %s: %v", tc.expectedLevel, report.Level, report.Incompatibilities)
+			}
+			var fields []string
+			for _, incompatibility := range report.Incompatibilities {
+				fields = append(fields, incompatibility.Field.String())
+			}
+			if len(fields) != len(tc.expectedFields) {
+				t.Fatalf("expected incompatibilities of %v, got %v", tc.expectedFields, report.Incompatibilities)
+			}
+			for i := range fields {
+				if fields[i] != tc.expectedFields[i] {
+					t.Errorf("expected incompatibilities of %v, got %v", tc.expectedFields, report.Incompatibilities)
+					break
+				}
+			}
+			if incompatibilities := report.IncompatibleWith(report.Level); len(incompatibilities) != 0 {
+				t.Errorf("expected no incompatibility with the level of the report, got %v", incompatibilities)
+			}
+		})
+	}
+}
+
+func TestIncompatibleWith(t *testing.T) {
+	scc := restrictedV2()
+	scc.AllowHostNetwork = true
+	scc.SeccompProfiles = nil
+	report := MapSecurityContextConstraints(scc)
+
+	if incompatibilities := report.IncompatibleWith(LevelRestricted); len(incompatibilities) != 2 {
+		t.Errorf("expected 2 incompatibilities with restricted, got %v", incompatibilities)
+	}
+	if incompatibilities := report.IncompatibleWith(LevelBaseline); len(incompatibilities) != 1 || incompatibilities[0].Field.String() != "allowHostNetwork" {
+		t.Errorf("expected the host network to be incompatible with baseline, got %v", incompatibilities)
+	}
+	if incompatibilities := report.IncompatibleWith(LevelPrivileged); len(incompatibilities) != 0 {
+		t.Errorf("expected no incompatibility with privileged, got %v", incompatibilities)
+	}
+
+	if level := LeastRestrictiveLevel(); level != LevelRestricted {
+		t.Errorf("expected restricted without report, got %s", level)
+	}
+	if level := LeastRestrictiveLevel(MapSecurityContextConstraints(restrictedV2()), report); level != LevelPrivileged {
+		t.Errorf("expected privileged, got %s", level)
+	}
+}
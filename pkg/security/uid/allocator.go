@@ -0,0 +1,291 @@
+package uid
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrFull         = errors.New("range is full")
+	ErrAllocated    = errors.New("provided block is already allocated")
+	ErrNotInRange   = errors.New("provided block is not in the range")
+	ErrRangeChanged = errors.New("the persisted allocations are of another range")
+)
+
+// PersistFunc persists the allocations of a range, in the format of Snapshot.
+type PersistFunc func(rangeString string, data []byte) error
+
+// interval is an interval of allocated block offsets, inclusive.
+type interval struct {
+	start, end uint32
+}
+
+// allocation is the allocation (or release) of a block not persisted yet.
+type allocation struct {
+	offset   uint32
+	released bool
+}
+
+// batch is the allocations persisted together.
+type batch struct {
+	allocations []allocation
+	done        chan struct{}
+	err         error
+}
+
+// IntervalAllocator allocates the blocks of a range, the lowest free block first. Unlike a bitmap, its memory and
+// the cost of its operations depend on the number of disjoint intervals of allocated blocks rather than on the size
+// of the range, which keeps very large ranges with many allocations cheap as the blocks are allocated contiguously.
+//
+// The allocations are persisted lazily: the allocations and releases made while the previous ones are persisted
+// are persisted together with a single call of the persist function, so that concurrent callers do not each wait
+// for their own write. The methods return once the change is persisted, and the change is reverted if it cannot be.
+// The allocations are persisted in the format of the bitmap allocator of kube, so that either allocator can restore
+// the allocations of the other.
+type IntervalAllocator struct {
+	r       *Range
+	persist PersistFunc
+
+	lock      sync.Mutex
+	allocated []interval
+	count     uint32
+	// pending are the changes not persisted yet, nil when none are
+	pending *batch
+	// flushing is true while a batch is persisted
+	flushing bool
+}
+
+// NewIntervalAllocator creates a new IntervalAllocator of the blocks of the range, persisting its allocations with
+// the persist function. The allocations are only kept in memory when persist is nil.
+func NewIntervalAllocator(r *Range, persist PersistFunc) *IntervalAllocator {
+	return &IntervalAllocator{r: r, persist: persist}
+}
+
+// Allocate allocates the block.
+func (a *IntervalAllocator) Allocate(block Block) error {
+	ok, offset := a.r.Offset(block)
+	if !ok {
+		return ErrNotInRange
+	}
+	a.lock.Lock()
+	if _, allocated := a.find(offset); allocated {
+		a.lock.Unlock()
+		return ErrAllocated
+	}
+	a.insert(offset)
+	return a.commit(allocation{offset: offset})
+}
+
+// AllocateNext allocates the lowest free block of the range.
+func (a *IntervalAllocator) AllocateNext() (Block, error) {
+	a.lock.Lock()
+	var offset uint32
+	if len(a.allocated) > 0 && a.allocated[0].start == 0 {
+		offset = a.allocated[0].end + 1
+	}
+	if offset >= a.r.Size() {
+		a.lock.Unlock()
+		return Block{}, ErrFull
+	}
+	a.insert(offset)
+	if err := a.commit(allocation{offset: offset}); err != nil {
+		return Block{}, err
+	}
+	block, _ := a.r.BlockAt(offset)
+	return block, nil
+}
+
+// Release releases the block, releasing a free block is a no-op.
+func (a *IntervalAllocator) Release(block Block) error {
+	ok, offset := a.r.Offset(block)
+	if !ok {
+		return nil
+	}
+	a.lock.Lock()
+	if _, allocated := a.find(offset); !allocated {
+		a.lock.Unlock()
+		return nil
+	}
+	a.remove(offset)
+	return a.commit(allocation{offset: offset, released: true})
+}
+
+// Has returns whether the block is allocated.
+func (a *IntervalAllocator) Has(block Block) bool {
+	ok, offset := a.r.Offset(block)
+	if !ok {
+		return false
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	_, allocated := a.find(offset)
+	return allocated
+}
+
+// Free returns the number of free blocks.
+func (a *IntervalAllocator) Free() int {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return int(a.r.Size() - a.count)
+}
+
+// Snapshot returns the range and its allocations, as a big endian bitmap of the allocated block offsets.
+func (a *IntervalAllocator) Snapshot() (string, []byte) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.r.String(), a.bitmap()
+}
+
+// Restore replaces the allocations with the ones of a snapshot of the same range, without persisting them.
+func (a *IntervalAllocator) Restore(rangeString string, data []byte) error {
+	if rangeString != a.r.String() {
+		return ErrRangeChanged
+	}
+	var allocated []interval
+	var count uint32
+	for i := len(data) - 1; i >= 0; i-- {
+		if data[i] == 0 {
+			continue
+		}
+		for bit := uint32(0); bit < 8; bit++ {
+			if data[i]&(1<<bit) == 0 {
+				continue
+			}
+			offset := uint32(len(data)-1-i)*8 + bit
+			if offset >= a.r.Size() {
+				return fmt.Errorf("the block %d is out of the range %s", offset, rangeString)
+			}
+			count++
+			if n := len(allocated); n > 0 && allocated[n-1].end+1 == offset {
+				allocated[n-1].end = offset
+			} else {
+				allocated = append(allocated, interval{start: offset, end: offset})
+			}
+		}
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.allocated, a.count = allocated, count
+	return nil
+}
+
+// commit persists the change, with the other pending changes. The lock must be held, commit releases it.
+func (a *IntervalAllocator) commit(change allocation) error {
+	if a.persist == nil {
+		a.lock.Unlock()
+		return nil
+	}
+	if a.pending == nil {
+		a.pending = &batch{done: make(chan struct{})}
+	}
+	b := a.pending
+	b.allocations = append(b.allocations, change)
+	if a.flushing {
+		// the flushing caller persists the batch after the current one
+		a.lock.Unlock()
+		<-b.done
+		return b.err
+	}
+
+	a.flushing = true
+	for a.pending != nil {
+		current := a.pending
+		a.pending = nil
+		rangeString, data := a.r.String(), a.bitmap()
+		a.lock.Unlock()
+
+		err := a.persist(rangeString, data)
+
+		a.lock.Lock()
+		if err != nil {
+			current.err = fmt.Errorf("unable to persist the allocations of %s: %v", rangeString, err)
+			a.revert(current.allocations)
+		}
+		close(current.done)
+	}
+	a.flushing = false
+	a.lock.Unlock()
+	return b.err
+}
+
+// revert reverts the changes that could not be persisted, in reverse order. The lock must be held.
+func (a *IntervalAllocator) revert(allocations []allocation) {
+	for i := len(allocations) - 1; i >= 0; i-- {
+		_, allocated := a.find(allocations[i].offset)
+		switch {
+		case allocations[i].released && !allocated:
+			a.insert(allocations[i].offset)
+		case !allocations[i].released && allocated:
+			a.remove(allocations[i].offset)
+		}
+	}
+}
+
+// find returns the index of the first interval that does not end before the offset, and whether it holds the offset.
+func (a *IntervalAllocator) find(offset uint32) (int, bool) {
+	i := sort.Search(len(a.allocated), func(i int) bool { return a.allocated[i].end >= offset })
+	return i, i < len(a.allocated) && a.allocated[i].start <= offset
+}
+
+// insert allocates the free offset, merging the adjacent intervals.
+func (a *IntervalAllocator) insert(offset uint32) {
+	i, _ := a.find(offset)
+	mergesPrevious := i > 0 && a.allocated[i-1].end+1 == offset
+	mergesNext := i < len(a.allocated) && a.allocated[i].start == offset+1
+	switch {
+	case mergesPrevious && mergesNext:
+		a.allocated[i-1].end = a.allocated[i].end
+		a.allocated = append(a.allocated[:i], a.allocated[i+1:]...)
+	case mergesPrevious:
+		a.allocated[i-1].end = offset
+	case mergesNext:
+		a.allocated[i].start = offset
+	default:
+		a.allocated = append(a.allocated, interval{})
+		copy(a.allocated[i+1:], a.allocated[i:])
+		a.allocated[i] = interval{start: offset, end: offset}
+	}
+	a.count++
+}
+
+// remove releases the allocated offset, splitting its interval.
+func (a *IntervalAllocator) remove(offset uint32) {
+	i, _ := a.find(offset)
+	current := a.allocated[i]
+	switch {
+	case current.start == offset && current.end == offset:
+		a.allocated = append(a.allocated[:i], a.allocated[i+1:]...)
+	case current.start == offset:
+		a.allocated[i].start++
+	case current.end == offset:
+		a.allocated[i].end--
+	default:
+		a.allocated = append(a.allocated, interval{})
+		copy(a.allocated[i+1:], a.allocated[i:])
+		a.allocated[i] = interval{start: current.start, end: offset - 1}
+		a.allocated[i+1] = interval{start: offset + 1, end: current.end}
+	}
+	a.count--
+}
+
+// bitmap returns the allocated offsets as the bytes of a big endian bitmap, without leading zeros, as returned by
+// big.Int.Bytes for the bitmap of the kube allocator.
+func (a *IntervalAllocator) bitmap() []byte {
+	if len(a.allocated) == 0 {
+		return []byte{}
+	}
+	last := a.allocated[len(a.allocated)-1].end
+	data := make([]byte, last/8+1)
+	for _, allocated := range a.allocated {
+		for offset := allocated.start; ; offset++ {
+			data[len(data)-1-int(offset/8)] |= 1 << (offset % 8)
+			if offset == allocated.end {
+				break
+			}
+		}
+	}
+	return data
+}
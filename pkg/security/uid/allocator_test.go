@@ -0,0 +1,227 @@
+package uid
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIntervalAllocator(t *testing.T) {
+	r, err := NewRange(1000, 1999, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewIntervalAllocator(r, nil)
+	if a.Free() != 100 {
+		t.Fatalf("expected 100 free blocks, got %d", a.Free())
+	}
+
+	// the lowest free blocks are allocated first
+	for i := uint32(0); i < 5; i++ {
+		block, err := a.AllocateNext()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := (Block{Start: 1000 + i*10, End: 1009 + i*10}); block != expected {
+			t.Errorf("expected %v, got %v", expected, block)
+		}
+	}
+	if err := a.Allocate(Block{Start: 1020, End: 1029}); err != ErrAllocated {
+		t.Errorf("expected ErrAllocated, got %v", err)
+	}
+	if err := a.Allocate(Block{Start: 1021, End: 1030}); err != ErrNotInRange {
+		t.Errorf("expected ErrNotInRange, got %v", err)
+	}
+	if err := a.Allocate(Block{Start: 1500, End: 1509}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Release(Block{Start: 1020, End: 1029}); err != nil {
+		t.Fatal(err)
+	}
+	if a.Has(Block{Start: 1020, End: 1029}) || !a.Has(Block{Start: 1030, End: 1039}) || !a.Has(Block{Start: 1500, End: 1509}) {
+		t.Errorf("unexpected allocations: %v", a.allocated)
+	}
+	if expected := []interval{{0, 1}, {3, 4}, {50, 50}}; fmt.Sprint(a.allocated) != fmt.Sprint(expected) {
+		t.Errorf("expected intervals %v, got %v", expected, a.allocated)
+	}
+	if a.Free() != 95 {
+		t.Errorf("expected 95 free blocks, got %d", a.Free())
+	}
+
+	// the released block is reused, merging the intervals
+	block, err := a.AllocateNext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (Block{Start: 1020, End: 1029}); block != expected {
+		t.Errorf("expected %v, got %v", expected, block)
+	}
+	if expected := []interval{{0, 4}, {50, 50}}; fmt.Sprint(a.allocated) != fmt.Sprint(expected) {
+		t.Errorf("expected intervals %v, got %v", expected, a.allocated)
+	}
+	if err := a.Release(Block{Start: 1700, End: 1709}); err != nil {
+		t.Errorf("expected releasing a free block to succeed, got %v", err)
+	}
+
+	for a.Free() > 0 {
+		if _, err := a.AllocateNext(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := a.AllocateNext(); err != ErrFull {
+		t.Errorf("expected ErrFull, got %v", err)
+	}
+	if expected := []interval{{0, 99}}; fmt.Sprint(a.allocated) != fmt.Sprint(expected) {
+		t.Errorf("expected intervals %v, got %v", expected, a.allocated)
+	}
+}
+
+func TestIntervalAllocatorSnapshot(t *testing.T) {
+	r, err := NewRange(1000000000, 1999999999, 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewIntervalAllocator(r, nil)
+	offsets := []uint32{0, 1, 2, 7, 8, 9, 63, 64, 1000, 99999}
+	bitmap := big.NewInt(0)
+	for _, offset := range offsets {
+		block, _ := r.BlockAt(offset)
+		if err := a.Allocate(block); err != nil {
+			t.Fatal(err)
+		}
+		bitmap.SetBit(bitmap, int(offset), 1)
+	}
+
+	rangeString, data := a.Snapshot()
+	if rangeString != "1000000000-1999999999/10000" {
+		t.Errorf("unexpected range %s", rangeString)
+	}
+	// the kube bitmap allocator snapshots the bytes of its big.Int
+	if !bytes.Equal(data, bitmap.Bytes()) {
+		t.Errorf("expected the snapshot to be the bitmap %x, got %x", bitmap.Bytes(), data)
+	}
+
+	restored := NewIntervalAllocator(r, nil)
+	if err := restored.Restore(rangeString, data); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(restored.allocated) != fmt.Sprint(a.allocated) || restored.Free() != a.Free() {
+		t.Errorf("expected %v, restored %v", a.allocated, restored.allocated)
+	}
+	if err := restored.Restore("1-100/10", data); err != ErrRangeChanged {
+		t.Errorf("expected ErrRangeChanged, got %v", err)
+	}
+	small, _ := NewRange(0, 99, 10)
+	if err := NewIntervalAllocator(small, nil).Restore(small.String(), data); err == nil {
+		t.Errorf("expected an error restoring blocks out of the range")
+	}
+
+	_, empty := NewIntervalAllocator(r, nil).Snapshot()
+	if !bytes.Equal(empty, big.NewInt(0).Bytes()) {
+		t.Errorf("expected an empty snapshot, got %x", empty)
+	}
+}
+
+func TestIntervalAllocatorPersistence(t *testing.T) {
+	r, err := NewRange(0, 99, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var persisted []byte
+	var fail bool
+	a := NewIntervalAllocator(r, func(rangeString string, data []byte) error {
+		if fail {
+			return errors.New("conflict")
+		}
+		persisted = data
+		return nil
+	})
+
+	if _, err := a.AllocateNext(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(persisted, []byte{1}) {
+		t.Errorf("expected the allocation to be persisted, got %x", persisted)
+	}
+
+	// the changes that cannot be persisted are reverted
+	fail = true
+	if _, err := a.AllocateNext(); err == nil {
+		t.Errorf("expected an error")
+	}
+	if err := a.Release(Block{Start: 0, End: 0}); err == nil {
+		t.Errorf("expected an error")
+	}
+	if !a.Has(Block{Start: 0, End: 0}) || a.Has(Block{Start: 1, End: 1}) || a.Free() != 99 {
+		t.Errorf("expected the changes to be reverted, got %v", a.allocated)
+	}
+}
+
+func TestIntervalAllocatorBatching(t *testing.T) {
+	r, err := NewRange(0, 9999, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lock sync.Mutex
+	var writes int
+	var persisted []byte
+	release := make(chan struct{})
+	a := NewIntervalAllocator(r, func(rangeString string, data []byte) error {
+		// block the first write until all the allocations are pending
+		<-release
+		lock.Lock()
+		defer lock.Unlock()
+		writes++
+		persisted = data
+		return nil
+	})
+
+	const allocations = 100
+	var wg sync.WaitGroup
+	blocks := make(chan Block, allocations)
+	for i := 0; i < allocations; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			block, err := a.AllocateNext()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			blocks <- block
+		}()
+	}
+	for {
+		a.lock.Lock()
+		pending := a.pending != nil && len(a.pending.allocations) == allocations-1
+		a.lock.Unlock()
+		if pending {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	close(blocks)
+
+	seen := map[Block]bool{}
+	for block := range blocks {
+		if seen[block] {
+			t.Errorf("block %v allocated twice", block)
+		}
+		seen[block] = true
+	}
+	if len(seen) != allocations {
+		t.Errorf("expected %d blocks, got %d", allocations, len(seen))
+	}
+	if writes != 2 {
+		t.Errorf("expected the allocations to be persisted in 2 writes, got %d", writes)
+	}
+	if _, data := a.Snapshot(); !bytes.Equal(data, persisted) {
+		t.Errorf("expected the last write to persist all the allocations")
+	}
+}
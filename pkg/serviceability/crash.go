@@ -0,0 +1,204 @@
+package serviceability
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/klog/v2"
+)
+
+// maxGoroutineDumpSize bounds the size of the goroutine dump of the crash reports.
+const maxGoroutineDumpSize = 64 * 1024 * 1024
+
+// CrashReport describes a panic of the process.
+type CrashReport struct {
+	Time time.Time `json:"time"`
+	// Panic is the value the process panicked with.
+	Panic string `json:"panic"`
+	// Stack is the stack of the panicking goroutine.
+	Stack string `json:"stack"`
+	// Goroutines is the dump of the stacks of all the goroutines.
+	Goroutines string `json:"goroutines"`
+	// RecentLogs are the last lines written to the log buffer, see CaptureRecentLogs.
+	RecentLogs []string `json:"recentLogs,omitempty"`
+	// Version is the product version of the process.
+	Version version.Info `json:"version"`
+	// Build describes the binary of the process.
+	Build *BuildInfo `json:"build,omitempty"`
+	Args  []string   `json:"args"`
+	PID   int        `json:"pid"`
+}
+
+// BuildInfo is the build information embedded in the binary.
+type BuildInfo struct {
+	GoVersion string `json:"goVersion"`
+	Path      string `json:"path"`
+	// Settings are the build settings, e.g. vcs.revision.
+	Settings map[string]string `json:"settings,omitempty"`
+}
+
+// CrashHandler handles the crash reports of the process, e.g. to send them to a telemetry service. It must not
+// panic, and should return quickly as the process is crashing.
+type CrashHandler interface {
+	HandleCrash(report *CrashReport) error
+}
+
+// CrashHandlerFunc is a function implementing CrashHandler.
+type CrashHandlerFunc func(report *CrashReport) error
+
+func (f CrashHandlerFunc) HandleCrash(report *CrashReport) error {
+	return f(report)
+}
+
+// FileCrashHandler returns a CrashHandler writing the crash reports as JSON files to the directory, named
+// crash-<time>-<pid>.json.
+func FileCrashHandler(dir string) CrashHandler {
+	return CrashHandlerFunc(func(report *CrashReport) error {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		name := fmt.Sprintf("crash-%s-%d.json", report.Time.UTC().Format("20060102T150405.000000000Z"), report.PID)
+		return os.WriteFile(filepath.Join(dir, name), data, 0600)
+	})
+}
+
+// LogBuffer keeps the last lines written to it, to capture the recent logs in the crash reports.
+type LogBuffer struct {
+	lock    sync.Mutex
+	lines   []string
+	next    int
+	full    bool
+	partial strings.Builder
+}
+
+// NewLogBuffer creates a new LogBuffer keeping the lines last lines.
+func NewLogBuffer(lines int) *LogBuffer {
+	if lines < 1 {
+		lines = 1
+	}
+	return &LogBuffer{lines: make([]string, lines)}
+}
+
+// Write appends the complete lines of p to the buffer, evicting the oldest lines.
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	rest := string(p)
+	for {
+		i := strings.IndexByte(rest, '\n')
+		if i == -1 {
+			b.partial.WriteString(rest)
+			return len(p), nil
+		}
+		b.partial.WriteString(rest[:i])
+		b.lines[b.next] = b.partial.String()
+		b.partial.Reset()
+		b.next = (b.next + 1) % len(b.lines)
+		if b.next == 0 {
+			b.full = true
+		}
+		rest = rest[i+1:]
+	}
+}
+
+// Lines returns the lines of the buffer, the oldest first.
+func (b *LogBuffer) Lines() []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var lines []string
+	if b.full {
+		lines = append(lines, b.lines[b.next:]...)
+	}
+	lines = append(lines, b.lines[:b.next]...)
+	if b.partial.Len() > 0 {
+		lines = append(lines, b.partial.String())
+	}
+	return lines
+}
+
+var (
+	recentLogsLock sync.Mutex
+	recentLogs     *LogBuffer
+)
+
+// CaptureRecentLogs makes the crash reports include the last lines of the logs, written to the returned buffer,
+// e.g. by klog.SetOutput or a logrus hook.
+func CaptureRecentLogs(lines int) *LogBuffer {
+	recentLogsLock.Lock()
+	defer recentLogsLock.Unlock()
+	recentLogs = NewLogBuffer(lines)
+	return recentLogs
+}
+
+// NewCrashReport returns the report of the panic, called from the panicking goroutine.
+func NewCrashReport(panicValue interface{}, productVersion version.Info) *CrashReport {
+	report := &CrashReport{
+		Time:       time.Now(),
+		Panic:      fmt.Sprintf("%v", panicValue),
+		Stack:      string(debug.Stack()),
+		Goroutines: goroutineDump(),
+		Version:    productVersion,
+		Args:       os.Args,
+		PID:        os.Getpid(),
+	}
+	recentLogsLock.Lock()
+	if recentLogs != nil {
+		report.RecentLogs = recentLogs.Lines()
+	}
+	recentLogsLock.Unlock()
+	if info, ok := debug.ReadBuildInfo(); ok {
+		report.Build = &BuildInfo{GoVersion: info.GoVersion, Path: info.Path, Settings: map[string]string{}}
+		for _, setting := range info.Settings {
+			report.Build.Settings[setting.Key] = setting.Value
+		}
+	}
+	return report
+}
+
+// goroutineDump returns the stacks of all the goroutines.
+func goroutineDump() string {
+	for size := 1024 * 1024; ; size *= 2 {
+		buf := make([]byte, size)
+		n := runtime.Stack(buf, true)
+		if n < size || size >= maxGoroutineDumpSize {
+			return string(buf[:n])
+		}
+	}
+}
+
+// CrashReportingPanicHandler returns a panic handler passing a report of the first panic to the crash handlers, to be
+// passed to utilruntime.HandleCrash or factory.Factory.WithPanicHandlers. It must be called from the panicking
+// goroutine for the report to include its stack.
+func CrashReportingPanicHandler(productVersion version.Info, crashHandlers ...CrashHandler) func(interface{}) {
+	reporter := &crashReporter{productVersion: productVersion, handlers: crashHandlers}
+	return reporter.report
+}
+
+// crashReporter reports the panics to the crash handlers, once per reporter.
+type crashReporter struct {
+	productVersion version.Info
+	handlers       []CrashHandler
+	once           sync.Once
+}
+
+func (r *crashReporter) report(panicValue interface{}) {
+	r.once.Do(func() {
+		report := NewCrashReport(panicValue, r.productVersion)
+		for _, handler := range r.handlers {
+			if err := handler.HandleCrash(report); err != nil {
+				klog.Errorf("Unable to handle the crash report: %v", err)
+			}
+		}
+	})
+}
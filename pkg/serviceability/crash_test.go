@@ -0,0 +1,137 @@
+package serviceability
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+func TestLogBuffer(t *testing.T) {
+	b := NewLogBuffer(3)
+	b.Write([]byte("one\ntwo\n"))
+	if lines, expected := b.Lines(), []string{"one", "two"}; !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected %v, got %v", expected, lines)
+	}
+	b.Write([]byte("three\nfo"))
+	b.Write([]byte("ur\nfive"))
+	if lines, expected := b.Lines(), []string{"two", "three", "four", "five"}; !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected %v, got %v", expected, lines)
+	}
+}
+
+func TestCrashHandlers(t *testing.T) {
+	panicHandlers, reallyCrash := utilruntime.PanicHandlers, utilruntime.ReallyCrash
+	defer func() {
+		utilruntime.PanicHandlers, utilruntime.ReallyCrash = panicHandlers, reallyCrash
+		recentLogs = nil
+	}()
+
+	utilruntime.ReallyCrash = false
+
+	logs := CaptureRecentLogs(10)
+	logs.Write([]byte("starting\n"))
+
+	dir := t.TempDir()
+	var reports []*CrashReport
+	handler := CrashReportingPanicHandler(version.Info{GitVersion: "v4.14.0"},
+		FileCrashHandler(dir),
+		CrashHandlerFunc(func(report *CrashReport) error {
+			reports = append(reports, report)
+			return nil
+		}),
+	)
+
+	// the panics of the goroutines are reported by HandleCrash with the handler
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer utilruntime.HandleCrash(handler)
+		crashingFunction()
+	}()
+	<-done
+
+	if len(reports) != 1 {
+		t.Fatalf("expected 1 report, got %d", len(reports))
+	}
+	report := reports[0]
+	if report.Panic != "crashing" {
+		t.Errorf("unexpected panic %q", report.Panic)
+	}
+	if !strings.Contains(report.Stack, "crashingFunction") {
+		t.Errorf("expected the stack of the panicking goroutine, got %s", report.Stack)
+	}
+	if !strings.Contains(report.Goroutines, "TestCrashHandlers") {
+		t.Errorf("expected the stacks of all the goroutines, got %s", report.Goroutines)
+	}
+	if !reflect.DeepEqual(report.RecentLogs, []string{"starting"}) {
+		t.Errorf("expected the recent logs, got %v", report.RecentLogs)
+	}
+	if report.Version.GitVersion != "v4.14.0" || report.PID != os.Getpid() {
+		t.Errorf("unexpected report %#v", report)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "crash-*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected a crash report file, got %v, %v", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written CrashReport
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatal(err)
+	}
+	if written.Panic != "crashing" || written.Stack != report.Stack {
+		t.Errorf("unexpected written report %#v", written)
+	}
+}
+
+func TestCrashHandlersDeferred(t *testing.T) {
+	panicHandlers := utilruntime.PanicHandlers
+	defer func() { utilruntime.PanicHandlers = panicHandlers }()
+
+	var reports []*CrashReport
+	handler := CrashHandlerFunc(func(report *CrashReport) error {
+		reports = append(reports, report)
+		return nil
+	})
+
+	// the global panic handlers are left alone
+	BehaviorOnPanic("", version.Info{}, handler)
+	if len(utilruntime.PanicHandlers) != len(panicHandlers) {
+		t.Errorf("expected the global panic handlers not to change")
+	}
+
+	// the panics of the caller are reported by the returned function, which panics again
+	var repanicked interface{}
+	func() {
+		defer func() { repanicked = recover() }()
+		defer BehaviorOnPanic("", version.Info{}, handler)()
+		crashingFunction()
+	}()
+	if repanicked != "crashing" {
+		t.Errorf("expected the panic to continue, got %v", repanicked)
+	}
+	if len(reports) != 1 || !strings.Contains(reports[0].Stack, "crashingFunction") {
+		t.Errorf("expected the panic to be reported, got %v", reports)
+	}
+
+	// no report without panic
+	func() {
+		defer BehaviorOnPanic("", version.Info{}, handler)()
+	}()
+	if len(reports) != 1 {
+		t.Errorf("expected no other report, got %d", len(reports))
+	}
+}
+
+func crashingFunction() {
+	panic("crashing")
+}
@@ -2,6 +2,7 @@ package serviceability
 
 import (
 	"encoding/json"
+	"runtime/debug"
 	"strings"
 	"time"
 
@@ -13,22 +14,39 @@ import (
 
 // BehaviorOnPanic is a helper for setting the crash mode of OpenShift when a panic is caught.
 // It returns a function that should be the defer handler for the caller.
-func BehaviorOnPanic(modeString string, productVersion version.Info) func() {
-	modes := []string{}
-	if err := json.Unmarshal([]byte(modeString), &modes); err != nil {
-		return behaviorOnPanic(modeString, productVersion)
+// The crash handlers are passed a report of a panic caught by the returned function, which logs its stack and panics
+// again. The panics of the controllers are reported with CrashReportingPanicHandler instead.
+func BehaviorOnPanic(modeString string, productVersion version.Info, crashHandlers ...CrashHandler) func() {
+	var reporter *crashReporter
+	if len(crashHandlers) > 0 {
+		reporter = &crashReporter{productVersion: productVersion, handlers: crashHandlers}
 	}
 
 	fns := []func(){}
-
-	for _, mode := range modes {
-		fns = append(fns, behaviorOnPanic(mode, productVersion))
+	modes := []string{}
+	if err := json.Unmarshal([]byte(modeString), &modes); err != nil {
+		fns = append(fns, behaviorOnPanic(modeString, productVersion))
+	} else {
+		for _, mode := range modes {
+			fns = append(fns, behaviorOnPanic(mode, productVersion))
+		}
 	}
 
 	return func() {
+		var r interface{}
+		if reporter != nil {
+			// recover only stops the panic when called by the deferred function itself
+			if r = recover(); r != nil {
+				klog.Errorf("Observed a panic: %v\n%s", r, debug.Stack())
+				reporter.report(r)
+			}
+		}
 		for _, fn := range fns {
 			fn()
 		}
+		if r != nil {
+			panic(r)
+		}
 	}
 }
 
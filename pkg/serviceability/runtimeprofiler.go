@@ -0,0 +1,196 @@
+package serviceability
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// RuntimeProfilingPath is the path of the endpoint enabling and disabling the profiling at runtime.
+	RuntimeProfilingPath = "/debug/profiling"
+	// RuntimeProfilerPprofPath is the path prefix of the pprof endpoints served while the profiling is enabled.
+	RuntimeProfilerPprofPath = "/debug/pprof/"
+)
+
+// ProfilingOptions are the options of the runtime profiling.
+type ProfilingOptions struct {
+	// Duration is how long the profiling stays enabled, the maximum duration of the profiler when zero.
+	Duration time.Duration
+	// MutexProfileFraction is the fraction of the mutex contention events reported, see runtime.SetMutexProfileFraction.
+	// Zero keeps the mutex profiling disabled.
+	MutexProfileFraction int
+	// BlockProfileRate is the rate of the blocking events reported, see runtime.SetBlockProfileRate. Zero keeps the
+	// block profiling disabled.
+	BlockProfileRate int
+}
+
+// ProfilingStatus is the state of the runtime profiling.
+type ProfilingStatus struct {
+	Enabled              bool       `json:"enabled"`
+	Until                *time.Time `json:"until,omitempty"`
+	MutexProfileFraction int        `json:"mutexProfileFraction,omitempty"`
+	BlockProfileRate     int        `json:"blockProfileRate,omitempty"`
+}
+
+// RuntimeProfiler enables the pprof endpoints and the mutex and block profiling of a running process for a limited
+// time, so that performance data can be collected from production processes without restarting them with
+// OPENSHIFT_PROFILE set. The profiling is disabled again when its duration elapses.
+//
+// As an http.Handler, it serves the pprof endpoints under /debug/pprof/ while the profiling is enabled and the
+// /debug/profiling endpoint: GET returns the ProfilingStatus, POST enables the profiling with the duration, mutex and
+// block query parameters, e.g. /debug/profiling?duration=5m&mutex=5, and DELETE disables it. The handler must be
+// served behind authentication and authorization.
+type RuntimeProfiler struct {
+	maxDuration time.Duration
+
+	lock   sync.Mutex
+	status ProfilingStatus
+	timer  *time.Timer
+}
+
+// NewRuntimeProfiler creates a disabled RuntimeProfiler, whose profiling cannot be enabled for longer than maxDuration.
+func NewRuntimeProfiler(maxDuration time.Duration) *RuntimeProfiler {
+	return &RuntimeProfiler{maxDuration: maxDuration}
+}
+
+// Enable enables the profiling, or extends it when already enabled, until the duration of the options elapses.
+func (p *RuntimeProfiler) Enable(options ProfilingOptions) error {
+	duration := options.Duration
+	if duration == 0 {
+		duration = p.maxDuration
+	}
+	if duration < 0 || duration > p.maxDuration {
+		return fmt.Errorf("the profiling duration must be between 0 and %v, got %v", p.maxDuration, duration)
+	}
+	if options.MutexProfileFraction < 0 || options.BlockProfileRate < 0 {
+		return fmt.Errorf("the mutex profile fraction and block profile rate must not be negative")
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	runtime.SetMutexProfileFraction(options.MutexProfileFraction)
+	runtime.SetBlockProfileRate(options.BlockProfileRate)
+	until := time.Now().Add(duration)
+	p.status = ProfilingStatus{
+		Enabled:              true,
+		Until:                &until,
+		MutexProfileFraction: options.MutexProfileFraction,
+		BlockProfileRate:     options.BlockProfileRate,
+	}
+	p.timer = time.AfterFunc(duration, p.Disable)
+	klog.Infof("Profiling enabled until %s", until.UTC().Format(time.RFC3339))
+	return nil
+}
+
+// Disable disables the profiling.
+func (p *RuntimeProfiler) Disable() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if !p.status.Enabled {
+		return
+	}
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	runtime.SetMutexProfileFraction(0)
+	runtime.SetBlockProfileRate(0)
+	p.status = ProfilingStatus{}
+	klog.Infof("Profiling disabled")
+}
+
+// Status returns the state of the profiling.
+func (p *RuntimeProfiler) Status() ProfilingStatus {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.status
+}
+
+// ServeHTTP serves the pprof and profiling endpoints.
+func (p *RuntimeProfiler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == RuntimeProfilingPath {
+		p.serveProfiling(w, r)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, RuntimeProfilerPprofPath) {
+		http.NotFound(w, r)
+		return
+	}
+	if !p.Status().Enabled {
+		http.Error(w, fmt.Sprintf("profiling is disabled, POST to %s to enable it", RuntimeProfilingPath), http.StatusForbidden)
+		return
+	}
+	switch strings.TrimPrefix(r.URL.Path, RuntimeProfilerPprofPath) {
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		// the index serves the named profiles, e.g. /debug/pprof/mutex
+		pprof.Index(w, r)
+	}
+}
+
+func (p *RuntimeProfiler) serveProfiling(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		options, err := profilingOptionsFromQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := p.Enable(options); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case http.MethodDelete:
+		p.Disable()
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, fmt.Sprintf("method %s is not allowed", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(p.Status()); err != nil {
+		klog.Warningf("Unable to write the profiling status: %v", err)
+	}
+}
+
+func profilingOptionsFromQuery(r *http.Request) (ProfilingOptions, error) {
+	var options ProfilingOptions
+	query := r.URL.Query()
+	if value := query.Get("duration"); len(value) > 0 {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return options, fmt.Errorf("invalid duration %q: %v", value, err)
+		}
+		options.Duration = duration
+	}
+	for name, target := range map[string]*int{"mutex": &options.MutexProfileFraction, "block": &options.BlockProfileRate} {
+		if value := query.Get(name); len(value) > 0 {
+			rate, err := strconv.Atoi(value)
+			if err != nil {
+				return options, fmt.Errorf("invalid %s rate %q: %v", name, value, err)
+			}
+			*target = rate
+		}
+	}
+	return options, nil
+}
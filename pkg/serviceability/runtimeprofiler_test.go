@@ -0,0 +1,86 @@
+package serviceability
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRuntimeProfiler(t *testing.T) {
+	defer runtime.SetMutexProfileFraction(runtime.SetMutexProfileFraction(0))
+	defer runtime.SetBlockProfileRate(0)
+
+	profiler := NewRuntimeProfiler(time.Hour)
+	serve := func(method, path string) (*httptest.ResponseRecorder, ProfilingStatus) {
+		recorder := httptest.NewRecorder()
+		profiler.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+		var status ProfilingStatus
+		if recorder.Code == http.StatusOK && path != RuntimeProfilerPprofPath+"mutex" {
+			if err := json.Unmarshal(recorder.Body.Bytes(), &status); err != nil {
+				t.Fatalf("%s %s: unexpected response %s: %v", method, path, recorder.Body, err)
+			}
+		}
+		return recorder, status
+	}
+
+	if recorder, _ := serve(http.MethodGet, RuntimeProfilerPprofPath+"mutex"); recorder.Code != http.StatusForbidden {
+		t.Errorf("expected the pprof endpoints to be disabled, got %d", recorder.Code)
+	}
+
+	tests := []struct {
+		method   string
+		path     string
+		code     int
+		expected ProfilingStatus
+	}{
+		{method: http.MethodGet, path: RuntimeProfilingPath, code: http.StatusOK},
+		{method: http.MethodPost, path: RuntimeProfilingPath + "?duration=2h", code: http.StatusBadRequest},
+		{method: http.MethodPost, path: RuntimeProfilingPath + "?mutex=many", code: http.StatusBadRequest},
+		{method: http.MethodPut, path: RuntimeProfilingPath, code: http.StatusMethodNotAllowed},
+		{method: http.MethodPost, path: RuntimeProfilingPath + "?duration=5m&mutex=5&block=10", code: http.StatusOK, expected: ProfilingStatus{Enabled: true, MutexProfileFraction: 5, BlockProfileRate: 10}},
+		{method: http.MethodGet, path: RuntimeProfilingPath, code: http.StatusOK, expected: ProfilingStatus{Enabled: true, MutexProfileFraction: 5, BlockProfileRate: 10}},
+		{method: http.MethodGet, path: RuntimeProfilerPprofPath + "mutex", code: http.StatusOK},
+		{method: http.MethodDelete, path: RuntimeProfilingPath, code: http.StatusOK},
+		{method: http.MethodGet, path: RuntimeProfilerPprofPath + "mutex", code: http.StatusForbidden},
+	}
+	for _, test := range tests {
+		recorder, status := serve(test.method, test.path)
+		if recorder.Code != test.code {
+			t.Fatalf("%s %s: expected %d, got %d: %s", test.method, test.path, test.code, recorder.Code, recorder.Body)
+		}
+		if status.Enabled != test.expected.Enabled || status.MutexProfileFraction != test.expected.MutexProfileFraction || status.BlockProfileRate != test.expected.BlockProfileRate {
+			t.Errorf("%s %s: expected %#v, got %#v", test.method, test.path, test.expected, status)
+		}
+		if status.Enabled && (status.Until == nil || status.Until.After(time.Now().Add(5*time.Minute))) {
+			t.Errorf("%s %s: unexpected end of the profiling %v", test.method, test.path, status.Until)
+		}
+	}
+	if fraction := runtime.SetMutexProfileFraction(-1); fraction != 0 {
+		t.Errorf("expected the mutex profiling to be disabled, got %d", fraction)
+	}
+}
+
+func TestRuntimeProfilerTimeout(t *testing.T) {
+	defer runtime.SetMutexProfileFraction(runtime.SetMutexProfileFraction(0))
+
+	profiler := NewRuntimeProfiler(time.Minute)
+	if err := profiler.Enable(ProfilingOptions{Duration: 10 * time.Millisecond, MutexProfileFraction: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if !profiler.Status().Enabled {
+		t.Fatalf("expected the profiling to be enabled")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for profiler.Status().Enabled {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the profiling to be disabled after its duration")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if fraction := runtime.SetMutexProfileFraction(-1); fraction != 0 {
+		t.Errorf("expected the mutex profiling to be disabled, got %d", fraction)
+	}
+}
@@ -0,0 +1,152 @@
+package generator
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ExpressionGeneratorName is the name of the ExpressionValueGenerator, used by the generate field of the template
+// parameters.
+const ExpressionGeneratorName = "expression"
+
+// Policy constrains the values of a generator, e.g. to match the requirements of the passwords of a database. The
+// zero value allows any value.
+type Policy struct {
+	// MinLength is the minimum number of characters of the values.
+	MinLength int
+	// MaxLength is the maximum number of characters of the values, unlimited when zero.
+	MaxLength int
+	// Charset are the characters allowed in the values, any character when empty.
+	Charset string
+	// RequiredCharsets are sets of characters of which the values must contain at least one, e.g. Numerals.
+	RequiredCharsets []string
+}
+
+// Validate returns an error if the value does not comply with the policy.
+func (p Policy) Validate(value string) error {
+	length := utf8.RuneCountInString(value)
+	if length < p.MinLength {
+		return fmt.Errorf("the generated value is %d characters long, at least %d are required", length, p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Errorf("the generated value is %d characters long, at most %d are allowed", length, p.MaxLength)
+	}
+	if len(p.Charset) > 0 {
+		for _, r := range value {
+			if !strings.ContainsRune(p.Charset, r) {
+				return fmt.Errorf("the generated value contains the character %q, which is not allowed", r)
+			}
+		}
+	}
+	for _, required := range p.RequiredCharsets {
+		if !strings.ContainsAny(value, required) {
+			return fmt.Errorf("the generated value must contain one of the characters %q", required)
+		}
+	}
+	return nil
+}
+
+// maxPolicyAttempts bounds the number of values generated to find one complying with a policy.
+const maxPolicyAttempts = 100
+
+// policyGenerator enforces the policy on the values of a generator.
+type policyGenerator struct {
+	generator Generator
+	policy    Policy
+}
+
+// WithPolicy returns a generator regenerating the values of the generator until one complies with the policy. It
+// fails after maxPolicyAttempts values, e.g. when the expression can never produce a compliant value.
+func WithPolicy(generator Generator, policy Policy) Generator {
+	return policyGenerator{generator: generator, policy: policy}
+}
+
+func (g policyGenerator) GenerateValue(expression string) (interface{}, error) {
+	var validationErr error
+	for i := 0; i < maxPolicyAttempts; i++ {
+		value, err := g.generator.GenerateValue(expression)
+		if err != nil {
+			return value, err
+		}
+		s, ok := value.(string)
+		if !ok {
+			return value, fmt.Errorf("the generated value %#v is not a string", value)
+		}
+		if validationErr = g.policy.Validate(s); validationErr == nil {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("no value complying with the policy was generated in %d attempts: %w", maxPolicyAttempts, validationErr)
+}
+
+// Registry holds the generators by name, so that products extending template processing can register their own
+// generators next to the expression generator, and pass them to the template processor.
+type Registry struct {
+	lock       sync.RWMutex
+	generators map[string]Generator
+}
+
+// NewRegistry creates a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{generators: map[string]Generator{}}
+}
+
+// NewDefaultRegistry creates a new Registry with the ExpressionValueGenerator and the UUIDGenerator, registered as
+// expression and uuid.
+func NewDefaultRegistry(seed *rand.Rand) *Registry {
+	r := NewRegistry()
+	r.generators[ExpressionGeneratorName] = NewExpressionValueGenerator(seed)
+	r.generators[UUIDGeneratorName] = NewUUIDGenerator(seed)
+	return r
+}
+
+// Register registers the generator with the name. The values of the generator are checked against the policies,
+// if any. It fails if a generator is already registered with the name.
+func (r *Registry) Register(name string, generator Generator, policies ...Policy) error {
+	if len(name) == 0 {
+		return fmt.Errorf("the name of the generator is required")
+	}
+	if generator == nil {
+		return fmt.Errorf("the generator %s is nil", name)
+	}
+	for _, policy := range policies {
+		generator = WithPolicy(generator, policy)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, exists := r.generators[name]; exists {
+		return fmt.Errorf("a generator is already registered as %s", name)
+	}
+	r.generators[name] = generator
+	return nil
+}
+
+// Names returns the sorted names of the registered generators.
+func (r *Registry) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	names := make([]string, 0, len(r.generators))
+	for name := range r.generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Generators returns a copy of the registered generators by name, e.g. for templateprocessing.NewProcessor.
+func (r *Registry) Generators() map[string]Generator {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	generators := make(map[string]Generator, len(r.generators))
+	for name, generator := range r.generators {
+		generators[name] = generator
+	}
+	return generators
+}
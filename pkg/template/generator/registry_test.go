@@ -0,0 +1,152 @@
+package generator
+
+import (
+	"encoding/base64"
+	"math/rand"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+type constantGenerator string
+
+func (g constantGenerator) GenerateValue(expression string) (interface{}, error) {
+	return string(g), nil
+}
+
+// sequenceGenerator generates its values in order, then the last one.
+type sequenceGenerator struct {
+	values []string
+	calls  *int
+}
+
+func (g sequenceGenerator) GenerateValue(expression string) (interface{}, error) {
+	i := *g.calls
+	*g.calls++
+	if i >= len(g.values) {
+		i = len(g.values) - 1
+	}
+	return g.values[i], nil
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewDefaultRegistry(rand.New(rand.NewSource(1337)))
+	if err := registry.Register("constant", constantGenerator("value")); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register(ExpressionGeneratorName, constantGenerator("value")); err == nil {
+		t.Errorf("expected an error registering the expression generator twice")
+	}
+	if err := registry.Register("nil", nil); err == nil {
+		t.Errorf("expected an error registering a nil generator")
+	}
+	if err := registry.Register("", constantGenerator("value")); err == nil {
+		t.Errorf("expected an error registering a generator without name")
+	}
+	if err := registry.Register("short", constantGenerator("value"), Policy{MinLength: 8}); err != nil {
+		t.Fatal(err)
+	}
+
+	if names, expected := registry.Names(), []string{"constant", "expression", "short", "uuid"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+	generators := registry.Generators()
+	if value, err := generators["constant"].GenerateValue(""); err != nil || value != "value" {
+		t.Errorf("expected value, got %v, %v", value, err)
+	}
+	if _, err := generators["short"].GenerateValue(""); err == nil {
+		t.Errorf("expected the policy to reject the value")
+	}
+	delete(generators, "constant")
+	if len(registry.Generators()) != 4 {
+		t.Errorf("expected the generators to be a copy")
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    Policy
+		value     string
+		expectErr bool
+	}{
+		{name: "zero policy", value: "anything goes!"},
+		{name: "min length", policy: Policy{MinLength: 4}, value: "abc", expectErr: true},
+		{name: "max length", policy: Policy{MaxLength: 4}, value: "abcde", expectErr: true},
+		{name: "length in runes", policy: Policy{MaxLength: 4}, value: "éééé"},
+		{name: "charset", policy: Policy{Charset: Alphabet + Numerals}, value: "abc123"},
+		{name: "charset violated", policy: Policy{Charset: Alphabet + Numerals}, value: "abc-123", expectErr: true},
+		{name: "required charsets", policy: Policy{RequiredCharsets: []string{Numerals, Symbols}}, value: "abc1!"},
+		{name: "required charsets violated", policy: Policy{RequiredCharsets: []string{Numerals, Symbols}}, value: "abc1", expectErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := WithPolicy(constantGenerator(tc.value), tc.policy).GenerateValue("")
+			if (err != nil) != tc.expectErr {
+				t.Errorf("expected error %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestPolicyExpressionGenerator(t *testing.T) {
+	generator := WithPolicy(NewExpressionValueGenerator(rand.New(rand.NewSource(1337))), Policy{MinLength: 16, Charset: Alphabet + Numerals})
+	if _, err := generator.GenerateValue("[\\a]{16}"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := generator.GenerateValue("[\\a]{8}"); err == nil {
+		t.Errorf("expected a value of 8 characters to be rejected")
+	}
+	if _, err := generator.GenerateValue("[\\A]{16}"); err == nil {
+		t.Errorf("expected a value with symbols to be rejected")
+	}
+}
+
+func TestPolicyRegeneratesValues(t *testing.T) {
+	calls := 0
+	generator := WithPolicy(sequenceGenerator{values: []string{"abc", "abc-", "abc1"}, calls: &calls}, Policy{Charset: Alphabet + Numerals, RequiredCharsets: []string{Numerals}})
+	value, err := generator.GenerateValue("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "abc1" || calls != 3 {
+		t.Errorf("expected the first compliant value after 3 attempts, got %v after %d", value, calls)
+	}
+
+	calls = 0
+	generator = WithPolicy(sequenceGenerator{values: []string{"abc"}, calls: &calls}, Policy{MinLength: 8})
+	if _, err := generator.GenerateValue(""); err == nil {
+		t.Errorf("expected an error when no value complies with the policy")
+	}
+	if calls != maxPolicyAttempts {
+		t.Errorf("expected %d attempts, got %d", maxPolicyAttempts, calls)
+	}
+}
+
+func TestUUIDGenerator(t *testing.T) {
+	uuidExp := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	generator := NewUUIDGenerator(rand.New(rand.NewSource(1337)))
+	first, err := generator.GenerateValue("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := generator.GenerateValue("")
+	for _, value := range []interface{}{first, second} {
+		if !uuidExp.MatchString(value.(string)) {
+			t.Errorf("%s is not a version 4 UUID", value)
+		}
+	}
+	if first == second {
+		t.Errorf("expected different UUIDs, got %s twice", first)
+	}
+}
+
+func TestBase64Generator(t *testing.T) {
+	value, err := NewBase64Generator(constantGenerator("secret")).GenerateValue("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := base64.StdEncoding.EncodeToString([]byte("secret")); value != expected {
+		t.Errorf("expected %s, got %s", expected, value)
+	}
+}
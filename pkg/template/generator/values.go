@@ -0,0 +1,62 @@
+package generator
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+)
+
+// UUIDGeneratorName is the name of the UUIDGenerator in the default registry.
+const UUIDGeneratorName = "uuid"
+
+// UUIDGenerator implements Generator interface. It generates random
+// (version 4) UUIDs, ignoring the input expression.
+//
+// Example: "8c7ba7a1-24b1-4d7a-9c8a-0a3f3c1e0e52"
+type UUIDGenerator struct {
+	seed *rand.Rand
+}
+
+// NewUUIDGenerator creates new UUIDGenerator.
+func NewUUIDGenerator(seed *rand.Rand) UUIDGenerator {
+	return UUIDGenerator{seed: seed}
+}
+
+// GenerateValue generates a random UUID.
+func (g UUIDGenerator) GenerateValue(expression string) (interface{}, error) {
+	var uuid [16]byte
+	if _, err := g.seed.Read(uuid[:]); err != nil {
+		return "", err
+	}
+	uuid[6] = (uuid[6] & 0x0f) | 0x40 // version 4
+	uuid[8] = (uuid[8] & 0x3f) | 0x80 // RFC 4122 variant
+	s := hex.EncodeToString(uuid[:])
+	return fmt.Sprintf("%s-%s-%s-%s-%s", s[0:8], s[8:12], s[12:16], s[16:20], s[20:]), nil
+}
+
+// Base64Generator implements Generator interface. It encodes in base64
+// the values of another generator, e.g. to generate the random bytes of a
+// key from the expression "[\A\w]{32}".
+type Base64Generator struct {
+	generator Generator
+}
+
+// NewBase64Generator creates new Base64Generator encoding the values of the generator.
+func NewBase64Generator(generator Generator) Base64Generator {
+	return Base64Generator{generator: generator}
+}
+
+// GenerateValue generates the value of the expression with the wrapped
+// generator and encodes it in base64.
+func (g Base64Generator) GenerateValue(expression string) (interface{}, error) {
+	value, err := g.generator.GenerateValue(expression)
+	if err != nil {
+		return "", err
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("the generated value %#v is not a string", value)
+	}
+	return base64.StdEncoding.EncodeToString([]byte(s)), nil
+}
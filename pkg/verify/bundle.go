@@ -0,0 +1,128 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+
+	"github.com/openshift/library-go/pkg/verify/store/memory"
+)
+
+// BundleVersion is the version of the bundle format.
+const BundleVersion = "v1"
+
+// Bundle is the material needed to verify release digests offline: the GPG keyrings of a verifier, and the
+// signatures of release digests satisfying it. It is portable as JSON, e.g. to verify release payloads in an
+// air-gapped environment before mirroring them.
+type Bundle struct {
+	// Version is the version of the bundle format, BundleVersion.
+	Version string `json:"version"`
+	// Verifiers are the ASCII armored GPG keyrings, by name.
+	Verifiers map[string]string `json:"verifiers"`
+	// Signatures are the signatures of the release digests, by release digest.
+	Signatures map[string][][]byte `json:"signatures"`
+}
+
+// ExportBundle verifies the release digests with the verifier, and returns a bundle with the keyrings of the verifier
+// and the signatures of the release digests. An error is returned when a release digest cannot be verified, or the
+// verifier has no GPG keyrings.
+func ExportBundle(ctx context.Context, verifier Interface, releaseDigests ...string) (*Bundle, error) {
+	if len(releaseDigests) == 0 {
+		return nil, fmt.Errorf("no release digests to export")
+	}
+	verifiers := verifier.Verifiers()
+	if len(verifiers) == 0 {
+		return nil, fmt.Errorf("the verifier has no GPG keyrings to export")
+	}
+
+	bundle := &Bundle{
+		Version:    BundleVersion,
+		Verifiers:  make(map[string]string, len(verifiers)),
+		Signatures: make(map[string][][]byte, len(releaseDigests)),
+	}
+	for name, keyring := range verifiers {
+		armored, err := armorKeyring(keyring)
+		if err != nil {
+			return nil, fmt.Errorf("unable to export the keyring %q: %w", name, err)
+		}
+		bundle.Verifiers[name] = armored
+	}
+	for _, releaseDigest := range releaseDigests {
+		if err := verifier.Verify(ctx, releaseDigest); err != nil {
+			return nil, fmt.Errorf("unable to export %s: %w", releaseDigest, err)
+		}
+		// read the signatures right after the verification, before they can be evicted from the cache
+		signatures := verifier.Signatures()[releaseDigest]
+		if len(signatures) == 0 {
+			return nil, fmt.Errorf("unable to export %s: the verifier did not record its signatures", releaseDigest)
+		}
+		bundle.Signatures[releaseDigest] = signatures
+	}
+	return bundle, nil
+}
+
+// LoadBundle parses a bundle serialized as JSON.
+func LoadBundle(data []byte) (*Bundle, error) {
+	bundle := &Bundle{}
+	if err := json.Unmarshal(data, bundle); err != nil {
+		return nil, fmt.Errorf("the bundle is not valid: %w", err)
+	}
+	if bundle.Version != BundleVersion {
+		return nil, fmt.Errorf("the bundle version %q is not supported, expected %q", bundle.Version, BundleVersion)
+	}
+	return bundle, nil
+}
+
+// Marshal serializes the bundle as JSON.
+func (b *Bundle) Marshal() ([]byte, error) {
+	return json.MarshalIndent(b, "", "  ")
+}
+
+// ReleaseDigests returns the sorted release digests of the bundle.
+func (b *Bundle) ReleaseDigests() []string {
+	digests := make([]string, 0, len(b.Signatures))
+	for digest := range b.Signatures {
+		digests = append(digests, digest)
+	}
+	sort.Strings(digests)
+	return digests
+}
+
+// NewFromBundle creates a release verifier with the keyrings of the bundle, verifying release digests with the
+// signatures of the bundle only. It does not access the network unless stores are added to it.
+func NewFromBundle(bundle *Bundle) (Interface, error) {
+	if len(bundle.Verifiers) == 0 {
+		return nil, fmt.Errorf("the bundle has no verifiers")
+	}
+	verifiers := make(map[string]openpgp.EntityList, len(bundle.Verifiers))
+	for name, armored := range bundle.Verifiers {
+		keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewBufferString(armored))
+		if err != nil {
+			return nil, fmt.Errorf("the bundle verifier %q is not a valid keyring: %w", name, err)
+		}
+		verifiers[name] = keyring
+	}
+	return NewReleaseVerifier(verifiers, &memory.Store{Data: bundle.Signatures}), nil
+}
+
+func armorKeyring(keyring openpgp.EntityList) (string, error) {
+	buf := &bytes.Buffer{}
+	w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return "", err
+	}
+	for _, entity := range keyring {
+		if err := entity.Serialize(w); err != nil {
+			return "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,130 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/openshift/library-go/pkg/verify/store/memory"
+)
+
+func TestBundle(t *testing.T) {
+	loadKeyring := func(name string) openpgp.EntityList {
+		data, err := os.ReadFile(filepath.Join("testdata", "keyrings", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewBuffer(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return keyring
+	}
+	redhatPublic := loadKeyring("redhat.txt")
+	simple := loadKeyring("simple.txt")
+
+	const (
+		signedDigest   = "sha256:e3f12513a4b22a2d7c0e7c9207f52128113758d9d68c7d06b11a0ac7672966f7"
+		unsignedDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	)
+	signature, err := os.ReadFile(filepath.Join("testdata", "signatures", strings.Replace(signedDigest, ":", "=", 1), "signature-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signatureStore := &memory.Store{Data: map[string][][]byte{signedDigest: {signature}}}
+
+	verifier := NewReleaseVerifier(map[string]openpgp.EntityList{"redhat": redhatPublic}, signatureStore)
+	if _, err := ExportBundle(context.Background(), verifier, signedDigest, unsignedDigest); err == nil || !strings.HasPrefix(err.Error(), "unable to export "+unsignedDigest) {
+		t.Fatalf("unexpected error exporting an unsigned digest: %v", err)
+	}
+	if _, err := ExportBundle(context.Background(), NewReleaseVerifier(nil, signatureStore), signedDigest); err == nil {
+		t.Fatal("expected error exporting a verifier without keyrings")
+	}
+
+	bundle, err := ExportBundle(context.Background(), verifier, signedDigest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digests := bundle.ReleaseDigests(); !reflect.DeepEqual(digests, []string{signedDigest}) {
+		t.Errorf("unexpected release digests %v", digests)
+	}
+	data, err := bundle.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadBundle(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(bundle, loaded) {
+		t.Errorf("the loaded bundle differs from the exported one")
+	}
+	offline, err := NewFromBundle(loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := offline.Verify(context.Background(), signedDigest); err != nil {
+		t.Errorf("unexpected error verifying with the bundle: %v", err)
+	}
+	if err := offline.Verify(context.Background(), unsignedDigest); err == nil {
+		t.Error("expected error verifying a digest missing from the bundle")
+	}
+
+	// the signatures of a bundle are not trusted without its keyrings
+	tampered, err := LoadBundle(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered.Verifiers["redhat"], err = armorKeyring(simple)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offline, err = NewFromBundle(tampered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := offline.Verify(context.Background(), signedDigest); err == nil {
+		t.Error("expected error verifying with another keyring")
+	}
+}
+
+func TestLoadBundle(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		expectedErr string
+	}{
+		{
+			name:        "not JSON",
+			data:        "verifiers:",
+			expectedErr: "the bundle is not valid: ",
+		},
+		{
+			name:        "unknown version",
+			data:        `{"version": "v2", "verifiers": {}, "signatures": {}}`,
+			expectedErr: `the bundle version "v2" is not supported, expected "v1"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadBundle([]byte(tt.data)); err == nil || !strings.HasPrefix(err.Error(), tt.expectedErr) {
+				t.Errorf("expected error %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+
+	bundle, err := LoadBundle([]byte(`{"version": "v1", "verifiers": {"invalid": "not a keyring"}, "signatures": {}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFromBundle(bundle); err == nil || !strings.HasPrefix(err.Error(), `the bundle verifier "invalid" is not a valid keyring`) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,325 @@
+package verify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// cosignSignature is a cosign signature of a release image, as it is found in signature stores: the signed simple
+// signing payload, and the signature bundle created by 'cosign sign --bundle'.
+//
+//	{
+//		"payload": "<base64 simple signing payload>",
+//		"base64Signature": "<base64 signature of the payload>",
+//		"cert": "<PEM Fulcio certificate, for keyless signatures>",
+//		"chain": "<PEM Fulcio intermediate certificates>",
+//		"rekorBundle": {
+//			"SignedEntryTimestamp": "<base64 signature of the Rekor log>",
+//			"Payload": {"body": "<base64 hashedrekord entry>", "integratedTime": 1700000000, "logIndex": 1, "logID": "<hex>"}
+//		}
+//	}
+type cosignSignature struct {
+	Payload         []byte       `json:"payload"`
+	Base64Signature string       `json:"base64Signature"`
+	Cert            string       `json:"cert,omitempty"`
+	Chain           string       `json:"chain,omitempty"`
+	RekorBundle     *rekorBundle `json:"rekorBundle,omitempty"`
+}
+
+type rekorBundle struct {
+	SignedEntryTimestamp []byte       `json:"SignedEntryTimestamp"`
+	Payload              rekorPayload `json:"Payload"`
+}
+
+// rekorPayload is signed by the Rekor log in the SignedEntryTimestamp. Its fields are in the order of their
+// canonical JSON serialization.
+type rekorPayload struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+}
+
+// hashedRekordEntry is the part of a hashedrekord Rekor entry that binds it to the signature.
+type hashedRekordEntry struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Data struct {
+			Hash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"hash"`
+		} `json:"data"`
+		Signature struct {
+			Content string `json:"content"`
+		} `json:"signature"`
+	} `json:"spec"`
+}
+
+// A cosign simple signing payload has the following schema, where optional may contain any annotations:
+//
+//	{
+//		"critical": {
+//			"type": "cosign container image signature",
+//			"image": {"docker-manifest-digest": "sha256:..."},
+//			"identity": {"docker-reference": "quay.io/openshift-release-dev/ocp-release"}
+//		},
+//		"optional": {}
+//	}
+type cosignPayload struct {
+	Critical criticalSignature      `json:"critical"`
+	Optional map[string]interface{} `json:"optional"`
+}
+
+// KeylessIdentity is the identity expected in the Fulcio certificates of keyless cosign signatures.
+type KeylessIdentity struct {
+	// FulcioRoots are the trusted Fulcio root certificates.
+	FulcioRoots *x509.CertPool
+	// RekorPublicKeys are the trusted keys of the Rekor transparency log, that must include the signatures.
+	RekorPublicKeys []crypto.PublicKey
+	// Issuer is the OIDC issuer of the identity, e.g. https://accounts.google.com.
+	Issuer string
+	// Subject is the email address or the URI of the identity. SubjectRegexp matches them instead, when set.
+	Subject       string
+	SubjectRegexp *regexp.Regexp
+}
+
+var (
+	// fulcioIssuerV1OID holds the OIDC issuer as a raw string, fulcioIssuerV2OID as a DER-encoded UTF8String.
+	fulcioIssuerV1OID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	fulcioIssuerV2OID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// isCosignSignature returns whether a signature from a store is a cosign signature rather than a GPG signature.
+func isCosignSignature(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+func parseCosignSignature(data []byte) (*cosignSignature, []byte, error) {
+	sig := &cosignSignature{}
+	if err := json.Unmarshal(data, sig); err != nil {
+		return nil, nil, fmt.Errorf("the cosign signature is not valid JSON: %v", err)
+	}
+	rawSignature, err := base64.StdEncoding.DecodeString(sig.Base64Signature)
+	if err != nil || len(rawSignature) == 0 {
+		return nil, nil, fmt.Errorf("the cosign signature has no valid base64Signature")
+	}
+	return sig, rawSignature, nil
+}
+
+// verifyCosignSignatureWithKeys verifies a cosign signature made with one of the pinned public keys.
+func verifyCosignSignatureWithKeys(data []byte, releaseDigest string, publicKeys []crypto.PublicKey) error {
+	sig, rawSignature, err := parseCosignSignature(data)
+	if err != nil {
+		return err
+	}
+	verified := false
+	for _, publicKey := range publicKeys {
+		if verifyPayloadSignature(publicKey, sig.Payload, rawSignature) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return fmt.Errorf("the cosign signature was not made by any of the %d trusted public keys", len(publicKeys))
+	}
+	return verifyCosignPayload(sig.Payload, releaseDigest)
+}
+
+// verifyKeylessCosignSignature verifies a keyless cosign signature: the Fulcio certificate must chain to a trusted
+// root and carry the expected identity, its key must have signed the payload, and the signature must be included
+// in the Rekor log while the short-lived certificate was valid.
+func verifyKeylessCosignSignature(data []byte, releaseDigest string, identity *KeylessIdentity) error {
+	sig, rawSignature, err := parseCosignSignature(data)
+	if err != nil {
+		return err
+	}
+	if len(sig.Cert) == 0 {
+		return fmt.Errorf("the cosign signature has no Fulcio certificate")
+	}
+	certs, err := parsePEMCertificates([]byte(sig.Cert))
+	if err != nil {
+		return fmt.Errorf("invalid Fulcio certificate: %v", err)
+	}
+	cert := certs[0]
+	intermediates := x509.NewCertPool()
+	if len(sig.Chain) > 0 {
+		chain, err := parsePEMCertificates([]byte(sig.Chain))
+		if err != nil {
+			return fmt.Errorf("invalid Fulcio certificate chain: %v", err)
+		}
+		for _, intermediate := range chain {
+			intermediates.AddCert(intermediate)
+		}
+	}
+
+	if sig.RekorBundle == nil {
+		return fmt.Errorf("the keyless cosign signature has no Rekor bundle")
+	}
+	integratedTime, err := verifyRekorBundle(sig.RekorBundle, sig.Payload, rawSignature, identity.RekorPublicKeys)
+	if err != nil {
+		return err
+	}
+
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         identity.FulcioRoots,
+		Intermediates: intermediates,
+		CurrentTime:   integratedTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}); err != nil {
+		return fmt.Errorf("the Fulcio certificate is not trusted when the signature was logged at %s: %v", integratedTime.UTC().Format(time.RFC3339), err)
+	}
+	if err := verifyFulcioIdentity(cert, identity); err != nil {
+		return err
+	}
+	if err := verifyPayloadSignature(cert.PublicKey, sig.Payload, rawSignature); err != nil {
+		return fmt.Errorf("the cosign signature was not made by the key of the Fulcio certificate: %v", err)
+	}
+	return verifyCosignPayload(sig.Payload, releaseDigest)
+}
+
+// verifyRekorBundle verifies that a trusted Rekor log signed the inclusion of the signature, and returns when.
+func verifyRekorBundle(bundle *rekorBundle, payload, rawSignature []byte, rekorPublicKeys []crypto.PublicKey) (time.Time, error) {
+	canonicalPayload, err := json.Marshal(bundle.Payload)
+	if err != nil {
+		return time.Time{}, err
+	}
+	verified := false
+	for _, publicKey := range rekorPublicKeys {
+		if verifyPayloadSignature(publicKey, canonicalPayload, bundle.SignedEntryTimestamp) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return time.Time{}, fmt.Errorf("the Rekor bundle was not signed by any of the %d trusted Rekor public keys", len(rekorPublicKeys))
+	}
+
+	body, err := base64.StdEncoding.DecodeString(bundle.Payload.Body)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid Rekor entry: %v", err)
+	}
+	entry := hashedRekordEntry{}
+	if err := json.Unmarshal(body, &entry); err != nil {
+		return time.Time{}, fmt.Errorf("invalid Rekor entry: %v", err)
+	}
+	if entry.Kind != "hashedrekord" {
+		return time.Time{}, fmt.Errorf("unsupported Rekor entry kind %q", entry.Kind)
+	}
+	payloadHash := sha256.Sum256(payload)
+	if entry.Spec.Data.Hash.Algorithm != "sha256" || entry.Spec.Data.Hash.Value != hex.EncodeToString(payloadHash[:]) {
+		return time.Time{}, fmt.Errorf("the Rekor entry does not match the signed payload")
+	}
+	if entry.Spec.Signature.Content != base64.StdEncoding.EncodeToString(rawSignature) {
+		return time.Time{}, fmt.Errorf("the Rekor entry does not match the signature")
+	}
+	return time.Unix(bundle.Payload.IntegratedTime, 0), nil
+}
+
+func verifyFulcioIdentity(cert *x509.Certificate, identity *KeylessIdentity) error {
+	issuer := ""
+	for _, extension := range cert.Extensions {
+		switch {
+		case extension.Id.Equal(fulcioIssuerV2OID):
+			if _, err := asn1.Unmarshal(extension.Value, &issuer); err != nil {
+				return fmt.Errorf("invalid OIDC issuer in the Fulcio certificate: %v", err)
+			}
+		case extension.Id.Equal(fulcioIssuerV1OID) && len(issuer) == 0:
+			issuer = string(extension.Value)
+		}
+	}
+	if issuer != identity.Issuer {
+		return fmt.Errorf("the Fulcio certificate was issued for an identity of the OIDC issuer %q instead of %q", issuer, identity.Issuer)
+	}
+
+	var subjects []string
+	subjects = append(subjects, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		subjects = append(subjects, uri.String())
+	}
+	for _, subject := range subjects {
+		if identity.SubjectRegexp != nil && identity.SubjectRegexp.MatchString(subject) || identity.SubjectRegexp == nil && subject == identity.Subject {
+			return nil
+		}
+	}
+	expected := identity.Subject
+	if identity.SubjectRegexp != nil {
+		expected = identity.SubjectRegexp.String()
+	}
+	return fmt.Errorf("the Fulcio certificate was issued for %v instead of %q", subjects, expected)
+}
+
+// verifyPayloadSignature verifies a signature of the SHA-256 digest of the payload, or of the payload itself for
+// Ed25519 keys.
+func verifyPayloadSignature(publicKey crypto.PublicKey, payload, signature []byte) error {
+	digest := sha256.Sum256(payload)
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], signature) {
+			return fmt.Errorf("invalid ECDSA signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, payload, signature) {
+			return fmt.Errorf("invalid Ed25519 signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
+
+func verifyCosignPayload(payload []byte, releaseDigest string) error {
+	var p cosignPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("the cosign payload is not valid JSON: %v", err)
+	}
+	if p.Critical.Type != "cosign container image signature" {
+		return fmt.Errorf("the cosign payload is not the correct type")
+	}
+	if len(p.Critical.Identity.DockerReference) == 0 {
+		return fmt.Errorf("the cosign payload must have an identity")
+	}
+	if p.Critical.Image.DockerManifestDigest != releaseDigest {
+		return fmt.Errorf("the cosign payload digest does not match")
+	}
+	return nil
+}
+
+func parsePEMCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no PEM certificate found")
+	}
+	return certs, nil
+}
@@ -0,0 +1,139 @@
+package manifest
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/openshift/library-go/pkg/verify"
+)
+
+// maxManifestFileSize bounds the size of the files read from the release-manifests directory of the layers.
+const maxManifestFileSize = 16 * 1024 * 1024
+
+// LayerFunc opens the blob of an image layer by digest.
+type LayerFunc func(ctx context.Context, digest string) (io.ReadCloser, error)
+
+// imageManifest is the subset of the OCI and Docker schema 2 image manifests describing the layers.
+type imageManifest struct {
+	SchemaVersion int `json:"schemaVersion"`
+	Layers        []struct {
+		MediaType string `json:"mediaType"`
+		Digest    string `json:"digest"`
+	} `json:"layers"`
+}
+
+// ReadVerifiedRelease reads the release metadata of the release image with the image manifest, after verifying the
+// digest of the image manifest with the verifier. The layers of the image are opened with layerFn, and their digests
+// are checked against the verified image manifest, so that the metadata is only read from content signed by the
+// release signers. The image manifest must be an OCI or Docker schema 2 manifest with gzip compressed layers.
+func ReadVerifiedRelease(ctx context.Context, verifier verify.Interface, manifest []byte, layerFn LayerFunc) (*Release, error) {
+	releaseDigest := sha256Digest(manifest)
+	if err := verifier.Verify(ctx, releaseDigest); err != nil {
+		return nil, err
+	}
+
+	parsed := &imageManifest{}
+	if err := json.Unmarshal(manifest, parsed); err != nil {
+		return nil, fmt.Errorf("the image manifest %s is not valid: %w", releaseDigest, err)
+	}
+	if parsed.SchemaVersion != 2 || len(parsed.Layers) == 0 {
+		return nil, fmt.Errorf("the image manifest %s is not a schema 2 image manifest", releaseDigest)
+	}
+
+	files := map[string][]byte{}
+	for _, layer := range parsed.Layers {
+		if !strings.HasPrefix(layer.Digest, "sha256:") {
+			return nil, fmt.Errorf("the layer %s of %s has an unsupported digest algorithm", layer.Digest, releaseDigest)
+		}
+		if err := readLayer(ctx, layerFn, layer.Digest, files); err != nil {
+			return nil, fmt.Errorf("unable to read the layer %s of %s: %w", layer.Digest, releaseDigest, err)
+		}
+	}
+
+	release, err := releaseFromFiles(files)
+	if err != nil {
+		return nil, fmt.Errorf("the release image %s is not valid: %w", releaseDigest, err)
+	}
+	release.Digest = releaseDigest
+	return release, nil
+}
+
+// readLayer applies the changes of the layer to the files of the release-manifests directory. The whole layer is read
+// to check its digest before the changes are applied.
+func readLayer(ctx context.Context, layerFn LayerFunc, digest string, files map[string][]byte) error {
+	blob, err := layerFn(ctx, digest)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	hash := sha256.New()
+	gzipReader, err := gzip.NewReader(io.TeeReader(blob, hash))
+	if err != nil {
+		return err
+	}
+
+	changes := map[string][]byte{}
+	var deleted []string
+	opaque := false
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		dir, name := path.Split(path.Clean(strings.TrimPrefix(header.Name, "/")))
+		if path.Clean(dir) != ReleaseManifestsDir {
+			continue
+		}
+		switch {
+		case name == ".wh..wh..opq":
+			opaque = true
+		case strings.HasPrefix(name, ".wh."):
+			deleted = append(deleted, strings.TrimPrefix(name, ".wh."))
+		case header.Typeflag == tar.TypeReg:
+			if header.Size > maxManifestFileSize {
+				return fmt.Errorf("the file %s is larger than %d bytes", header.Name, maxManifestFileSize)
+			}
+			data, err := io.ReadAll(tarReader)
+			if err != nil {
+				return err
+			}
+			changes[name] = data
+		}
+	}
+	// read the rest of the blob, e.g. the gzip footer and padding, to compute the digest of the whole blob
+	if _, err := io.Copy(io.Discard, gzipReader); err != nil {
+		return err
+	}
+	if _, err := io.Copy(hash, blob); err != nil {
+		return err
+	}
+	if actual := "sha256:" + hex.EncodeToString(hash.Sum(nil)); actual != digest {
+		return fmt.Errorf("the layer digest is %s", actual)
+	}
+
+	if opaque {
+		for name := range files {
+			delete(files, name)
+		}
+	}
+	for _, name := range deleted {
+		delete(files, name)
+	}
+	for name, data := range changes {
+		files[name] = data
+	}
+	return nil
+}
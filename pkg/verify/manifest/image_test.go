@@ -0,0 +1,133 @@
+package manifest
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/openshift/library-go/pkg/verify"
+	"github.com/openshift/library-go/pkg/verify/store"
+)
+
+// fakeVerifier accepts the release digests it is configured with.
+type fakeVerifier struct {
+	accepted map[string]bool
+}
+
+func (v *fakeVerifier) Verify(ctx context.Context, releaseDigest string) error {
+	if !v.accepted[releaseDigest] {
+		return fmt.Errorf("%s is not signed", releaseDigest)
+	}
+	return nil
+}
+
+func (v *fakeVerifier) Signatures() map[string][][]byte { return nil }
+
+func (v *fakeVerifier) Verifiers() map[string]openpgp.EntityList { return nil }
+
+func (v *fakeVerifier) AddStore(additionalStore store.Store) {}
+
+var _ verify.Interface = &fakeVerifier{}
+
+func layer(t *testing.T, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, content := range files {
+		if err := tarWriter.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tarWriter.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func imageManifestFor(layers ...[]byte) []byte {
+	var descriptors []string
+	for _, layer := range layers {
+		descriptors = append(descriptors, fmt.Sprintf(`{"mediaType": "application/vnd.docker.image.rootfs.diff.tar.gzip", "size": %d, "digest": %q}`, len(layer), sha256Digest(layer)))
+	}
+	return []byte(fmt.Sprintf(`{"schemaVersion": 2, "mediaType": "application/vnd.docker.distribution.manifest.v2+json", "layers": [%s]}`, strings.Join(descriptors, ", ")))
+}
+
+func TestReadVerifiedRelease(t *testing.T) {
+	baseLayer := layer(t, map[string]string{
+		"usr/bin/cluster-version-operator":                    "binary",
+		"release-manifests/release-metadata":                  `{"kind": "cincinnati-metadata-v0", "version": "4.14.0"}`,
+		"release-manifests/0000_50_removed.yaml":              "removed",
+		"release-manifests/0000_00_namespace.yaml":            testManifest,
+		"release-manifests/nested/0000_90_not-a-manifest.yml": "nested",
+	})
+	payloadLayer := layer(t, map[string]string{
+		"/release-manifests/release-metadata":        testReleaseMetadata,
+		"release-manifests/image-references":         testImageReferences,
+		"release-manifests/.wh.0000_50_removed.yaml": "",
+	})
+	layers := map[string][]byte{sha256Digest(baseLayer): baseLayer, sha256Digest(payloadLayer): payloadLayer}
+	layerFn := func(ctx context.Context, digest string) (io.ReadCloser, error) {
+		data, ok := layers[digest]
+		if !ok {
+			return nil, fmt.Errorf("blob unknown")
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	manifest := imageManifestFor(baseLayer, payloadLayer)
+	releaseDigest := sha256Digest(manifest)
+	verifier := &fakeVerifier{accepted: map[string]bool{releaseDigest: true}}
+
+	release, err := ReadVerifiedRelease(context.Background(), verifier, manifest, layerFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if release.Digest != releaseDigest || release.Version != "4.14.1" || len(release.Images) != 2 {
+		t.Errorf("unexpected release %#v", release)
+	}
+	if len(release.ManifestDigests) != 3 || release.ManifestDigests["0000_00_namespace.yaml"] != sha256Digest([]byte(testManifest)) {
+		t.Errorf("unexpected manifest digests %v", release.ManifestDigests)
+	}
+
+	t.Run("unsigned", func(t *testing.T) {
+		unsigned := imageManifestFor(payloadLayer, baseLayer)
+		if _, err := ReadVerifiedRelease(context.Background(), verifier, unsigned, layerFn); err == nil || err.Error() != sha256Digest(unsigned)+" is not signed" {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+
+	t.Run("tampered layer", func(t *testing.T) {
+		tampered := layer(t, map[string]string{"release-manifests/image-references": `{"kind": "ImageStream"}`})
+		tamperedFn := func(ctx context.Context, digest string) (io.ReadCloser, error) {
+			if digest == sha256Digest(payloadLayer) {
+				return io.NopCloser(bytes.NewReader(tampered)), nil
+			}
+			return layerFn(ctx, digest)
+		}
+		_, err := ReadVerifiedRelease(context.Background(), verifier, manifest, tamperedFn)
+		if expected := fmt.Sprintf("unable to read the layer %s of %s: the layer digest is %s", sha256Digest(payloadLayer), releaseDigest, sha256Digest(tampered)); err == nil || err.Error() != expected {
+			t.Errorf("expected error %q, got %v", expected, err)
+		}
+	})
+
+	t.Run("incomplete payload", func(t *testing.T) {
+		incomplete := imageManifestFor(baseLayer)
+		verifier := &fakeVerifier{accepted: map[string]bool{sha256Digest(incomplete): true}}
+		_, err := ReadVerifiedRelease(context.Background(), verifier, incomplete, layerFn)
+		if expected := fmt.Sprintf("the release image %s is not valid: the release has no image-references file", sha256Digest(incomplete)); err == nil || err.Error() != expected {
+			t.Errorf("expected error %q, got %v", expected, err)
+		}
+	})
+}
@@ -0,0 +1,169 @@
+// Package manifest extracts the metadata of release images: the version, the component images and the digests of the
+// manifests of the payload, without a cluster.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+
+	"github.com/blang/semver"
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/library-go/pkg/image/reference"
+)
+
+const (
+	// ReleaseManifestsDir is the directory of the payload in release images.
+	ReleaseManifestsDir = "release-manifests"
+	// ReleaseMetadataFile describes the release for the update graph.
+	ReleaseMetadataFile = "release-metadata"
+	// ImageReferencesFile is the image stream of the component images of the release.
+	ImageReferencesFile = "image-references"
+
+	cincinnatiMetadataKind = "cincinnati-metadata-v0"
+)
+
+// releaseMetadata is the content of the release-metadata file.
+type releaseMetadata struct {
+	Kind     string            `json:"kind"`
+	Version  string            `json:"version"`
+	Previous []string          `json:"previous,omitempty"`
+	Next     []string          `json:"next,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Release is the metadata of a release image.
+type Release struct {
+	// Digest is the digest of the release image. It is only set when the release was read from the image.
+	Digest string
+	// Version is the semantic version of the release.
+	Version string
+	// Previous are the versions the release can be updated from.
+	Previous []string
+	// Metadata are the update graph metadata of the release, e.g. its channels.
+	Metadata map[string]string
+	// Images are the pull specs of the component images by name. They all reference images by digest.
+	Images map[string]string
+	// ManifestDigests are the sha256 digests of the files of the release-manifests directory by file name, including
+	// the release-metadata and image-references files.
+	ManifestDigests map[string]string
+}
+
+// ImageNames returns the sorted names of the component images.
+func (r *Release) ImageNames() []string {
+	names := make([]string, 0, len(r.Images))
+	for name := range r.Images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReadRelease reads the release metadata from the file system of an extracted release image, whose root contains
+// the release-manifests directory.
+func ReadRelease(fsys fs.FS) (*Release, error) {
+	files := map[string][]byte{}
+	entries, err := fs.ReadDir(fsys, ReleaseManifestsDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(ReleaseManifestsDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files[entry.Name()] = data
+	}
+	return releaseFromFiles(files)
+}
+
+// releaseFromFiles parses and validates the release metadata from the files of the release-manifests directory.
+func releaseFromFiles(files map[string][]byte) (*Release, error) {
+	metadataData, ok := files[ReleaseMetadataFile]
+	if !ok {
+		return nil, fmt.Errorf("the release has no %s file", ReleaseMetadataFile)
+	}
+	metadata := &releaseMetadata{}
+	if err := json.Unmarshal(metadataData, metadata); err != nil {
+		return nil, fmt.Errorf("the %s file is not valid: %w", ReleaseMetadataFile, err)
+	}
+	if metadata.Kind != cincinnatiMetadataKind {
+		return nil, fmt.Errorf("the %s file has the unsupported kind %q, expected %q", ReleaseMetadataFile, metadata.Kind, cincinnatiMetadataKind)
+	}
+	if _, err := semver.Parse(metadata.Version); err != nil {
+		return nil, fmt.Errorf("the %s file has an invalid version %q: %w", ReleaseMetadataFile, metadata.Version, err)
+	}
+	for _, previous := range metadata.Previous {
+		if _, err := semver.Parse(previous); err != nil {
+			return nil, fmt.Errorf("the %s file has an invalid previous version %q: %w", ReleaseMetadataFile, previous, err)
+		}
+	}
+
+	images, err := readImageReferences(files, metadata.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	release := &Release{
+		Version:         metadata.Version,
+		Previous:        metadata.Previous,
+		Metadata:        metadata.Metadata,
+		Images:          images,
+		ManifestDigests: make(map[string]string, len(files)),
+	}
+	for name, data := range files {
+		release.ManifestDigests[name] = sha256Digest(data)
+	}
+	return release, nil
+}
+
+// readImageReferences returns the pull specs of the component images of the image-references file, which must all
+// reference images by digest.
+func readImageReferences(files map[string][]byte, version string) (map[string]string, error) {
+	data, ok := files[ImageReferencesFile]
+	if !ok {
+		return nil, fmt.Errorf("the release has no %s file", ImageReferencesFile)
+	}
+	imageStream := &imagev1.ImageStream{}
+	if err := json.Unmarshal(data, imageStream); err != nil {
+		return nil, fmt.Errorf("the %s file is not valid: %w", ImageReferencesFile, err)
+	}
+	if imageStream.Kind != "ImageStream" {
+		return nil, fmt.Errorf("the %s file is a %q, expected an ImageStream", ImageReferencesFile, imageStream.Kind)
+	}
+	if len(imageStream.Name) > 0 && imageStream.Name != version {
+		return nil, fmt.Errorf("the %s file is for version %q, expected %q", ImageReferencesFile, imageStream.Name, version)
+	}
+
+	images := make(map[string]string, len(imageStream.Spec.Tags))
+	for _, tag := range imageStream.Spec.Tags {
+		if tag.From == nil || tag.From.Kind != "DockerImage" {
+			return nil, fmt.Errorf("the image %q of the %s file does not reference a DockerImage", tag.Name, ImageReferencesFile)
+		}
+		if _, ok := images[tag.Name]; ok {
+			return nil, fmt.Errorf("the image %q of the %s file is duplicated", tag.Name, ImageReferencesFile)
+		}
+		ref, err := reference.Parse(tag.From.Name)
+		if err != nil {
+			return nil, fmt.Errorf("the image %q of the %s file has an invalid pull spec %q: %w", tag.Name, ImageReferencesFile, tag.From.Name, err)
+		}
+		if len(ref.ID) == 0 {
+			return nil, fmt.Errorf("the image %q of the %s file is not referenced by digest: %s", tag.Name, ImageReferencesFile, tag.From.Name)
+		}
+		images[tag.Name] = tag.From.Name
+	}
+	return images, nil
+}
+
+func sha256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,130 @@
+package manifest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const (
+	testReleaseMetadata = `{
+  "kind": "cincinnati-metadata-v0",
+  "version": "4.14.1",
+  "previous": ["4.13.19", "4.14.0"],
+  "metadata": {"io.openshift.upgrades.graph.release.channels": "stable-4.14"}
+}`
+	testImageReferences = `{
+  "kind": "ImageStream",
+  "apiVersion": "image.openshift.io/v1",
+  "metadata": {"name": "4.14.1"},
+  "spec": {
+    "tags": [
+      {"name": "cli", "from": {"kind": "DockerImage", "name": "quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:1111111111111111111111111111111111111111111111111111111111111111"}},
+      {"name": "cluster-version-operator", "from": {"kind": "DockerImage", "name": "quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:2222222222222222222222222222222222222222222222222222222222222222"}}
+    ]
+  }
+}`
+	testManifest = "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: openshift-cluster-version\n"
+)
+
+func testReleaseFS(overrides map[string]string) fstest.MapFS {
+	files := map[string]string{
+		"release-metadata": testReleaseMetadata,
+		"image-references": testImageReferences,
+		"0000_00_cluster-version-operator_00_namespace.yaml": testManifest,
+	}
+	for name, content := range overrides {
+		files[name] = content
+	}
+	fsys := fstest.MapFS{"other/file": {Data: []byte("not part of the payload")}}
+	for name, content := range files {
+		if len(content) > 0 {
+			fsys["release-manifests/"+name] = &fstest.MapFile{Data: []byte(content)}
+		}
+	}
+	return fsys
+}
+
+func TestReadRelease(t *testing.T) {
+	release, err := ReadRelease(testReleaseFS(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &Release{
+		Version:  "4.14.1",
+		Previous: []string{"4.13.19", "4.14.0"},
+		Metadata: map[string]string{"io.openshift.upgrades.graph.release.channels": "stable-4.14"},
+		Images: map[string]string{
+			"cli":                      "quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:1111111111111111111111111111111111111111111111111111111111111111",
+			"cluster-version-operator": "quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:2222222222222222222222222222222222222222222222222222222222222222",
+		},
+		ManifestDigests: map[string]string{
+			"release-metadata": sha256Digest([]byte(testReleaseMetadata)),
+			"image-references": sha256Digest([]byte(testImageReferences)),
+			"0000_00_cluster-version-operator_00_namespace.yaml": sha256Digest([]byte(testManifest)),
+		},
+	}
+	if !reflect.DeepEqual(expected, release) {
+		t.Errorf("expected %#v, got %#v", expected, release)
+	}
+	if names := release.ImageNames(); !reflect.DeepEqual(names, []string{"cli", "cluster-version-operator"}) {
+		t.Errorf("unexpected image names %v", names)
+	}
+}
+
+func TestReadReleaseInvalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		overrides   map[string]string
+		expectedErr string
+	}{
+		{
+			name:        "no metadata",
+			overrides:   map[string]string{"release-metadata": ""},
+			expectedErr: "the release has no release-metadata file",
+		},
+		{
+			name:        "unknown metadata kind",
+			overrides:   map[string]string{"release-metadata": `{"kind": "cincinnati-metadata-v1", "version": "4.14.1"}`},
+			expectedErr: `the release-metadata file has the unsupported kind "cincinnati-metadata-v1"`,
+		},
+		{
+			name:        "invalid version",
+			overrides:   map[string]string{"release-metadata": `{"kind": "cincinnati-metadata-v0", "version": "4.14"}`},
+			expectedErr: `the release-metadata file has an invalid version "4.14"`,
+		},
+		{
+			name:        "invalid previous version",
+			overrides:   map[string]string{"release-metadata": `{"kind": "cincinnati-metadata-v0", "version": "4.14.1", "previous": ["latest"]}`},
+			expectedErr: `the release-metadata file has an invalid previous version "latest"`,
+		},
+		{
+			name:        "no image references",
+			overrides:   map[string]string{"image-references": ""},
+			expectedErr: "the release has no image-references file",
+		},
+		{
+			name:        "image references of another version",
+			overrides:   map[string]string{"image-references": `{"kind": "ImageStream", "metadata": {"name": "4.14.0"}}`},
+			expectedErr: `the image-references file is for version "4.14.0", expected "4.14.1"`,
+		},
+		{
+			name:        "image reference by tag",
+			overrides:   map[string]string{"image-references": `{"kind": "ImageStream", "spec": {"tags": [{"name": "cli", "from": {"kind": "DockerImage", "name": "quay.io/openshift/cli:latest"}}]}}`},
+			expectedErr: `the image "cli" of the image-references file is not referenced by digest: quay.io/openshift/cli:latest`,
+		},
+		{
+			name:        "image reference to an image stream tag",
+			overrides:   map[string]string{"image-references": `{"kind": "ImageStream", "spec": {"tags": [{"name": "cli", "from": {"kind": "ImageStreamTag", "name": "cli:latest"}}]}}`},
+			expectedErr: `the image "cli" of the image-references file does not reference a DockerImage`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadRelease(testReleaseFS(tt.overrides)); err == nil || !strings.HasPrefix(err.Error(), tt.expectedErr) {
+				t.Errorf("expected error %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+	"k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/verify/store"
+)
+
+// Policy is the verification policy of release images. A release digest is verified when every requirement of the
+// policy is satisfied by at least one signature.
+type Policy struct {
+	Requirements []Requirement
+}
+
+// Requirement is a clause of a Policy. It is satisfied by a signature made with any of its trust roots, which
+// allows a requirement to accept both GPG and cosign signatures while moving from one scheme to the other.
+type Requirement struct {
+	// Name identifies the requirement in errors. It does not need to be unique.
+	Name string
+	// Keyring accepts GPG signatures in the containers/image format.
+	Keyring openpgp.EntityList
+	// CosignPublicKeys accept cosign signatures made with one of the pinned keys.
+	CosignPublicKeys []crypto.PublicKey
+	// Keyless accepts keyless cosign signatures of the identity, certified by Fulcio and logged in Rekor.
+	Keyless *KeylessIdentity
+}
+
+// verify checks that the signature satisfies the requirement for the release digest.
+func (r *Requirement) verify(signature []byte, releaseDigest string) error {
+	if isCosignSignature(signature) {
+		var errs []error
+		if len(r.CosignPublicKeys) > 0 {
+			err := verifyCosignSignatureWithKeys(signature, releaseDigest, r.CosignPublicKeys)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, fmt.Errorf("cosign public keys: %w", err))
+		}
+		if r.Keyless != nil {
+			err := verifyKeylessCosignSignature(signature, releaseDigest, r.Keyless)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, fmt.Errorf("cosign keyless: %w", err))
+		}
+		if len(errs) == 0 {
+			return fmt.Errorf("cosign signatures are not accepted")
+		}
+		return errors.NewAggregate(errs)
+	}
+
+	if len(r.Keyring) == 0 {
+		return fmt.Errorf("GPG signatures are not accepted")
+	}
+	content, _, err := verifySignatureWithKeyring(bytes.NewReader(signature), r.Keyring)
+	if err != nil {
+		return fmt.Errorf("GPG: %w", err)
+	}
+	if err := verifyAtomicContainerSignature(content, releaseDigest); err != nil {
+		return fmt.Errorf("GPG: %w", err)
+	}
+	return nil
+}
+
+func (r *Requirement) String() string {
+	var schemes []string
+	if len(r.Keyring) > 0 {
+		schemes = append(schemes, fmt.Sprintf("GPG signatures from %d keys", len(r.Keyring)))
+	}
+	if len(r.CosignPublicKeys) > 0 {
+		schemes = append(schemes, fmt.Sprintf("cosign signatures from %d public keys", len(r.CosignPublicKeys)))
+	}
+	if r.Keyless != nil {
+		subject := r.Keyless.Subject
+		if r.Keyless.SubjectRegexp != nil {
+			subject = r.Keyless.SubjectRegexp.String()
+		}
+		schemes = append(schemes, fmt.Sprintf("keyless cosign signatures of %s issued by %s", subject, r.Keyless.Issuer))
+	}
+	if len(schemes) == 0 {
+		schemes = append(schemes, "<ERROR: no trust roots>")
+	}
+	return fmt.Sprintf("%s (%s)", r.Name, strings.Join(schemes, " or "))
+}
+
+// policyVerifier verifies release digests against a Policy. It shares the signature cache and the stores of the
+// releaseVerifier.
+type policyVerifier struct {
+	*releaseVerifier
+	policy Policy
+}
+
+// NewPolicyVerifier creates a release verifier enforcing the policy, with the signatures of the store. The stores
+// may return both GPG and cosign signatures.
+func NewPolicyVerifier(policy Policy, store store.Store) Interface {
+	keyrings := map[string]openpgp.EntityList{}
+	for i := range policy.Requirements {
+		if len(policy.Requirements[i].Keyring) > 0 {
+			keyrings[requirementName(policy.Requirements, i)] = policy.Requirements[i].Keyring
+		}
+	}
+	return &policyVerifier{
+		releaseVerifier: &releaseVerifier{
+			verifiers:      keyrings,
+			store:          store,
+			signatureCache: make(map[string][][]byte),
+		},
+		policy: policy,
+	}
+}
+
+// String summarizes the verifier for human consumption
+func (v *policyVerifier) String() string {
+	var requirements []string
+	for i := range v.policy.Requirements {
+		requirements = append(requirements, v.policy.Requirements[i].String())
+	}
+	sort.Strings(requirements)
+	if len(requirements) == 0 {
+		requirements = append(requirements, "<ERROR: no requirements>")
+	}
+	storeString := "<ERROR: no store>"
+	if v.store != nil {
+		storeString = fmt.Sprint(v.store)
+	}
+	return fmt.Sprintf("All release image digests must satisfy %s - will check for signatures at %s", strings.Join(requirements, " and "), storeString)
+}
+
+// Verify ensures that every requirement of the policy is satisfied by at least one signature of the release
+// digest, or returns an error listing the unsatisfied requirements, and why each signature failed them.
+func (v *policyVerifier) Verify(ctx context.Context, releaseDigest string) error {
+	if len(v.policy.Requirements) == 0 || v.store == nil {
+		return fmt.Errorf("the release verifier is incorrectly configured, unable to verify digests")
+	}
+	if len(releaseDigest) == 0 {
+		return fmt.Errorf("release images that are not accessed via digest cannot be verified")
+	}
+	if !validReleaseDigest.MatchString(releaseDigest) {
+		return fmt.Errorf("the provided release image digest has an invalid format: %q", releaseDigest)
+	}
+
+	if v.hasVerified(releaseDigest) {
+		return nil
+	}
+
+	// requirements are tracked by index, names are not required to be unique
+	remaining := make(map[int]*Requirement, len(v.policy.Requirements))
+	for i := range v.policy.Requirements {
+		remaining[i] = &v.policy.Requirements[i]
+	}
+
+	var signedWith [][]byte
+	requirementErrs := map[int][]error{}
+	var errs []error
+	err := v.store.Signatures(ctx, "", releaseDigest, func(ctx context.Context, signature []byte, errIn error) (done bool, err error) {
+		if errIn != nil {
+			klog.V(4).Infof("error retrieving signature for %s: %v", releaseDigest, errIn)
+			errs = append(errs, fmt.Errorf("%s: %w", time.Now().Format(time.RFC3339), errIn))
+			return false, nil
+		}
+		for i, requirement := range remaining {
+			if err := requirement.verify(signature, releaseDigest); err != nil {
+				klog.V(4).Infof("signature for %s does not satisfy the requirement %q: %v", releaseDigest, requirementName(v.policy.Requirements, i), err)
+				requirementErrs[i] = append(requirementErrs[i], err)
+				continue
+			}
+			delete(remaining, i)
+			signedWith = append(signedWith, signature)
+		}
+		return len(remaining) == 0, nil
+	})
+	if err != nil {
+		klog.V(4).Infof("Failed to retrieve signatures for %s: %v", releaseDigest, err)
+		errs = append(errs, fmt.Errorf("%s: %w", time.Now().Format(time.RFC3339), err))
+	}
+
+	if len(remaining) > 0 {
+		remainingIndexes := make([]int, 0, len(remaining))
+		for i := range remaining {
+			remainingIndexes = append(remainingIndexes, i)
+		}
+		sort.Ints(remainingIndexes)
+		remainingNames := make([]string, 0, len(remaining))
+		for _, i := range remainingIndexes {
+			name := requirementName(v.policy.Requirements, i)
+			remainingNames = append(remainingNames, name)
+			if len(requirementErrs[i]) == 0 {
+				errs = append(errs, fmt.Errorf("requirement %q: no signature found", name))
+				continue
+			}
+			errs = append(errs, fmt.Errorf("requirement %q: %w", name, errors.NewAggregate(requirementErrs[i])))
+		}
+		err := &wrapError{
+			msg: fmt.Sprintf("unable to verify %s against the requirements: %s", releaseDigest, strings.Join(remainingNames, ", ")),
+			err: errors.NewAggregate(errs),
+		}
+		klog.V(4).Info(err.Error())
+		return err
+	}
+
+	v.cacheVerification(releaseDigest, signedWith)
+
+	return nil
+}
+
+// requirementName returns the name of the i-th requirement for messages. Empty and duplicate names are
+// disambiguated by the index of the requirement.
+func requirementName(requirements []Requirement, i int) string {
+	name := requirements[i].Name
+	if len(name) == 0 {
+		return fmt.Sprintf("#%d", i)
+	}
+	for j := range requirements {
+		if j != i && requirements[j].Name == name {
+			return fmt.Sprintf("%s#%d", name, i)
+		}
+	}
+	return name
+}
@@ -0,0 +1,324 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/openshift/library-go/pkg/verify/store/memory"
+)
+
+const testReleaseDigest = "sha256:e3f12513a4b22a2d7c0e7c9207f52128113758d9d68c7d06b11a0ac7672966f7"
+
+type testSigstore struct {
+	t          *testing.T
+	fulcioKey  *ecdsa.PrivateKey
+	fulcioCert *x509.Certificate
+	rekorKey   *ecdsa.PrivateKey
+}
+
+func newTestSigstore(t *testing.T) *testSigstore {
+	s := &testSigstore{t: t, fulcioKey: newTestKey(t), rekorKey: newTestKey(t)}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-fulcio"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, s.fulcioKey.Public(), s.fulcioKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.fulcioCert, err = x509.ParseCertificate(der); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func (s *testSigstore) identity(subject string) *KeylessIdentity {
+	roots := x509.NewCertPool()
+	roots.AddCert(s.fulcioCert)
+	return &KeylessIdentity{
+		FulcioRoots:     roots,
+		RekorPublicKeys: []crypto.PublicKey{s.rekorKey.Public()},
+		Issuer:          "https://accounts.example.com",
+		Subject:         subject,
+	}
+}
+
+func testCosignPayload(digest string) []byte {
+	return []byte(`{"critical":{"identity":{"docker-reference":"quay.io/openshift-release-dev/ocp-release"},"image":{"docker-manifest-digest":"` + digest + `"},"type":"cosign container image signature"},"optional":{"creator":"test"}}`)
+}
+
+func signPayload(t *testing.T, key *ecdsa.PrivateKey, payload []byte) []byte {
+	digest := sha256.Sum256(payload)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
+
+func cosignSignatureWithKey(t *testing.T, key *ecdsa.PrivateKey, digest string) []byte {
+	payload := testCosignPayload(digest)
+	data, err := json.Marshal(cosignSignature{Payload: payload, Base64Signature: base64.StdEncoding.EncodeToString(signPayload(t, key, payload))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func (s *testSigstore) keylessSignature(email, digest string) []byte {
+	t := s.t
+	key := newTestKey(t)
+	issuer, err := asn1.Marshal("https://accounts.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		NotBefore:       time.Now().Add(-time.Minute),
+		NotAfter:        time.Now().Add(10 * time.Minute),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		EmailAddresses:  []string{email},
+		ExtraExtensions: []pkix.Extension{{Id: fulcioIssuerV2OID, Value: issuer}},
+	}, s.fulcioCert, key.Public(), s.fulcioKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	payload := testCosignPayload(digest)
+	signature := signPayload(t, key, payload)
+	payloadHash := sha256.Sum256(payload)
+	body, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "0.0.1",
+		"kind":       "hashedrekord",
+		"spec": map[string]interface{}{
+			"data":      map[string]interface{}{"hash": map[string]string{"algorithm": "sha256", "value": hex.EncodeToString(payloadHash[:])}},
+			"signature": map[string]interface{}{"content": base64.StdEncoding.EncodeToString(signature), "publicKey": map[string]string{"content": base64.StdEncoding.EncodeToString(certPEM)}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rekorPayload := rekorPayload{Body: base64.StdEncoding.EncodeToString(body), IntegratedTime: time.Now().Unix(), LogID: "c0ffee", LogIndex: 42}
+	canonicalPayload, err := json.Marshal(rekorPayload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(cosignSignature{
+		Payload:         payload,
+		Base64Signature: base64.StdEncoding.EncodeToString(signature),
+		Cert:            string(certPEM),
+		RekorBundle: &rekorBundle{
+			SignedEntryTimestamp: signPayload(t, s.rekorKey, canonicalPayload),
+			Payload:              rekorPayload,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestPolicyVerifier(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "keyrings", "redhat.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	redhatPublic, err := openpgp.ReadArmoredKeyRing(bytes.NewBuffer(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gpgSignature, err := ioutil.ReadFile(filepath.Join("testdata", "signatures", "sha256=e3f12513a4b22a2d7c0e7c9207f52128113758d9d68c7d06b11a0ac7672966f7", "signature-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sigstore := newTestSigstore(t)
+	cosignKey := newTestKey(t)
+	otherKey := newTestKey(t)
+	releaseEngineering := sigstore.identity("release@example.com")
+
+	keylessSignature := sigstore.keylessSignature("release@example.com", testReleaseDigest)
+	tamperedRekorBundle := &cosignSignature{}
+	if err := json.Unmarshal(keylessSignature, tamperedRekorBundle); err != nil {
+		t.Fatal(err)
+	}
+	tamperedRekorBundle.RekorBundle.Payload.LogIndex++
+	tamperedRekorBundleSignature, err := json.Marshal(tamperedRekorBundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		policy      Policy
+		signatures  [][]byte
+		expectedErr string
+	}{
+		{
+			name:       "pinned cosign key",
+			policy:     Policy{Requirements: []Requirement{{Name: "cosign", CosignPublicKeys: []crypto.PublicKey{otherKey.Public(), cosignKey.Public()}}}},
+			signatures: [][]byte{cosignSignatureWithKey(t, cosignKey, testReleaseDigest)},
+		},
+		{
+			name:        "untrusted cosign key",
+			policy:      Policy{Requirements: []Requirement{{Name: "cosign", CosignPublicKeys: []crypto.PublicKey{cosignKey.Public()}}}},
+			signatures:  [][]byte{cosignSignatureWithKey(t, otherKey, testReleaseDigest)},
+			expectedErr: `requirement "cosign": cosign public keys: the cosign signature was not made by any of the 1 trusted public keys`,
+		},
+		{
+			name:        "cosign signature of another digest",
+			policy:      Policy{Requirements: []Requirement{{Name: "cosign", CosignPublicKeys: []crypto.PublicKey{cosignKey.Public()}}}},
+			signatures:  [][]byte{cosignSignatureWithKey(t, cosignKey, "sha256:0000")},
+			expectedErr: "the cosign payload digest does not match",
+		},
+		{
+			name:       "keyless",
+			policy:     Policy{Requirements: []Requirement{{Name: "keyless", Keyless: releaseEngineering}}},
+			signatures: [][]byte{keylessSignature},
+		},
+		{
+			name: "keyless subject regexp",
+			policy: Policy{Requirements: []Requirement{{Name: "keyless", Keyless: &KeylessIdentity{
+				FulcioRoots:     releaseEngineering.FulcioRoots,
+				RekorPublicKeys: releaseEngineering.RekorPublicKeys,
+				Issuer:          releaseEngineering.Issuer,
+				SubjectRegexp:   regexp.MustCompile(`^.*@example\.com$`),
+			}}}},
+			signatures: [][]byte{keylessSignature},
+		},
+		{
+			name:        "keyless signature of another identity",
+			policy:      Policy{Requirements: []Requirement{{Name: "keyless", Keyless: releaseEngineering}}},
+			signatures:  [][]byte{sigstore.keylessSignature("someone@example.com", testReleaseDigest)},
+			expectedErr: `requirement "keyless": cosign keyless: the Fulcio certificate was issued for [someone@example.com] instead of "release@example.com"`,
+		},
+		{
+			name:        "keyless signature with a tampered Rekor bundle",
+			policy:      Policy{Requirements: []Requirement{{Name: "keyless", Keyless: releaseEngineering}}},
+			signatures:  [][]byte{tamperedRekorBundleSignature},
+			expectedErr: "the Rekor bundle was not signed by any of the 1 trusted Rekor public keys",
+		},
+		{
+			name: "keyless signature from another Fulcio",
+			policy: Policy{Requirements: []Requirement{{Name: "keyless", Keyless: &KeylessIdentity{
+				FulcioRoots:     newTestSigstore(t).identity("release@example.com").FulcioRoots,
+				RekorPublicKeys: releaseEngineering.RekorPublicKeys,
+				Issuer:          releaseEngineering.Issuer,
+				Subject:         releaseEngineering.Subject,
+			}}}},
+			signatures:  [][]byte{keylessSignature},
+			expectedErr: "the Fulcio certificate is not trusted when the signature was logged at",
+		},
+		{
+			name:       "GPG or cosign",
+			policy:     Policy{Requirements: []Requirement{{Name: "redhat", Keyring: redhatPublic, Keyless: releaseEngineering}}},
+			signatures: [][]byte{keylessSignature},
+		},
+		{
+			name: "GPG and cosign",
+			policy: Policy{Requirements: []Requirement{
+				{Name: "redhat", Keyring: redhatPublic},
+				{Name: "keyless", Keyless: releaseEngineering},
+			}},
+			signatures: [][]byte{keylessSignature, gpgSignature},
+		},
+		{
+			name: "GPG and cosign without cosign signature",
+			policy: Policy{Requirements: []Requirement{
+				{Name: "redhat", Keyring: redhatPublic},
+				{Name: "keyless", Keyless: releaseEngineering},
+			}},
+			signatures:  [][]byte{gpgSignature},
+			expectedErr: `unable to verify ` + testReleaseDigest + ` against the requirements: keyless`,
+		},
+		{
+			name: "requirements with the same name are all enforced",
+			policy: Policy{Requirements: []Requirement{
+				{Name: "release", Keyring: redhatPublic},
+				{Name: "release", Keyless: releaseEngineering},
+			}},
+			signatures:  [][]byte{gpgSignature},
+			expectedErr: `unable to verify ` + testReleaseDigest + ` against the requirements: release#1`,
+		},
+		{
+			name: "requirements without name are all enforced",
+			policy: Policy{Requirements: []Requirement{
+				{Keyless: releaseEngineering},
+				{Keyring: redhatPublic},
+			}},
+			signatures:  [][]byte{keylessSignature},
+			expectedErr: `unable to verify ` + testReleaseDigest + ` against the requirements: #1`,
+		},
+		{
+			name:        "cosign signature for a GPG requirement",
+			policy:      Policy{Requirements: []Requirement{{Name: "redhat", Keyring: redhatPublic}}},
+			signatures:  [][]byte{keylessSignature},
+			expectedErr: `requirement "redhat": cosign signatures are not accepted`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := NewPolicyVerifier(tt.policy, &memory.Store{Data: map[string][][]byte{testReleaseDigest: tt.signatures}})
+			err := verifier.Verify(context.Background(), testReleaseDigest)
+			if len(tt.expectedErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got := len(verifier.Signatures()[testReleaseDigest]); got != len(tt.policy.Requirements) {
+					t.Errorf("expected %d cached signatures, got %d", len(tt.policy.Requirements), got)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error %q", tt.expectedErr)
+			}
+			if details := err.Error() + ": " + errorsUnwrapString(err); !strings.Contains(details, tt.expectedErr) {
+				t.Errorf("expected %q in the error %q", tt.expectedErr, details)
+			}
+		})
+	}
+}
+
+func errorsUnwrapString(err error) string {
+	if wrapped, ok := err.(*wrapError); ok && wrapped.err != nil {
+		return wrapped.err.Error()
+	}
+	return ""
+}
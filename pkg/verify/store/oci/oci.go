@@ -0,0 +1,288 @@
+// Package oci retrieves cosign signatures attached to release images in
+// their container registry, as described in [1]. Signatures are found
+// with the OCI referrers API, and with the cosign tag convention, where
+// the signatures of "<ALGO>:<DIGEST>" are the layers of the manifest
+// tagged "<ALGO>-<DIGEST>.sig".
+//
+// Disconnected clusters get the signatures with the release images when
+// mirroring them, without mirroring a separate signature store.
+//
+// [1]: https://github.com/sigstore/cosign/blob/main/specs/SIGNATURE_SPEC.md
+package oci
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/image/registryclient"
+	"github.com/openshift/library-go/pkg/verify/store"
+)
+
+const (
+	// maxSignatureSearch prevents unbounded recursion on malicious registries.
+	maxSignatureSearch = 10
+	// maxManifestSize and maxPayloadSize prevent unbounded reads.
+	maxManifestSize = 256 * 1024
+	maxPayloadSize  = 50 * 1024
+
+	cosignSignatureArtifactType = "application/vnd.dev.cosign.artifact.sig.v1+json"
+	signatureAnnotation         = "dev.cosignproject.cosign/signature"
+	certificateAnnotation       = "dev.sigstore.cosign/certificate"
+	chainAnnotation             = "dev.sigstore.cosign/chain"
+	bundleAnnotation            = "dev.sigstore.cosign/bundle"
+)
+
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Store provides access to the cosign signatures attached to release images in a registry.
+type Store struct {
+	// Registry is the host, and optional port, of the registry, e.g. quay.io.
+	Registry string
+
+	// Repository is the repository of the release images in the registry,
+	// e.g. openshift-release-dev/ocp-release.
+	Repository string
+
+	// Insecure connects to the registry over plain HTTP.
+	Insecure bool
+
+	// RegistryContext connects and authenticates to the registry, with the
+	// transports and the credentials it is configured with. It is
+	// required.
+	RegistryContext *registryclient.Context
+}
+
+// manifest is the part of OCI image manifests and indexes read by the store.
+type manifest struct {
+	Manifests []descriptor `json:"manifests,omitempty"`
+	Layers    []descriptor `json:"layers,omitempty"`
+}
+
+type descriptor struct {
+	MediaType    string            `json:"mediaType"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// cosignSignature is the format of the cosign signatures fed to the callback, as expected by the release verifier.
+type cosignSignature struct {
+	Payload         []byte          `json:"payload"`
+	Base64Signature string          `json:"base64Signature"`
+	Cert            string          `json:"cert,omitempty"`
+	Chain           string          `json:"chain,omitempty"`
+	RekorBundle     json.RawMessage `json:"rekorBundle,omitempty"`
+}
+
+// Signatures fetches signatures for the provided digest.
+func (s *Store) Signatures(ctx context.Context, name string, digest string, fn store.Callback) error {
+	algorithm, hash, ok := strings.Cut(digest, ":")
+	if !ok || len(algorithm) == 0 || len(hash) == 0 {
+		return fmt.Errorf("invalid digest %q", digest)
+	}
+
+	client, err := s.client(ctx)
+	if err != nil {
+		_, err = fn(ctx, nil, err)
+		return err
+	}
+
+	// signature manifests, from the referrers API and from the cosign tag
+	var signatureManifests []string
+	referrers := &manifest{}
+	err = s.getJSON(ctx, client, fmt.Sprintf("referrers/%s?artifactType=%s", digest, url.QueryEscape(cosignSignatureArtifactType)), []string{"application/vnd.oci.image.index.v1+json"}, referrers)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		// the registry does not support the referrers API
+	case err != nil:
+		if done, err := fn(ctx, nil, fmt.Errorf("unable to list the referrers of %s: %w", digest, err)); done || err != nil {
+			return err
+		}
+	default:
+		for _, referrer := range referrers.Manifests {
+			if referrer.ArtifactType == cosignSignatureArtifactType {
+				signatureManifests = append(signatureManifests, referrer.Digest)
+			}
+		}
+	}
+	signatureManifests = append(signatureManifests, fmt.Sprintf("%s-%s.sig", algorithm, hash))
+
+	found := 0
+	for _, reference := range signatureManifests {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		signatureManifest := &manifest{}
+		if err := s.getJSON(ctx, client, "manifests/"+reference, manifestMediaTypes, signatureManifest); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			if done, err := fn(ctx, nil, fmt.Errorf("unable to retrieve the signature manifest %s: %w", reference, err)); done || err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, layer := range signatureManifest.Layers {
+			if found == maxSignatureSearch {
+				break
+			}
+			signatureData, ok := layer.Annotations[signatureAnnotation]
+			if !ok {
+				continue
+			}
+			found++
+			data, err := s.signature(ctx, client, layer, signatureData)
+			if err != nil {
+				klog.V(4).Infof("unable to load signature: %v", err)
+				if done, err := fn(ctx, nil, fmt.Errorf("unable to retrieve signature %s: %w", layer.Digest, err)); done || err != nil {
+					return err
+				}
+				continue
+			}
+			if done, err := fn(ctx, data, nil); done || err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = fn(ctx, nil, fmt.Errorf("%s %s: %w", s.String(), digest, store.ErrNotFound))
+	return err
+}
+
+// signature builds the cosign signature of a layer of a signature manifest.
+func (s *Store) signature(ctx context.Context, client *repositoryClient, layer descriptor, signatureData string) ([]byte, error) {
+	if layer.Size > maxPayloadSize {
+		return nil, fmt.Errorf("the payload of %d bytes is too large", layer.Size)
+	}
+	algorithm, hash, _ := strings.Cut(layer.Digest, ":")
+	if algorithm != "sha256" {
+		return nil, fmt.Errorf("unsupported payload digest %q", layer.Digest)
+	}
+	payload, err := s.get(ctx, client, "blobs/"+layer.Digest, nil, maxPayloadSize)
+	if err != nil {
+		return nil, err
+	}
+	if payloadHash := sha256.Sum256(payload); hex.EncodeToString(payloadHash[:]) != hash {
+		return nil, fmt.Errorf("the payload does not match its digest")
+	}
+
+	sig := cosignSignature{
+		Payload:         payload,
+		Base64Signature: signatureData,
+		Cert:            layer.Annotations[certificateAnnotation],
+		Chain:           layer.Annotations[chainAnnotation],
+	}
+	if bundle := layer.Annotations[bundleAnnotation]; len(bundle) > 0 {
+		if !json.Valid([]byte(bundle)) {
+			return nil, fmt.Errorf("the Rekor bundle is not valid JSON")
+		}
+		sig.RekorBundle = json.RawMessage(bundle)
+	}
+	return json.Marshal(sig)
+}
+
+// repositoryClient sends the requests of the store to the API of the repository in the registry.
+type repositoryClient struct {
+	*http.Client
+	// base is the URL of the repository in the registry API
+	base string
+}
+
+// client returns the client of the repository, authenticating with the registry context.
+func (s *Store) client(ctx context.Context) (*repositoryClient, error) {
+	if s.RegistryContext == nil {
+		return nil, fmt.Errorf("%s: no registry context", s.String())
+	}
+	registry := &url.URL{Scheme: "https", Host: s.Registry}
+	if s.Insecure {
+		registry.Scheme = "http"
+	}
+	rt, registry, err := s.RegistryContext.RepositoryTransport(ctx, registry, s.Repository, s.Insecure)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to %s: %w", s.Registry, err)
+	}
+	return &repositoryClient{
+		Client: &http.Client{Transport: rt},
+		base:   fmt.Sprintf("%s/v2/%s/", strings.TrimSuffix(registry.String(), "/"), s.Repository),
+	}, nil
+}
+
+func (s *Store) getJSON(ctx context.Context, client *repositoryClient, path string, accept []string, into interface{}) error {
+	data, err := s.get(ctx, client, path, accept, maxManifestSize)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, into); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return nil
+}
+
+// get reads a path of the repository in the registry API.
+func (s *Store) get(ctx context.Context, client *repositoryClient, path string, accept []string, maxSize int64) ([]byte, error) {
+	requestURL := client.base + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, mediaType := range accept {
+		req.Header.Add("Accept", mediaType)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readBody(resp, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, store.ErrNotFound
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, fmt.Errorf("response status %d from %s", resp.StatusCode, requestURL)
+	}
+	return data, nil
+}
+
+// readBody reads the body of the response, being careful not to allow unbounded reads.
+func readBody(resp *http.Response, maxSize int64) ([]byte, error) {
+	body := resp.Body
+	r := io.LimitReader(body, maxSize+1)
+	defer func() {
+		// read the remaining body to avoid breaking the connection
+		io.Copy(ioutil.Discard, r)
+		body.Close()
+	}()
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("the response is larger than %d bytes", maxSize)
+	}
+	return data, nil
+}
+
+// String returns a description of where this store finds
+// signatures.
+func (s *Store) String() string {
+	return fmt.Sprintf("cosign signatures attached in the registry to %s/%s", s.Registry, s.Repository)
+}
@@ -0,0 +1,131 @@
+package oci
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/image/registryclient"
+	"github.com/openshift/library-go/pkg/verify/store"
+)
+
+const testDigest = "sha256:e3f12513a4b22a2d7c0e7c9207f52128113758d9d68c7d06b11a0ac7672966f7"
+
+func TestStore(t *testing.T) {
+	payload := []byte(`{"critical":{"type":"cosign container image signature"}}`)
+	payloadHash := sha256.Sum256(payload)
+	payloadDigest := "sha256:" + hex.EncodeToString(payloadHash[:])
+	signatureManifest := func(signature string) []byte {
+		data, _ := json.Marshal(manifest{Layers: []descriptor{{
+			MediaType: "application/vnd.dev.cosign.simplesigning.v1+json",
+			Digest:    payloadDigest,
+			Size:      int64(len(payload)),
+			Annotations: map[string]string{
+				signatureAnnotation: signature,
+				bundleAnnotation:    `{"SignedEntryTimestamp":"c2V0","Payload":{"logIndex":1}}`,
+			},
+		}}})
+		return data
+	}
+
+	tests := []struct {
+		name               string
+		referrers          bool
+		expectedSignatures []string
+	}{
+		{
+			name:               "cosign tag",
+			expectedSignatures: []string{"tag-signature"},
+		},
+		{
+			name:               "referrers and cosign tag",
+			referrers:          true,
+			expectedSignatures: []string{"referrer-signature", "tag-signature"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				requests = append(requests, req.URL.Path)
+				if req.URL.Path == "/token" {
+					if req.URL.Query().Get("scope") != "repository:ocp/release:pull" {
+						t.Errorf("unexpected token scope %q", req.URL.Query().Get("scope"))
+					}
+					fmt.Fprint(w, `{"token":"secret"}`)
+					return
+				}
+				if req.Header.Get("Authorization") != "Bearer secret" {
+					w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test"`, server.URL))
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				switch req.URL.Path {
+				case "/v2/ocp/release/referrers/" + testDigest:
+					if !tt.referrers {
+						http.NotFound(w, req)
+						return
+					}
+					json.NewEncoder(w).Encode(manifest{Manifests: []descriptor{
+						{ArtifactType: cosignSignatureArtifactType, Digest: "sha256:referrer"},
+						{ArtifactType: "application/spdx+json", Digest: "sha256:sbom"},
+					}})
+				case "/v2/ocp/release/manifests/sha256:referrer":
+					w.Write(signatureManifest("referrer-signature"))
+				case "/v2/ocp/release/manifests/sha256-" + strings.TrimPrefix(testDigest, "sha256:") + ".sig":
+					w.Write(signatureManifest("tag-signature"))
+				case "/v2/ocp/release/blobs/" + payloadDigest:
+					w.Write(payload)
+				default:
+					t.Errorf("unexpected request %s", req.URL.Path)
+					http.NotFound(w, req)
+				}
+			}))
+			defer server.Close()
+
+			s := &Store{
+				Registry:   strings.TrimPrefix(server.URL, "http://"),
+				Repository: "ocp/release",
+				Insecure:   true,
+
+				RegistryContext: registryclient.NewContext(http.DefaultTransport, http.DefaultTransport),
+			}
+			var signatures []string
+			err := s.Signatures(context.Background(), "", testDigest, func(ctx context.Context, signature []byte, errIn error) (bool, error) {
+				if errIn != nil {
+					if !errors.Is(errIn, store.ErrNotFound) {
+						t.Errorf("unexpected error: %v", errIn)
+					}
+					return false, nil
+				}
+				sig := cosignSignature{}
+				if err := json.Unmarshal(signature, &sig); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(sig.Payload, payload) || !strings.Contains(string(sig.RekorBundle), "SignedEntryTimestamp") {
+					t.Errorf("unexpected signature %s", signature)
+				}
+				signatures = append(signatures, sig.Base64Signature)
+				return false, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tt.expectedSignatures, signatures) {
+				t.Errorf("expected signatures %v, got %v", tt.expectedSignatures, signatures)
+			}
+			if tokenRequests := strings.Count(strings.Join(requests, " "), "/token"); tokenRequests != 1 {
+				t.Errorf("expected a single token request, got %d", tokenRequests)
+			}
+		})
+	}
+}